@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// refreshCookie holds the refresh token; it is scoped to /auth so browsers
+// never send it to API routes
+const refreshCookie = "refresh_token"
+
+// revocationStore is a jwtauth.Blocklist that can also record revocations
+type revocationStore interface {
+	jwtauth.Blocklist
+	Revoke(ctx context.Context, jti string, until time.Time) error
+}
+
+// user is a demo account; real applications store password hashes
+type user struct {
+	password string
+	roles    []string
+}
+
+var users = map[string]user{
+	"alice": {password: "alice-password", roles: []string{"admin", "user"}},
+	"bob":   {password: "bob-password", roles: []string{"user"}},
+}
+
+// appConfig holds the settings for newApp
+type appConfig struct {
+	AccessSecret  []byte
+	RefreshSecret []byte
+	AccessTTL     time.Duration
+	RefreshTTL    time.Duration
+	Store         revocationStore
+	Logger        *slog.Logger
+}
+
+// app holds the two middleware configs and token issuing settings.
+// Access and refresh tokens use different secrets so neither can be
+// presented in place of the other.
+type app struct {
+	appConfig
+	accessCfg  *jwtauth.Config
+	refreshCfg *jwtauth.Config
+}
+
+func newApp(cfg appConfig) (*app, error) {
+	accessCfg, err := jwtauth.NewConfig(
+		jwtauth.WithHS256(cfg.AccessSecret),
+		jwtauth.WithRequiredClaims("sub", "sid"),
+		jwtauth.WithBlocklist(cfg.Store),
+		jwtauth.WithLogger(cfg.Logger),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshCfg, err := jwtauth.NewConfig(
+		jwtauth.WithHS256(cfg.RefreshSecret),
+		jwtauth.WithCookie(refreshCookie),
+		jwtauth.WithRequiredClaims("sub"),
+		jwtauth.WithBlocklist(cfg.Store),
+		jwtauth.WithLogger(cfg.Logger),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &app{appConfig: cfg, accessCfg: accessCfg, refreshCfg: refreshCfg}, nil
+}
+
+// login checks credentials and starts a session
+func (a *app) login(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username and password required"})
+		return
+	}
+
+	u, ok := users[req.Username]
+	if !ok || !jwtauth.SecureCompare(u.password, req.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	a.issueTokens(c, req.Username)
+}
+
+// refresh rotates the refresh token: the presented token is revoked so a
+// stolen refresh token can be used at most once
+func (a *app) refresh(c *gin.Context) {
+	claims := jwtauth.MustGetClaims(c.Request.Context())
+
+	if err := a.Store.Revoke(c.Request.Context(), claims.JWTID, claims.ExpiresAt); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "session store unavailable"})
+		return
+	}
+
+	if _, ok := users[claims.Subject]; !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "account no longer exists"})
+		return
+	}
+
+	a.issueTokens(c, claims.Subject)
+}
+
+// logout revokes the access token and the refresh token it was issued with
+func (a *app) logout(c *gin.Context) {
+	ctx := c.Request.Context()
+	claims := jwtauth.MustGetClaims(ctx)
+	sessionID, _ := claims.GetString("sid")
+
+	if err := a.Store.Revoke(ctx, claims.JWTID, claims.ExpiresAt); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "session store unavailable"})
+		return
+	}
+	if err := a.Store.Revoke(ctx, sessionID, time.Now().Add(a.RefreshTTL)); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "session store unavailable"})
+		return
+	}
+
+	c.SetCookie(refreshCookie, "", -1, "/auth", "", true, true)
+	c.Status(http.StatusNoContent)
+}
+
+// issueTokens mints an access/refresh pair. The access token's "sid" claim
+// is the refresh token's jti, linking them so logout can revoke both.
+func (a *app) issueTokens(c *gin.Context, subject string) {
+	now := time.Now()
+	refreshID := newTokenID()
+
+	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": subject,
+		"jti": refreshID,
+		"iat": now.Unix(),
+		"exp": now.Add(a.RefreshTTL).Unix(),
+	}).SignedString(a.RefreshSecret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token issuance failed"})
+		return
+	}
+
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   subject,
+		"jti":   newTokenID(),
+		"sid":   refreshID,
+		"roles": users[subject].roles,
+		"iat":   now.Unix(),
+		"exp":   now.Add(a.AccessTTL).Unix(),
+	}).SignedString(a.AccessSecret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token issuance failed"})
+		return
+	}
+
+	c.SetCookie(refreshCookie, refreshToken, int(a.RefreshTTL.Seconds()), "/auth", "", true, true)
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(a.AccessTTL.Seconds()),
+	})
+}
+
+// newTokenID returns a random jti
+func newTokenID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
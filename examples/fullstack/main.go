@@ -0,0 +1,133 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+	"github.com/gin-gonic/gin"
+)
+
+// This example is a small but complete API demonstrating how the middleware's
+// features fit together:
+//
+//   - POST /auth/login    issues an access token and a refresh token cookie
+//   - POST /auth/refresh  rotates the refresh token (old one is revoked)
+//   - POST /auth/logout   revokes both tokens of the session
+//   - GET  /api/profile   any authenticated user
+//   - GET  /api/admin     users with the "admin" role only
+//
+// Revocations are stored in Redis (REDIS_ADDR, e.g. localhost:6379) so they
+// apply to every replica; without REDIS_ADDR an in-memory blocklist is used.
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+
+	cache := jwtauth.NewMemoryCache(0)
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		var err error
+		cache, err = jwtauth.NewRedisCache(jwtauth.RedisCacheConfig{Addr: addr, Prefix: "fullstack:"})
+		if err != nil {
+			log.Fatalf("Redis config error: %v", err)
+		}
+		log.Printf("Using Redis blocklist at %s\n", addr)
+	}
+	store := jwtauth.NewCacheBlocklist(cache)
+
+	app, err := newApp(appConfig{
+		AccessSecret:  []byte("access-token-secret-min-32-bytes-for-demo!!"),
+		RefreshSecret: []byte("refresh-token-secret-min-32-bytes-for-demo!"),
+		AccessTTL:     15 * time.Minute,
+		RefreshTTL:    7 * 24 * time.Hour,
+		Store:         store,
+		Logger:        logger,
+	})
+	if err != nil {
+		log.Fatalf("Config error: %v", err)
+	}
+
+	log.Println("Starting server on :8080")
+	log.Println("Login: curl -c jar -d '{\"username\":\"alice\",\"password\":\"alice-password\"}' http://localhost:8080/auth/login")
+
+	if err := app.router().Run(":8080"); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+// router wires public, session and protected routes
+func (a *app) router() *gin.Engine {
+	r := gin.Default()
+
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{"status": "healthy"})
+	})
+
+	// Session routes: login is public, refresh authenticates the refresh
+	// token cookie, logout authenticates the access token
+	auth := r.Group("/auth")
+	{
+		auth.POST("/login", a.login)
+		auth.POST("/refresh", jwtauth.JWTAuth(a.refreshCfg), a.refresh)
+		auth.POST("/logout", jwtauth.JWTAuth(a.accessCfg), a.logout)
+	}
+
+	// Protected routes
+	api := r.Group("/api")
+	api.Use(jwtauth.JWTAuth(a.accessCfg))
+	{
+		api.GET("/profile", getProfile)
+		api.GET("/admin", requireRole("admin"), getAdmin)
+	}
+
+	return r
+}
+
+func getProfile(c *gin.Context) {
+	claims := jwtauth.MustGetClaims(c.Request.Context())
+	c.JSON(200, gin.H{
+		"user_id":    claims.Subject,
+		"roles":      rolesFromClaims(claims),
+		"expires_at": claims.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+func getAdmin(c *gin.Context) {
+	claims := jwtauth.MustGetClaims(c.Request.Context())
+	c.JSON(200, gin.H{
+		"message": "welcome to the admin area",
+		"user_id": claims.Subject,
+	})
+}
+
+// requireRole aborts with 403 unless the authenticated user has role
+func requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := jwtauth.MustGetClaims(c.Request.Context())
+		for _, r := range rolesFromClaims(claims) {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(403, gin.H{
+			"error":   "forbidden",
+			"message": role + " role required",
+		})
+	}
+}
+
+// rolesFromClaims returns the "roles" custom claim as a string slice
+func rolesFromClaims(claims *jwtauth.Claims) []string {
+	raw, _ := claims.Custom["roles"].([]interface{})
+	roles := make([]string, 0, len(raw))
+	for _, r := range raw {
+		if s, ok := r.(string); ok {
+			roles = append(roles, s)
+		}
+	}
+	return roles
+}
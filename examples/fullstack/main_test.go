@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestApp(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	a, err := newApp(appConfig{
+		AccessSecret:  []byte("access-token-secret-min-32-bytes-for-test!!"),
+		RefreshSecret: []byte("refresh-token-secret-min-32-bytes-for-test!"),
+		AccessTTL:     time.Minute,
+		RefreshTTL:    time.Hour,
+		Store:         jwtauth.NewCacheBlocklist(jwtauth.NewMemoryCache(0)),
+	})
+	if err != nil {
+		t.Fatalf("newApp failed: %v", err)
+	}
+	return a.router()
+}
+
+// session is the client-side state of a logged-in user
+type session struct {
+	access  string
+	refresh *http.Cookie
+}
+
+func do(router *gin.Engine, method, path, body string, s session) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if s.access != "" {
+		req.Header.Set("Authorization", "Bearer "+s.access)
+	}
+	if s.refresh != nil {
+		req.AddCookie(s.refresh)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// readSession extracts the tokens issued by login or refresh
+func readSession(t *testing.T, w *httptest.ResponseRecorder) session {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 issuing tokens, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+
+	s := session{access: body.AccessToken}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == refreshCookie {
+			s.refresh = &http.Cookie{Name: cookie.Name, Value: cookie.Value}
+		}
+	}
+	if s.access == "" || s.refresh == nil {
+		t.Fatal("Expected access token and refresh cookie")
+	}
+	return s
+}
+
+func reason(w *httptest.ResponseRecorder) string {
+	var body map[string]string
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return body["reason"]
+}
+
+// TestSessionLifecycle walks a user through login, role checks, refresh
+// rotation and logout
+func TestSessionLifecycle(t *testing.T) {
+	router := newTestApp(t)
+
+	alice := readSession(t, do(router, "POST", "/auth/login", `{"username":"alice","password":"alice-password"}`, session{}))
+
+	if w := do(router, "GET", "/api/profile", "", session{access: alice.access}); w.Code != http.StatusOK {
+		t.Fatalf("Expected profile access, got %d", w.Code)
+	}
+	if w := do(router, "GET", "/api/admin", "", session{access: alice.access}); w.Code != http.StatusOK {
+		t.Fatalf("Expected admin access for alice, got %d", w.Code)
+	}
+
+	// Refresh tokens are not accepted as access tokens
+	if w := do(router, "GET", "/api/profile", "", session{access: alice.refresh.Value}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected refresh token to be rejected on API routes, got %d", w.Code)
+	}
+
+	// Rotation: the new refresh token works, the old one is revoked
+	rotated := readSession(t, do(router, "POST", "/auth/refresh", "", session{refresh: alice.refresh}))
+	w := do(router, "POST", "/auth/refresh", "", session{refresh: alice.refresh})
+	if w.Code != http.StatusUnauthorized || reason(w) != string(jwtauth.ErrTokenRevoked) {
+		t.Errorf("Expected reused refresh token to be revoked, got %d %s", w.Code, reason(w))
+	}
+
+	// Logout revokes the access token and its refresh token
+	if w := do(router, "POST", "/auth/logout", "", session{access: rotated.access}); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected logout to succeed, got %d", w.Code)
+	}
+	if w := do(router, "GET", "/api/profile", "", session{access: rotated.access}); reason(w) != string(jwtauth.ErrTokenRevoked) {
+		t.Errorf("Expected access token to be revoked after logout, got %s", reason(w))
+	}
+	if w := do(router, "POST", "/auth/refresh", "", session{refresh: rotated.refresh}); reason(w) != string(jwtauth.ErrTokenRevoked) {
+		t.Errorf("Expected refresh token to be revoked after logout, got %s", reason(w))
+	}
+}
+
+// TestRoleProtectedRoute tests that users without the admin role are forbidden
+func TestRoleProtectedRoute(t *testing.T) {
+	router := newTestApp(t)
+
+	bob := readSession(t, do(router, "POST", "/auth/login", `{"username":"bob","password":"bob-password"}`, session{}))
+
+	if w := do(router, "GET", "/api/admin", "", session{access: bob.access}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for bob, got %d", w.Code)
+	}
+	if w := do(router, "POST", "/auth/login", `{"username":"bob","password":"wrong"}`, session{}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected bad password to be rejected, got %d", w.Code)
+	}
+}
@@ -0,0 +1,262 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// adminRefreshTimeout bounds a key refresh triggered through the admin API
+const adminRefreshTimeout = 30 * time.Second
+
+// AdminOptions configures AdminHandler
+type AdminOptions struct {
+	// Authorize decides whether r may use the admin API (required). It runs
+	// before every request; JWTAuth is not applied.
+	Authorize func(r *http.Request) bool
+
+	// Reload, when set, is called by POST /reload to rebuild the
+	// application's configuration (e.g. re-read key files and swap Configs)
+	Reload func(ctx context.Context) error
+}
+
+// AdminHandler returns an http.Handler exposing the runtime state of cfg for
+// operators. Paths are relative to the mount point; mount it with
+// http.StripPrefix:
+//
+//	mux.Handle("/internal/auth/", http.StripPrefix("/internal/auth", admin))
+//
+// Endpoints:
+//
+//	GET  /state         Keys (SHA-256 fingerprints, never key material), cache and blocklist stats, in-flight validations
+//	POST /keys/refresh  Re-fetch keys of every KeyPrefetcher provider (remote JWKS)
+//	POST /reload        Call AdminOptions.Reload
+//
+// HMAC secrets are listed by length or kid only.
+func AdminHandler(cfg *Config, opts AdminOptions) (http.Handler, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("admin handler requires a config")
+	}
+	if opts.Authorize == nil {
+		return nil, fmt.Errorf("admin handler requires an Authorize hook")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /state", func(w http.ResponseWriter, r *http.Request) {
+		writeAdminJSON(w, http.StatusOK, cfg.adminState())
+	})
+	mux.HandleFunc("POST /keys/refresh", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), adminRefreshTimeout)
+		defer cancel()
+		if err := cfg.Prefetch(ctx); err != nil {
+			writeAdminJSON(w, http.StatusBadGateway, adminResult{Error: err.Error()})
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, adminResult{OK: true})
+	})
+	mux.HandleFunc("POST /reload", func(w http.ResponseWriter, r *http.Request) {
+		if opts.Reload == nil {
+			writeAdminJSON(w, http.StatusNotImplemented, adminResult{Error: "reload not configured"})
+			return
+		}
+		if err := opts.Reload(r.Context()); err != nil {
+			writeAdminJSON(w, http.StatusInternalServerError, adminResult{Error: err.Error()})
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, adminResult{OK: true})
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !opts.Authorize(r) {
+			writeAdminJSON(w, http.StatusForbidden, adminResult{Error: "forbidden"})
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		mux.ServeHTTP(w, r)
+	}), nil
+}
+
+// adminResult is the response to admin actions
+type adminResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// adminState is the GET /state response
+type adminState struct {
+	Algorithms []adminAlgorithm `json:"algorithms"`
+	TokenCache *adminCacheStats `json:"token_cache,omitempty"`
+	ConnCache  *adminConnCache  `json:"conn_cache,omitempty"`
+	Blocklist  *adminBlocklist  `json:"blocklist,omitempty"`
+	IssuedAt   []adminIssuedAt  `json:"issued_at,omitempty"`
+	Verify     *adminVerify     `json:"verification,omitempty"`
+
+	InFlight     int64 `json:"in_flight"`
+	ShuttingDown bool  `json:"shutting_down"`
+}
+
+// adminAlgorithm describes the keys configured for one algorithm
+type adminAlgorithm struct {
+	Algorithm   string     `json:"algorithm"`
+	Source      string     `json:"source"` // "static", "secret" or "provider"
+	Provider    string     `json:"provider,omitempty"`
+	SecretBytes int        `json:"secret_bytes,omitempty"`
+	Keys        []adminKey `json:"keys,omitempty"`
+}
+
+// adminKey identifies a public key without revealing it
+type adminKey struct {
+	KeyID       string `json:"kid,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"` // Not set for secrets
+}
+
+// adminCacheStats reports token cache state
+type adminCacheStats struct {
+	TTL     string `json:"ttl"`
+	Entries *int   `json:"entries,omitempty"` // Only for caches that can count
+}
+
+// adminConnCache reports per-connection claims cache counters
+type adminConnCache struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// adminBlocklist reports blocklist state
+type adminBlocklist struct {
+	Type    string `json:"type"`
+	Entries *int   `json:"entries,omitempty"` // Only for blocklists that can count
+}
+
+// adminVerify reports the signature verification worker pool
+type adminVerify struct {
+	Workers  int    `json:"workers"`
+	Busy     int    `json:"busy"`
+	Waiting  int    `json:"waiting"`
+	Rejected uint64 `json:"rejected"`
+}
+
+// adminIssuedAt reports the (now - iat) distribution of one issuer
+type adminIssuedAt struct {
+	Issuer             string                `json:"issuer"`
+	Count              uint64                `json:"count"`
+	Buckets            []adminIssuedAtBucket `json:"buckets"`
+	SuggestedClockSkew string                `json:"suggested_clock_skew"`
+}
+
+// adminIssuedAtBucket is a cumulative histogram bucket
+type adminIssuedAtBucket struct {
+	LE    string `json:"le"` // "+Inf" for the last bucket
+	Count uint64 `json:"count"`
+}
+
+// counter is implemented by in-process caches and blocklists that can
+// report their size
+type counter interface {
+	Len() int
+}
+
+// keySnapshotter is implemented by key providers that can list the keys
+// they currently hold
+type keySnapshotter interface {
+	keySnapshot() map[string]interface{}
+}
+
+// adminState collects the state reported by GET /state
+func (c *Config) adminState() adminState {
+	state := adminState{InFlight: c.InFlight(), ShuttingDown: c.ShuttingDown()}
+	for _, alg := range c.AvailableAlgorithms() {
+		validator, _ := c.getValidator(alg)
+		entry := adminAlgorithm{Algorithm: alg, Source: "static"}
+		switch key := validator.signingKey.(type) {
+		case nil:
+			if validator.keySet != nil {
+				for _, kid := range validator.keySet.kids() {
+					k := validator.keySet.keys[kid]
+					if _, ok := k.([]byte); ok {
+						entry.Source = "secret"
+						entry.Keys = append(entry.Keys, adminKey{KeyID: kid})
+						continue
+					}
+					entry.Keys = append(entry.Keys, adminKey{KeyID: kid, Fingerprint: keyFingerprint(k)})
+				}
+				break
+			}
+			entry.Source = "provider"
+			entry.Provider = fmt.Sprintf("%T", validator.keyProvider)
+			if snapshotter, ok := validator.keyProvider.(keySnapshotter); ok {
+				keys := snapshotter.keySnapshot()
+				for kid, k := range keys {
+					entry.Keys = append(entry.Keys, adminKey{KeyID: kid, Fingerprint: keyFingerprint(k)})
+				}
+				sort.Slice(entry.Keys, func(i, j int) bool { return entry.Keys[i].KeyID < entry.Keys[j].KeyID })
+			}
+		case []byte:
+			entry.Source = "secret"
+			entry.SecretBytes = len(key)
+		default:
+			entry.Keys = []adminKey{{Fingerprint: keyFingerprint(key)}}
+		}
+		state.Algorithms = append(state.Algorithms, entry)
+	}
+
+	if c.tokenCache != nil {
+		state.TokenCache = &adminCacheStats{TTL: c.tokenCacheTTL.String()}
+		if n, ok := c.tokenCache.(counter); ok {
+			entries := n.Len()
+			state.TokenCache.Entries = &entries
+		}
+	}
+	if c.connCacheCounters != nil {
+		stats := c.ConnCacheStats()
+		state.ConnCache = &adminConnCache{Hits: stats.Hits, Misses: stats.Misses}
+	}
+	for _, stats := range c.IssuedAtStats() {
+		entry := adminIssuedAt{Issuer: stats.Issuer, Count: stats.Count, SuggestedClockSkew: stats.SuggestedClockSkew.String()}
+		for _, bucket := range stats.Buckets {
+			le := "+Inf"
+			if bucket.UpperBound != math.MaxInt64 {
+				le = bucket.UpperBound.String()
+			}
+			entry.Buckets = append(entry.Buckets, adminIssuedAtBucket{LE: le, Count: bucket.Count})
+		}
+		state.IssuedAt = append(state.IssuedAt, entry)
+	}
+	if c.verifyPool != nil {
+		stats := c.VerificationStats()
+		state.Verify = &adminVerify{Workers: stats.Workers, Busy: stats.Busy, Waiting: stats.Waiting, Rejected: stats.Rejected}
+	}
+	if c.blocklist != nil {
+		state.Blocklist = &adminBlocklist{Type: fmt.Sprintf("%T", c.blocklist)}
+		if n, ok := c.blocklist.(counter); ok {
+			entries := n.Len()
+			state.Blocklist.Entries = &entries
+		}
+	}
+	return state
+}
+
+// keyFingerprint returns the SHA-256 fingerprint of a public key's PKIX
+// encoding, or "unknown" for keys that cannot be encoded
+func keyFingerprint(key interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "unknown"
+	}
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// writeAdminJSON writes v as a JSON response
+func writeAdminJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,96 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestAdminHandler tests the admin state and action endpoints
+func TestAdminHandler(t *testing.T) {
+	key := mustGenerateECKey()
+	url, fetches := serveJWKS(t, JSONWebKey{KeyID: "k1", Algorithm: "ES256", Key: &key.PublicKey})
+	blocklist := NewMemoryBlocklist()
+	blocklist.Revoke("jti-1", time.Now().Add(time.Hour))
+	cfg := mustCreateConfig(
+		WithHS256([]byte("test-secret-key-min-32-bytes-long!!")),
+		WithKeyProvider("ES256", newRemoteJWKS(url)),
+		WithTokenCache(NewMemoryCache(10), time.Minute),
+		WithBlocklist(blocklist),
+	)
+
+	reloads := 0
+	admin, err := AdminHandler(cfg, AdminOptions{
+		Authorize: func(r *http.Request) bool { return r.Header.Get("X-Admin-Token") == "ops" },
+		Reload: func(ctx context.Context) error {
+			reloads++
+			if reloads > 1 {
+				return errors.New("bad key file")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("AdminHandler failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/internal/auth/", http.StripPrefix("/internal/auth", admin))
+
+	call := func(method, path string, authorized bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if authorized {
+			req.Header.Set("X-Admin-Token", "ops")
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := call(http.MethodGet, "/internal/auth/state", false); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without authorization, got %d", w.Code)
+	}
+
+	if w := call(http.MethodPost, "/internal/auth/keys/refresh", true); w.Code != http.StatusOK || fetches.Load() != 1 {
+		t.Fatalf("Expected refresh to fetch the JWKS, got %d after %d fetches", w.Code, fetches.Load())
+	}
+
+	w := call(http.MethodGet, "/internal/auth/state", true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var state adminState
+	if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Algorithms) != 2 {
+		t.Fatalf("Expected 2 algorithms, got %+v", state.Algorithms)
+	}
+	es, hs := state.Algorithms[0], state.Algorithms[1]
+	if es.Source != "provider" || len(es.Keys) != 1 || es.Keys[0].KeyID != "k1" || es.Keys[0].Fingerprint != keyFingerprint(&key.PublicKey) {
+		t.Errorf("Unexpected ES256 state: %+v", es)
+	}
+	if hs.Source != "secret" || hs.SecretBytes != 35 || len(hs.Keys) != 0 {
+		t.Errorf("Unexpected HS256 state: %+v", hs)
+	}
+	if state.TokenCache == nil || state.TokenCache.TTL != "1m0s" || state.TokenCache.Entries == nil {
+		t.Errorf("Unexpected token cache state: %+v", state.TokenCache)
+	}
+	if state.Blocklist == nil || state.Blocklist.Entries == nil || *state.Blocklist.Entries != 1 {
+		t.Errorf("Unexpected blocklist state: %+v", state.Blocklist)
+	}
+
+	if w := call(http.MethodPost, "/internal/auth/reload", true); w.Code != http.StatusOK {
+		t.Errorf("Expected reload to succeed, got %d", w.Code)
+	}
+	if w := call(http.MethodPost, "/internal/auth/reload", true); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected failed reload to return 500, got %d", w.Code)
+	}
+
+	if _, err := AdminHandler(cfg, AdminOptions{}); err == nil {
+		t.Error("Expected missing Authorize hook to be rejected")
+	}
+}
@@ -0,0 +1,163 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AnomalyDetection configures detection of material claim changes between
+// tokens presented by the same subject
+type AnomalyDetection struct {
+	Window      time.Duration                              // How long a subject's claims are remembered (default 10m)
+	Claims      []string                                   // Claims compared across tokens (default iss, aud, role, roles, scope)
+	MaxSubjects int                                        // Maximum subjects remembered (default 10000)
+	OnAnomaly   func(ctx context.Context, a ClaimsAnomaly) // Optional callback, e.g. to alert or step up auth
+}
+
+// ClaimsAnomaly describes a subject whose watched claims changed within the window
+type ClaimsAnomaly struct {
+	Subject       string
+	ChangedClaims []string
+	Previous      map[string]string // Claim name -> JSON value from the earlier token
+	Current       map[string]string // Claim name -> JSON value from the current token
+}
+
+// defaultAnomalyClaims are the claims whose change usually indicates
+// privilege escalation or a token from an unexpected issuer
+var defaultAnomalyClaims = []string{"iss", "aud", "role", "roles", "scope"}
+
+// WithAnomalyDetection emits an "anomaly" SecurityEvent when a subject
+// presents a token whose watched claims differ from its previous token
+// within the window (e.g. a role escalates or the issuer changes).
+// Requests are not rejected; use OnAnomaly to act on anomalies.
+func WithAnomalyDetection(d AnomalyDetection) ConfigOption {
+	return func(c *Config) error {
+		if d.Window < 0 {
+			return fmt.Errorf("anomaly window must be non-negative, got %v", d.Window)
+		}
+		if d.MaxSubjects < 0 {
+			return fmt.Errorf("anomaly max subjects must be non-negative, got %d", d.MaxSubjects)
+		}
+		if d.Window == 0 {
+			d.Window = 10 * time.Minute
+		}
+		if len(d.Claims) == 0 {
+			d.Claims = defaultAnomalyClaims
+		}
+		if d.MaxSubjects == 0 {
+			d.MaxSubjects = 10000
+		}
+		c.anomalyDetector = &anomalyDetector{
+			config:   d,
+			subjects: make(map[string]*claimsFingerprint),
+		}
+		return nil
+	}
+}
+
+// claimsFingerprint is the last seen watched-claim values for a subject
+type claimsFingerprint struct {
+	values map[string]string
+	seenAt time.Time
+}
+
+// anomalyDetector is a per-subject fingerprint cache with TTL
+type anomalyDetector struct {
+	config   AnomalyDetection
+	mu       sync.Mutex
+	subjects map[string]*claimsFingerprint
+}
+
+// observe records claims for subject and returns the anomaly, if any
+func (d *anomalyDetector) observe(subject string, claims *Claims, now time.Time) *ClaimsAnomaly {
+	current := make(map[string]string, len(d.config.Claims))
+	for _, name := range d.config.Claims {
+		if value, ok := claims.Get(name); ok {
+			encoded, _ := json.Marshal(value) // Stable encoding: map keys are sorted
+			current[name] = string(encoded)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	previous, ok := d.subjects[subject]
+	if !ok || now.Sub(previous.seenAt) > d.config.Window {
+		d.evict(now)
+		d.subjects[subject] = &claimsFingerprint{values: current, seenAt: now}
+		return nil
+	}
+
+	var changed []string
+	for _, name := range d.config.Claims {
+		if previous.values[name] != current[name] {
+			changed = append(changed, name)
+		}
+	}
+
+	var anomaly *ClaimsAnomaly
+	if len(changed) > 0 {
+		anomaly = &ClaimsAnomaly{
+			Subject:       claims.Subject,
+			ChangedClaims: changed,
+			Previous:      previous.values,
+			Current:       current,
+		}
+	}
+
+	// Compare future tokens against the latest claims so one change alerts once
+	d.subjects[subject] = &claimsFingerprint{values: current, seenAt: now}
+	return anomaly
+}
+
+// evict makes room for a new subject, dropping expired entries first
+func (d *anomalyDetector) evict(now time.Time) {
+	if len(d.subjects) < d.config.MaxSubjects {
+		return
+	}
+	for subject, fp := range d.subjects {
+		if now.Sub(fp.seenAt) > d.config.Window {
+			delete(d.subjects, subject)
+		}
+	}
+	for subject := range d.subjects {
+		if len(d.subjects) < d.config.MaxSubjects {
+			break
+		}
+		delete(d.subjects, subject)
+	}
+}
+
+// detectClaimsAnomaly compares claims with the subject's previous token and
+// reports material changes
+func detectClaimsAnomaly(ctx context.Context, cfg *Config, requestID, token string, claims *Claims, tenant string) {
+	if cfg.anomalyDetector == nil || claims.Subject == "" {
+		return
+	}
+
+	// Subjects are only unique within a tenant
+	anomaly := cfg.anomalyDetector.observe(tenant+"\x00"+claims.Subject, claims, time.Now())
+	if anomaly == nil {
+		return
+	}
+
+	clientIP, _ := GetClientIP(ctx)
+	logSecurityEvent(cfg.Logger(), SecurityEvent{
+		EventType:     "anomaly",
+		Timestamp:     time.Now(),
+		RequestID:     requestID,
+		ClientIP:      clientIP,
+		UserID:        claims.Subject,
+		TenantID:      tenant,
+		Algorithm:     extractAlgorithmFromToken(token),
+		TokenPreview:  token,
+		ChangedClaims: anomaly.ChangedClaims,
+	})
+
+	if cfg.anomalyDetector.config.OnAnomaly != nil {
+		cfg.anomalyDetector.config.OnAnomaly(ctx, *anomaly)
+	}
+}
@@ -0,0 +1,92 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestAnomalyDetection tests anomaly events for claim changes within the window
+func TestAnomalyDetection(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	var logs bytes.Buffer
+	var anomalies []ClaimsAnomaly
+
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+		WithAnomalyDetection(AnomalyDetection{
+			OnAnomaly: func(ctx context.Context, a ClaimsAnomaly) {
+				anomalies = append(anomalies, a)
+			},
+		}),
+	)
+	router := createTestRouter(cfg)
+
+	request := func(claims jwt.MapClaims) {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+mustSignHS256(secret, claims))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected anomalies not to block requests, got %d", w.Code)
+		}
+	}
+
+	request(jwt.MapClaims{"sub": "alice", "roles": []string{"user"}})
+	request(jwt.MapClaims{"sub": "alice", "roles": []string{"user"}, "name": "Alice"}) // Unwatched claim
+	request(jwt.MapClaims{"sub": "bob", "roles": []string{"admin"}})                   // Different subject
+	if len(anomalies) != 0 {
+		t.Fatalf("Expected no anomalies yet, got %+v", anomalies)
+	}
+
+	request(jwt.MapClaims{"sub": "alice", "roles": []string{"admin", "user"}})
+	request(jwt.MapClaims{"sub": "alice", "roles": []string{"admin", "user"}}) // Alerts once
+
+	if len(anomalies) != 1 {
+		t.Fatalf("Expected 1 anomaly, got %d", len(anomalies))
+	}
+	a := anomalies[0]
+	if a.Subject != "alice" || len(a.ChangedClaims) != 1 || a.ChangedClaims[0] != "roles" {
+		t.Errorf("Unexpected anomaly: %+v", a)
+	}
+	if a.Previous["roles"] != `["user"]` || a.Current["roles"] != `["admin","user"]` {
+		t.Errorf("Unexpected claim values: %v -> %v", a.Previous, a.Current)
+	}
+
+	if !strings.Contains(logs.String(), `"event":"anomaly"`) || !strings.Contains(logs.String(), `"changed_claims":["roles"]`) {
+		t.Errorf("Expected anomaly security event, got %s", logs.String())
+	}
+}
+
+// TestAnomalyDetectorWindow tests that fingerprints expire and the cache stays bounded
+func TestAnomalyDetectorWindow(t *testing.T) {
+	cfg := mustCreateConfig(
+		WithHS256([]byte("test-secret-key-min-32-bytes-long!!")),
+		WithAnomalyDetection(AnomalyDetection{Window: time.Minute, MaxSubjects: 2}),
+	)
+	d := cfg.anomalyDetector
+	now := time.Now()
+
+	d.observe("alice", &Claims{Issuer: "a"}, now)
+	if d.observe("alice", &Claims{Issuer: "b"}, now.Add(2*time.Minute)) != nil {
+		t.Error("Expected change outside the window to be ignored")
+	}
+	if d.observe("alice", &Claims{Issuer: "c"}, now.Add(150*time.Second)) == nil {
+		t.Error("Expected issuer change within the window to be flagged")
+	}
+
+	d.observe("bob", &Claims{}, now)
+	d.observe("carol", &Claims{}, now)
+	if len(d.subjects) > 2 {
+		t.Errorf("Expected at most 2 subjects, got %d", len(d.subjects))
+	}
+}
@@ -0,0 +1,94 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+)
+
+// AnyOf returns a configuration accepting tokens valid under any of cfgs,
+// e.g. tokens from a legacy and a new issuer during a migration:
+//
+//	legacy, _ := jwtauth.NewConfig(jwtauth.WithHS256(oldSecret), jwtauth.WithIssuer("https://old.example.com"))
+//	current, _ := jwtauth.NewConfig(jwtauth.WithJWKSURL(jwksURL), jwtauth.WithIssuer("https://idp.example.com"))
+//	cfg, err := jwtauth.AnyOf(current, legacy)
+//
+// Configs are tried in order and each applies all of its own checks and
+// policies (caches, revocation, bindings, rate limits, canary). The
+// position of the accepting config is logged as matched_config in success
+// events. When every config rejects the token, the error of the first
+// config that verified its signature is returned, or else the first error.
+//
+// Token extraction, logging, error responses and route requirements follow
+// the first config.
+func AnyOf(cfgs ...*Config) (*Config, error) {
+	if len(cfgs) < 2 {
+		return nil, NewValidationError(ErrConfigError, "AnyOf requires at least two configurations", nil)
+	}
+	for i, cfg := range cfgs {
+		if cfg == nil || cfg.parser == nil {
+			return nil, NewValidationError(ErrConfigError, fmt.Sprintf("AnyOf configuration %d must be created with NewConfig", i+1), nil)
+		}
+	}
+
+	composite := *cfgs[0]
+	composite.anyOf = append([]*Config(nil), cfgs...)
+	composite.canary = nil
+	composite.faults = nil
+	composite.lifecycle = &lifecycle{}
+	return &composite, nil
+}
+
+// authenticateAnyOf authenticates a token under the configs of an AnyOf
+// composite, returning the first acceptance
+func authenticateAnyOf(ctx context.Context, tokenString, requestID string, cfg *Config) (*Claims, string, error) {
+	return firstAccepted(cfg.anyOf, func(member *Config) (*Claims, string, error) {
+		return authenticateToken(ctx, tokenString, requestID, member)
+	})
+}
+
+// parseAnyOf is ParseToken for an AnyOf composite
+func parseAnyOf(ctx context.Context, tokenString string, cfg *Config) (*Claims, error) {
+	claims, _, err := firstAccepted(cfg.anyOf, func(member *Config) (*Claims, string, error) {
+		claims, err := ParseToken(ctx, tokenString, member)
+		return claims, "", err
+	})
+	return claims, err
+}
+
+// firstAccepted runs authenticate for each config until one accepts,
+// recording the accepting config's position on the claims
+func firstAccepted(cfgs []*Config, authenticate func(*Config) (*Claims, string, error)) (*Claims, string, error) {
+	var firstErr, verifiedErr error
+	for i, member := range cfgs {
+		claims, tenant, err := authenticate(member)
+		if err == nil {
+			matched := *claims
+			matched.matchedConfig = i + 1
+			return &matched, tenant, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if verifiedErr == nil && signatureVerified(err) {
+			verifiedErr = err
+		}
+	}
+	if verifiedErr != nil {
+		return nil, "", verifiedErr
+	}
+	return nil, "", firstErr
+}
+
+// signatureVerified reports whether err was raised after the token's
+// signature was verified, i.e. by a claim check or request policy
+func signatureVerified(err error) bool {
+	valErr, ok := err.(*ValidationError)
+	if !ok {
+		return false
+	}
+	switch valErr.Cause() {
+	case CategoryClaims, CategoryPolicy:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestAnyOf tests accepting tokens from a legacy and a new issuer
+func TestAnyOf(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	ecKey := mustGenerateECKey()
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	current := mustCreateConfig(WithES256(&ecKey.PublicKey), WithIssuer("https://idp.example.com"), WithLogger(logger))
+	legacy := mustCreateConfig(WithHS256(secret), WithIssuer("https://old.example.com"))
+	cfg, err := AnyOf(current, legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	currentToken, _ := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "new-user", "iss": "https://idp.example.com", "exp": exp}).SignedString(ecKey)
+	legacyToken := mustSignHS256(secret, jwt.MapClaims{"sub": "old-user", "iss": "https://old.example.com", "exp": exp})
+	wrongIssuer := mustSignHS256(secret, jwt.MapClaims{"sub": "old-user", "iss": "https://idp.example.com", "exp": exp})
+
+	router := createTestRouter(cfg)
+	for token, want := range map[string]string{currentToken: `"matched_config":1`, legacyToken: `"matched_config":2`} {
+		logs.Reset()
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Errorf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("expected %s in the success event, got %s", want, logs.String())
+		}
+	}
+
+	if claims, err := ParseToken(context.Background(), legacyToken, cfg); err != nil || claims.Subject != "old-user" {
+		t.Errorf("expected ParseToken to accept the legacy token, got %v", err)
+	}
+
+	// The legacy config verified the signature, so its error wins over the
+	// first config's algorithm rejection
+	if _, err := ParseToken(context.Background(), wrongIssuer, cfg); getErrorCode(err) != string(ErrInvalidIssuer) {
+		t.Errorf("expected INVALID_ISSUER, got %v", err)
+	}
+	if _, err := ParseToken(context.Background(), mustSignHS256([]byte("another-secret-key-min-32-bytes!!"), jwt.MapClaims{"exp": exp}), cfg); getErrorCode(err) != string(ErrUnsupportedAlgorithm) {
+		t.Errorf("expected the first config's error, got %v", err)
+	}
+}
+
+// TestAnyOfErrors tests rejected compositions
+func TestAnyOfErrors(t *testing.T) {
+	cfg := mustCreateConfig(WithHS256([]byte("test-secret-key-min-32-bytes-long!!")))
+	if _, err := AnyOf(cfg); err == nil {
+		t.Error("expected error for a single configuration")
+	}
+	if _, err := AnyOf(cfg, nil); err == nil {
+		t.Error("expected error for a nil configuration")
+	}
+	if _, err := AnyOf(cfg, &Config{}); err == nil {
+		t.Error("expected error for a configuration not created with NewConfig")
+	}
+}
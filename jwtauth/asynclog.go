@@ -0,0 +1,167 @@
+package jwtauth
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultAsyncLogBuffer is the queue size used when NewAsyncHandler is given
+// a non-positive buffer size
+const defaultAsyncLogBuffer = 1024
+
+// AsyncHandler is a slog.Handler that queues records and writes them to the
+// wrapped handler from a single background goroutine, so a slow log sink
+// never adds latency to token validation:
+//
+//	async := jwtauth.NewAsyncHandler(slog.NewJSONHandler(os.Stdout, nil), 4096)
+//	defer async.Close(context.Background())
+//	cfg, err := jwtauth.NewConfig(jwtauth.WithLogger(slog.New(async)), ...)
+//
+// When the queue is full, records are dropped rather than blocking the
+// caller; Dropped reports how many. Handlers derived with WithAttrs and
+// WithGroup share the queue. Records are written in the order they were
+// queued.
+type AsyncHandler struct {
+	next  slog.Handler
+	queue *asyncLogQueue
+}
+
+// asyncLogQueue is shared by an AsyncHandler and the handlers derived from it
+type asyncLogQueue struct {
+	mu      sync.RWMutex // Guards closed against sends on the closed channel
+	closed  bool
+	records chan asyncLogRecord
+	done    chan struct{} // Closed when the writer has drained the queue
+	dropped atomic.Uint64
+}
+
+// asyncLogRecord is a queued record, or a flush marker when flushed is set
+type asyncLogRecord struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+	flushed chan struct{}
+}
+
+// NewAsyncHandler starts the background writer for next with a queue of
+// bufferSize records (default 1024). Call Close on shutdown to write the
+// records still queued.
+func NewAsyncHandler(next slog.Handler, bufferSize int) *AsyncHandler {
+	if bufferSize <= 0 {
+		bufferSize = defaultAsyncLogBuffer
+	}
+	q := &asyncLogQueue{
+		records: make(chan asyncLogRecord, bufferSize),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return &AsyncHandler{next: next, queue: q}
+}
+
+// run writes queued records until the queue is closed and drained
+func (q *asyncLogQueue) run() {
+	defer close(q.done)
+	for rec := range q.records {
+		if rec.flushed != nil {
+			close(rec.flushed)
+			continue
+		}
+		// Sink errors cannot be returned to the caller that logged
+		_ = rec.handler.Handle(rec.ctx, rec.record)
+	}
+}
+
+// Enabled implements slog.Handler
+func (h *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler. It queues the record without blocking and
+// drops it when the queue is full. After Close, records are written
+// synchronously.
+func (h *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	q := h.queue
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return h.next.Handle(ctx, r)
+	}
+
+	// The request context is usually canceled before the record is written
+	rec := asyncLogRecord{ctx: context.WithoutCancel(ctx), handler: h.next, record: r.Clone()}
+	select {
+	case q.records <- rec:
+	default:
+		q.dropped.Add(1)
+	}
+	return nil
+}
+
+// WithAttrs implements slog.Handler
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{next: h.next.WithAttrs(attrs), queue: h.queue}
+}
+
+// WithGroup implements slog.Handler
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	return &AsyncHandler{next: h.next.WithGroup(name), queue: h.queue}
+}
+
+// Dropped returns the number of records dropped because the queue was full
+func (h *AsyncHandler) Dropped() uint64 {
+	return h.queue.dropped.Load()
+}
+
+// Flush waits until every record queued before the call has been written, or
+// ctx is done
+func (h *AsyncHandler) Flush(ctx context.Context) error {
+	q := h.queue
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		return h.waitDrained(ctx)
+	}
+
+	// The marker waits for queue space rather than being dropped
+	marker := asyncLogRecord{flushed: make(chan struct{})}
+	select {
+	case q.records <- marker:
+		q.mu.RUnlock()
+	case <-ctx.Done():
+		q.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-marker.flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops queueing, writes the records already queued and waits for the
+// writer to finish, or for ctx to be done. Close is safe to call more than
+// once; it applies to derived handlers as well.
+func (h *AsyncHandler) Close(ctx context.Context) error {
+	q := h.queue
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.records)
+	}
+	q.mu.Unlock()
+	return h.waitDrained(ctx)
+}
+
+// waitDrained waits for the writer goroutine to exit
+func (h *AsyncHandler) waitDrained(ctx context.Context) error {
+	select {
+	case <-h.queue.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
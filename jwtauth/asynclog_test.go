@@ -0,0 +1,106 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// blockingHandler is a slog sink that waits on gate before each write
+type blockingHandler struct {
+	slog.Handler
+	gate chan struct{}
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, r slog.Record) error {
+	<-h.gate
+	h.mu.Lock()
+	h.msgs = append(h.msgs, r.Message)
+	h.mu.Unlock()
+	return nil
+}
+
+func (h *blockingHandler) messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.msgs...)
+}
+
+// TestAsyncHandler tests that a stalled sink drops records instead of
+// blocking, and that Close writes the queued records in order
+func TestAsyncHandler(t *testing.T) {
+	sink := &blockingHandler{Handler: slog.NewTextHandler(&bytes.Buffer{}, nil), gate: make(chan struct{})}
+	async := NewAsyncHandler(sink, 4)
+	logger := slog.New(async)
+
+	start := time.Now()
+	for i := 0; i < 20; i++ {
+		logger.Info(string(rune('a' + i)))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Expected logging to return immediately with a stalled sink, took %v", elapsed)
+	}
+	// The writer holds at most one record while blocked, so 4 or 5 are kept
+	if dropped := async.Dropped(); dropped < 15 || dropped > 16 {
+		t.Errorf("Expected 15-16 dropped records, got %d", dropped)
+	}
+
+	close(sink.gate)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := async.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	msgs := sink.messages()
+	if uint64(len(msgs))+async.Dropped() != 20 || strings.Join(msgs, "") != "abcde"[:len(msgs)] {
+		t.Errorf("Expected the first records in order, got %v", msgs)
+	}
+
+	// Records after Close are written synchronously rather than lost
+	logger.Info("late")
+	if msgs := sink.messages(); msgs[len(msgs)-1] != "late" {
+		t.Errorf("Expected late record to be written, got %v", msgs)
+	}
+	if err := async.Close(ctx); err != nil {
+		t.Errorf("Expected second Close to succeed, got %v", err)
+	}
+}
+
+// TestAsyncHandlerSecurityEvents tests the handler as the Config logger
+func TestAsyncHandlerSecurityEvents(t *testing.T) {
+	var buf bytes.Buffer
+	async := NewAsyncHandler(slog.NewJSONHandler(&buf, nil), 0)
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithLogger(slog.New(async).With("service", "api")))
+	router := createTestRouter(cfg)
+
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	for _, auth := range []string{"Bearer " + token, "Bearer " + token + "x"} {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", auth)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := async.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `"authentication succeeded"`) || !strings.Contains(out, `"INVALID_SIGNATURE"`) || !strings.Contains(out, `"service":"api"`) {
+		t.Errorf("Expected both events with derived attrs after Flush, got %s", out)
+	}
+	if err := async.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,309 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is the storage backend shared by the middleware's caches (verification
+// keys, validated tokens, revocations). Values are opaque bytes so the same
+// interface fits in-process and networked stores. Implementations must be
+// safe for concurrent use.
+//
+// A cache shared outside the process is a trust anchor: whoever can write to
+// it can plant revocations, keys or claims. Token and key cache entries in
+// caches other than NewMemoryCache are therefore authenticated
+// (WithTokenCacheEncryption and the NewCachedKeyProvider secret are
+// required) and HMAC secrets are never written to them, but revocations are
+// stored as-is, so restrict who can reach the backend.
+type Cache interface {
+	// Get returns the value for key, or found=false if absent or expired
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	// Set stores value for key, expiring after ttl (ttl <= 0 means no expiry)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key
+	Delete(ctx context.Context, key string) error
+}
+
+// memoryCacheEntry is a value with its expiry
+type memoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time // Zero means no expiry
+}
+
+// memoryCache is an in-process Cache with TTLs and a size bound. Reads are
+// lock-free (sync.Map); only eviction of a full cache serializes writers.
+type memoryCache struct {
+	entries    sync.Map // key -> *memoryCacheEntry (pointers compare for CompareAndDelete)
+	size       atomic.Int64
+	maxEntries int
+	evictMu    sync.Mutex
+}
+
+// isInProcessCache reports whether cache lives in this process, so its
+// entries cannot have been written by anyone else
+func isInProcessCache(cache Cache) bool {
+	_, ok := cache.(*memoryCache)
+	return ok
+}
+
+// NewMemoryCache returns an in-process Cache holding at most maxEntries values
+// (0 means 10000). When full, expired entries are dropped first, then
+// arbitrary ones.
+func NewMemoryCache(maxEntries int) Cache {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &memoryCache{maxEntries: maxEntries}
+}
+
+// Get implements Cache
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, ok := c.entries.Load(key)
+	if !ok {
+		return nil, false, nil
+	}
+	entry := v.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.remove(key, v)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+// Set implements Cache
+func (c *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := &memoryCacheEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	if _, replaced := c.entries.Swap(key, entry); !replaced {
+		if c.size.Add(1) > int64(c.maxEntries) {
+			c.evict(key)
+		}
+	}
+	return nil
+}
+
+// Delete implements Cache
+func (c *memoryCache) Delete(ctx context.Context, key string) error {
+	if _, loaded := c.entries.LoadAndDelete(key); loaded {
+		c.size.Add(-1)
+	}
+	return nil
+}
+
+// Len returns the number of cached values, including expired ones not yet
+// dropped
+func (c *memoryCache) Len() int {
+	return int(c.size.Load())
+}
+
+// remove deletes key if it still holds the given entry
+func (c *memoryCache) remove(key string, entry interface{}) {
+	if c.entries.CompareAndDelete(key, entry) {
+		c.size.Add(-1)
+	}
+}
+
+// evict restores the size bound after inserting keep, dropping expired
+// entries first
+func (c *memoryCache) evict(keep string) {
+	c.evictMu.Lock()
+	defer c.evictMu.Unlock()
+
+	now := time.Now()
+	c.entries.Range(func(key, v interface{}) bool {
+		if entry := v.(*memoryCacheEntry); !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+			c.remove(key.(string), v)
+		}
+		return true
+	})
+	c.entries.Range(func(key, v interface{}) bool {
+		if c.size.Load() <= int64(c.maxEntries) {
+			return false
+		}
+		if key.(string) != keep {
+			c.remove(key.(string), v)
+		}
+		return true
+	})
+}
+
+// WithTokenCache caches validated claims in cache for up to ttl (never past
+// the token's expiry), so replicas sharing a cache verify each token's
+// signature once. Entries are keyed by a hash of the token and the Config's
+// keys and claim policies, so Configs that trust different keys never share
+// results. Configs using key providers share entries only within a process.
+//
+// Cached claims are trusted without verifying the token again, so a cache
+// other than NewMemoryCache also requires WithTokenCacheEncryption, whose
+// AES-GCM entries cannot be forged without the key.
+func WithTokenCache(cache Cache, ttl time.Duration) ConfigOption {
+	return func(c *Config) error {
+		if cache == nil {
+			return fmt.Errorf("token cache cannot be nil")
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("token cache TTL must be positive, got %v", ttl)
+		}
+		c.tokenCache = cache
+		c.tokenCacheTTL = ttl
+		return nil
+	}
+}
+
+// validateWithTokenCache returns claims cached for a previously validated
+// token, falling back to full validation and caching the result. Cache
+// failures degrade to full validation.
+func validateWithTokenCache(ctx context.Context, tokenString string, cfg *Config) (*Claims, error) {
+	if cfg.tokenCache == nil {
+		return parseAndValidateJWTContext(ctx, tokenString, cfg)
+	}
+
+	key := cfg.tokenCacheKey(tokenString)
+	now := time.Now()
+
+	if data, found, err := cfg.tokenCache.Get(ctx, key); err == nil && found {
+		var claims Claims
+		if data, err := cfg.openCachedClaims(key, data); err == nil &&
+			json.Unmarshal(data, &claims) == nil &&
+			(claims.ExpiresAt.IsZero() || !now.After(claims.ExpiresAt.Add(cfg.ClockSkewLeeway()))) {
+			// Entries cached before Audiences existed carry only Audience
+			if claims.Audiences == nil && claims.Audience != "" {
+				claims.Audiences = []string{claims.Audience}
+			}
+			// Configs sharing keys may map permissions differently
+			claims.Permissions = cfg.mapPermissions(&claims)
+			return &claims, nil
+		}
+	}
+
+	claims, err := parseAndValidateJWTContext(ctx, tokenString, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Degraded results must be revalidated once the key source recovers
+	if claims.degraded {
+		return claims, nil
+	}
+
+	ttl := cfg.tokenCacheTTL
+	if !claims.ExpiresAt.IsZero() {
+		if remaining := claims.ExpiresAt.Add(cfg.ClockSkewLeeway()).Sub(now); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	if ttl > 0 {
+		if data, err := json.Marshal(claims); err == nil {
+			if data, err := cfg.sealCachedClaims(key, data); err == nil {
+				cfg.tokenCache.Set(ctx, key, data, ttl)
+			}
+		}
+	}
+
+	return claims, nil
+}
+
+// tokenCacheKey returns the cache key for a token under this Config
+func (c *Config) tokenCacheKey(tokenString string) string {
+	sum := sha256.Sum256([]byte(c.fingerprint + "\x00" + tokenString))
+	return "jwtauth:token:" + hex.EncodeToString(sum[:])
+}
+
+// computeFingerprint hashes the trust anchors, header checks and claim
+// policies that decide whether a token is valid under this Config. Configs
+// sharing a cache must never share results if any of them differ.
+func (c *Config) computeFingerprint() string {
+	h := sha256.New()
+	for _, alg := range c.AvailableAlgorithms() {
+		validator := c.validators[alg]
+		fmt.Fprintf(h, "alg=%s;", alg)
+		switch {
+		case validator.keySet != nil:
+			fmt.Fprintf(h, "fallback=%d;default=%q;", validator.keySet.fallback, validator.keySet.defaultKey)
+			for _, kid := range validator.keySet.kids() {
+				fmt.Fprintf(h, "kid=%q;", kid)
+				hashKey(h, validator.keySet.keys[kid])
+			}
+		case validator.signingKey == nil:
+			fmt.Fprintf(h, "provider=%p;", validator.keyProvider)
+		default:
+			hashKey(h, validator.signingKey)
+		}
+	}
+
+	required := append([]string(nil), c.requiredClaims...)
+	sort.Strings(required)
+	audiences := append([]string(nil), c.audiences...)
+	sort.Strings(audiences)
+	fmt.Fprintf(h, "required=%q;typed=%v;aud=%q;match=%d;skew=%d;delegation=%t", required, c.claimRequirements, audiences, c.audienceMatch, c.clockSkewLeeway, c.delegationValidation)
+	if len(c.issuers) > 0 {
+		issuers := append([]string(nil), c.issuers...)
+		sort.Strings(issuers)
+		fmt.Fprintf(h, ";iss=%q", issuers)
+	}
+	if c.claimSchema != nil {
+		fmt.Fprintf(h, ";schema=%s", c.claimSchema.digest)
+	}
+	tokenTypes := append([]string(nil), c.tokenTypes...)
+	sort.Strings(tokenTypes)
+	fmt.Fprintf(h, ";typ=%q;atprofile=%t;embedded=%t;minrsa=%d;detached=%t",
+		tokenTypes, c.accessTokenProfile, c.rejectEmbeddedKeys, c.minRSAKeyBits, c.detachedPayloads)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashKey writes a secret or the PKIX encoding of a public key to h
+func hashKey(h io.Writer, key interface{}) {
+	if secret, ok := key.([]byte); ok {
+		h.Write(secret)
+		return
+	}
+	if der, err := x509.MarshalPKIXPublicKey(key); err == nil {
+		h.Write(der)
+	} else {
+		fmt.Fprintf(h, "key=%p;", key)
+	}
+}
+
+// CacheBlocklist is a Blocklist backed by a Cache. With a shared cache such as
+// NewRedisCache, revocations apply across replicas.
+type CacheBlocklist struct {
+	cache Cache
+}
+
+// NewCacheBlocklist returns a Blocklist storing revocations in cache
+func NewCacheBlocklist(cache Cache) *CacheBlocklist {
+	return &CacheBlocklist{cache: cache}
+}
+
+// Revoke blocks jti until the given time, normally the token's expiry.
+// A zero until blocks jti indefinitely; a past until is a no-op.
+func (b *CacheBlocklist) Revoke(ctx context.Context, jti string, until time.Time) error {
+	var ttl time.Duration
+	if !until.IsZero() {
+		ttl = time.Until(until)
+		if ttl <= 0 {
+			return nil
+		}
+	}
+	return b.cache.Set(ctx, "jwtauth:revoked:"+jti, []byte{1}, ttl)
+}
+
+// IsRevoked implements Blocklist
+func (b *CacheBlocklist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	_, found, err := b.cache.Get(ctx, "jwtauth:revoked:"+jti)
+	return found, err
+}
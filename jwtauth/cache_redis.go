@@ -0,0 +1,257 @@
+package jwtauth
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// redisMaxBulkBytes bounds the values read from Redis
+const redisMaxBulkBytes = 16 << 20
+
+// RedisCacheConfig configures NewRedisCache
+type RedisCacheConfig struct {
+	Addr        string        // host:port of the Redis server
+	Username    string        // ACL username (optional)
+	Password    string        // Password (optional)
+	DB          int           // Database number
+	Prefix      string        // Prepended to every key, e.g. "myservice:"
+	DialTimeout time.Duration // Connect timeout (default 1s)
+	PoolSize    int           // Idle connections kept open (default 8)
+
+	// TLSConfig enables TLS; ServerName defaults to the host in Addr. AUTH
+	// is sent only after the handshake.
+	TLSConfig *tls.Config
+
+	// AllowPlaintextAuth permits sending Password without TLS to a
+	// non-loopback address, e.g. over a private network or a sidecar
+	AllowPlaintextAuth bool
+}
+
+// redisCache is a Cache backed by Redis using a minimal RESP client
+type redisCache struct {
+	cfg  RedisCacheConfig
+	idle chan *redisConn
+}
+
+// redisConn is a pooled Redis connection
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// NewRedisCache returns a Cache stored in Redis, so caches and revocations
+// are shared by every replica. Connections are opened lazily.
+func NewRedisCache(cfg RedisCacheConfig) (Cache, error) {
+	if cfg.Addr == "" {
+		return nil, errors.New("redis address cannot be empty")
+	}
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis address %q: %w", cfg.Addr, err)
+	}
+	if cfg.Password != "" && cfg.TLSConfig == nil && !cfg.AllowPlaintextAuth && !isLoopbackHost(host) {
+		return nil, fmt.Errorf("redis password for %s requires TLSConfig (or AllowPlaintextAuth)", cfg.Addr)
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = time.Second
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 8
+	}
+	return &redisCache{
+		cfg:  cfg,
+		idle: make(chan *redisConn, cfg.PoolSize),
+	}, nil
+}
+
+// Get implements Cache
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", c.cfg.Prefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	return reply, true, nil
+}
+
+// Set implements Cache
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := c.do(ctx, c.setArgs(key, value, ttl)...)
+	return err
+}
+
+// add stores value only if key is absent (SET NX) and reports whether it
+// was stored
+func (c *redisCache) add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	reply, err := c.do(ctx, append(c.setArgs(key, value, ttl), "NX")...)
+	return reply != nil, err
+}
+
+// setArgs builds a SET command with an optional millisecond TTL
+func (c *redisCache) setArgs(key string, value []byte, ttl time.Duration) []string {
+	args := []string{"SET", c.cfg.Prefix + key, string(value)}
+	if ttl > 0 {
+		ms := ttl.Milliseconds()
+		if ms == 0 {
+			ms = 1
+		}
+		args = append(args, "PX", strconv.FormatInt(ms, 10))
+	}
+	return args
+}
+
+// Delete implements Cache
+func (c *redisCache) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, "DEL", c.cfg.Prefix+key)
+	return err
+}
+
+// do runs one command on a pooled connection and returns the reply payload
+// (nil for a null bulk string)
+func (c *redisCache) do(ctx context.Context, args ...string) ([]byte, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.roundTrip(ctx, args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		conn.Close() // Connection state is unknown after I/O errors
+		return nil, err
+	}
+	c.put(conn)
+	return reply, err
+}
+
+// get returns an idle connection or dials a new one
+func (c *redisCache) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	// The TLS handshake completes within DialContext, before AUTH
+	dialer := &net.Dialer{Timeout: c.cfg.DialTimeout}
+	var netConn net.Conn
+	var err error
+	if c.cfg.TLSConfig != nil {
+		netConn, err = (&tls.Dialer{NetDialer: dialer, Config: c.cfg.TLSConfig}).DialContext(ctx, "tcp", c.cfg.Addr)
+	} else {
+		netConn, err = dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis dial: %w", err)
+	}
+	conn := &redisConn{Conn: netConn, r: bufio.NewReader(netConn)}
+
+	if c.cfg.Password != "" {
+		args := []string{"AUTH", c.cfg.Password}
+		if c.cfg.Username != "" {
+			args = []string{"AUTH", c.cfg.Username, c.cfg.Password}
+		}
+		if _, err := conn.roundTrip(ctx, args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis auth: %w", err)
+		}
+	}
+	if c.cfg.DB != 0 {
+		if _, err := conn.roundTrip(ctx, "SELECT", strconv.Itoa(c.cfg.DB)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis select: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// put returns a healthy connection to the pool
+func (c *redisCache) put(conn *redisConn) {
+	select {
+	case c.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// redisError is an error reply from the server; the connection stays usable
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// roundTrip writes a command and reads its reply
+func (conn *redisConn) roundTrip(ctx context.Context, args ...string) ([]byte, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Second)
+	}
+	conn.SetDeadline(deadline)
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := conn.Write(buf); err != nil {
+		return nil, fmt.Errorf("redis write: %w", err)
+	}
+
+	return conn.readReply()
+}
+
+// readReply parses a simple string, error, integer or bulk string reply
+func (conn *redisConn) readReply() ([]byte, error) {
+	line, err := conn.r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("redis read: %w", err)
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	payload := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+', ':':
+		return []byte(payload), nil
+	case '-':
+		return nil, redisError(payload)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", payload)
+		}
+		if n < 0 {
+			return nil, nil // Null bulk string: key not found
+		}
+		if n > redisMaxBulkBytes {
+			return nil, fmt.Errorf("redis: bulk reply of %d bytes exceeds %d", n, redisMaxBulkBytes)
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(conn.r, data); err != nil {
+			return nil, fmt.Errorf("redis read: %w", err)
+		}
+		if data[n] != '\r' || data[n+1] != '\n' {
+			return nil, fmt.Errorf("redis: bulk reply not terminated by CRLF")
+		}
+		return data[:n], nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,532 @@
+package jwtauth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestMemoryCache tests TTL expiry and the size bound
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+
+	cache.Set(ctx, "a", []byte("1"), 0)
+	cache.Set(ctx, "b", []byte("2"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if _, found, _ := cache.Get(ctx, "b"); found {
+		t.Error("Expected expired entry to be absent")
+	}
+	if value, found, _ := cache.Get(ctx, "a"); !found || string(value) != "1" {
+		t.Errorf("Expected a=1, got %q %v", value, found)
+	}
+
+	cache.Set(ctx, "c", []byte("3"), 0)
+	cache.Set(ctx, "d", []byte("4"), 0)
+	if n := cache.(*memoryCache).size.Load(); n > 2 {
+		t.Errorf("Expected at most 2 entries, got %d", n)
+	}
+
+	cache.Delete(ctx, "d")
+	if _, found, _ := cache.Get(ctx, "d"); found {
+		t.Error("Expected deleted entry to be absent")
+	}
+}
+
+// TestTokenCache tests that validated tokens are served from a shared cache
+func TestTokenCache(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, _ := NewCryptoSigner("ES256", "", ecKey)
+
+	lookups := 0
+	provider := KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		lookups++
+		return &ecKey.PublicKey, nil
+	})
+
+	cache := NewMemoryCache(0)
+	cfg := mustCreateConfig(WithKeyProvider("ES256", provider), WithTokenCache(cache, time.Minute))
+
+	token, _ := SignToken(context.Background(), signer, map[string]interface{}{
+		"sub":   "user123",
+		"roles": []string{"admin"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+
+	for i := 0; i < 3; i++ {
+		claims, err := validateWithTokenCache(context.Background(), token, cfg)
+		if err != nil {
+			t.Fatalf("Validation failed: %v", err)
+		}
+		if claims.Subject != "user123" || claims.Custom["roles"] == nil {
+			t.Errorf("Unexpected claims from cache: %+v", claims)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("Expected signature to be verified once, got %d key lookups", lookups)
+	}
+
+	// A Config trusting a different key must not reuse the cached result
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other := mustCreateConfig(WithKeyProvider("ES256", StaticKeyProvider(&otherKey.PublicKey)), WithTokenCache(cache, time.Minute))
+	if _, err := validateWithTokenCache(context.Background(), token, other); err == nil {
+		t.Error("Expected token cached under another Config to be re-verified and rejected")
+	}
+}
+
+// TestTokenCacheFingerprint tests that Configs differing only in a
+// validation option never share cached results
+func TestTokenCacheFingerprint(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cache := NewMemoryCache(16)
+	base := []ConfigOption{WithHS256(secret), WithAudience("api"), WithTokenCache(cache, time.Minute)}
+	lax := mustCreateConfig(base...)
+
+	for name, opt := range map[string]ConfigOption{
+		"token types":            WithTokenTypes(TokenTypeAccessToken),
+		"access token profile":   WithAccessTokenProfile(),
+		"embedded key rejection": WithEmbeddedKeyRejection(),
+		"minimum RSA key size":   WithMinRSAKeySize(3072),
+		"detached payloads":      WithDetachedPayloads(),
+	} {
+		cfg := mustCreateConfig(append(base[:len(base):len(base)], opt)...)
+		if cfg.fingerprint == lax.fingerprint {
+			t.Errorf("%s: expected a different fingerprint", name)
+		}
+	}
+
+	// A token the strict Config rejects stays rejected after the lax one
+	// cached it
+	strict := mustCreateConfig(append(base[:len(base):len(base)], WithTokenTypes(TokenTypeAccessToken), WithEmbeddedKeyRejection())...)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user-1", "aud": "api", "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["jku"] = "https://evil.example.com/jwks.json"
+	signed, _ := token.SignedString(secret)
+
+	if _, err := validateWithTokenCache(context.Background(), signed, lax); err != nil {
+		t.Fatalf("expected the lax Config to accept the token, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := validateWithTokenCache(context.Background(), signed, strict); err == nil {
+			t.Fatal("expected the strict Config to reject the token cached by the lax one")
+		}
+	}
+}
+
+// TestCachedKeyProviderNegative tests that unknown kids are remembered briefly
+func TestCachedKeyProviderNegative(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	calls := 0
+	provider := NewCachedKeyProvider(KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		calls++
+		if kid != "known" {
+			return nil, ErrKeyNotFound
+		}
+		return &ecKey.PublicKey, nil
+	}), NewMemoryCache(0), time.Minute, nil)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := provider.VerificationKey(ctx, "ES256", "unknown"); err != ErrKeyNotFound {
+			t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		key, err := provider.VerificationKey(ctx, "ES256", "known")
+		if err != nil || !ecKey.PublicKey.Equal(key) {
+			t.Fatalf("Expected cached public key, got %v %v", key, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 upstream calls, got %d", calls)
+	}
+}
+
+// sharedTestCache is an in-memory Cache that the package treats as shared
+// outside the process, like a Redis cache
+type sharedTestCache struct {
+	Cache
+}
+
+// TestCachedKeyProviderShared tests that key entries in a shared cache are
+// authenticated and that HMAC secrets are never written to it
+func TestCachedKeyProviderShared(t *testing.T) {
+	ctx := context.Background()
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	hmacSecret := []byte("test-secret-key-min-32-bytes-long!!")
+	cacheSecret := []byte("key-cache-secret-min-32-bytes-long!")
+	calls := 0
+	upstream := KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		calls++
+		if alg == "HS256" {
+			return hmacSecret, nil
+		}
+		return &ecKey.PublicKey, nil
+	})
+	shared := sharedTestCache{NewMemoryCache(0)}
+	replica1 := NewCachedKeyProvider(upstream, shared, time.Minute, cacheSecret)
+	replica2 := NewCachedKeyProvider(upstream, shared, time.Minute, cacheSecret)
+
+	// Public keys are shared between replicas holding the same secret
+	if _, err := replica1.VerificationKey(ctx, "ES256", "k1"); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := replica2.VerificationKey(ctx, "ES256", "k1"); err != nil || !ecKey.PublicKey.Equal(key) || calls != 1 {
+		t.Fatalf("Expected the key cached by the other replica, got %v %v after %d calls", key, err, calls)
+	}
+
+	// HMAC secrets never reach the shared cache
+	if key, err := replica1.VerificationKey(ctx, "HS256", "h1"); err != nil || !bytes.Equal(key.([]byte), hmacSecret) {
+		t.Fatalf("Expected the HMAC secret, got %v %v", key, err)
+	}
+	if _, found, _ := shared.Get(ctx, "jwtauth:key:HS256|h1"); found {
+		t.Error("Expected the HMAC secret not to be written to the shared cache")
+	}
+
+	// A forged entry is ignored and the key is fetched again
+	attackerKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(&attackerKey.PublicKey)
+	shared.Set(ctx, "jwtauth:key:ES256|k2", append(make([]byte, 32), append([]byte(cachedKeyPKIX), der...)...), time.Minute)
+	calls = 0
+	if key, err := replica1.VerificationKey(ctx, "ES256", "k2"); err != nil || !ecKey.PublicKey.Equal(key) || calls != 1 {
+		t.Errorf("Expected the forged entry to be refetched, got %v %v after %d calls", key, err, calls)
+	}
+
+	// Entries written under another secret do not verify either
+	other := NewCachedKeyProvider(upstream, shared, time.Minute, []byte("another-cache-secret-32-bytes-long!"))
+	calls = 0
+	if _, err := other.VerificationKey(ctx, "ES256", "k1"); err != nil || calls != 1 {
+		t.Errorf("Expected an entry under another secret to be refetched, got %v after %d calls", err, calls)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a shared cache without a secret to panic")
+		}
+	}()
+	NewCachedKeyProvider(upstream, shared, time.Minute, nil)
+}
+
+// TestTokenCacheShared tests that claims are only cached outside the
+// process when encrypted
+func TestTokenCacheShared(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	shared := sharedTestCache{NewMemoryCache(0)}
+	if _, err := NewConfig(WithHS256(secret), WithTokenCache(shared, time.Minute)); err == nil {
+		t.Error("Expected a shared token cache without encryption to be rejected")
+	}
+	cfg := mustCreateConfig(WithHS256(secret), WithTokenCache(shared, time.Minute), WithTokenCacheEncryption(make([]byte, 32)))
+
+	// A planted plaintext entry is not trusted
+	forged := mustSignHS256([]byte("attacker-secret-key-min-32-bytes!!"), jwt.MapClaims{"sub": "admin", "exp": time.Now().Add(time.Hour).Unix()})
+	shared.Set(context.Background(), cfg.tokenCacheKey(forged), []byte(`{"Subject":"admin"}`), time.Minute)
+	if _, err := validateWithTokenCache(context.Background(), forged, cfg); getErrorCode(err) != string(ErrInvalidSignature) {
+		t.Errorf("Expected the planted entry to be ignored, got %v", err)
+	}
+}
+
+// TestCacheBlocklist tests revocations stored in a Cache
+func TestCacheBlocklist(t *testing.T) {
+	ctx := context.Background()
+	blocklist := NewCacheBlocklist(NewMemoryCache(0))
+
+	blocklist.Revoke(ctx, "a", time.Now().Add(time.Minute))
+	blocklist.Revoke(ctx, "b", time.Now().Add(-time.Minute))
+
+	if revoked, _ := blocklist.IsRevoked(ctx, "a"); !revoked {
+		t.Error("Expected a to be revoked")
+	}
+	if revoked, _ := blocklist.IsRevoked(ctx, "b"); revoked {
+		t.Error("Expected already-expired revocation to be a no-op")
+	}
+
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithBlocklist(blocklist))
+	token := mustSignHS256(secret, jwt.MapClaims{"jti": "a", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, _, err := authenticateToken(ctx, token, "", cfg); getErrorCode(err) != string(ErrTokenRevoked) {
+		t.Errorf("Expected TOKEN_REVOKED, got %v", err)
+	}
+}
+
+// TestRedisCache tests the RESP client against a minimal in-process server
+func TestRedisCache(t *testing.T) {
+	addr := startFakeRedis(t, "secret")
+	ctx := context.Background()
+
+	cache, err := NewRedisCache(RedisCacheConfig{Addr: addr, Password: "secret", DB: 2, Prefix: "svc:"})
+	if err != nil {
+		t.Fatalf("NewRedisCache failed: %v", err)
+	}
+
+	if _, found, err := cache.Get(ctx, "k"); err != nil || found {
+		t.Fatalf("Expected miss, got found=%v err=%v", found, err)
+	}
+	if err := cache.Set(ctx, "k", []byte("v\r\nwith crlf"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if value, found, err := cache.Get(ctx, "k"); err != nil || !found || string(value) != "v\r\nwith crlf" {
+		t.Fatalf("Expected stored value, got %q %v %v", value, found, err)
+	}
+	if err := cache.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, found, _ := cache.Get(ctx, "k"); found {
+		t.Error("Expected deleted key to be absent")
+	}
+
+	bad, _ := NewRedisCache(RedisCacheConfig{Addr: addr, Password: "wrong"})
+	if _, _, err := bad.Get(ctx, "k"); err == nil {
+		t.Error("Expected authentication failure")
+	}
+	if _, err := NewRedisCache(RedisCacheConfig{}); err == nil {
+		t.Error("Expected empty address to be rejected")
+	}
+	if _, err := NewRedisCache(RedisCacheConfig{Addr: "redis.internal:6379", Password: "secret"}); err == nil {
+		t.Error("Expected a password without TLS to a remote host to be rejected")
+	}
+	if _, err := NewRedisCache(RedisCacheConfig{Addr: "redis.internal:6379", Password: "secret", AllowPlaintextAuth: true}); err != nil {
+		t.Errorf("Expected AllowPlaintextAuth to permit it, got %v", err)
+	}
+}
+
+// TestRedisCacheTLS tests AUTH and commands over TLS
+func TestRedisCacheTLS(t *testing.T) {
+	// Borrow httptest's certificate for 127.0.0.1
+	certSrv := httptest.NewTLSServer(nil)
+	certs, roots := certSrv.TLS.Certificates, x509.NewCertPool()
+	roots.AddCert(certSrv.Certificate())
+	certSrv.Close()
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: certs})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	addr := serveFakeRedis(lis, "secret")
+	ctx := context.Background()
+
+	cache, err := NewRedisCache(RedisCacheConfig{Addr: addr, Password: "secret", TLSConfig: &tls.Config{RootCAs: roots}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Set(ctx, "k", []byte("v"), time.Minute); err != nil {
+		t.Fatalf("Set over TLS failed: %v", err)
+	}
+	if value, found, err := cache.Get(ctx, "k"); err != nil || !found || string(value) != "v" {
+		t.Fatalf("Expected stored value over TLS, got %q %v %v", value, found, err)
+	}
+
+	// The server certificate is verified
+	untrusted, _ := NewRedisCache(RedisCacheConfig{Addr: addr, Password: "secret", TLSConfig: &tls.Config{}})
+	if _, _, err := untrusted.Get(ctx, "k"); err == nil {
+		t.Error("Expected an untrusted certificate to be rejected")
+	}
+}
+
+// fakeRedisStep is one scripted reply; close ends the connection after it
+// and hang never replies
+type fakeRedisStep struct {
+	reply string
+	close bool
+	hang  bool
+}
+
+// TestRedisCacheErrors tests error replies, malformed and partial replies and
+// timeouts, and that broken connections are not reused
+func TestRedisCacheErrors(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	steps := make(chan fakeRedisStep, 1)
+	var conns int
+	var connsMu sync.Mutex
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			connsMu.Lock()
+			conns++
+			connsMu.Unlock()
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					if _, err := readFakeRedisCommand(r); err != nil {
+						return
+					}
+					step := <-steps
+					if step.hang {
+						io.Copy(io.Discard, r)
+						return
+					}
+					conn.Write([]byte(step.reply))
+					if step.close {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	connections := func() int {
+		connsMu.Lock()
+		defer connsMu.Unlock()
+		return conns
+	}
+
+	cache, _ := NewRedisCache(RedisCacheConfig{Addr: lis.Addr().String()})
+	get := func(step fakeRedisStep, timeout time.Duration) ([]byte, error) {
+		steps <- step
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		value, _, err := cache.Get(ctx, "k")
+		return value, err
+	}
+
+	// Error replies keep the connection
+	_, err = get(fakeRedisStep{reply: "-ERR boom\r\n"}, time.Second)
+	var redisErr redisError
+	if !errors.As(err, &redisErr) {
+		t.Fatalf("Expected a server error, got %v", err)
+	}
+	if value, err := get(fakeRedisStep{reply: "$1\r\nv\r\n"}, time.Second); err != nil || string(value) != "v" {
+		t.Fatalf("Expected a reply on the same connection, got %q %v", value, err)
+	}
+	if n := connections(); n != 1 {
+		t.Errorf("Expected the connection to be reused, got %d connections", n)
+	}
+
+	// Broken replies fail and their connection is replaced
+	for _, tc := range []struct {
+		name string
+		step fakeRedisStep
+	}{
+		{"truncated bulk", fakeRedisStep{reply: "$10\r\nabc", close: true}},
+		{"truncated line", fakeRedisStep{reply: "$1", close: true}},
+		{"unterminated bulk", fakeRedisStep{reply: "$1\r\nvXX"}},
+		{"oversized bulk", fakeRedisStep{reply: "$999999999\r\n"}},
+		{"malformed bulk length", fakeRedisStep{reply: "$x\r\n"}},
+		{"unknown type", fakeRedisStep{reply: "?\r\n"}},
+		{"timeout", fakeRedisStep{hang: true}},
+	} {
+		before := connections()
+		if value, err := get(tc.step, 100*time.Millisecond); err == nil {
+			t.Errorf("%s: expected error, got %q", tc.name, value)
+		}
+		if value, err := get(fakeRedisStep{reply: "$1\r\nv\r\n"}, time.Second); err != nil || string(value) != "v" {
+			t.Errorf("%s: expected recovery on a new connection, got %q %v", tc.name, value, err)
+		}
+		if connections() != before+1 {
+			t.Errorf("%s: expected the broken connection to be replaced", tc.name)
+		}
+	}
+}
+
+// startFakeRedis serves GET/SET/DEL/AUTH/SELECT over RESP and returns its address
+func startFakeRedis(t *testing.T, password string) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	return serveFakeRedis(lis, password)
+}
+
+// serveFakeRedis serves GET/SET/DEL/AUTH/SELECT on lis and returns its address
+func serveFakeRedis(lis net.Listener, password string) string {
+
+	var mu sync.Mutex
+	data := map[string]string{}
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				authed := password == ""
+				for {
+					args, err := readFakeRedisCommand(r)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					switch cmd := strings.ToUpper(args[0]); {
+					case cmd == "AUTH":
+						authed = args[len(args)-1] == password
+						if authed {
+							conn.Write([]byte("+OK\r\n"))
+						} else {
+							conn.Write([]byte("-WRONGPASS invalid password\r\n"))
+						}
+					case !authed:
+						conn.Write([]byte("-NOAUTH Authentication required\r\n"))
+					case cmd == "SELECT":
+						conn.Write([]byte("+OK\r\n"))
+					case cmd == "SET":
+						data[args[1]] = args[2]
+						conn.Write([]byte("+OK\r\n"))
+					case cmd == "GET":
+						if v, ok := data[args[1]]; ok {
+							conn.Write([]byte("$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"))
+						} else {
+							conn.Write([]byte("$-1\r\n"))
+						}
+					case cmd == "DEL":
+						delete(data, args[1])
+						conn.Write([]byte(":1\r\n"))
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+
+	return lis.Addr().String()
+}
+
+// readFakeRedisCommand reads one RESP array of bulk strings
+func readFakeRedisCommand(r *bufio.Reader) ([]string, error) {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+	args := make([]string, n)
+	for i := range args {
+		lenLine, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(lenLine[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
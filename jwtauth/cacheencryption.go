@@ -0,0 +1,77 @@
+package jwtauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// cachedClaimsVersion prefixes encrypted token cache entries
+const cachedClaimsVersion = 1
+
+// WithTokenCacheEncryption encrypts the claims WithTokenCache stores with
+// AES-GCM, so a compromised shared cache (e.g. Redis) does not leak identity
+// data. Keys must be 16, 24 or 32 bytes. The first key encrypts and every key
+// decrypts: rotate by prepending a new key and dropping the old one once the
+// cache TTL has passed. Entries are bound to their cache key, and entries
+// that do not decrypt (older plaintext, retired keys, tampering) are treated
+// as misses and the token is validated again.
+func WithTokenCacheEncryption(keys ...[]byte) ConfigOption {
+	return func(c *Config) error {
+		if len(keys) == 0 {
+			return fmt.Errorf("token cache encryption requires at least one key")
+		}
+		aeads := make([]cipher.AEAD, 0, len(keys))
+		for i, key := range keys {
+			block, err := aes.NewCipher(key)
+			if err != nil {
+				return fmt.Errorf("token cache encryption key %d: %w", i, err)
+			}
+			aead, err := cipher.NewGCM(block)
+			if err != nil {
+				return fmt.Errorf("token cache encryption key %d: %w", i, err)
+			}
+			aeads = append(aeads, aead)
+		}
+		c.tokenCacheAEADs = aeads
+		return nil
+	}
+}
+
+// sealCachedClaims encrypts serialized claims for the cache entry at key
+func (c *Config) sealCachedClaims(key string, data []byte) ([]byte, error) {
+	if c.tokenCacheAEADs == nil {
+		return data, nil
+	}
+	aead := c.tokenCacheAEADs[0]
+
+	out := make([]byte, 1+aead.NonceSize(), 1+aead.NonceSize()+len(data)+aead.Overhead())
+	out[0] = cachedClaimsVersion
+	if _, err := rand.Read(out[1:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(out, out[1:], data, []byte(key)), nil
+}
+
+// openCachedClaims decrypts a cache entry sealed by sealCachedClaims
+func (c *Config) openCachedClaims(key string, data []byte) ([]byte, error) {
+	if c.tokenCacheAEADs == nil {
+		return data, nil
+	}
+	if len(data) == 0 || data[0] != cachedClaimsVersion {
+		return nil, errors.New("cache entry is not encrypted")
+	}
+
+	for _, aead := range c.tokenCacheAEADs {
+		if len(data) < 1+aead.NonceSize()+aead.Overhead() {
+			continue
+		}
+		nonce, ciphertext := data[1:1+aead.NonceSize()], data[1+aead.NonceSize():]
+		if plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(key)); err == nil {
+			return plaintext, nil
+		}
+	}
+	return nil, errors.New("cache entry could not be decrypted")
+}
@@ -0,0 +1,74 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestTokenCacheEncryption tests AES-GCM encryption of cached claims
+func TestTokenCacheEncryption(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	cache := NewMemoryCache(0)
+
+	cfg := mustCreateConfig(WithHS256(secret), WithTokenCache(cache, time.Minute), WithTokenCacheEncryption(oldKey))
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "email": "user@example.com", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := validateWithTokenCache(ctx, token, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	key := cfg.tokenCacheKey(token)
+	stored, found, _ := cache.Get(ctx, key)
+	if !found {
+		t.Fatal("Expected claims to be cached")
+	}
+	if bytes.Contains(stored, []byte("user@example.com")) || bytes.Contains(stored, []byte("user-1")) {
+		t.Error("Expected cached claims to be encrypted")
+	}
+
+	// Rotation: a Config listing the new key first still reads old entries
+	rotated := mustCreateConfig(WithHS256(secret), WithTokenCache(cache, time.Minute), WithTokenCacheEncryption(newKey, oldKey))
+	if _, err := rotated.openCachedClaims(key, stored); err != nil {
+		t.Errorf("Expected rotated keys to decrypt old entries, got %v", err)
+	}
+	retired := mustCreateConfig(WithHS256(secret), WithTokenCache(cache, time.Minute), WithTokenCacheEncryption(newKey))
+	if _, err := retired.openCachedClaims(key, stored); err == nil {
+		t.Error("Expected retired key entries to be unreadable")
+	}
+
+	// Entries are bound to their cache key and tamper-evident
+	if _, err := cfg.openCachedClaims("jwtauth:token:other", stored); err == nil {
+		t.Error("Expected entry moved to another key to be rejected")
+	}
+	tampered := append([]byte(nil), stored...)
+	tampered[len(tampered)-1] ^= 1
+	cache.Set(ctx, key, tampered, time.Minute)
+	claims, err := validateWithTokenCache(ctx, token, cfg)
+	if err != nil || claims.Custom["email"] != "user@example.com" {
+		t.Fatalf("Expected tampered entry to fall back to validation, got %v, %v", claims, err)
+	}
+
+	// Cache hits decrypt back to the claims
+	claims, err = validateWithTokenCache(ctx, token, cfg)
+	if err != nil || claims.Subject != "user-1" {
+		t.Errorf("Expected cached claims, got %v, %v", claims, err)
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		if _, err := NewConfig(WithHS256(secret), WithTokenCacheEncryption(oldKey)); err == nil {
+			t.Error("Expected encryption without a token cache to be rejected")
+		}
+		if _, err := NewConfig(WithHS256(secret), WithTokenCache(cache, time.Minute), WithTokenCacheEncryption([]byte("short"))); err == nil {
+			t.Error("Expected invalid key size to be rejected")
+		}
+		if _, err := NewConfig(WithHS256(secret), WithTokenCache(cache, time.Minute), WithTokenCacheEncryption()); err == nil {
+			t.Error("Expected missing key to be rejected")
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"math"
+	"slices"
+	"time"
+)
+
+// canaryPolicy holds the stricter configuration rolled out by WithCanary
+type canaryPolicy struct {
+	fraction  float64
+	threshold uint64 // Tokens hashing below this are enforced
+	opts      []ConfigOption
+	cfg       *Config
+}
+
+// WithCanary rolls out stricter validation gradually. The options are
+// applied on top of the rest of the configuration; a deterministic,
+// hash-based fraction of tokens (0 to 1) is validated with the result and
+// rejected on failure, while all other requests are checked in report-only
+// mode: violations are logged as "canary" security events and the request
+// proceeds under the base configuration.
+//
+//	jwtauth.WithCanary(0.05,
+//		jwtauth.WithAudience("orders-api"),
+//		jwtauth.WithDelegationValidation(),
+//	)
+//
+// Report-only checks verify the token a second time, so expect extra CPU for
+// the duration of the rollout. Raise the fraction to 1 to enforce everywhere,
+// then move the options into the base configuration.
+func WithCanary(fraction float64, opts ...ConfigOption) ConfigOption {
+	return func(c *Config) error {
+		if math.IsNaN(fraction) || fraction < 0 || fraction > 1 {
+			return fmt.Errorf("canary fraction must be between 0 and 1")
+		}
+		if len(opts) == 0 {
+			return fmt.Errorf("canary requires at least one option")
+		}
+
+		threshold := uint64(fraction * math.MaxUint64)
+		if fraction == 1 {
+			threshold = math.MaxUint64
+		}
+		c.canary = &canaryPolicy{fraction: fraction, threshold: threshold, opts: opts}
+		return nil
+	}
+}
+
+// CanaryFraction returns the fraction of tokens enforced with the canary
+// options (0 when no canary is configured)
+func (c *Config) CanaryFraction() float64 {
+	if c.canary == nil {
+		return 0
+	}
+	return c.canary.fraction
+}
+
+// buildCanary derives the canary configuration from the final base settings.
+// Shared state (blocklist, caches, rate limiters, anomaly detector) is reused.
+func (c *Config) buildCanary() error {
+	strict := *c
+	strict.canary = nil
+	strict.validators = maps.Clone(c.validators)
+	strict.requiredClaims = slices.Clone(c.requiredClaims)
+	strict.claimRequirements = slices.Clone(c.claimRequirements)
+	strict.audiences = slices.Clone(c.audiences)
+	strict.issuers = slices.Clone(c.issuers)
+	strict.tokenTypes = slices.Clone(c.tokenTypes)
+	strict.trustedProxies = slices.Clone(c.trustedProxies)
+
+	for _, opt := range c.canary.opts {
+		if err := opt(&strict); err != nil {
+			return NewValidationError(ErrConfigError, fmt.Sprintf("configuration error: canary: %v", err), err)
+		}
+	}
+	if strict.canary != nil {
+		return NewValidationError(ErrConfigError, "configuration error: canary options cannot contain WithCanary", nil)
+	}
+	if err := strict.finalize(); err != nil {
+		return err
+	}
+
+	c.canary.cfg = &strict
+	return nil
+}
+
+// enforces reports whether the token falls in the enforced fraction
+func (p *canaryPolicy) enforces(token string) bool {
+	if p.threshold == math.MaxUint64 {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(token))
+	return h.Sum64() < p.threshold
+}
+
+// report checks an already authenticated token against the canary
+// configuration without enforcing it, logging any violation
+func (p *canaryPolicy) report(ctx context.Context, cfg *Config, token, requestID string, claims *Claims, tenant string) {
+	strict := p.cfg
+	_, err := parseAndValidateJWTContext(ctx, token, strict)
+	if err == nil {
+		err = checkIPBinding(ctx, strict, claims)
+	}
+	if err == nil {
+		err = checkDeviceBinding(ctx, strict, claims)
+	}
+	if err == nil || cfg.Logger() == nil {
+		return
+	}
+
+	clientIP, _ := GetClientIP(ctx)
+	logSecurityEvent(cfg.Logger(), SecurityEvent{
+		EventType:     "canary",
+		Timestamp:     time.Now(),
+		RequestID:     requestID,
+		ClientIP:      clientIP,
+		UserID:        claims.Subject,
+		TenantID:      tenant,
+		Algorithm:     extractAlgorithmFromToken(token),
+		FailureReason: getErrorCode(err),
+		TokenPreview:  token,
+	})
+}
@@ -0,0 +1,116 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestCanaryRollout tests enforced and report-only canary validation
+func TestCanaryRollout(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	var logs bytes.Buffer
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		WithCanary(0.3, WithAudience("orders-api")),
+	)
+
+	// Tokens without the audience fail only in the enforced fraction
+	const n = 1000
+	rejected := 0
+	for i := 0; i < n; i++ {
+		token := mustSignHS256(secret, jwt.MapClaims{
+			"sub": fmt.Sprintf("user-%d", i),
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		_, _, err := authenticateToken(context.Background(), token, "req", cfg)
+		if err != nil {
+			if getErrorCode(err) != string(ErrInvalidAudience) {
+				t.Fatalf("Expected INVALID_AUDIENCE, got %v", err)
+			}
+			rejected++
+		}
+
+		// Selection is deterministic per token
+		_, _, again := authenticateToken(context.Background(), token, "req", cfg)
+		if (err == nil) != (again == nil) {
+			t.Fatal("Expected the same token to get the same canary decision")
+		}
+	}
+	if rejected < 230 || rejected > 370 {
+		t.Errorf("Expected about 30%% of tokens enforced, got %d/%d", rejected, n)
+	}
+
+	// The rest were reported
+	reported := strings.Count(logs.String(), "canary validation failed (report-only)")
+	if reported != 2*(n-rejected) {
+		t.Errorf("Expected %d report-only events, got %d", 2*(n-rejected), reported)
+	}
+	if !strings.Contains(logs.String(), "failure_reason=INVALID_AUDIENCE") {
+		t.Error("Expected report-only events to carry the failure reason")
+	}
+
+	// Compliant tokens pass everywhere without reports
+	logs.Reset()
+	for i := 0; i < 50; i++ {
+		token := mustSignHS256(secret, jwt.MapClaims{
+			"sub": fmt.Sprintf("user-%d", i),
+			"aud": "orders-api",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		if _, _, err := authenticateToken(context.Background(), token, "req", cfg); err != nil {
+			t.Fatalf("Expected compliant token to pass, got %v", err)
+		}
+	}
+	if strings.Contains(logs.String(), "canary") {
+		t.Errorf("Expected no canary events for compliant tokens, got %s", logs.String())
+	}
+}
+
+// TestCanaryFullEnforcement tests that fraction 1 enforces every token
+func TestCanaryFullEnforcement(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithCanary(1, WithRequiredClaims("tid")))
+
+	for i := 0; i < 20; i++ {
+		token := mustSignHS256(secret, jwt.MapClaims{"sub": fmt.Sprintf("user-%d", i), "exp": time.Now().Add(time.Hour).Unix()})
+		if _, _, err := authenticateToken(context.Background(), token, "req", cfg); err == nil {
+			t.Fatal("Expected every token to be enforced")
+		}
+	}
+	if cfg.CanaryFraction() != 1 {
+		t.Errorf("Expected fraction 1, got %v", cfg.CanaryFraction())
+	}
+	if len(cfg.RequiredClaims()) != 0 {
+		t.Errorf("Expected base configuration to be unchanged, got %v", cfg.RequiredClaims())
+	}
+}
+
+// TestWithCanaryValidation tests canary option validation
+func TestWithCanaryValidation(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	tests := []struct {
+		name string
+		opt  ConfigOption
+	}{
+		{"negative fraction", WithCanary(-0.1, WithAudience("api"))},
+		{"fraction above one", WithCanary(1.5, WithAudience("api"))},
+		{"no options", WithCanary(0.5)},
+		{"invalid option", WithCanary(0.5, WithAudience(""))},
+		{"nested canary", WithCanary(0.5, WithCanary(0.1, WithAudience("api")))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConfig(WithHS256(secret), tt.opt); err == nil {
+				t.Error("Expected configuration to be rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package jwtauth
+
+import "fmt"
+
+// WithClaimAllowlist keeps only the named custom claims in the Claims stored
+// in the request context; all other custom claims are dropped after
+// validation. This bounds per-request memory and keeps sensitive claims
+// (emails, national IDs) away from downstream code that might log them.
+//
+// Standard claims (sub, iss, aud, exp, nbf, iat, jti) have typed fields and
+// are always kept, so listing them is allowed but has no effect. Policies
+// that read claims (binding, revocation, tenants, delegation, schemas) see
+// the full token before it is minimized.
+func WithClaimAllowlist(names ...string) ConfigOption {
+	return func(c *Config) error {
+		if len(names) == 0 {
+			return fmt.Errorf("claim allowlist requires at least one claim")
+		}
+		c.claimAllowlist = make(map[string]bool, len(names))
+		for _, name := range names {
+			if name == "" {
+				return fmt.Errorf("claim allowlist names cannot be empty")
+			}
+			c.claimAllowlist[name] = true
+		}
+		return nil
+	}
+}
+
+// minimizeClaims returns claims with custom claims outside the allowlist
+// removed. Claims may be shared with caches, so a copy is returned.
+func (c *Config) minimizeClaims(claims *Claims) *Claims {
+	if c.claimAllowlist == nil || claims == nil {
+		return claims
+	}
+
+	minimized := *claims
+	minimized.Custom = make(map[string]interface{}, len(c.claimAllowlist))
+	for name, value := range claims.Custom {
+		if c.claimAllowlist[name] {
+			minimized.Custom[name] = value
+		}
+	}
+	return &minimized
+}
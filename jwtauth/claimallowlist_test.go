@@ -0,0 +1,72 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestClaimAllowlist tests that only allowlisted custom claims reach the context
+func TestClaimAllowlist(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithClaimAllowlist("sub", "scope"),
+		WithTenantClaim("tenant"),
+		WithTokenCache(NewMemoryCache(100), time.Minute),
+	)
+	token := mustSignHS256(secret, jwt.MapClaims{
+		"sub":    "user-1",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"scope":  "orders.read",
+		"tenant": "acme",
+		"email":  "user@example.com",
+		"ssn":    "078-05-1120",
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	router.GET("/protected", func(c *gin.Context) {
+		claims, _ := GetClaims(c.Request.Context())
+		tenant, _ := GetTenant(c.Request.Context())
+		if claims.Subject != "user-1" || claims.ExpiresAt.IsZero() {
+			t.Errorf("Expected standard claims to be kept, got %+v", claims)
+		}
+		if len(claims.Custom) != 1 || claims.Custom["scope"] != "orders.read" {
+			t.Errorf("Expected only scope in custom claims, got %v", claims.Custom)
+		}
+		if tenant != "acme" {
+			t.Errorf("Expected tenant from the full token, got %q", tenant)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	// The second request is served from the token cache, which must still
+	// hold the full claims
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	full := &Claims{Subject: "user-1", Custom: map[string]interface{}{"scope": "a", "email": "b"}}
+	if minimized := cfg.minimizeClaims(full); len(minimized.Custom) != 1 || len(full.Custom) != 2 {
+		t.Errorf("Expected a minimized copy, got %v (original %v)", minimized.Custom, full.Custom)
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithClaimAllowlist()); err == nil {
+		t.Error("Expected empty allowlist to be rejected")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithClaimAllowlist("scope", "")); err == nil {
+		t.Error("Expected empty claim name to be rejected")
+	}
+}
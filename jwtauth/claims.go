@@ -1,15 +1,84 @@
 package jwtauth
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 // Claims represents parsed and validated JWT claims
 type Claims struct {
-	Subject   string                 // User identifier (sub claim)
-	Issuer    string                 // Token issuer (iss claim)
-	Audience  string                 // Intended audience (aud claim)
+	Subject string // User identifier (sub claim)
+	Issuer  string // Token issuer (iss claim)
+	// Audience is the aud claim when it names exactly one audience, and
+	// empty otherwise.
+	//
+	// Deprecated: Use Audiences or HasAudience, which also cover tokens
+	// issued to several audiences.
+	Audience  string
 	ExpiresAt time.Time              // Expiration time (exp claim)
 	NotBefore time.Time              // Not-before time (nbf claim)
 	IssuedAt  time.Time              // Issue time (iat claim)
 	JWTID     string                 // JWT ID (jti claim)
 	Custom    map[string]interface{} // Custom application-specific claims
+
+	Provenance Provenance // Validator that authenticated the token
+
+	// Permissions are mapped from IdP-specific claims by
+	// WithPermissionMapping; nil without it
+	Permissions []string
+
+	Audiences []string // Intended audiences (aud claim, string or array form)
+
+	degraded      bool // Accepted under a key outage policy (IsDegraded)
+	matchedConfig int  // 1-based position of the accepting AnyOf config; 0 outside AnyOf
+}
+
+// HasAudience reports whether the token was issued to aud
+func (c *Claims) HasAudience(aud string) bool {
+	return aud != "" && slices.Contains(c.Audiences, aud)
+}
+
+// Get returns the value of a claim by its JWT name. Standard claims are
+// returned from their typed fields; all other names are looked up in Custom.
+// "aud" is a string for a single audience and a []interface{} of strings,
+// as decoded from JSON, for several.
+func (c *Claims) Get(name string) (interface{}, bool) {
+	switch name {
+	case "sub":
+		return c.Subject, c.Subject != ""
+	case "iss":
+		return c.Issuer, c.Issuer != ""
+	case "aud":
+		if len(c.Audiences) == 1 {
+			return c.Audiences[0], true
+		}
+		if len(c.Audiences) == 0 {
+			return nil, false
+		}
+		auds := make([]interface{}, len(c.Audiences))
+		for i, aud := range c.Audiences {
+			auds[i] = aud
+		}
+		return auds, true
+	case "jti":
+		return c.JWTID, c.JWTID != ""
+	case "exp":
+		return c.ExpiresAt, !c.ExpiresAt.IsZero()
+	case "nbf":
+		return c.NotBefore, !c.NotBefore.IsZero()
+	case "iat":
+		return c.IssuedAt, !c.IssuedAt.IsZero()
+	}
+	value, ok := c.Custom[name]
+	return value, ok
+}
+
+// GetString returns a claim value as a string, or false if absent or not a string
+func (c *Claims) GetString(name string) (string, bool) {
+	value, ok := c.Get(name)
+	if !ok {
+		return "", false
+	}
+	s, ok := value.(string)
+	return s, ok
 }
@@ -0,0 +1,662 @@
+package jwtauth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SchemaViolation describes the first claim that fails the claims schema.
+// It is the Internal error of CLAIMS_SCHEMA_VIOLATION validation errors.
+type SchemaViolation struct {
+	Path   string // JSON Pointer to the failing value, e.g. "/roles/0" ("" is the whole claims set)
+	Reason string
+}
+
+// Error implements the error interface
+func (v *SchemaViolation) Error() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, v.Reason)
+}
+
+// WithClaimSchema validates the claims set of every token against a JSON
+// Schema document, compiled when the configuration is built. It is meant for
+// contract-testing identity provider payloads: the schema describes the
+// custom claims a service relies on, and tokens that drift from it fail with
+// CLAIMS_SCHEMA_VIOLATION naming the JSON Pointer of the failing value.
+//
+// The supported subset of draft 2020-12 covers type, enum, const, object
+// keywords (properties, required, additionalProperties, patternProperties,
+// minProperties, maxProperties), array keywords (items, minItems, maxItems,
+// uniqueItems), string keywords (minLength, maxLength, pattern), numeric
+// keywords (minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// multipleOf), allOf, anyOf, oneOf, not and local $ref ("#/$defs/..."). Other
+// standard validation keywords are rejected rather than silently ignored;
+// annotations such as title, description and format are ignored.
+func WithClaimSchema(schema []byte) ConfigOption {
+	return func(c *Config) error {
+		compiled, err := compileClaimSchema(schema)
+		if err != nil {
+			return fmt.Errorf("claim schema: %w", err)
+		}
+		c.claimSchema = compiled
+		return nil
+	}
+}
+
+// validateClaimSchema checks the claims set against the configured schema
+func validateClaimSchema(mapClaims jwt.MapClaims, cfg *Config) error {
+	if cfg.claimSchema == nil {
+		return nil
+	}
+	if v := cfg.claimSchema.root.validate(map[string]interface{}(mapClaims), "", 0); v != nil {
+		return NewValidationError(ErrClaimsSchemaViolation, "claims schema violation at "+v.Error(), v)
+	}
+	return nil
+}
+
+// maxSchemaDepth bounds $ref recursion during validation
+const maxSchemaDepth = 64
+
+// claimSchema is a compiled claims schema
+type claimSchema struct {
+	root   *schemaNode
+	digest string // Hash of the schema document, part of the config fingerprint
+}
+
+// schemaNode is a compiled (sub)schema
+type schemaNode struct {
+	reject bool // The false schema
+
+	types    []string
+	enum     []interface{}
+	constVal interface{}
+	hasConst bool
+
+	properties        map[string]*schemaNode
+	required          []string
+	additional        *schemaNode
+	patternProperties []patternSchema
+	minProperties     *int
+	maxProperties     *int
+
+	items       *schemaNode
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	allOf []*schemaNode
+	anyOf []*schemaNode
+	oneOf []*schemaNode
+	not   *schemaNode
+	ref   *schemaNode
+}
+
+// patternSchema is a patternProperties entry
+type patternSchema struct {
+	pattern *regexp.Regexp
+	schema  *schemaNode
+}
+
+// schemaUnsupported are standard validation keywords this validator lacks
+var schemaUnsupported = map[string]bool{
+	"if": true, "then": true, "else": true, "prefixItems": true, "contains": true,
+	"minContains": true, "maxContains": true, "dependentRequired": true, "dependentSchemas": true,
+	"dependencies": true, "propertyNames": true, "unevaluatedProperties": true,
+	"unevaluatedItems": true, "$dynamicRef": true, "$dynamicAnchor": true, "$anchor": true,
+	"$recursiveRef": true, "$recursiveAnchor": true, "additionalItems": true,
+}
+
+var schemaTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// schemaCompiler compiles a schema document, resolving local references
+type schemaCompiler struct {
+	doc  interface{}
+	refs map[string]*schemaNode
+}
+
+// compileClaimSchema parses and compiles a JSON Schema document
+func compileClaimSchema(schema []byte) (*claimSchema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("schema must be a JSON object")
+	}
+
+	sc := &schemaCompiler{doc: doc, refs: make(map[string]*schemaNode)}
+	root, err := sc.compileRef("#")
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(schema)
+	return &claimSchema{root: root, digest: hex.EncodeToString(sum[:])}, nil
+}
+
+// compileRef compiles the subschema a local reference points to. Nodes are
+// registered before compiling so recursive references resolve.
+func (sc *schemaCompiler) compileRef(ref string) (*schemaNode, error) {
+	if node, ok := sc.refs[ref]; ok {
+		return node, nil
+	}
+	if ref != "#" && !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("$ref %q: only local references are supported", ref)
+	}
+
+	target := sc.doc
+	if ref != "#" {
+		for _, token := range strings.Split(ref[2:], "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			switch t := target.(type) {
+			case map[string]interface{}:
+				target = t[token]
+			case []interface{}:
+				i, err := strconv.Atoi(token)
+				if err != nil || i < 0 || i >= len(t) {
+					return nil, fmt.Errorf("$ref %q does not resolve", ref)
+				}
+				target = t[i]
+			default:
+				target = nil
+			}
+			if target == nil {
+				return nil, fmt.Errorf("$ref %q does not resolve", ref)
+			}
+		}
+	}
+
+	node := &schemaNode{}
+	sc.refs[ref] = node
+	if err := sc.compileInto(node, target, ref); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// compile compiles an inline subschema
+func (sc *schemaCompiler) compile(raw interface{}, at string) (*schemaNode, error) {
+	node := &schemaNode{}
+	if err := sc.compileInto(node, raw, at); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// compileInto compiles raw into node; at locates raw in errors
+func (sc *schemaCompiler) compileInto(node *schemaNode, raw interface{}, at string) error {
+	if b, ok := raw.(bool); ok {
+		node.reject = !b
+		return nil
+	}
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return &schemaError{at: at, err: errors.New("schema must be an object or boolean")}
+	}
+
+	for _, k := range sortedKeys(obj) {
+		v := obj[k]
+		loc := at + "/" + k
+		var err error
+		switch k {
+		case "type":
+			node.types, err = schemaTypeList(v)
+		case "enum":
+			values, ok := v.([]interface{})
+			if !ok || len(values) == 0 {
+				err = fmt.Errorf("must be a non-empty array")
+			}
+			node.enum = values
+		case "const":
+			node.constVal, node.hasConst = v, true
+		case "properties":
+			node.properties, err = sc.compileMap(v, loc)
+		case "required":
+			node.required, err = schemaStringList(v)
+		case "additionalProperties":
+			node.additional, err = sc.compile(v, loc)
+		case "patternProperties":
+			var props map[string]*schemaNode
+			if props, err = sc.compileMap(v, loc); err == nil {
+				for _, p := range sortedKeys(props) {
+					re, rerr := regexp.Compile(p)
+					if rerr != nil {
+						return &schemaError{at: loc + "/" + p, err: rerr}
+					}
+					node.patternProperties = append(node.patternProperties, patternSchema{pattern: re, schema: props[p]})
+				}
+			}
+		case "minProperties":
+			node.minProperties, err = schemaCount(v)
+		case "maxProperties":
+			node.maxProperties, err = schemaCount(v)
+		case "items":
+			node.items, err = sc.compile(v, loc)
+		case "minItems":
+			node.minItems, err = schemaCount(v)
+		case "maxItems":
+			node.maxItems, err = schemaCount(v)
+		case "uniqueItems":
+			node.uniqueItems, ok = v.(bool)
+			if !ok {
+				err = fmt.Errorf("must be a boolean")
+			}
+		case "minLength":
+			node.minLength, err = schemaCount(v)
+		case "maxLength":
+			node.maxLength, err = schemaCount(v)
+		case "pattern":
+			s, ok := v.(string)
+			if !ok {
+				err = fmt.Errorf("must be a string")
+				break
+			}
+			node.pattern, err = regexp.Compile(s)
+		case "minimum":
+			node.minimum, err = schemaNumber(v)
+		case "maximum":
+			node.maximum, err = schemaNumber(v)
+		case "exclusiveMinimum":
+			node.exclusiveMinimum, err = schemaNumber(v)
+		case "exclusiveMaximum":
+			node.exclusiveMaximum, err = schemaNumber(v)
+		case "multipleOf":
+			if node.multipleOf, err = schemaNumber(v); err == nil && *node.multipleOf <= 0 {
+				err = fmt.Errorf("must be greater than 0")
+			}
+		case "allOf":
+			node.allOf, err = sc.compileList(v, loc)
+		case "anyOf":
+			node.anyOf, err = sc.compileList(v, loc)
+		case "oneOf":
+			node.oneOf, err = sc.compileList(v, loc)
+		case "not":
+			node.not, err = sc.compile(v, loc)
+		case "$ref":
+			ref, ok := v.(string)
+			if !ok {
+				err = fmt.Errorf("must be a string")
+				break
+			}
+			node.ref, err = sc.compileRef(ref)
+		default:
+			// Annotations ($defs, title, format, ...) and vendor keywords are ignored
+			if schemaUnsupported[k] {
+				err = fmt.Errorf("keyword is not supported")
+			}
+		}
+		if err != nil {
+			var located *schemaError
+			if errors.As(err, &located) {
+				return err // Raised by a subschema
+			}
+			return &schemaError{at: loc, err: err}
+		}
+	}
+	return nil
+}
+
+// schemaError locates a compile error in the schema document
+type schemaError struct {
+	at  string
+	err error
+}
+
+func (e *schemaError) Error() string { return e.at + ": " + e.err.Error() }
+func (e *schemaError) Unwrap() error { return e.err }
+
+// compileMap compiles an object of subschemas
+func (sc *schemaCompiler) compileMap(raw interface{}, at string) (map[string]*schemaNode, error) {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be an object")
+	}
+	nodes := make(map[string]*schemaNode, len(obj))
+	for name, sub := range obj {
+		node, err := sc.compile(sub, at+"/"+name)
+		if err != nil {
+			return nil, err
+		}
+		nodes[name] = node
+	}
+	return nodes, nil
+}
+
+// compileList compiles a non-empty array of subschemas
+func (sc *schemaCompiler) compileList(raw interface{}, at string) ([]*schemaNode, error) {
+	list, ok := raw.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("must be a non-empty array")
+	}
+	nodes := make([]*schemaNode, len(list))
+	for i, sub := range list {
+		node, err := sc.compile(sub, fmt.Sprintf("%s/%d", at, i))
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = node
+	}
+	return nodes, nil
+}
+
+func schemaTypeList(v interface{}) ([]string, error) {
+	var types []string
+	switch t := v.(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		list, err := schemaStringList(t)
+		if err != nil {
+			return nil, err
+		}
+		types = list
+	default:
+		return nil, fmt.Errorf("must be a string or array of strings")
+	}
+	for _, t := range types {
+		if !schemaTypes[t] {
+			return nil, fmt.Errorf("unknown type %q", t)
+		}
+	}
+	return types, nil
+}
+
+func schemaStringList(v interface{}) ([]string, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be an array of strings")
+	}
+	out := make([]string, len(list))
+	for i, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be an array of strings")
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+func schemaCount(v interface{}) (*int, error) {
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return nil, fmt.Errorf("must be a non-negative integer")
+	}
+	n := int(f)
+	return &n, nil
+}
+
+func schemaNumber(v interface{}) (*float64, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("must be a number")
+	}
+	return &f, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validate returns the first violation of value against the schema
+func (n *schemaNode) validate(value interface{}, path string, depth int) *SchemaViolation {
+	if depth > maxSchemaDepth {
+		return &SchemaViolation{Path: path, Reason: "schema recursion too deep"}
+	}
+	if n.reject {
+		return &SchemaViolation{Path: path, Reason: "value is not allowed"}
+	}
+	fail := func(format string, args ...interface{}) *SchemaViolation {
+		return &SchemaViolation{Path: path, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	if n.ref != nil {
+		if v := n.ref.validate(value, path, depth+1); v != nil {
+			return v
+		}
+	}
+	if len(n.types) > 0 && !schemaTypeMatches(n.types, value) {
+		return fail("expected %s, got %s", strings.Join(n.types, " or "), schemaTypeOf(value))
+	}
+	if n.hasConst && !schemaEqual(value, n.constVal) {
+		return fail("value does not match const")
+	}
+	if n.enum != nil && !slices.ContainsFunc(n.enum, func(e interface{}) bool { return schemaEqual(e, value) }) {
+		return fail("value is not one of the allowed values")
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if violation := n.validateObject(v, path, depth); violation != nil {
+			return violation
+		}
+	case []interface{}:
+		if violation := n.validateArray(v, path, depth); violation != nil {
+			return violation
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n.minLength != nil && length < *n.minLength {
+			return fail("string is shorter than %d characters", *n.minLength)
+		}
+		if n.maxLength != nil && length > *n.maxLength {
+			return fail("string is longer than %d characters", *n.maxLength)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			return fail("string does not match pattern %q", n.pattern.String())
+		}
+	default:
+		if f, ok := toFloat(value); ok {
+			if violation := n.validateNumber(f, path); violation != nil {
+				return violation
+			}
+		}
+	}
+
+	for _, sub := range n.allOf {
+		if v := sub.validate(value, path, depth+1); v != nil {
+			return v
+		}
+	}
+	if n.anyOf != nil {
+		matched := false
+		for _, sub := range n.anyOf {
+			if sub.validate(value, path, depth+1) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fail("value does not match any anyOf schema")
+		}
+	}
+	if n.oneOf != nil {
+		matches := 0
+		for _, sub := range n.oneOf {
+			if sub.validate(value, path, depth+1) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fail("value matches %d oneOf schemas, expected exactly 1", matches)
+		}
+	}
+	if n.not != nil && n.not.validate(value, path, depth+1) == nil {
+		return fail("value matches a schema it must not match")
+	}
+	return nil
+}
+
+func (n *schemaNode) validateObject(obj map[string]interface{}, path string, depth int) *SchemaViolation {
+	for _, name := range n.required {
+		if _, ok := obj[name]; !ok {
+			return &SchemaViolation{Path: path, Reason: fmt.Sprintf("missing required property %q", name)}
+		}
+	}
+	if n.minProperties != nil && len(obj) < *n.minProperties {
+		return &SchemaViolation{Path: path, Reason: fmt.Sprintf("object has fewer than %d properties", *n.minProperties)}
+	}
+	if n.maxProperties != nil && len(obj) > *n.maxProperties {
+		return &SchemaViolation{Path: path, Reason: fmt.Sprintf("object has more than %d properties", *n.maxProperties)}
+	}
+
+	for _, name := range sortedKeys(obj) {
+		child := path + "/" + escapePointerToken(name)
+		matched := false
+		if sub, ok := n.properties[name]; ok {
+			matched = true
+			if v := sub.validate(obj[name], child, depth+1); v != nil {
+				return v
+			}
+		}
+		for _, p := range n.patternProperties {
+			if p.pattern.MatchString(name) {
+				matched = true
+				if v := p.schema.validate(obj[name], child, depth+1); v != nil {
+					return v
+				}
+			}
+		}
+		if !matched && n.additional != nil {
+			if n.additional.reject {
+				return &SchemaViolation{Path: child, Reason: "additional property is not allowed"}
+			}
+			if v := n.additional.validate(obj[name], child, depth+1); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func (n *schemaNode) validateArray(items []interface{}, path string, depth int) *SchemaViolation {
+	if n.minItems != nil && len(items) < *n.minItems {
+		return &SchemaViolation{Path: path, Reason: fmt.Sprintf("array has fewer than %d items", *n.minItems)}
+	}
+	if n.maxItems != nil && len(items) > *n.maxItems {
+		return &SchemaViolation{Path: path, Reason: fmt.Sprintf("array has more than %d items", *n.maxItems)}
+	}
+	if n.uniqueItems {
+		for i := range items {
+			for j := 0; j < i; j++ {
+				if schemaEqual(items[i], items[j]) {
+					return &SchemaViolation{Path: fmt.Sprintf("%s/%d", path, i), Reason: "array items are not unique"}
+				}
+			}
+		}
+	}
+	if n.items != nil {
+		for i, item := range items {
+			if v := n.items.validate(item, fmt.Sprintf("%s/%d", path, i), depth+1); v != nil {
+				return v
+			}
+		}
+	}
+	return nil
+}
+
+func (n *schemaNode) validateNumber(f float64, path string) *SchemaViolation {
+	fail := func(format string, bound float64) *SchemaViolation {
+		return &SchemaViolation{Path: path, Reason: fmt.Sprintf(format, strconv.FormatFloat(bound, 'g', -1, 64))}
+	}
+	if n.minimum != nil && f < *n.minimum {
+		return fail("must be >= %s", *n.minimum)
+	}
+	if n.maximum != nil && f > *n.maximum {
+		return fail("must be <= %s", *n.maximum)
+	}
+	if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+		return fail("must be > %s", *n.exclusiveMinimum)
+	}
+	if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+		return fail("must be < %s", *n.exclusiveMaximum)
+	}
+	if n.multipleOf != nil {
+		if q := f / *n.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			return fail("must be a multiple of %s", *n.multipleOf)
+		}
+	}
+	return nil
+}
+
+// schemaTypeMatches reports whether value has one of the JSON Schema types
+func schemaTypeMatches(types []string, value interface{}) bool {
+	actual := schemaTypeOf(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaTypeOf returns the JSON Schema type of a decoded JSON value
+func schemaTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if f, ok := toFloat(value); ok {
+		if f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return jsonTypeName(value)
+}
+
+// schemaEqual compares decoded JSON values, numbers by value
+func schemaEqual(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// escapePointerToken escapes a JSON Pointer reference token (RFC 6901)
+func escapePointerToken(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
@@ -0,0 +1,170 @@
+package jwtauth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClaimSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["sub", "tenant", "roles"],
+	"properties": {
+		"tenant": {"type": "string", "pattern": "^[a-z0-9-]+$"},
+		"roles": {"type": "array", "minItems": 1, "uniqueItems": true, "items": {"$ref": "#/$defs/role"}},
+		"plan": {"enum": ["free", "pro"]},
+		"seats": {"type": "integer", "minimum": 1},
+		"profile": {
+			"type": "object",
+			"properties": {"email": {"type": "string", "minLength": 3}},
+			"additionalProperties": false
+		}
+	},
+	"$defs": {
+		"role": {"type": "string", "enum": ["reader", "writer", "admin"]}
+	}
+}`
+
+// TestClaimSchema tests claims schema validation and failing paths
+func TestClaimSchema(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithClaimSchema([]byte(testClaimSchema)))
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		wantPath string // Empty when the token is valid
+	}{
+		{"valid", jwt.MapClaims{"tenant": "acme", "roles": []interface{}{"reader"}, "seats": 5}, ""},
+		{"valid nested", jwt.MapClaims{"tenant": "acme", "roles": []interface{}{"admin"}, "profile": map[string]interface{}{"email": "a@b.c"}}, ""},
+		{"missing tenant", jwt.MapClaims{"roles": []interface{}{"reader"}}, "/"},
+		{"tenant wrong type", jwt.MapClaims{"tenant": 42, "roles": []interface{}{"reader"}}, "/tenant"},
+		{"tenant pattern", jwt.MapClaims{"tenant": "Acme Corp", "roles": []interface{}{"reader"}}, "/tenant"},
+		{"unknown role", jwt.MapClaims{"tenant": "acme", "roles": []interface{}{"reader", "root"}}, "/roles/1"},
+		{"duplicate role", jwt.MapClaims{"tenant": "acme", "roles": []interface{}{"reader", "reader"}}, "/roles/1"},
+		{"no roles", jwt.MapClaims{"tenant": "acme", "roles": []interface{}{}}, "/roles"},
+		{"plan not allowed", jwt.MapClaims{"tenant": "acme", "roles": []interface{}{"reader"}, "plan": "gold"}, "/plan"},
+		{"fractional seats", jwt.MapClaims{"tenant": "acme", "roles": []interface{}{"reader"}, "seats": 1.5}, "/seats"},
+		{"zero seats", jwt.MapClaims{"tenant": "acme", "roles": []interface{}{"reader"}, "seats": 0}, "/seats"},
+		{"extra profile field", jwt.MapClaims{"tenant": "acme", "roles": []interface{}{"reader"}, "profile": map[string]interface{}{"email": "a@b.c", "ssn": "x"}}, "/profile/ssn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims["sub"] = "user123"
+			tt.claims["exp"] = time.Now().Add(time.Hour).Unix()
+
+			_, err := parseAndValidateJWT(mustSignHS256(secret, tt.claims), cfg)
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Fatalf("Expected token to validate, got %v", err)
+				}
+				return
+			}
+			if getErrorCode(err) != string(ErrClaimsSchemaViolation) {
+				t.Fatalf("Expected CLAIMS_SCHEMA_VIOLATION, got %v", err)
+			}
+			var violation *SchemaViolation
+			if !errors.As(err, &violation) {
+				t.Fatalf("Expected SchemaViolation, got %T", err)
+			}
+			path := violation.Path
+			if path == "" {
+				path = "/"
+			}
+			if path != tt.wantPath {
+				t.Errorf("Expected path %s, got %s (%s)", tt.wantPath, path, violation.Reason)
+			}
+			if !strings.Contains(err.Error(), tt.wantPath) {
+				t.Errorf("Expected message to name the path, got %v", err)
+			}
+		})
+	}
+}
+
+// TestClaimSchemaCombinators tests allOf, anyOf, oneOf and not
+func TestClaimSchemaCombinators(t *testing.T) {
+	schema := `{
+		"properties": {
+			"scope": {"anyOf": [{"type": "string"}, {"type": "array", "items": {"type": "string"}}]},
+			"level": {"oneOf": [{"type": "integer"}, {"type": "number", "maximum": 10}]},
+			"env": {"allOf": [{"type": "string"}, {"not": {"const": "prod"}}]}
+		}
+	}`
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithClaimSchema([]byte(schema)))
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		wantErr bool
+	}{
+		{"scope string", jwt.MapClaims{"scope": "read"}, false},
+		{"scope array", jwt.MapClaims{"scope": []interface{}{"read", "write"}}, false},
+		{"scope number", jwt.MapClaims{"scope": 1}, true},
+		{"level fraction", jwt.MapClaims{"level": 2.5}, false},
+		{"level integer matches both", jwt.MapClaims{"level": 3}, true},
+		{"level large integer", jwt.MapClaims{"level": 30}, false},
+		{"env staging", jwt.MapClaims{"env": "staging"}, false},
+		{"env prod", jwt.MapClaims{"env": "prod"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims["sub"] = "user123"
+			tt.claims["exp"] = time.Now().Add(time.Hour).Unix()
+
+			_, err := parseAndValidateJWT(mustSignHS256(secret, tt.claims), cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestWithClaimSchemaValidation tests that invalid schemas fail at config time
+func TestWithClaimSchemaValidation(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{"invalid JSON", `{"type":`, "invalid JSON"},
+		{"not an object", `[]`, "must be a JSON object"},
+		{"unknown type", `{"properties": {"a": {"type": "text"}}}`, `#/properties/a/type: unknown type "text"`},
+		{"bad pattern", `{"properties": {"a": {"pattern": "("}}}`, "#/properties/a/pattern"},
+		{"unsupported keyword", `{"if": {"required": ["a"]}, "then": {"required": ["b"]}}`, "#/if: keyword is not supported"},
+		{"remote ref", `{"$ref": "https://example.com/schema.json"}`, "only local references"},
+		{"dangling ref", `{"$ref": "#/$defs/missing"}`, "does not resolve"},
+		{"negative count", `{"minItems": -1}`, "#/minItems: must be a non-negative integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfig(WithHS256(secret), WithClaimSchema([]byte(tt.schema)))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// Recursive references compile and validate nested data
+	recursive := `{"$defs": {"node": {"type": "object", "properties": {"child": {"$ref": "#/$defs/node"}}}}, "properties": {"tree": {"$ref": "#/$defs/node"}}}`
+	cfg := mustCreateConfig(WithHS256(secret), WithClaimSchema([]byte(recursive)))
+	token := mustSignHS256(secret, jwt.MapClaims{
+		"sub":  "user123",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"tree": map[string]interface{}{"child": map[string]interface{}{"child": "leaf"}},
+	})
+	_, err := parseAndValidateJWT(token, cfg)
+	var violation *SchemaViolation
+	if !errors.As(err, &violation) || violation.Path != "/tree/child/child" {
+		t.Errorf("Expected violation at /tree/child/child, got %v", err)
+	}
+}
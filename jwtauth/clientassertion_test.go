@@ -0,0 +1,98 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/url"
+	"testing"
+)
+
+// TestClientAssertion tests minting private_key_jwt assertions from a PEM key
+func TestClientAssertion(t *testing.T) {
+	ctx := context.Background()
+	ecKey := mustGenerateECKey()
+	der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParsePrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewCryptoSigner("ES256", "client-key-1", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := mustCreateConfig(WithKeyProvider("ES256", KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		return &ecKey.PublicKey, nil
+	})))
+
+	a := ClientAssertion{ClientID: "svc-a", Signer: signer}
+	form := url.Values{"token": {"abc"}}
+	if err := a.Authenticate(ctx, form, "https://idp.example.com/introspect"); err != nil {
+		t.Fatal(err)
+	}
+	if form.Get("client_id") != "svc-a" || form.Get("client_assertion_type") != ClientAssertionType {
+		t.Errorf("Unexpected form %v", form)
+	}
+	claims, err := ParseToken(ctx, form.Get("client_assertion"), idp)
+	if err != nil {
+		t.Fatalf("Expected assertion to verify, got %v", err)
+	}
+	if claims.Issuer != "svc-a" || claims.Subject != "svc-a" {
+		t.Errorf("Expected client as iss and sub, got %q, %q", claims.Issuer, claims.Subject)
+	}
+	if aud, _ := claims.Get("aud"); aud != "https://idp.example.com/introspect" {
+		t.Errorf("Unexpected audience %v", aud)
+	}
+	if jti, _ := claims.GetString("jti"); len(jti) != 32 {
+		t.Errorf("Expected random jti, got %q", jti)
+	}
+	second, _ := a.Mint(ctx, "https://idp.example.com/introspect")
+	if second == form.Get("client_assertion") {
+		t.Error("Expected each assertion to be unique")
+	}
+
+	if _, err := (ClientAssertion{ClientID: "svc-a"}).Mint(ctx, "aud"); err == nil {
+		t.Error("Expected missing signer to be rejected")
+	}
+	if _, err := a.Mint(ctx, ""); err == nil {
+		t.Error("Expected missing audience to be rejected")
+	}
+}
+
+// TestParsePrivateKeyFromPEM tests the supported private key encodings
+func TestParsePrivateKeyFromPEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey := mustGenerateECKey()
+	ecDER, _ := x509.MarshalECPrivateKey(ecKey)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edKey)
+
+	for name, block := range map[string]*pem.Block{
+		"PKCS#1 RSA":     {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)},
+		"SEC 1 EC":       {Type: "EC PRIVATE KEY", Bytes: ecDER},
+		"PKCS#8 Ed25519": {Type: "PRIVATE KEY", Bytes: edDER},
+	} {
+		if _, err := ParsePrivateKeyFromPEM(pem.EncodeToMemory(block)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if key, _ := ParsePrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER})); key != nil {
+		if _, ok := key.(*ecdsa.PrivateKey); !ok {
+			t.Errorf("Expected *ecdsa.PrivateKey, got %T", key)
+		}
+	}
+	if _, err := ParsePrivateKeyFromPEM([]byte("not pem")); err == nil {
+		t.Error("Expected invalid PEM to be rejected")
+	}
+}
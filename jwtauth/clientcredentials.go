@@ -0,0 +1,140 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ClientCredentials is an OAuth 2.0 client credentials grant (RFC 6749
+// Section 4.4) client for an IdP token endpoint. It authenticates with
+// ClientSecret (client_secret_basic) or, when Signer is set, with a
+// private_key_jwt client assertion (RFC 7523) signed by Signer.
+//
+// ClientCredentials is itself a TokenSource that requests a new token on
+// every call; use NewClientCredentialsTokenSource for caching.
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string   // Sent with HTTP Basic authentication
+	Signer       Signer   // Signs private_key_jwt assertions instead of sending a secret
+	Scopes       []string // Optional
+	Audience     string   // Optional "audience" parameter (Auth0, Okta)
+
+	// AssertionAudience is the private_key_jwt assertion audience (default
+	// TokenURL; some IdPs expect their issuer URL)
+	AssertionAudience string
+
+	HTTPClient    *http.Client  // Defaults to a client with a 10s timeout
+	RefreshBefore time.Duration // Refresh this long before expiry (default 1m)
+}
+
+// maxTokenResponseBytes bounds token endpoint responses
+const maxTokenResponseBytes = 1 << 20
+
+// NewClientCredentialsTokenSource returns a caching TokenSource fetching
+// tokens with the client credentials grant. Tokens are refreshed
+// RefreshBefore ahead of expiry and concurrent callers share one request to
+// the token endpoint.
+func NewClientCredentialsTokenSource(cc ClientCredentials) (TokenSource, error) {
+	if cc.TokenURL == "" || cc.ClientID == "" {
+		return nil, fmt.Errorf("client credentials require a token URL and client ID")
+	}
+	if cc.ClientSecret != "" && cc.Signer != nil {
+		return nil, fmt.Errorf("client credentials take a client secret or a signer, not both")
+	}
+	if _, err := url.ParseRequestURI(cc.TokenURL); err != nil {
+		return nil, fmt.Errorf("invalid token URL: %w", err)
+	}
+	if cc.RefreshBefore == 0 {
+		cc.RefreshBefore = time.Minute
+	}
+	return NewCachingTokenSource(cc, cc.RefreshBefore), nil
+}
+
+// tokenResponse is a token endpoint response (RFC 6749 Sections 5.1 and 5.2)
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token requests a new token from the token endpoint
+func (cc ClientCredentials) Token(ctx context.Context) (*AccessToken, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(cc.Scopes) > 0 {
+		form.Set("scope", strings.Join(cc.Scopes, " "))
+	}
+	if cc.Audience != "" {
+		form.Set("audience", cc.Audience)
+	}
+	return cc.grant(ctx, form)
+}
+
+// grant authenticates the client and posts form to the token endpoint
+func (cc ClientCredentials) grant(ctx context.Context, form url.Values) (*AccessToken, error) {
+	if cc.Signer != nil {
+		audience := cc.AssertionAudience
+		if audience == "" {
+			audience = cc.TokenURL
+		}
+		if err := (ClientAssertion{ClientID: cc.ClientID, Signer: cc.Signer}).Authenticate(ctx, form, audience); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cc.Signer == nil {
+		// RFC 6749 Section 2.3.1: credentials are form-encoded before Basic encoding
+		req.SetBasicAuth(url.QueryEscape(cc.ClientID), url.QueryEscape(cc.ClientSecret))
+	}
+
+	client := cc.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	issued := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tr tokenResponse
+	jsonErr := json.Unmarshal(body, &tr)
+	if resp.StatusCode != http.StatusOK {
+		if jsonErr == nil && tr.Error != "" {
+			return nil, fmt.Errorf("token endpoint returned %d: %s %s", resp.StatusCode, tr.Error, tr.ErrorDescription)
+		}
+		return nil, fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+	if jsonErr != nil {
+		return nil, fmt.Errorf("failed to parse token response: %w", jsonErr)
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+
+	tok := &AccessToken{Token: tr.AccessToken, Type: tr.TokenType}
+	if tr.ExpiresIn > 0 {
+		tok.ExpiresAt = issued.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
@@ -0,0 +1,100 @@
+package jwtauth
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// WithTrustedProxies sets the proxies whose X-Forwarded-For header is
+// believed when deriving the client IP, as CIDRs or bare addresses
+// ("10.0.0.0/8", "192.0.2.10"). Without trusted proxies the client IP is the
+// connection's remote address and X-Forwarded-For is ignored, since any
+// client can send it.
+//
+// The derived IP is exposed via GetClientIP(ctx), recorded in security events
+// and used by IP binding.
+func WithTrustedProxies(cidrs ...string) ConfigOption {
+	return func(c *Config) error {
+		for _, cidr := range cidrs {
+			prefix, err := parseAddrOrPrefix(cidr)
+			if err != nil {
+				return fmt.Errorf("trusted proxy: %w", err)
+			}
+			c.trustedProxies = append(c.trustedProxies, prefix)
+		}
+		return nil
+	}
+}
+
+// parseAddrOrPrefix parses a CIDR or a single address (as a full-length prefix)
+func parseAddrOrPrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid address or CIDR %q: %w", s, err)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid address or CIDR %q: %w", s, err)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// isTrustedProxy reports whether addr belongs to a trusted proxy
+func (c *Config) isTrustedProxy(addr netip.Addr) bool {
+	for _, prefix := range c.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP derives the client address from the connection's remote address
+// and X-Forwarded-For values. Forwarded hops are walked from the nearest one
+// outwards while they are trusted proxies; the first untrusted hop is the
+// client. An unparseable hop stops the walk at the last trusted address.
+func (c *Config) clientIP(remoteAddr string, forwardedFor []string) string {
+	addr, ok := parseRemoteAddr(remoteAddr)
+	if !ok {
+		return ""
+	}
+	if !c.isTrustedProxy(addr) {
+		return addr.String()
+	}
+
+	var hops []string
+	for _, value := range forwardedFor {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !c.isTrustedProxy(addr) {
+			break
+		}
+	}
+	return addr.String()
+}
+
+// parseRemoteAddr parses "host:port" or a bare address
+func parseRemoteAddr(remoteAddr string) (netip.Addr, bool) {
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,91 @@
+package jwtauth
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestClientIP tests client IP derivation with and without trusted proxies
+func TestClientIP(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	untrusted := mustCreateConfig(WithHS256(secret))
+	trusted := mustCreateConfig(WithHS256(secret), WithTrustedProxies("10.0.0.0/8", "2001:db8::1"))
+
+	tests := []struct {
+		name         string
+		cfg          *Config
+		remoteAddr   string
+		forwardedFor []string
+		want         string
+	}{
+		{"no proxies ignores header", untrusted, "203.0.113.7:4242", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"untrusted peer ignores header", trusted, "203.0.113.7:4242", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted peer", trusted, "10.1.2.3:4242", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", trusted, "10.1.2.3:4242", []string{"198.51.100.1, 10.9.9.9", "10.8.8.8"}, "198.51.100.1"},
+		{"spoofed leftmost entry", trusted, "10.1.2.3:4242", []string{"1.1.1.1, 198.51.100.1"}, "198.51.100.1"},
+		{"garbage stops walk", trusted, "10.1.2.3:4242", []string{"198.51.100.1, not-an-ip"}, "10.1.2.3"},
+		{"all hops trusted", trusted, "10.1.2.3:4242", []string{"10.4.4.4"}, "10.4.4.4"},
+		{"trusted IPv6 address", trusted, "[2001:db8::1]:443", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"IPv4-mapped peer", trusted, "[::ffff:10.1.2.3]:4242", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"unparseable peer", trusted, "pipe", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.clientIP(tt.remoteAddr, tt.forwardedFor); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestClientIPInContextAndEvents tests that the middleware exposes the client
+// IP to handlers and security events
+func TestClientIPInContextAndEvents(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	var logs bytes.Buffer
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithTrustedProxies("10.0.0.0/8"),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+
+	r := gin.New()
+	r.Use(JWTAuth(cfg))
+	r.GET("/protected", func(c *gin.Context) {
+		ip, _ := GetClientIP(c.Request.Context())
+		c.String(http.StatusOK, ip)
+	})
+
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.RemoteAddr = "10.0.0.5:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != "198.51.100.1" {
+		t.Errorf("Expected client IP in context, got %q", w.Body.String())
+	}
+	if !strings.Contains(logs.String(), "client_ip=198.51.100.1") {
+		t.Errorf("Expected client_ip in security event, got %s", logs.String())
+	}
+}
+
+// TestWithTrustedProxiesRejectsInvalid tests option validation
+func TestWithTrustedProxiesRejectsInvalid(t *testing.T) {
+	for _, cidr := range []string{"10.0.0.0/33", "not-an-ip", ""} {
+		if _, err := NewConfig(WithHS256([]byte("test-secret-key-min-32-bytes-long!!")), WithTrustedProxies(cidr)); err == nil {
+			t.Errorf("Expected %q to be rejected", cidr)
+		}
+	}
+}
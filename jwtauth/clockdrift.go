@@ -0,0 +1,238 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrClockDrift is returned by CheckClockDrift when the local clock differs
+// from the reference by more than the configured clock skew
+var ErrClockDrift = errors.New("clock drift exceeds clock skew")
+
+// TimeSource reports a reference time to compare the local clock against
+type TimeSource interface {
+	Now(ctx context.Context) (time.Time, error)
+}
+
+// TimeSourceFunc adapts a function to the TimeSource interface
+type TimeSourceFunc func(ctx context.Context) (time.Time, error)
+
+// Now implements TimeSource
+func (f TimeSourceFunc) Now(ctx context.Context) (time.Time, error) {
+	return f(ctx)
+}
+
+// NTPTimeSource returns a TimeSource querying an (S)NTP server, e.g.
+// "time.google.com" or "pool.ntp.org:123"
+func NTPTimeSource(server string) TimeSource {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	return ntpTimeSource{server: server}
+}
+
+// ntpTimeSource is a minimal SNTP (RFC 4330) client
+type ntpTimeSource struct {
+	server string
+}
+
+// ntpEpochOffset is the number of seconds between 1900 and 1970
+const ntpEpochOffset = 2208988800
+
+// Now implements TimeSource
+func (s ntpTimeSource) Now(ctx context.Context) (time.Time, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", s.server)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ntp dial: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	conn.SetDeadline(deadline)
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // LI=0, VN=4, Mode=3 (client)
+	t1 := time.Now()
+	if _, err := conn.Write(req); err != nil {
+		return time.Time{}, fmt.Errorf("ntp write: %w", err)
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	t4 := time.Now()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ntp read: %w", err)
+	}
+	if n < 48 || resp[0]&0x07 != 4 || resp[1] == 0 {
+		return time.Time{}, fmt.Errorf("ntp: invalid server response")
+	}
+
+	// Server receive (t2) and transmit (t3) timestamps
+	t2 := ntpTime(resp[32:40])
+	t3 := ntpTime(resp[40:48])
+	delay := t4.Sub(t1) - t3.Sub(t2)
+	return t3.Add(delay / 2), nil
+}
+
+// ntpTime decodes a 64-bit NTP timestamp
+func ntpTime(b []byte) time.Time {
+	seconds := int64(binary.BigEndian.Uint32(b[0:4])) - ntpEpochOffset
+	fraction := int64(binary.BigEndian.Uint32(b[4:8]))
+	return time.Unix(seconds, fraction*1e9>>32)
+}
+
+// CheckClockDrift compares the local clock with source and returns the
+// offset (reference minus local; positive when the local clock is behind).
+// When the offset exceeds the configured clock skew, a warning is logged and
+// the error wraps ErrClockDrift: with a drifting clock, valid tokens fail as
+// EXPIRED. Call it at startup and periodically.
+func (c *Config) CheckClockDrift(ctx context.Context, source TimeSource) (time.Duration, error) {
+	before := time.Now()
+	reference, err := source.Now(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("time source unavailable: %w", err)
+	}
+	after := time.Now()
+
+	offset := reference.Sub(before.Add(after.Sub(before) / 2))
+	if absDuration(offset) <= c.clockSkewLeeway {
+		return offset, nil
+	}
+
+	c.warnClockDrift("time_source", offset, 0)
+	return offset, fmt.Errorf("%w: offset %v, clock skew %v", ErrClockDrift, offset, c.clockSkewLeeway)
+}
+
+// ClockDriftDetection configures WithClockDriftDetection
+type ClockDriftDetection struct {
+	// Issuers whose tokens are presented right after issuance (internal
+	// services minting per-call tokens). Required: long-lived tokens would
+	// make the estimate meaningless.
+	Issuers []string
+
+	Window     time.Duration // Observation window (default 5m)
+	MinSamples int           // Tokens required per window before warning (default 10)
+
+	// OnDrift is called with the estimated offset when it exceeds the
+	// clock skew (optional; a warning is always logged)
+	OnDrift func(offset time.Duration)
+}
+
+// WithClockDriftDetection estimates local clock drift from the iat claim of
+// freshly issued tokens. Such tokens are presented moments after issuance, so
+// the smallest (now - iat) in a window approximates the offset between the
+// issuer's clock and ours. When it exceeds the clock skew, a "clock drift
+// exceeds clock skew" warning is logged once per window. Tokens rejected as
+// EXPIRED are observed too, since that is how drift usually shows up.
+func WithClockDriftDetection(d ClockDriftDetection) ConfigOption {
+	return func(c *Config) error {
+		if len(d.Issuers) == 0 {
+			return fmt.Errorf("clock drift detection requires at least one issuer")
+		}
+		if d.Window == 0 {
+			d.Window = 5 * time.Minute
+		}
+		if d.MinSamples == 0 {
+			d.MinSamples = 10
+		}
+		if d.Window < 0 || d.MinSamples < 0 {
+			return fmt.Errorf("clock drift window and sample count must be positive")
+		}
+
+		issuers := make(map[string]bool, len(d.Issuers))
+		for _, iss := range d.Issuers {
+			issuers[iss] = true
+		}
+		c.driftDetector = &driftDetector{config: d, issuers: issuers}
+		return nil
+	}
+}
+
+// driftDetector tracks (now - iat) samples for the current window
+type driftDetector struct {
+	config  ClockDriftDetection
+	issuers map[string]bool
+
+	mu          sync.Mutex
+	windowStart time.Time
+	samples     int
+	minAge      time.Duration
+	warned      bool
+}
+
+// observeIssuedAt records a verified token's iat
+func (c *Config) observeIssuedAt(issuer string, issuedAt time.Time) {
+	d := c.driftDetector
+	if d == nil || issuedAt.IsZero() || !d.issuers[issuer] {
+		return
+	}
+
+	now := time.Now()
+	age := now.Sub(issuedAt)
+
+	d.mu.Lock()
+	if now.Sub(d.windowStart) >= d.config.Window {
+		d.windowStart = now
+		d.samples = 0
+		d.minAge = age
+		d.warned = false
+	}
+	d.samples++
+	if age < d.minAge {
+		d.minAge = age
+	}
+
+	offset := -d.minAge
+	drifting := !d.warned && d.samples >= d.config.MinSamples && absDuration(offset) > c.clockSkewLeeway
+	if drifting {
+		d.warned = true
+	}
+	samples := d.samples
+	d.mu.Unlock()
+
+	if drifting {
+		c.warnClockDrift("iat", offset, samples)
+		if d.config.OnDrift != nil {
+			d.config.OnDrift(offset)
+		}
+	}
+}
+
+// observeClaimsIssuedAt records iat from claims that failed time validation
+func observeClaimsIssuedAt(cfg *Config, mapClaims jwt.MapClaims) {
+	issuer, _ := mapClaims.GetIssuer()
+	if issuedAt, err := mapClaims.GetIssuedAt(); err == nil && issuedAt != nil {
+		cfg.observeIssuedAt(issuer, issuedAt.Time)
+	}
+}
+
+// warnClockDrift logs a clock drift warning
+func (c *Config) warnClockDrift(source string, offset time.Duration, samples int) {
+	if c.Logger() == nil {
+		return
+	}
+	args := []interface{}{"source", source, "offset", offset, "clock_skew", c.clockSkewLeeway}
+	if samples > 0 {
+		args = append(args, "samples", samples)
+	}
+	c.Logger().Warn("clock drift exceeds clock skew", args...)
+}
+
+// absDuration returns the absolute value of d
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
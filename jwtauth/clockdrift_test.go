@@ -0,0 +1,151 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// startFakeNTP serves SNTP responses from a clock offset from ours
+func startFakeNTP(t *testing.T, offset time.Duration) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 48)
+		for {
+			_, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			now := time.Now().Add(offset)
+			resp := make([]byte, 48)
+			resp[0] = 0x24 // LI=0, VN=4, Mode=4 (server)
+			resp[1] = 2    // Stratum
+			putNTPTime(resp[32:40], now)
+			putNTPTime(resp[40:48], now)
+			conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[0:4], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:8], uint32((int64(t.Nanosecond())<<32)/1e9))
+}
+
+// TestCheckClockDrift tests drift measurement against an NTP source
+func TestCheckClockDrift(t *testing.T) {
+	var logs bytes.Buffer
+	cfg := mustCreateConfig(
+		WithHS256([]byte("test-secret-key-min-32-bytes-long!!")),
+		WithClockSkew(30*time.Second),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	offset, err := cfg.CheckClockDrift(ctx, NTPTimeSource(startFakeNTP(t, 5*time.Second)))
+	if err != nil {
+		t.Fatalf("Expected drift within skew, got %v", err)
+	}
+	if offset < 4*time.Second || offset > 6*time.Second {
+		t.Errorf("Expected offset around 5s, got %v", offset)
+	}
+
+	offset, err = cfg.CheckClockDrift(ctx, NTPTimeSource(startFakeNTP(t, -2*time.Minute)))
+	if !errors.Is(err, ErrClockDrift) {
+		t.Fatalf("Expected ErrClockDrift, got %v", err)
+	}
+	if offset > -time.Minute {
+		t.Errorf("Expected negative offset, got %v", offset)
+	}
+	if !strings.Contains(logs.String(), "clock drift exceeds clock skew") {
+		t.Errorf("Expected drift warning, got %s", logs.String())
+	}
+
+	failing := TimeSourceFunc(func(context.Context) (time.Time, error) { return time.Time{}, errors.New("down") })
+	if _, err := cfg.CheckClockDrift(ctx, failing); err == nil || errors.Is(err, ErrClockDrift) {
+		t.Errorf("Expected time source error, got %v", err)
+	}
+}
+
+// TestClockDriftDetection tests drift estimation from iat of fresh tokens
+func TestClockDriftDetection(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+
+	tests := []struct {
+		name      string
+		issuer    string
+		iatOffset time.Duration // Issuer clock relative to ours
+		wantDrift bool
+	}{
+		{"clocks in sync", "internal", 0, false},
+		{"local clock behind", "internal", 10 * time.Minute, true},
+		{"local clock ahead (tokens expire)", "internal", -10 * time.Minute, true},
+		{"other issuers ignored", "external", 10 * time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			var reported time.Duration
+			cfg := mustCreateConfig(
+				WithHS256(secret),
+				WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+				WithClockDriftDetection(ClockDriftDetection{
+					Issuers:    []string{"internal"},
+					MinSamples: 3,
+					OnDrift:    func(offset time.Duration) { reported = offset },
+				}),
+			)
+
+			issued := time.Now().Add(tt.iatOffset)
+			for i := 0; i < 5; i++ {
+				token := mustSignHS256(secret, jwt.MapClaims{
+					"iss": tt.issuer,
+					"sub": "svc",
+					"iat": issued.Unix(),
+					"exp": issued.Add(5 * time.Minute).Unix(),
+				})
+				parseAndValidateJWT(token, cfg)
+			}
+
+			warnings := strings.Count(logs.String(), "clock drift exceeds clock skew")
+			if tt.wantDrift {
+				if warnings != 1 {
+					t.Errorf("Expected one drift warning per window, got %d", warnings)
+				}
+				if absDuration(reported-tt.iatOffset) > 5*time.Second {
+					t.Errorf("Expected offset around %v, got %v", tt.iatOffset, reported)
+				}
+			} else if warnings != 0 {
+				t.Errorf("Expected no drift warning, got %s", logs.String())
+			}
+		})
+	}
+}
+
+// TestWithClockDriftDetectionValidation tests option validation
+func TestWithClockDriftDetectionValidation(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	if _, err := NewConfig(WithHS256(secret), WithClockDriftDetection(ClockDriftDetection{})); err == nil {
+		t.Error("Expected missing issuers to be rejected")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithClockDriftDetection(ClockDriftDetection{Issuers: []string{"a"}, Window: -time.Second})); err == nil {
+		t.Error("Expected negative window to be rejected")
+	}
+}
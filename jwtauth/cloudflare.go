@@ -0,0 +1,54 @@
+package jwtauth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CloudflareAccessHeader carries the application token Cloudflare Access adds
+// to requests it lets through
+const CloudflareAccessHeader = "Cf-Access-Jwt-Assertion"
+
+// cloudflareAccessCertsURL returns the team's key endpoint. A variable so
+// tests can serve their own keys.
+var cloudflareAccessCertsURL = func(issuer string) string {
+	return issuer + "/cdn-cgi/access/certs"
+}
+
+// WithCloudflareAccess validates the application tokens Cloudflare Access
+// adds in Cf-Access-Jwt-Assertion: RS256 tokens signed with the team's keys
+// (fetched from <team domain>/cdn-cgi/access/certs and cached by kid),
+// issued by the team domain and carrying one of audTags, the Application
+// Audience (AUD) tags of the Access applications fronting the service.
+//
+// teamDomain is "myteam.cloudflareaccess.com" (a bare "myteam" is expanded)
+// or the team's custom domain.
+func WithCloudflareAccess(teamDomain string, audTags ...string) ConfigOption {
+	return func(c *Config) error {
+		issuer, err := cloudflareAccessIssuer(teamDomain)
+		if err != nil {
+			return err
+		}
+		if len(audTags) == 0 {
+			return fmt.Errorf("Cloudflare Access audience tag is required")
+		}
+		return applyOptions(c,
+			WithTokenHeader(CloudflareAccessHeader),
+			WithKeyProvider("RS256", newRemoteJWKS(cloudflareAccessCertsURL(issuer))),
+			WithAudience(audTags...),
+			WithIssuers(issuer),
+		)
+	}
+}
+
+// cloudflareAccessIssuer normalizes a team domain to the iss of its tokens
+func cloudflareAccessIssuer(teamDomain string) (string, error) {
+	host := strings.TrimSuffix(strings.TrimPrefix(teamDomain, "https://"), "/")
+	if host == "" || strings.ContainsAny(host, "/:@ ") {
+		return "", fmt.Errorf("invalid Cloudflare Access team domain %q", teamDomain)
+	}
+	if !strings.Contains(host, ".") {
+		host += ".cloudflareaccess.com"
+	}
+	return "https://" + host, nil
+}
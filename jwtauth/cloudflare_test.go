@@ -0,0 +1,95 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestWithCloudflareAccess tests Access application tokens from the
+// Cf-Access-Jwt-Assertion header
+func TestWithCloudflareAccess(t *testing.T) {
+	key := mustGenerateRSAKey()
+	url, _ := serveJWKS(t, JSONWebKey{KeyID: "cf-1", Algorithm: "RS256", Key: &key.PublicKey})
+	defer func(old func(string) string) { cloudflareAccessCertsURL = old }(cloudflareAccessCertsURL)
+	var certsIssuer string
+	cloudflareAccessCertsURL = func(issuer string) string {
+		certsIssuer = issuer
+		return url
+	}
+
+	const issuer = "https://myteam.cloudflareaccess.com"
+	cfg := mustCreateConfig(WithCloudflareAccess("myteam", "aud-tag-app1", "aud-tag-app2"))
+	if certsIssuer != issuer {
+		t.Errorf("Expected certs for %s, got %s", issuer, certsIssuer)
+	}
+	router := createTestRouter(cfg)
+
+	sign := func(iss string, aud ...string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"iss": iss, "aud": aud, "email": "user@example.com", "sub": "7335d417", "exp": time.Now().Add(time.Hour).Unix()})
+		token.Header["kid"] = "cf-1"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		token      string
+		wantStatus int
+		wantReason string
+	}{
+		{"first application", CloudflareAccessHeader, sign(issuer, "aud-tag-app1"), http.StatusOK, ""},
+		{"second application", CloudflareAccessHeader, sign(issuer, "aud-tag-app2"), http.StatusOK, ""},
+		{"other application", CloudflareAccessHeader, sign(issuer, "aud-tag-other"), http.StatusUnauthorized, "INVALID_AUDIENCE"},
+		{"other team", CloudflareAccessHeader, sign("https://otherteam.cloudflareaccess.com", "aud-tag-app1"), http.StatusUnauthorized, "INVALID_ISSUER"},
+		{"authorization header ignored", "Authorization", "Bearer " + sign(issuer, "aud-tag-app1"), http.StatusUnauthorized, "MISSING_TOKEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set(tt.header, tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantReason) {
+				t.Errorf("Expected %s, got %s", tt.wantReason, w.Body)
+			}
+		})
+	}
+}
+
+// TestCloudflareAccessIssuer tests team domain normalization
+func TestCloudflareAccessIssuer(t *testing.T) {
+	tests := []struct {
+		teamDomain string
+		want       string
+		wantErr    bool
+	}{
+		{"myteam", "https://myteam.cloudflareaccess.com", false},
+		{"myteam.cloudflareaccess.com", "https://myteam.cloudflareaccess.com", false},
+		{"https://access.example.com/", "https://access.example.com", false},
+		{"", "", true},
+		{"http://myteam.cloudflareaccess.com", "", true},
+		{"myteam.cloudflareaccess.com/cdn-cgi", "", true},
+	}
+	for _, tt := range tests {
+		got, err := cloudflareAccessIssuer(tt.teamDomain)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("cloudflareAccessIssuer(%q) = %q, %v; want %q", tt.teamDomain, got, err, tt.want)
+		}
+	}
+
+	if _, err := NewConfig(WithCloudflareAccess("myteam")); err == nil {
+		t.Error("Expected preset without audience tag to be rejected")
+	}
+}
@@ -0,0 +1,229 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// The tests in this file exercise shared state from many goroutines; run
+// them with -race.
+
+// TestConcurrentAuthentication validates tokens from many goroutines against
+// one Config with every cache and detector enabled, while keys rotate and
+// tokens are revoked
+func TestConcurrentAuthentication(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+
+	h := &rotationHarness{}
+	initial, err := h.generate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotation, err := NewRotationManager(initial, RotationConfig{
+		Generate:         h.generate,
+		Publish:          h.publish,
+		PropagationDelay: time.Millisecond,
+		MaxTokenLifetime: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocklist := NewMemoryBlocklist()
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithKeyProvider("ES256", CachedKeyProvider(rotation, time.Minute)),
+		WithTokenCache(NewMemoryCache(64), time.Minute),
+		WithBlocklist(blocklist),
+		WithTenantClaim("tenant"),
+		WithTenantRateLimit(TenantRateLimiter{Default: RateLimit{Requests: 1000000, Period: time.Second}}),
+		WithAnomalyDetection(AnomalyDetection{MaxSubjects: 8}),
+		WithClockDriftDetection(ClockDriftDetection{Issuers: []string{"internal"}, MinSamples: 1}),
+		WithConnectionClaimsCache(16),
+		WithCanary(0.5, WithAudience("api")),
+	)
+	router := createTestRouter(cfg)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	// Rotate keys while requests are validated
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			if err := rotation.Rotate(ctx); err != nil {
+				t.Errorf("Rotate failed: %v", err)
+				return
+			}
+			time.Sleep(2 * time.Millisecond)
+			if err := rotation.Advance(ctx); err != nil {
+				t.Errorf("Advance failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	const workers, iterations = 8, 50
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Each worker is one connection sharing a per-connection cache
+			connCtx := ConnContext(ctx, nil)
+
+			for i := 0; i < iterations; i++ {
+				claims := jwt.MapClaims{
+					"sub":    fmt.Sprintf("user-%d", i%10),
+					"iss":    "internal",
+					"aud":    "api",
+					"tenant": fmt.Sprintf("tenant-%d", w%3),
+					"jti":    fmt.Sprintf("jti-%d-%d", w, i),
+					"iat":    time.Now().Unix(),
+					"exp":    time.Now().Add(time.Hour).Unix(),
+				}
+
+				token := mustSignHS256(secret, claims)
+				if i%2 == 1 {
+					var err error
+					if token, err = SignToken(ctx, rotation.Current(), claims); err != nil {
+						t.Errorf("SignToken failed: %v", err)
+						return
+					}
+				}
+
+				revoked := i%10 == 9
+				if revoked {
+					blocklist.Revoke(claims["jti"].(string), time.Time{})
+				}
+
+				req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				want := http.StatusOK
+				if revoked {
+					want = http.StatusUnauthorized
+				}
+				if rec.Code != want {
+					t.Errorf("Worker %d request %d: expected %d, got %d: %s", w, i, want, rec.Code, rec.Body.String())
+				}
+
+				// The same token again through the connection cache
+				if !revoked {
+					if _, _, err := authenticateToken(connCtx, token, "", cfg); err != nil {
+						t.Errorf("Worker %d request %d: expected cached token to validate, got %v", w, i, err)
+					}
+				}
+			}
+		}(w)
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("Timed out waiting for concurrent requests")
+	}
+}
+
+// TestMemoryCacheConcurrent tests the size bound and entry accounting under
+// concurrent writers, readers and deletes
+func TestMemoryCacheConcurrent(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(32).(*memoryCache)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprintf("key-%d", (w*31+i)%100)
+				switch i % 4 {
+				case 0, 1:
+					cache.Set(ctx, key, []byte(key), time.Duration(i%3)*time.Millisecond)
+				case 2:
+					if value, found, _ := cache.Get(ctx, key); found && string(value) != key {
+						t.Errorf("Expected %s, got %s", key, value)
+					}
+				case 3:
+					cache.Delete(ctx, key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	entries := 0
+	cache.entries.Range(func(key, value interface{}) bool {
+		entries++
+		return true
+	})
+	if size := cache.size.Load(); size != int64(entries) {
+		t.Errorf("Expected size %d to match %d entries", size, entries)
+	}
+	if entries > 32 {
+		t.Errorf("Expected at most 32 entries, got %d", entries)
+	}
+}
+
+// TestRotationManagerConcurrentReads tests lock-free key lookups during rotations
+func TestRotationManagerConcurrentReads(t *testing.T) {
+	ctx := context.Background()
+	m, h := newTestRotationManager(t)
+	var clock atomic.Int64
+	clock.Store(h.now.UnixNano())
+	m.now = func() time.Time { return time.Unix(0, clock.Load()) }
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				current := m.Current()
+				if _, err := m.VerificationKey(ctx, "ES256", current.KeyID()); err != nil {
+					t.Errorf("Expected current key %s to be published: %v", current.KeyID(), err)
+					return
+				}
+				if len(m.Keys()) == 0 {
+					t.Error("Expected published keys")
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := m.Rotate(ctx); err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+		clock.Add(int64(15 * time.Minute)) // Past the propagation delay
+		if err := m.Advance(ctx); err != nil {
+			t.Fatalf("Advance failed: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+}
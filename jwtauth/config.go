@@ -1,9 +1,15 @@
 package jwtauth
 
 import (
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"fmt"
 	"log/slog"
+	"net/netip"
+	"slices"
 	"sort"
 	"time"
 
@@ -12,20 +18,94 @@ import (
 
 // algorithmValidator holds signing key and method for a specific algorithm
 type algorithmValidator struct {
-	signingKey    interface{}       // []byte for HS256, *rsa.PublicKey for RS256
-	signingMethod jwt.SigningMethod // jwt.SigningMethodHS256 or jwt.SigningMethodRS256
+	signingKey    interface{}       // []byte for HS256, *rsa.PublicKey for RS256, *ecdsa.PublicKey for ES256, *secp256k1.PublicKey for ES256K, ed25519.PublicKey for EdDSA
+	signingMethod jwt.SigningMethod // jwt.SigningMethodHS256, RS256, ES256 or EdDSA
+	keyProvider   KeyProvider       // Resolves keys at validation time (nil for static keys)
+	keySet        *keySet           // Keys selected by kid (WithKeySet; nil for single keys)
 }
 
-// Config holds immutable configuration for JWT validation
+// Config holds immutable configuration for JWT validation. A Config is not
+// modified after NewConfig returns and is safe for concurrent use; the caches
+// and detectors it references are safe for concurrent use as well.
 type Config struct {
-	validators       map[string]algorithmValidator // "HS256" -> validator, "RS256" -> validator
-	clockSkewLeeway  time.Duration
-	cookieName       string
-	requiredClaims   []string
-	logger           *slog.Logger
-	contextKeyPrefix string
+	validators        map[string]algorithmValidator // "HS256" -> validator, "RS256" -> validator
+	clockSkewLeeway   time.Duration
+	cookieName        string
+	requiredClaims    []string
+	claimRequirements []RequiredClaim
+	logger            *slog.Logger
+	contextKeyPrefix  string
+	sealedKeys        bool
+	audiences         []string
+	audienceMatch     AudienceMatch
+	tenantClaim       string
+	tenantRateLimiter *TenantRateLimiter
+	connCacheSize     int
+	connCacheCounters *connCacheCounters
+	blocklist         Blocklist
+	streamExpiry      bool
+	streamExpiryFunc  StreamExpiryFunc
+	anomalyDetector   *anomalyDetector
+	tokenCache        Cache
+	tokenCacheTTL     time.Duration
+	tokenCacheAEADs   []cipher.AEAD // WithTokenCacheEncryption; the first one encrypts
+	fingerprint       string        // Hash of keys and claim policies, keys token cache entries
+	parser            *jwt.Parser
+	staticKeyFunc     jwt.Keyfunc // Shared key function when no validator uses a key provider
+
+	duplicateHeaderPolicy DuplicateHeaderPolicy
+	cors                  *CORSConfig
+	trustedProxies        []netip.Prefix
+	ipBindingClaim        string
+	deviceBinding         *DeviceBinding
+	delegationValidation  bool
+	canary                *canaryPolicy
+	driftDetector         *driftDetector
+	claimSchema           *claimSchema
+	detachedPayloads      bool
+	webhook               *WebhookVerification
+	claimAllowlist        map[string]bool
+	httpSignatures        *HTTPMessageSignatures
+	failureDelay          *failureDelay
+	tokenHeader           string   // WithTokenHeader; replaces Authorization
+	issuers               []string // Accepted iss values; empty accepts any
+	grpcTokenKeys         []string // WithGRPCTokenKeys; lowercase
+	keyOutage             *keyOutageState
+	prefetchTimeout       time.Duration // WithKeyPrefetch; zero skips the startup prefetch
+	expiresInHeader       string        // WithExpiresInHeader; canonical header name
+	identityHeaders       []byte        // WithIdentityHeaders HMAC secret
+	expiryWarning         *ExpiryWarning
+	methodRequirements    map[string]Requirement // WithMethodRequirements; full method name or /Service/* -> requirement
+	routeRules            []routeRule            // WithRouteRequirements; first match applies
+	messageTranslator     MessageTranslator
+	rejectEmbeddedKeys    bool      // WithEmbeddedKeyRejection
+	faults                []Fault   // WithFaultInjector
+	anyOf                 []*Config // AnyOf members; empty for other configs
+	tokenTypes            []string  // WithTokenTypes; normalized, empty accepts any
+	accessTokenProfile    bool      // WithAccessTokenProfile
+	issuedAtTelemetry     *issuedAtTelemetry
+	minRSAKeyBits         int           // WithMinRSAKeySize
+	verifyPool            *verifyPool   // WithVerificationWorkers
+	verifyQueueMax        int           // WithVerificationQueue
+	verifyQueueTimeout    time.Duration // WithVerificationQueue
+	verifyQueueSet        bool
+	roleClaim             string // WithRoleClaim
+	policy                Policy // WithPolicy
+	policyOptions         *policyOptions
+	permissionMappers     []PermissionMapper // WithPermissionMapping
+	lifecycle             *lifecycle
 }
 
+// AudienceMatch selects how configured audiences are matched against the aud claim
+type AudienceMatch int
+
+const (
+	// MatchAny accepts tokens containing at least one configured audience (default)
+	MatchAny AudienceMatch = iota
+	// MatchAll accepts tokens containing every configured audience
+	MatchAll
+)
+
 // ConfigOption is a functional option for configuring the middleware
 type ConfigOption func(*Config) error
 
@@ -35,6 +115,7 @@ func NewConfig(opts ...ConfigOption) (*Config, error) {
 		validators:       make(map[string]algorithmValidator),
 		clockSkewLeeway:  60 * time.Second, // Default 60 seconds
 		contextKeyPrefix: "jwtauth",
+		lifecycle:        &lifecycle{},
 	}
 
 	for _, opt := range opts {
@@ -43,40 +124,114 @@ func NewConfig(opts ...ConfigOption) (*Config, error) {
 		}
 	}
 
+	if err := cfg.finalize(); err != nil {
+		return nil, err
+	}
+	cfg.watchKeyChanges()
+	return cfg, nil
+}
+
+// finalize validates the configuration once all options are applied and
+// derives internal state
+func (c *Config) finalize() error {
 	// Validate required fields
-	if len(cfg.validators) == 0 {
-		return nil, NewValidationError(ErrConfigError, "at least one algorithm must be configured (use WithHS256 or WithRS256)", nil)
+	if len(c.validators) == 0 {
+		return NewValidationError(ErrConfigError, "at least one algorithm must be configured (use WithHS256, WithRS256, WithPS256, WithES256 or WithEdDSA)", nil)
 	}
 
 	// Reject "none" algorithm variants
-	for alg := range cfg.validators {
+	for alg := range c.validators {
 		if alg == "none" || alg == "None" || alg == "NONE" {
-			return nil, NewValidationError(ErrConfigError, "none algorithm is prohibited", nil)
+			return NewValidationError(ErrConfigError, "none algorithm is prohibited", nil)
 		}
 	}
 
+	if c.tenantRateLimiter != nil && c.tenantClaim == "" {
+		return NewValidationError(ErrConfigError, "tenant rate limiting requires WithTenantClaim", nil)
+	}
+
+	if c.accessTokenProfile && len(c.audiences) == 0 {
+		return NewValidationError(ErrConfigError, "access token profile requires WithAudience", nil)
+	}
+
+	if webhookType := c.webhookVerification().Type; slices.Contains(c.tokenTypes, webhookType) {
+		return NewValidationError(ErrConfigError, fmt.Sprintf("webhook type %s cannot also be a token type", webhookType), nil)
+	}
+
+	if c.tokenCacheAEADs != nil && c.tokenCache == nil {
+		return NewValidationError(ErrConfigError, "token cache encryption requires WithTokenCache", nil)
+	}
+	if c.tokenCache != nil && c.tokenCacheAEADs == nil && !isInProcessCache(c.tokenCache) {
+		return NewValidationError(ErrConfigError, "a token cache shared outside the process requires WithTokenCacheEncryption", nil)
+	}
+	if c.tokenCache != nil {
+		c.fingerprint = c.computeFingerprint()
+	}
+
 	// Validate each validator
-	for alg, validator := range cfg.validators {
-		if validator.signingKey == nil {
-			return nil, NewValidationError(ErrConfigError, fmt.Sprintf("signing key for %s cannot be nil", alg), nil)
+	for alg, validator := range c.validators {
+		if validator.signingKey == nil && validator.keyProvider == nil && validator.keySet == nil {
+			return NewValidationError(ErrConfigError, fmt.Sprintf("signing key for %s cannot be nil", alg), nil)
 		}
 		if validator.signingMethod == nil {
-			return nil, NewValidationError(ErrConfigError, fmt.Sprintf("signing method for %s cannot be nil", alg), nil)
+			return NewValidationError(ErrConfigError, fmt.Sprintf("signing method for %s cannot be nil", alg), nil)
 		}
 	}
 
-	return cfg, nil
+	if err := c.checkStaticKeySizes(); err != nil {
+		return err
+	}
+
+	if err := c.checkVerifyPool(); err != nil {
+		return err
+	}
+
+	c.buildParser()
+
+	if err := c.checkFaults(); err != nil {
+		return err
+	}
+
+	if err := c.prefetchKeys(); err != nil {
+		return err
+	}
+
+	// Build the stricter canary configuration on top of the final settings
+	if c.canary != nil {
+		if err := c.buildCanary(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // WithHS256 configures HMAC-SHA256 validation with the given secret
 func WithHS256(secret []byte) ConfigOption {
+	return withHMAC(jwt.SigningMethodHS256, secret)
+}
+
+// WithHS384 configures HMAC-SHA384 validation with the given secret
+func WithHS384(secret []byte) ConfigOption {
+	return withHMAC(jwt.SigningMethodHS384, secret)
+}
+
+// WithHS512 configures HMAC-SHA512 validation with the given secret
+func WithHS512(secret []byte) ConfigOption {
+	return withHMAC(jwt.SigningMethodHS512, secret)
+}
+
+// withHMAC registers an HMAC validator. Secrets must be at least as long as
+// the hash output (RFC 7518 section 3.2): 32, 48 and 64 bytes.
+func withHMAC(method *jwt.SigningMethodHMAC, secret []byte) ConfigOption {
 	return func(c *Config) error {
-		if len(secret) < 32 {
-			return fmt.Errorf("HS256 secret must be at least 32 bytes (256 bits), got %d bytes", len(secret))
+		minSize := method.Hash.Size()
+		if len(secret) < minSize {
+			return fmt.Errorf("%s secret must be at least %d bytes (%d bits), got %d bytes", method.Alg(), minSize, minSize*8, len(secret))
 		}
-		c.validators["HS256"] = algorithmValidator{
+		c.validators[method.Alg()] = algorithmValidator{
 			signingKey:    secret,
-			signingMethod: jwt.SigningMethodHS256,
+			signingMethod: method,
 		}
 		return nil
 	}
@@ -84,13 +239,76 @@ func WithHS256(secret []byte) ConfigOption {
 
 // WithRS256 configures RSA-SHA256 validation with the given public key
 func WithRS256(publicKey *rsa.PublicKey) ConfigOption {
+	return withRSA(jwt.SigningMethodRS256, publicKey)
+}
+
+// WithRS384 configures RSA-SHA384 validation with the given public key
+func WithRS384(publicKey *rsa.PublicKey) ConfigOption {
+	return withRSA(jwt.SigningMethodRS384, publicKey)
+}
+
+// WithRS512 configures RSA-SHA512 validation with the given public key
+func WithRS512(publicKey *rsa.PublicKey) ConfigOption {
+	return withRSA(jwt.SigningMethodRS512, publicKey)
+}
+
+// WithES256 configures ECDSA P-256 SHA-256 validation with the given public key
+func WithES256(publicKey *ecdsa.PublicKey) ConfigOption {
+	return func(c *Config) error {
+		if publicKey == nil {
+			return fmt.Errorf("ES256 public key cannot be nil")
+		}
+		if publicKey.Curve != elliptic.P256() {
+			return fmt.Errorf("ES256 requires a P-256 public key")
+		}
+		c.validators["ES256"] = algorithmValidator{
+			signingKey:    publicKey,
+			signingMethod: jwt.SigningMethodES256,
+		}
+		return nil
+	}
+}
+
+// WithEdDSA configures Ed25519 validation with the given public key
+func WithEdDSA(publicKey ed25519.PublicKey) ConfigOption {
+	return func(c *Config) error {
+		if len(publicKey) != ed25519.PublicKeySize {
+			return fmt.Errorf("EdDSA public key must be %d bytes, got %d", ed25519.PublicKeySize, len(publicKey))
+		}
+		c.validators["EdDSA"] = algorithmValidator{
+			signingKey:    publicKey,
+			signingMethod: jwt.SigningMethodEdDSA,
+		}
+		return nil
+	}
+}
+
+// WithPS256 configures RSASSA-PSS SHA-256 validation with the given public key
+func WithPS256(publicKey *rsa.PublicKey) ConfigOption {
+	return withRSA(jwt.SigningMethodPS256, publicKey)
+}
+
+// WithPS384 configures RSASSA-PSS SHA-384 validation with the given public key
+func WithPS384(publicKey *rsa.PublicKey) ConfigOption {
+	return withRSA(jwt.SigningMethodPS384, publicKey)
+}
+
+// WithPS512 configures RSASSA-PSS SHA-512 validation with the given public key
+func WithPS512(publicKey *rsa.PublicKey) ConfigOption {
+	return withRSA(jwt.SigningMethodPS512, publicKey)
+}
+
+// withRSA registers an RSA (PKCS#1 v1.5 or PSS) validator. Each variant is
+// its own algorithm, so a key configured for PS256 never verifies RS256 or
+// PS512 tokens.
+func withRSA(method jwt.SigningMethod, publicKey *rsa.PublicKey) ConfigOption {
 	return func(c *Config) error {
 		if publicKey == nil {
-			return fmt.Errorf("RS256 public key cannot be nil")
+			return fmt.Errorf("%s public key cannot be nil", method.Alg())
 		}
-		c.validators["RS256"] = algorithmValidator{
+		c.validators[method.Alg()] = algorithmValidator{
 			signingKey:    publicKey,
-			signingMethod: jwt.SigningMethodRS256,
+			signingMethod: method,
 		}
 		return nil
 	}
@@ -123,7 +341,8 @@ func WithLogger(logger *slog.Logger) ConfigOption {
 	}
 }
 
-// WithRequiredClaims specifies claim names that must be present in the JWT
+// WithRequiredClaims specifies claim names that must be present in the JWT.
+// Use WithClaimRequirements to also constrain their type or value.
 func WithRequiredClaims(claims ...string) ConfigOption {
 	return func(c *Config) error {
 		c.requiredClaims = append(c.requiredClaims, claims...)
@@ -131,6 +350,78 @@ func WithRequiredClaims(claims ...string) ConfigOption {
 	}
 }
 
+// WithAudience requires the aud claim (string or array) to contain the given audiences.
+// By default any one audience matches; see WithAudienceMatch.
+func WithAudience(audiences ...string) ConfigOption {
+	return func(c *Config) error {
+		if len(audiences) == 0 {
+			return fmt.Errorf("at least one audience must be specified")
+		}
+		for _, aud := range audiences {
+			if aud == "" {
+				return fmt.Errorf("audience cannot be empty")
+			}
+		}
+		c.audiences = append(c.audiences, audiences...)
+		return nil
+	}
+}
+
+// WithAudienceMatch sets whether any (MatchAny) or all (MatchAll) configured audiences must be present
+func WithAudienceMatch(match AudienceMatch) ConfigOption {
+	return func(c *Config) error {
+		if match != MatchAny && match != MatchAll {
+			return fmt.Errorf("invalid audience match mode %d", match)
+		}
+		c.audienceMatch = match
+		return nil
+	}
+}
+
+// WithIssuer requires the iss claim to equal issuer; see WithIssuers
+func WithIssuer(issuer string) ConfigOption {
+	return WithIssuers(issuer)
+}
+
+// WithIssuers requires the iss claim to be one of the given issuers. Tokens
+// with a missing or unlisted iss fail with INVALID_ISSUER. Repeated use adds
+// to the allowlist.
+func WithIssuers(issuers ...string) ConfigOption {
+	return func(c *Config) error {
+		if len(issuers) == 0 {
+			return fmt.Errorf("at least one issuer must be specified")
+		}
+		for _, iss := range issuers {
+			if iss == "" {
+				return fmt.Errorf("issuer cannot be empty")
+			}
+		}
+		c.issuers = append(c.issuers, issuers...)
+		return nil
+	}
+}
+
+// WithTenantClaim extracts the named claim as the tenant identifier, making it
+// available via GetTenant(ctx) and in security events
+func WithTenantClaim(claim string) ConfigOption {
+	return func(c *Config) error {
+		if claim == "" {
+			return fmt.Errorf("tenant claim name cannot be empty")
+		}
+		c.tenantClaim = claim
+		return nil
+	}
+}
+
+// WithSealedKeys disables the deprecated Algorithm() and SigningKey() accessors
+// so raw key material (including HMAC secrets) cannot be read back from the Config
+func WithSealedKeys() ConfigOption {
+	return func(c *Config) error {
+		c.sealedKeys = true
+		return nil
+	}
+}
+
 // Getter methods for internal use
 
 // AvailableAlgorithms returns a sorted list of configured algorithm names
@@ -151,7 +442,11 @@ func (c *Config) getValidator(alg string) (algorithmValidator, bool) {
 
 // Algorithm returns the first algorithm in sorted order (deprecated, for backward compatibility)
 // Deprecated: Use AvailableAlgorithms() for multi-algorithm configurations
+// Returns an empty string when the config was built with WithSealedKeys()
 func (c *Config) Algorithm() string {
+	if c.sealedKeys {
+		return ""
+	}
 	algs := c.AvailableAlgorithms()
 	if len(algs) > 0 {
 		return algs[0]
@@ -161,7 +456,11 @@ func (c *Config) Algorithm() string {
 
 // SigningKey returns the signing key of the first validator (deprecated, for backward compatibility)
 // Deprecated: Use getValidator() to retrieve algorithm-specific keys
+// Returns nil when the config was built with WithSealedKeys()
 func (c *Config) SigningKey() interface{} {
+	if c.sealedKeys {
+		return nil
+	}
 	algs := c.AvailableAlgorithms()
 	if len(algs) > 0 {
 		validator, _ := c.getValidator(algs[0])
@@ -185,3 +484,32 @@ func (c *Config) RequiredClaims() []string {
 func (c *Config) Logger() *slog.Logger {
 	return c.logger
 }
+
+// Audiences returns the audiences configured with WithAudience
+func (c *Config) Audiences() []string {
+	return c.audiences
+}
+
+// AudienceMatch returns whether any or all configured audiences must match
+func (c *Config) AudienceMatch() AudienceMatch {
+	return c.audienceMatch
+}
+
+// TenantClaim returns the claim configured with WithTenantClaim, or ""
+func (c *Config) TenantClaim() string {
+	return c.tenantClaim
+}
+
+// tenantFromClaims returns the tenant identifier from claims, or "" if not configured or absent
+func (c *Config) tenantFromClaims(claims *Claims) string {
+	if c.tenantClaim == "" || claims == nil {
+		return ""
+	}
+	tenant, _ := claims.GetString(c.tenantClaim)
+	return tenant
+}
+
+// KeysSealed reports whether the deprecated key accessors are disabled
+func (c *Config) KeysSealed() bool {
+	return c.sealedKeys
+}
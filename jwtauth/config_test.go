@@ -1,9 +1,17 @@
 package jwtauth
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // TestDualAlgorithmConfiguration tests dual-algorithm configuration scenarios (FR-001, FR-002)
@@ -176,6 +184,37 @@ func TestBackwardCompatibility(t *testing.T) {
 	}
 }
 
+// TestSealedKeys tests that WithSealedKeys disables the deprecated key accessors
+func TestSealedKeys(t *testing.T) {
+	hs256Secret := make([]byte, 32)
+	rand.Read(hs256Secret)
+
+	cfg, err := NewConfig(WithHS256(hs256Secret), WithSealedKeys())
+	if err != nil {
+		t.Fatalf("Failed to create sealed config: %v", err)
+	}
+
+	if !cfg.KeysSealed() {
+		t.Error("Expected KeysSealed() to return true")
+	}
+	if cfg.Algorithm() != "" {
+		t.Errorf("Expected Algorithm() to return empty string, got %s", cfg.Algorithm())
+	}
+	if cfg.SigningKey() != nil {
+		t.Error("Expected SigningKey() to return nil for sealed config")
+	}
+
+	// Sealing must not affect validation
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "user123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	tokenString, _ := token.SignedString(hs256Secret)
+	if _, err := parseAndValidateJWT(tokenString, cfg); err != nil {
+		t.Errorf("Expected sealed config to validate token, got %v", err)
+	}
+}
+
 // TestConfigValidatorIntegrity tests that validators are properly populated
 func TestConfigValidatorIntegrity(t *testing.T) {
 	hs256Secret := make([]byte, 32)
@@ -207,6 +246,190 @@ func TestConfigValidatorIntegrity(t *testing.T) {
 	}
 }
 
+// TestWithES256 tests ECDSA P-256 validation and key checks
+func TestWithES256(t *testing.T) {
+	key := mustGenerateECKey()
+	otherKey := mustGenerateECKey()
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithES256(&key.PublicKey))
+
+	claims := jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()}
+	sign := func(method jwt.SigningMethod, k interface{}) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode string
+	}{
+		{"ES256 token", sign(jwt.SigningMethodES256, key), ""},
+		{"HS256 token alongside", sign(jwt.SigningMethodHS256, secret), ""},
+		{"wrong key", sign(jwt.SigningMethodES256, otherKey), "INVALID_SIGNATURE"},
+		{"ES384 token", sign(jwt.SigningMethodES384, p384), "UNSUPPORTED_ALGORITHM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAndValidateJWT(tt.token, cfg)
+			if tt.wantCode == "" {
+				if err != nil || got.Subject != "user123" {
+					t.Errorf("Expected token to validate, got %v", err)
+				}
+				return
+			}
+			if getErrorCode(err) != tt.wantCode {
+				t.Errorf("Expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+
+	if _, err := NewConfig(WithES256(nil)); err == nil {
+		t.Error("Expected nil ES256 key to be rejected")
+	}
+	if _, err := NewConfig(WithES256(&p384.PublicKey)); err == nil {
+		t.Error("Expected P-384 key to be rejected for ES256")
+	}
+}
+
+// TestWithEdDSA tests Ed25519 validation alongside HS256
+func TestWithEdDSA(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	otherPub, otherPriv, _ := ed25519.GenerateKey(rand.Reader)
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithEdDSA(pub))
+
+	claims := jwt.MapClaims{"sub": "svc-a", "exp": time.Now().Add(time.Hour).Unix()}
+	signed, _ := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(priv)
+	if got, err := parseAndValidateJWT(signed, cfg); err != nil || got.Subject != "svc-a" {
+		t.Errorf("Expected EdDSA token to validate, got %v", err)
+	}
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(otherPriv)
+	if _, err := parseAndValidateJWT(forged, cfg); getErrorCode(err) != "INVALID_SIGNATURE" {
+		t.Errorf("Expected INVALID_SIGNATURE, got %v", err)
+	}
+	if _, err := parseAndValidateJWT(mustSignHS256(secret, claims), cfg); err != nil {
+		t.Errorf("Expected HS256 token to keep validating, got %v", err)
+	}
+
+	if _, err := NewConfig(WithEdDSA(otherPub[:16])); err == nil {
+		t.Error("Expected truncated EdDSA key to be rejected")
+	}
+	if _, err := NewConfig(WithEdDSA(nil)); err == nil {
+		t.Error("Expected nil EdDSA key to be rejected")
+	}
+}
+
+// TestWithRS384AndRS512 tests the longer-hash RSA options
+func TestWithRS384AndRS512(t *testing.T) {
+	key := mustGenerateRSAKey()
+	claims := jwt.MapClaims{"sub": "partner", "exp": time.Now().Add(time.Hour).Unix()}
+	cfg := mustCreateConfig(WithRS384(&key.PublicKey), WithRS512(&key.PublicKey))
+
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodRS384, jwt.SigningMethodRS512} {
+		signed, _ := jwt.NewWithClaims(method, claims).SignedString(key)
+		if got, err := parseAndValidateJWT(signed, cfg); err != nil || got.Subject != "partner" {
+			t.Errorf("Expected %s token to validate, got %v", method.Alg(), err)
+		}
+	}
+	rs256, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	if _, err := parseAndValidateJWT(rs256, cfg); getErrorCode(err) != "UNSUPPORTED_ALGORITHM" {
+		t.Errorf("Expected unconfigured RS256 to be rejected, got %v", err)
+	}
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodRS512, claims).SignedString(mustGenerateRSAKey())
+	if _, err := parseAndValidateJWT(forged, cfg); getErrorCode(err) != "INVALID_SIGNATURE" {
+		t.Errorf("Expected INVALID_SIGNATURE, got %v", err)
+	}
+	if _, err := NewConfig(WithRS512(nil)); err == nil {
+		t.Error("Expected nil RS512 key to be rejected")
+	}
+}
+
+// TestWithRSAPSS tests the PS256/PS384/PS512 options
+func TestWithRSAPSS(t *testing.T) {
+	key := mustGenerateRSAKey()
+	claims := jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()}
+	sign := func(method jwt.SigningMethod) string {
+		signed, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		option ConfigOption
+		method *jwt.SigningMethodRSAPSS
+	}{
+		{WithPS256(&key.PublicKey), jwt.SigningMethodPS256},
+		{WithPS384(&key.PublicKey), jwt.SigningMethodPS384},
+		{WithPS512(&key.PublicKey), jwt.SigningMethodPS512},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method.Alg(), func(t *testing.T) {
+			cfg := mustCreateConfig(tt.option)
+			if got, err := parseAndValidateJWT(sign(tt.method), cfg); err != nil || got.Subject != "user123" {
+				t.Errorf("Expected %s token to validate, got %v", tt.method.Alg(), err)
+			}
+			// Same key, other RSA algorithms must not be accepted
+			for _, other := range []jwt.SigningMethod{jwt.SigningMethodRS256, jwt.SigningMethodPS256, jwt.SigningMethodPS512} {
+				if other.Alg() == tt.method.Alg() {
+					continue
+				}
+				if _, err := parseAndValidateJWT(sign(other), cfg); getErrorCode(err) != "UNSUPPORTED_ALGORITHM" {
+					t.Errorf("Expected %s token to be rejected, got %v", other.Alg(), err)
+				}
+			}
+		})
+	}
+
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodPS256, claims).SignedString(mustGenerateRSAKey())
+	if _, err := parseAndValidateJWT(forged, mustCreateConfig(WithPS256(&key.PublicKey))); getErrorCode(err) != "INVALID_SIGNATURE" {
+		t.Errorf("Expected INVALID_SIGNATURE, got %v", err)
+	}
+	if _, err := NewConfig(WithPS384(nil)); err == nil {
+		t.Error("Expected nil PS384 key to be rejected")
+	}
+}
+
+// TestWithHS384AndHS512 tests the longer HMAC variants and their secret sizes
+func TestWithHS384AndHS512(t *testing.T) {
+	secret := []byte(strings.Repeat("s", 64))
+	claims := jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()}
+
+	tests := []struct {
+		name    string
+		option  func([]byte) ConfigOption
+		method  *jwt.SigningMethodHMAC
+		minSize int
+	}{
+		{"HS384", WithHS384, jwt.SigningMethodHS384, 48},
+		{"HS512", WithHS512, jwt.SigningMethodHS512, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mustCreateConfig(tt.option(secret[:tt.minSize]))
+			signed, _ := jwt.NewWithClaims(tt.method, claims).SignedString(secret[:tt.minSize])
+			if _, err := parseAndValidateJWT(signed, cfg); err != nil {
+				t.Errorf("Expected %s token to validate, got %v", tt.name, err)
+			}
+			// An HS256 token signed with the same secret is not accepted
+			if _, err := parseAndValidateJWT(mustSignHS256(secret[:tt.minSize], claims), cfg); getErrorCode(err) != "UNSUPPORTED_ALGORITHM" {
+				t.Errorf("Expected HS256 token to be rejected, got %v", err)
+			}
+
+			_, err := NewConfig(tt.option(secret[:tt.minSize-1]))
+			if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("at least %d bytes", tt.minSize)) {
+				t.Errorf("Expected short %s secret to be rejected, got %v", tt.name, err)
+			}
+		})
+	}
+}
+
 // contains checks if a string contains a substring
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
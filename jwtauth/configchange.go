@@ -0,0 +1,209 @@
+package jwtauth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources of config_change events
+const (
+	changeSourceReload        = "reload"         // LogConfigChange
+	changeSourceJWKSRefresh   = "jwks_refresh"   // Remote JWKS document changed
+	changeSourceRotation      = "rotation"       // RotationManager changed its published keys
+	changeSourceSecretRefresh = "secret_refresh" // WithHS256ProviderRefresh loaded a new secret
+	changeSourceFileReload    = "file_reload"    // WithKeyFileReload loaded a changed key file
+)
+
+// keyChange describes a change of the keys served by a key provider
+type keyChange struct {
+	source        string
+	before, after []string // keyLabel values
+}
+
+// keyWatcher is implemented by key providers whose keys change at runtime
+type keyWatcher interface {
+	watchKeys(fn func(keyChange))
+}
+
+// keyWatchers notifies registered functions of key changes. Providers embed
+// it to implement keyWatcher.
+type keyWatchers struct {
+	mu  sync.Mutex
+	fns []func(keyChange)
+}
+
+// watchKeys implements keyWatcher
+func (w *keyWatchers) watchKeys(fn func(keyChange)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.fns = append(w.fns, fn)
+}
+
+// notifyKeys reports a change from before to after, unless they are equal
+func (w *keyWatchers) notifyKeys(source string, before, after []string) {
+	if slices.Equal(before, after) {
+		return
+	}
+	w.mu.Lock()
+	fns := w.fns
+	w.mu.Unlock()
+	for _, fn := range fns {
+		fn(keyChange{source: source, before: before, after: after})
+	}
+}
+
+// keyLabel identifies a key in config_change events: "kid=SHA256:..." for
+// public keys and a truncated digest for secrets, which is enough to tell
+// secrets apart without revealing them
+func keyLabel(kid string, key interface{}) string {
+	fingerprint := keyFingerprint(key)
+	if secret, ok := key.([]byte); ok {
+		sum := sha256.Sum256(secret)
+		fingerprint = "secret:" + hex.EncodeToString(sum[:4])
+	}
+	if kid == "" {
+		return fingerprint
+	}
+	return kid + "=" + fingerprint
+}
+
+// keyLabels returns the sorted labels of keys by kid
+func keyLabels(keys map[string]interface{}) []string {
+	labels := make([]string, 0, len(keys))
+	for kid, key := range keys {
+		labels = append(labels, keyLabel(kid, key))
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// watchKeyChanges logs a config_change event whenever a key provider of
+// this Config swaps keys. Only configs with a logger register, so rebuilt
+// configs sharing a provider do not accumulate silent watchers.
+func (c *Config) watchKeyChanges() {
+	if c.logger == nil {
+		return
+	}
+	var watchers []keyWatcher
+	algs := map[keyWatcher][]string{}
+	for _, alg := range c.AvailableAlgorithms() {
+		w, ok := c.validators[alg].keyProvider.(keyWatcher)
+		if !ok {
+			continue
+		}
+		if _, seen := algs[w]; !seen {
+			watchers = append(watchers, w)
+		}
+		algs[w] = append(algs[w], alg)
+	}
+	for _, w := range watchers {
+		alg := strings.Join(algs[w], ",")
+		w.watchKeys(func(change keyChange) {
+			c.logConfigChange(change.source, alg, []string{"keys"}, change.before, change.after)
+		})
+	}
+}
+
+// LogConfigChange compares the settings and static keys of the Config
+// being replaced with its successor and, when they differ, logs a
+// config_change event to after's logger. Call it when swapping Configs on a
+// hot reload, e.g. from AdminOptions.Reload. It returns the changed fields.
+//
+// Key changes made by providers at runtime (JWKS refresh, RotationManager,
+// WithHS256ProviderRefresh, WithKeyFileReload) are logged automatically
+// when a logger is set.
+func LogConfigChange(before, after *Config) []string {
+	old, updated := before.configSummary(), after.configSummary()
+	var changed []string
+	for field, value := range updated {
+		if old[field] != value {
+			changed = append(changed, field)
+		}
+	}
+	for field := range old {
+		if _, ok := updated[field]; !ok {
+			changed = append(changed, field)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	sort.Strings(changed)
+	after.logConfigChange(changeSourceReload, strings.Join(after.AvailableAlgorithms(), ","), changed, before.staticKeyLabels(), after.staticKeyLabels())
+	return changed
+}
+
+// configSummary describes the settings that decide whether a token is
+// accepted, by field name
+func (c *Config) configSummary() map[string]string {
+	required := slices.Clone(c.requiredClaims)
+	sort.Strings(required)
+	audiences := slices.Clone(c.audiences)
+	sort.Strings(audiences)
+	issuers := slices.Clone(c.issuers)
+	sort.Strings(issuers)
+
+	summary := map[string]string{
+		"algorithms":           strings.Join(c.AvailableAlgorithms(), ","),
+		"keys":                 strings.Join(c.staticKeyLabels(), ","),
+		"audiences":            fmt.Sprintf("%q/%d", audiences, c.audienceMatch),
+		"issuers":              fmt.Sprintf("%q", issuers),
+		"required_claims":      fmt.Sprintf("%q", required),
+		"claim_requirements":   fmt.Sprintf("%v", c.claimRequirements),
+		"clock_skew":           c.clockSkewLeeway.String(),
+		"delegation":           fmt.Sprintf("%t", c.delegationValidation),
+		"method_requirements":  fmt.Sprintf("%v", c.methodRequirements),
+		"route_requirements":   fmt.Sprintf("%v", c.routeRules),
+		"token_cache_ttl":      c.tokenCacheTTL.String(),
+		"blocklist":            fmt.Sprintf("%T", c.blocklist),
+		"reject_embedded_keys": fmt.Sprintf("%t", c.rejectEmbeddedKeys),
+		"faults":               fmt.Sprintf("%v", c.faults),
+		"token_types":          fmt.Sprintf("%q", c.tokenTypes),
+		"min_rsa_key_bits":     fmt.Sprintf("%d", c.minRSAKeyBits),
+		"role_claim":           c.roleClaim,
+		"policy":               fmt.Sprintf("%T", c.policy),
+	}
+	if c.claimSchema != nil {
+		summary["claim_schema"] = c.claimSchema.digest
+	}
+	return summary
+}
+
+// staticKeyLabels returns labels for the configured keys: static keys, key
+// sets and, for providers, the provider type
+func (c *Config) staticKeyLabels() []string {
+	var labels []string
+	for _, alg := range c.AvailableAlgorithms() {
+		validator := c.validators[alg]
+		switch {
+		case validator.keySet != nil:
+			for _, label := range keyLabels(validator.keySet.keys) {
+				labels = append(labels, alg+":"+label)
+			}
+		case validator.signingKey != nil:
+			labels = append(labels, alg+":"+keyLabel("", validator.signingKey))
+		default:
+			labels = append(labels, fmt.Sprintf("%s:provider=%T", alg, validator.keyProvider))
+		}
+	}
+	return labels
+}
+
+// logConfigChange emits a config_change security event
+func (c *Config) logConfigChange(source, alg string, fields, before, after []string) {
+	logSecurityEvent(c.Logger(), SecurityEvent{
+		EventType:     "config_change",
+		Timestamp:     time.Now(),
+		Algorithm:     alg,
+		ChangeSource:  source,
+		ChangedFields: fields,
+		KeysBefore:    before,
+		KeysAfter:     after,
+	})
+}
@@ -0,0 +1,140 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// configChangeEvents returns the auth_event of every config_change entry in JSON logs
+func configChangeEvents(t *testing.T, logs *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var events []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry struct {
+			Event map[string]interface{} `json:"auth_event"`
+		}
+		if line == "" || json.Unmarshal([]byte(line), &entry) != nil {
+			continue
+		}
+		if entry.Event["event"] == "config_change" {
+			events = append(events, entry.Event)
+		}
+	}
+	return events
+}
+
+// TestLogConfigChange tests the config_change event for a hot reload
+func TestLogConfigChange(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	oldSecret := []byte("old-secret-key-min-32-bytes-long!!!")
+	newSecret := []byte("new-secret-key-min-32-bytes-long!!!")
+
+	before := mustCreateConfig(WithHS256(oldSecret), WithAudience("api"), WithLogger(logger))
+	after := mustCreateConfig(WithHS256(newSecret), WithAudience("api", "admin"), WithLogger(logger))
+
+	changed := LogConfigChange(before, after)
+	if want := []string{"audiences", "keys"}; !slices.Equal(changed, want) {
+		t.Errorf("expected changed fields %v, got %v", want, changed)
+	}
+	events := configChangeEvents(t, &logs)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 config_change event, got %d: %s", len(events), logs.String())
+	}
+	event := events[0]
+	if event["change_source"] != "reload" {
+		t.Errorf("expected source reload, got %v", event["change_source"])
+	}
+	if event["keys_before"] == nil || event["keys_after"] == nil {
+		t.Errorf("expected key fingerprints before and after, got %v", event)
+	}
+	if strings.Contains(logs.String(), string(oldSecret)) || strings.Contains(logs.String(), string(newSecret)) {
+		t.Error("secret leaked into config_change event")
+	}
+
+	logs.Reset()
+	if changed := LogConfigChange(after, mustCreateConfig(WithHS256(newSecret), WithAudience("admin", "api"), WithLogger(logger))); changed != nil {
+		t.Errorf("expected no changes for equivalent configs, got %v", changed)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("expected no event for equivalent configs, got %s", logs.String())
+	}
+}
+
+// TestConfigChangeJWKSRefresh tests the config_change event for a rotated JWKS document
+func TestConfigChangeJWKSRefresh(t *testing.T) {
+	oldKey, newKey := mustGenerateRSAKey(), mustGenerateRSAKey()
+	var doc atomic.Value
+	setKeys := func(keys ...JSONWebKey) {
+		data, _ := json.Marshal(map[string]interface{}{"keys": keys})
+		doc.Store(data)
+	}
+	setKeys(JSONWebKey{KeyID: "old", Algorithm: "RS256", Key: &oldKey.PublicKey})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(doc.Load().([]byte))
+	}))
+	t.Cleanup(srv.Close)
+
+	var logs bytes.Buffer
+	cfg := mustCreateConfig(
+		WithJWKSURL(srv.URL),
+		WithKeyPrefetch(time.Second),
+		WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+	)
+	if events := configChangeEvents(t, &logs); len(events) != 0 {
+		t.Fatalf("expected no event for the initial load, got %v", events)
+	}
+
+	// An unchanged document is not a change
+	if err := cfg.Prefetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if events := configChangeEvents(t, &logs); len(events) != 0 {
+		t.Fatalf("expected no event for an unchanged document, got %v", events)
+	}
+
+	setKeys(
+		JSONWebKey{KeyID: "old", Algorithm: "RS256", Key: &oldKey.PublicKey},
+		JSONWebKey{KeyID: "new", Algorithm: "RS256", Key: &newKey.PublicKey},
+	)
+	if err := cfg.Prefetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	events := configChangeEvents(t, &logs)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 config_change event, got %d", len(events))
+	}
+	if events[0]["change_source"] != "jwks_refresh" || events[0]["algorithm"] != "RS256" {
+		t.Errorf("unexpected event %v", events[0])
+	}
+	before, _ := events[0]["keys_before"].([]interface{})
+	after, _ := events[0]["keys_after"].([]interface{})
+	if len(before) != 1 || len(after) != 2 || !strings.HasPrefix(after[0].(string), "new=SHA256:") {
+		t.Errorf("expected key fingerprints [old] -> [new old], got %v -> %v", before, after)
+	}
+}
+
+// TestConfigChangeRotation tests the config_change event for RotationManager key changes
+func TestConfigChangeRotation(t *testing.T) {
+	manager, _ := newTestRotationManager(t)
+
+	var logs bytes.Buffer
+	mustCreateConfig(WithKeyProvider("ES256", manager), WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+	if err := manager.Rotate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	events := configChangeEvents(t, &logs)
+	if len(events) != 1 || events[0]["change_source"] != "rotation" {
+		t.Fatalf("expected 1 rotation event, got %v", events)
+	}
+}
@@ -0,0 +1,140 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/stats"
+)
+
+// connCacheContextKey marks contexts carrying a per-connection claims cache
+type connCacheContextKey struct{}
+
+// connCacheKey identifies a validated token under a specific config
+type connCacheKey struct {
+	cfg   *Config
+	token string
+}
+
+// connClaimsCache holds claims validated on a single connection
+type connClaimsCache struct {
+	mu      sync.Mutex
+	entries map[connCacheKey]*Claims
+}
+
+// ConnCacheStats reports per-connection claims cache effectiveness
+type ConnCacheStats struct {
+	Hits   uint64 // Requests served from the connection cache
+	Misses uint64 // Requests that required full validation
+}
+
+// connCacheCounters are the live counters behind ConnCacheStats
+type connCacheCounters struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// WithConnectionClaimsCache caches validated claims per client connection so
+// HTTP/2 and gRPC clients re-sending an identical token skip signature
+// verification until the token expires. maxEntries bounds distinct tokens
+// cached per connection.
+//
+// Requires installing the per-connection cache: set http.Server.ConnContext
+// to ConnContext for HTTP, or add grpc.StatsHandler(ConnStatsHandler()) for gRPC.
+func WithConnectionClaimsCache(maxEntries int) ConfigOption {
+	return func(c *Config) error {
+		if maxEntries <= 0 {
+			return fmt.Errorf("connection cache size must be positive, got %d", maxEntries)
+		}
+		c.connCacheSize = maxEntries
+		c.connCacheCounters = &connCacheCounters{}
+		return nil
+	}
+}
+
+// ConnContext installs a per-connection claims cache. Use as http.Server.ConnContext.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connCacheContextKey{}, &connClaimsCache{
+		entries: make(map[connCacheKey]*Claims),
+	})
+}
+
+// connStatsHandler installs a per-connection claims cache for gRPC connections
+type connStatsHandler struct{}
+
+// ConnStatsHandler returns a gRPC stats.Handler that installs a per-connection
+// claims cache. Use with grpc.StatsHandler(jwtauth.ConnStatsHandler()).
+func ConnStatsHandler() stats.Handler {
+	return connStatsHandler{}
+}
+
+func (connStatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ConnContext(ctx, nil)
+}
+
+func (connStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (connStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+func (connStatsHandler) HandleRPC(context.Context, stats.RPCStats) {}
+
+// ConnCacheStats returns hit/miss counts for the per-connection claims cache
+func (c *Config) ConnCacheStats() ConnCacheStats {
+	if c.connCacheCounters == nil {
+		return ConnCacheStats{}
+	}
+	return ConnCacheStats{
+		Hits:   c.connCacheCounters.hits.Load(),
+		Misses: c.connCacheCounters.misses.Load(),
+	}
+}
+
+// validateWithConnCache returns cached claims for a token already validated on
+// this connection, falling back to full validation and caching the result
+func validateWithConnCache(ctx context.Context, tokenString string, cfg *Config) (*Claims, error) {
+	cache, ok := ctx.Value(connCacheContextKey{}).(*connClaimsCache)
+	if cfg.connCacheSize == 0 || !ok {
+		return validateWithTokenCache(ctx, tokenString, cfg)
+	}
+
+	key := connCacheKey{cfg: cfg, token: tokenString}
+	now := time.Now()
+
+	cache.mu.Lock()
+	claims, found := cache.entries[key]
+	if found && !claims.ExpiresAt.IsZero() && now.After(claims.ExpiresAt.Add(cfg.ClockSkewLeeway())) {
+		delete(cache.entries, key)
+		found = false
+	}
+	cache.mu.Unlock()
+
+	if found {
+		cfg.connCacheCounters.hits.Add(1)
+		return claims, nil
+	}
+	cfg.connCacheCounters.misses.Add(1)
+
+	claims, err := validateWithTokenCache(ctx, tokenString, cfg)
+	if err != nil || claims.degraded {
+		return claims, err
+	}
+
+	cache.mu.Lock()
+	if len(cache.entries) >= cfg.connCacheSize {
+		// Evict an arbitrary entry; connections rarely rotate through many tokens
+		for k := range cache.entries {
+			delete(cache.entries, k)
+			break
+		}
+	}
+	cache.entries[key] = claims
+	cache.mu.Unlock()
+
+	return claims, nil
+}
@@ -0,0 +1,101 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// TestConnectionClaimsCacheHTTP tests that repeated tokens on one connection hit the cache
+func TestConnectionClaimsCacheHTTP(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithConnectionClaimsCache(4))
+
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	router.GET("/protected", func(c *gin.Context) { c.Status(200) })
+
+	tokenString := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+	connCtx := ConnContext(context.Background(), nil)
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/protected", nil).WithContext(connCtx)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != 200 {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+
+	stats := cfg.ConnCacheStats()
+	if stats.Misses != 1 || stats.Hits != 2 {
+		t.Errorf("Expected 1 miss and 2 hits, got %+v", stats)
+	}
+
+	// A new connection starts with an empty cache
+	req := httptest.NewRequest("GET", "/protected", nil).WithContext(ConnContext(context.Background(), nil))
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if stats := cfg.ConnCacheStats(); stats.Misses != 2 {
+		t.Errorf("Expected miss on new connection, got %+v", stats)
+	}
+
+	// Invalid tokens are never cached
+	req = httptest.NewRequest("GET", "/protected", nil).WithContext(connCtx)
+	req.Header.Set("Authorization", "Bearer "+tokenString+"x")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != 401 {
+		t.Errorf("Expected 401 for tampered token, got %d", w.Code)
+	}
+}
+
+// TestConnectionClaimsCacheExpiry tests that cached claims are dropped once the token expires
+func TestConnectionClaimsCacheExpiry(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithConnectionClaimsCache(4), WithClockSkew(0))
+
+	tokenString := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Second).Unix()})
+	ctx := ConnContext(context.Background(), nil)
+
+	if _, err := validateWithConnCache(ctx, tokenString, cfg); err != nil {
+		t.Fatalf("Expected token to validate, got %v", err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := validateWithConnCache(ctx, tokenString, cfg); err == nil {
+		t.Error("Expected expired token to be rejected despite cache")
+	}
+}
+
+// TestConnectionClaimsCacheGRPC tests per-connection caching through ConnStatsHandler
+func TestConnectionClaimsCacheGRPC(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithConnectionClaimsCache(4))
+
+	conn := startTestGRPCServer(t,
+		grpc.StatsHandler(ConnStatsHandler()),
+		grpc.UnaryInterceptor(UnaryServerInterceptor(cfg)),
+	)
+	client := healthpb.NewHealthClient(conn)
+
+	tokenString := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+tokenString)
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("Call %d failed: %v", i+1, err)
+		}
+	}
+
+	if stats := cfg.ConnCacheStats(); stats.Misses != 1 || stats.Hits != 2 {
+		t.Errorf("Expected 1 miss and 2 hits, got %+v", stats)
+	}
+}
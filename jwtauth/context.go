@@ -6,8 +6,14 @@ import "context"
 type contextKey string
 
 const (
-	claimsContextKey    contextKey = "github.com/user/vibrant-auth-middleware-go/jwtauth:claims"
-	requestIDContextKey contextKey = "github.com/user/vibrant-auth-middleware-go/jwtauth:request_id"
+	claimsContextKey        contextKey = "github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth:claims"
+	requestIDContextKey     contextKey = "github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth:request_id"
+	tenantContextKey        contextKey = "github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth:tenant"
+	clientIPContextKey      contextKey = "github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth:client_ip"
+	deviceContextKey        contextKey = "github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth:device"
+	serviceClaimsContextKey contextKey = "github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth:service_claims"
+	tokenContextKey         contextKey = "github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth:token"
+	configContextKey        contextKey = "github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth:config"
 )
 
 // WithClaims stores validated JWT claims in the request context.
@@ -34,6 +40,32 @@ func MustGetClaims(ctx context.Context) *Claims {
 	return claims
 }
 
+// WithToken stores the validated inbound token in context
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenContextKey, token)
+}
+
+// GetToken retrieves the inbound token whose claims GetClaims returns, for
+// exchanging it with an OnBehalfOf token source. Never log or forward it
+// as-is to services that are not its audience.
+func GetToken(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenContextKey).(string)
+	return token, ok && token != ""
+}
+
+// WithServiceClaims stores the calling service's validated claims in context
+func WithServiceClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, serviceClaimsContextKey, claims)
+}
+
+// GetServiceClaims retrieves the claims of the service token validated by
+// JWTAuthService or ServiceUnaryServerInterceptor. The user's claims remain
+// available through GetClaims.
+func GetServiceClaims(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(serviceClaimsContextKey).(*Claims)
+	return claims, ok
+}
+
 // WithRequestID stores a request ID in context for correlation
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, requestIDContextKey, requestID)
@@ -44,3 +76,34 @@ func GetRequestID(ctx context.Context) (string, bool) {
 	id, ok := ctx.Value(requestIDContextKey).(string)
 	return id, ok
 }
+
+// WithTenant stores the tenant identifier in context
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey, tenant)
+}
+
+// GetTenant retrieves the tenant identifier extracted via WithTenantClaim.
+// Returns "", false if no tenant claim is configured or the token had none.
+func GetTenant(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey).(string)
+	return tenant, ok
+}
+
+// WithClientIP stores the client IP address in context
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPContextKey, ip)
+}
+
+// GetClientIP retrieves the client IP derived by the middleware, honoring
+// WithTrustedProxies. Returns "", false for transports without a peer
+// address (message buses) or when none was recorded.
+func GetClientIP(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPContextKey).(string)
+	return ip, ok && ip != ""
+}
+
+// withConfig stores the configuration that authenticated the request, for
+// middleware and interceptors chained after JWTAuth and the gRPC interceptors
+func withConfig(ctx context.Context, cfg *Config) context.Context {
+	return context.WithValue(ctx, configContextKey, cfg)
+}
@@ -0,0 +1,128 @@
+package jwtauth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig configures the CORS headers added to error responses
+type CORSConfig struct {
+	AllowedOrigins   []string // Origins to echo back; "*" allows any origin
+	AllowCredentials bool     // Send Access-Control-Allow-Credentials: true
+	ExposeHeaders    []string // Response headers readable by scripts (default Retry-After)
+	AllowMethods     []string // Methods allowed in preflights (default GET, HEAD, POST, PUT, PATCH, DELETE)
+	AllowHeaders     []string // Request headers allowed in preflights (default Authorization, Content-Type and the gRPC-Web headers)
+}
+
+// WithCORS adds CORS headers to the middleware's error responses so browsers
+// expose the status and reason to the calling script instead of reporting an
+// opaque network error. Headers already set by an earlier CORS middleware
+// (e.g. gin-contrib/cors mounted before JWTAuth) are left untouched.
+//
+// With WithCORS the middleware also answers preflight OPTIONS requests
+// itself: 204 with the allowed methods and headers for an allowed origin,
+// 403 otherwise. Preflights carry no credentials, so the next handler never
+// runs for them.
+func WithCORS(cors CORSConfig) ConfigOption {
+	return func(c *Config) error {
+		if len(cors.AllowedOrigins) == 0 {
+			return fmt.Errorf("CORS requires at least one allowed origin")
+		}
+		for _, origin := range cors.AllowedOrigins {
+			if origin == "*" && cors.AllowCredentials {
+				return fmt.Errorf("CORS wildcard origin cannot be combined with credentials")
+			}
+		}
+		if cors.ExposeHeaders == nil {
+			cors.ExposeHeaders = []string{"Retry-After"}
+		}
+		if cors.AllowMethods == nil {
+			cors.AllowMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+		}
+		if cors.AllowHeaders == nil {
+			cors.AllowHeaders = []string{"Authorization", "Content-Type", "X-Grpc-Web", "X-User-Agent", "Grpc-Timeout"}
+		}
+		c.cors = &cors
+		return nil
+	}
+}
+
+// isPreflight reports whether the request is a CORS preflight that the
+// middleware answers itself
+func isPreflight(c *gin.Context, cfg *Config) bool {
+	return isPreflightRequest(c.Request, cfg)
+}
+
+// abortPreflight answers a CORS preflight without calling the next handler
+func abortPreflight(c *gin.Context, cfg *Config) {
+	c.AbortWithStatus(preflightResponse(c.Writer.Header(), c.Request, cfg))
+}
+
+// isPreflightRequest implements isPreflight for net/http requests
+func isPreflightRequest(r *http.Request, cfg *Config) bool {
+	return cfg.cors != nil &&
+		r.Method == http.MethodOptions &&
+		r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// preflightResponse sets the preflight response headers and returns the
+// status to answer with: 204 for an allowed origin, 403 otherwise. A CORS
+// middleware that already answered leaves the headers untouched.
+func preflightResponse(h http.Header, r *http.Request, cfg *Config) int {
+	if h.Get("Access-Control-Allow-Origin") != "" {
+		return http.StatusNoContent
+	}
+	if !setCORSResponseHeaders(h, r, cfg) {
+		return http.StatusForbidden
+	}
+	h.Set("Access-Control-Allow-Methods", strings.Join(cfg.cors.AllowMethods, ", "))
+	h.Set("Access-Control-Allow-Headers", strings.Join(cfg.cors.AllowHeaders, ", "))
+	return http.StatusNoContent
+}
+
+// setCORSHeaders adds CORS headers for an allowed Origin unless a CORS
+// middleware has already handled the request
+func setCORSHeaders(c *gin.Context, cfg *Config) {
+	setCORSResponseHeaders(c.Writer.Header(), c.Request, cfg)
+}
+
+// setCORSResponseHeaders implements setCORSHeaders for net/http responses
+// and reports whether the origin was allowed
+func setCORSResponseHeaders(h http.Header, r *http.Request, cfg *Config) bool {
+	if cfg.cors == nil {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" || h.Get("Access-Control-Allow-Origin") != "" {
+		return false
+	}
+
+	allowed := ""
+	for _, o := range cfg.cors.AllowedOrigins {
+		if o == "*" {
+			allowed = "*"
+			break
+		}
+		if strings.EqualFold(o, origin) {
+			allowed = origin
+			break
+		}
+	}
+	h.Add("Vary", "Origin")
+	if allowed == "" {
+		return false
+	}
+
+	h.Set("Access-Control-Allow-Origin", allowed)
+	if cfg.cors.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(cfg.cors.ExposeHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(cfg.cors.ExposeHeaders, ", "))
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestCORSErrorResponses tests CORS headers on the abort path
+func TestCORSErrorResponses(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithCORS(CORSConfig{
+			AllowedOrigins:   []string{"https://app.example.com"},
+			AllowCredentials: true,
+		}),
+	)
+	router := createTestRouter(cfg)
+
+	t.Run("allowed origin", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected 401, got %d", w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("Expected origin to be echoed, got %q", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("Expected credentials header, got %q", got)
+		}
+		if got := w.Header().Get("Access-Control-Expose-Headers"); got != "Retry-After" {
+			t.Errorf("Expected Retry-After to be exposed, got %q", got)
+		}
+		if got := w.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("Expected Vary: Origin, got %q", got)
+		}
+	})
+
+	t.Run("unknown origin", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Expected no CORS headers for unknown origin, got %q", got)
+		}
+	})
+
+	t.Run("preflight answered without the handler", func(t *testing.T) {
+		r := gin.New()
+		r.Use(JWTAuth(cfg))
+		handlerRan := false
+		r.Handle(http.MethodOptions, "/protected", func(c *gin.Context) { handlerRan = true })
+
+		req := httptest.NewRequest(http.MethodOptions, "/protected", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if handlerRan {
+			t.Error("Expected the handler not to run for a preflight")
+		}
+		if w.Code != http.StatusNoContent {
+			t.Errorf("Expected 204, got %d", w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, HEAD, POST, PUT, PATCH, DELETE" {
+			t.Errorf("Expected default allowed methods, got %q", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, "Authorization") {
+			t.Errorf("Expected Authorization to be allowed, got %q", got)
+		}
+
+		req.Header.Set("Origin", "https://evil.example.com")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if handlerRan || w.Code != http.StatusForbidden {
+			t.Errorf("Expected 403 for an unknown origin, got %d (handler ran: %v)", w.Code, handlerRan)
+		}
+	})
+
+	t.Run("earlier CORS middleware wins", func(t *testing.T) {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Header("Access-Control-Allow-Origin", "*")
+			c.Next()
+		})
+		r.Use(JWTAuth(cfg))
+		r.GET("/protected", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("Expected existing CORS header to be kept, got %q", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("Expected no headers added, got credentials %q", got)
+		}
+	})
+}
+
+// TestWithCORSValidation tests CORS option validation
+func TestWithCORSValidation(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	if _, err := NewConfig(WithHS256(secret), WithCORS(CORSConfig{})); err == nil {
+		t.Error("Expected empty origin list to be rejected")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithCORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})); err == nil {
+		t.Error("Expected wildcard with credentials to be rejected")
+	}
+}
@@ -0,0 +1,97 @@
+package jwtauth
+
+import (
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// WithDelegationValidation enforces RFC 8693 may_act constraints for
+// services that accept tokens acting on behalf of users:
+//
+//   - a token with an act claim is accepted only if its may_act claim
+//     authorizes the actor (act.sub, and act.iss when may_act names an issuer)
+//   - a token with may_act but no act is accepted only if the presenting
+//     client (azp) is authorized by may_act
+//   - tokens with neither claim are not delegated and pass unchanged
+//
+// may_act may be a single object or, as some issuers emit, an array of them.
+// Only the current actor (the outermost act) is checked. Failures return
+// DELEGATION_NOT_ALLOWED.
+func WithDelegationValidation() ConfigOption {
+	return func(c *Config) error {
+		c.delegationValidation = true
+		return nil
+	}
+}
+
+// validateDelegation checks the actor against the may_act claim
+func validateDelegation(mapClaims jwt.MapClaims, cfg *Config) error {
+	if !cfg.delegationValidation {
+		return nil
+	}
+
+	mayAct, hasMayAct := mapClaims["may_act"]
+	actorSub, actorIss, hasActor := currentActor(mapClaims)
+	if !hasActor {
+		if !hasMayAct {
+			return nil // Not a delegated token
+		}
+		azp, _ := mapClaims["azp"].(string)
+		if azp == "" {
+			return NewValidationError(ErrDelegationNotAllowed, "token has may_act but no presenting client (azp)", nil)
+		}
+		actorSub = azp
+	}
+
+	if !hasMayAct {
+		return NewValidationError(ErrDelegationNotAllowed, fmt.Sprintf("actor %s is not authorized: may_act claim missing", actorSub), nil)
+	}
+	if !mayActAllows(mayAct, actorSub, actorIss) {
+		return NewValidationError(ErrDelegationNotAllowed, fmt.Sprintf("actor %s is not authorized by may_act", actorSub), nil)
+	}
+	return nil
+}
+
+// currentActor returns the subject and issuer of the outermost act claim
+func currentActor(mapClaims jwt.MapClaims) (sub, iss string, ok bool) {
+	act, ok := mapClaims["act"].(map[string]interface{})
+	if !ok {
+		return "", "", false
+	}
+	sub, _ = act["sub"].(string)
+	iss, _ = act["iss"].(string)
+	return sub, iss, true
+}
+
+// mayActAllows reports whether any may_act entry matches the actor. An
+// entry's sub must equal the actor; its iss, when present, must equal the
+// actor's issuer.
+func mayActAllows(mayAct interface{}, sub, iss string) bool {
+	if sub == "" {
+		return false
+	}
+
+	var entries []interface{}
+	switch v := mayAct.(type) {
+	case map[string]interface{}:
+		entries = []interface{}{v}
+	case []interface{}:
+		entries = v
+	}
+
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if entrySub, _ := entry["sub"].(string); entrySub != sub {
+			continue
+		}
+		if entryIss, ok := entry["iss"].(string); ok && entryIss != iss {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package jwtauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestDelegationValidation tests may_act enforcement
+func TestDelegationValidation(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithDelegationValidation())
+
+	broker := map[string]interface{}{"sub": "broker-service", "iss": "https://idp.example.com"}
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		wantErr bool
+	}{
+		{"ordinary token", jwt.MapClaims{"azp": "web-app"}, false},
+		{"actor allowed", jwt.MapClaims{"act": broker, "may_act": map[string]interface{}{"sub": "broker-service"}}, false},
+		{"actor allowed with issuer", jwt.MapClaims{"act": broker, "may_act": broker}, false},
+		{"actor allowed by array entry", jwt.MapClaims{"act": broker, "may_act": []interface{}{map[string]interface{}{"sub": "other"}, broker}}, false},
+		{"actor not listed", jwt.MapClaims{"act": broker, "may_act": map[string]interface{}{"sub": "other"}}, true},
+		{"actor issuer mismatch", jwt.MapClaims{"act": broker, "may_act": map[string]interface{}{"sub": "broker-service", "iss": "https://evil.example.com"}}, true},
+		{"actor without may_act", jwt.MapClaims{"act": broker}, true},
+		{"presenting client allowed", jwt.MapClaims{"azp": "broker-service", "may_act": map[string]interface{}{"sub": "broker-service"}}, false},
+		{"presenting client not allowed", jwt.MapClaims{"azp": "web-app", "may_act": map[string]interface{}{"sub": "broker-service"}}, true},
+		{"may_act without client", jwt.MapClaims{"may_act": map[string]interface{}{"sub": "broker-service"}}, true},
+		{"actor without subject", jwt.MapClaims{"act": map[string]interface{}{}, "may_act": map[string]interface{}{"sub": ""}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims["sub"] = "user123"
+			tt.claims["exp"] = time.Now().Add(time.Hour).Unix()
+
+			_, err := parseAndValidateJWT(mustSignHS256(secret, tt.claims), cfg)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Expected token to validate, got %v", err)
+				}
+				return
+			}
+			if getErrorCode(err) != string(ErrDelegationNotAllowed) {
+				t.Errorf("Expected DELEGATION_NOT_ALLOWED, got %v", err)
+			}
+		})
+	}
+}
+
+// TestDelegationValidationDisabled tests that may_act is ignored by default
+func TestDelegationValidationDisabled(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret))
+
+	token := mustSignHS256(secret, jwt.MapClaims{
+		"sub": "user123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"act": map[string]interface{}{"sub": "anyone"},
+	})
+	if _, err := parseAndValidateJWT(token, cfg); err != nil {
+		t.Errorf("Expected token to validate without delegation checks, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// WithDetachedPayloads enables VerifyDetachedJWS for partners that sign
+// content with detached JWS (RFC 7515 Appendix F), including the RFC 7797
+// unencoded payload option ("b64": false). Bearer tokens are unaffected:
+// tokens with detached or unencoded payloads are still rejected by the
+// middleware, since they carry no claims.
+func WithDetachedPayloads() ConfigOption {
+	return func(c *Config) error {
+		c.detachedPayloads = true
+		return nil
+	}
+}
+
+// VerifyDetachedJWS verifies a compact JWS with a detached payload
+// ("<header>..<signature>") over payload, using the configured algorithms and
+// keys, and returns the protected header. Requires WithDetachedPayloads. Key
+// resolution follows the token rules: WithEmbeddedKeyRejection, the minimum
+// RSA key size, WithKeyOutagePolicy (without failing open) and
+// WithVerificationWorkers apply.
+//
+// With "b64": false in the header (which must then be listed in "crit"), the
+// signature covers the raw payload bytes; otherwise it covers their base64url
+// encoding. Other "crit" parameters are rejected, as RFC 7515 requires for
+// extensions the recipient does not understand. Failures are
+// *ValidationError values.
+func VerifyDetachedJWS(ctx context.Context, jws string, payload []byte, cfg *Config) (map[string]interface{}, error) {
+	if cfg == nil || cfg.parser == nil {
+		return nil, NewValidationError(ErrConfigError, "configuration is required (use NewConfig)", nil)
+	}
+	if !cfg.detachedPayloads {
+		return nil, NewValidationError(ErrDetachedPayload, "detached payloads are not enabled (use WithDetachedPayloads)", nil)
+	}
+	return verifyDetachedJWS(ctx, jws, payload, cfg)
+}
+
+// verifyDetachedJWS implements VerifyDetachedJWS without the opt-in check
+func verifyDetachedJWS(ctx context.Context, jws string, payload []byte, cfg *Config) (map[string]interface{}, error) {
+	if len(jws) > MaxTokenSize {
+		return nil, NewValidationError(ErrMalformed, fmt.Sprintf("JWS exceeds %d bytes", MaxTokenSize), nil)
+	}
+
+	segments := strings.Split(jws, ".")
+	if len(segments) != 3 {
+		return nil, NewValidationError(ErrMalformed, fmt.Sprintf("JWS must have 3 segments, got %d", len(segments)), nil)
+	}
+	if segments[1] != "" {
+		return nil, NewValidationError(ErrMalformed, "JWS payload segment must be empty (detached)", nil)
+	}
+
+	header, err := decodeJWSHeader(segments[0])
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return nil, NewValidationError(ErrMalformed, "could not decode JWS signature", err)
+	}
+
+	unencoded, err := unencodedPayloadMode(header)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, _ := header["alg"].(string)
+	token := &jwt.Token{Header: header, Method: jwt.GetSigningMethod(alg), Signature: signature}
+	validator, alg, err := selectValidator(token, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) == 0 {
+		return nil, NewValidationError(ErrEmptySignature, "JWS signature is empty", nil)
+	}
+	if cfg.rejectEmbeddedKeys {
+		if err := checkEmbeddedKeyHeaders(token); err != nil {
+			return nil, err
+		}
+	}
+
+	// Resolve the key as for tokens, except that an outage never admits
+	// unverified content
+	if cfg.keyOutage != nil {
+		ctx = context.WithValue(ctx, outageMarkContextKey{}, &outageMark{verifiedOnly: true})
+	}
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		return resolveVerificationKey(ctx, token, cfg, validator, alg)
+	}
+	var release func()
+	if cfg.verifyPool != nil {
+		keyFunc = cfg.verifyPool.wrap(ctx, keyFunc, &release)
+	}
+	key, err := keyFunc(token)
+	if release != nil {
+		defer release()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var signingInput []byte
+	if unencoded {
+		// RFC 7797: the encoded header, '.', then the raw payload bytes
+		signingInput = append([]byte(segments[0]+"."), payload...)
+	} else {
+		signingInput = []byte(segments[0] + "." + base64.RawURLEncoding.EncodeToString(payload))
+	}
+	if err := token.Method.Verify(string(signingInput), signature, key); err != nil {
+		return nil, NewValidationError(ErrInvalidSignature, "invalid signature", err)
+	}
+
+	return header, nil
+}
+
+// SignDetachedJWS signs payload with signer and returns a compact JWS with
+// the payload detached ("<header>..<signature>"), for sending alongside the
+// payload. With unencoded, the RFC 7797 "b64": false mode signs the raw
+// payload bytes.
+func SignDetachedJWS(ctx context.Context, signer Signer, payload []byte, unencoded bool) (string, error) {
+	return signDetachedJWS(ctx, signer, payload, unencoded, nil)
+}
+
+// signDetachedJWS implements SignDetachedJWS with extra header parameters
+func signDetachedJWS(ctx context.Context, signer Signer, payload []byte, unencoded bool, extra map[string]interface{}) (string, error) {
+	header := map[string]interface{}{"alg": signer.Algorithm()}
+	for name, value := range extra {
+		header[name] = value
+	}
+	if kid := signer.KeyID(); kid != "" {
+		header["kid"] = kid
+	}
+	if unencoded {
+		header["b64"] = false
+		header["crit"] = []string{"b64"}
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode header: %w", err)
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(headerJSON)
+
+	var signingInput []byte
+	if unencoded {
+		signingInput = append([]byte(encodedHeader+"."), payload...)
+	} else {
+		signingInput = []byte(encodedHeader + "." + base64.RawURLEncoding.EncodeToString(payload))
+	}
+
+	sig, err := signer.Sign(ctx, signingInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign payload: %w", err)
+	}
+	return encodedHeader + ".." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// decodeJWSHeader decodes a protected header segment into a JSON object
+func decodeJWSHeader(segment string) (map[string]interface{}, error) {
+	if segment == "" {
+		return nil, NewValidationError(ErrMalformed, "JWS header cannot be empty", nil)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return nil, NewValidationError(ErrMalformed, "could not decode JWS header", err)
+	}
+
+	var header map[string]interface{}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, NewValidationError(ErrMalformed, "could not parse JWS header", err)
+	}
+	if header == nil {
+		return nil, NewValidationError(ErrMalformed, "JWS header must be a JSON object", nil)
+	}
+	return header, nil
+}
+
+// unencodedPayloadMode reports whether the header selects the RFC 7797
+// unencoded payload and validates the crit parameter
+func unencodedPayloadMode(header map[string]interface{}) (bool, error) {
+	unencoded := false
+	if b64, ok := header["b64"]; ok {
+		flag, isBool := b64.(bool)
+		if !isBool {
+			return false, NewValidationError(ErrMalformed, "b64 header parameter must be a boolean", nil)
+		}
+		unencoded = !flag
+	}
+
+	critical := false
+	if crit, ok := header["crit"]; ok {
+		names, isList := crit.([]interface{})
+		if !isList || len(names) == 0 {
+			return false, NewValidationError(ErrMalformed, "crit header parameter must be a non-empty array", nil)
+		}
+		for _, name := range names {
+			if name != "b64" {
+				return false, NewValidationError(ErrMalformed, fmt.Sprintf("unsupported critical header parameter %v", name), nil)
+			}
+			critical = true
+		}
+	}
+
+	if unencoded && !critical {
+		return false, NewValidationError(ErrMalformed, `b64=false requires "b64" in the crit header parameter`, nil)
+	}
+	return unencoded, nil
+}
@@ -0,0 +1,206 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestVerifyDetachedJWS tests detached and unencoded payload verification
+func TestVerifyDetachedJWS(t *testing.T) {
+	ctx := context.Background()
+	key := mustGenerateECKey()
+	signer, err := NewCryptoSigner("ES256", "partner-1", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := mustCreateConfig(
+		WithKeyProvider("ES256", KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+			if kid != "partner-1" {
+				return nil, ErrKeyNotFound
+			}
+			return &key.PublicKey, nil
+		})),
+		WithDetachedPayloads(),
+	)
+
+	payload := []byte(`{"event":"invoice.paid","amount":42.10}` + "\n")
+	for _, unencoded := range []bool{false, true} {
+		jws, err := SignDetachedJWS(ctx, signer, payload, unencoded)
+		if err != nil {
+			t.Fatalf("SignDetachedJWS failed: %v", err)
+		}
+		if !strings.Contains(jws, "..") {
+			t.Fatalf("Expected detached JWS, got %s", jws)
+		}
+
+		header, err := VerifyDetachedJWS(ctx, jws, payload, cfg)
+		if err != nil {
+			t.Fatalf("unencoded=%v: expected payload to verify, got %v", unencoded, err)
+		}
+		if header["kid"] != "partner-1" {
+			t.Errorf("Expected header to be returned, got %v", header)
+		}
+
+		tampered := append([]byte(nil), payload...)
+		tampered[10] ^= 1
+		if _, err := VerifyDetachedJWS(ctx, jws, tampered, cfg); getErrorCode(err) != string(ErrInvalidSignature) {
+			t.Errorf("unencoded=%v: expected INVALID_SIGNATURE for a tampered payload, got %v", unencoded, err)
+		}
+
+		// Detached tokens never authenticate requests
+		if _, err := parseAndValidateJWT(jws, cfg); err == nil {
+			t.Errorf("unencoded=%v: expected middleware validation to reject detached JWS", unencoded)
+		}
+	}
+}
+
+// TestVerifyDetachedJWSRejections tests malformed and disallowed detached JWS
+func TestVerifyDetachedJWSRejections(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithDetachedPayloads())
+	enc := base64.RawURLEncoding.EncodeToString
+	payload := []byte("hello")
+
+	sign := func(header string, input []byte) string {
+		sig, _ := jwt.SigningMethodHS256.Sign(string(input), secret)
+		return header + ".." + enc(sig)
+	}
+	encodedHeader := enc([]byte(`{"alg":"HS256"}`))
+	unencodedHeader := enc([]byte(`{"alg":"HS256","b64":false,"crit":["b64"]}`))
+	noCritHeader := enc([]byte(`{"alg":"HS256","b64":false}`))
+	unknownCritHeader := enc([]byte(`{"alg":"HS256","crit":["exp"]}`))
+
+	tests := []struct {
+		name     string
+		jws      string
+		wantCode ErrorCode // Empty when the JWS verifies
+	}{
+		{"encoded payload", sign(encodedHeader, []byte(encodedHeader+"."+enc(payload))), ""},
+		{"unencoded payload", sign(unencodedHeader, []byte(unencodedHeader+".hello")), ""},
+		{"unencoded signature over encoded input", sign(unencodedHeader, []byte(unencodedHeader+"."+enc(payload))), ErrInvalidSignature},
+		{"b64=false without crit", sign(noCritHeader, []byte(noCritHeader+".hello")), ErrMalformed},
+		{"unknown crit parameter", sign(unknownCritHeader, []byte(unknownCritHeader+"."+enc(payload))), ErrMalformed},
+		{"attached payload", encodedHeader + "." + enc(payload) + ".sig", ErrMalformed},
+		{"empty signature", encodedHeader + "..", ErrEmptySignature},
+		{"none algorithm", enc([]byte(`{"alg":"none"}`)) + "..", ErrNoneAlgorithm},
+		{"unconfigured algorithm", enc([]byte(`{"alg":"RS256"}`)) + "..c2ln", ErrUnsupportedAlgorithm},
+		{"header not an object", enc([]byte(`null`)) + "..c2ln", ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyDetachedJWS(ctx, tt.jws, payload, cfg)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("Expected JWS to verify, got %v", err)
+				}
+				return
+			}
+			if getErrorCode(err) != string(tt.wantCode) {
+				t.Errorf("Expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+
+	// Opt-in only
+	plain := mustCreateConfig(WithHS256(secret))
+	jws := sign(encodedHeader, []byte(encodedHeader+"."+enc(payload)))
+	if _, err := VerifyDetachedJWS(ctx, jws, payload, plain); getErrorCode(err) != string(ErrDetachedPayload) {
+		t.Errorf("Expected DETACHED_PAYLOAD without WithDetachedPayloads, got %v", err)
+	}
+}
+
+// TestVerifyDetachedJWSKeyPolicies tests that detached JWS follow the token
+// rules for embedded keys, key sizes, key outages and verification workers
+func TestVerifyDetachedJWSKeyPolicies(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{"event":"invoice.paid"}`)
+	ecKey := mustGenerateECKey()
+	signer, err := NewCryptoSigner("ES256", "partner-1", ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jws, err := SignDetachedJWS(ctx, signer, payload, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var down atomic.Bool
+	provider := KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		if down.Load() {
+			return nil, fmt.Errorf("%w: connection refused", ErrKeyProviderUnavailable)
+		}
+		return &ecKey.PublicKey, nil
+	})
+
+	// Embedded keys
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"partner-1","jku":"https://attacker.example/jwks"}`))
+	sig, err := signer.Sign(ctx, []byte(header+"."+base64.RawURLEncoding.EncodeToString(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withJKU := header + ".." + base64.RawURLEncoding.EncodeToString(sig)
+	strict := mustCreateConfig(WithKeyProvider("ES256", provider), WithDetachedPayloads(), WithEmbeddedKeyRejection())
+	if _, err := VerifyDetachedJWS(ctx, withJKU, payload, strict); getErrorCode(err) != string(ErrEmbeddedKeyHeader) {
+		t.Errorf("expected EMBEDDED_KEY_HEADER, got %v", err)
+	}
+
+	// Minimum RSA key size
+	rsaKey := mustGenerateRSAKey()
+	rsaSigner, err := NewCryptoSigner("RS256", "partner-2", rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaJWS, err := SignDetachedJWS(ctx, rsaSigner, payload, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sized := mustCreateConfig(WithKeyProvider("RS256", KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		return &rsaKey.PublicKey, nil
+	})), WithDetachedPayloads(), WithMinRSAKeySize(4096))
+	if _, err := VerifyDetachedJWS(ctx, rsaJWS, payload, sized); getErrorCode(err) != string(ErrKeyUnavailable) {
+		t.Errorf("expected KEY_UNAVAILABLE for a short RSA key, got %v", err)
+	}
+
+	// Key outages: known keys keep verifying, failing open never accepts
+	for _, policy := range []KeyOutagePolicy{KeyOutageKnownKeys, KeyOutageFailOpen} {
+		down.Store(false)
+		cfg := mustCreateConfig(WithKeyProvider("ES256", provider), WithDetachedPayloads(), WithKeyOutagePolicy(KeyOutage{Policy: policy}))
+		if _, err := VerifyDetachedJWS(ctx, jws, payload, cfg); err != nil {
+			t.Fatalf("policy %v: expected verification, got %v", policy, err)
+		}
+		down.Store(true)
+		if _, err := VerifyDetachedJWS(ctx, jws, payload, cfg); err != nil {
+			t.Errorf("policy %v: expected the known key to verify during the outage, got %v", policy, err)
+		}
+		other, _ := NewCryptoSigner("ES256", "partner-3", mustGenerateECKey())
+		unknown, _ := SignDetachedJWS(ctx, other, payload, false)
+		if _, err := VerifyDetachedJWS(ctx, unknown, payload, cfg); getErrorCode(err) != string(ErrKeyUnavailable) {
+			t.Errorf("policy %v: expected KEY_UNAVAILABLE for an unknown key during the outage, got %v", policy, err)
+		}
+	}
+
+	// Verification workers
+	down.Store(false)
+	pooled := mustCreateConfig(WithKeyProvider("ES256", provider), WithDetachedPayloads(), WithVerificationWorkers(1))
+	release, err := pooled.verifyPool.acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := VerifyDetachedJWS(waitCtx, jws, payload, pooled); getErrorCode(err) != string(ErrVerificationOverloaded) {
+		t.Errorf("expected VERIFICATION_OVERLOADED, got %v", err)
+	}
+	release()
+	if _, err := VerifyDetachedJWS(ctx, jws, payload, pooled); err != nil || len(pooled.verifyPool.slots) != 0 {
+		t.Errorf("expected verification with a free worker and the worker released, got %v", err)
+	}
+}
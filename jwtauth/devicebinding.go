@@ -0,0 +1,113 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// DeviceBinding configures WithDeviceBinding
+type DeviceBinding struct {
+	Claim  string // Claim holding the device fingerprint, e.g. "dfp"
+	Header string // Request header carrying the fingerprint, e.g. "X-Device-Fingerprint"
+	Cookie string // Cookie carrying the fingerprint (used when the header is absent)
+
+	// Hashed compares the claim with the hex SHA-256 of the presented value,
+	// so the raw fingerprint never appears in the token (OWASP token sidejacking
+	// pattern: a random value in a hardened cookie, its hash in the token)
+	Hashed bool
+}
+
+// WithDeviceBinding rejects tokens presented without the device fingerprint
+// they were issued to, with DEVICE_MISMATCH. The fingerprint is read from the
+// configured header (gRPC metadata and message headers use the same name) or
+// cookie and compared in constant time.
+func WithDeviceBinding(binding DeviceBinding) ConfigOption {
+	return func(c *Config) error {
+		if binding.Claim == "" {
+			return fmt.Errorf("device binding claim name cannot be empty")
+		}
+		if binding.Header == "" && binding.Cookie == "" {
+			return fmt.Errorf("device binding requires a header or cookie")
+		}
+		c.deviceBinding = &binding
+		return nil
+	}
+}
+
+// deviceFingerprintFromRequest reads the presented fingerprint from an HTTP request
+func deviceFingerprintFromRequest(r *http.Request, cfg *Config) string {
+	if cfg.deviceBinding == nil {
+		return ""
+	}
+	if cfg.deviceBinding.Header != "" {
+		if value := strings.TrimSpace(r.Header.Get(cfg.deviceBinding.Header)); value != "" {
+			return value
+		}
+	}
+	if cfg.deviceBinding.Cookie != "" {
+		if cookie, err := r.Cookie(cfg.deviceBinding.Cookie); err == nil {
+			return strings.TrimSpace(cookie.Value)
+		}
+	}
+	return ""
+}
+
+// deviceFingerprintFromMetadata reads the presented fingerprint from gRPC metadata
+func deviceFingerprintFromMetadata(md metadata.MD, cfg *Config) string {
+	if cfg.deviceBinding == nil || cfg.deviceBinding.Header == "" {
+		return ""
+	}
+	if values := md.Get(cfg.deviceBinding.Header); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// deviceFingerprintFromMessage reads the presented fingerprint from message headers
+func deviceFingerprintFromMessage(headers map[string][]string, cfg *Config) string {
+	if cfg.deviceBinding == nil || cfg.deviceBinding.Header == "" {
+		return ""
+	}
+	return strings.TrimSpace(messageHeader(headers, cfg.deviceBinding.Header))
+}
+
+// withDeviceFingerprint stores the presented fingerprint for checkDeviceBinding.
+// It is deliberately not exposed to handlers.
+func withDeviceFingerprint(ctx context.Context, fingerprint string) context.Context {
+	if fingerprint == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, deviceContextKey, fingerprint)
+}
+
+// checkDeviceBinding compares the token's device claim with the presented fingerprint
+func checkDeviceBinding(ctx context.Context, cfg *Config, claims *Claims) error {
+	if cfg.deviceBinding == nil {
+		return nil
+	}
+
+	bound, ok := claims.GetString(cfg.deviceBinding.Claim)
+	if !ok || bound == "" {
+		return NewValidationError(ErrDeviceMismatch, "token is not bound to a device", nil)
+	}
+	presented, _ := ctx.Value(deviceContextKey).(string)
+	if presented == "" {
+		return NewValidationError(ErrDeviceMismatch, "device fingerprint not presented", nil)
+	}
+
+	if cfg.deviceBinding.Hashed {
+		sum := sha256.Sum256([]byte(presented))
+		presented = hex.EncodeToString(sum[:])
+		bound = strings.ToLower(bound)
+	}
+	if !SecureCompare(bound, presented) {
+		return NewValidationError(ErrDeviceMismatch, "device fingerprint does not match token", nil)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestDeviceBinding tests device fingerprint binding over HTTP
+func TestDeviceBinding(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	sum := sha256.Sum256([]byte("raw-fingerprint"))
+	hashed := hex.EncodeToString(sum[:])
+
+	sign := func(dfp string) string {
+		claims := jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()}
+		if dfp != "" {
+			claims["dfp"] = dfp
+		}
+		return mustSignHS256(secret, claims)
+	}
+
+	tests := []struct {
+		name       string
+		binding    DeviceBinding
+		token      string
+		header     string
+		cookie     string
+		wantStatus int
+	}{
+		{"header matches", DeviceBinding{Claim: "dfp", Header: "X-Device-Fingerprint"}, sign("device-1"), "device-1", "", http.StatusOK},
+		{"cookie matches", DeviceBinding{Claim: "dfp", Header: "X-Device-Fingerprint", Cookie: "__Host-dfp"}, sign("device-1"), "", "device-1", http.StatusOK},
+		{"hashed matches", DeviceBinding{Claim: "dfp", Cookie: "__Host-dfp", Hashed: true}, sign(hashed), "", "raw-fingerprint", http.StatusOK},
+		{"hashed rejects hash as value", DeviceBinding{Claim: "dfp", Cookie: "__Host-dfp", Hashed: true}, sign(hashed), "", hashed, http.StatusUnauthorized},
+		{"mismatch", DeviceBinding{Claim: "dfp", Header: "X-Device-Fingerprint"}, sign("device-1"), "device-2", "", http.StatusUnauthorized},
+		{"not presented", DeviceBinding{Claim: "dfp", Header: "X-Device-Fingerprint"}, sign("device-1"), "", "", http.StatusUnauthorized},
+		{"unbound token", DeviceBinding{Claim: "dfp", Header: "X-Device-Fingerprint"}, sign(""), "device-1", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := createTestRouter(mustCreateConfig(WithHS256(secret), WithDeviceBinding(tt.binding)))
+
+			req := httptest.NewRequest("GET", "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.header != "" {
+				req.Header.Set("X-Device-Fingerprint", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "__Host-dfp", Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized && !strings.Contains(w.Body.String(), "DEVICE_MISMATCH") {
+				t.Errorf("Expected DEVICE_MISMATCH, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+// TestDeviceBindingGRPCAndMessages tests device binding on other transports
+func TestDeviceBindingGRPCAndMessages(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithDeviceBinding(DeviceBinding{Claim: "dfp", Header: "X-Device-Fingerprint"}),
+	)
+	token := mustSignHS256(secret, jwt.MapClaims{
+		"sub": "user123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"dfp": "device-1",
+	})
+
+	conn := startTestGRPCServer(t, grpc.UnaryInterceptor(UnaryServerInterceptor(cfg)))
+	client := healthpb.NewHealthClient(conn)
+	for fingerprint, wantOK := range map[string]bool{"device-1": true, "device-2": false} {
+		ctx := metadata.AppendToOutgoingContext(context.Background(),
+			"authorization", "Bearer "+token,
+			"x-device-fingerprint", fingerprint,
+		)
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if wantOK && err != nil {
+			t.Errorf("Expected gRPC call with %s to succeed, got %v", fingerprint, err)
+		}
+		if !wantOK && status.Convert(err).Message() != "DEVICE_MISMATCH" {
+			t.Errorf("Expected DEVICE_MISMATCH for %s, got %v", fingerprint, err)
+		}
+	}
+
+	_, err := ValidateMessage(context.Background(), cfg, map[string][]string{
+		"Authorization":        {"Bearer " + token},
+		"x-device-fingerprint": {"device-1"},
+	})
+	if err != nil {
+		t.Errorf("Expected message to validate, got %v", err)
+	}
+}
+
+// TestWithDeviceBindingValidation tests option validation
+func TestWithDeviceBindingValidation(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	if _, err := NewConfig(WithHS256(secret), WithDeviceBinding(DeviceBinding{Header: "X-Device"})); err == nil {
+		t.Error("Expected missing claim to be rejected")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithDeviceBinding(DeviceBinding{Claim: "dfp"})); err == nil {
+		t.Error("Expected missing header and cookie to be rejected")
+	}
+}
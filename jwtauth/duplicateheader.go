@@ -0,0 +1,170 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DuplicateHeaderPolicy controls requests carrying more than one
+// Authorization header (or gRPC authorization metadata value). Proxies
+// sometimes append a header instead of replacing it, or merge repeats into a
+// single comma-separated value.
+type DuplicateHeaderPolicy int
+
+const (
+	// DuplicateHeaderFirst validates the first header only (default)
+	DuplicateHeaderFirst DuplicateHeaderPolicy = iota
+	// DuplicateHeaderStrict rejects ambiguous requests with AMBIGUOUS_TOKEN
+	DuplicateHeaderStrict
+	// DuplicateHeaderLenient tries each candidate and accepts the first that
+	// authenticates
+	DuplicateHeaderLenient
+)
+
+// String returns the policy name used in logs
+func (p DuplicateHeaderPolicy) String() string {
+	switch p {
+	case DuplicateHeaderStrict:
+		return "strict"
+	case DuplicateHeaderLenient:
+		return "lenient"
+	default:
+		return "first"
+	}
+}
+
+// WithDuplicateHeaderPolicy sets how requests with several distinct
+// Authorization values are handled. Identical repeats are not ambiguous and
+// are always collapsed. Whenever distinct values are seen the policy applied
+// is logged at Warn level.
+func WithDuplicateHeaderPolicy(policy DuplicateHeaderPolicy) ConfigOption {
+	return func(c *Config) error {
+		if policy < DuplicateHeaderFirst || policy > DuplicateHeaderLenient {
+			return fmt.Errorf("unknown duplicate header policy %d", policy)
+		}
+		c.duplicateHeaderPolicy = policy
+		return nil
+	}
+}
+
+// DuplicateHeaderPolicy returns the configured duplicate header policy
+func (c *Config) DuplicateHeaderPolicy() DuplicateHeaderPolicy {
+	return c.duplicateHeaderPolicy
+}
+
+// bearerCandidates parses Authorization values into the tokens to
+// authenticate under cfg's duplicate header policy
+func bearerCandidates(values []string, cfg *Config, requestID string, formatMessage string) ([]string, error) {
+	// Fast path: a single value cannot carry duplicates unless merged with commas
+	if len(values) == 1 && !strings.Contains(values[0], ",") {
+		token, err := parseBearer(values[0], formatMessage)
+		if err != nil {
+			return nil, err
+		}
+		return []string{token}, nil
+	}
+
+	distinct := splitAuthorizationValues(values)
+	if len(distinct) > 1 {
+		logDuplicateHeaders(cfg, requestID, len(distinct))
+	}
+
+	if len(distinct) <= 1 || cfg.duplicateHeaderPolicy == DuplicateHeaderFirst {
+		token, err := parseBearer(values[0], formatMessage)
+		if err != nil {
+			return nil, err
+		}
+		return []string{token}, nil
+	}
+
+	if cfg.duplicateHeaderPolicy == DuplicateHeaderStrict {
+		return nil, NewValidationError(
+			ErrAmbiguousToken,
+			fmt.Sprintf("request carries %d distinct authorization values", len(distinct)),
+			nil,
+		)
+	}
+
+	// Lenient: keep every well-formed candidate, in order
+	var tokens []string
+	var firstErr error
+	for _, value := range distinct {
+		token, err := parseBearer(value, formatMessage)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	if len(tokens) == 0 {
+		return nil, firstErr
+	}
+	return tokens, nil
+}
+
+// splitAuthorizationValues returns the distinct, non-empty Authorization
+// values, splitting values merged with commas (JWTs never contain commas)
+func splitAuthorizationValues(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var distinct []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" || seen[part] {
+				continue
+			}
+			seen[part] = true
+			distinct = append(distinct, part)
+		}
+	}
+	return distinct
+}
+
+// authenticateCandidates authenticates the extracted token candidates and
+// returns the one that was accepted. There is more than one candidate only
+// under DuplicateHeaderLenient; on failure the first candidate's error is
+// returned.
+func authenticateCandidates(ctx context.Context, tokens []string, requestID string, cfg *Config) (string, *Claims, string, error) {
+	var firstErr error
+	for i, token := range tokens {
+		claims, tenant, err := authenticateToken(ctx, token, requestID, cfg)
+		if err == nil {
+			if len(tokens) > 1 && cfg.Logger() != nil {
+				cfg.Logger().Warn("authorization candidate accepted",
+					"request_id", requestID,
+					"candidate", i+1,
+					"candidates", len(tokens),
+				)
+			}
+			return token, claims, tenant, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	cfg.delayFailure(ctx, firstErr)
+	return tokens[0], nil, "", firstErr
+}
+
+// logDuplicateHeaders records that a request carried several distinct
+// Authorization values and which policy handles them
+func logDuplicateHeaders(cfg *Config, requestID string, count int) {
+	if cfg.Logger() == nil {
+		return
+	}
+	cfg.Logger().Warn("duplicate authorization headers",
+		"request_id", requestID,
+		"count", count,
+		"policy", cfg.duplicateHeaderPolicy.String(),
+	)
+}
+
+// isAmbiguous reports whether err rejects a request for duplicate headers
+func isAmbiguous(err error) bool {
+	var valErr *ValidationError
+	return errors.As(err, &valErr) && valErr.Code == ErrAmbiguousToken
+}
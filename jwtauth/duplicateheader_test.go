@@ -0,0 +1,133 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestDuplicateAuthorizationHeaders tests each duplicate header policy
+func TestDuplicateAuthorizationHeaders(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	valid := mustSignHS256(secret, jwt.MapClaims{
+		"sub": "user123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	invalid := mustSignHS256([]byte("some-other-secret-min-32-bytes-long!"), jwt.MapClaims{
+		"sub": "attacker",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	tests := []struct {
+		name       string
+		policy     DuplicateHeaderPolicy
+		headers    []string
+		wantStatus int
+		wantReason string
+		wantLog    string
+	}{
+		{"first policy uses first header", DuplicateHeaderFirst, []string{"Bearer " + valid, "Bearer " + invalid}, http.StatusOK, "", "policy=first"},
+		{"first policy rejects invalid first header", DuplicateHeaderFirst, []string{"Bearer " + invalid, "Bearer " + valid}, http.StatusUnauthorized, "INVALID_SIGNATURE", "policy=first"},
+		{"strict rejects distinct headers", DuplicateHeaderStrict, []string{"Bearer " + valid, "Bearer " + invalid}, http.StatusUnauthorized, "AMBIGUOUS_TOKEN", "policy=strict"},
+		{"strict rejects merged headers", DuplicateHeaderStrict, []string{"Bearer " + valid + ", Bearer " + invalid}, http.StatusUnauthorized, "AMBIGUOUS_TOKEN", "policy=strict"},
+		{"strict allows identical repeats", DuplicateHeaderStrict, []string{"Bearer " + valid, "Bearer " + valid}, http.StatusOK, "", ""},
+		{"lenient tries each candidate", DuplicateHeaderLenient, []string{"Bearer " + invalid, "Basic dXNlcjpwYXNz", "Bearer " + valid}, http.StatusOK, "", "candidate=2 candidates=2"},
+		{"lenient reports first failure", DuplicateHeaderLenient, []string{"Bearer " + invalid, "Bearer not.a.jwt"}, http.StatusUnauthorized, "INVALID_SIGNATURE", "policy=lenient"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			cfg := mustCreateConfig(
+				WithHS256(secret),
+				WithDuplicateHeaderPolicy(tt.policy),
+				WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+			)
+			router := createTestRouter(cfg)
+
+			req := httptest.NewRequest("GET", "/protected", nil)
+			for _, h := range tt.headers {
+				req.Header.Add("Authorization", h)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantReason != "" && !strings.Contains(w.Body.String(), tt.wantReason) {
+				t.Errorf("Expected reason %s, got %s", tt.wantReason, w.Body.String())
+			}
+			if tt.wantLog != "" && !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("Expected log to contain %q, got %s", tt.wantLog, logs.String())
+			}
+			if tt.wantLog == "" && strings.Contains(logs.String(), "duplicate authorization headers") {
+				t.Errorf("Expected no duplicate header log, got %s", logs.String())
+			}
+		})
+	}
+}
+
+// TestDuplicateHeaderPolicyTransports tests the policy for gRPC and messages
+func TestDuplicateHeaderPolicyTransports(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	valid := mustSignHS256(secret, jwt.MapClaims{
+		"sub": "user123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	other := mustSignHS256(secret, jwt.MapClaims{
+		"sub": "user456",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	t.Run("gRPC strict", func(t *testing.T) {
+		cfg := mustCreateConfig(WithHS256(secret), WithDuplicateHeaderPolicy(DuplicateHeaderStrict))
+		conn := startTestGRPCServer(t, grpc.UnaryInterceptor(UnaryServerInterceptor(cfg)))
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(),
+			"authorization", "Bearer "+valid,
+			"authorization", "Bearer "+other,
+		)
+		_, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		if status.Code(err) != codes.Unauthenticated || status.Convert(err).Message() != "AMBIGUOUS_TOKEN" {
+			t.Errorf("Expected AMBIGUOUS_TOKEN, got %v", err)
+		}
+	})
+
+	t.Run("message lenient", func(t *testing.T) {
+		cfg := mustCreateConfig(WithHS256(secret), WithDuplicateHeaderPolicy(DuplicateHeaderLenient))
+		claims, err := ValidateMessage(context.Background(), cfg, map[string][]string{
+			"authorization": {"Bearer expired.or.garbage"},
+			"Authorization": {"Bearer " + other},
+		})
+		if err != nil {
+			t.Fatalf("Expected a candidate to validate, got %v", err)
+		}
+		if claims.Subject != "user456" {
+			t.Errorf("Expected subject user456, got %s", claims.Subject)
+		}
+	})
+}
+
+// TestWithDuplicateHeaderPolicyRejectsUnknown tests option validation
+func TestWithDuplicateHeaderPolicyRejectsUnknown(t *testing.T) {
+	_, err := NewConfig(
+		WithHS256([]byte("test-secret-key-min-32-bytes-long!!")),
+		WithDuplicateHeaderPolicy(DuplicateHeaderPolicy(42)),
+	)
+	if err == nil {
+		t.Error("Expected unknown policy to be rejected")
+	}
+}
@@ -0,0 +1,105 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrorCategory classifies the underlying cause of a ValidationError, so
+// metrics and logs can separate, say, forged signatures from broken JSON
+// without matching error strings
+type ErrorCategory string
+
+const (
+	CategoryCrypto      ErrorCategory = "crypto"      // Signature did not verify
+	CategoryEncoding    ErrorCategory = "encoding"    // Invalid base64url segment
+	CategoryJSON        ErrorCategory = "json"        // Header or claims are not valid JSON
+	CategoryFormat      ErrorCategory = "format"      // Missing, ambiguous or structurally invalid token
+	CategoryAlgorithm   ErrorCategory = "algorithm"   // Unsupported, "none" or malformed alg header
+	CategoryKey         ErrorCategory = "key"         // Verification key missing, unavailable or of the wrong type
+	CategoryClaims      ErrorCategory = "claims"      // Claims are expired, invalid or violate a policy on their values
+	CategoryPolicy      ErrorCategory = "policy"      // Request rejected by rate limits, revocation, binding or authorization requirements
+	CategoryUnavailable ErrorCategory = "unavailable" // A dependency (blocklist, key provider) failed or timed out
+	CategoryConfig      ErrorCategory = "config"      // Middleware misconfiguration
+	CategoryUnknown     ErrorCategory = "unknown"
+)
+
+// codeCategories is the fallback category for each code when the Internal
+// chain does not identify a more specific cause
+var codeCategories = map[ErrorCode]ErrorCategory{
+	ErrExpired:                  CategoryClaims,
+	ErrInvalidSignature:         CategoryCrypto,
+	ErrMissingToken:             CategoryFormat,
+	ErrMalformed:                CategoryFormat,
+	ErrAlgorithmMismatch:        CategoryAlgorithm,
+	ErrNoneAlgorithm:            CategoryAlgorithm,
+	ErrConfigError:              CategoryConfig,
+	ErrUnsupportedAlgorithm:     CategoryAlgorithm,
+	ErrMalformedAlgorithmHeader: CategoryAlgorithm,
+	ErrKeyUnavailable:           CategoryKey,
+	ErrInvalidAudience:          CategoryClaims,
+	ErrRateLimited:              CategoryPolicy,
+	ErrTokenRevoked:             CategoryPolicy,
+	ErrRevocationUnavailable:    CategoryUnavailable,
+	ErrInvalidClaim:             CategoryClaims,
+	ErrAmbiguousToken:           CategoryFormat,
+	ErrIPMismatch:               CategoryPolicy,
+	ErrDeviceMismatch:           CategoryPolicy,
+	ErrDelegationNotAllowed:     CategoryClaims,
+	ErrClaimsSchemaViolation:    CategoryClaims,
+	ErrEmptySignature:           CategoryFormat,
+	ErrDetachedPayload:          CategoryFormat,
+	ErrUnencodedPayload:         CategoryFormat,
+	ErrForbidden:                CategoryPolicy,
+	ErrEmbeddedKeyHeader:        CategoryFormat,
+	ErrInvalidIssuer:            CategoryClaims,
+	ErrTokenReplayed:            CategoryPolicy,
+	ErrInvalidTokenType:         CategoryFormat,
+	ErrVerificationOverloaded:   CategoryUnavailable,
+	ErrPolicyDenied:             CategoryPolicy,
+	ErrPolicyUnavailable:        CategoryUnavailable,
+}
+
+// Cause returns the category of the underlying failure. The Internal chain
+// (including golang-jwt's joined errors) is inspected first, so a MALFORMED
+// token reports CategoryJSON or CategoryEncoding and a key provider timeout
+// reports CategoryUnavailable; otherwise the category follows the code.
+func (e *ValidationError) Cause() ErrorCategory {
+	if e == nil {
+		return CategoryUnknown
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var base64Err base64.CorruptInputError
+	var netErr net.Error
+	switch err := e.Internal; {
+	case err == nil:
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return CategoryJSON
+	case errors.As(err, &base64Err):
+		return CategoryEncoding
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled), errors.As(err, &netErr):
+		return CategoryUnavailable
+	case errors.Is(err, ErrKeyNotFound), errors.Is(err, jwt.ErrInvalidKey), errors.Is(err, jwt.ErrInvalidKeyType),
+		errors.Is(err, jwt.ErrHashUnavailable):
+		return CategoryKey
+	case errors.Is(err, jwt.ErrSignatureInvalid), errors.Is(err, jwt.ErrECDSAVerification),
+		errors.Is(err, jwt.ErrEd25519Verification), errors.Is(err, rsa.ErrVerification),
+		errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return CategoryCrypto
+	case errors.Is(err, jwt.ErrTokenInvalidClaims):
+		return CategoryClaims
+	}
+
+	if category, ok := codeCategories[e.Code]; ok {
+		return category
+	}
+	return CategoryUnknown
+}
@@ -0,0 +1,71 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestValidationErrorCause tests cause categories for real validation failures
+func TestValidationErrorCause(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	key := mustGenerateECKey()
+	var providerErr error
+	cfg := mustCreateConfig(WithHS256(secret), WithKeyProvider("ES256", KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		if providerErr != nil {
+			return nil, providerErr
+		}
+		return &key.PublicKey, nil
+	})))
+
+	valid := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	parts := strings.Split(valid, ".")
+	otherKey := mustGenerateECKey()
+	forgedES, _ := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "user-1"}).SignedString(otherKey)
+
+	tests := []struct {
+		name        string
+		token       string
+		providerErr error
+		want        ErrorCategory
+	}{
+		{"forged HMAC", mustSignHS256([]byte("attacker-secret-key-min-32-bytes!!"), jwt.MapClaims{"sub": "user-1"}), nil, CategoryCrypto},
+		{"forged ECDSA", forgedES, nil, CategoryCrypto},
+		{"invalid base64", parts[0] + ".!!!." + parts[2], nil, CategoryEncoding},
+		{"invalid JSON", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":`)) + "." + parts[2], nil, CategoryJSON},
+		{"expired", mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(-time.Hour).Unix()}), nil, CategoryClaims},
+		{"none algorithm", "eyJhbGciOiJub25lIn0.eyJzdWIiOiJ1c2VyLTEifQ.", nil, CategoryAlgorithm},
+		{"unknown key", forgedES, ErrKeyNotFound, CategoryKey},
+		{"provider timeout", forgedES, context.DeadlineExceeded, CategoryUnavailable},
+		{"provider network error", forgedES, &net.OpError{Op: "dial", Err: errors.New("connection refused")}, CategoryUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providerErr = tt.providerErr
+			_, err := parseAndValidateJWT(tt.token, cfg)
+			var valErr *ValidationError
+			if !errors.As(err, &valErr) {
+				t.Fatalf("Expected ValidationError, got %v", err)
+			}
+			if got := valErr.Cause(); got != tt.want {
+				t.Errorf("Expected %s, got %s (%v: %v)", tt.want, got, valErr, valErr.Internal)
+			}
+		})
+	}
+
+	// Every code has a fallback category
+	for _, info := range ErrorCodes() {
+		if cause := NewValidationError(info.Code, "", nil).Cause(); cause == CategoryUnknown {
+			t.Errorf("Expected a category for %s", info.Code)
+		}
+	}
+	if cause := (*ValidationError)(nil).Cause(); cause != CategoryUnknown {
+		t.Errorf("Expected nil error to be unknown, got %s", cause)
+	}
+}
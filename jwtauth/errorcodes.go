@@ -0,0 +1,86 @@
+package jwtauth
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// ErrorCodeInfo documents an error code and how the middleware reports it
+type ErrorCodeInfo struct {
+	Code        ErrorCode
+	Description string
+	HTTPStatus  int        // Status written by the HTTP middleware
+	GRPCCode    codes.Code // Status code returned by the gRPC interceptors
+	HasMessage  bool       // HTTP responses include the error message
+
+	// Deprecated codes are no longer returned; ReplacedBy names the code
+	// returned instead
+	Deprecated bool
+	ReplacedBy ErrorCode
+}
+
+// errorCodes is the registry behind ErrorCodes, in declaration order. Every
+// ErrorCode constant must be listed (enforced by TestErrorCodesRegistry).
+var errorCodes = []ErrorCodeInfo{
+	{Code: ErrExpired, Description: "Token has expired"},
+	{Code: ErrInvalidSignature, Description: "Signature verification failed"},
+	{Code: ErrMissingToken, Description: "No token provided in request"},
+	{Code: ErrMalformed, Description: "Token structure is invalid"},
+	{Code: ErrAlgorithmMismatch, Description: "Token algorithm does not match the configured algorithm", Deprecated: true, ReplacedBy: ErrUnsupportedAlgorithm},
+	{Code: ErrNoneAlgorithm, Description: `"none" algorithm explicitly rejected`},
+	{Code: ErrConfigError, Description: "Middleware configuration is invalid or missing"},
+	{Code: ErrUnsupportedAlgorithm, Description: "Token uses an algorithm not configured", HasMessage: true},
+	{Code: ErrMalformedAlgorithmHeader, Description: "Algorithm header is malformed", HasMessage: true},
+	{Code: ErrKeyUnavailable, Description: "Key provider could not supply a verification key"},
+	{Code: ErrInvalidAudience, Description: "aud claim missing or not matching configured audiences"},
+	{Code: ErrRateLimited, Description: "Tenant exceeded its request quota, or client has too many delayed signature failures in flight (Retry-After header set)", HTTPStatus: http.StatusTooManyRequests, GRPCCode: codes.ResourceExhausted},
+	{Code: ErrTokenRevoked, Description: "Token's jti is on the configured blocklist"},
+	{Code: ErrRevocationUnavailable, Description: "Blocklist or single-use store could not be consulted (fails closed)"},
+	{Code: ErrInvalidClaim, Description: "Claim has the wrong type or value (message names the claim)", HasMessage: true},
+	{Code: ErrAmbiguousToken, Description: "Several distinct Authorization values under DuplicateHeaderStrict"},
+	{Code: ErrIPMismatch, Description: "Token is unbound or bound to a different client IP"},
+	{Code: ErrDeviceMismatch, Description: "Device fingerprint missing or not matching the token"},
+	{Code: ErrDelegationNotAllowed, Description: "Actor (act) or presenting client (azp) not authorized by may_act"},
+	{Code: ErrClaimsSchemaViolation, Description: "Claims do not satisfy the configured claims schema"},
+	{Code: ErrEmptySignature, Description: "Real algorithm with an empty signature segment"},
+	{Code: ErrDetachedPayload, Description: "Empty payload segment (detached JWS content)"},
+	{Code: ErrUnencodedPayload, Description: `RFC 7797 "b64": false header`},
+	{Code: ErrForbidden, Description: "Token lacks the scopes or claims the method requires", HTTPStatus: http.StatusForbidden, GRPCCode: codes.PermissionDenied, HasMessage: true},
+	{Code: ErrEmbeddedKeyHeader, Description: "Token carries a sender-supplied key header (jwk, jku or x5u)", HasMessage: true},
+	{Code: ErrInvalidIssuer, Description: "iss claim missing or not in the configured issuers", HasMessage: true},
+	{Code: ErrTokenReplayed, Description: "Single-use token was already used (RequireSingleUse)"},
+	{Code: ErrInvalidTokenType, Description: "typ header missing or not an accepted token type", HasMessage: true},
+	{Code: ErrVerificationOverloaded, Description: "No signature verification worker became available in time (Retry-After header set)", HTTPStatus: http.StatusServiceUnavailable, GRPCCode: codes.Unavailable},
+	{Code: ErrPolicyDenied, Description: "Authorization policy denied the request; the message carries the policy's reason", HTTPStatus: http.StatusForbidden, GRPCCode: codes.PermissionDenied, HasMessage: true},
+	{Code: ErrPolicyUnavailable, Description: "Authorization policy could not be evaluated (fails closed)", HTTPStatus: http.StatusServiceUnavailable, GRPCCode: codes.Unavailable},
+}
+
+// errorCodeIndex maps codes to registry entries, with defaults filled in
+var errorCodeIndex = func() map[ErrorCode]ErrorCodeInfo {
+	index := make(map[ErrorCode]ErrorCodeInfo, len(errorCodes))
+	for i := range errorCodes {
+		info := &errorCodes[i]
+		if info.HTTPStatus == 0 {
+			info.HTTPStatus = http.StatusUnauthorized
+		}
+		if info.GRPCCode == codes.OK {
+			info.GRPCCode = codes.Unauthenticated
+		}
+		index[info.Code] = *info
+	}
+	return index
+}()
+
+// ErrorCodes returns every error code with its description, HTTP status and
+// gRPC code, for generating API documentation and client SDKs. Deprecated
+// codes are included and marked.
+func ErrorCodes() []ErrorCodeInfo {
+	return append([]ErrorCodeInfo(nil), errorCodes...)
+}
+
+// Info returns the registry entry for the code
+func (c ErrorCode) Info() (ErrorCodeInfo, bool) {
+	info, ok := errorCodeIndex[c]
+	return info, ok
+}
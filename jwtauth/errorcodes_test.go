@@ -0,0 +1,94 @@
+package jwtauth
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+// TestErrorCodesRegistry tests that every ErrorCode constant is registered
+func TestErrorCodesRegistry(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "errors.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	declared := 0
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if ident, ok := vs.Type.(*ast.Ident); !ok || ident.Name != "ErrorCode" {
+				continue
+			}
+			for i, name := range vs.Names {
+				declared++
+				value, err := strconv.Unquote(vs.Values[i].(*ast.BasicLit).Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := ErrorCode(value).Info(); !ok {
+					t.Errorf("%s is not in the error code registry", name.Name)
+				}
+			}
+		}
+	}
+	if declared != len(ErrorCodes()) {
+		t.Errorf("Declared %d error codes, registry has %d", declared, len(ErrorCodes()))
+	}
+
+	seen := map[ErrorCode]bool{}
+	for _, info := range ErrorCodes() {
+		if seen[info.Code] {
+			t.Errorf("%s is registered twice", info.Code)
+		}
+		seen[info.Code] = true
+		if info.Description == "" {
+			t.Errorf("%s has no description", info.Code)
+		}
+		if info.Deprecated {
+			if replacement, ok := info.ReplacedBy.Info(); !ok || replacement.Deprecated {
+				t.Errorf("%s must name a current replacement", info.Code)
+			}
+		}
+	}
+}
+
+// TestErrorCodeMappings tests status mappings derived from the registry
+func TestErrorCodeMappings(t *testing.T) {
+	info, ok := ErrAlgorithmMismatch.Info()
+	if !ok || !info.Deprecated || info.ReplacedBy != ErrUnsupportedAlgorithm {
+		t.Errorf("Expected ALGORITHM_MISMATCH to be deprecated, got %+v", info)
+	}
+	if info, _ := ErrExpired.Info(); info.HTTPStatus != http.StatusUnauthorized || info.GRPCCode != codes.Unauthenticated {
+		t.Errorf("Expected default mappings, got %+v", info)
+	}
+	if _, ok := ErrorCode("NOPE").Info(); ok {
+		t.Error("Expected unknown code to be absent")
+	}
+
+	limited := NewValidationError(ErrRateLimited, "slow down", nil)
+	if httpStatusForError(limited) != http.StatusTooManyRequests || grpcCodeForError(limited) != codes.ResourceExhausted {
+		t.Error("Expected RATE_LIMITED to map to 429 / RESOURCE_EXHAUSTED")
+	}
+	if got := buildErrorResponse(NewValidationError(ErrInvalidClaim, "claim role must be a string", nil)); got["message"] == nil {
+		t.Error("Expected INVALID_CLAIM response to include the message")
+	}
+	if got := buildErrorResponse(NewValidationError(ErrExpired, "token expired", nil)); got["message"] != nil {
+		t.Error("Expected EXPIRED response to omit the message")
+	}
+
+	codesCopy := ErrorCodes()
+	codesCopy[0].Description = "changed"
+	if ErrorCodes()[0].Description == "changed" {
+		t.Error("Expected ErrorCodes to return a copy")
+	}
+}
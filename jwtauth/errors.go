@@ -1,27 +1,58 @@
 package jwtauth
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // ErrorCode represents a validation error code
 type ErrorCode string
 
 const (
-	ErrExpired                  ErrorCode = "EXPIRED"
-	ErrInvalidSignature         ErrorCode = "INVALID_SIGNATURE"
-	ErrMissingToken             ErrorCode = "MISSING_TOKEN"
-	ErrMalformed                ErrorCode = "MALFORMED"
-	ErrAlgorithmMismatch        ErrorCode = "ALGORITHM_MISMATCH" // Deprecated: use ErrUnsupportedAlgorithm
+	ErrExpired          ErrorCode = "EXPIRED"
+	ErrInvalidSignature ErrorCode = "INVALID_SIGNATURE"
+	ErrMissingToken     ErrorCode = "MISSING_TOKEN"
+	ErrMalformed        ErrorCode = "MALFORMED"
+
+	// Deprecated: ALGORITHM_MISMATCH is no longer returned; tokens with an
+	// algorithm outside the configuration fail with ErrUnsupportedAlgorithm.
+	// ErrorCodes lists it with Deprecated set.
+	ErrAlgorithmMismatch ErrorCode = "ALGORITHM_MISMATCH"
+
 	ErrNoneAlgorithm            ErrorCode = "NONE_ALGORITHM"
 	ErrConfigError              ErrorCode = "CONFIG_ERROR"
 	ErrUnsupportedAlgorithm     ErrorCode = "UNSUPPORTED_ALGORITHM"
 	ErrMalformedAlgorithmHeader ErrorCode = "MALFORMED_ALGORITHM_HEADER"
+	ErrKeyUnavailable           ErrorCode = "KEY_UNAVAILABLE"
+	ErrInvalidAudience          ErrorCode = "INVALID_AUDIENCE"
+	ErrRateLimited              ErrorCode = "RATE_LIMITED"
+	ErrTokenRevoked             ErrorCode = "TOKEN_REVOKED"
+	ErrRevocationUnavailable    ErrorCode = "REVOCATION_UNAVAILABLE"
+	ErrInvalidClaim             ErrorCode = "INVALID_CLAIM"
+	ErrAmbiguousToken           ErrorCode = "AMBIGUOUS_TOKEN"
+	ErrIPMismatch               ErrorCode = "IP_MISMATCH"
+	ErrDeviceMismatch           ErrorCode = "DEVICE_MISMATCH"
+	ErrDelegationNotAllowed     ErrorCode = "DELEGATION_NOT_ALLOWED"
+	ErrClaimsSchemaViolation    ErrorCode = "CLAIMS_SCHEMA_VIOLATION"
+	ErrEmptySignature           ErrorCode = "EMPTY_SIGNATURE"
+	ErrDetachedPayload          ErrorCode = "DETACHED_PAYLOAD"
+	ErrUnencodedPayload         ErrorCode = "UNENCODED_PAYLOAD"
+	ErrForbidden                ErrorCode = "FORBIDDEN"
+	ErrEmbeddedKeyHeader        ErrorCode = "EMBEDDED_KEY_HEADER"
+	ErrInvalidIssuer            ErrorCode = "INVALID_ISSUER"
+	ErrTokenReplayed            ErrorCode = "TOKEN_REPLAYED"
+	ErrInvalidTokenType         ErrorCode = "INVALID_TOKEN_TYPE"
+	ErrVerificationOverloaded   ErrorCode = "VERIFICATION_OVERLOADED"
+	ErrPolicyDenied             ErrorCode = "POLICY_DENIED"
+	ErrPolicyUnavailable        ErrorCode = "POLICY_UNAVAILABLE"
 )
 
 // ValidationError represents a JWT validation error with a code and message
 type ValidationError struct {
-	Code     ErrorCode
-	Message  string
-	Internal error
+	Code       ErrorCode
+	Message    string
+	Internal   error
+	RetryAfter time.Duration // Set for RATE_LIMITED errors
 }
 
 // Error implements the error interface
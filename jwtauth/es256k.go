@@ -0,0 +1,143 @@
+//go:build es256k
+
+package jwtauth
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningMethodES256K verifies ES256K (ECDSA over secp256k1 with SHA-256,
+// RFC 8812) signatures, as issued by blockchain wallets and custody
+// platforms, with the decred secp256k1 implementation. It is verification
+// only: Sign returns an error. It is registered with jwt.RegisterSigningMethod
+// when a Config first names ES256K (WithES256K, or "ES256K" with
+// WithKeyProvider, WithKeySet or WithJWKSAlgorithms), so programs that never
+// opt in do not recognize the algorithm.
+var SigningMethodES256K jwt.SigningMethod = &signingMethodES256K{}
+
+// errES256KSigning is returned by SigningMethodES256K.Sign
+var errES256KSigning = errors.New("ES256K signing is not supported")
+
+// signingMethodES256K verifies raw R || S signatures with secp256k1 keys
+type signingMethodES256K struct{}
+
+// Alg implements jwt.SigningMethod
+func (m *signingMethodES256K) Alg() string {
+	return "ES256K"
+}
+
+// Verify implements jwt.SigningMethod. The key must be a
+// *secp256k1.PublicKey, so a P-256 key never verifies an ES256K token.
+func (m *signingMethodES256K) Verify(signingString string, sig []byte, key interface{}) error {
+	pub, ok := key.(*secp256k1.PublicKey)
+	if !ok {
+		return fmt.Errorf("ES256K verify expects a *secp256k1.PublicKey: %w", jwt.ErrInvalidKeyType)
+	}
+	if len(sig) != 64 {
+		return jwt.ErrECDSAVerification
+	}
+	// R and S must be in [1, N-1]; SetByteSlice reduces larger values mod N
+	var r, s secp256k1.ModNScalar
+	if r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) || r.IsZero() || s.IsZero() {
+		return jwt.ErrECDSAVerification
+	}
+	digest := sha256.Sum256([]byte(signingString))
+	if !secp256k1ecdsa.NewSignature(&r, &s).Verify(digest[:], pub) {
+		return jwt.ErrECDSAVerification
+	}
+	return nil
+}
+
+// Sign implements jwt.SigningMethod; ES256K signing is not supported
+func (m *signingMethodES256K) Sign(signingString string, key interface{}) ([]byte, error) {
+	return nil, errES256KSigning
+}
+
+var registerES256KOnce sync.Once
+
+// lookupSigningMethod returns the signing method for alg, registering
+// ES256K on first use since it is opt-in
+func lookupSigningMethod(alg string) jwt.SigningMethod {
+	if alg == SigningMethodES256K.Alg() {
+		registerES256KOnce.Do(func() {
+			jwt.RegisterSigningMethod(alg, func() jwt.SigningMethod { return SigningMethodES256K })
+		})
+	}
+	return jwt.GetSigningMethod(alg)
+}
+
+// isES256KMethod reports whether method is SigningMethodES256K
+func isES256KMethod(method jwt.SigningMethod) bool {
+	_, ok := method.(*signingMethodES256K)
+	return ok
+}
+
+// WithES256K configures ES256K (ECDSA secp256k1 SHA-256) validation with the
+// given public key. secp256k1 is not a NIST curve and not supported by the
+// standard library, so ES256K is only compiled in with the es256k build tag
+// and off unless configured. The key may come from ParseSecp256k1PublicKey,
+// a secp256k1 JWK (ParseJWK) or secp256k1.ParsePubKey.
+func WithES256K(publicKey *secp256k1.PublicKey) ConfigOption {
+	return func(c *Config) error {
+		if publicKey == nil {
+			return fmt.Errorf("ES256K public key cannot be nil")
+		}
+		c.validators["ES256K"] = algorithmValidator{
+			signingKey:    publicKey,
+			signingMethod: lookupSigningMethod("ES256K"),
+		}
+		return nil
+	}
+}
+
+// ParseSecp256k1PublicKey parses a SEC 1 encoded secp256k1 public key,
+// compressed (33 bytes, 0x02 or 0x03 prefix) or uncompressed (65 bytes,
+// 0x04 prefix), as exchanged by blockchain tooling
+func ParseSecp256k1PublicKey(data []byte) (*secp256k1.PublicKey, error) {
+	key, err := secp256k1.ParsePubKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secp256k1 public key: %w", err)
+	}
+	return key, nil
+}
+
+// es256kKeyOption returns WithES256K for a secp256k1 key, or nil for other
+// keys
+func es256kKeyOption(key interface{}) ConfigOption {
+	if k, ok := key.(*secp256k1.PublicKey); ok {
+		return WithES256K(k)
+	}
+	return nil
+}
+
+// parseSecp256k1JWK returns the secp256k1 key with the given JWK
+// coordinates
+func parseSecp256k1JWK(x, y []byte) (interface{}, error) {
+	var fx, fy secp256k1.FieldVal
+	if len(x) > 32 || len(y) > 32 || fx.SetByteSlice(x) || fy.SetByteSlice(y) {
+		return nil, fmt.Errorf("EC point is not on curve secp256k1")
+	}
+	key := secp256k1.NewPublicKey(&fx, &fy)
+	if !key.IsOnCurve() {
+		return nil, fmt.Errorf("EC point is not on curve secp256k1")
+	}
+	return key, nil
+}
+
+// secp256k1JWKCoordinates returns the JWK x and y coordinates of a
+// secp256k1 key
+func secp256k1JWKCoordinates(key interface{}) (x, y []byte, ok bool) {
+	k, ok := key.(*secp256k1.PublicKey)
+	if !ok {
+		return nil, nil, false
+	}
+	uncompressed := k.SerializeUncompressed()
+	return uncompressed[1:33], uncompressed[33:], true
+}
@@ -0,0 +1,36 @@
+//go:build !es256k
+
+package jwtauth
+
+import (
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// lookupSigningMethod returns the signing method for alg. ES256K is only
+// available with the es256k build tag.
+func lookupSigningMethod(alg string) jwt.SigningMethod {
+	return jwt.GetSigningMethod(alg)
+}
+
+// isES256KMethod reports whether method is SigningMethodES256K, which is
+// not compiled in
+func isES256KMethod(method jwt.SigningMethod) bool {
+	return false
+}
+
+// es256kKeyOption returns nil; secp256k1 keys need the es256k build tag
+func es256kKeyOption(key interface{}) ConfigOption {
+	return nil
+}
+
+// parseSecp256k1JWK rejects secp256k1 JWKs without the es256k build tag
+func parseSecp256k1JWK(x, y []byte) (interface{}, error) {
+	return nil, fmt.Errorf("secp256k1 keys require building with -tags es256k")
+}
+
+// secp256k1JWKCoordinates reports that key is not a secp256k1 key
+func secp256k1JWKCoordinates(key interface{}) (x, y []byte, ok bool) {
+	return nil, nil, false
+}
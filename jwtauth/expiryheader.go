@@ -0,0 +1,47 @@
+package jwtauth
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultExpiresInHeader is the header set by WithExpiresInHeader("")
+const DefaultExpiresInHeader = "X-Token-Expires-In"
+
+// WithExpiresInHeader adds a header to successful responses with the number
+// of whole seconds until the token expires, so browser clients can schedule
+// a silent refresh without decoding the JWT. name defaults to
+// X-Token-Expires-In. Tokens without exp get no header. Cross-origin scripts
+// can only read the header if the application's CORS middleware exposes it.
+func WithExpiresInHeader(name string) ConfigOption {
+	return func(c *Config) error {
+		if name == "" {
+			name = DefaultExpiresInHeader
+		}
+		if strings.ContainsAny(name, " :\t") {
+			return fmt.Errorf("invalid expires-in header name %q", name)
+		}
+		c.expiresInHeader = textproto.CanonicalMIMEHeaderKey(name)
+		return nil
+	}
+}
+
+// setExpiryHeaders sets the WithExpiresInHeader and WithExpiryWarning
+// headers for claims
+func setExpiryHeaders(h http.Header, cfg *Config, claims *Claims) {
+	if claims.ExpiresAt.IsZero() {
+		return
+	}
+	now := time.Now()
+	seconds := strconv.FormatInt(max(int64(claims.ExpiresAt.Sub(now)/time.Second), 0), 10)
+	if cfg.expiresInHeader != "" {
+		h.Set(cfg.expiresInHeader, seconds)
+	}
+	if _, near := cfg.nearExpiry(claims, now); near && cfg.expiryWarning.Header != "" {
+		h.Set(cfg.expiryWarning.Header, seconds)
+	}
+}
@@ -0,0 +1,65 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestWithExpiresInHeader tests the expiry countdown header on responses
+func TestWithExpiresInHeader(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+
+	tests := []struct {
+		name       string
+		option     ConfigOption
+		claims     jwt.MapClaims
+		header     string
+		wantHeader bool
+	}{
+		{"default name", WithExpiresInHeader(""), jwt.MapClaims{"sub": "u", "exp": time.Now().Add(10 * time.Minute).Unix()}, "X-Token-Expires-In", true},
+		{"custom name", WithExpiresInHeader("x-session-ttl"), jwt.MapClaims{"sub": "u", "exp": time.Now().Add(10 * time.Minute).Unix()}, "X-Session-Ttl", true},
+		{"no exp claim", WithExpiresInHeader(""), jwt.MapClaims{"sub": "u"}, "X-Token-Expires-In", false},
+		{"disabled", WithClockSkew(time.Minute), jwt.MapClaims{"sub": "u", "exp": time.Now().Add(10 * time.Minute).Unix()}, "X-Token-Expires-In", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := createTestRouter(mustCreateConfig(WithHS256(secret), tt.option))
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+mustSignHS256(secret, tt.claims))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d", w.Code)
+			}
+
+			value := w.Header().Get(tt.header)
+			if !tt.wantHeader {
+				if value != "" {
+					t.Errorf("Expected no %s header, got %q", tt.header, value)
+				}
+				return
+			}
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 595 || seconds > 600 {
+				t.Errorf("Expected %s near 600, got %q", tt.header, value)
+			}
+		})
+	}
+
+	// Failed requests carry no countdown
+	router := createTestRouter(mustCreateConfig(WithHS256(secret), WithExpiresInHeader("")))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/protected", nil))
+	if w.Header().Get(DefaultExpiresInHeader) != "" {
+		t.Error("Expected no header on an unauthorized response")
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithExpiresInHeader("bad name")); err == nil {
+		t.Error("Expected invalid header name to be rejected")
+	}
+}
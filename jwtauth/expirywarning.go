@@ -0,0 +1,68 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// ExpiryWarning configures WithExpiryWarning
+type ExpiryWarning struct {
+	Window time.Duration // Remaining lifetime below which a warning is emitted (required)
+	Header string        // Optional response header set to the remaining seconds, e.g. "X-Token-Expiring"
+}
+
+// WithExpiryWarning emits an "expiry_warning" SecurityEvent when a token
+// validates with less than Window left before exp, to measure how many
+// clients use tokens until they expire instead of refreshing early. With
+// Header set, such HTTP and SSE responses also carry the remaining seconds
+// in that header. Requests are not rejected.
+func WithExpiryWarning(w ExpiryWarning) ConfigOption {
+	return func(c *Config) error {
+		if w.Window <= 0 {
+			return fmt.Errorf("expiry warning window must be positive, got %v", w.Window)
+		}
+		if w.Header != "" {
+			if strings.ContainsAny(w.Header, " :\t") {
+				return fmt.Errorf("invalid expiry warning header name %q", w.Header)
+			}
+			w.Header = textproto.CanonicalMIMEHeaderKey(w.Header)
+		}
+		c.expiryWarning = &w
+		return nil
+	}
+}
+
+// nearExpiry returns the remaining lifetime of claims and whether it is
+// within the WithExpiryWarning window
+func (c *Config) nearExpiry(claims *Claims, now time.Time) (time.Duration, bool) {
+	if c.expiryWarning == nil || claims.ExpiresAt.IsZero() {
+		return 0, false
+	}
+	remaining := claims.ExpiresAt.Sub(now)
+	return remaining, remaining < c.expiryWarning.Window
+}
+
+// warnNearExpiry emits an expiry_warning event for tokens close to expiry
+func warnNearExpiry(ctx context.Context, cfg *Config, requestID, token string, claims *Claims, tenant string) {
+	now := time.Now()
+	remaining, near := cfg.nearExpiry(claims, now)
+	if !near {
+		return
+	}
+
+	clientIP, _ := GetClientIP(ctx)
+	logSecurityEvent(cfg.Logger(), SecurityEvent{
+		EventType:    "expiry_warning",
+		Timestamp:    now,
+		RequestID:    requestID,
+		ClientIP:     clientIP,
+		UserID:       claims.Subject,
+		TenantID:     tenant,
+		Algorithm:    extractAlgorithmFromToken(token),
+		TokenPreview: token,
+		ExpiresIn:    max(remaining, 0),
+	})
+}
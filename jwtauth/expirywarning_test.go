@@ -0,0 +1,67 @@
+package jwtauth
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestWithExpiryWarning tests warning events and headers for tokens close to expiry
+func TestWithExpiryWarning(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+
+	tests := []struct {
+		name     string
+		exp      interface{}
+		wantWarn bool
+	}{
+		{"near expiry", time.Now().Add(2 * time.Minute).Unix(), true},
+		{"fresh token", time.Now().Add(time.Hour).Unix(), false},
+		{"no exp", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			cfg := mustCreateConfig(
+				WithHS256(secret),
+				WithExpiryWarning(ExpiryWarning{Window: 5 * time.Minute, Header: "x-token-expiring"}),
+				WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+			)
+			claims := jwt.MapClaims{"sub": "user123"}
+			if tt.exp != nil {
+				claims["exp"] = tt.exp
+			}
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+mustSignHS256(secret, claims))
+			w := httptest.NewRecorder()
+			createTestRouter(cfg).ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d", w.Code)
+			}
+
+			warned := strings.Contains(logs.String(), `"msg":"token near expiry"`)
+			if warned != tt.wantWarn {
+				t.Errorf("Expected warning=%t, got logs %s", tt.wantWarn, logs.String())
+			}
+			if tt.wantWarn && !strings.Contains(logs.String(), `"event":"expiry_warning"`) {
+				t.Errorf("Expected an expiry_warning event, got %s", logs.String())
+			}
+			if got := w.Header().Get("X-Token-Expiring"); (got != "") != tt.wantWarn {
+				t.Errorf("Expected header=%t, got %q", tt.wantWarn, got)
+			}
+		})
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithExpiryWarning(ExpiryWarning{})); err == nil {
+		t.Error("Expected zero window to be rejected")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithExpiryWarning(ExpiryWarning{Window: time.Minute, Header: "bad header"})); err == nil {
+		t.Error("Expected invalid header name to be rejected")
+	}
+}
@@ -2,25 +2,38 @@ package jwtauth
 
 import (
 	"net/http"
+	"net/textproto"
 	"strings"
 
 	"google.golang.org/grpc/metadata"
 )
 
-// extractTokenFromHeader extracts JWT token from Authorization header
+const (
+	headerFormatMessage   = "invalid authorization header format, expected 'Bearer <token>'"
+	metadataFormatMessage = "invalid authorization format, expected 'Bearer <token>'"
+)
+
+// extractTokensFromHeader extracts JWT candidates from Authorization headers
 // Expected format: "Authorization: Bearer <token>"
-func extractTokenFromHeader(r *http.Request) (string, error) {
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		return "", NewValidationError(ErrMissingToken, "authorization header not found", nil)
+func extractTokensFromHeader(r *http.Request, cfg *Config, requestID string) ([]string, error) {
+	if cfg.tokenHeader != "" {
+		return tokenHeaderCandidates(r.Header.Values(cfg.tokenHeader), cfg, requestID)
+	}
+	values := r.Header.Values("Authorization")
+	if len(values) == 0 || values[0] == "" {
+		return nil, NewValidationError(ErrMissingToken, "authorization header not found", nil)
 	}
+	return bearerCandidates(values, cfg, requestID, headerFormatMessage)
+}
 
-	parts := strings.SplitN(authHeader, " ", 2)
-	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-		return "", NewValidationError(ErrMalformed, "invalid authorization header format, expected 'Bearer <token>'", nil)
+// parseBearer extracts the token from a single "Bearer <token>" value
+func parseBearer(authHeader string, formatMessage string) (string, error) {
+	scheme, token, ok := strings.Cut(authHeader, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return "", NewValidationError(ErrMalformed, formatMessage, nil)
 	}
 
-	token := strings.TrimSpace(parts[1])
+	token = strings.TrimSpace(token)
 	if token == "" {
 		return "", NewValidationError(ErrMissingToken, "token is empty", nil)
 	}
@@ -30,12 +43,12 @@ func extractTokenFromHeader(r *http.Request) (string, error) {
 
 // extractTokenFromCookie extracts JWT token from a cookie
 func extractTokenFromCookie(r *http.Request, cookieName string) (string, error) {
-	cookie, err := r.Cookie(cookieName)
-	if err != nil {
-		return "", NewValidationError(ErrMissingToken, "cookie not found", err)
+	value, ok := cookieValue(r.Header["Cookie"], cookieName)
+	if !ok {
+		return "", NewValidationError(ErrMissingToken, "cookie not found", http.ErrNoCookie)
 	}
 
-	token := strings.TrimSpace(cookie.Value)
+	token := strings.TrimSpace(value)
 	if token == "" {
 		return "", NewValidationError(ErrMissingToken, "cookie value is empty", nil)
 	}
@@ -43,44 +56,87 @@ func extractTokenFromCookie(r *http.Request, cookieName string) (string, error)
 	return token, nil
 }
 
-// extractToken extracts JWT token from HTTP request
-// Checks Authorization header first, then falls back to cookie if configured
-func extractToken(r *http.Request, cfg *Config) (string, error) {
-	// Try header first
-	token, err := extractTokenFromHeader(r)
-	if err == nil {
-		return token, nil
+// cookieValue returns the first valid value of the named cookie, as
+// r.Cookie does, without allocating a Cookie for every pair in the header
+func cookieValue(lines []string, name string) (string, bool) {
+	for _, line := range lines {
+		for len(line) > 0 {
+			var part string
+			part, line, _ = strings.Cut(line, ";")
+			part = textproto.TrimString(part)
+			key, value, _ := strings.Cut(part, "=")
+			if key != name {
+				continue
+			}
+			if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+				value = value[1 : len(value)-1]
+			}
+			if validCookieValue(value) {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// validCookieValue reports whether value contains only cookie-octets
+// (RFC 6265), the bytes net/http accepts in cookie values
+func validCookieValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if b := value[i]; b < 0x20 || b >= 0x7f || b == '"' || b == ';' || b == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// extractTokens extracts JWT candidates from HTTP request
+// Checks Authorization header first, then falls back to cookie if configured.
+// Ambiguous headers never fall back: the request is rejected as sent.
+func extractTokens(r *http.Request, cfg *Config, requestID string) ([]string, error) {
+	// The Authorization header wins; cookies are only parsed when it fails
+	tokens, err := extractTokensFromHeader(r, cfg, requestID)
+	if err == nil || isAmbiguous(err) {
+		return tokens, err
 	}
 
 	// If cookie is configured, try it as fallback
 	if cfg.CookieName() != "" {
 		token, cookieErr := extractTokenFromCookie(r, cfg.CookieName())
 		if cookieErr == nil {
-			return token, nil
+			return []string{token}, nil
 		}
 	}
 
 	// Return the original header error
-	return "", err
+	return nil, err
 }
 
-// extractTokenFromMetadata extracts JWT token from gRPC metadata
-func extractTokenFromMetadata(md metadata.MD) (string, error) {
-	values := md.Get("authorization")
+// extractTokensFromMetadata extracts JWT candidates from gRPC metadata
+func extractTokensFromMetadata(md metadata.MD, cfg *Config, requestID string) ([]string, error) {
+	if cfg.tokenHeader != "" {
+		return tokenHeaderCandidates(metadataValues(md, cfg.tokenHeader), cfg, requestID)
+	}
+	values := metadataValues(md, "authorization")
+	if len(cfg.grpcTokenKeys) > 0 {
+		return grpcTokenKeyCandidates(md, values, cfg, requestID)
+	}
 	if len(values) == 0 {
-		return "", NewValidationError(ErrMissingToken, "authorization metadata not found", nil)
+		return nil, NewValidationError(ErrMissingToken, "authorization metadata not found", nil)
 	}
+	return bearerCandidates(values, cfg, requestID, metadataFormatMessage)
+}
 
-	authHeader := values[0]
-	parts := strings.SplitN(authHeader, " ", 2)
-	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-		return "", NewValidationError(ErrMalformed, "invalid authorization format, expected 'Bearer <token>'", nil)
+// extractTokensFromMessageHeaders extracts JWT candidates from message-bus
+// headers. Header names are matched case-insensitively because brokers
+// differ in whether they canonicalize them.
+func extractTokensFromMessageHeaders(headers map[string][]string, cfg *Config, requestID string) ([]string, error) {
+	if cfg.tokenHeader != "" {
+		return tokenHeaderCandidates(messageHeaderValues(headers, cfg.tokenHeader), cfg, requestID)
 	}
-
-	token := strings.TrimSpace(parts[1])
-	if token == "" {
-		return "", NewValidationError(ErrMissingToken, "token is empty", nil)
+	values := messageHeaderValues(headers, "Authorization")
+	if len(values) == 0 || values[0] == "" {
+		return nil, NewValidationError(ErrMissingToken, "authorization header not found", nil)
 	}
-
-	return token, nil
+	return bearerCandidates(values, cfg, requestID, headerFormatMessage)
 }
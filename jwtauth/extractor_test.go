@@ -0,0 +1,58 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestExtractTokens tests header precedence and cookie fallback
+func TestExtractTokens(t *testing.T) {
+	cfg := mustCreateConfig(WithHS256([]byte("test-secret-key-min-32-bytes-long!!")), WithCookie("session"))
+
+	tests := []struct {
+		name     string
+		auth     string
+		cookies  []string
+		want     string
+		wantCode ErrorCode
+	}{
+		{"header", "Bearer header.token", []string{"session=cookie.token"}, "header.token", ""},
+		{"lowercase scheme", "bearer header.token", nil, "header.token", ""},
+		{"cookie fallback", "", []string{"theme=dark; session=cookie.token"}, "cookie.token", ""},
+		{"malformed header falls back", "Basic abc", []string{"session=cookie.token"}, "cookie.token", ""},
+		{"quoted cookie", "", []string{`session="cookie.token"`}, "cookie.token", ""},
+		{"second cookie line", "", []string{"theme=dark", "session=cookie.token"}, "cookie.token", ""},
+		{"invalid cookie value skipped", "", []string{`session=bad\value; session=cookie.token`}, "cookie.token", ""},
+		{"missing", "", []string{"sessionid=other"}, "", ErrMissingToken},
+		{"malformed without cookie", "Token abc", nil, "", ErrMalformed},
+		{"empty bearer", "Bearer ", nil, "", ErrMissingToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			for _, line := range tt.cookies {
+				r.Header.Add("Cookie", line)
+			}
+
+			tokens, err := extractTokens(r, cfg, "req-1")
+			if tt.wantCode != "" {
+				if getErrorCode(err) != string(tt.wantCode) {
+					t.Errorf("Expected %s, got %v", tt.wantCode, err)
+				}
+				return
+			}
+			if err != nil || len(tokens) != 1 || tokens[0] != tt.want {
+				t.Errorf("Expected %q, got %v, %v", tt.want, tokens, err)
+			}
+
+			// The cookie scanner must agree with net/http
+			if c, err := r.Cookie("session"); tt.auth == "" && (err != nil || c.Value != tt.want) {
+				t.Errorf("net/http parsed %v, %v", c, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxDelayedFailuresPerClient bounds how many delayed failures one client IP
+// may have in flight. Further failures from it are answered at once, so
+// concurrent forgeries cannot hold more goroutines, while tokens that verify
+// are never turned away.
+const maxDelayedFailuresPerClient = 4
+
+// failureDelay holds the WithFailureDelay range and the delayed failures in
+// flight per client IP
+type failureDelay struct {
+	min, max time.Duration
+	delayed  sync.Map // Client IP -> *atomic.Int32 delayed failures in flight
+}
+
+// WithFailureDelay delays INVALID_SIGNATURE responses by a random duration
+// between min and max, slowing down signature brute-forcing. Valid tokens
+// and other failures are not delayed.
+//
+// Go servers cannot hold a request without its goroutine: the delay waits on
+// a timer in the goroutine net/http or gRPC already runs the request on, and
+// ends early when the client disconnects or the RPC is canceled. To bound
+// what one client can hold, further INVALID_SIGNATURE failures from a client
+// IP with 4 delayed failures in flight are answered without delay. Tokens
+// are always verified first, so valid tokens from that IP (e.g. other users
+// behind the same NAT) are still accepted. Many attacking IPs are bounded
+// only by the server's own connection limits, so keep max short.
+func WithFailureDelay(min, max time.Duration) ConfigOption {
+	return func(c *Config) error {
+		if min < 0 || max < min {
+			return fmt.Errorf("failure delay requires 0 <= min <= max, got %v and %v", min, max)
+		}
+		if max == 0 {
+			c.failureDelay = nil
+			return nil
+		}
+		c.failureDelay = &failureDelay{min: min, max: max}
+		return nil
+	}
+}
+
+// delayFailure waits out the failure delay for err, if any, unless the
+// client already has the maximum number of delayed failures in flight
+func (c *Config) delayFailure(ctx context.Context, err error) {
+	d := c.failureDelay
+	if d == nil {
+		return
+	}
+	if valErr, ok := err.(*ValidationError); !ok || valErr.Code != ErrInvalidSignature {
+		return
+	}
+
+	// Only HTTP and gRPC responses are delayed; messages carry no client IP
+	clientIP, ok := GetClientIP(ctx)
+	if !ok {
+		return
+	}
+
+	// A count deleted while another failure joins it only undercounts
+	// briefly
+	v, _ := d.delayed.LoadOrStore(clientIP, new(atomic.Int32))
+	inFlight := v.(*atomic.Int32)
+	over := inFlight.Add(1) > maxDelayedFailuresPerClient
+	defer func() {
+		if inFlight.Add(-1) <= 0 {
+			d.delayed.CompareAndDelete(clientIP, inFlight)
+		}
+	}()
+	if over {
+		return
+	}
+
+	delay := d.min
+	if d.max > d.min {
+		delay += rand.N(d.max - d.min + 1)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,120 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestWithFailureDelay tests that only INVALID_SIGNATURE responses are delayed
+func TestWithFailureDelay(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithFailureDelay(50*time.Millisecond, 60*time.Millisecond))
+	router := createTestRouter(cfg)
+
+	valid := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	forged := mustSignHS256([]byte("attacker-secret-key-min-32-bytes!!"), jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	expired := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(-time.Hour).Unix()})
+
+	tests := []struct {
+		name    string
+		token   string
+		status  int
+		delayed bool
+	}{
+		{"valid token", valid, http.StatusOK, false},
+		{"invalid signature", forged, http.StatusUnauthorized, true},
+		{"expired token", expired, http.StatusUnauthorized, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			start := time.Now()
+			router.ServeHTTP(w, req)
+			elapsed := time.Since(start)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if delayed := elapsed >= 50*time.Millisecond; delayed != tt.delayed {
+				t.Errorf("Expected delayed=%v, took %v", tt.delayed, elapsed)
+			}
+		})
+	}
+
+	t.Run("canceled request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(WithClientIP(context.Background(), "192.0.2.1"))
+		cancel()
+		start := time.Now()
+		cfg.delayFailure(ctx, NewValidationError(ErrInvalidSignature, "", nil))
+		if elapsed := time.Since(start); elapsed >= 50*time.Millisecond {
+			t.Errorf("Expected canceled request to skip the delay, took %v", elapsed)
+		}
+	})
+
+	t.Run("delayed failures per client", func(t *testing.T) {
+		delayedFor := func(clientIP string) int32 {
+			if v, ok := cfg.failureDelay.delayed.Load(clientIP); ok {
+				return v.(*atomic.Int32).Load()
+			}
+			return 0
+		}
+		request := func(token string) (*httptest.ResponseRecorder, time.Duration) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.RemoteAddr = "192.0.2.10:1234"
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			start := time.Now()
+			router.ServeHTTP(w, req)
+			return w, time.Since(start)
+		}
+
+		// Hold the maximum number of delayed failures for one client
+		var wg sync.WaitGroup
+		for i := 0; i < maxDelayedFailuresPerClient; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				request(forged)
+			}()
+		}
+		deadline := time.Now().Add(time.Second)
+		for delayedFor("192.0.2.10") < maxDelayedFailuresPerClient && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+
+		// A valid token from the saturated client is still accepted
+		if w, _ := request(valid); w.Code != http.StatusOK {
+			t.Errorf("Expected a valid token from a saturated client to get 200, got %d", w.Code)
+		}
+
+		// Further forgeries are answered at once with the same error
+		w, elapsed := request(forged)
+		if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), string(ErrInvalidSignature)) {
+			t.Errorf("Expected 401 INVALID_SIGNATURE, got %d %s", w.Code, w.Body)
+		}
+		if elapsed >= 50*time.Millisecond {
+			t.Errorf("Expected the failure over the cap not to be delayed, took %v", elapsed)
+		}
+
+		wg.Wait()
+		if _, tracked := cfg.failureDelay.delayed.Load("192.0.2.10"); tracked {
+			t.Error("Expected the client to be forgotten once its failures completed")
+		}
+	})
+
+	for _, bad := range [][2]time.Duration{{-time.Second, time.Second}, {time.Second, time.Millisecond}} {
+		if _, err := NewConfig(WithHS256(secret), WithFailureDelay(bad[0], bad[1])); err == nil {
+			t.Errorf("Expected WithFailureDelay(%v, %v) to be rejected", bad[0], bad[1])
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Fault is a failure injected by WithFaultInjector. Effects can be
+// combined, e.g. latency followed by a rejection.
+type Fault struct {
+	Fraction  float64       // Share of requests affected, 0 to 1
+	Code      ErrorCode     // Reject the request with this code
+	Latency   time.Duration // Delay validation; stops early when the request is canceled
+	KeyOutage bool          // Fail key provider lookups as if the key source were down
+}
+
+// ErrFaultInjected is the internal error of failures injected by
+// WithFaultInjector; check with errors.Is
+var ErrFaultInjected = errors.New("jwtauth: injected fault")
+
+// faultKeyOutageContextKey marks a request whose key lookups must fail
+type faultKeyOutageContextKey struct{}
+
+// WithFaultInjector injects failures into a random fraction of requests
+// for chaos testing, so services can be exercised under auth degradation:
+//
+//	jwtauth.WithFaultInjector(
+//		jwtauth.Fault{Fraction: 0.05, Code: jwtauth.ErrKeyUnavailable},
+//		jwtauth.Fault{Fraction: 0.2, Latency: 300 * time.Millisecond},
+//		jwtauth.Fault{Fraction: 0.1, KeyOutage: true},
+//	)
+//
+// Each fault is drawn independently. Injected rejections carry
+// ErrFaultInjected as their internal error and go through the usual error
+// responses and security events. Key outages fail key provider lookups, so
+// WithKeyOutagePolicy and cached tokens behave as during a real outage.
+//
+// For testing only: never enable it in production configurations. A
+// warning is logged when a configuration with faults is built.
+func WithFaultInjector(faults ...Fault) ConfigOption {
+	return func(c *Config) error {
+		if len(faults) == 0 {
+			return fmt.Errorf("fault injector requires at least one fault")
+		}
+		for i, f := range faults {
+			if math.IsNaN(f.Fraction) || f.Fraction < 0 || f.Fraction > 1 {
+				return fmt.Errorf("fault %d: fraction must be between 0 and 1", i)
+			}
+			if f.Code == "" && f.Latency == 0 && !f.KeyOutage {
+				return fmt.Errorf("fault %d: no code, latency or key outage set", i)
+			}
+			if f.Latency < 0 {
+				return fmt.Errorf("fault %d: latency must be non-negative, got %v", i, f.Latency)
+			}
+			if info, ok := f.Code.Info(); f.Code != "" && (!ok || info.Deprecated) {
+				return fmt.Errorf("fault %d: unknown error code %q", i, f.Code)
+			}
+		}
+		c.faults = append(c.faults, faults...)
+		return nil
+	}
+}
+
+// checkFaults validates the fault injector against the final configuration
+func (c *Config) checkFaults() error {
+	if len(c.faults) == 0 {
+		return nil
+	}
+	for i, f := range c.faults {
+		if f.KeyOutage && !c.hasKeyProvider() {
+			return NewValidationError(ErrConfigError, fmt.Sprintf("fault %d: key outage requires a key provider", i), nil)
+		}
+	}
+	if c.Logger() != nil {
+		c.Logger().Warn("fault injection enabled; for testing only", "faults", len(c.faults))
+	}
+	return nil
+}
+
+// hasKeyProvider reports whether any validator resolves keys from a provider
+func (c *Config) hasKeyProvider() bool {
+	for _, validator := range c.validators {
+		if validator.keyProvider != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// injectFaults applies the faults drawn for one request. It returns the
+// context to validate with and the injected rejection, if any.
+func (c *Config) injectFaults(ctx context.Context) (context.Context, error) {
+	for _, f := range c.faults {
+		if f.Fraction < 1 && rand.Float64() >= f.Fraction {
+			continue
+		}
+		if f.Latency > 0 {
+			timer := time.NewTimer(f.Latency)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+			}
+		}
+		if f.KeyOutage {
+			ctx = context.WithValue(ctx, faultKeyOutageContextKey{}, true)
+		}
+		if f.Code != "" {
+			info, _ := f.Code.Info()
+			return ctx, NewValidationError(f.Code, "injected fault: "+info.Description, ErrFaultInjected)
+		}
+	}
+	return ctx, nil
+}
+
+// faultKeyOutage reports whether key lookups for the request must fail
+func faultKeyOutage(ctx context.Context) bool {
+	outage, _ := ctx.Value(faultKeyOutageContextKey{}).(bool)
+	return outage
+}
@@ -0,0 +1,94 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestFaultInjectorCodesAndLatency tests forced rejections and added latency
+func TestFaultInjectorCodesAndLatency(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	serve := func(cfg *Config) (int, string) {
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		createTestRouter(cfg).ServeHTTP(w, req)
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body.Reason
+	}
+
+	cfg := mustCreateConfig(WithHS256(secret), WithFaultInjector(Fault{Fraction: 1, Code: ErrKeyUnavailable}))
+	if status, reason := serve(cfg); status != 401 || reason != string(ErrKeyUnavailable) {
+		t.Errorf("expected injected 401 KEY_UNAVAILABLE, got %d %s", status, reason)
+	}
+	if _, _, err := authenticateToken(context.Background(), token, "", cfg); !errors.Is(err, ErrFaultInjected) {
+		t.Errorf("expected ErrFaultInjected, got %v", err)
+	}
+
+	cfg = mustCreateConfig(WithHS256(secret), WithFaultInjector(Fault{Fraction: 0, Code: ErrExpired}))
+	if status, _ := serve(cfg); status != 200 {
+		t.Errorf("expected fraction 0 to inject nothing, got %d", status)
+	}
+
+	cfg = mustCreateConfig(WithHS256(secret), WithFaultInjector(Fault{Fraction: 1, Latency: 50 * time.Millisecond}))
+	start := time.Now()
+	if status, _ := serve(cfg); status != 200 {
+		t.Errorf("expected latency-only fault to pass, got %d", status)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected at least 50ms of injected latency, got %v", elapsed)
+	}
+}
+
+// TestFaultInjectorKeyOutage tests that injected key outages go through the
+// key outage policy
+func TestFaultInjectorKeyOutage(t *testing.T) {
+	ecKey := mustGenerateECKey()
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}).SignedString(ecKey)
+	provider := WithKeyProvider("ES256", StaticKeyProvider(&ecKey.PublicKey))
+	outage := WithFaultInjector(Fault{Fraction: 1, KeyOutage: true})
+
+	_, _, err := authenticateToken(context.Background(), token, "", mustCreateConfig(provider, outage))
+	if getErrorCode(err) != string(ErrKeyUnavailable) || !errors.Is(err, ErrFaultInjected) {
+		t.Errorf("expected injected KEY_UNAVAILABLE, got %v", err)
+	}
+
+	claims, _, err := authenticateToken(context.Background(), token, "", mustCreateConfig(provider, outage, WithKeyOutagePolicy(KeyOutage{Policy: KeyOutageFailOpen})))
+	if err != nil || !claims.degraded {
+		t.Errorf("expected a degraded acceptance under fail-open, got %v", err)
+	}
+}
+
+// TestFaultInjectorConfigErrors tests rejected fault configurations
+func TestFaultInjectorConfigErrors(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	tests := []struct {
+		name string
+		opt  ConfigOption
+	}{
+		{"no faults", WithFaultInjector()},
+		{"fraction above 1", WithFaultInjector(Fault{Fraction: 1.5, Code: ErrExpired})},
+		{"no effect", WithFaultInjector(Fault{Fraction: 0.5})},
+		{"negative latency", WithFaultInjector(Fault{Fraction: 0.5, Latency: -time.Second})},
+		{"unknown code", WithFaultInjector(Fault{Fraction: 0.5, Code: "NOPE"})},
+		{"key outage without provider", WithFaultInjector(Fault{Fraction: 0.5, KeyOutage: true})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConfig(WithHS256(secret), tt.opt); err == nil {
+				t.Error("expected configuration error")
+			}
+		})
+	}
+}
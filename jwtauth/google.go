@@ -0,0 +1,85 @@
+package jwtauth
+
+import "fmt"
+
+// Google identity headers and issuers
+const (
+	// GoogleIAPHeader carries the signed identity assertion added by Cloud IAP
+	GoogleIAPHeader = "X-Goog-IAP-JWT-Assertion"
+	// GoogleIAPIssuer is the iss of Cloud IAP assertions
+	GoogleIAPIssuer = "https://cloud.google.com/iap"
+	// GoogleESPHeader carries the caller's original Authorization value when
+	// ESPv2 (Cloud Endpoints, API Gateway) authenticates to the backend itself
+	GoogleESPHeader = "X-Forwarded-Authorization"
+)
+
+// Google key endpoints. Variables so tests can serve their own keys.
+var (
+	googleIAPKeysURL     = "https://www.gstatic.com/iap/verify/public_key-jwk"
+	googleIDTokenKeysURL = "https://www.googleapis.com/oauth2/v3/certs"
+)
+
+// googleIDTokenIssuers are both iss forms used in Google-signed ID tokens
+var googleIDTokenIssuers = []string{"https://accounts.google.com", "accounts.google.com"}
+
+// WithGoogleIAP validates the assertions Cloud IAP adds to requests it lets
+// through: ES256 tokens in X-Goog-IAP-JWT-Assertion, signed with Google's
+// IAP keys (fetched and cached by kid) and issued by
+// https://cloud.google.com/iap. audience is the backend's expected aud,
+// "/projects/PROJECT_NUMBER/global/backendServices/SERVICE_ID" or
+// "/projects/PROJECT_NUMBER/apps/PROJECT_ID" for App Engine.
+//
+// Validating the assertion protects against requests that bypass IAP, e.g.
+// from inside the VPC. The user's email is in the "email" claim.
+func WithGoogleIAP(audience string) ConfigOption {
+	return func(c *Config) error {
+		if audience == "" {
+			return fmt.Errorf("Google IAP audience is required")
+		}
+		return applyOptions(c,
+			WithTokenHeader(GoogleIAPHeader),
+			WithKeyProvider("ES256", newRemoteJWKS(googleIAPKeysURL)),
+			WithAudience(audience),
+			WithIssuers(GoogleIAPIssuer),
+		)
+	}
+}
+
+// WithGoogleIDToken validates Google-signed OIDC ID tokens in the
+// Authorization header, as sent by Cloud Run, Cloud Functions, Cloud
+// Scheduler and Pub/Sub push with service account identities. Tokens must
+// be RS256, signed with Google's OAuth2 keys, issued by accounts.google.com
+// and carry one of audiences (usually the service URL).
+func WithGoogleIDToken(audiences ...string) ConfigOption {
+	return func(c *Config) error {
+		if len(audiences) == 0 {
+			return fmt.Errorf("Google ID token audience is required")
+		}
+		return applyOptions(c,
+			WithKeyProvider("RS256", newRemoteJWKS(googleIDTokenKeysURL)),
+			WithAudience(audiences...),
+			WithIssuers(googleIDTokenIssuers...),
+		)
+	}
+}
+
+// WithGoogleESP is WithGoogleIDToken for backends behind ESPv2 (Cloud
+// Endpoints, API Gateway) with backend authentication enabled: ESPv2 then
+// replaces Authorization with its own token and forwards the caller's in
+// X-Forwarded-Authorization. Tokens from other issuers ESPv2 accepts
+// (Firebase, Auth0) need their own configuration.
+func WithGoogleESP(audiences ...string) ConfigOption {
+	return func(c *Config) error {
+		return applyOptions(c, WithGoogleIDToken(audiences...), WithTokenHeader(GoogleESPHeader))
+	}
+}
+
+// applyOptions applies opts in order, for presets composed of other options
+func applyOptions(c *Config, opts ...ConfigOption) error {
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
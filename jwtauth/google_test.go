@@ -0,0 +1,132 @@
+package jwtauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// serveJWKS serves keys as a JWKS document and counts fetches
+func serveJWKS(t *testing.T, keys ...JSONWebKey) (string, *atomic.Int32) {
+	t.Helper()
+	var fetches atomic.Int32
+	doc, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(doc)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &fetches
+}
+
+// TestWithGoogleIAP tests IAP assertion validation from the IAP header
+func TestWithGoogleIAP(t *testing.T) {
+	key := mustGenerateECKey()
+	url, fetches := serveJWKS(t, JSONWebKey{KeyID: "iap-1", Algorithm: "ES256", Key: &key.PublicKey})
+	defer func(old string) { googleIAPKeysURL = old }(googleIAPKeysURL)
+	googleIAPKeysURL = url
+
+	const audience = "/projects/123/global/backendServices/456"
+	cfg := mustCreateConfig(WithGoogleIAP(audience))
+	router := createTestRouter(cfg)
+
+	sign := func(kid string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	claims := func(iss, aud string) jwt.MapClaims {
+		return jwt.MapClaims{"iss": iss, "aud": aud, "sub": "accounts.google.com:1234", "email": "user@example.com", "exp": time.Now().Add(time.Hour).Unix()}
+	}
+	valid := sign("iap-1", claims(GoogleIAPIssuer, audience))
+
+	tests := []struct {
+		name       string
+		headers    map[string][]string
+		wantStatus int
+		wantReason string
+	}{
+		{"valid assertion", map[string][]string{GoogleIAPHeader: {valid}}, http.StatusOK, ""},
+		{"authorization header ignored", map[string][]string{"Authorization": {"Bearer " + valid}}, http.StatusUnauthorized, "MISSING_TOKEN"},
+		{"wrong audience", map[string][]string{GoogleIAPHeader: {sign("iap-1", claims(GoogleIAPIssuer, "/projects/123/apps/other"))}}, http.StatusUnauthorized, "INVALID_AUDIENCE"},
+		{"wrong issuer", map[string][]string{GoogleIAPHeader: {sign("iap-1", claims("https://evil.example.com", audience))}}, http.StatusUnauthorized, "INVALID_ISSUER"},
+		{"unknown kid", map[string][]string{GoogleIAPHeader: {sign("iap-2", claims(GoogleIAPIssuer, audience))}}, http.StatusUnauthorized, "KEY_UNAVAILABLE"},
+		{"two assertions", map[string][]string{GoogleIAPHeader: {valid, sign("iap-1", claims(GoogleIAPIssuer, audience+"x"))}}, http.StatusUnauthorized, "AMBIGUOUS_TOKEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			for name, values := range tt.headers {
+				for _, v := range values {
+					req.Header.Add(name, v)
+				}
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantReason) {
+				t.Errorf("Expected %s, got %s", tt.wantReason, w.Body)
+			}
+		})
+	}
+
+	// Unknown kids must not refetch the key set on every request
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("Expected 1 JWKS fetch, got %d", n)
+	}
+}
+
+// TestWithGoogleESP tests Google ID tokens forwarded by ESPv2
+func TestWithGoogleESP(t *testing.T) {
+	key := mustGenerateRSAKey()
+	url, _ := serveJWKS(t,
+		JSONWebKey{KeyID: "enc-1", Use: "enc", Key: &key.PublicKey},
+		JSONWebKey{KeyID: "google-1", Algorithm: "RS256", Use: "sig", Key: &key.PublicKey},
+	)
+	defer func(old string) { googleIDTokenKeysURL = old }(googleIDTokenKeysURL)
+	googleIDTokenKeysURL = url
+
+	cfg := mustCreateConfig(WithGoogleESP("https://api.example.com"))
+	router := createTestRouter(cfg)
+
+	for _, iss := range googleIDTokenIssuers {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"iss": iss, "aud": "https://api.example.com", "sub": "1234", "exp": time.Now().Add(time.Hour).Unix()})
+		token.Header["kid"] = "google-1"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer esp-backend-token")
+		req.Header.Set(GoogleESPHeader, "Bearer "+signed)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected forwarded token from %s to validate, got %d: %s", iss, w.Code, w.Body)
+		}
+	}
+
+	if _, err := NewConfig(WithGoogleIAP("")); err == nil {
+		t.Error("Expected IAP preset without audience to be rejected")
+	}
+	if _, err := NewConfig(WithGoogleIDToken()); err == nil {
+		t.Error("Expected ID token preset without audience to be rejected")
+	}
+}
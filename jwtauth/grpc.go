@@ -5,10 +5,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // UnaryServerInterceptor returns a gRPC unary server interceptor for JWT authentication
@@ -19,65 +22,215 @@ func UnaryServerInterceptor(cfg *Config) grpc.UnaryServerInterceptor {
 		info *grpc.UnaryServerInfo,
 		handler grpc.UnaryHandler,
 	) (interface{}, error) {
-		startTime := time.Now()
-
-		// Generate request ID for correlation
-		requestID := uuid.New().String()
-
-		// Extract metadata
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			logAuthFailureGRPC(cfg, requestID, "", NewValidationError(ErrMissingToken, "metadata not found", nil), time.Since(startTime))
-			return nil, status.Error(codes.Unauthenticated, "metadata not found")
+		ctx, cancel, err := authenticateGRPC(ctx, cfg, info.FullMethod)
+		if err != nil {
+			return nil, err
 		}
+		defer cancel(nil)
+
+		// Call the handler with enriched context
+		return handler(ctx, req)
+	}
+}
 
-		// Extract token from metadata
-		token, err := extractTokenFromMetadata(md)
+// StreamServerInterceptor returns a gRPC stream server interceptor for JWT
+// authentication. Handlers read claims from stream.Context(). With
+// WithStreamExpiryEnforcement, stream.Context() is cancelled when the token
+// expires, later SendMsg and RecvMsg calls fail, and the stream is closed
+// with UNAUTHENTICATED once the handler returns.
+func StreamServerInterceptor(cfg *Config) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, cancel, err := authenticateGRPC(ss.Context(), cfg, info.FullMethod)
 		if err != nil {
-			logAuthFailureGRPC(cfg, requestID, token, err, time.Since(startTime))
-			return nil, status.Error(codes.Unauthenticated, getErrorCode(err))
+			return err
 		}
+		defer cancel(nil)
 
-		// Validate token
-		claims, err := parseAndValidateJWT(token, cfg)
-		if err != nil {
-			logAuthFailureGRPC(cfg, requestID, token, err, time.Since(startTime))
-			return nil, status.Error(codes.Unauthenticated, getErrorCode(err))
+		// The handler owns the stream until it returns, so the interceptor
+		// never returns before it
+		err = handler(srv, &authenticatedServerStream{ServerStream: ss, ctx: ctx})
+		if terminated := streamTerminated(ctx); terminated != nil {
+			return terminated
 		}
+		return err
+	}
+}
 
-		// Inject claims and request ID into context
-		ctx = WithClaims(ctx, claims)
-		ctx = WithRequestID(ctx, requestID)
+// authenticatedServerStream overrides the stream context with the enriched one
+type authenticatedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
 
-		// Log successful authentication
-		logAuthSuccessGRPC(cfg, requestID, claims, token, time.Since(startTime))
+// Context returns the context carrying claims, request ID and tenant
+func (s *authenticatedServerStream) Context() context.Context {
+	return s.ctx
+}
 
-		// Call the handler with enriched context
-		return handler(ctx, req)
+// SendMsg fails once the token that opened the stream has expired
+func (s *authenticatedServerStream) SendMsg(m interface{}) error {
+	if err := streamTerminated(s.ctx); err != nil {
+		return err
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+// RecvMsg fails once the token that opened the stream has expired. A receive
+// already blocked returns with the client's next message or cancellation, so
+// handlers waiting on the client should also watch Context().
+func (s *authenticatedServerStream) RecvMsg(m interface{}) error {
+	if err := streamTerminated(s.ctx); err != nil {
+		return err
+	}
+	return s.ServerStream.RecvMsg(m)
+}
+
+// streamTerminated returns the UNAUTHENTICATED status for a stream ended by
+// expiry enforcement, or nil
+func streamTerminated(ctx context.Context) error {
+	if reason := StreamTerminationReason(ctx); reason != nil {
+		return status.Error(codes.Unauthenticated, getErrorCode(reason))
+	}
+	return nil
+}
+
+// authenticateGRPC authenticates an incoming RPC from its metadata and returns
+// the enriched context. The cancel func releases the expiry watcher and must
+// be called when the RPC completes. method is the full method name checked
+// against WithMethodRequirements. Errors are gRPC status errors.
+func authenticateGRPC(ctx context.Context, cfg *Config, method string) (context.Context, context.CancelCauseFunc, error) {
+	// GRPCWebHandler already authenticated this RPC from the same headers
+	// and under the same Config
+	if grpcWebAuthenticated(ctx, cfg) {
+		return ctx, func(error) {}, nil
+	}
+
+	startTime := time.Now()
+
+	// Generate request ID for correlation
+	requestID := uuid.New().String()
+
+	// Extract metadata
+	md, ok := metadata.FromIncomingContext(ctx)
+	clientIP := grpcClientIP(ctx, md, cfg)
+	ctx = WithClientIP(ctx, clientIP)
+	ctx = withDeviceFingerprint(ctx, deviceFingerprintFromMetadata(md, cfg))
+	if !ok {
+		logAuthFailureGRPC(cfg, requestID, clientIP, "", NewValidationError(ErrMissingToken, "metadata not found", nil), time.Since(startTime))
+		return nil, nil, status.Error(codes.Unauthenticated, "metadata not found")
 	}
+
+	// Extract token from metadata
+	tokens, err := extractTokensFromMetadata(md, cfg, requestID)
+	if err != nil {
+		logAuthFailureGRPC(cfg, requestID, clientIP, "", err, time.Since(startTime))
+		return nil, nil, status.Error(codes.Unauthenticated, getErrorCode(err))
+	}
+
+	// Validate token and apply revocation and rate limit policies
+	token, claims, tenant, err := authenticateCandidates(ctx, tokens, requestID, cfg)
+	if err != nil {
+		logAuthFailureGRPC(cfg, requestID, clientIP, token, err, time.Since(startTime))
+		return nil, nil, grpcStatusError(err)
+	}
+
+	// Enforce per-method authorization
+	if err := cfg.checkMethodRequirements(method, claims); err != nil {
+		logAuthFailureGRPC(cfg, requestID, clientIP, token, err, time.Since(startTime))
+		return nil, nil, grpcStatusError(err)
+	}
+	if err := cfg.checkGRPCPolicy(ctx, method, md, claims, clientIP, tenant); err != nil {
+		logAuthFailureGRPC(cfg, requestID, clientIP, token, err, time.Since(startTime))
+		return nil, nil, grpcStatusError(err)
+	}
+
+	// Inject claims and request ID into context
+	ctx = WithClaims(ctx, claims)
+	ctx = WithToken(ctx, token)
+	ctx = WithRequestID(ctx, requestID)
+	ctx = withConfig(ctx, cfg)
+	if tenant != "" {
+		ctx = WithTenant(ctx, tenant)
+	}
+
+	// Terminate the RPC when the token expires
+	cancel := context.CancelCauseFunc(func(error) {})
+	if cfg.streamExpiry {
+		ctx, cancel = watchStream(ctx, cfg, claims, 0, func(reason error) {
+			logAuthFailureGRPC(cfg, requestID, clientIP, token, reason, time.Since(startTime))
+		})
+	}
+
+	// Log successful authentication
+	logAuthSuccessGRPC(cfg, requestID, clientIP, claims, token, time.Since(startTime))
+
+	return ctx, cancel, nil
+}
+
+// grpcClientIP derives the client IP from the peer address and, when the
+// peer is a trusted proxy, x-forwarded-for metadata
+func grpcClientIP(ctx context.Context, md metadata.MD, cfg *Config) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	return cfg.clientIP(p.Addr.String(), md.Get("x-forwarded-for"))
+}
+
+// grpcCodeForError maps a validation error to its gRPC status code
+func grpcCodeForError(err error) codes.Code {
+	if valErr, ok := err.(*ValidationError); ok {
+		if info, ok := valErr.Code.Info(); ok {
+			return info.GRPCCode
+		}
+	}
+	return codes.Unauthenticated
+}
+
+// grpcStatusError converts an authentication failure to a gRPC status
+// error. RATE_LIMITED errors carry a google.rpc.RetryInfo detail with the
+// retry delay, so clients honoring it back off.
+func grpcStatusError(err error) error {
+	st := status.New(grpcCodeForError(err), getErrorCode(err))
+	if valErr, ok := err.(*ValidationError); ok && valErr.RetryAfter > 0 {
+		if detailed, detailErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(valErr.RetryAfter)}); detailErr == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
 }
 
 // logAuthSuccessGRPC logs a successful gRPC authentication event
-func logAuthSuccessGRPC(cfg *Config, requestID string, claims *Claims, token string, latency time.Duration) {
+func logAuthSuccessGRPC(cfg *Config, requestID, clientIP string, claims *Claims, token string, latency time.Duration) {
 	if cfg.Logger() == nil {
 		return
 	}
 
 	event := SecurityEvent{
-		EventType:    "success",
-		Timestamp:    time.Now(),
-		RequestID:    requestID,
-		UserID:       claims.Subject,
-		Algorithm:    extractAlgorithmFromToken(token),
-		TokenPreview: token,
-		Latency:      latency,
+		EventType:     "success",
+		Timestamp:     time.Now(),
+		RequestID:     requestID,
+		ClientIP:      clientIP,
+		UserID:        claims.Subject,
+		TenantID:      cfg.tenantFromClaims(claims),
+		Algorithm:     extractAlgorithmFromToken(token),
+		TokenPreview:  token,
+		Latency:       latency,
+		Degraded:      claims.degraded,
+		KeySource:     claims.Provenance.KeySource,
+		MatchedConfig: claims.matchedConfig,
 	}
 
 	logSecurityEvent(cfg.Logger(), event)
 }
 
 // logAuthFailureGRPC logs a failed gRPC authentication event
-func logAuthFailureGRPC(cfg *Config, requestID string, token string, err error, latency time.Duration) {
+func logAuthFailureGRPC(cfg *Config, requestID, clientIP, token string, err error, latency time.Duration) {
 	if cfg.Logger() == nil {
 		return
 	}
@@ -86,8 +239,10 @@ func logAuthFailureGRPC(cfg *Config, requestID string, token string, err error,
 		EventType:     "failure",
 		Timestamp:     time.Now(),
 		RequestID:     requestID,
+		ClientIP:      clientIP,
 		Algorithm:     extractAlgorithmFromToken(token),
 		FailureReason: getErrorCode(err),
+		FailureCause:  failureCause(err),
 		TokenPreview:  token,
 		Latency:       latency,
 	}
@@ -0,0 +1,91 @@
+package jwtauth
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// WithGRPCTokenKeys also accepts the token from these gRPC metadata keys,
+// for per-RPC credentials that send it under a custom key such as
+// "x-access-token" instead of authorization. Values may be the bare token or
+// "Bearer <token>". Keys are matched case-insensitively. A request carrying
+// different tokens under authorization and a custom key, or under two custom
+// keys, is rejected as AMBIGUOUS_TOKEN.
+func WithGRPCTokenKeys(keys ...string) ConfigOption {
+	return func(c *Config) error {
+		if len(keys) == 0 {
+			return fmt.Errorf("at least one metadata key must be specified")
+		}
+		for _, key := range keys {
+			key = strings.ToLower(key)
+			if key == "" || key == "authorization" || strings.HasPrefix(key, ":") ||
+				strings.HasSuffix(key, "-bin") || strings.ContainsAny(key, " \t") {
+				return fmt.Errorf("invalid token metadata key %q", key)
+			}
+			c.grpcTokenKeys = append(c.grpcTokenKeys, key)
+		}
+		return nil
+	}
+}
+
+// metadataValues returns the values of key in md, matching key
+// case-insensitively. Metadata received over HTTP/2 is lowercase, but
+// metadata built in-process (interceptor chains, in-memory transports, tests)
+// keeps whatever case the caller used with a map literal.
+func metadataValues(md metadata.MD, key string) []string {
+	var match string
+	for k := range md {
+		if strings.EqualFold(k, key) {
+			if match != "" {
+				// Several casings of the same key: merge them deterministically
+				return messageHeaderValues(md, key)
+			}
+			match = k
+		}
+	}
+	if match == "" {
+		return nil
+	}
+	return md[match]
+}
+
+// grpcTokenKeyCandidates extracts the token from authorization and the keys
+// configured with WithGRPCTokenKeys
+func grpcTokenKeyCandidates(md metadata.MD, authorization []string, cfg *Config, requestID string) ([]string, error) {
+	var values []string
+	var name string
+	for _, key := range cfg.grpcTokenKeys {
+		if v := metadataValues(md, key); len(v) > 0 {
+			values = append(values, v...)
+			if name == "" {
+				name = key
+			}
+		}
+	}
+	if len(values) == 0 {
+		if len(authorization) == 0 {
+			return nil, NewValidationError(ErrMissingToken, "authorization metadata not found", nil)
+		}
+		return bearerCandidates(authorization, cfg, requestID, metadataFormatMessage)
+	}
+
+	tokens, err := singleTokenCandidates(values, name, cfg, requestID)
+	if err != nil || len(authorization) == 0 {
+		return tokens, err
+	}
+	bearer, err := bearerCandidates(authorization, cfg, requestID, metadataFormatMessage)
+	if err != nil {
+		return nil, err
+	}
+	if len(bearer) != 1 || bearer[0] != tokens[0] {
+		logDuplicateHeaders(cfg, requestID, 2)
+		return nil, NewValidationError(
+			ErrAmbiguousToken,
+			fmt.Sprintf("request carries different tokens in authorization and %s", name),
+			nil,
+		)
+	}
+	return tokens, nil
+}
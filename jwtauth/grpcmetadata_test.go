@@ -0,0 +1,89 @@
+package jwtauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// mapCredentials is a PerRPCCredentials sending fixed metadata, as client
+// libraries with their own credential types do
+type mapCredentials map[string]string
+
+func (m mapCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return m, nil
+}
+
+func (m mapCredentials) RequireTransportSecurity() bool { return false }
+
+// TestGRPCPerRPCCredentialKeys tests tokens sent by per-RPC credentials
+// under differently cased and custom metadata keys
+func TestGRPCPerRPCCredentialKeys(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	valid := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+	other := mustSignHS256(secret, jwt.MapClaims{"sub": "user456", "exp": time.Now().Add(time.Hour).Unix()})
+
+	cfg := mustCreateConfig(WithHS256(secret), WithGRPCTokenKeys("X-Access-Token", "token"))
+	conn := startTestGRPCServer(t, grpc.UnaryInterceptor(UnaryServerInterceptor(cfg)))
+
+	tests := []struct {
+		name     string
+		creds    mapCredentials
+		wantCode codes.Code
+		wantMsg  string
+	}{
+		{"lowercase authorization", mapCredentials{"authorization": "Bearer " + valid}, codes.OK, ""},
+		{"canonical Authorization", mapCredentials{"Authorization": "Bearer " + valid}, codes.OK, ""},
+		{"custom key bare token", mapCredentials{"x-access-token": valid}, codes.OK, ""},
+		{"custom key mixed case", mapCredentials{"X-Access-Token": "Bearer " + valid}, codes.OK, ""},
+		{"second custom key", mapCredentials{"token": valid}, codes.OK, ""},
+		{"same token twice", mapCredentials{"authorization": "Bearer " + valid, "x-access-token": valid}, codes.OK, ""},
+		{"different tokens", mapCredentials{"authorization": "Bearer " + valid, "x-access-token": other}, codes.Unauthenticated, "AMBIGUOUS_TOKEN"},
+		{"two custom keys", mapCredentials{"token": valid, "x-access-token": other}, codes.Unauthenticated, "AMBIGUOUS_TOKEN"},
+		{"unconfigured key", mapCredentials{"x-api-token": valid}, codes.Unauthenticated, "MISSING_TOKEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.PerRPCCredentials(tt.creds))
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Expected %v, got %v", tt.wantCode, err)
+			}
+			if tt.wantMsg != "" && status.Convert(err).Message() != tt.wantMsg {
+				t.Errorf("Expected %s, got %v", tt.wantMsg, err)
+			}
+		})
+	}
+}
+
+// TestExtractTokensFromMetadataCasing tests metadata built in-process, which
+// grpc-go does not lowercase
+func TestExtractTokensFromMetadataCasing(t *testing.T) {
+	cfg := mustCreateConfig(WithHS256([]byte("test-secret-key-min-32-bytes-long!!")), WithDuplicateHeaderPolicy(DuplicateHeaderStrict))
+
+	tokens, err := extractTokensFromMetadata(metadata.MD{"Authorization": {"Bearer abc.def.ghi"}}, cfg, "")
+	if err != nil || len(tokens) != 1 || tokens[0] != "abc.def.ghi" {
+		t.Errorf("Expected token from Authorization key, got %v, %v", tokens, err)
+	}
+
+	// Two casings of the key are one key with two values
+	_, err = extractTokensFromMetadata(metadata.MD{
+		"Authorization": {"Bearer abc.def.ghi"},
+		"authorization": {"Bearer jkl.mno.pqr"},
+	}, cfg, "")
+	if getErrorCode(err) != string(ErrAmbiguousToken) {
+		t.Errorf("Expected AMBIGUOUS_TOKEN, got %v", err)
+	}
+
+	for _, key := range []string{"", "authorization", ":authority", "token-bin"} {
+		if _, err := NewConfig(WithHS256([]byte("test-secret-key-min-32-bytes-long!!")), WithGRPCTokenKeys(key)); err == nil {
+			t.Errorf("Expected metadata key %q to be rejected", key)
+		}
+	}
+}
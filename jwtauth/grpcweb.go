@@ -0,0 +1,160 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/base64"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// GRPCWebHandler authenticates gRPC-Web requests in front of a gRPC-Web
+// wrapper (e.g. improbable-eng/grpc-web's WrapServer) or a grpc.Server
+// served over net/http:
+//
+//	wrapped := grpcweb.WrapServer(grpcServer)
+//	http.ListenAndServe(addr, jwtauth.GRPCWebHandler(cfg, wrapped))
+//
+// gRPC-Web clients send the token in HTTP headers that the wrapper only
+// later turns into metadata. The handler builds the same metadata from the
+// headers and authenticates it exactly as the gRPC interceptors do (token
+// extraction, client IP, device binding, error codes); interceptors on the
+// wrapped server then reuse the result when they use the same Config
+// (interceptors with another Config authenticate again), so one server can
+// register UnaryServerInterceptor for native clients and be wrapped for
+// browsers. The method checked against WithMethodRequirements and the
+// policy is the trailing "/pkg.Service/Method" of the URL path, so the
+// handler may be mounted under a prefix.
+//
+// Failures are written as trailers-only gRPC-Web responses carrying
+// grpc-status and grpc-message (plus grpc-status-details-bin and Retry-After
+// for rate limiting), with CORS headers under WithCORS. Under WithCORS,
+// preflights are answered by the handler itself. Every request a gRPC-Web
+// wrapper would serve is authenticated: any application/grpc* Content-Type
+// and WebSocket upgrades offering the grpc-websockets subprotocol, whose
+// token must then come from the handshake headers. Only other requests
+// (e.g. static assets) are passed to next untouched.
+func GRPCWebHandler(cfg *Config, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPreflightRequest(r, cfg) {
+			w.WriteHeader(preflightResponse(w.Header(), r, cfg))
+			return
+		}
+		if !isGRPCWebRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := metadata.NewIncomingContext(r.Context(), headerMetadata(r.Header))
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: httpRemoteAddr(r.RemoteAddr)})
+
+		ctx, cancel, err := authenticateGRPC(ctx, cfg, grpcWebMethod(r.URL.Path))
+		if err != nil {
+			writeGRPCWebError(w, r, cfg, err)
+			return
+		}
+		defer cancel(nil)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, grpcWebAuthenticatedKey{}, cfg)))
+	})
+}
+
+// isGRPCWebRequest reports whether a gRPC or gRPC-Web wrapper would serve
+// r: gRPC content types in any case, and WebSocket transport requests
+func isGRPCWebRequest(r *http.Request) bool {
+	if strings.HasPrefix(strings.ToLower(r.Header.Get("Content-Type")), "application/grpc") {
+		return true
+	}
+	for _, value := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(protocol), "grpc-websockets") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// grpcWebAuthenticatedKey marks contexts authenticated by GRPCWebHandler
+// with the *Config used. The key is unexported, so callers cannot forge the
+// mark.
+type grpcWebAuthenticatedKey struct{}
+
+// grpcWebAuthenticated reports whether GRPCWebHandler already authenticated
+// the RPC carried by ctx under cfg
+func grpcWebAuthenticated(ctx context.Context, cfg *Config) bool {
+	authenticatedBy, _ := ctx.Value(grpcWebAuthenticatedKey{}).(*Config)
+	return authenticatedBy != nil && authenticatedBy == cfg
+}
+
+// grpcWebMethod returns the full gRPC method name ("/pkg.Service/Method")
+// from the last two segments of a request path, ignoring any mount prefix
+func grpcWebMethod(path string) string {
+	method := strings.LastIndex(path, "/")
+	if method <= 0 {
+		return path
+	}
+	service := strings.LastIndex(path[:method], "/")
+	if service < 0 {
+		return "/" + path
+	}
+	return path[service:]
+}
+
+// headerMetadata converts HTTP headers to incoming metadata the way gRPC
+// server transports do: keys are lowercased
+func headerMetadata(h http.Header) metadata.MD {
+	md := make(metadata.MD, len(h))
+	for key, values := range h {
+		md.Append(strings.ToLower(key), values...)
+	}
+	return md
+}
+
+// httpRemoteAddr adapts an HTTP remote address to net.Addr
+type httpRemoteAddr string
+
+// Network implements net.Addr
+func (a httpRemoteAddr) Network() string { return "tcp" }
+
+// String implements net.Addr
+func (a httpRemoteAddr) String() string { return string(a) }
+
+// writeGRPCWebError writes a trailers-only response for a gRPC status error
+func writeGRPCWebError(w http.ResponseWriter, r *http.Request, cfg *Config, err error) {
+	h := w.Header()
+	if setCORSResponseHeaders(h, r, cfg) {
+		exposed := "grpc-status, grpc-message, grpc-status-details-bin"
+		current := h.Get("Access-Control-Expose-Headers")
+		if !strings.Contains(strings.ToLower(current), "retry-after") {
+			exposed += ", Retry-After"
+		}
+		if current != "" {
+			exposed = current + ", " + exposed
+		}
+		h.Set("Access-Control-Expose-Headers", exposed)
+	}
+
+	st := status.Convert(err)
+	h.Set("Content-Type", r.Header.Get("Content-Type"))
+	h.Set("grpc-status", strconv.Itoa(int(st.Code())))
+	h.Set("grpc-message", st.Message())
+	if details := st.Details(); len(details) > 0 {
+		if encoded, err := proto.Marshal(st.Proto()); err == nil {
+			h.Set("grpc-status-details-bin", base64.RawStdEncoding.EncodeToString(encoded))
+		}
+		for _, detail := range details {
+			if info, ok := detail.(*errdetails.RetryInfo); ok {
+				h.Set("Retry-After", strconv.Itoa(int(math.Ceil(info.GetRetryDelay().AsDuration().Seconds()))))
+			}
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,174 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestGRPCWebHandler tests authentication in front of a grpc.Server served
+// over net/http, as gRPC-Web wrappers do
+func TestGRPCWebHandler(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithCORS(CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}))
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	// The interceptor must see claims without validating a second time
+	var validations atomic.Int32
+	counting := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !grpcWebAuthenticated(ctx, cfg) {
+			validations.Add(1)
+		}
+		return handler(ctx, req)
+	}
+	var subject atomic.Value
+	recordSubject := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if claims, ok := GetClaims(ctx); ok {
+			subject.Store(claims.Subject)
+		}
+		return handler(ctx, req)
+	}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(counting, UnaryServerInterceptor(cfg), recordSubject))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	hs := httptest.NewUnstartedServer(GRPCWebHandler(cfg, srv))
+	hs.EnableHTTP2 = true
+	hs.StartTLS()
+	defer hs.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(hs.Certificate())
+	conn, err := grpc.NewClient(hs.Listener.Addr().String(), grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(pool, "example.com")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Expected authenticated call to succeed, got %v", err)
+	}
+	if subject.Load() != "user-1" || validations.Load() != 0 {
+		t.Errorf("Expected handler claims without revalidation, got subject %v, %d validations", subject.Load(), validations.Load())
+	}
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Convert(err).Message() != "MISSING_TOKEN" {
+		t.Errorf("Expected MISSING_TOKEN, got %v", err)
+	}
+
+	t.Run("grpc-web error response", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/grpc.health.v1.Health/Check", nil)
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Authorization", "Bearer "+mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(-time.Hour).Unix()}))
+		w := httptest.NewRecorder()
+		GRPCWebHandler(cfg, http.NotFoundHandler()).ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || w.Header().Get("grpc-status") != "16" || w.Header().Get("grpc-message") != "EXPIRED" {
+			t.Errorf("Expected trailers-only UNAUTHENTICATED/EXPIRED, got %d %v", w.Code, w.Header())
+		}
+		if w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+			t.Error("Expected CORS headers on grpc-web error")
+		}
+		if got := w.Header().Get("Access-Control-Expose-Headers"); got != "Retry-After, grpc-status, grpc-message, grpc-status-details-bin" {
+			t.Errorf("Expected grpc-status to be exposed, got %q", got)
+		}
+	})
+
+	t.Run("method requirements under a mount prefix", func(t *testing.T) {
+		admin := mustCreateConfig(WithHS256(secret), WithMethodRequirements(map[string]Requirement{
+			"/grpc.health.v1.Health/*": {Roles: []string{"admin"}},
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/rpc/grpc.health.v1.Health/Check", nil)
+		req.Header.Set("Content-Type", "application/grpc-web+proto")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		GRPCWebHandler(admin, http.NotFoundHandler()).ServeHTTP(w, req)
+		if w.Header().Get("grpc-status") != "7" {
+			t.Errorf("Expected PERMISSION_DENIED for the prefixed method, got %v", w.Header())
+		}
+	})
+
+	t.Run("interceptors with another config authenticate again", func(t *testing.T) {
+		marked := context.WithValue(context.Background(), grpcWebAuthenticatedKey{}, cfg)
+		other := mustCreateConfig(WithHS256(secret))
+		if _, _, err := authenticateGRPC(marked, other, "/grpc.health.v1.Health/Check"); status.Convert(err).Message() != "metadata not found" {
+			t.Errorf("Expected the mark of another config to be ignored, got %v", err)
+		}
+	})
+
+	for path, want := range map[string]string{
+		"/pkg.Service/Method":        "/pkg.Service/Method",
+		"/api/v1/pkg.Service/Method": "/pkg.Service/Method",
+		"pkg.Service/Method":         "/pkg.Service/Method",
+	} {
+		if got := grpcWebMethod(path); got != want {
+			t.Errorf("grpcWebMethod(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	t.Run("preflight answered by the handler", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/grpc.health.v1.Health/Check", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+		GRPCWebHandler(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("Expected the wrapped handler not to run for a preflight")
+		})).ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+			t.Errorf("Expected 204 with CORS headers, got %d %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("non-grpc requests pass through", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		GRPCWebHandler(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/index.html", nil))
+		if w.Code != http.StatusTeapot {
+			t.Errorf("Expected static request to reach next handler, got %d", w.Code)
+		}
+	})
+
+	t.Run("websocket and mixed-case grpc requests are authenticated", func(t *testing.T) {
+		websocket := httptest.NewRequest(http.MethodGet, "/grpc.health.v1.Health/Watch", nil)
+		websocket.Header.Set("Connection", "Upgrade")
+		websocket.Header.Set("Upgrade", "websocket")
+		websocket.Header.Set("Sec-WebSocket-Protocol", "chat, grpc-websockets")
+		mixedCase := httptest.NewRequest(http.MethodPost, "/grpc.health.v1.Health/Check", nil)
+		mixedCase.Header.Set("Content-Type", "Application/GRPC-Web+proto")
+
+		for name, req := range map[string]*http.Request{"websocket": websocket, "mixed case": mixedCase} {
+			w := httptest.NewRecorder()
+			GRPCWebHandler(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("Expected the unauthenticated %s request not to reach next", name)
+			})).ServeHTTP(w, req)
+			if w.Header().Get("grpc-message") != "MISSING_TOKEN" {
+				t.Errorf("Expected MISSING_TOKEN for the %s request, got %v", name, w.Header())
+			}
+		}
+
+		websocket.Header.Set("Authorization", "Bearer "+token)
+		reached := false
+		GRPCWebHandler(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reached = grpcWebAuthenticated(r.Context(), cfg)
+		})).ServeHTTP(httptest.NewRecorder(), websocket)
+		if !reached {
+			t.Error("Expected an authenticated websocket request to reach next")
+		}
+	})
+}
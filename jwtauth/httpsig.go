@@ -0,0 +1,358 @@
+package jwtauth
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// HTTPMessageSignatures configures VerifyHTTPMessageSignature
+type HTTPMessageSignatures struct {
+	// Label selects the signature to verify (e.g. "sig1"). By default the
+	// first signature covering RequiredComponents is verified.
+	Label string
+
+	// RequiredComponents must be covered by the signature (default
+	// "@method", "@target-uri"). Requests with a body must also cover
+	// "content-digest", which is checked against the body.
+	RequiredComponents []string
+
+	MaxAge       time.Duration // Reject signatures created longer ago (default 5m)
+	MaxBodyBytes int64         // Largest body read for content-digest (default 1 MiB)
+}
+
+// defaultHTTPMessageSignatures is used when WithHTTPMessageSignatures is not set
+var defaultHTTPMessageSignatures = HTTPMessageSignatures{
+	RequiredComponents: []string{"@method", "@target-uri"},
+	MaxAge:             5 * time.Minute,
+	MaxBodyBytes:       1 << 20,
+}
+
+// WithHTTPMessageSignatures overrides the label, covered components, age
+// limit and body limit used by VerifyHTTPMessageSignature
+func WithHTTPMessageSignatures(s HTTPMessageSignatures) ConfigOption {
+	return func(c *Config) error {
+		if s.RequiredComponents == nil {
+			s.RequiredComponents = defaultHTTPMessageSignatures.RequiredComponents
+		}
+		if s.MaxAge == 0 {
+			s.MaxAge = defaultHTTPMessageSignatures.MaxAge
+		}
+		if s.MaxBodyBytes == 0 {
+			s.MaxBodyBytes = defaultHTTPMessageSignatures.MaxBodyBytes
+		}
+		if s.MaxAge < 0 || s.MaxBodyBytes < 0 {
+			return fmt.Errorf("message signature age and body limits must be positive")
+		}
+		s.RequiredComponents = slices.Clone(s.RequiredComponents)
+		c.httpSignatures = &s
+		return nil
+	}
+}
+
+// MessageSignature describes a verified HTTP message signature
+type MessageSignature struct {
+	Label      string
+	KeyID      string
+	Algorithm  string   // RFC 9421 algorithm name, e.g. "ecdsa-p256-sha256"
+	Components []string // Covered component identifiers, in signed order
+	Created    time.Time
+	Tag        string
+}
+
+// httpSignatureAlgorithms maps RFC 9421 algorithm names to the JWS
+// algorithms whose keys and verification they share
+var httpSignatureAlgorithms = map[string]string{
+	"rsa-pss-sha512":    "PS512",
+	"rsa-v1_5-sha256":   "RS256",
+	"hmac-sha256":       "HS256",
+	"ecdsa-p256-sha256": "ES256",
+	"ecdsa-p384-sha384": "ES384",
+	"ed25519":           "EdDSA",
+}
+
+// VerifyHTTPMessageSignature verifies an RFC 9421 HTTP message signature
+// (Signature-Input and Signature headers) on a request, with the keys the
+// Config trusts for tokens: the signature's alg selects the configured JWS
+// algorithm (ecdsa-p256-sha256 → ES256, rsa-v1_5-sha256 → RS256,
+// rsa-pss-sha512 → PS512, hmac-sha256 → HS256, ed25519 → EdDSA) and keyid is
+// passed to its key provider. The alg parameter is required.
+//
+// When the request has a body, the signature must cover content-digest and
+// the Content-Digest header (RFC 9530, sha-256 or sha-512) must match the
+// body, which is restored on r.Body. Failures are *ValidationError values.
+func VerifyHTTPMessageSignature(r *http.Request, cfg *Config) (*MessageSignature, error) {
+	if cfg == nil || cfg.parser == nil {
+		return nil, NewValidationError(ErrConfigError, "configuration is required (use NewConfig)", nil)
+	}
+	opts := defaultHTTPMessageSignatures
+	if cfg.httpSignatures != nil {
+		opts = *cfg.httpSignatures
+	}
+
+	input, signatures := r.Header.Values("Signature-Input"), r.Header.Values("Signature")
+	if len(input) == 0 || len(signatures) == 0 {
+		return nil, NewValidationError(ErrMissingToken, "Signature-Input and Signature headers are required", nil)
+	}
+	inputs, err := parseSFDictionary(strings.Join(input, ", "))
+	if err != nil {
+		return nil, NewValidationError(ErrMalformed, "invalid Signature-Input header", err)
+	}
+	sigs, err := parseSFDictionary(strings.Join(signatures, ", "))
+	if err != nil {
+		return nil, NewValidationError(ErrMalformed, "invalid Signature header", err)
+	}
+
+	required := opts.RequiredComponents
+	hasBody := r.ContentLength != 0
+	if hasBody && !slices.Contains(required, "content-digest") {
+		required = append(slices.Clone(required), "content-digest")
+	}
+
+	member, components, err := selectMessageSignature(inputs, opts.Label, required)
+	if err != nil {
+		return nil, err
+	}
+	var signature []byte
+	for _, s := range sigs {
+		if s.name == member.name {
+			signature, _ = s.item.value.([]byte)
+		}
+	}
+	if len(signature) == 0 {
+		return nil, NewValidationError(ErrMalformed, fmt.Sprintf("no signature for label %s", member.name), nil)
+	}
+
+	result := &MessageSignature{Label: member.name, Components: components}
+	if err := checkSignatureParams(member, result, opts.MaxAge, cfg.clockSkewLeeway); err != nil {
+		return nil, err
+	}
+
+	jwsAlg, ok := httpSignatureAlgorithms[result.Algorithm]
+	if !ok {
+		return nil, NewValidationError(ErrUnsupportedAlgorithm, fmt.Sprintf("message signature algorithm %q not supported", result.Algorithm), nil)
+	}
+	validator, ok := cfg.getValidator(jwsAlg)
+	if !ok {
+		return nil, NewValidationError(ErrUnsupportedAlgorithm,
+			fmt.Sprintf("message signature algorithm %s needs %s (available: %s)", result.Algorithm, jwsAlg, strings.Join(cfg.AvailableAlgorithms(), ", ")), nil)
+	}
+
+	if hasBody {
+		body, err := readRequestBody(r, opts.MaxBodyBytes)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkContentDigest(r.Header.Values("Content-Digest"), body); err != nil {
+			return nil, err
+		}
+	}
+
+	base, err := signatureBase(r, components, member.raw)
+	if err != nil {
+		return nil, err
+	}
+	key, err := validator.resolveKey(r.Context(), jwsAlg, result.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := jwt.GetSigningMethod(jwsAlg).Verify(base, signature, key); err != nil {
+		return nil, NewValidationError(ErrInvalidSignature, "invalid message signature", err)
+	}
+	return result, nil
+}
+
+// selectMessageSignature picks the signature to verify and returns its
+// covered components
+func selectMessageSignature(inputs []sfMember, label string, required []string) (sfMember, []string, error) {
+	for _, member := range inputs {
+		if label != "" && member.name != label {
+			continue
+		}
+		if !member.isList {
+			return sfMember{}, nil, NewValidationError(ErrMalformed, fmt.Sprintf("signature input %s must be an inner list", member.name), nil)
+		}
+
+		components := make([]string, 0, len(member.list))
+		for _, item := range member.list {
+			name, ok := item.value.(string)
+			if !ok || name == "" {
+				return sfMember{}, nil, NewValidationError(ErrMalformed, "covered components must be strings", nil)
+			}
+			if len(item.params) > 0 {
+				return sfMember{}, nil, NewValidationError(ErrMalformed, fmt.Sprintf("component parameters are not supported (%s)", name), nil)
+			}
+			if slices.Contains(components, name) {
+				return sfMember{}, nil, NewValidationError(ErrMalformed, fmt.Sprintf("component %s is covered twice", name), nil)
+			}
+			components = append(components, name)
+		}
+
+		covered := true
+		for _, name := range required {
+			if !slices.Contains(components, name) {
+				covered = false
+				if label != "" {
+					return sfMember{}, nil, NewValidationError(ErrInvalidSignature, fmt.Sprintf("signature %s does not cover %s", label, name), nil)
+				}
+			}
+		}
+		if covered {
+			return member, components, nil
+		}
+	}
+
+	if label != "" {
+		return sfMember{}, nil, NewValidationError(ErrMissingToken, fmt.Sprintf("signature %s not found", label), nil)
+	}
+	return sfMember{}, nil, NewValidationError(ErrInvalidSignature, fmt.Sprintf("no signature covers %s", strings.Join(required, ", ")), nil)
+}
+
+// checkSignatureParams reads keyid, alg, created, expires and tag and checks
+// the signature's age
+func checkSignatureParams(member sfMember, result *MessageSignature, maxAge, skew time.Duration) error {
+	for _, p := range member.params {
+		switch p.name {
+		case "keyid", "alg", "tag", "nonce":
+			s, ok := p.value.(string)
+			if !ok {
+				return NewValidationError(ErrMalformed, fmt.Sprintf("signature parameter %s must be a string", p.name), nil)
+			}
+			switch p.name {
+			case "keyid":
+				result.KeyID = s
+			case "alg":
+				result.Algorithm = s
+			case "tag":
+				result.Tag = s
+			}
+		case "created", "expires":
+			if _, ok := p.value.(int64); !ok {
+				return NewValidationError(ErrMalformed, fmt.Sprintf("signature parameter %s must be an integer", p.name), nil)
+			}
+		}
+	}
+	if result.Algorithm == "" {
+		return NewValidationError(ErrMalformedAlgorithmHeader, "message signature alg parameter is required", nil)
+	}
+
+	now := time.Now()
+	created, ok := member.param("created")
+	if !ok {
+		return NewValidationError(ErrMalformed, "message signature created parameter is required", nil)
+	}
+	result.Created = time.Unix(created.(int64), 0)
+	if result.Created.After(now.Add(skew)) {
+		return NewValidationError(ErrMalformed, "message signature created in the future", nil)
+	}
+	if now.Sub(result.Created) > maxAge+skew {
+		return NewValidationError(ErrExpired, "message signature is too old", nil)
+	}
+	if expires, ok := member.param("expires"); ok && now.After(time.Unix(expires.(int64), 0).Add(skew)) {
+		return NewValidationError(ErrExpired, "message signature has expired", nil)
+	}
+	return nil
+}
+
+// signatureBase builds the RFC 9421 Section 2.5 signature base
+func signatureBase(r *http.Request, components []string, params string) (string, error) {
+	var b strings.Builder
+	for _, name := range components {
+		value, err := componentValue(r, name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%q: %s\n", name, value)
+	}
+	fmt.Fprintf(&b, "%q: %s", "@signature-params", params)
+	return b.String(), nil
+}
+
+// componentValue returns the value of a derived component or header field
+func componentValue(r *http.Request, name string) (string, error) {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	authority := strings.ToLower(r.Host)
+
+	switch name {
+	case "@method":
+		return r.Method, nil
+	case "@authority":
+		return authority, nil
+	case "@scheme":
+		return scheme, nil
+	case "@target-uri":
+		return scheme + "://" + authority + r.URL.RequestURI(), nil
+	case "@request-target":
+		return r.URL.RequestURI(), nil
+	case "@path":
+		if path := r.URL.EscapedPath(); path != "" {
+			return path, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + r.URL.RawQuery, nil
+	case "host":
+		return authority, nil
+	}
+	if strings.HasPrefix(name, "@") {
+		return "", NewValidationError(ErrMalformed, fmt.Sprintf("component %s is not supported", name), nil)
+	}
+	if name != strings.ToLower(name) {
+		return "", NewValidationError(ErrMalformed, fmt.Sprintf("component %s must be lowercase", name), nil)
+	}
+
+	values := r.Header.Values(name)
+	if len(values) == 0 {
+		return "", NewValidationError(ErrInvalidSignature, fmt.Sprintf("covered header %s is missing", name), nil)
+	}
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(values, ", "), nil
+}
+
+// checkContentDigest verifies an RFC 9530 Content-Digest header against the
+// body. Every supported digest present must match; unknown ones are ignored.
+func checkContentDigest(header []string, body []byte) error {
+	if len(header) == 0 {
+		return NewValidationError(ErrInvalidSignature, "Content-Digest header is required for requests with a body", nil)
+	}
+	digests, err := parseSFDictionary(strings.Join(header, ", "))
+	if err != nil {
+		return NewValidationError(ErrMalformed, "invalid Content-Digest header", err)
+	}
+
+	checked := false
+	for _, d := range digests {
+		var sum []byte
+		switch d.name {
+		case "sha-256":
+			s := sha256.Sum256(body)
+			sum = s[:]
+		case "sha-512":
+			s := sha512.Sum512(body)
+			sum = s[:]
+		default:
+			continue
+		}
+		got, _ := d.item.value.([]byte)
+		if subtle.ConstantTimeCompare(got, sum) != 1 {
+			return NewValidationError(ErrInvalidSignature, fmt.Sprintf("Content-Digest %s does not match the body", d.name), nil)
+		}
+		checked = true
+	}
+	if !checked {
+		return NewValidationError(ErrInvalidSignature, "Content-Digest has no sha-256 or sha-512 digest", nil)
+	}
+	return nil
+}
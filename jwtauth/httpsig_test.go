@@ -0,0 +1,180 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHTTPMessageSignatureRFC9421Vector checks the signature base and
+// Content-Digest against RFC 9421 Appendix B.2.6 (ed25519)
+func TestHTTPMessageSignatureRFC9421Vector(t *testing.T) {
+	block, _ := pem.Decode([]byte("-----BEGIN PUBLIC KEY-----\nMCowBQYDK2VwAyEAJrQLj5P/89iXES9+vFgrIy29clF9CC/oPPsw3c5D0bs=\n-----END PUBLIC KEY-----\n"))
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"hello": "world"}`
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/foo?param=Value&Pet=dog", strings.NewReader(body))
+	r.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Length", "18")
+	r.Header.Set("Content-Digest", "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:")
+
+	inputs, err := parseSFDictionary(`sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, components, err := selectMessageSignature(inputs, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := signatureBase(r, components, inputs[0].raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _ := base64.StdEncoding.DecodeString("wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==")
+	if !ed25519.Verify(pub.(ed25519.PublicKey), []byte(base), sig) {
+		t.Errorf("Signature base does not match the RFC vector:\n%s", base)
+	}
+	if err := checkContentDigest(r.Header.Values("Content-Digest"), []byte(body)); err != nil {
+		t.Errorf("Expected RFC Content-Digest to match, got %v", err)
+	}
+}
+
+// TestVerifyHTTPMessageSignature tests request verification with key providers
+func TestVerifyHTTPMessageSignature(t *testing.T) {
+	ctx := context.Background()
+	key := mustGenerateECKey()
+	signer, err := NewCryptoSigner("ES256", "partner-1", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := mustCreateConfig(WithKeyProvider("ES256", KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		if kid != "partner-1" {
+			return nil, ErrKeyNotFound
+		}
+		return &key.PublicKey, nil
+	})))
+
+	// sign builds the signature base independently of the verifier
+	sign := func(r *http.Request, components []string, values []string, params string) {
+		list := make([]string, len(components))
+		var base strings.Builder
+		for i, c := range components {
+			list[i] = `"` + c + `"`
+			fmt.Fprintf(&base, "\"%s\": %s\n", c, values[i])
+		}
+		input := "(" + strings.Join(list, " ") + ")" + params
+		base.WriteString(`"@signature-params": ` + input)
+		sig, err := signer.Sign(ctx, []byte(base.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Header.Set("Signature-Input", "sig1="+input)
+		r.Header.Set("Signature", "sig1=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	}
+
+	body := `{"amount": 100}`
+	sum := sha256.Sum256([]byte(body))
+	digest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+	params := fmt.Sprintf(`;created=%d;keyid="partner-1";alg="ecdsa-p256-sha256"`, time.Now().Unix())
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "http://api.example.com/payments?id=7", strings.NewReader(body))
+		r.Header.Set("Content-Digest", digest)
+		sign(r, []string{"@method", "@target-uri", "content-digest"},
+			[]string{"POST", "http://api.example.com/payments?id=7", digest}, params)
+		return r
+	}
+
+	r := newRequest()
+	result, err := VerifyHTTPMessageSignature(r, cfg)
+	if err != nil {
+		t.Fatalf("Expected signature to verify, got %v", err)
+	}
+	if result.Label != "sig1" || result.KeyID != "partner-1" || result.Algorithm != "ecdsa-p256-sha256" || len(result.Components) != 3 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if restored, _ := io.ReadAll(r.Body); string(restored) != body {
+		t.Errorf("Expected body to be restored, got %q", restored)
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(r *http.Request)
+		wantCode ErrorCode
+	}{
+		{"tampered body", func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader(`{"amount": 999}`)) }, ErrInvalidSignature},
+		{"tampered method", func(r *http.Request) { r.Method = http.MethodPut }, ErrInvalidSignature},
+		{"missing headers", func(r *http.Request) { r.Header.Del("Signature") }, ErrMissingToken},
+		{"body not covered", func(r *http.Request) {
+			sign(r, []string{"@method", "@target-uri"}, []string{"POST", "http://api.example.com/payments?id=7"}, params)
+		}, ErrInvalidSignature},
+		{"too old", func(r *http.Request) {
+			old := fmt.Sprintf(`;created=%d;keyid="partner-1";alg="ecdsa-p256-sha256"`, time.Now().Add(-time.Hour).Unix())
+			sign(r, []string{"@method", "@target-uri", "content-digest"}, []string{"POST", "http://api.example.com/payments?id=7", digest}, old)
+		}, ErrExpired},
+		{"unsupported algorithm", func(r *http.Request) {
+			sign(r, []string{"@method", "@target-uri", "content-digest"}, []string{"POST", "http://api.example.com/payments?id=7", digest},
+				fmt.Sprintf(`;created=%d;keyid="partner-1";alg="rsa-v1_5-sha256"`, time.Now().Unix()))
+		}, ErrUnsupportedAlgorithm},
+		{"unknown key", func(r *http.Request) {
+			sign(r, []string{"@method", "@target-uri", "content-digest"}, []string{"POST", "http://api.example.com/payments?id=7", digest},
+				fmt.Sprintf(`;created=%d;keyid="partner-2";alg="ecdsa-p256-sha256"`, time.Now().Unix()))
+		}, ErrKeyUnavailable},
+		{"malformed input", func(r *http.Request) { r.Header.Set("Signature-Input", `sig1=("@method"`) }, ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRequest()
+			tt.modify(r)
+			_, err := VerifyHTTPMessageSignature(r, cfg)
+			if code := getErrorCode(err); code != string(tt.wantCode) {
+				t.Errorf("Expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+
+	t.Run("label and required components", func(t *testing.T) {
+		strict := mustCreateConfig(
+			WithKeyProvider("ES256", KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+				return &key.PublicKey, nil
+			})),
+			WithHTTPMessageSignatures(HTTPMessageSignatures{Label: "sig1", RequiredComponents: []string{"@method", "@target-uri", "x-request-id"}}),
+		)
+		if _, err := VerifyHTTPMessageSignature(newRequest(), strict); getErrorCode(err) != string(ErrInvalidSignature) {
+			t.Errorf("Expected uncovered x-request-id to be rejected, got %v", err)
+		}
+	})
+}
+
+// TestParseSFDictionary tests the structured field subset used for signatures
+func TestParseSFDictionary(t *testing.T) {
+	members, err := parseSFDictionary(`a=("x" "y";p=1);created=5, b=:aGk=:, c, d=tok/en;q=?0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 4 || !members[0].isList || len(members[0].list) != 2 || members[0].raw != `("x" "y";p=1);created=5` {
+		t.Fatalf("Unexpected members %+v", members)
+	}
+	if string(members[1].item.value.([]byte)) != "hi" || members[2].item.value != true || members[3].item.value != sfToken("tok/en") {
+		t.Errorf("Unexpected items %+v", members[1:])
+	}
+
+	for _, bad := range []string{`a=`, `a=("x"`, `A=1`, `a=1,`, `a="unterminated`, `a=1.5`, `a=:!!:`} {
+		if _, err := parseSFDictionary(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package jwtauth
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// issuedAtBounds are the histogram bucket upper bounds for (now - iat).
+// Negative ages mean the token was issued in our future: the issuer's
+// clock is ahead of ours.
+var issuedAtBounds = [...]time.Duration{
+	-5 * time.Minute, -time.Minute, -30 * time.Second, -10 * time.Second, -5 * time.Second, -2 * time.Second, -time.Second,
+	0,
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour,
+}
+
+// maxIssuedAtIssuers bounds the issuers tracked separately; tokens from
+// further issuers are counted under IssuedAtOtherIssuers
+const maxIssuedAtIssuers = 32
+
+// IssuedAtOtherIssuers is the IssuedAtStats.Issuer aggregating issuers
+// beyond the tracked limit
+const IssuedAtOtherIssuers = "*"
+
+// IssuedAtStats is the distribution of (now - iat) over successful
+// validations of one issuer's tokens
+type IssuedAtStats struct {
+	Issuer  string
+	Count   uint64
+	Buckets []IssuedAtBucket // Cumulative, ordered by UpperBound; the last bound is math.MaxInt64
+
+	// SuggestedClockSkew is the smallest clock skew covering 99.9% of
+	// tokens issued in our future, i.e. by an issuer whose clock is ahead
+	SuggestedClockSkew time.Duration
+}
+
+// IssuedAtBucket counts tokens with (now - iat) <= UpperBound
+type IssuedAtBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// WithIssuedAtTelemetry records the distribution of (now - iat) for every
+// successfully validated token, per issuer, so operators can see the clock
+// drift of real clients and pick WithClockSkew from evidence. Read it with
+// Config.IssuedAtStats or the admin API's GET /state. Recording costs two
+// atomic increments per validation; cached tokens are not recorded again.
+func WithIssuedAtTelemetry() ConfigOption {
+	return func(c *Config) error {
+		c.issuedAtTelemetry = &issuedAtTelemetry{}
+		return nil
+	}
+}
+
+// issuedAtTelemetry holds a histogram per issuer
+type issuedAtTelemetry struct {
+	mu       sync.Mutex
+	issuers  sync.Map // issuer -> *issuedAtHistogram
+	tracked  int
+	overflow issuedAtHistogram
+}
+
+// issuedAtHistogram counts samples per bucket; the last bucket is overflow
+type issuedAtHistogram struct {
+	buckets [len(issuedAtBounds) + 1]atomic.Uint64
+}
+
+// observe records a validated token's iat
+func (t *issuedAtTelemetry) observe(issuer string, issuedAt time.Time) {
+	if t == nil || issuedAt.IsZero() {
+		return
+	}
+	age := time.Since(issuedAt)
+	i := sort.Search(len(issuedAtBounds), func(i int) bool { return age <= issuedAtBounds[i] })
+	t.histogram(issuer).buckets[i].Add(1)
+}
+
+// histogram returns the issuer's histogram, creating it while below the
+// tracked issuer limit
+func (t *issuedAtTelemetry) histogram(issuer string) *issuedAtHistogram {
+	if h, ok := t.issuers.Load(issuer); ok {
+		return h.(*issuedAtHistogram)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if h, ok := t.issuers.Load(issuer); ok {
+		return h.(*issuedAtHistogram)
+	}
+	if t.tracked >= maxIssuedAtIssuers {
+		return &t.overflow
+	}
+	h := &issuedAtHistogram{}
+	t.issuers.Store(issuer, h)
+	t.tracked++
+	return h
+}
+
+// IssuedAtStats returns the (now - iat) distribution per issuer, sorted by
+// issuer, or nil without WithIssuedAtTelemetry
+func (c *Config) IssuedAtStats() []IssuedAtStats {
+	t := c.issuedAtTelemetry
+	if t == nil {
+		return nil
+	}
+	var stats []IssuedAtStats
+	t.issuers.Range(func(issuer, h interface{}) bool {
+		stats = append(stats, h.(*issuedAtHistogram).stats(issuer.(string)))
+		return true
+	})
+	if overflow := t.overflow.stats(IssuedAtOtherIssuers); overflow.Count > 0 {
+		stats = append(stats, overflow)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Issuer < stats[j].Issuer })
+	return stats
+}
+
+// stats snapshots the histogram
+func (h *issuedAtHistogram) stats(issuer string) IssuedAtStats {
+	s := IssuedAtStats{Issuer: issuer, Buckets: make([]IssuedAtBucket, len(h.buckets))}
+	for i := range h.buckets {
+		s.Count += h.buckets[i].Load()
+		bound := time.Duration(math.MaxInt64)
+		if i < len(issuedAtBounds) {
+			bound = issuedAtBounds[i]
+		}
+		s.Buckets[i] = IssuedAtBucket{UpperBound: bound, Count: s.Count}
+	}
+	s.SuggestedClockSkew = s.suggestClockSkew()
+	return s
+}
+
+// suggestClockSkew returns the smallest non-negative bucket bound s such
+// that at most 0.1% of tokens were issued s or more in our future
+func (s IssuedAtStats) suggestClockSkew() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	allowed := s.Count / 1000
+	// Walk the non-positive bounds from 0 towards -5m; Buckets[i].Count
+	// tokens were issued at least -UpperBound in our future
+	for i := len(s.Buckets) - 1; i >= 0; i-- {
+		bound := s.Buckets[i].UpperBound
+		if bound <= 0 && s.Buckets[i].Count <= allowed {
+			return -bound
+		}
+	}
+	return -issuedAtBounds[0]
+}
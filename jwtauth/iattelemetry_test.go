@@ -0,0 +1,83 @@
+package jwtauth
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestIssuedAtTelemetry tests the (now - iat) histogram and skew suggestion
+func TestIssuedAtTelemetry(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithClockSkew(time.Minute), WithIssuedAtTelemetry())
+	validate := func(iss string, age time.Duration) {
+		t.Helper()
+		now := time.Now()
+		token := mustSignHS256(secret, jwt.MapClaims{
+			"iss": iss, "sub": "user-1", "iat": now.Add(-age).Unix(), "exp": now.Add(time.Hour).Unix(),
+		})
+		if _, err := parseAndValidateJWT(token, cfg); err != nil {
+			t.Fatalf("expected token to validate, got %v", err)
+		}
+	}
+
+	validate("https://a.example.com", 3*time.Second)
+	validate("https://a.example.com", 20*time.Second)
+	validate("https://b.example.com", -8*time.Second) // Issuer clock ahead of ours
+
+	stats := cfg.IssuedAtStats()
+	if len(stats) != 2 || stats[0].Issuer != "https://a.example.com" || stats[1].Issuer != "https://b.example.com" {
+		t.Fatalf("expected stats for two issuers, got %+v", stats)
+	}
+	a := stats[0]
+	if a.Count != 2 {
+		t.Errorf("expected 2 samples, got %d", a.Count)
+	}
+	last := a.Buckets[len(a.Buckets)-1]
+	if last.UpperBound != time.Duration(math.MaxInt64) || last.Count != 2 {
+		t.Errorf("expected the last bucket to hold every sample, got %+v", last)
+	}
+	for _, bucket := range a.Buckets {
+		want := uint64(0)
+		if bucket.UpperBound >= 5*time.Second {
+			want = 1
+		}
+		if bucket.UpperBound >= 30*time.Second {
+			want = 2
+		}
+		if bucket.Count != want {
+			t.Errorf("bucket <= %v: expected %d, got %d", bucket.UpperBound, want, bucket.Count)
+		}
+	}
+	if a.SuggestedClockSkew != 0 {
+		t.Errorf("expected no skew needed for tokens issued in the past, got %v", a.SuggestedClockSkew)
+	}
+	if skew := stats[1].SuggestedClockSkew; skew != 10*time.Second {
+		t.Errorf("expected 10s skew for a token issued 8s ahead, got %v", skew)
+	}
+
+	if mustCreateConfig(WithHS256(secret)).IssuedAtStats() != nil {
+		t.Error("expected no stats without WithIssuedAtTelemetry")
+	}
+}
+
+// TestIssuedAtTelemetryIssuerLimit tests that issuers beyond the limit are
+// aggregated
+func TestIssuedAtTelemetryIssuerLimit(t *testing.T) {
+	telemetry := &issuedAtTelemetry{}
+	cfg := &Config{issuedAtTelemetry: telemetry}
+	for i := 0; i < maxIssuedAtIssuers+5; i++ {
+		telemetry.observe(fmt.Sprintf("issuer-%02d", i), time.Now())
+	}
+
+	stats := cfg.IssuedAtStats()
+	if len(stats) != maxIssuedAtIssuers+1 {
+		t.Fatalf("expected %d entries, got %d", maxIssuedAtIssuers+1, len(stats))
+	}
+	if other := stats[0]; other.Issuer != IssuedAtOtherIssuers || other.Count != 5 {
+		t.Errorf("expected 5 samples for other issuers, got %+v", other)
+	}
+}
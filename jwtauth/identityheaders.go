@@ -0,0 +1,266 @@
+package jwtauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Identity headers set by WithIdentityHeaders
+const (
+	IdentityHeaderSubject     = "X-Auth-Subject"
+	IdentityHeaderIssuer      = "X-Auth-Issuer"
+	IdentityHeaderTenant      = "X-Auth-Tenant"
+	IdentityHeaderPermissions = "X-Auth-Permissions" // Space-separated; names never contain whitespace
+	IdentityHeaderExpires     = "X-Auth-Expires"     // Token exp in Unix seconds
+	IdentityHeaderRequestID   = "X-Auth-Request-Id"
+	IdentityHeaderTimestamp   = "X-Auth-Timestamp" // Signing time in Unix seconds
+	IdentityHeaderSignature   = "X-Auth-Signature" // "v1=" and the base64url HMAC-SHA256
+)
+
+// DefaultIdentityHeadersMaxAge is how long VerifyIdentityHeaders accepts
+// headers after signing unless told otherwise
+const DefaultIdentityHeadersMaxAge = 30 * time.Second
+
+// identityHeaderNames are the signed headers, in signing order
+var identityHeaderNames = []string{
+	IdentityHeaderSubject,
+	IdentityHeaderIssuer,
+	IdentityHeaderTenant,
+	IdentityHeaderPermissions,
+	IdentityHeaderExpires,
+	IdentityHeaderRequestID,
+	IdentityHeaderTimestamp,
+}
+
+// Identity is the caller identity forwarded in signed X-Auth-* headers
+type Identity struct {
+	Subject     string
+	Issuer      string
+	Tenant      string
+	Permissions []string
+	ExpiresAt   time.Time // Zero when the token had no exp
+	RequestID   string
+	SignedAt    time.Time
+}
+
+// WithIdentityHeaders makes JWTAuth forward the authenticated identity to
+// upstream services, for gateways proxying requests (e.g. with
+// httputil.ReverseProxy) and for ForwardAuth. Incoming X-Auth-* headers are
+// removed and replaced by the Identity headers, signed with secret
+// (HMAC-SHA256, at least 32 bytes) so upstreams can check with
+// VerifyIdentityHeaders that nothing inside the network forged them. The
+// signature also covers the method, Host and request target (path and
+// query) of the request, so headers cannot be replayed on another request;
+// the proxy must forward these unchanged. Tokens whose permissions contain
+// whitespace are rejected with INVALID_CLAIM, since they cannot be listed
+// unambiguously.
+func WithIdentityHeaders(secret []byte) ConfigOption {
+	return func(c *Config) error {
+		if len(secret) < 32 {
+			return fmt.Errorf("identity header secret must be at least 32 bytes, got %d bytes", len(secret))
+		}
+		c.identityHeaders = append([]byte(nil), secret...)
+		return nil
+	}
+}
+
+// ForwardAuth returns a handler for forward-auth subrequests (Traefik
+// ForwardAuth, nginx auth_request). It authenticates the request like
+// JWTAuth and answers 200 with the signed X-Auth-* headers, which the proxy
+// copies to the upstream request, or with the JWTAuth error response. Route
+// requirements see the path of the auth endpoint, not the proxied request.
+//
+// The headers are signed for the proxied request, which the proxy names in
+// X-Forwarded-Method, X-Forwarded-Host and X-Forwarded-Uri (as Traefik
+// does; configure nginx to send them). Requests without X-Forwarded-Method
+// or X-Forwarded-Uri are answered 400. ForwardAuth panics without
+// WithIdentityHeaders.
+func ForwardAuth(cfg *Config) gin.HandlerFunc {
+	if cfg.identityHeaders == nil {
+		panic("jwtauth: ForwardAuth requires WithIdentityHeaders")
+	}
+	auth := JWTAuth(cfg)
+	return func(c *gin.Context) {
+		auth(c)
+		if c.IsAborted() {
+			return
+		}
+		method, uri := c.GetHeader("X-Forwarded-Method"), c.GetHeader("X-Forwarded-Uri")
+		if method == "" || uri == "" {
+			c.AbortWithStatus(http.StatusBadRequest)
+			return
+		}
+		host := c.GetHeader("X-Forwarded-Host")
+		if host == "" {
+			host = c.Request.Host
+		}
+		for _, name := range identityHeaderNames {
+			if value := c.Request.Header.Get(name); value != "" {
+				c.Header(name, value)
+			}
+		}
+		signIdentityHeaders(c.Writer.Header(), cfg.identityHeaders, method, host, uri)
+		c.Status(http.StatusOK)
+	}
+}
+
+// stripIdentityHeaders removes X-Auth-* headers a client sent
+func stripIdentityHeaders(h http.Header) {
+	for name := range h {
+		if strings.HasPrefix(name, "X-Auth-") {
+			delete(h, name)
+		}
+	}
+}
+
+// setIdentityHeaders sets the identity headers for claims on r, signed for
+// r's method, Host and request target
+func setIdentityHeaders(r *http.Request, secret []byte, claims *Claims, tenant, requestID string, now time.Time) error {
+	for _, permission := range claims.Permissions {
+		if permission == "" || strings.ContainsFunc(permission, unicode.IsSpace) {
+			return NewValidationError(ErrInvalidClaim, fmt.Sprintf("permission %q cannot be forwarded in identity headers", permission), nil)
+		}
+	}
+	var expires string
+	if !claims.ExpiresAt.IsZero() {
+		expires = strconv.FormatInt(claims.ExpiresAt.Unix(), 10)
+	}
+	values := []string{
+		claims.Subject,
+		claims.Issuer,
+		tenant,
+		strings.Join(claims.Permissions, " "),
+		expires,
+		requestID,
+		strconv.FormatInt(now.Unix(), 10),
+	}
+	for i, name := range identityHeaderNames {
+		if values[i] != "" {
+			r.Header.Set(name, values[i])
+		} else {
+			r.Header.Del(name)
+		}
+	}
+	signIdentityHeaders(r.Header, secret, r.Method, r.Host, r.URL.RequestURI())
+	return nil
+}
+
+// signIdentityHeaders sets the signature over the identity headers in h for
+// a request with the given method, host and request target
+func signIdentityHeaders(h http.Header, secret []byte, method, host, target string) {
+	values := make([]string, len(identityHeaderNames))
+	for i, name := range identityHeaderNames {
+		values[i] = h.Get(name)
+	}
+	h.Set(IdentityHeaderSignature, "v1="+base64.RawURLEncoding.EncodeToString(identityHeadersMAC(secret, method, host, target, values)))
+}
+
+// identityHeadersMAC signs the request method, host and target, then the
+// header values in identityHeaderNames order. Values are length-prefixed,
+// so no two sets of values sign alike.
+func identityHeadersMAC(secret []byte, method, host, target string, values []string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("jwtauth-identity-v1"))
+	for _, value := range append([]string{method, strings.ToLower(host), target}, values...) {
+		fmt.Fprintf(mac, "\n%d:%s", len(value), value)
+	}
+	return mac.Sum(nil)
+}
+
+// VerifyIdentityHeaders verifies the X-Auth-* headers of r set by
+// WithIdentityHeaders, for upstream services behind the gateway. It accepts
+// headers signed for r's method, Host and request target with any of
+// secrets (to rotate the shared secret) within maxAge of signing
+// (DefaultIdentityHeadersMaxAge if zero), for tokens that have not expired.
+// Failures are *ValidationError values: MISSING_TOKEN without a signature,
+// INVALID_SIGNATURE for forged or altered headers or headers signed for
+// another request, EXPIRED for stale headers or tokens and MALFORMED
+// otherwise.
+//
+//	identity, err := jwtauth.VerifyIdentityHeaders(r, 0, secret)
+//	if err != nil {
+//		http.Error(w, "unauthenticated", http.StatusUnauthorized)
+//		return
+//	}
+func VerifyIdentityHeaders(r *http.Request, maxAge time.Duration, secrets ...[]byte) (*Identity, error) {
+	if len(secrets) == 0 {
+		return nil, NewValidationError(ErrConfigError, "identity header secret is required", nil)
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultIdentityHeadersMaxAge
+	}
+
+	h := r.Header
+	signatures := h.Values(IdentityHeaderSignature)
+	if len(signatures) == 0 {
+		return nil, NewValidationError(ErrMissingToken, "identity headers are not signed", nil)
+	}
+	values := make([]string, len(identityHeaderNames))
+	for i, name := range append(identityHeaderNames, IdentityHeaderSignature) {
+		switch v := h.Values(name); {
+		case len(v) > 1:
+			return nil, NewValidationError(ErrMalformed, fmt.Sprintf("duplicate %s header", name), nil)
+		case len(v) == 1 && i < len(values):
+			values[i] = v[0]
+		}
+	}
+
+	encoded, ok := strings.CutPrefix(signatures[0], "v1=")
+	if !ok {
+		return nil, NewValidationError(ErrMalformed, "unsupported identity signature version", nil)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, NewValidationError(ErrMalformed, "identity signature is not base64url", err)
+	}
+	valid := false
+	for _, secret := range secrets {
+		if hmac.Equal(signature, identityHeadersMAC(secret, r.Method, r.Host, r.URL.RequestURI(), values)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, NewValidationError(ErrInvalidSignature, "identity header signature is invalid", nil)
+	}
+
+	// The values are authentic from here on
+	now := time.Now()
+	signedAt, err := strconv.ParseInt(h.Get(IdentityHeaderTimestamp), 10, 64)
+	if err != nil {
+		return nil, NewValidationError(ErrMalformed, "identity header timestamp is invalid", err)
+	}
+	identity := &Identity{
+		Subject:   h.Get(IdentityHeaderSubject),
+		Issuer:    h.Get(IdentityHeaderIssuer),
+		Tenant:    h.Get(IdentityHeaderTenant),
+		RequestID: h.Get(IdentityHeaderRequestID),
+		SignedAt:  time.Unix(signedAt, 0),
+	}
+	if age := now.Sub(identity.SignedAt); age > maxAge || age < -maxAge {
+		return nil, NewValidationError(ErrExpired, "identity headers are too old", nil)
+	}
+	if expires := h.Get(IdentityHeaderExpires); expires != "" {
+		exp, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil {
+			return nil, NewValidationError(ErrMalformed, "identity header expiry is invalid", err)
+		}
+		identity.ExpiresAt = time.Unix(exp, 0)
+		if !now.Before(identity.ExpiresAt) {
+			return nil, NewValidationError(ErrExpired, "token has expired", nil)
+		}
+	}
+	if permissions := h.Get(IdentityHeaderPermissions); permissions != "" {
+		identity.Permissions = strings.Fields(permissions)
+	}
+	return identity, nil
+}
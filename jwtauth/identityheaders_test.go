@@ -0,0 +1,187 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestIdentityHeaders tests that JWTAuth replaces client X-Auth-* headers
+// with signed ones that upstreams can verify
+func TestIdentityHeaders(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	headerSecret := []byte("identity-header-secret-32-bytes-long!")
+	cfg := mustCreateConfig(WithHS256(secret), WithIdentityHeaders(headerSecret), WithPermissionMapping(ScopePermissions()))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var forwarded *http.Request
+	router.GET("/proxy", JWTAuth(cfg), func(c *gin.Context) {
+		forwarded = c.Request.Clone(c.Request.Context())
+	})
+
+	exp := time.Now().Add(time.Hour).Unix()
+	req := httptest.NewRequest(http.MethodGet, "/proxy?order=1", nil)
+	req.Header.Set("Authorization", "Bearer "+mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "iss": "https://idp.example.com", "scope": "orders:read orders:write", "exp": exp}))
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set(IdentityHeaderSubject, "admin")
+	req.Header.Set("X-Auth-Role", "superuser")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if forwarded == nil {
+		t.Fatal("expected the request to be authenticated")
+	}
+	if forwarded.Header.Get("X-Auth-Role") != "" {
+		t.Error("expected client X-Auth-* headers to be removed")
+	}
+
+	identity, err := VerifyIdentityHeaders(forwarded, 0, headerSecret)
+	if err != nil {
+		t.Fatalf("expected the forwarded headers to verify, got %v", err)
+	}
+	if identity.Subject != "user-1" || identity.Issuer != "https://idp.example.com" || identity.RequestID != "req-1" {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+	if !slices.Equal(identity.Permissions, []string{"orders:read", "orders:write"}) || identity.ExpiresAt.Unix() != exp {
+		t.Errorf("unexpected permissions or expiry in %+v", identity)
+	}
+
+	// Rotated secrets are tried in turn
+	if _, err := VerifyIdentityHeaders(forwarded, 0, []byte("previous-identity-secret-32-bytes!!"), headerSecret); err != nil {
+		t.Errorf("expected verification with the second secret, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *http.Request)
+		secret []byte
+		want   ErrorCode
+	}{
+		{"altered subject", func(r *http.Request) { r.Header.Set(IdentityHeaderSubject, "admin") }, headerSecret, ErrInvalidSignature},
+		{"added tenant", func(r *http.Request) { r.Header.Set(IdentityHeaderTenant, "other") }, headerSecret, ErrInvalidSignature},
+		{"wrong secret", func(r *http.Request) {}, []byte("another-identity-secret-32-bytes!!!"), ErrInvalidSignature},
+		{"other method", func(r *http.Request) { r.Method = http.MethodDelete }, headerSecret, ErrInvalidSignature},
+		{"other host", func(r *http.Request) { r.Host = "admin.example.com" }, headerSecret, ErrInvalidSignature},
+		{"other path", func(r *http.Request) { r.URL.Path = "/admin" }, headerSecret, ErrInvalidSignature},
+		{"other query", func(r *http.Request) { r.URL.RawQuery = "order=2" }, headerSecret, ErrInvalidSignature},
+		{"unsigned", func(r *http.Request) { r.Header.Del(IdentityHeaderSignature) }, headerSecret, ErrMissingToken},
+		{"duplicate subject", func(r *http.Request) { r.Header.Add(IdentityHeaderSubject, "admin") }, headerSecret, ErrMalformed},
+		{"unknown version", func(r *http.Request) { r.Header.Set(IdentityHeaderSignature, "v2=abc") }, headerSecret, ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := forwarded.Clone(forwarded.Context())
+			tt.modify(r)
+			if _, err := VerifyIdentityHeaders(r, 0, tt.secret); getErrorCode(err) != string(tt.want) {
+				t.Errorf("expected %s, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Stale headers and expired tokens are rejected
+	claims := &Claims{Subject: "user-1", ExpiresAt: time.Now().Add(time.Hour)}
+	stale := httptest.NewRequest(http.MethodGet, "/proxy", nil)
+	setIdentityHeaders(stale, headerSecret, claims, "", "", time.Now().Add(-time.Minute))
+	if _, err := VerifyIdentityHeaders(stale, 0, headerSecret); getErrorCode(err) != string(ErrExpired) {
+		t.Errorf("expected EXPIRED for stale headers, got %v", err)
+	}
+	if _, err := VerifyIdentityHeaders(stale, 2*time.Minute, headerSecret); err != nil {
+		t.Errorf("expected stale headers within a longer max age, got %v", err)
+	}
+	claims.ExpiresAt = time.Now().Add(-time.Second)
+	expired := httptest.NewRequest(http.MethodGet, "/proxy", nil)
+	setIdentityHeaders(expired, headerSecret, claims, "", "", time.Now())
+	if _, err := VerifyIdentityHeaders(expired, 0, headerSecret); getErrorCode(err) != string(ErrExpired) {
+		t.Errorf("expected EXPIRED for an expired token, got %v", err)
+	}
+
+	// Permissions that would split differently are not forwarded
+	spaced := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "permissions": []string{"orders:read admin"}, "exp": exp})
+	auth0 := mustCreateConfig(WithHS256(secret), WithIdentityHeaders(headerSecret), WithPermissionMapping(Auth0Permissions()))
+	router = gin.New()
+	router.GET("/proxy", JWTAuth(auth0), func(c *gin.Context) { t.Error("expected the request to be rejected") })
+	req = httptest.NewRequest(http.MethodGet, "/proxy", nil)
+	req.Header.Set("Authorization", "Bearer "+spaced)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), string(ErrInvalidClaim)) {
+		t.Errorf("expected 401 INVALID_CLAIM for a permission with a space, got %d %s", w.Code, w.Body)
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithIdentityHeaders([]byte("short"))); err == nil {
+		t.Error("expected error for a short secret")
+	}
+}
+
+// TestForwardAuth tests forward-auth responses
+func TestForwardAuth(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	headerSecret := []byte("identity-header-secret-32-bytes-long!")
+	cfg := mustCreateConfig(WithHS256(secret), WithIdentityHeaders(headerSecret), WithTenantClaim("tenant"))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/auth", ForwardAuth(cfg))
+
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "tenant": "acme", "exp": time.Now().Add(time.Hour).Unix()})
+	req := httptest.NewRequest(http.MethodGet, "/auth", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(IdentityHeaderTenant, "other")
+	req.Header.Set("X-Forwarded-Method", http.MethodPost)
+	req.Header.Set("X-Forwarded-Host", "api.example.com")
+	req.Header.Set("X-Forwarded-Uri", "/orders?id=1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	// The headers verify on the proxied request, not on the auth request
+	upstream := httptest.NewRequest(http.MethodPost, "http://api.example.com/orders?id=1", nil)
+	for name, values := range w.Header() {
+		if strings.HasPrefix(name, "X-Auth-") {
+			upstream.Header[name] = values
+		}
+	}
+	identity, err := VerifyIdentityHeaders(upstream, 0, headerSecret)
+	if err != nil {
+		t.Fatalf("expected signed response headers, got %v", err)
+	}
+	upstream.URL.Path = "/auth"
+	if _, err := VerifyIdentityHeaders(upstream, 0, headerSecret); getErrorCode(err) != string(ErrInvalidSignature) {
+		t.Errorf("expected INVALID_SIGNATURE for another path, got %v", err)
+	}
+	if identity.Subject != "user-1" || identity.Tenant != "acme" {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+
+	// Unauthenticated requests get the JWTAuth error and no identity
+	req = httptest.NewRequest(http.MethodGet, "/auth", nil)
+	req.Header.Set(IdentityHeaderSubject, "admin")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || w.Header().Get(IdentityHeaderSubject) != "" {
+		t.Errorf("expected 401 without identity headers, got %d %v", w.Code, w.Header())
+	}
+
+	// The proxied request must be named
+	req = httptest.NewRequest(http.MethodGet, "/auth", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || w.Header().Get(IdentityHeaderSignature) != "" {
+		t.Errorf("expected 400 without X-Forwarded-Uri, got %d %v", w.Code, w.Header())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected ForwardAuth to panic without WithIdentityHeaders")
+		}
+	}()
+	ForwardAuth(mustCreateConfig(WithHS256(secret)))
+}
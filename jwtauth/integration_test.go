@@ -1,10 +1,13 @@
 package jwtauth
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -289,6 +292,50 @@ func TestGinMiddlewareClaimsInjection(t *testing.T) {
 	}
 }
 
+// TestGinMiddlewareTenantExtraction tests WithTenantClaim context propagation and logging
+func TestGinMiddlewareTenantExtraction(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logBuf, nil))
+	cfg := mustCreateConfig(WithHS256(secret), WithTenantClaim("tid"), WithLogger(logger))
+
+	var tenant string
+	var found bool
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	router.GET("/protected", func(c *gin.Context) {
+		tenant, found = GetTenant(c.Request.Context())
+		c.Status(200)
+	})
+
+	tokenString := mustSignHS256(secret, jwt.MapClaims{
+		"sub": "user123",
+		"tid": "acme",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !found || tenant != "acme" {
+		t.Errorf("Expected tenant acme in context, got %q (found=%v)", tenant, found)
+	}
+	if !strings.Contains(logBuf.String(), `"tenant_id":"acme"`) {
+		t.Errorf("Expected tenant_id in security event, got %s", logBuf.String())
+	}
+
+	// Tokens without the tenant claim leave the context value unset
+	tokenString = mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+	req = httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if found {
+		t.Errorf("Expected no tenant for token without tid, got %q", tenant)
+	}
+}
+
 // Helper functions
 
 func mustCreateConfig(opts ...ConfigOption) *Config {
@@ -299,6 +346,14 @@ func mustCreateConfig(opts ...ConfigOption) *Config {
 	return cfg
 }
 
+func mustSignHS256(secret []byte, claims jwt.MapClaims) string {
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		panic(err)
+	}
+	return tokenString
+}
+
 func mustGenerateRSAKey() *rsa.PrivateKey {
 	key, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
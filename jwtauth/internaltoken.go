@@ -0,0 +1,174 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultInternalTokenEntries bounds the minted token cache
+const defaultInternalTokenEntries = 10000
+
+// InternalToken configures translating validated external tokens into
+// short-lived internal tokens at the edge, so services behind it only trust
+// one internal issuer and key
+type InternalToken struct {
+	Issuer   string        // iss of minted tokens, e.g. "https://edge.internal"
+	Signer   Signer        // Internal signing key, e.g. NewCryptoSigner with a KMS key
+	Lifetime time.Duration // Validity of minted tokens (default 1m); never past the external token's exp
+
+	// Claims are copied from the external token by name, e.g. "email" or
+	// "roles". sub is always copied; registered claims cannot be listed.
+	Claims []string
+
+	// MaxEntries bounds cached minted tokens (default 10000)
+	MaxEntries int
+}
+
+// internalTokenReserved are set by the minter and cannot be copied
+var internalTokenReserved = map[string]bool{
+	"iss": true, "sub": true, "aud": true, "exp": true, "nbf": true, "iat": true, "jti": true,
+}
+
+// InternalTokenMinter mints internal tokens from validated claims. It is
+// safe for concurrent use.
+type InternalTokenMinter struct {
+	it  InternalToken
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*AccessToken
+}
+
+// NewInternalTokenMinter returns a minter for it
+func NewInternalTokenMinter(it InternalToken) (*InternalTokenMinter, error) {
+	if it.Issuer == "" || it.Signer == nil {
+		return nil, fmt.Errorf("internal token requires an issuer and signer")
+	}
+	if it.Lifetime < 0 {
+		return nil, fmt.Errorf("internal token lifetime must be non-negative, got %v", it.Lifetime)
+	}
+	for _, name := range it.Claims {
+		if name == "" || internalTokenReserved[name] {
+			return nil, fmt.Errorf("internal token cannot copy claim %q", name)
+		}
+	}
+	if it.Lifetime == 0 {
+		it.Lifetime = time.Minute
+	}
+	if it.MaxEntries <= 0 {
+		it.MaxEntries = defaultInternalTokenEntries
+	}
+	return &InternalTokenMinter{it: it, now: time.Now, entries: make(map[string]*AccessToken)}, nil
+}
+
+// TokenSource returns a TokenSource minting internal tokens for audience
+// from the validated claims of the request context (set by JWTAuth, the
+// gRPC interceptors and ValidateMessage). Use it with Transport or
+// TokenCredentials and pass the request context to outbound calls:
+//
+//	client := &http.Client{Transport: &jwtauth.Transport{Source: minter.TokenSource("orders")}}
+//	req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, ordersURL, nil)
+//
+// Tokens are cached per inbound token and audience, and reused while more
+// than half of their lifetime remains.
+func (m *InternalTokenMinter) TokenSource(audience string) TokenSource {
+	return TokenSourceFunc(func(ctx context.Context) (*AccessToken, error) {
+		claims, ok := GetClaims(ctx)
+		if !ok {
+			return nil, fmt.Errorf("internal token: no validated claims in context")
+		}
+		token, _ := GetToken(ctx)
+		return m.token(ctx, token, claims, audience)
+	})
+}
+
+// Mint returns a new internal token for audience carrying claims
+func (m *InternalTokenMinter) Mint(ctx context.Context, claims *Claims, audience string) (*AccessToken, error) {
+	if claims == nil || claims.Subject == "" {
+		return nil, fmt.Errorf("internal token requires claims with a subject")
+	}
+	if audience == "" {
+		return nil, fmt.Errorf("internal token requires an audience")
+	}
+
+	now := m.now()
+	expires := now.Add(m.it.Lifetime)
+	if !claims.ExpiresAt.IsZero() && claims.ExpiresAt.Before(expires) {
+		expires = claims.ExpiresAt
+	}
+	if !now.Before(expires) {
+		return nil, fmt.Errorf("internal token: external token has expired")
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return nil, fmt.Errorf("failed to generate token ID: %w", err)
+	}
+	payload := map[string]interface{}{
+		"iss": m.it.Issuer,
+		"sub": claims.Subject,
+		"aud": audience,
+		"jti": hex.EncodeToString(jti),
+		"iat": now.Unix(),
+		"exp": expires.Unix(),
+	}
+	for _, name := range m.it.Claims {
+		if value, ok := claims.Get(name); ok {
+			payload[name] = value
+		}
+	}
+
+	token, err := SignToken(ctx, m.it.Signer, payload)
+	if err != nil {
+		return nil, fmt.Errorf("internal token: %w", err)
+	}
+	return &AccessToken{Token: token, ExpiresAt: time.Unix(expires.Unix(), 0)}, nil
+}
+
+// token returns a cached internal token, minting one on a miss
+func (m *InternalTokenMinter) token(ctx context.Context, token string, claims *Claims, audience string) (*AccessToken, error) {
+	sum := sha256.Sum256([]byte(token + "\x00" + claims.Subject + "\x00" + audience))
+	key := hex.EncodeToString(sum[:])
+
+	m.mu.Lock()
+	cached, ok := m.entries[key]
+	m.mu.Unlock()
+	if ok && cached.ExpiresAt.Sub(m.now()) > m.it.Lifetime/2 {
+		return cached, nil
+	}
+
+	minted, err := m.Mint(ctx, claims, audience)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.evictLocked()
+	m.entries[key] = minted
+	m.mu.Unlock()
+	return minted, nil
+}
+
+// evictLocked removes expired entries and, when still full, an arbitrary
+// entry. m.mu must be held.
+func (m *InternalTokenMinter) evictLocked() {
+	if len(m.entries) < m.it.MaxEntries {
+		return
+	}
+	now := m.now()
+	for key, tok := range m.entries {
+		if !tok.Valid(now) {
+			delete(m.entries, key)
+		}
+	}
+	for key := range m.entries {
+		if len(m.entries) < m.it.MaxEntries {
+			break
+		}
+		delete(m.entries, key)
+	}
+}
@@ -0,0 +1,112 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestInternalTokenPropagation tests translating an external token at the
+// edge into an internal token accepted by a downstream service
+func TestInternalTokenPropagation(t *testing.T) {
+	externalSecret := []byte("test-secret-key-min-32-bytes-long!!")
+	internalKey := mustGenerateECKey()
+	signer, err := NewCryptoSigner("ES256", "edge-1", internalKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minter, err := NewInternalTokenMinter(InternalToken{Issuer: "https://edge.internal", Signer: signer, Claims: []string{"email", "roles"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Downstream service trusting only the internal issuer
+	downstreamCfg := mustCreateConfig(WithES256(&internalKey.PublicKey), WithIssuer("https://edge.internal"), WithAudience("orders"))
+	gin.SetMode(gin.TestMode)
+	downstream := gin.New()
+	downstream.Use(JWTAuth(downstreamCfg))
+	downstream.GET("/orders", func(c *gin.Context) {
+		claims := MustGetClaims(c.Request.Context())
+		if email, _ := claims.GetString("email"); claims.Subject != "user-1" || email != "user@example.com" {
+			t.Errorf("unexpected downstream claims %+v", claims)
+		}
+		if _, ok := claims.Custom["internal_only"]; ok {
+			t.Error("expected claims outside the list not to be copied")
+		}
+		c.Status(200)
+	})
+	downstreamSrv := httptest.NewServer(downstream)
+	defer downstreamSrv.Close()
+
+	// Edge service calling downstream with the translated token
+	client := &http.Client{Transport: &Transport{Source: minter.TokenSource("orders")}}
+	edge := gin.New()
+	edge.Use(JWTAuth(mustCreateConfig(WithHS256(externalSecret))))
+	edge.GET("/checkout", func(c *gin.Context) {
+		req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, downstreamSrv.URL+"/orders", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		c.Status(resp.StatusCode)
+	})
+
+	external := mustSignHS256(externalSecret, jwt.MapClaims{
+		"sub": "user-1", "email": "user@example.com", "roles": []string{"buyer"}, "internal_only": true,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	req := httptest.NewRequest("GET", "/checkout", nil)
+	req.Header.Set("Authorization", "Bearer "+external)
+	w := httptest.NewRecorder()
+	edge.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Errorf("expected the downstream call to be authorized, got %d", w.Code)
+	}
+}
+
+// TestInternalTokenLifetime tests the lifetime cap, caching and errors
+func TestInternalTokenLifetime(t *testing.T) {
+	signer, _ := NewCryptoSigner("ES256", "", mustGenerateECKey())
+	minter, err := NewInternalTokenMinter(InternalToken{Issuer: "https://edge.internal", Signer: signer, Lifetime: 5 * time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The internal token never outlives the external one
+	expires := time.Now().Add(time.Minute).Truncate(time.Second)
+	claims := &Claims{Subject: "user-1", ExpiresAt: expires}
+	tok, err := minter.Mint(context.Background(), claims, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tok.ExpiresAt.Equal(expires) {
+		t.Errorf("expected expiry %v, got %v", expires, tok.ExpiresAt)
+	}
+
+	ctx := WithToken(WithClaims(context.Background(), &Claims{Subject: "user-1"}), "external")
+	first, _ := minter.TokenSource("orders").Token(ctx)
+	second, _ := minter.TokenSource("orders").Token(ctx)
+	other, _ := minter.TokenSource("billing").Token(ctx)
+	if first.Token != second.Token || first.Token == other.Token {
+		t.Error("expected tokens cached per audience")
+	}
+
+	if _, err := minter.TokenSource("orders").Token(context.Background()); err == nil {
+		t.Error("expected error without claims in context")
+	}
+	if _, err := minter.Mint(context.Background(), &Claims{Subject: "user-1", ExpiresAt: time.Now().Add(-time.Second)}, "orders"); err == nil {
+		t.Error("expected error for an expired external token")
+	}
+	if _, err := NewInternalTokenMinter(InternalToken{Issuer: "https://edge.internal", Signer: signer, Claims: []string{"aud"}}); err == nil {
+		t.Error("expected error for copying a registered claim")
+	}
+	if _, err := NewInternalTokenMinter(InternalToken{Signer: signer}); err == nil {
+		t.Error("expected error without an issuer")
+	}
+}
@@ -0,0 +1,68 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// WithIPBinding rejects tokens used from a different client IP than the one
+// they were issued to. The bound address is read from the named claim,
+// falling back to the "ip" member of the cnf (confirmation) claim. Either may
+// hold an address ("198.51.100.7") or a prefix ("198.51.100.0/24") to
+// tolerate clients moving within a network.
+//
+// The request's client IP is derived as described in WithTrustedProxies;
+// configure trusted proxies when running behind a load balancer, or every
+// request will appear to come from the proxy. Tokens without a binding, and
+// transports without a client IP (message buses), are rejected: enable IP
+// binding only on routes that require it.
+func WithIPBinding(claim string) ConfigOption {
+	return func(c *Config) error {
+		if claim == "" {
+			return fmt.Errorf("IP binding claim name cannot be empty")
+		}
+		c.ipBindingClaim = claim
+		return nil
+	}
+}
+
+// checkIPBinding compares the token's bound IP with the request's client IP
+func checkIPBinding(ctx context.Context, cfg *Config, claims *Claims) error {
+	if cfg.ipBindingClaim == "" {
+		return nil
+	}
+
+	bound, ok := boundIP(claims, cfg.ipBindingClaim)
+	if !ok {
+		return NewValidationError(ErrIPMismatch, "token is not bound to a client IP", nil)
+	}
+	prefix, err := parseAddrOrPrefix(bound)
+	if err != nil {
+		return NewValidationError(ErrIPMismatch, "token IP binding is invalid", err)
+	}
+
+	clientIP, _ := GetClientIP(ctx)
+	addr, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return NewValidationError(ErrIPMismatch, "client IP unavailable", nil)
+	}
+	if !prefix.Contains(addr.Unmap()) {
+		return NewValidationError(ErrIPMismatch, "token is bound to a different client IP", nil)
+	}
+	return nil
+}
+
+// boundIP reads the bound address from the claim or cnf.ip
+func boundIP(claims *Claims, claim string) (string, bool) {
+	if ip, ok := claims.GetString(claim); ok && strings.TrimSpace(ip) != "" {
+		return ip, true
+	}
+	if cnf, ok := claims.Custom["cnf"].(map[string]interface{}); ok {
+		if ip, ok := cnf["ip"].(string); ok && strings.TrimSpace(ip) != "" {
+			return ip, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,86 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestIPBinding tests that bound tokens are only accepted from their IP
+func TestIPBinding(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithTrustedProxies("10.0.0.0/8"),
+		WithIPBinding("client_ip"),
+	)
+	router := createTestRouter(cfg)
+
+	sign := func(extra jwt.MapClaims) string {
+		claims := jwt.MapClaims{"sub": "admin", "exp": time.Now().Add(time.Hour).Unix()}
+		for k, v := range extra {
+			claims[k] = v
+		}
+		return mustSignHS256(secret, claims)
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		remoteAddr   string
+		forwardedFor string
+		wantStatus   int
+		wantReason   string
+	}{
+		{"matching IP", sign(jwt.MapClaims{"client_ip": "198.51.100.7"}), "198.51.100.7:1234", "", http.StatusOK, ""},
+		{"matching IP behind proxy", sign(jwt.MapClaims{"client_ip": "198.51.100.7"}), "10.0.0.1:1234", "198.51.100.7", http.StatusOK, ""},
+		{"matching prefix", sign(jwt.MapClaims{"client_ip": "198.51.100.0/24"}), "198.51.100.42:1234", "", http.StatusOK, ""},
+		{"cnf ip fallback", sign(jwt.MapClaims{"cnf": map[string]interface{}{"ip": "198.51.100.7"}}), "198.51.100.7:1234", "", http.StatusOK, ""},
+		{"different IP", sign(jwt.MapClaims{"client_ip": "198.51.100.7"}), "203.0.113.9:1234", "", http.StatusUnauthorized, "IP_MISMATCH"},
+		{"spoofed header from untrusted peer", sign(jwt.MapClaims{"client_ip": "198.51.100.7"}), "203.0.113.9:1234", "198.51.100.7", http.StatusUnauthorized, "IP_MISMATCH"},
+		{"unbound token", sign(nil), "198.51.100.7:1234", "", http.StatusUnauthorized, "IP_MISMATCH"},
+		{"invalid binding", sign(jwt.MapClaims{"client_ip": "nowhere"}), "198.51.100.7:1234", "", http.StatusUnauthorized, "IP_MISMATCH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/protected", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantReason != "" && !strings.Contains(w.Body.String(), tt.wantReason) {
+				t.Errorf("Expected reason %s, got %s", tt.wantReason, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestIPBindingWithoutClientIP tests that transports without a peer address
+// fail closed
+func TestIPBindingWithoutClientIP(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithIPBinding("client_ip"))
+	token := mustSignHS256(secret, jwt.MapClaims{
+		"sub":       "admin",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"client_ip": "198.51.100.7",
+	})
+
+	_, err := ValidateMessage(context.Background(), cfg, map[string][]string{"Authorization": {"Bearer " + token}})
+	if getErrorCode(err) != string(ErrIPMismatch) {
+		t.Errorf("Expected IP_MISMATCH, got %v", err)
+	}
+}
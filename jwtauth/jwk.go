@@ -0,0 +1,169 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// JSONWebKey is a public key parsed from an RFC 7517 JSON Web Key
+type JSONWebKey struct {
+	KeyID     string      // "kid" member
+	Algorithm string      // "alg" member (may be empty)
+	Use       string      // "use" member (may be empty)
+	Key       interface{} // *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or, with the es256k build tag, *secp256k1.PublicKey
+}
+
+// rawJWK holds the JSON members of a public JWK
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// ParseJWK parses a single public JSON Web Key (RSA, EC or OKP/Ed25519).
+// HSM key types reported by key vaults ("RSA-HSM", "EC-HSM") are accepted.
+func ParseJWK(data []byte) (*JSONWebKey, error) {
+	var raw rawJWK
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode JWK: %w", err)
+	}
+	return raw.parse()
+}
+
+// parse converts the raw JWK members into a public key
+func (r rawJWK) parse() (*JSONWebKey, error) {
+	jwk := &JSONWebKey{KeyID: r.Kid, Algorithm: r.Alg, Use: r.Use}
+
+	switch strings.TrimSuffix(r.Kty, "-HSM") {
+	case "RSA":
+		n, err := decodeJWKInt(r.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := decodeJWKInt(r.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		jwk.Key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+
+	case "EC":
+		var curve elliptic.Curve
+		switch r.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		case "secp256k1":
+			// RFC 8812: secp256k1 keys are used with ES256K only
+			if r.Alg != "" && r.Alg != "ES256K" {
+				return nil, fmt.Errorf("secp256k1 key cannot be used with %s", r.Alg)
+			}
+			x, err := base64.RawURLEncoding.DecodeString(r.X)
+			if err != nil {
+				return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+			}
+			y, err := base64.RawURLEncoding.DecodeString(r.Y)
+			if err != nil {
+				return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+			}
+			if jwk.Key, err = parseSecp256k1JWK(x, y); err != nil {
+				return nil, err
+			}
+			return jwk, nil
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", r.Crv)
+		}
+		x, err := decodeJWKInt(r.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := decodeJWKInt(r.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("EC point is not on curve %s", r.Crv)
+		}
+		jwk.Key = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+
+	case "OKP":
+		if r.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", r.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(r.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key")
+		}
+		jwk.Key = ed25519.PublicKey(x)
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", r.Kty)
+	}
+
+	return jwk, nil
+}
+
+// decodeJWKInt decodes a base64url-encoded big-endian integer
+func decodeJWKInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// MarshalJSON encodes the key as a public JSON Web Key, e.g. for publishing
+// in a JWKS document ({"keys": [...]})
+func (k JSONWebKey) MarshalJSON() ([]byte, error) {
+	raw := rawJWK{Kid: k.KeyID, Alg: k.Algorithm, Use: k.Use}
+
+	switch key := k.Key.(type) {
+	case *rsa.PublicKey:
+		raw.Kty = "RSA"
+		raw.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		raw.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		bits := key.Curve.Params().BitSize
+		size := (bits + 7) / 8
+		raw.Kty = "EC"
+		raw.Crv = fmt.Sprintf("P-%d", bits)
+		raw.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size)))
+		raw.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		raw.Kty = "OKP"
+		raw.Crv = "Ed25519"
+		raw.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		x, y, ok := secp256k1JWKCoordinates(k.Key)
+		if !ok {
+			return nil, fmt.Errorf("unsupported public key type %T", k.Key)
+		}
+		raw.Kty = "EC"
+		raw.Crv = "secp256k1"
+		raw.X = base64.RawURLEncoding.EncodeToString(x)
+		raw.Y = base64.RawURLEncoding.EncodeToString(y)
+	}
+
+	return json.Marshal(raw)
+}
@@ -0,0 +1,107 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func b64url(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// TestParseJWK tests parsing of RSA, EC and OKP public keys
+func TestParseJWK(t *testing.T) {
+	rsaKey := mustGenerateRSAKey()
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	rsaJWK := fmt.Sprintf(`{"kty":"RSA-HSM","kid":"r1","alg":"RS256","n":%q,"e":%q}`,
+		b64url(rsaKey.N.Bytes()), b64url(big.NewInt(int64(rsaKey.E)).Bytes()))
+	ecJWK := fmt.Sprintf(`{"kty":"EC","kid":"e1","crv":"P-256","x":%q,"y":%q}`,
+		b64url(ecKey.X.Bytes()), b64url(ecKey.Y.Bytes()))
+	edJWK := fmt.Sprintf(`{"kty":"OKP","kid":"o1","crv":"Ed25519","x":%q}`, b64url(edPub))
+
+	jwk, err := ParseJWK([]byte(rsaJWK))
+	if err != nil {
+		t.Fatalf("RSA JWK: %v", err)
+	}
+	if pub, ok := jwk.Key.(*rsa.PublicKey); !ok || pub.N.Cmp(rsaKey.N) != 0 || jwk.KeyID != "r1" || jwk.Algorithm != "RS256" {
+		t.Errorf("RSA JWK parsed incorrectly: %+v", jwk)
+	}
+
+	jwk, err = ParseJWK([]byte(ecJWK))
+	if err != nil {
+		t.Fatalf("EC JWK: %v", err)
+	}
+	if pub, ok := jwk.Key.(*ecdsa.PublicKey); !ok || !pub.Equal(&ecKey.PublicKey) {
+		t.Errorf("EC JWK parsed incorrectly: %+v", jwk)
+	}
+
+	jwk, err = ParseJWK([]byte(edJWK))
+	if err != nil {
+		t.Fatalf("OKP JWK: %v", err)
+	}
+	if pub, ok := jwk.Key.(ed25519.PublicKey); !ok || !pub.Equal(edPub) {
+		t.Errorf("OKP JWK parsed incorrectly: %+v", jwk)
+	}
+}
+
+// TestParseJWKErrors tests rejection of malformed or unsupported keys
+func TestParseJWKErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		jwk  string
+	}{
+		{"invalid JSON", `{`},
+		{"symmetric key", `{"kty":"oct","k":"c2VjcmV0"}`},
+		{"missing modulus", `{"kty":"RSA","e":"AQAB"}`},
+		{"unsupported curve", `{"kty":"EC","crv":"P-192","x":"AA","y":"AA"}`},
+		{"point not on curve", `{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}`},
+		{"short Ed25519 key", `{"kty":"OKP","crv":"Ed25519","x":"AQ"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseJWK([]byte(tt.jwk)); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+// TestMarshalJWKRoundTrip tests that marshalled keys parse back unchanged
+func TestMarshalJWKRoundTrip(t *testing.T) {
+	rsaKey := mustGenerateRSAKey()
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	for _, key := range []interface{}{&rsaKey.PublicKey, &ecKey.PublicKey, edPub} {
+		data, err := json.Marshal(JSONWebKey{KeyID: "k1", Algorithm: "X", Use: "sig", Key: key})
+		if err != nil {
+			t.Fatalf("Marshal %T: %v", key, err)
+		}
+		jwk, err := ParseJWK(data)
+		if err != nil {
+			t.Fatalf("ParseJWK(%s): %v", data, err)
+		}
+		if jwk.KeyID != "k1" || jwk.Use != "sig" {
+			t.Errorf("Metadata not preserved: %s", data)
+		}
+		if !jwk.Key.(interface{ Equal(crypto.PublicKey) bool }).Equal(key) {
+			t.Errorf("Key %T not preserved: %s", key, data)
+		}
+	}
+
+	if _, err := json.Marshal(JSONWebKey{Key: []byte("secret")}); err == nil {
+		t.Error("Expected symmetric keys to be rejected")
+	}
+}
@@ -0,0 +1,484 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Remote JWKS defaults
+const (
+	jwksRefreshInterval    = time.Hour
+	jwksMinRefreshInterval = time.Minute
+	jwksFetchTimeout       = 10 * time.Second
+	jwksMaxStaleness       = 24 * time.Hour
+	jwksMaxBytes           = 1 << 20
+)
+
+// jwksOptions holds settings for WithJWKSURL
+type jwksOptions struct {
+	client            *http.Client
+	timeout           time.Duration
+	refreshInterval   time.Duration
+	maxStaleness      time.Duration
+	algorithms        []string
+	backgroundCtx     context.Context
+	backgroundRefresh time.Duration
+	onRefreshError    func(url string, err error)
+}
+
+// JWKSOption configures WithJWKSURL
+type JWKSOption func(*jwksOptions)
+
+// WithJWKSHTTPClient sets the HTTP client used to fetch the JWKS document,
+// e.g. for proxies or custom TLS roots (default: a client with no settings
+// beyond the fetch timeout)
+func WithJWKSHTTPClient(client *http.Client) JWKSOption {
+	return func(o *jwksOptions) {
+		o.client = client
+	}
+}
+
+// WithJWKSTimeout bounds each JWKS fetch (default 10s)
+func WithJWKSTimeout(timeout time.Duration) JWKSOption {
+	return func(o *jwksOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithJWKSCacheTTL sets how long a fetched JWKS document is used before it
+// is fetched again (default 1h)
+func WithJWKSCacheTTL(ttl time.Duration) JWKSOption {
+	return func(o *jwksOptions) {
+		o.refreshInterval = ttl
+	}
+}
+
+// WithJWKSMaxStaleness bounds how long the last fetched JWKS document keeps
+// verifying tokens while refetches fail (default 24h, at least the cache
+// TTL). Past it, lookups fail like an unreachable endpoint and
+// WithKeyOutagePolicy decides.
+func WithJWKSMaxStaleness(d time.Duration) JWKSOption {
+	return func(o *jwksOptions) {
+		o.maxStaleness = d
+	}
+}
+
+// WithJWKSAlgorithms sets the algorithms verified with the JWKS keys
+// (default RS256). Only asymmetric algorithms are accepted.
+func WithJWKSAlgorithms(algs ...string) JWKSOption {
+	return func(o *jwksOptions) {
+		o.algorithms = algs
+	}
+}
+
+// WithJWKSBackgroundRefresh refetches the JWKS document every interval
+// until ctx is done, so keys added by the identity provider are known before
+// the first token signed with them arrives and removed keys stop verifying
+// tokens. Failed refreshes keep the previous keys up to
+// WithJWKSMaxStaleness.
+func WithJWKSBackgroundRefresh(ctx context.Context, interval time.Duration) JWKSOption {
+	return func(o *jwksOptions) {
+		o.backgroundCtx = ctx
+		o.backgroundRefresh = interval
+	}
+}
+
+// WithJWKSRefreshErrorHandler sets a callback for failed JWKS refreshes
+// (background and unknown-kid refetches), e.g. to feed an alert or health
+// check; fn must not block. Failures are also logged at warn level when a
+// logger is configured.
+func WithJWKSRefreshErrorHandler(fn func(url string, err error)) JWKSOption {
+	return func(o *jwksOptions) {
+		o.onRefreshError = fn
+	}
+}
+
+// WithJWKSURL verifies tokens with the keys published at a JWKS endpoint,
+// such as those of Auth0 (https://TENANT/.well-known/jwks.json), Keycloak
+// (https://HOST/realms/REALM/protocol/openid-connect/certs) or Cognito
+// (https://cognito-idp.REGION.amazonaws.com/POOL_ID/.well-known/jwks.json).
+// RSA, EC and OKP (Ed25519) keys are selected by the token's kid header and
+// cached in memory; an unknown kid triggers a refetch at most once a minute,
+// so key rotation is picked up without letting forged kids hammer the
+// endpoint. Keys whose JWK declares an alg are only used for that algorithm.
+//
+// The URL must use HTTPS; plain HTTP is accepted for loopback hosts only.
+//
+//	jwtauth.WithJWKSURL("https://example.auth0.com/.well-known/jwks.json",
+//		jwtauth.WithJWKSAlgorithms("RS256", "ES256"),
+//		jwtauth.WithJWKSTimeout(5*time.Second),
+//	)
+func WithJWKSURL(rawURL string, opts ...JWKSOption) ConfigOption {
+	return func(c *Config) error {
+		o := jwksOptions{timeout: jwksFetchTimeout, refreshInterval: jwksRefreshInterval, maxStaleness: jwksMaxStaleness, algorithms: []string{"RS256"}}
+		for _, opt := range opts {
+			opt(&o)
+		}
+
+		if err := checkKeySourceURL("JWKS", rawURL); err != nil {
+			return err
+		}
+		if o.timeout <= 0 {
+			return fmt.Errorf("JWKS fetch timeout must be positive, got %v", o.timeout)
+		}
+		if o.refreshInterval <= 0 {
+			return fmt.Errorf("JWKS cache TTL must be positive, got %v", o.refreshInterval)
+		}
+		if o.maxStaleness < o.refreshInterval {
+			return fmt.Errorf("JWKS max staleness %v must be at least the cache TTL %v", o.maxStaleness, o.refreshInterval)
+		}
+		if len(o.algorithms) == 0 {
+			return fmt.Errorf("JWKS URL %s needs at least one algorithm", rawURL)
+		}
+		if o.backgroundCtx != nil && o.backgroundRefresh <= 0 {
+			return fmt.Errorf("JWKS background refresh interval must be positive, got %v", o.backgroundRefresh)
+		}
+
+		provider := newRemoteJWKS(rawURL)
+		provider.refreshInterval = o.refreshInterval
+		provider.maxStaleness = o.maxStaleness
+		provider.client = &http.Client{Timeout: o.timeout}
+		if o.client != nil {
+			provider.client = o.client
+			provider.timeout = o.timeout
+		}
+		provider.onRefreshError = func(err error) {
+			if logger := c.Logger(); logger != nil {
+				logger.Warn("JWKS refresh failed", "url", rawURL, "error", err)
+			}
+			if o.onRefreshError != nil {
+				o.onRefreshError(rawURL, err)
+			}
+		}
+		for _, alg := range o.algorithms {
+			if !isJWKSAlgorithm(alg) {
+				return fmt.Errorf("algorithm %s cannot be used with JWKS keys", alg)
+			}
+			if err := WithKeyProvider(alg, provider)(c); err != nil {
+				return err
+			}
+		}
+		if o.backgroundCtx != nil {
+			go provider.refreshEvery(o.backgroundCtx, o.backgroundRefresh)
+		}
+		return nil
+	}
+}
+
+// checkKeySourceURL requires an absolute https URL, or http for loopback hosts
+func checkKeySourceURL(kind, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid %s URL %q", kind, rawURL)
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && isLoopbackHost(u.Hostname())) {
+		return fmt.Errorf("%s URL %s must use https", kind, rawURL)
+	}
+	return nil
+}
+
+// isJWKSAlgorithm reports whether alg is an asymmetric algorithm that can be
+// verified with JWKS keys
+func isJWKSAlgorithm(alg string) bool {
+	method := lookupSigningMethod(alg)
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+		return true
+	}
+	return isES256KMethod(method)
+}
+
+// isLoopbackHost reports whether host is localhost or a loopback address
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// remoteJWKS is a KeyProvider resolving keys by kid from a JWKS document
+// served over HTTPS. The document is fetched on first use and again after
+// refreshInterval (default jwksRefreshInterval); an unknown kid forces a fetch at most once per
+// jwksMinRefreshInterval (or refreshInterval, if shorter), so rotated keys are picked up without letting
+// tokens with random kids hammer the endpoint.
+//
+// Lookups read an atomically published snapshot of the document and take
+// no lock; the mutex only coordinates fetches. Concurrent fetches collapse
+// into one in-flight fetch whose result every caller shares, so a burst of
+// tokens with a newly rotated kid costs a single request. The fetch runs
+// without holding the lock and ends at the shortest deadline of the callers
+// waiting for it; each caller stops waiting when its own context is done.
+// While fetches fail, the previous document is served until it is
+// maxStaleness old.
+type remoteJWKS struct {
+	url             string
+	client          *http.Client
+	timeout         time.Duration // Per-fetch deadline for clients without their own timeout
+	refreshInterval time.Duration
+	maxStaleness    time.Duration // How long the last document outlives failed refetches
+	onRefreshError  func(error)   // Reports failed refetches; nil ignores them
+	stop            chan struct{} // Closed by Close to end background refresh
+	stopOnce        sync.Once
+
+	keyWatchers
+
+	snapshot atomic.Pointer[jwksSnapshot] // Last fetched document, nil before the first fetch
+
+	mu     sync.Mutex  // Guards flight and serializes snapshot updates
+	flight *jwksFlight // In-flight fetch, nil when idle
+}
+
+// jwksSnapshot is a fetched JWKS document; it is never modified once published
+type jwksSnapshot struct {
+	keys    map[string]JSONWebKey
+	fetched time.Time
+}
+
+// jwksFlight is a fetch shared by concurrent callers
+type jwksFlight struct {
+	done     chan struct{} // Closed when err is set
+	err      error
+	cancel   context.CancelFunc
+	deadline time.Time   // Shortest caller deadline; guarded by remoteJWKS.mu
+	timer    *time.Timer // Cancels the fetch at deadline
+}
+
+// newRemoteJWKS returns a provider for the JWKS document at url
+func newRemoteJWKS(url string) *remoteJWKS {
+	return &remoteJWKS{url: url, client: &http.Client{Timeout: jwksFetchTimeout}, refreshInterval: jwksRefreshInterval, maxStaleness: jwksMaxStaleness, stop: make(chan struct{})}
+}
+
+// Close stops background refresh, for Config.Close. Keys fetched so far
+// stay in use.
+func (j *remoteJWKS) Close() error {
+	j.stopOnce.Do(func() { close(j.stop) })
+	return nil
+}
+
+// KeySource implements KeySourcer
+func (j *remoteJWKS) KeySource() string { return "jwks" }
+
+// VerificationKey implements KeyProvider
+func (j *remoteJWKS) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	if kid == "" {
+		return nil, fmt.Errorf("token has no kid header: %w", ErrKeyNotFound)
+	}
+
+	snap := j.snapshot.Load()
+	if snap != nil {
+		if key, ok := snap.keys[kid]; ok && time.Since(snap.fetched) < j.refreshInterval {
+			return jwkKeyFor(key, alg)
+		}
+	}
+	if snap == nil || time.Since(snap.fetched) >= min(j.refreshInterval, jwksMinRefreshInterval) {
+		j.mu.Lock()
+		flight := j.joinFlightLocked(ctx)
+		j.mu.Unlock()
+		err := j.await(ctx, flight)
+		snap = j.snapshot.Load()
+		if err != nil {
+			if snap == nil {
+				return nil, err
+			}
+			// Keep serving the previous document, but not indefinitely
+			if age := time.Since(snap.fetched); age > j.maxStaleness {
+				return nil, fmt.Errorf("JWKS from %s is %v old: %w", j.url, age.Round(time.Second), err)
+			}
+		}
+	}
+
+	if key, ok := snap.keys[kid]; ok {
+		return jwkKeyFor(key, alg)
+	}
+	return nil, fmt.Errorf("kid %q not in %s: %w", kid, j.url, ErrKeyNotFound)
+}
+
+// joinFlightLocked returns the in-flight fetch, starting one if none is
+// running, and pulls its deadline in to ctx's deadline if that is earlier
+func (j *remoteJWKS) joinFlightLocked(ctx context.Context) *jwksFlight {
+	flight := j.flight
+	if flight == nil {
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		flight = &jwksFlight{done: make(chan struct{}), cancel: cancel}
+		j.flight = flight
+		go j.runFlight(fetchCtx, flight)
+	}
+	if deadline, ok := ctx.Deadline(); ok && (flight.deadline.IsZero() || deadline.Before(flight.deadline)) {
+		flight.deadline = deadline
+		if flight.timer != nil {
+			flight.timer.Stop()
+		}
+		flight.timer = time.AfterFunc(time.Until(deadline), flight.cancel)
+	}
+	return flight
+}
+
+// runFlight fetches the document, stores it and releases the waiters.
+// Failed refetches are reported once per flight, not once per waiter.
+func (j *remoteJWKS) runFlight(ctx context.Context, flight *jwksFlight) {
+	defer flight.cancel()
+	keys, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	if flight.timer != nil {
+		flight.timer.Stop()
+	}
+	j.flight = nil
+	if err == nil {
+		j.storeKeysLocked(keys)
+	} else if j.snapshot.Load() != nil {
+		j.reportRefreshError(err)
+	}
+	flight.err = err
+	j.mu.Unlock()
+	close(flight.done)
+}
+
+// await waits for flight or until ctx is done
+func (j *remoteJWKS) await(ctx context.Context, flight *jwksFlight) error {
+	select {
+	case <-flight.done:
+		return flight.err
+	case <-ctx.Done():
+		return fmt.Errorf("fetching JWKS: %w", ctx.Err())
+	}
+}
+
+// refresh fetches the document now, sharing an in-flight fetch
+func (j *remoteJWKS) refresh(ctx context.Context) error {
+	j.mu.Lock()
+	flight := j.joinFlightLocked(ctx)
+	j.mu.Unlock()
+	return j.await(ctx, flight)
+}
+
+// jwkKeyFor returns the key of jwk unless its declared alg differs from alg
+func jwkKeyFor(jwk JSONWebKey, alg string) (interface{}, error) {
+	if jwk.Algorithm != "" && jwk.Algorithm != alg {
+		return nil, fmt.Errorf("kid %q is a %s key, token uses %s: %w", jwk.KeyID, jwk.Algorithm, alg, ErrKeyNotFound)
+	}
+	return jwk.Key, nil
+}
+
+// Prefetch implements KeyPrefetcher by fetching the JWKS document
+func (j *remoteJWKS) Prefetch(ctx context.Context) error {
+	return j.refresh(ctx)
+}
+
+// storeKeysLocked replaces the keys with a fetched document, reporting
+// changes to watchers (but not the initial load)
+func (j *remoteJWKS) storeKeysLocked(keys map[string]JSONWebKey) {
+	previous := j.snapshot.Swap(&jwksSnapshot{keys: keys, fetched: time.Now()})
+	if previous != nil {
+		j.notifyKeys(changeSourceJWKSRefresh, jwkLabels(previous.keys), jwkLabels(keys))
+	}
+}
+
+// jwkLabels returns the sorted keyLabel values of a JWKS document
+func jwkLabels(keys map[string]JSONWebKey) []string {
+	plain := make(map[string]interface{}, len(keys))
+	for kid, jwk := range keys {
+		plain[kid] = jwk.Key
+	}
+	return keyLabels(plain)
+}
+
+// refreshEvery refetches the document every interval until ctx is done or
+// the provider is closed. Failures are reported by the fetch itself.
+func (j *remoteJWKS) refreshEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-j.stop:
+			return
+		case <-ticker.C:
+		}
+		j.refresh(ctx)
+	}
+}
+
+// reportRefreshError passes a failed refetch to onRefreshError
+func (j *remoteJWKS) reportRefreshError(err error) {
+	if j.onRefreshError != nil {
+		j.onRefreshError(err)
+	}
+}
+
+// keySnapshot returns the keys of the last fetched document by kid
+func (j *remoteJWKS) keySnapshot() map[string]interface{} {
+	snap := j.snapshot.Load()
+	if snap == nil {
+		return map[string]interface{}{}
+	}
+	keys := make(map[string]interface{}, len(snap.keys))
+	for kid, jwk := range snap.keys {
+		keys[kid] = jwk.Key
+	}
+	return keys
+}
+
+// fetch downloads and parses the JWKS document
+func (j *remoteJWKS) fetch(ctx context.Context) (map[string]JSONWebKey, error) {
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS: %s returned %s: %w", j.url, resp.Status, ErrKeyProviderUnavailable)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, jwksMaxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("fetching JWKS: %w: %w", ErrKeyProviderUnavailable, err)
+	}
+	return parseJWKSet(data)
+}
+
+// parseJWKSet parses a JWKS document into keys by kid. Keys of unsupported
+// types and encryption keys are skipped, as RFC 7517 requires.
+func parseJWKSet(data []byte) (map[string]JSONWebKey, error) {
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]JSONWebKey, len(set.Keys))
+	for _, raw := range set.Keys {
+		jwk, err := ParseJWK(raw)
+		if err != nil || jwk.KeyID == "" || jwk.Use == "enc" {
+			continue
+		}
+		keys[jwk.KeyID] = *jwk
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
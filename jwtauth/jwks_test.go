@@ -0,0 +1,359 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestWithJWKSURL tests verification with RSA, EC and OKP keys from a JWKS endpoint
+func TestWithJWKSURL(t *testing.T) {
+	rsaKey := mustGenerateRSAKey()
+	ecKey := mustGenerateECKey()
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, fetches := serveJWKS(t,
+		JSONWebKey{KeyID: "rsa-1", Algorithm: "RS256", Key: &rsaKey.PublicKey},
+		JSONWebKey{KeyID: "ec-1", Key: &ecKey.PublicKey},
+		JSONWebKey{KeyID: "ed-1", Algorithm: "EdDSA", Key: edPub},
+	)
+	cfg := mustCreateConfig(WithJWKSURL(url, WithJWKSAlgorithms("RS256", "ES256", "EdDSA")))
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"RSA key", sign(jwt.SigningMethodRS256, "rsa-1", rsaKey), false},
+		{"EC key", sign(jwt.SigningMethodES256, "ec-1", ecKey), false},
+		{"OKP key", sign(jwt.SigningMethodEdDSA, "ed-1", edPriv), false},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "rsa-2", rsaKey), true},
+		{"alg differs from JWK alg", sign(jwt.SigningMethodRS256, "ed-1", rsaKey), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseToken(context.Background(), tt.token, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("expected 1 JWKS fetch (unknown kids rate limited), got %d", n)
+	}
+}
+
+// TestWithJWKSURLCacheTTL tests that the document is refetched after the cache TTL
+func TestWithJWKSURLCacheTTL(t *testing.T) {
+	key := mustGenerateRSAKey()
+	url, fetches := serveJWKS(t, JSONWebKey{KeyID: "k1", Algorithm: "RS256", Key: &key.PublicKey})
+	cfg := mustCreateConfig(WithJWKSURL(url, WithJWKSCacheTTL(50*time.Millisecond)))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["kid"] = "k1"
+	signed, _ := token.SignedString(key)
+
+	if _, err := ParseToken(context.Background(), signed, cfg); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err := ParseToken(context.Background(), signed, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("expected 2 JWKS fetches, got %d", n)
+	}
+}
+
+// TestWithJWKSMaxStaleness tests that the last document outlives failed
+// refetches only up to the max staleness
+func TestWithJWKSMaxStaleness(t *testing.T) {
+	key := mustGenerateRSAKey()
+	data, _ := json.Marshal(map[string]interface{}{"keys": []JSONWebKey{{KeyID: "k1", Algorithm: "RS256", Key: &key.PublicKey}}})
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	cfg := mustCreateConfig(WithJWKSURL(srv.URL, WithJWKSCacheTTL(20*time.Millisecond), WithJWKSMaxStaleness(300*time.Millisecond)))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["kid"] = "k1"
+	signed, _ := token.SignedString(key)
+	if _, err := ParseToken(context.Background(), signed, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Past the TTL, failed refetches keep the previous document
+	failing.Store(true)
+	time.Sleep(50 * time.Millisecond)
+	if _, err := ParseToken(context.Background(), signed, cfg); err != nil {
+		t.Errorf("expected the previous document within the max staleness, got %v", err)
+	}
+
+	// Past the max staleness, lookups fail as an outage
+	time.Sleep(300 * time.Millisecond)
+	_, err := ParseToken(context.Background(), signed, cfg)
+	if err == nil {
+		t.Fatal("expected error once the document is too stale")
+	}
+	if !errors.Is(err, ErrKeyProviderUnavailable) {
+		t.Errorf("expected an error wrapping ErrKeyProviderUnavailable, got %v", err)
+	}
+
+	// A successful refetch restores service
+	failing.Store(false)
+	if _, err := ParseToken(context.Background(), signed, cfg); err != nil {
+		t.Errorf("expected validation after recovery, got %v", err)
+	}
+}
+
+// TestWithJWKSURLTimeout tests that a slow endpoint fails within the fetch timeout
+func TestWithJWKSURLTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := mustCreateConfig(WithJWKSURL(srv.URL, WithJWKSHTTPClient(srv.Client()), WithJWKSTimeout(100*time.Millisecond)))
+	key := mustGenerateRSAKey()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["kid"] = "k1"
+	signed, _ := token.SignedString(key)
+
+	start := time.Now()
+	if _, err := ParseToken(context.Background(), signed, cfg); err == nil {
+		t.Fatal("expected error from unreachable JWKS")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("fetch was not bounded by the timeout: took %v", elapsed)
+	}
+}
+
+// TestWithJWKSURLInvalid tests option validation
+func TestWithJWKSURLInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  ConfigOption
+	}{
+		{"plain HTTP to remote host", WithJWKSURL("http://example.com/jwks.json")},
+		{"no host", WithJWKSURL("https:///jwks.json")},
+		{"symmetric algorithm", WithJWKSURL("https://example.com/jwks.json", WithJWKSAlgorithms("HS256"))},
+		{"no algorithms", WithJWKSURL("https://example.com/jwks.json", WithJWKSAlgorithms())},
+		{"non-positive timeout", WithJWKSURL("https://example.com/jwks.json", WithJWKSTimeout(0))},
+		{"non-positive TTL", WithJWKSURL("https://example.com/jwks.json", WithJWKSCacheTTL(-time.Second))},
+		{"max staleness below TTL", WithJWKSURL("https://example.com/jwks.json", WithJWKSMaxStaleness(time.Minute))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConfig(tt.opt); err == nil {
+				t.Error("expected configuration error")
+			}
+		})
+	}
+}
+
+// TestWithJWKSBackgroundRefresh tests that rotated keys are picked up in the
+// background and refresh failures are reported
+func TestWithJWKSBackgroundRefresh(t *testing.T) {
+	oldKey, newKey := mustGenerateRSAKey(), mustGenerateRSAKey()
+	var (
+		doc     atomic.Value
+		failing atomic.Bool
+	)
+	setKeys := func(keys ...JSONWebKey) {
+		data, err := json.Marshal(map[string]interface{}{"keys": keys})
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.Store(data)
+	}
+	setKeys(JSONWebKey{KeyID: "old", Algorithm: "RS256", Key: &oldKey.PublicKey})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(doc.Load().([]byte))
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	refreshErrs := make(chan error, 16)
+	cfg := mustCreateConfig(WithJWKSURL(srv.URL,
+		WithJWKSBackgroundRefresh(ctx, 20*time.Millisecond),
+		WithJWKSRefreshErrorHandler(func(url string, err error) {
+			select {
+			case refreshErrs <- err:
+			default:
+			}
+		}),
+	), WithKeyPrefetch(time.Second))
+
+	sign := func(kid string, key interface{}) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+		token.Header["kid"] = kid
+		signed, _ := token.SignedString(key)
+		return signed
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Rotate: the new key is added and the old one removed
+	setKeys(JSONWebKey{KeyID: "new", Algorithm: "RS256", Key: &newKey.PublicKey})
+	waitFor("old key removal", func() bool {
+		_, err := ParseToken(context.Background(), sign("old", oldKey), cfg)
+		return err != nil
+	})
+	if _, err := ParseToken(context.Background(), sign("new", newKey), cfg); err != nil {
+		t.Errorf("expected the rotated key to verify, got %v", err)
+	}
+
+	// Failing refreshes are reported and keep the current keys
+	failing.Store(true)
+	select {
+	case <-refreshErrs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a refresh error to be reported")
+	}
+	if _, err := ParseToken(context.Background(), sign("new", newKey), cfg); err != nil {
+		t.Errorf("expected the last keys to stay in use, got %v", err)
+	}
+}
+
+// TestJWKSThunderingHerd tests that concurrent unknown-kid lookups after a
+// key rotation share a single fetch
+func TestJWKSThunderingHerd(t *testing.T) {
+	oldKey, newKey := mustGenerateRSAKey(), mustGenerateRSAKey()
+	var doc atomic.Value
+	doc.Store([]JSONWebKey{{KeyID: "old", Algorithm: "RS256", Key: &oldKey.PublicKey}})
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		time.Sleep(50 * time.Millisecond) // Keep the fetch in flight while the herd arrives
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": doc.Load()})
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := mustCreateConfig(WithJWKSURL(srv.URL, WithJWKSCacheTTL(20*time.Millisecond)), WithKeyPrefetch(time.Second))
+	doc.Store([]JSONWebKey{
+		{KeyID: "old", Algorithm: "RS256", Key: &oldKey.PublicKey},
+		{KeyID: "new", Algorithm: "RS256", Key: &newKey.PublicKey},
+	})
+	time.Sleep(30 * time.Millisecond)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["kid"] = "new"
+	signed, _ := token.SignedString(newKey)
+
+	const callers = 50
+	errs := make(chan error, callers)
+	start := make(chan struct{})
+	for i := 0; i < callers; i++ {
+		go func() {
+			<-start
+			_, err := ParseToken(context.Background(), signed, cfg)
+			errs <- err
+		}()
+	}
+	close(start)
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("caller %d: %v", i, err)
+		}
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("expected the prefetch and 1 shared refetch, got %d fetches", n)
+	}
+}
+
+// TestJWKSFetchCallerDeadline tests that a shared fetch ends at the shortest
+// caller deadline and that callers stop waiting at their own deadline
+func TestJWKSFetchCallerDeadline(t *testing.T) {
+	canceled := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			canceled <- struct{}{}
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := mustCreateConfig(WithJWKSURL(srv.URL, WithJWKSHTTPClient(srv.Client())))
+	key := mustGenerateRSAKey()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["kid"] = "k1"
+	signed, _ := token.SignedString(key)
+
+	// A caller without a deadline starts the fetch; a caller with a short
+	// deadline joins it
+	patient := make(chan error, 1)
+	go func() {
+		_, err := ParseToken(context.Background(), signed, cfg)
+		patient <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	if _, err := ParseToken(ctx, signed, cfg); err == nil {
+		t.Fatal("expected the deadline to fail the lookup")
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("caller was not released at its deadline: took %v", elapsed)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("expected the shared fetch to be canceled at the shortest deadline")
+	}
+	select {
+	case err := <-patient:
+		if err == nil {
+			t.Error("expected the canceled fetch to fail the other caller")
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the other caller to receive the shared result")
+	}
+}
@@ -0,0 +1,226 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keyFileOptions holds settings for WithKeyFile
+type keyFileOptions struct {
+	reloadInterval time.Duration
+	onReloadError  func(path string, err error)
+}
+
+// KeyFileOption configures WithKeyFile
+type KeyFileOption func(*keyFileOptions)
+
+// WithKeyFileReload re-checks the key file for changes at most once per
+// interval and swaps in the new key when its modification time or size
+// changed. The check runs on the first validation after the interval has
+// passed, so a rotated key is in use for the first token after that. A file
+// that fails to load keeps the previous key in use.
+func WithKeyFileReload(interval time.Duration) KeyFileOption {
+	return func(o *keyFileOptions) {
+		o.reloadInterval = interval
+	}
+}
+
+// WithKeyFileErrorHandler sets a callback for failed reloads; fn must not
+// block. Failures are also logged at warn level when a logger is configured.
+func WithKeyFileErrorHandler(fn func(path string, err error)) KeyFileOption {
+	return func(o *keyFileOptions) {
+		o.onReloadError = fn
+	}
+}
+
+// WithKeyFile configures alg with a verification key read from path while
+// NewConfig runs: a PEM public key (PKIX "PUBLIC KEY", PKCS#1 "RSA PUBLIC
+// KEY" or the key of a "CERTIFICATE") for asymmetric algorithms, or the raw
+// secret (trailing newline removed) for HS256/384/512. Keys are checked like
+// the single-key options. With WithKeyFileReload the file is watched by
+// polling, so keys can be rotated without a restart:
+//
+//	jwtauth.WithKeyFile("RS256", "/etc/jwt/public.pem", jwtauth.WithKeyFileReload(30*time.Second))
+func WithKeyFile(alg, path string, opts ...KeyFileOption) ConfigOption {
+	return func(c *Config) error {
+		var o keyFileOptions
+		for _, opt := range opts {
+			opt(&o)
+		}
+		if o.reloadInterval < 0 {
+			return fmt.Errorf("key file reload interval cannot be negative")
+		}
+
+		state, err := loadKeyFile(alg, path)
+		if err != nil {
+			return err
+		}
+		if o.reloadInterval == 0 {
+			c.validators[alg] = state.validator
+			return nil
+		}
+
+		p := &fileKey{alg: alg, path: path, every: o.reloadInterval}
+		p.onReloadError = func(err error) {
+			if logger := c.Logger(); logger != nil {
+				logger.Warn("key file reload failed", "path", path, "error", err)
+			}
+			if o.onReloadError != nil {
+				o.onReloadError(path, err)
+			}
+		}
+		state.next = time.Now().Add(o.reloadInterval)
+		p.current.Store(state)
+		c.validators[alg] = algorithmValidator{signingMethod: state.validator.signingMethod, keyProvider: p}
+		return nil
+	}
+}
+
+// WithRS256File is WithKeyFile for RS256
+func WithRS256File(path string, opts ...KeyFileOption) ConfigOption {
+	return WithKeyFile("RS256", path, opts...)
+}
+
+// WithPS256File is WithKeyFile for PS256
+func WithPS256File(path string, opts ...KeyFileOption) ConfigOption {
+	return WithKeyFile("PS256", path, opts...)
+}
+
+// WithES256File is WithKeyFile for ES256
+func WithES256File(path string, opts ...KeyFileOption) ConfigOption {
+	return WithKeyFile("ES256", path, opts...)
+}
+
+// WithEdDSAFile is WithKeyFile for EdDSA
+func WithEdDSAFile(path string, opts ...KeyFileOption) ConfigOption {
+	return WithKeyFile("EdDSA", path, opts...)
+}
+
+// WithHS256File is WithKeyFile for HS256 with a secret file
+func WithHS256File(path string, opts ...KeyFileOption) ConfigOption {
+	return WithKeyFile("HS256", path, opts...)
+}
+
+// fileKey is the KeyProvider behind WithKeyFileReload
+type fileKey struct {
+	keyWatchers
+
+	alg           string
+	path          string
+	every         time.Duration
+	onReloadError func(error)
+
+	current atomic.Pointer[fileKeyState]
+	mu      sync.Mutex // Serializes reloads
+}
+
+// fileKeyState is a loaded key file
+type fileKeyState struct {
+	validator algorithmValidator
+	modTime   time.Time
+	size      int64
+	next      time.Time // Next check for changes
+}
+
+// KeySource implements KeySourcer
+func (p *fileKey) KeySource() string { return "file" }
+
+// VerificationKey implements KeyProvider. It returns the current key,
+// reloading the file first when the check is due and the file changed.
+func (p *fileKey) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	state := p.current.Load()
+	if time.Now().After(state.next) && p.mu.TryLock() {
+		state = p.reload()
+		p.mu.Unlock()
+	}
+	return state.validator.signingKey, nil
+}
+
+// reload swaps in the key file if it changed, keeping the previous key when
+// it cannot be loaded
+func (p *fileKey) reload() *fileKeyState {
+	previous := p.current.Load()
+	next := time.Now().Add(p.every)
+
+	info, err := os.Stat(p.path)
+	if err == nil && info.ModTime().Equal(previous.modTime) && info.Size() == previous.size {
+		unchanged := *previous
+		unchanged.next = next
+		p.current.Store(&unchanged)
+		return &unchanged
+	}
+
+	state, loadErr := loadKeyFile(p.alg, p.path)
+	if err != nil || loadErr != nil {
+		if loadErr == nil {
+			loadErr = err
+		}
+		if p.onReloadError != nil {
+			p.onReloadError(loadErr)
+		}
+		stale := *previous
+		stale.next = next
+		p.current.Store(&stale)
+		return &stale
+	}
+	state.next = next
+	p.current.Store(state)
+	p.notifyKeys(changeSourceFileReload, []string{keyLabel("", previous.validator.signingKey)}, []string{keyLabel("", state.validator.signingKey)})
+	return state
+}
+
+// loadKeyFile reads and validates the key in path for alg
+func loadKeyFile(alg, path string) (*fileKeyState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s key file: %w", alg, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s key file: %w", alg, err)
+	}
+
+	var key interface{}
+	if _, ok := jwt.GetSigningMethod(alg).(*jwt.SigningMethodHMAC); ok {
+		key = bytes.TrimRight(data, "\r\n")
+	} else if key, err = parsePublicKeyPEM(data); err != nil {
+		return nil, fmt.Errorf("%s key file %s: %w", alg, path, err)
+	}
+
+	validator, err := singleKeyValidator(alg, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s key file %s: %w", alg, path, err)
+	}
+	return &fileKeyState{validator: validator, modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// parsePublicKeyPEM parses a PKIX or PKCS#1 public key, or the public key of
+// a certificate, from the first PEM block of data
+func parsePublicKeyPEM(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unsupported PEM block type %q (want PUBLIC KEY, RSA PUBLIC KEY or CERTIFICATE)", block.Type)
+}
@@ -0,0 +1,122 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writePublicKeyPEM writes key as a PKIX PEM file
+func writePublicKeyPEM(t *testing.T, path string, key interface{}) {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWithKeyFile tests loading keys from PEM and secret files
+func TestWithKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, ecKey := mustGenerateRSAKey(), mustGenerateECKey()
+	writePublicKeyPEM(t, filepath.Join(dir, "rsa.pem"), &rsaKey.PublicKey)
+	writePublicKeyPEM(t, filepath.Join(dir, "ec.pem"), &ecKey.PublicKey)
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	if err := os.WriteFile(filepath.Join(dir, "secret"), append(secret, '\n'), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := mustCreateConfig(
+		WithRS256File(filepath.Join(dir, "rsa.pem")),
+		WithES256File(filepath.Join(dir, "ec.pem")),
+		WithHS256File(filepath.Join(dir, "secret")),
+	)
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	for method, key := range map[jwt.SigningMethod]interface{}{
+		jwt.SigningMethodRS256: rsaKey,
+		jwt.SigningMethodES256: ecKey,
+		jwt.SigningMethodHS256: secret,
+	} {
+		token, _ := jwt.NewWithClaims(method, claims).SignedString(key)
+		if _, err := ParseToken(context.Background(), token, cfg); err != nil {
+			t.Errorf("%s: %v", method.Alg(), err)
+		}
+	}
+}
+
+// TestWithKeyFileInvalid tests rejected key files
+func TestWithKeyFileInvalid(t *testing.T) {
+	dir := t.TempDir()
+	writePublicKeyPEM(t, filepath.Join(dir, "ec.pem"), &mustGenerateECKey().PublicKey)
+	os.WriteFile(filepath.Join(dir, "short"), []byte("too-short"), 0o600)
+	os.WriteFile(filepath.Join(dir, "garbage.pem"), []byte("not a key"), 0o600)
+
+	tests := []struct {
+		name string
+		opt  ConfigOption
+	}{
+		{"missing file", WithRS256File(filepath.Join(dir, "missing.pem"))},
+		{"key type mismatch", WithRS256File(filepath.Join(dir, "ec.pem"))},
+		{"short secret", WithHS256File(filepath.Join(dir, "short"))},
+		{"not PEM", WithES256File(filepath.Join(dir, "garbage.pem"))},
+		{"negative interval", WithES256File(filepath.Join(dir, "ec.pem"), WithKeyFileReload(-time.Second))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConfig(tt.opt); err == nil {
+				t.Error("expected configuration error")
+			}
+		})
+	}
+}
+
+// TestWithKeyFileReload tests swapping keys when the file changes
+func TestWithKeyFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "public.pem")
+	oldKey, newKey := mustGenerateECKey(), mustGenerateECKey()
+	writePublicKeyPEM(t, path, &oldKey.PublicKey)
+
+	reloadErrs := make(chan error, 4)
+	cfg := mustCreateConfig(WithES256File(path,
+		WithKeyFileReload(10*time.Millisecond),
+		WithKeyFileErrorHandler(func(path string, err error) { reloadErrs <- err }),
+	))
+	sign := func(key interface{}) string {
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}).SignedString(key)
+		return token
+	}
+	if _, err := ParseToken(context.Background(), sign(oldKey), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	writePublicKeyPEM(t, path, &newKey.PublicKey)
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second)) // Ensure a new mtime on coarse filesystems
+	time.Sleep(20 * time.Millisecond)
+	if _, err := ParseToken(context.Background(), sign(newKey), cfg); err != nil {
+		t.Fatalf("expected the new key after reload, got %v", err)
+	}
+	if _, err := ParseToken(context.Background(), sign(oldKey), cfg); err == nil {
+		t.Error("expected the old key to be replaced")
+	}
+
+	// A broken file keeps the current key
+	os.WriteFile(path, []byte("truncated"), 0o600)
+	time.Sleep(20 * time.Millisecond)
+	if _, err := ParseToken(context.Background(), sign(newKey), cfg); err != nil {
+		t.Errorf("expected the previous key to stay in use, got %v", err)
+	}
+	select {
+	case <-reloadErrs:
+	default:
+		t.Error("expected the failed reload to be reported")
+	}
+}
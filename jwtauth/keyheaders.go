@@ -0,0 +1,34 @@
+package jwtauth
+
+import (
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// embeddedKeyHeaders are JOSE header parameters through which a token's
+// sender supplies its own verification key (jwk) or a URL to fetch one from
+// (jku, x5u). The middleware never uses them, but their presence signals a
+// key injection attempt against verifiers that do.
+var embeddedKeyHeaders = []string{"jwk", "jku", "x5u"}
+
+// WithEmbeddedKeyRejection rejects tokens carrying a jwk, jku or x5u header
+// with EMBEDDED_KEY_HEADER before any key is resolved, so key injection
+// attempts are refused and reported instead of silently ignored. Enabled by
+// WithStrictProfile.
+func WithEmbeddedKeyRejection() ConfigOption {
+	return func(c *Config) error {
+		c.rejectEmbeddedKeys = true
+		return nil
+	}
+}
+
+// checkEmbeddedKeyHeaders rejects tokens carrying sender-supplied keys
+func checkEmbeddedKeyHeaders(token *jwt.Token) error {
+	for _, name := range embeddedKeyHeaders {
+		if _, ok := token.Header[name]; ok {
+			return NewValidationError(ErrEmbeddedKeyHeader, fmt.Sprintf("token header %s is not accepted", name), nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestWithEmbeddedKeyRejection tests rejecting tokens with sender-supplied key headers
+func TestWithEmbeddedKeyRejection(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	sign := func(header string, value interface{}) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+		if header != "" {
+			token.Header[header] = value
+		}
+		signed, _ := token.SignedString(secret)
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  ErrorCode
+	}{
+		{"no key header", sign("", nil), ""},
+		{"kid is fine", sign("kid", "key-1"), ""},
+		{"embedded jwk", sign("jwk", map[string]interface{}{"kty": "oct", "k": "c2VjcmV0"}), ErrEmbeddedKeyHeader},
+		{"jku URL", sign("jku", "https://evil.example.com/jwks.json"), ErrEmbeddedKeyHeader},
+		{"x5u URL", sign("x5u", "https://evil.example.com/cert.pem"), ErrEmbeddedKeyHeader},
+	}
+	for _, cfg := range []*Config{
+		mustCreateConfig(WithHS256(secret), WithEmbeddedKeyRejection()),
+		mustCreateConfig(WithHS256(secret), WithStrictProfile()),
+	} {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := ParseToken(context.Background(), tt.token, cfg)
+				var valErr *ValidationError
+				if tt.want == "" && err != nil {
+					t.Errorf("expected success, got %v", err)
+				} else if tt.want != "" && (!errors.As(err, &valErr) || valErr.Code != tt.want) {
+					t.Errorf("expected %s, got %v", tt.want, err)
+				}
+			})
+		}
+	}
+
+	// Without the option the headers are ignored
+	if _, err := ParseToken(context.Background(), sign("x5u", "https://evil.example.com/cert.pem"), mustCreateConfig(WithHS256(secret))); err != nil {
+		t.Errorf("expected x5u to be ignored by default, got %v", err)
+	}
+}
+
+// TestWithStrictProfile tests the settings enabled by the strict profile
+func TestWithStrictProfile(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithStrictProfile())
+	if cfg.DuplicateHeaderPolicy() != DuplicateHeaderStrict {
+		t.Errorf("expected DuplicateHeaderStrict, got %v", cfg.DuplicateHeaderPolicy())
+	}
+
+	router := createTestRouter(cfg)
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Add("Authorization", "Bearer "+mustSignHS256(secret, jwt.MapClaims{"sub": "a", "exp": time.Now().Add(time.Hour).Unix()}))
+	req.Header.Add("Authorization", "Bearer "+mustSignHS256(secret, jwt.MapClaims{"sub": "b", "exp": time.Now().Add(time.Hour).Unix()}))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != 401 {
+		t.Errorf("expected ambiguous Authorization values to be rejected, got %d", w.Code)
+	}
+
+	relaxed := mustCreateConfig(WithHS256(secret), WithStrictProfile(), WithDuplicateHeaderPolicy(DuplicateHeaderFirst))
+	if relaxed.DuplicateHeaderPolicy() != DuplicateHeaderFirst || !relaxed.rejectEmbeddedKeys {
+		t.Error("expected later options to relax only their own setting")
+	}
+}
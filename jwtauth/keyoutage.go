@@ -0,0 +1,223 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyOutagePolicy selects how validation behaves when a key provider (JWKS
+// endpoint, KMS, secret manager) fails
+type KeyOutagePolicy int
+
+const (
+	// KeyOutageFailClosed rejects tokens with KEY_UNAVAILABLE (default)
+	KeyOutageFailClosed KeyOutagePolicy = iota
+	// KeyOutageKnownKeys verifies tokens whose kid was resolved within the
+	// grace window with the last key served for it, and fails closed otherwise
+	KeyOutageKnownKeys
+	// KeyOutageFailOpen behaves like KeyOutageKnownKeys but accepts tokens
+	// with unknown kids without verifying their signature. Claims are still
+	// validated. Only for services where availability outweighs the risk of
+	// forged tokens during an outage.
+	KeyOutageFailOpen
+)
+
+// String returns the policy name used in security events
+func (p KeyOutagePolicy) String() string {
+	switch p {
+	case KeyOutageKnownKeys:
+		return "known_keys"
+	case KeyOutageFailOpen:
+		return "fail_open"
+	default:
+		return "fail_closed"
+	}
+}
+
+// KeyOutage configures WithKeyOutagePolicy
+type KeyOutage struct {
+	Policy KeyOutagePolicy
+	Grace  time.Duration // How long after its last successful lookup a key stays usable (default 1h)
+}
+
+// defaultKeyOutageGrace is the default KeyOutage.Grace
+const defaultKeyOutageGrace = time.Hour
+
+// WithKeyOutagePolicy sets how tokens are handled while a key provider is
+// failing. Tokens accepted in degraded mode are flagged (IsDegraded), never
+// cached, and every degraded decision is logged at error level as a
+// "key_outage" security event. Only errors wrapping ErrKeyProviderUnavailable,
+// network errors and timeouts are outages; a provider answering that a kid is
+// unknown (ErrKeyNotFound) or failing any other way rejects the token.
+func WithKeyOutagePolicy(o KeyOutage) ConfigOption {
+	return func(c *Config) error {
+		if o.Policy < KeyOutageFailClosed || o.Policy > KeyOutageFailOpen {
+			return fmt.Errorf("invalid key outage policy %d", o.Policy)
+		}
+		if o.Grace < 0 {
+			return fmt.Errorf("key outage grace must be non-negative, got %v", o.Grace)
+		}
+		if o.Grace == 0 {
+			o.Grace = defaultKeyOutageGrace
+		}
+		c.keyOutage = &keyOutageState{policy: o.Policy, grace: o.Grace}
+		return nil
+	}
+}
+
+// IsDegraded reports whether the request was authenticated in degraded mode
+// under WithKeyOutagePolicy: with a stale key or, under KeyOutageFailOpen,
+// without signature verification. Handlers may refuse sensitive operations.
+func IsDegraded(ctx context.Context) bool {
+	claims, ok := GetClaims(ctx)
+	return ok && claims.degraded
+}
+
+// keyOutageState remembers keys served by providers for use during outages
+type keyOutageState struct {
+	policy KeyOutagePolicy
+	grace  time.Duration
+	known  sync.Map // alg + "\x00" + kid -> *knownKey
+}
+
+// knownKey is a key last served by a provider
+type knownKey struct {
+	key  interface{}
+	seen time.Time
+}
+
+// errKeyOutageFailOpen makes the parser give up so the token can be
+// accepted unverified under KeyOutageFailOpen
+var errKeyOutageFailOpen = errors.New("key outage: accepting unverified token")
+
+// outageMarkContextKey carries the *outageMark of one validation
+type outageMarkContextKey struct{}
+
+// outageMark records that a validation used degraded mode
+type outageMark struct {
+	degraded     bool
+	verifiedOnly bool // Content without claims (detached JWS) never fails open
+}
+
+// remember records a key served by a provider. Entries are refreshed at
+// most once a second to keep the hot path free of writes.
+func (s *keyOutageState) remember(alg, kid string, key interface{}) {
+	id := alg + "\x00" + kid
+	now := time.Now()
+	if v, ok := s.known.Load(id); ok && now.Sub(v.(*knownKey).seen) < time.Second {
+		return
+	}
+	s.known.Store(id, &knownKey{key: key, seen: now})
+}
+
+// recall returns the last key served for alg and kid within the grace window
+func (s *keyOutageState) recall(alg, kid string) (interface{}, bool) {
+	v, ok := s.known.Load(alg + "\x00" + kid)
+	if !ok || time.Since(v.(*knownKey).seen) > s.grace {
+		return nil, false
+	}
+	return v.(*knownKey).key, true
+}
+
+// resolveKeyWithOutagePolicy resolves the verification key, applying the
+// outage policy when the provider fails
+func resolveKeyWithOutagePolicy(ctx context.Context, cfg *Config, validator algorithmValidator, alg, kid string) (interface{}, error) {
+	var key interface{}
+	var err error
+	if validator.keyProvider != nil && faultKeyOutage(ctx) {
+		err = NewValidationError(ErrKeyUnavailable, fmt.Sprintf("verification key for %s unavailable", alg), ErrFaultInjected)
+	} else {
+		key, err = validator.resolveKey(ctx, alg, kid)
+	}
+	state := cfg.keyOutage
+	if state == nil || validator.keyProvider == nil {
+		return key, err
+	}
+	if err == nil {
+		state.remember(alg, kid, key)
+		return key, nil
+	}
+
+	var valErr *ValidationError
+	if !errors.As(err, &valErr) || !isKeySourceOutage(valErr.Internal) {
+		return nil, err
+	}
+
+	action := "fail_closed"
+	mark, _ := ctx.Value(outageMarkContextKey{}).(*outageMark)
+	if state.policy != KeyOutageFailClosed && mark != nil {
+		if known, ok := state.recall(alg, kid); ok {
+			action, key = "known_key", known
+		} else if state.policy == KeyOutageFailOpen && !mark.verifiedOnly {
+			action = "fail_open"
+		}
+	}
+	logKeyOutage(ctx, cfg, alg, kid, action, valErr)
+
+	switch action {
+	case "known_key":
+		mark.degraded = true
+		return key, nil
+	case "fail_open":
+		mark.degraded = true
+		return nil, errKeyOutageFailOpen
+	}
+	return nil, err
+}
+
+// isKeySourceOutage reports whether a provider error positively signals that
+// the key source is unavailable. Anything else, such as an unknown kid or a
+// key of the wrong type, must not be mistaken for an outage: under
+// KeyOutageFailOpen that would accept forged tokens.
+func isKeySourceOutage(err error) bool {
+	if err == nil || errors.Is(err, ErrKeyNotFound) {
+		return false
+	}
+	if errors.Is(err, ErrKeyProviderUnavailable) || errors.Is(err, ErrFaultInjected) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// parseUnverified parses a token accepted under KeyOutageFailOpen, applying
+// the registered claim checks the parser would have applied
+func parseUnverified(tokenString string) (*jwt.Token, error) {
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return token, err
+	}
+	if err := jwt.NewValidator().Validate(token.Claims); err != nil {
+		return token, err
+	}
+	token.Valid = true
+	return token, nil
+}
+
+// logKeyOutage emits a key_outage security event at error level
+func logKeyOutage(ctx context.Context, cfg *Config, alg, kid, action string, err *ValidationError) {
+	if cfg.Logger() == nil {
+		return
+	}
+	clientIP, _ := GetClientIP(ctx)
+	cfg.Logger().Error("verification key source unavailable",
+		"auth_event", SecurityEvent{
+			EventType:     "key_outage",
+			Timestamp:     time.Now(),
+			ClientIP:      clientIP,
+			Algorithm:     alg,
+			FailureReason: string(err.Code),
+			FailureCause:  err.Cause(),
+		},
+		"kid", kid,
+		"policy", cfg.keyOutage.policy.String(),
+		"action", action,
+		"error", err.Internal,
+	)
+}
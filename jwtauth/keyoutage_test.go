@@ -0,0 +1,142 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestKeyOutagePolicy tests token handling while a key provider fails
+func TestKeyOutagePolicy(t *testing.T) {
+	key := mustGenerateECKey()
+	forger := mustGenerateECKey()
+	var down atomic.Bool
+	provider := KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		if down.Load() {
+			return nil, fmt.Errorf("jwks endpoint: %w", ErrKeyProviderUnavailable)
+		}
+		if kid != "k1" && kid != "k2" {
+			return nil, ErrKeyNotFound
+		}
+		return &key.PublicKey, nil
+	})
+
+	sign := func(k interface{}, kid string, exp time.Time) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "user123", "exp": exp.Unix()})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	valid := time.Now().Add(time.Hour)
+	expired := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name         string
+		policy       KeyOutagePolicy
+		token        string
+		wantCode     string
+		wantDegraded bool
+	}{
+		{"fail closed", KeyOutageFailClosed, sign(key, "k1", valid), "KEY_UNAVAILABLE", false},
+		{"known kid", KeyOutageKnownKeys, sign(key, "k1", valid), "", true},
+		{"known kid forged", KeyOutageKnownKeys, sign(forger, "k1", valid), "INVALID_SIGNATURE", false},
+		{"unseen kid", KeyOutageKnownKeys, sign(key, "k2", valid), "KEY_UNAVAILABLE", false},
+		{"fail open unseen kid", KeyOutageFailOpen, sign(forger, "k2", valid), "", true},
+		{"fail open expired", KeyOutageFailOpen, sign(forger, "k2", expired), "EXPIRED", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			cfg := mustCreateConfig(
+				WithKeyProvider("ES256", provider),
+				WithKeyOutagePolicy(KeyOutage{Policy: tt.policy}),
+				WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+			)
+
+			// Resolve k1 while the provider is healthy
+			down.Store(false)
+			if _, err := ParseToken(context.Background(), sign(key, "k1", valid), cfg); err != nil {
+				t.Fatalf("Expected token to validate before the outage, got %v", err)
+			}
+
+			down.Store(true)
+			claims, err := ParseToken(context.Background(), tt.token, cfg)
+			if tt.wantCode != "" {
+				if getErrorCode(err) != tt.wantCode {
+					t.Fatalf("Expected %s, got %v", tt.wantCode, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected token to be accepted, got %v", err)
+			}
+			if err == nil && IsDegraded(WithClaims(context.Background(), claims)) != tt.wantDegraded {
+				t.Errorf("Expected degraded=%t", tt.wantDegraded)
+			}
+			if !strings.Contains(logs.String(), `"level":"ERROR","msg":"verification key source unavailable"`) {
+				t.Errorf("Expected a key_outage event, got %s", logs.String())
+			}
+		})
+	}
+
+	t.Run("unknown kid is not an outage", func(t *testing.T) {
+		down.Store(false)
+		cfg := mustCreateConfig(WithKeyProvider("ES256", provider), WithKeyOutagePolicy(KeyOutage{Policy: KeyOutageFailOpen}))
+		if _, err := ParseToken(context.Background(), sign(forger, "k3", valid), cfg); getErrorCode(err) != "KEY_UNAVAILABLE" {
+			t.Errorf("Expected KEY_UNAVAILABLE, got %v", err)
+		}
+	})
+
+	// Only a positive unavailability signal is an outage; other provider
+	// errors reject the token even under KeyOutageFailOpen
+	for name, providerErr := range map[string]error{
+		"provider error":    errors.New("pkcs11: unknown key id \"forged\""),
+		"wrapped not found": fmt.Errorf("%w: %w", ErrKeyProviderUnavailable, ErrKeyNotFound),
+	} {
+		t.Run(name, func(t *testing.T) {
+			failing := KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+				return nil, providerErr
+			})
+			cfg := mustCreateConfig(WithKeyProvider("ES256", failing), WithKeyOutagePolicy(KeyOutage{Policy: KeyOutageFailOpen}))
+			if _, err := ParseToken(context.Background(), sign(forger, "forged", valid), cfg); getErrorCode(err) != "KEY_UNAVAILABLE" {
+				t.Errorf("Expected KEY_UNAVAILABLE, got %v", err)
+			}
+		})
+	}
+	for name, providerErr := range map[string]error{
+		"network error": &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+		"timeout":       fmt.Errorf("kms: %w", context.DeadlineExceeded),
+	} {
+		if !isKeySourceOutage(providerErr) {
+			t.Errorf("%s: expected an outage", name)
+		}
+	}
+
+	t.Run("grace window", func(t *testing.T) {
+		down.Store(false)
+		cfg := mustCreateConfig(WithKeyProvider("ES256", provider), WithKeyOutagePolicy(KeyOutage{Policy: KeyOutageKnownKeys, Grace: time.Millisecond}))
+		token := sign(key, "k1", valid)
+		if _, err := ParseToken(context.Background(), token, cfg); err != nil {
+			t.Fatal(err)
+		}
+		down.Store(true)
+		time.Sleep(5 * time.Millisecond)
+		if _, err := ParseToken(context.Background(), token, cfg); getErrorCode(err) != "KEY_UNAVAILABLE" {
+			t.Errorf("Expected KEY_UNAVAILABLE after the grace window, got %v", err)
+		}
+	})
+
+	if _, err := NewConfig(WithHS256([]byte("test-secret-key-min-32-bytes-long!!")), WithKeyOutagePolicy(KeyOutage{Policy: 7})); err == nil {
+		t.Error("Expected unknown policy to be rejected")
+	}
+}
@@ -0,0 +1,267 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KeyProvider resolves verification keys from an external key source
+// (HSMs, cloud KMS, JWKS endpoints, static keys). Implementations must be
+// safe for concurrent use.
+type KeyProvider interface {
+	// VerificationKey returns the key used to verify a token signed with alg.
+	// kid is the token's "kid" header and may be empty.
+	VerificationKey(ctx context.Context, alg, kid string) (interface{}, error)
+}
+
+// KeyProviderFunc adapts a function to the KeyProvider interface
+type KeyProviderFunc func(ctx context.Context, alg, kid string) (interface{}, error)
+
+// VerificationKey implements KeyProvider
+func (f KeyProviderFunc) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	return f(ctx, alg, kid)
+}
+
+// StaticKeyProvider returns a KeyProvider that always returns the given key
+func StaticKeyProvider(key interface{}) KeyProvider {
+	return KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		return key, nil
+	})
+}
+
+// ErrKeyNotFound is returned by key providers when no key matches the
+// requested alg/kid. Cached providers remember it briefly so tokens with
+// unknown kids cannot force a remote lookup on every request.
+var ErrKeyNotFound = errors.New("jwtauth: verification key not found")
+
+// ErrKeyProviderUnavailable is wrapped by key providers whose key source
+// cannot be reached or answers with a server error. WithKeyOutagePolicy
+// only treats such errors, network errors and timeouts as outages; any
+// other provider error rejects the token.
+var ErrKeyProviderUnavailable = errors.New("jwtauth: key provider unavailable")
+
+// cachingKeyProvider memoizes keys from another provider per (alg, kid)
+type cachingKeyProvider struct {
+	provider    KeyProvider
+	cache       Cache
+	local       Cache  // Holds HMAC secrets, which never leave the process
+	secret      []byte // Authenticates entries in cache
+	ttl         time.Duration
+	negativeTTL time.Duration
+	decoded     sync.Map // cacheKey -> decodedKey, avoids re-parsing DER on every hit
+}
+
+// decodedKey is a parsed key with the cached bytes it was parsed from
+type decodedKey struct {
+	data []byte
+	key  interface{}
+}
+
+// CachedKeyProvider wraps provider so resolved keys are reused for ttl,
+// avoiding a remote key source round trip on every validation
+func CachedKeyProvider(provider KeyProvider, ttl time.Duration) KeyProvider {
+	return NewCachedKeyProvider(provider, NewMemoryCache(0), ttl, nil)
+}
+
+// NewCachedKeyProvider is CachedKeyProvider with keys stored in cache, e.g.
+// a Redis cache shared by replicas. Public keys are stored in PKIX DER form,
+// authenticated with HMAC-SHA256 under secret, which every replica sharing
+// the cache must use; entries that fail the check are treated as misses.
+// HMAC secrets are only kept in a per-process memory cache. ErrKeyNotFound
+// results are cached for up to 30 seconds.
+//
+// secret may be nil for a NewMemoryCache; for any other cache it must be at
+// least 32 bytes, and NewCachedKeyProvider panics otherwise.
+func NewCachedKeyProvider(provider KeyProvider, cache Cache, ttl time.Duration, secret []byte) KeyProvider {
+	local := cache
+	if !isInProcessCache(cache) {
+		if len(secret) < 32 {
+			panic("jwtauth: NewCachedKeyProvider requires a secret of at least 32 bytes for a shared cache")
+		}
+		local = NewMemoryCache(0)
+	}
+	if secret == nil {
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+	negativeTTL := 30 * time.Second
+	if ttl < negativeTTL {
+		negativeTTL = ttl
+	}
+	return &cachingKeyProvider{
+		provider:    provider,
+		cache:       cache,
+		local:       local,
+		secret:      bytes.Clone(secret),
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+// KeySource implements KeySourcer with the source of the wrapped provider
+func (p *cachingKeyProvider) KeySource() string {
+	return algorithmValidator{keyProvider: p.provider}.keySource()
+}
+
+// VerificationKey implements KeyProvider
+func (p *cachingKeyProvider) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	if p.ttl <= 0 {
+		return p.provider.VerificationKey(ctx, alg, kid)
+	}
+	cacheKey := "jwtauth:key:" + alg + "|" + kid
+
+	if key, found, err := p.cached(ctx, p.local, cacheKey); found {
+		return key, err
+	}
+	if p.cache != p.local {
+		if key, found, err := p.cached(ctx, p.cache, cacheKey); found {
+			return key, err
+		}
+	}
+
+	key, err := p.provider.VerificationKey(ctx, alg, kid)
+	if errors.Is(err, ErrKeyNotFound) {
+		p.cache.Set(ctx, cacheKey, p.seal(cacheKey, []byte(cachedKeyMissing)), p.negativeTTL)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := encodeCachedKey(key); err == nil {
+		if _, isSecret := key.([]byte); isSecret {
+			p.local.Set(ctx, cacheKey, p.seal(cacheKey, data), p.ttl)
+		} else {
+			p.cache.Set(ctx, cacheKey, p.seal(cacheKey, data), p.ttl)
+		}
+	}
+	return key, nil
+}
+
+// cached returns the key stored in cache, or the cached ErrKeyNotFound.
+// Misses and entries that do not authenticate report found=false.
+func (p *cachingKeyProvider) cached(ctx context.Context, cache Cache, cacheKey string) (interface{}, bool, error) {
+	sealed, found, err := cache.Get(ctx, cacheKey)
+	if err != nil || !found {
+		return nil, false, nil
+	}
+	if d, ok := p.decoded.Load(cacheKey); ok && bytes.Equal(d.(decodedKey).data, sealed) {
+		return d.(decodedKey).key, true, nil
+	}
+	data, ok := p.open(cacheKey, sealed)
+	if !ok {
+		return nil, false, nil
+	}
+	key, err := decodeCachedKey(data)
+	if err == nil {
+		p.decoded.Store(cacheKey, decodedKey{data: sealed, key: key})
+		return key, true, nil
+	}
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, true, err
+	}
+	return nil, false, nil
+}
+
+// seal prefixes data with its HMAC-SHA256, bound to the cache key
+func (p *cachingKeyProvider) seal(cacheKey string, data []byte) []byte {
+	return append(p.mac(cacheKey, data), data...)
+}
+
+// open returns the data of an entry sealed by seal, or ok=false if the
+// entry was not written by a provider holding the same secret
+func (p *cachingKeyProvider) open(cacheKey string, sealed []byte) ([]byte, bool) {
+	if len(sealed) < sha256.Size {
+		return nil, false
+	}
+	tag, data := sealed[:sha256.Size], sealed[sha256.Size:]
+	return data, hmac.Equal(tag, p.mac(cacheKey, data))
+}
+
+// mac authenticates data stored under cacheKey
+func (p *cachingKeyProvider) mac(cacheKey string, data []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(cacheKey))
+	mac.Write([]byte{0})
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// Cached key encodings
+const (
+	cachedKeyMissing = "missing:"
+	cachedKeyHMAC    = "hmac:"
+	cachedKeyPKIX    = "pkix:"
+)
+
+// encodeCachedKey serializes a verification key for storage in a Cache
+func encodeCachedKey(key interface{}) ([]byte, error) {
+	if secret, ok := key.([]byte); ok {
+		return append([]byte(cachedKeyHMAC), secret...), nil
+	}
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(cachedKeyPKIX), der...), nil
+}
+
+// decodeCachedKey restores a key stored by encodeCachedKey
+func decodeCachedKey(data []byte) (interface{}, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte(cachedKeyMissing)):
+		return nil, ErrKeyNotFound
+	case bytes.HasPrefix(data, []byte(cachedKeyHMAC)):
+		return bytes.Clone(data[len(cachedKeyHMAC):]), nil
+	case bytes.HasPrefix(data, []byte(cachedKeyPKIX)):
+		return x509.ParsePKIXPublicKey(data[len(cachedKeyPKIX):])
+	}
+	return nil, fmt.Errorf("unrecognized cached key encoding")
+}
+
+// WithKeyProvider configures validation for alg using keys resolved from provider
+func WithKeyProvider(alg string, provider KeyProvider) ConfigOption {
+	return func(c *Config) error {
+		if provider == nil {
+			return fmt.Errorf("key provider for %s cannot be nil", alg)
+		}
+		if alg == "none" || alg == "None" || alg == "NONE" {
+			return fmt.Errorf("none algorithm is prohibited")
+		}
+		method := lookupSigningMethod(alg)
+		if method == nil {
+			return fmt.Errorf("unknown signing algorithm %s", alg)
+		}
+		c.validators[alg] = algorithmValidator{
+			signingMethod: method,
+			keyProvider:   provider,
+		}
+		return nil
+	}
+}
+
+// resolveKey returns the verification key for a validator, consulting its provider when set
+func (v algorithmValidator) resolveKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	if v.keySet != nil {
+		return v.keySet.lookup(alg, kid)
+	}
+	if v.keyProvider == nil {
+		return v.signingKey, nil
+	}
+	key, err := v.keyProvider.VerificationKey(ctx, alg, kid)
+	if err != nil {
+		return nil, NewValidationError(ErrKeyUnavailable, fmt.Sprintf("verification key for %s unavailable", alg), err)
+	}
+	if key == nil {
+		return nil, NewValidationError(ErrKeyUnavailable, fmt.Sprintf("verification key for %s unavailable", alg), nil)
+	}
+	return key, nil
+}
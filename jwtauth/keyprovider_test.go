@@ -0,0 +1,115 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestWithKeyProvider tests validation with keys resolved from a KeyProvider
+func TestWithKeyProvider(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, err := NewCryptoSigner("ES256", "kid-1", ecKey)
+	if err != nil {
+		t.Fatalf("NewCryptoSigner failed: %v", err)
+	}
+
+	var gotKid string
+	provider := KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		gotKid = kid
+		return &ecKey.PublicKey, nil
+	})
+
+	cfg := mustCreateConfig(WithKeyProvider("ES256", provider))
+
+	tokenString, _ := SignToken(context.Background(), signer, map[string]interface{}{
+		"sub": "user123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	claims, err := parseAndValidateJWT(tokenString, cfg)
+	if err != nil {
+		t.Fatalf("Expected token to validate, got %v", err)
+	}
+	if claims.Subject != "user123" {
+		t.Errorf("Expected subject user123, got %s", claims.Subject)
+	}
+	if gotKid != "kid-1" {
+		t.Errorf("Expected provider to receive kid-1, got %q", gotKid)
+	}
+}
+
+// TestWithKeyProviderUnavailable tests that provider failures surface as KEY_UNAVAILABLE
+func TestWithKeyProviderUnavailable(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, _ := NewCryptoSigner("ES256", "", ecKey)
+
+	provider := KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		return nil, errors.New("hsm offline")
+	})
+	cfg := mustCreateConfig(WithKeyProvider("ES256", provider))
+
+	tokenString, _ := SignToken(context.Background(), signer, map[string]interface{}{"sub": "user123"})
+
+	_, err := parseAndValidateJWT(tokenString, cfg)
+	if getErrorCode(err) != string(ErrKeyUnavailable) {
+		t.Errorf("Expected KEY_UNAVAILABLE, got %v", err)
+	}
+}
+
+// TestWithKeyProviderConfigErrors tests config-time validation of key providers
+func TestWithKeyProviderConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		alg      string
+		provider KeyProvider
+	}{
+		{"nil provider", "ES256", nil},
+		{"none algorithm", "none", StaticKeyProvider([]byte("x"))},
+		{"unknown algorithm", "XX999", StaticKeyProvider([]byte("x"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConfig(WithKeyProvider(tt.alg, tt.provider)); err == nil {
+				t.Error("Expected config error")
+			}
+		})
+	}
+}
+
+// TestCachedKeyProvider tests that keys are reused within the TTL per (alg, kid)
+func TestCachedKeyProvider(t *testing.T) {
+	calls := 0
+	provider := CachedKeyProvider(KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		calls++
+		return []byte(alg + kid), nil
+	}), time.Minute)
+
+	ctx := context.Background()
+	provider.VerificationKey(ctx, "RS256", "a")
+	provider.VerificationKey(ctx, "RS256", "a")
+	if calls != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", calls)
+	}
+
+	provider.VerificationKey(ctx, "RS256", "b")
+	if calls != 2 {
+		t.Errorf("Expected distinct kid to miss cache, got %d calls", calls)
+	}
+
+	expired := CachedKeyProvider(KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		calls++
+		return []byte("k"), nil
+	}), 0)
+	calls = 0
+	expired.VerificationKey(ctx, "RS256", "")
+	expired.VerificationKey(ctx, "RS256", "")
+	if calls != 2 {
+		t.Errorf("Expected zero TTL to bypass cache, got %d calls", calls)
+	}
+}
@@ -1,6 +1,7 @@
 package jwtauth
 
 import (
+	"crypto"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -30,3 +31,30 @@ func ParseRSAPublicKeyFromPEM(pemBytes []byte) (*rsa.PublicKey, error) {
 
 	return nil, fmt.Errorf("failed to parse RSA public key from PEM")
 }
+
+// ParsePrivateKeyFromPEM parses an RSA, ECDSA or Ed25519 private key from PEM
+// format for use with NewCryptoSigner
+// Supports PKCS#8, PKCS#1 (RSA) and SEC 1 (EC) PEM formats
+func ParsePrivateKeyFromPEM(pemBytes []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+
+	// Try PKCS#8 format first (most common)
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("failed to parse private key from PEM")
+}
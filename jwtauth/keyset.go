@@ -0,0 +1,150 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KidFallback selects how a key set verifies tokens without a kid header
+type KidFallback int
+
+const (
+	// KidFallbackReject rejects tokens without kid with KEY_UNAVAILABLE (default)
+	KidFallbackReject KidFallback = iota
+	// KidFallbackDefault verifies tokens without kid with KeySet.Default
+	KidFallbackDefault
+	// KidFallbackTryAll tries every key in the set, for issuers that never
+	// send kid. Costs one verification per key on forged tokens.
+	KidFallbackTryAll
+)
+
+// KeySet configures WithKeySet
+type KeySet struct {
+	Keys     map[string]interface{} // kid -> verification key ([]byte, *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or *secp256k1.PublicKey)
+	Fallback KidFallback            // Handling of tokens without kid
+	Default  string                 // kid used by KidFallbackDefault
+}
+
+// WithKeySet configures alg with several keys selected by the token's kid
+// header, so keys can be rotated by adding the new key before tokens
+// signed with it appear and removing the old one after its tokens expire.
+// Keys are checked like the single-key options (secret sizes, curves).
+// Tokens whose kid is not in the set are rejected with KEY_UNAVAILABLE.
+//
+//	jwtauth.WithKeySet("RS256", jwtauth.KeySet{
+//		Keys: map[string]interface{}{"2024-06": oldKey, "2024-09": newKey},
+//	})
+func WithKeySet(alg string, set KeySet) ConfigOption {
+	return func(c *Config) error {
+		if len(set.Keys) == 0 {
+			return fmt.Errorf("key set for %s must contain at least one key", alg)
+		}
+		switch set.Fallback {
+		case KidFallbackReject, KidFallbackTryAll:
+		case KidFallbackDefault:
+			if _, ok := set.Keys[set.Default]; !ok {
+				return fmt.Errorf("key set for %s: default kid %q is not in the set", alg, set.Default)
+			}
+		default:
+			return fmt.Errorf("key set for %s: invalid kid fallback %d", alg, set.Fallback)
+		}
+
+		ks := &keySet{keys: make(map[string]interface{}, len(set.Keys)), fallback: set.Fallback, defaultKey: set.Default}
+		var method jwt.SigningMethod
+		for kid, key := range set.Keys {
+			if kid == "" {
+				return fmt.Errorf("key set for %s: kid cannot be empty", alg)
+			}
+			validator, err := singleKeyValidator(alg, key)
+			if err != nil {
+				return fmt.Errorf("key set for %s, kid %q: %w", alg, kid, err)
+			}
+			ks.keys[kid] = validator.signingKey
+			method = validator.signingMethod
+		}
+		c.validators[alg] = algorithmValidator{signingMethod: method, keySet: ks}
+		return nil
+	}
+}
+
+// singleKeyValidator validates key for alg with the matching single-key option
+func singleKeyValidator(alg string, key interface{}) (algorithmValidator, error) {
+	var option ConfigOption
+	switch k := key.(type) {
+	case []byte:
+		if method, ok := jwt.GetSigningMethod(alg).(*jwt.SigningMethodHMAC); ok {
+			option = withHMAC(method, k)
+		}
+	case *rsa.PublicKey:
+		switch jwt.GetSigningMethod(alg).(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			option = withRSA(jwt.GetSigningMethod(alg), k)
+		}
+	case *ecdsa.PublicKey:
+		if alg == "ES256" {
+			option = WithES256(k)
+		}
+	case ed25519.PublicKey:
+		if alg == "EdDSA" {
+			option = WithEdDSA(k)
+		}
+	default:
+		if alg == "ES256K" {
+			option = es256kKeyOption(key)
+		}
+	}
+	if option == nil {
+		return algorithmValidator{}, fmt.Errorf("%T cannot be used with %s", key, alg)
+	}
+
+	scratch := &Config{validators: make(map[string]algorithmValidator, 1)}
+	if err := option(scratch); err != nil {
+		return algorithmValidator{}, err
+	}
+	return scratch.validators[alg], nil
+}
+
+// keySet holds the keys of one algorithm by kid
+type keySet struct {
+	keys       map[string]interface{}
+	fallback   KidFallback
+	defaultKey string
+}
+
+// lookup returns the key for kid, applying the fallback when kid is empty.
+// KidFallbackTryAll returns a jwt.VerificationKeySet.
+func (s *keySet) lookup(alg, kid string) (interface{}, error) {
+	if kid != "" {
+		if key, ok := s.keys[kid]; ok {
+			return key, nil
+		}
+		return nil, NewValidationError(ErrKeyUnavailable, fmt.Sprintf("no %s key with kid %q", alg, kid), ErrKeyNotFound)
+	}
+
+	switch s.fallback {
+	case KidFallbackDefault:
+		return s.keys[s.defaultKey], nil
+	case KidFallbackTryAll:
+		set := jwt.VerificationKeySet{}
+		for _, kid := range s.kids() {
+			set.Keys = append(set.Keys, s.keys[kid])
+		}
+		return set, nil
+	}
+	return nil, NewValidationError(ErrKeyUnavailable, fmt.Sprintf("token has no kid header and %s uses a key set", alg), ErrKeyNotFound)
+}
+
+// kids returns the key IDs in sorted order
+func (s *keySet) kids() []string {
+	kids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	return kids
+}
@@ -0,0 +1,83 @@
+package jwtauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestWithKeySet tests kid routing and the fallbacks for tokens without kid
+func TestWithKeySet(t *testing.T) {
+	oldKey, newKey, otherKey := mustGenerateRSAKey(), mustGenerateRSAKey(), mustGenerateRSAKey()
+	keys := map[string]interface{}{"2024-06": &oldKey.PublicKey, "2024-09": &newKey.PublicKey}
+
+	sign := func(key interface{}, kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name     string
+		set      KeySet
+		token    string
+		wantCode string
+	}{
+		{"old kid", KeySet{Keys: keys}, sign(oldKey, "2024-06"), ""},
+		{"new kid", KeySet{Keys: keys}, sign(newKey, "2024-09"), ""},
+		{"kid of another key", KeySet{Keys: keys}, sign(newKey, "2024-06"), "INVALID_SIGNATURE"},
+		{"unknown kid", KeySet{Keys: keys}, sign(otherKey, "2025-01"), "KEY_UNAVAILABLE"},
+		{"no kid rejected", KeySet{Keys: keys}, sign(newKey, ""), "KEY_UNAVAILABLE"},
+		{"no kid default", KeySet{Keys: keys, Fallback: KidFallbackDefault, Default: "2024-09"}, sign(newKey, ""), ""},
+		{"no kid default mismatch", KeySet{Keys: keys, Fallback: KidFallbackDefault, Default: "2024-09"}, sign(oldKey, ""), "INVALID_SIGNATURE"},
+		{"no kid try all", KeySet{Keys: keys, Fallback: KidFallbackTryAll}, sign(oldKey, ""), ""},
+		{"no kid try all forged", KeySet{Keys: keys, Fallback: KidFallbackTryAll}, sign(otherKey, ""), "INVALID_SIGNATURE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mustCreateConfig(WithKeySet("RS256", tt.set))
+			_, err := parseAndValidateJWT(tt.token, cfg)
+			if tt.wantCode == "" && err != nil {
+				t.Fatalf("Expected token to validate, got %v", err)
+			}
+			if tt.wantCode != "" && getErrorCode(err) != tt.wantCode {
+				t.Fatalf("Expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+
+	invalid := map[string]KeySet{
+		"empty set":       {},
+		"empty kid":       {Keys: map[string]interface{}{"": &newKey.PublicKey}},
+		"wrong key type":  {Keys: map[string]interface{}{"k": []byte("test-secret-key-min-32-bytes-long!!")}},
+		"missing default": {Keys: keys, Fallback: KidFallbackDefault, Default: "2023-01"},
+	}
+	for name, set := range invalid {
+		if _, err := NewConfig(WithKeySet("RS256", set)); err == nil {
+			t.Errorf("%s: expected key set to be rejected", name)
+		}
+	}
+	if _, err := NewConfig(WithKeySet("HS512", KeySet{Keys: map[string]interface{}{"k": []byte("only-32-bytes-long-secret-value!")}})); err == nil {
+		t.Error("Expected short HS512 secret to be rejected")
+	}
+}
+
+// TestKeySetSelfTest tests that SelfTest round-trips every secret of a key set
+func TestKeySetSelfTest(t *testing.T) {
+	cfg := mustCreateConfig(WithKeySet("HS256", KeySet{Keys: map[string]interface{}{
+		"a": []byte("test-secret-key-min-32-bytes-long!!"),
+		"b": []byte("another-secret-key-min-32-bytes-long"),
+	}}))
+	report := cfg.SelfTest(context.Background())
+	if !report.OK() || report.Checks[0].Status != SelfTestPass {
+		t.Errorf("Expected key set self-test to pass, got %+v", report.Checks)
+	}
+}
@@ -0,0 +1,56 @@
+package jwtauth
+
+import (
+	"crypto/rsa"
+	"fmt"
+)
+
+// WithMinRSAKeySize rejects RSA verification keys with a modulus shorter than
+// bits, e.g. 4096 for policies mandating 4096-bit RSA. Static keys and key
+// sets are checked by NewConfig; keys resolved from key providers (JWKS, KMS,
+// key files) are checked on every validation, and tokens they would verify
+// are rejected with KEY_UNAVAILABLE.
+func WithMinRSAKeySize(bits int) ConfigOption {
+	return func(c *Config) error {
+		if bits < 2048 || bits%8 != 0 {
+			return fmt.Errorf("minimum RSA key size must be a multiple of 8 of at least 2048 bits, got %d", bits)
+		}
+		c.minRSAKeyBits = bits
+		return nil
+	}
+}
+
+// checkStaticKeySizes applies WithMinRSAKeySize to static keys and key sets
+func (c *Config) checkStaticKeySizes() error {
+	if c.minRSAKeyBits == 0 {
+		return nil
+	}
+	for _, alg := range c.AvailableAlgorithms() {
+		validator := c.validators[alg]
+		if err := c.checkRSAKeySize(validator.signingKey); err != nil {
+			return NewValidationError(ErrConfigError, fmt.Sprintf("%s: %v", alg, err), nil)
+		}
+		if validator.keySet == nil {
+			continue
+		}
+		for kid, key := range validator.keySet.keys {
+			if err := c.checkRSAKeySize(key); err != nil {
+				return NewValidationError(ErrConfigError, fmt.Sprintf("%s key set, kid %q: %v", alg, kid, err), nil)
+			}
+		}
+	}
+	return nil
+}
+
+// checkRSAKeySize rejects RSA keys below the configured minimum; other key
+// types pass
+func (c *Config) checkRSAKeySize(key interface{}) error {
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok || c.minRSAKeyBits == 0 {
+		return nil
+	}
+	if bits := rsaKey.N.BitLen(); bits < c.minRSAKeyBits {
+		return fmt.Errorf("RSA key is %d bits, minimum is %d", bits, c.minRSAKeyBits)
+	}
+	return nil
+}
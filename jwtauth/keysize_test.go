@@ -0,0 +1,68 @@
+package jwtauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	rsa4096Once sync.Once
+	rsa4096Key  *rsa.PrivateKey
+)
+
+// mustGenerateRSA4096Key returns a 4096-bit key shared by the tests, since
+// generating one takes seconds
+func mustGenerateRSA4096Key() *rsa.PrivateKey {
+	rsa4096Once.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 4096)
+		if err != nil {
+			panic(err)
+		}
+		rsa4096Key = key
+	})
+	return rsa4096Key
+}
+
+// TestMinRSAKeySize tests static and provider key size enforcement
+func TestMinRSAKeySize(t *testing.T) {
+	large := mustGenerateRSA4096Key()
+	small := mustGenerateRSAKey()
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	sign := func(key *rsa.PrivateKey) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodPS512, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	cfg := mustCreateConfig(WithPS512(&large.PublicKey), WithMinRSAKeySize(4096))
+	if _, err := parseAndValidateJWT(sign(large), cfg); err != nil {
+		t.Errorf("expected 4096-bit token to validate, got %v", err)
+	}
+
+	if _, err := NewConfig(WithPS512(&small.PublicKey), WithMinRSAKeySize(4096)); err == nil || !contains(err.Error(), "2048 bits") {
+		t.Errorf("expected error for a 2048-bit static key, got %v", err)
+	}
+	set := KeySet{Keys: map[string]interface{}{"old": &small.PublicKey, "new": &large.PublicKey}}
+	if _, err := NewConfig(WithKeySet("PS512", set), WithMinRSAKeySize(4096)); err == nil {
+		t.Error("expected error for a 2048-bit key in a key set")
+	}
+
+	// Provider keys are checked when resolved
+	providerCfg := mustCreateConfig(WithKeyProvider("PS512", StaticKeyProvider(&small.PublicKey)), WithMinRSAKeySize(4096))
+	if _, err := parseAndValidateJWT(sign(small), providerCfg); getErrorCode(err) != string(ErrKeyUnavailable) {
+		t.Errorf("expected KEY_UNAVAILABLE for a 2048-bit provider key, got %v", err)
+	}
+
+	for _, bits := range []int{0, 1024, 4095} {
+		if _, err := NewConfig(WithPS512(&large.PublicKey), WithMinRSAKeySize(bits)); err == nil {
+			t.Errorf("expected error for minimum %d", bits)
+		}
+	}
+}
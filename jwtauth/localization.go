@@ -0,0 +1,104 @@
+package jwtauth
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAcceptLanguages bounds the language ranges tried per request
+const maxAcceptLanguages = 8
+
+// MessageTranslator returns the message for code in lang (a BCP 47 tag from
+// Accept-Language, e.g. "de-CH" or "de"), or "" when it has none
+type MessageTranslator func(code ErrorCode, lang string) string
+
+// WithMessageTranslator localizes the message field of HTTP error
+// responses. The translator is tried with each language of the request's
+// Accept-Language header in preference order, each tag followed by its
+// primary language ("de-CH", then "de"); the first non-empty result becomes
+// the message and is announced with Content-Language. The error and reason
+// fields stay unchanged, so clients keep matching on reason codes. Without a
+// translation the default message, if any, is kept.
+//
+//	jwtauth.WithMessageTranslator(func(code jwtauth.ErrorCode, lang string) string {
+//		return catalog[lang][code]
+//	})
+func WithMessageTranslator(fn MessageTranslator) ConfigOption {
+	return func(c *Config) error {
+		c.messageTranslator = fn
+		return nil
+	}
+}
+
+// localizeErrorResponse replaces the message of response with a translation
+// for the request's preferred languages
+func localizeErrorResponse(c *gin.Context, cfg *Config, err error, response gin.H) {
+	if cfg.messageTranslator == nil {
+		return
+	}
+	valErr, ok := err.(*ValidationError)
+	if !ok {
+		return
+	}
+	c.Header("Vary", "Accept-Language")
+	for _, lang := range acceptLanguages(c.GetHeader("Accept-Language")) {
+		if msg := cfg.messageTranslator(valErr.Code, lang); msg != "" {
+			response["message"] = msg
+			c.Header("Content-Language", lang)
+			return
+		}
+	}
+}
+
+// acceptLanguages returns the language tags of an Accept-Language header in
+// preference order, each followed by its primary language. Wildcards and
+// ranges with q=0 are skipped.
+func acceptLanguages(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		ranges = append(ranges, weighted{tag: tag, q: q})
+		if len(ranges) == maxAcceptLanguages {
+			break
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	seen := map[string]bool{}
+	var langs []string
+	for _, r := range ranges {
+		candidates := []string{r.tag}
+		if primary, _, ok := strings.Cut(r.tag, "-"); ok {
+			candidates = append(candidates, primary)
+		}
+		for _, lang := range candidates {
+			if key := strings.ToLower(lang); !seen[key] {
+				seen[key] = true
+				langs = append(langs, lang)
+			}
+		}
+	}
+	return langs
+}
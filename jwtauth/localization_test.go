@@ -0,0 +1,86 @@
+package jwtauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// TestWithMessageTranslator tests localized error messages driven by Accept-Language
+func TestWithMessageTranslator(t *testing.T) {
+	catalog := map[string]map[ErrorCode]string{
+		"de": {ErrMissingToken: "Anmeldung erforderlich"},
+		"fr": {ErrMissingToken: "Authentification requise"},
+	}
+	cfg := mustCreateConfig(
+		WithHS256([]byte("test-secret-key-min-32-bytes-long!!")),
+		WithMessageTranslator(func(code ErrorCode, lang string) string {
+			return catalog[lang][code]
+		}),
+	)
+	router := createTestRouter(cfg)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		wantMessage    string
+		wantLanguage   string
+	}{
+		{"primary language fallback", "de-CH", "Anmeldung erforderlich", "de"},
+		{"quality order", "en;q=0.9, fr;q=0.8, de;q=0.5", "Authentification requise", "fr"},
+		{"excluded language", "fr;q=0, de;q=0.1", "Anmeldung erforderlich", "de"},
+		{"no translation", "ja", "", ""},
+		{"no header", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/protected", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("expected 401, got %d", w.Code)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["reason"] != string(ErrMissingToken) {
+				t.Errorf("expected reason to stay %s, got %s", ErrMissingToken, body["reason"])
+			}
+			if body["message"] != tt.wantMessage {
+				t.Errorf("expected message %q, got %q", tt.wantMessage, body["message"])
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("expected Content-Language %q, got %q", tt.wantLanguage, got)
+			}
+			if w.Header().Get("Vary") != "Accept-Language" {
+				t.Errorf("expected Vary: Accept-Language, got %q", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+// TestAcceptLanguages tests Accept-Language parsing
+func TestAcceptLanguages(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"da, en-GB;q=0.8, en;q=0.7", []string{"da", "en-GB", "en"}},
+		{"en-US;q=0.5, fr-CA", []string{"fr-CA", "fr", "en-US", "en"}},
+		{"*, de;q=0.5", []string{"de"}},
+		{"de;q=abc, fr", []string{"fr"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := acceptLanguages(tt.header); !slices.Equal(got, tt.want) {
+			t.Errorf("acceptLanguages(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -7,19 +7,31 @@ import (
 
 // SecurityEvent represents a structured security log entry
 type SecurityEvent struct {
-	EventType     string        // "success" or "failure"
+	EventType     string        // "success", "failure", "anomaly", "canary", "key_outage", "expiry_warning" or "config_change"
 	Timestamp     time.Time     // Event timestamp
 	RequestID     string        // Correlation ID
+	ClientIP      string        // Client address, honoring trusted proxies (optional)
 	UserID        string        // Subject from claims (empty on failure)
+	TenantID      string        // Tenant from the configured tenant claim (optional)
 	Algorithm     string        // Algorithm used (HS256, RS256) or attempted
-	FailureReason string        // Error code (on failure)
+	FailureReason string        // Error code (on failure or canary violation)
+	FailureCause  ErrorCategory // Cause category of the failure (optional)
 	TokenPreview  string        // Redacted token preview
 	Latency       time.Duration // Validation latency
+	ChangedClaims []string      // Claims that changed since the subject's previous token (anomaly only)
+	Degraded      bool          // Authenticated under a key outage policy (success only)
+	KeySource     string        // Provenance.KeySource of the verifying key (success only)
+	MatchedConfig int           // 1-based position of the AnyOf config that accepted the token (success only)
+	ExpiresIn     time.Duration // Remaining token lifetime (expiry_warning only)
+	ChangeSource  string        // "reload", "jwks_refresh", "rotation", "secret_refresh" or "file_reload" (config_change only)
+	ChangedFields []string      // Settings that changed (config_change only)
+	KeysBefore    []string      // Key fingerprints before the change (config_change only)
+	KeysAfter     []string      // Key fingerprints after the change (config_change only)
 }
 
 // LogValue implements slog.LogValuer for structured logging with redaction
 func (e SecurityEvent) LogValue() slog.Value {
-	return slog.GroupValue(
+	attrs := []slog.Attr{
 		slog.String("event", e.EventType),
 		slog.Time("timestamp", e.Timestamp),
 		slog.String("request_id", e.RequestID),
@@ -28,7 +40,52 @@ func (e SecurityEvent) LogValue() slog.Value {
 		slog.String("failure_reason", e.FailureReason),
 		slog.String("token", redactToken(e.TokenPreview)),
 		slog.Duration("latency", e.Latency),
-	)
+	}
+
+	// Optional fields are only emitted when set
+	if e.TenantID != "" {
+		attrs = append(attrs, slog.String("tenant_id", e.TenantID))
+	}
+	if e.FailureCause != "" {
+		attrs = append(attrs, slog.String("failure_cause", string(e.FailureCause)))
+	}
+	if e.ClientIP != "" {
+		attrs = append(attrs, slog.String("client_ip", e.ClientIP))
+	}
+	if len(e.ChangedClaims) > 0 {
+		attrs = append(attrs, slog.Any("changed_claims", e.ChangedClaims))
+	}
+	if e.Degraded {
+		attrs = append(attrs, slog.Bool("degraded", true))
+	}
+	if e.KeySource != "" {
+		attrs = append(attrs, slog.String("key_source", e.KeySource))
+	}
+	if e.MatchedConfig > 0 {
+		attrs = append(attrs, slog.Int("matched_config", e.MatchedConfig))
+	}
+	if e.EventType == "expiry_warning" {
+		attrs = append(attrs, slog.Duration("expires_in", e.ExpiresIn))
+	}
+	if e.EventType == "config_change" {
+		attrs = append(attrs,
+			slog.String("change_source", e.ChangeSource),
+			slog.Any("changed_fields", e.ChangedFields),
+			slog.Any("keys_before", e.KeysBefore),
+			slog.Any("keys_after", e.KeysAfter),
+		)
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// failureCause returns the cause category of err, or "" when err is not a
+// ValidationError
+func failureCause(err error) ErrorCategory {
+	if valErr, ok := err.(*ValidationError); ok {
+		return valErr.Cause()
+	}
+	return ""
 }
 
 // redactToken redacts sensitive token data
@@ -48,9 +105,24 @@ func logSecurityEvent(logger *slog.Logger, event SecurityEvent) {
 		return // Logging disabled
 	}
 
-	if event.EventType == "failure" {
+	switch event.EventType {
+	case "failure":
 		logger.Warn("authentication failed", "auth_event", event)
-	} else {
+	case "anomaly":
+		logger.Warn("claims anomaly detected", "auth_event", event)
+	case "canary":
+		logger.Warn("canary validation failed (report-only)", "auth_event", event)
+	case "expiry_warning":
+		logger.Info("token near expiry", "auth_event", event)
+	case "config_change":
+		logger.Warn("authentication configuration changed", "auth_event", event)
+	case "success":
+		if event.Degraded {
+			logger.Warn("authentication succeeded in degraded mode", "auth_event", event)
+			return
+		}
+		logger.Info("authentication succeeded", "auth_event", event)
+	default:
 		logger.Info("authentication succeeded", "auth_event", event)
 	}
 }
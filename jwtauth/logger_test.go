@@ -117,7 +117,7 @@ func TestSecurityEvent_AlgorithmField_Success(t *testing.T) {
 
 			// Manually trigger logAuthSuccess to test logging
 			claims := &Claims{Subject: "test-user"}
-			logAuthSuccess(cfgWithLogger, "test-req-123", claims, tokenString, 10*time.Millisecond)
+			logAuthSuccess(cfgWithLogger, "test-req-123", "", claims, tokenString, 10*time.Millisecond)
 
 			// Parse logged JSON
 			var logEntry map[string]interface{}
@@ -207,7 +207,7 @@ func TestSecurityEvent_AlgorithmField_Failure(t *testing.T) {
 			}
 
 			// Trigger logAuthFailure
-			logAuthFailure(cfgWithLogger, "test-req-456", tt.token, valErr, 5*time.Millisecond)
+			logAuthFailure(cfgWithLogger, "test-req-456", "", tt.token, valErr, 5*time.Millisecond)
 
 			// Parse logged JSON
 			var logEntry map[string]interface{}
@@ -240,6 +240,11 @@ func TestSecurityEvent_AlgorithmField_Failure(t *testing.T) {
 			if failureReason != tt.expectedFailureCode {
 				t.Errorf("Expected failure_reason=%s, got failure_reason=%s", tt.expectedFailureCode, failureReason)
 			}
+
+			// Verify failure cause
+			if cause := authEvent["failure_cause"]; cause != string(valErr.Cause()) {
+				t.Errorf("Expected failure_cause=%s, got %v", valErr.Cause(), cause)
+			}
 		})
 	}
 }
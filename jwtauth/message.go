@@ -0,0 +1,145 @@
+package jwtauth
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ValidateMessage authenticates a message-bus message (NATS, Kafka, AMQP, ...)
+// from its headers. The token is read from an "Authorization: Bearer <token>"
+// header and goes through the same validation, revocation and rate limit
+// checks as HTTP and gRPC requests, so failures carry the same error codes.
+//
+// headers matches nats.Header directly; other clients can convert their
+// header representation. Header names are matched case-insensitively.
+func ValidateMessage(ctx context.Context, cfg *Config, headers map[string][]string) (*Claims, error) {
+	_, claims, err := authenticateMessage(ctx, cfg, headers)
+	return claims, err
+}
+
+// MessageContext authenticates a message like ValidateMessage and returns a
+// context carrying the claims, request ID and tenant, mirroring what the HTTP
+// and gRPC middleware provide to handlers. The request ID is taken from an
+// "X-Request-ID" header when present.
+//
+// Consumers typically wrap their handler:
+//
+//	sub, _ := nc.Subscribe("commands.>", func(msg *nats.Msg) {
+//		ctx, err := jwtauth.MessageContext(context.Background(), cfg, msg.Header)
+//		if err != nil {
+//			msg.Term() // or reply with jwtauth error code
+//			return
+//		}
+//		handleCommand(ctx, msg)
+//	})
+func MessageContext(ctx context.Context, cfg *Config, headers map[string][]string) (context.Context, error) {
+	ctx, _, err := authenticateMessage(ctx, cfg, headers)
+	return ctx, err
+}
+
+// authenticateMessage extracts, validates and logs a message token
+func authenticateMessage(ctx context.Context, cfg *Config, headers map[string][]string) (context.Context, *Claims, error) {
+	startTime := time.Now()
+
+	requestID := messageHeader(headers, "X-Request-ID")
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	clientIP := "" // Messages carry no peer address
+	ctx = withDeviceFingerprint(ctx, deviceFingerprintFromMessage(headers, cfg))
+
+	tokens, err := extractTokensFromMessageHeaders(headers, cfg, requestID)
+	if err != nil {
+		logAuthFailure(cfg, requestID, clientIP, "", err, time.Since(startTime))
+		return ctx, nil, err
+	}
+
+	token, claims, tenant, err := authenticateCandidates(ctx, tokens, requestID, cfg)
+	if err != nil {
+		logAuthFailure(cfg, requestID, clientIP, token, err, time.Since(startTime))
+		return ctx, nil, err
+	}
+
+	ctx = WithClaims(ctx, claims)
+	ctx = WithToken(ctx, token)
+	ctx = WithRequestID(ctx, requestID)
+	if tenant != "" {
+		ctx = WithTenant(ctx, tenant)
+	}
+
+	logAuthSuccess(cfg, requestID, clientIP, claims, token, time.Since(startTime))
+	return ctx, claims, nil
+}
+
+// messageHeader returns the first value of a header, matched case-insensitively
+func messageHeader(headers map[string][]string, name string) string {
+	for key, values := range headers {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// messageHeaderValues returns every value of a header, matched
+// case-insensitively. Keys are visited in sorted order so the result is
+// deterministic when a message carries several spellings of the name.
+func messageHeaderValues(headers map[string][]string, name string) []string {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var values []string
+	for _, key := range keys {
+		values = append(values, headers[key]...)
+	}
+	return values
+}
+
+// RecordHeader is a Kafka record header. It has the same shape as kafka-go's
+// kafka.Header and franz-go's kgo.RecordHeader, so their header slices can be
+// passed to ValidateRecord and RecordContext without conversion.
+type RecordHeader struct {
+	Key   string
+	Value []byte
+}
+
+// recordHeader matches any header type shaped like RecordHeader
+type recordHeader interface {
+	~struct {
+		Key   string
+		Value []byte
+	}
+}
+
+// ValidateRecord authenticates a Kafka record from its headers, like
+// ValidateMessage:
+//
+//	claims, err := jwtauth.ValidateRecord(ctx, cfg, msg.Headers)    // kafka-go
+//	claims, err := jwtauth.ValidateRecord(ctx, cfg, record.Headers) // franz-go
+func ValidateRecord[H recordHeader](ctx context.Context, cfg *Config, headers []H) (*Claims, error) {
+	return ValidateMessage(ctx, cfg, recordHeadersToMap(headers))
+}
+
+// RecordContext authenticates a Kafka record like MessageContext
+func RecordContext[H recordHeader](ctx context.Context, cfg *Config, headers []H) (context.Context, error) {
+	return MessageContext(ctx, cfg, recordHeadersToMap(headers))
+}
+
+// recordHeadersToMap converts record headers to the map form used by ValidateMessage
+func recordHeadersToMap[H recordHeader](headers []H) map[string][]string {
+	m := make(map[string][]string, len(headers))
+	for _, h := range headers {
+		header := RecordHeader(h)
+		m[header.Key] = append(m[header.Key], string(header.Value))
+	}
+	return m
+}
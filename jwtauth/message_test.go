@@ -0,0 +1,104 @@
+package jwtauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestValidateMessage tests message header authentication with the shared error codes
+func TestValidateMessage(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret))
+
+	valid := mustSignHS256(secret, jwt.MapClaims{"sub": "svc-orders", "exp": time.Now().Add(time.Hour).Unix()})
+	expired := mustSignHS256(secret, jwt.MapClaims{"sub": "svc-orders", "exp": time.Now().Add(-time.Hour).Unix()})
+
+	tests := []struct {
+		name     string
+		headers  map[string][]string
+		wantCode ErrorCode
+	}{
+		{"canonical header", map[string][]string{"Authorization": {"Bearer " + valid}}, ""},
+		{"lowercase header", map[string][]string{"authorization": {"Bearer " + valid}}, ""},
+		{"missing header", map[string][]string{"Content-Type": {"application/json"}}, ErrMissingToken},
+		{"nil headers", nil, ErrMissingToken},
+		{"wrong scheme", map[string][]string{"Authorization": {"Basic abc"}}, ErrMalformed},
+		{"expired token", map[string][]string{"Authorization": {"Bearer " + expired}}, ErrExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ValidateMessage(context.Background(), cfg, tt.headers)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("Expected message to validate, got %v", err)
+				}
+				if claims.Subject != "svc-orders" {
+					t.Errorf("Expected subject svc-orders, got %s", claims.Subject)
+				}
+				return
+			}
+			if getErrorCode(err) != string(tt.wantCode) {
+				t.Errorf("Expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+}
+
+// TestMessageContext tests that the returned context mirrors the middleware's
+func TestMessageContext(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithTenantClaim("tid"))
+
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "svc-orders", "tid": "acme", "exp": time.Now().Add(time.Hour).Unix()})
+	ctx, err := MessageContext(context.Background(), cfg, map[string][]string{
+		"Authorization": {"Bearer " + token},
+		"X-Request-Id":  {"msg-42"},
+	})
+	if err != nil {
+		t.Fatalf("MessageContext failed: %v", err)
+	}
+
+	if claims, ok := GetClaims(ctx); !ok || claims.Subject != "svc-orders" {
+		t.Error("Expected claims in context")
+	}
+	if id, _ := GetRequestID(ctx); id != "msg-42" {
+		t.Errorf("Expected request ID msg-42, got %q", id)
+	}
+	if tenant, _ := GetTenant(ctx); tenant != "acme" {
+		t.Errorf("Expected tenant acme, got %q", tenant)
+	}
+}
+
+// kafkaGoHeader mirrors kafka-go's kafka.Header
+type kafkaGoHeader struct {
+	Key   string
+	Value []byte
+}
+
+// TestValidateRecord tests Kafka header validation with client-specific header types
+func TestValidateRecord(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret))
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "svc-billing", "exp": time.Now().Add(time.Hour).Unix()})
+
+	headers := []kafkaGoHeader{
+		{Key: "trace-id", Value: []byte("abc")},
+		{Key: "authorization", Value: []byte("Bearer " + token)},
+	}
+	claims, err := ValidateRecord(context.Background(), cfg, headers)
+	if err != nil {
+		t.Fatalf("Expected record to validate, got %v", err)
+	}
+	if claims.Subject != "svc-billing" {
+		t.Errorf("Expected subject svc-billing, got %s", claims.Subject)
+	}
+
+	_, err = RecordContext(context.Background(), cfg, []RecordHeader{{Key: "trace-id", Value: []byte("abc")}})
+	if getErrorCode(err) != string(ErrMissingToken) {
+		t.Errorf("Expected MISSING_TOKEN, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package jwtauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestWithMethodRequirements tests per-RPC scope and claim requirements
+func TestWithMethodRequirements(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithMethodRequirements(map[string]Requirement{
+			"/grpc.health.v1.Health/*": {Scopes: []string{"health:read"}},
+			"/grpc.health.v1.Health/Check": {
+				Scopes: []string{"health:check"},
+				Claims: []RequiredClaim{{Name: "env", Type: ClaimString, Equals: "prod"}},
+			},
+		}),
+	)
+	conn := startTestGRPCServer(t,
+		grpc.UnaryInterceptor(UnaryServerInterceptor(cfg)),
+		grpc.StreamInterceptor(StreamServerInterceptor(cfg)),
+	)
+	client := healthpb.NewHealthClient(conn)
+
+	withToken := func(claims jwt.MapClaims) context.Context {
+		claims["sub"] = "svc-a"
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+mustSignHS256(secret, claims))
+	}
+
+	tests := []struct {
+		name      string
+		claims    jwt.MapClaims
+		wantCheck codes.Code
+		wantWatch codes.Code
+	}{
+		{"all granted", jwt.MapClaims{"scope": "health:check health:read", "env": "prod"}, codes.OK, codes.OK},
+		{"scp array", jwt.MapClaims{"scp": []string{"health:check"}, "env": "prod"}, codes.OK, codes.PermissionDenied},
+		{"missing scope", jwt.MapClaims{"scope": "health:read", "env": "prod"}, codes.PermissionDenied, codes.OK},
+		{"wrong claim value", jwt.MapClaims{"scope": "health:check", "env": "dev"}, codes.PermissionDenied, codes.PermissionDenied},
+		{"missing claim", jwt.MapClaims{"scope": "health:check"}, codes.PermissionDenied, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := withToken(tt.claims)
+			_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+			if status.Code(err) != tt.wantCheck {
+				t.Errorf("Check: expected %v, got %v", tt.wantCheck, err)
+			}
+			if tt.wantCheck == codes.PermissionDenied && status.Convert(err).Message() != string(ErrForbidden) {
+				t.Errorf("Check: expected FORBIDDEN, got %q", status.Convert(err).Message())
+			}
+
+			// The service wildcard applies to Watch, a streaming method
+			stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if status.Code(err) != tt.wantWatch {
+				t.Errorf("Watch: expected %v, got %v", tt.wantWatch, err)
+			}
+		})
+	}
+
+	for _, method := range []string{"grpc.health.v1.Health/Check", "/Check", "/grpc.health.v1.Health/", "/a/b/c"} {
+		if _, err := NewConfig(WithHS256(secret), WithMethodRequirements(map[string]Requirement{method: {}})); err == nil {
+			t.Errorf("Expected method %q to be rejected", method)
+		}
+	}
+	if _, err := NewConfig(WithHS256(secret), WithMethodRequirements(map[string]Requirement{"/a.B/C": {Scopes: []string{"a b"}}})); err == nil {
+		t.Error("Expected scope with spaces to be rejected")
+	}
+}
@@ -1,8 +1,12 @@
 package jwtauth
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +17,15 @@ import (
 // JWTAuth returns a Gin middleware handler for JWT authentication
 func JWTAuth(cfg *Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Only the identity this middleware signs reaches upstreams
+		if cfg.identityHeaders != nil {
+			stripIdentityHeaders(c.Request.Header)
+		}
+		if isPreflight(c, cfg) {
+			abortPreflight(c, cfg)
+			return
+		}
+
 		startTime := time.Now()
 
 		// Generate or extract request ID for correlation
@@ -20,30 +33,67 @@ func JWTAuth(cfg *Config) gin.HandlerFunc {
 		if requestID == "" {
 			requestID = uuid.New().String()
 		}
+		clientIP := cfg.clientIP(c.Request.RemoteAddr, c.Request.Header.Values("X-Forwarded-For"))
+		reqCtx := WithClientIP(c.Request.Context(), clientIP)
+		reqCtx = withDeviceFingerprint(reqCtx, deviceFingerprintFromRequest(c.Request, cfg))
 
 		// Extract token from request
-		token, err := extractToken(c.Request, cfg)
+		tokens, err := extractTokens(c.Request, cfg, requestID)
 		if err != nil {
-			logAuthFailure(cfg, requestID, token, err, time.Since(startTime))
-			c.AbortWithStatusJSON(401, buildErrorResponse(err))
+			logAuthFailure(cfg, requestID, clientIP, "", err, time.Since(startTime))
+			abortWithError(c, cfg, err)
 			return
 		}
 
-		// Validate token
-		claims, err := parseAndValidateJWT(token, cfg)
+		// Validate token and apply revocation and rate limit policies
+		token, claims, tenant, err := authenticateCandidates(reqCtx, tokens, requestID, cfg)
 		if err != nil {
-			logAuthFailure(cfg, requestID, token, err, time.Since(startTime))
-			c.AbortWithStatusJSON(401, buildErrorResponse(err))
+			logAuthFailure(cfg, requestID, clientIP, token, err, time.Since(startTime))
+			abortWithError(c, cfg, err)
+			return
+		}
+
+		// Enforce per-route authorization
+		if err := cfg.checkRouteRequirements(c.Request, claims); err != nil {
+			logAuthFailure(cfg, requestID, clientIP, token, err, time.Since(startTime))
+			abortWithError(c, cfg, err)
+			return
+		}
+		if err := cfg.checkHTTPPolicy(c.Request.WithContext(reqCtx), claims, clientIP, tenant); err != nil {
+			logAuthFailure(cfg, requestID, clientIP, token, err, time.Since(startTime))
+			abortWithError(c, cfg, err)
 			return
 		}
+		if cfg.identityHeaders != nil {
+			if err := setIdentityHeaders(c.Request, cfg.identityHeaders, claims, tenant, requestID, time.Now()); err != nil {
+				logAuthFailure(cfg, requestID, clientIP, token, err, time.Since(startTime))
+				abortWithError(c, cfg, err)
+				return
+			}
+		}
 
 		// Inject claims and request ID into context
-		ctx := WithClaims(c.Request.Context(), claims)
+		ctx := WithClaims(reqCtx, claims)
+		ctx = WithToken(ctx, token)
 		ctx = WithRequestID(ctx, requestID)
+		ctx = withConfig(ctx, cfg)
+		if tenant != "" {
+			ctx = WithTenant(ctx, tenant)
+		}
+
+		// Terminate long-lived requests (e.g. WebSockets) when the token expires
+		if cfg.streamExpiry {
+			var cancel context.CancelCauseFunc
+			ctx, cancel = watchStream(ctx, cfg, claims, 0, func(reason error) {
+				logAuthFailure(cfg, requestID, clientIP, token, reason, time.Since(startTime))
+			})
+			defer cancel(nil)
+		}
 		c.Request = c.Request.WithContext(ctx)
+		setExpiryHeaders(c.Writer.Header(), cfg, claims)
 
 		// Log successful authentication
-		logAuthSuccess(cfg, requestID, claims, token, time.Since(startTime))
+		logAuthSuccess(cfg, requestID, clientIP, claims, token, time.Since(startTime))
 
 		// Continue to next handler
 		c.Next()
@@ -51,26 +101,31 @@ func JWTAuth(cfg *Config) gin.HandlerFunc {
 }
 
 // logAuthSuccess logs a successful authentication event
-func logAuthSuccess(cfg *Config, requestID string, claims *Claims, token string, latency time.Duration) {
+func logAuthSuccess(cfg *Config, requestID, clientIP string, claims *Claims, token string, latency time.Duration) {
 	if cfg.Logger() == nil {
 		return
 	}
 
 	event := SecurityEvent{
-		EventType:    "success",
-		Timestamp:    time.Now(),
-		RequestID:    requestID,
-		UserID:       claims.Subject,
-		Algorithm:    extractAlgorithmFromToken(token),
-		TokenPreview: token,
-		Latency:      latency,
+		EventType:     "success",
+		Timestamp:     time.Now(),
+		RequestID:     requestID,
+		ClientIP:      clientIP,
+		UserID:        claims.Subject,
+		TenantID:      cfg.tenantFromClaims(claims),
+		Algorithm:     extractAlgorithmFromToken(token),
+		TokenPreview:  token,
+		Latency:       latency,
+		Degraded:      claims.degraded,
+		KeySource:     claims.Provenance.KeySource,
+		MatchedConfig: claims.matchedConfig,
 	}
 
 	logSecurityEvent(cfg.Logger(), event)
 }
 
 // logAuthFailure logs a failed authentication event
-func logAuthFailure(cfg *Config, requestID string, token string, err error, latency time.Duration) {
+func logAuthFailure(cfg *Config, requestID, clientIP, token string, err error, latency time.Duration) {
 	if cfg.Logger() == nil {
 		return
 	}
@@ -79,8 +134,10 @@ func logAuthFailure(cfg *Config, requestID string, token string, err error, late
 		EventType:     "failure",
 		Timestamp:     time.Now(),
 		RequestID:     requestID,
+		ClientIP:      clientIP,
 		Algorithm:     extractAlgorithmFromToken(token),
 		FailureReason: getErrorCode(err),
+		FailureCause:  failureCause(err),
 		TokenPreview:  token,
 		Latency:       latency,
 	}
@@ -88,6 +145,44 @@ func logAuthFailure(cfg *Config, requestID string, token string, err error, late
 	logSecurityEvent(cfg.Logger(), event)
 }
 
+// abortWithError aborts the request with the status and JSON body for err
+func abortWithError(c *gin.Context, cfg *Config, err error) {
+	setCORSHeaders(c, cfg)
+	status := httpStatusForError(err)
+	if valErr, ok := err.(*ValidationError); ok && valErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(valErr.RetryAfter.Seconds()))))
+	}
+	response := buildErrorResponse(err)
+	localizeErrorResponse(c, cfg, err, response)
+	c.AbortWithStatusJSON(status, response)
+}
+
+// abortAfterAuth rejects a request from middleware chained after JWTAuth,
+// logging and responding with the configuration JWTAuth ran with
+func abortAfterAuth(c *gin.Context, err error) {
+	ctx := c.Request.Context()
+	cfg, ok := ctx.Value(configContextKey).(*Config)
+	if !ok {
+		c.AbortWithStatusJSON(httpStatusForError(err), buildErrorResponse(err))
+		return
+	}
+	requestID, _ := GetRequestID(ctx)
+	clientIP, _ := GetClientIP(ctx)
+	token, _ := GetToken(ctx)
+	logAuthFailure(cfg, requestID, clientIP, token, err, 0)
+	abortWithError(c, cfg, err)
+}
+
+// httpStatusForError maps a validation error to its HTTP status code
+func httpStatusForError(err error) int {
+	if valErr, ok := err.(*ValidationError); ok {
+		if info, ok := valErr.Code.Info(); ok {
+			return info.HTTPStatus
+		}
+	}
+	return http.StatusUnauthorized
+}
+
 // getErrorCode extracts the error code from a validation error
 func getErrorCode(err error) string {
 	if valErr, ok := err.(*ValidationError); ok {
@@ -103,12 +198,18 @@ func buildErrorResponse(err error) gin.H {
 		"error":  "unauthorized",
 		"reason": getErrorCode(err),
 	}
+	switch httpStatusForError(err) {
+	case http.StatusTooManyRequests:
+		response["error"] = "rate_limited"
+	case http.StatusForbidden:
+		response["error"] = "forbidden"
+	}
 
 	// Add message field for specific error types (US3 requirement)
 	if valErr, ok := err.(*ValidationError); ok {
-		// Include message for UNSUPPORTED_ALGORITHM (lists available algorithms)
-		// and MALFORMED errors (helps debugging)
-		if valErr.Code == ErrUnsupportedAlgorithm || valErr.Code == ErrMalformedAlgorithmHeader {
+		// Include message for UNSUPPORTED_ALGORITHM (lists available algorithms),
+		// MALFORMED and INVALID_CLAIM errors (helps debugging)
+		if info, _ := valErr.Code.Info(); info.HasMessage {
 			if valErr.Message != "" {
 				response["message"] = valErr.Message
 			}
@@ -0,0 +1,208 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OnBehalfOfFlow selects the token endpoint protocol of an OnBehalfOf exchange
+type OnBehalfOfFlow int
+
+const (
+	// FlowAzureOBO is the Microsoft identity platform on-behalf-of flow: a
+	// jwt-bearer grant with requested_token_use=on_behalf_of (default)
+	FlowAzureOBO OnBehalfOfFlow = iota
+	// FlowTokenExchange is RFC 8693 token exchange (Keycloak, Okta, Auth0)
+	FlowTokenExchange
+)
+
+// Grant and token type URIs for on-behalf-of exchanges
+const (
+	grantTypeJWTBearer     = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	grantTypeTokenExchange = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccessToken   = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// defaultOnBehalfOfEntries bounds the exchanged token cache
+const defaultOnBehalfOfEntries = 10000
+
+// OnBehalfOf configures exchanging inbound user tokens for tokens scoped to
+// a downstream API, so the downstream call carries the user's identity.
+type OnBehalfOf struct {
+	// Client authenticates this service to the token endpoint. TokenURL,
+	// ClientID, ClientSecret or Signer, HTTPClient and RefreshBefore apply;
+	// Scopes and Audience are ignored.
+	Client ClientCredentials
+	Flow   OnBehalfOfFlow
+
+	// MaxEntries bounds cached exchanged tokens (default 10000)
+	MaxEntries int
+}
+
+// OnBehalfOfExchanger exchanges and caches on-behalf-of tokens. It is safe
+// for concurrent use.
+type OnBehalfOfExchanger struct {
+	obo OnBehalfOf
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*oboEntry
+}
+
+// oboEntry caches the exchanged token for one user and downstream target
+type oboEntry struct {
+	source  TokenSource
+	token   atomic.Pointer[string] // Latest inbound token, used for refreshes
+	expires time.Time              // Inbound token expiry, guarded by the exchanger's mu; zero if none
+}
+
+// NewOnBehalfOf returns an exchanger for obo
+func NewOnBehalfOf(obo OnBehalfOf) (*OnBehalfOfExchanger, error) {
+	if _, err := NewClientCredentialsTokenSource(obo.Client); err != nil {
+		return nil, err
+	}
+	if obo.Flow != FlowAzureOBO && obo.Flow != FlowTokenExchange {
+		return nil, fmt.Errorf("invalid on-behalf-of flow %d", obo.Flow)
+	}
+	if obo.Client.RefreshBefore == 0 {
+		obo.Client.RefreshBefore = time.Minute
+	}
+	if obo.MaxEntries <= 0 {
+		obo.MaxEntries = defaultOnBehalfOfEntries
+	}
+	return &OnBehalfOfExchanger{obo: obo, now: time.Now, entries: make(map[string]*oboEntry)}, nil
+}
+
+// TokenSource returns a TokenSource exchanging the inbound token of the
+// request context (set by JWTAuth, the gRPC interceptors and
+// ValidateMessage) for a token for audience. Use it with Transport or
+// TokenCredentials and pass the request context to outbound calls:
+//
+//	client := &http.Client{Transport: &jwtauth.Transport{Source: obo.TokenSource("api://orders", "api://orders/.default")}}
+//	req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, ordersURL, nil)
+//
+// Exchanged tokens are cached per user (issuer and subject of the validated
+// claims) and target until they expire, and never outlive the inbound token.
+// For FlowAzureOBO without scopes, scope defaults to audience + "/.default".
+func (e *OnBehalfOfExchanger) TokenSource(audience string, scopes ...string) TokenSource {
+	if e.obo.Flow == FlowAzureOBO && len(scopes) == 0 {
+		scopes = []string{strings.TrimSuffix(audience, "/") + "/.default"}
+	}
+	return TokenSourceFunc(func(ctx context.Context) (*AccessToken, error) {
+		token, ok := GetToken(ctx)
+		if !ok {
+			return nil, fmt.Errorf("on-behalf-of exchange: no inbound token in context")
+		}
+		claims, _ := GetClaims(ctx)
+		return e.token(ctx, token, claims, audience, scopes)
+	})
+}
+
+// token returns the cached exchanged token, exchanging on a miss
+func (e *OnBehalfOfExchanger) token(ctx context.Context, token string, claims *Claims, audience string, scopes []string) (*AccessToken, error) {
+	key := oboCacheKey(token, claims, audience, scopes)
+	var expires time.Time
+	if claims != nil {
+		expires = claims.ExpiresAt
+	}
+
+	e.mu.Lock()
+	entry, ok := e.entries[key]
+	if !ok {
+		e.evictLocked()
+		entry = &oboEntry{expires: expires}
+		entry.source = NewCachingTokenSource(TokenSourceFunc(func(ctx context.Context) (*AccessToken, error) {
+			tok, err := e.exchange(ctx, *entry.token.Load(), audience, scopes)
+			if err != nil {
+				return nil, err
+			}
+			// The downstream token must not outlive the user's session
+			e.mu.Lock()
+			expires := entry.expires
+			e.mu.Unlock()
+			if !expires.IsZero() && (tok.ExpiresAt.IsZero() || expires.Before(tok.ExpiresAt)) {
+				tok.ExpiresAt = expires
+			}
+			return tok, nil
+		}), e.obo.Client.RefreshBefore)
+		e.entries[key] = entry
+	} else if expires.After(entry.expires) {
+		// A newer inbound token for the same user extends the entry
+		entry.expires = expires
+	}
+	entry.token.Store(&token)
+	e.mu.Unlock()
+
+	return entry.source.Token(ctx)
+}
+
+// evictLocked removes entries whose inbound token expired and, when still
+// full, an arbitrary entry. e.mu must be held.
+func (e *OnBehalfOfExchanger) evictLocked() {
+	if len(e.entries) < e.obo.MaxEntries {
+		return
+	}
+	now := e.now()
+	for key, entry := range e.entries {
+		if !entry.expires.IsZero() && !now.Before(entry.expires) {
+			delete(e.entries, key)
+		}
+	}
+	for key := range e.entries {
+		if len(e.entries) < e.obo.MaxEntries {
+			break
+		}
+		delete(e.entries, key)
+	}
+}
+
+// exchange requests a token for audience on behalf of the token's subject
+func (e *OnBehalfOfExchanger) exchange(ctx context.Context, token, audience string, scopes []string) (*AccessToken, error) {
+	var form url.Values
+	switch e.obo.Flow {
+	case FlowTokenExchange:
+		form = url.Values{
+			"grant_type":         {grantTypeTokenExchange},
+			"subject_token":      {token},
+			"subject_token_type": {tokenTypeAccessToken},
+		}
+		if audience != "" {
+			form.Set("audience", audience)
+		}
+	default:
+		form = url.Values{
+			"grant_type":          {grantTypeJWTBearer},
+			"assertion":           {token},
+			"requested_token_use": {"on_behalf_of"},
+		}
+	}
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+
+	tok, err := e.obo.Client.grant(ctx, form)
+	if err != nil {
+		return nil, fmt.Errorf("on-behalf-of exchange: %w", err)
+	}
+	return tok, nil
+}
+
+// oboCacheKey identifies the user and downstream target. Users are
+// identified by validated claims; without a subject, by the token itself.
+func oboCacheKey(token string, claims *Claims, audience string, scopes []string) string {
+	h := sha256.New()
+	if claims != nil && claims.Subject != "" {
+		h.Write([]byte("sub\x00" + claims.Issuer + "\x00" + claims.Subject))
+	} else {
+		h.Write([]byte("token\x00" + token))
+	}
+	h.Write([]byte("\x00" + audience + "\x00" + strings.Join(scopes, " ")))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,153 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// startTestOBOEndpoint starts a token endpoint exchanging user tokens and
+// records each request form
+func startTestOBOEndpoint(t *testing.T) (*httptest.Server, func() []url.Values) {
+	t.Helper()
+	var mu sync.Mutex
+	var forms []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "svc-a" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		mu.Lock()
+		forms = append(forms, r.PostForm)
+		n := len(forms)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("downstream-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]url.Values(nil), forms...)
+	}
+}
+
+// TestOnBehalfOfTransport tests exchanging inbound tokens for downstream
+// calls made from a handler
+func TestOnBehalfOfTransport(t *testing.T) {
+	idp, forms := startTestOBOEndpoint(t)
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer downstream.Close()
+
+	obo, err := NewOnBehalfOf(OnBehalfOf{Client: ClientCredentials{TokenURL: idp.URL, ClientID: "svc-a", ClientSecret: "s3cret"}})
+	if err != nil {
+		t.Fatalf("NewOnBehalfOf failed: %v", err)
+	}
+	client := &http.Client{Transport: &Transport{Source: obo.TokenSource("api://orders")}}
+
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JWTAuth(mustCreateConfig(WithHS256(secret))))
+	router.GET("/orders", func(c *gin.Context) {
+		req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, downstream.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			c.String(http.StatusBadGateway, err.Error())
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	call := func(sub string, iat int64) string {
+		token := mustSignHS256(secret, jwt.MapClaims{"sub": sub, "iat": iat, "exp": time.Now().Add(time.Hour).Unix()})
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+		}
+		return w.Body.String()
+	}
+
+	now := time.Now().Unix()
+	if got := call("alice", now); got != "Bearer downstream-1" {
+		t.Errorf("Expected exchanged token, got %q", got)
+	}
+	// A new token for the same user reuses the exchanged token
+	if got := call("alice", now-10); got != "Bearer downstream-1" {
+		t.Errorf("Expected cached token for the same user, got %q", got)
+	}
+	if got := call("bob", now); got != "Bearer downstream-2" {
+		t.Errorf("Expected a separate exchange per user, got %q", got)
+	}
+
+	form := forms()[0]
+	if form.Get("grant_type") != grantTypeJWTBearer || form.Get("requested_token_use") != "on_behalf_of" ||
+		form.Get("scope") != "api://orders/.default" || form.Get("assertion") == "" {
+		t.Errorf("Unexpected OBO request: %v", form)
+	}
+
+	if _, err := obo.TokenSource("api://orders").Token(context.Background()); err == nil {
+		t.Error("Expected an error without an inbound token")
+	}
+}
+
+// TestOnBehalfOfTokenExchange tests the RFC 8693 flow and expiry capping
+func TestOnBehalfOfTokenExchange(t *testing.T) {
+	idp, forms := startTestOBOEndpoint(t)
+	obo, err := NewOnBehalfOf(OnBehalfOf{
+		Client: ClientCredentials{TokenURL: idp.URL, ClientID: "svc-a", ClientSecret: "s3cret"},
+		Flow:   FlowTokenExchange,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expires := time.Now().Add(10 * time.Minute)
+	ctx := WithToken(WithClaims(context.Background(), &Claims{Subject: "alice", Issuer: "idp", ExpiresAt: expires}), "user-token")
+	tok, err := obo.TokenSource("https://billing.example.com", "invoices:read").Token(ctx)
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	if !tok.ExpiresAt.Equal(expires) {
+		t.Errorf("Expected exchanged token to expire with the inbound token at %v, got %v", expires, tok.ExpiresAt)
+	}
+
+	form := forms()[0]
+	want := url.Values{
+		"grant_type":         {grantTypeTokenExchange},
+		"subject_token":      {"user-token"},
+		"subject_token_type": {tokenTypeAccessToken},
+		"audience":           {"https://billing.example.com"},
+		"scope":              {"invoices:read"},
+	}
+	for key := range want {
+		if form.Get(key) != want.Get(key) {
+			t.Errorf("Expected %s=%q, got %q", key, want.Get(key), form.Get(key))
+		}
+	}
+
+	if _, err := NewOnBehalfOf(OnBehalfOf{Client: ClientCredentials{TokenURL: idp.URL}}); err == nil {
+		t.Error("Expected a client without ID to be rejected")
+	}
+}
@@ -0,0 +1,105 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// oidcDiscoveryPath is appended to the issuer URL to locate the provider
+// metadata (OpenID Connect Discovery 1.0, section 4)
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+// oidcMetadata holds the provider metadata used for token validation
+type oidcMetadata struct {
+	Issuer      string   `json:"issuer"`
+	JWKSURI     string   `json:"jwks_uri"`
+	SigningAlgs []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// WithOIDCDiscovery configures validation from an OpenID provider's
+// metadata, fetched from issuerURL + "/.well-known/openid-configuration"
+// while NewConfig runs: tokens must carry the advertised issuer as iss and
+// are verified with keys from its jwks_uri (see WithJWKSURL) for the
+// advertised asymmetric signing algorithms (RS256 when none are listed).
+// NewConfig fails if the metadata cannot be fetched or its issuer does not
+// match issuerURL.
+//
+// opts configure the metadata and JWKS fetches; WithJWKSAlgorithms
+// overrides the advertised algorithms.
+//
+//	jwtauth.WithOIDCDiscovery("https://keycloak.example.com/realms/acme")
+func WithOIDCDiscovery(issuerURL string, opts ...JWKSOption) ConfigOption {
+	return func(c *Config) error {
+		if err := checkKeySourceURL("OIDC issuer", issuerURL); err != nil {
+			return err
+		}
+		o := jwksOptions{timeout: jwksFetchTimeout}
+		for _, opt := range opts {
+			opt(&o)
+		}
+		if o.timeout <= 0 {
+			return fmt.Errorf("JWKS fetch timeout must be positive, got %v", o.timeout)
+		}
+		client := o.client
+		if client == nil {
+			client = &http.Client{}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+		defer cancel()
+		meta, err := fetchOIDCMetadata(ctx, client, issuerURL)
+		if err != nil {
+			return err
+		}
+
+		var algs []string
+		for _, alg := range meta.SigningAlgs {
+			if isJWKSAlgorithm(alg) {
+				algs = append(algs, alg)
+			}
+		}
+		if len(algs) == 0 {
+			algs = []string{"RS256"} // Required of every OpenID provider
+		}
+		return applyOptions(c,
+			WithJWKSURL(meta.JWKSURI, append([]JWKSOption{WithJWKSAlgorithms(algs...)}, opts...)...),
+			WithIssuers(meta.Issuer),
+		)
+	}
+}
+
+// fetchOIDCMetadata downloads and checks the provider metadata of issuerURL
+func fetchOIDCMetadata(ctx context.Context, client *http.Client, issuerURL string) (*oidcMetadata, error) {
+	discoveryURL := strings.TrimSuffix(issuerURL, "/") + oidcDiscoveryPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching OIDC discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching OIDC discovery document: %s returned %s", discoveryURL, resp.Status)
+	}
+
+	var meta oidcMetadata
+	if err := json.NewDecoder(io.LimitReader(resp.Body, jwksMaxBytes)).Decode(&meta); err != nil {
+		return nil, fmt.Errorf("failed to decode OIDC discovery document: %w", err)
+	}
+	// The issuer must be identical to the URL the metadata was retrieved
+	// from (section 4.3), tolerating a trailing slash difference
+	if strings.TrimSuffix(meta.Issuer, "/") != strings.TrimSuffix(issuerURL, "/") {
+		return nil, fmt.Errorf("OIDC discovery document issuer %q does not match %q", meta.Issuer, issuerURL)
+	}
+	if meta.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery document for %s has no jwks_uri", issuerURL)
+	}
+	return &meta, nil
+}
@@ -0,0 +1,124 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// serveOIDC starts an OpenID provider serving metadata and keys. metadata
+// receives the server URL and returns the discovery document.
+func serveOIDC(t *testing.T, metadata func(url string) map[string]interface{}, keys ...JSONWebKey) string {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/realms/acme/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(metadata(srv.URL))
+		case "/realms/acme/certs":
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/realms/acme"
+}
+
+// TestWithOIDCDiscovery tests issuer, key and algorithm configuration from provider metadata
+func TestWithOIDCDiscovery(t *testing.T) {
+	ecKey, rsaKey := mustGenerateECKey(), mustGenerateRSAKey()
+	issuer := serveOIDC(t, func(url string) map[string]interface{} {
+		return map[string]interface{}{
+			"issuer":                                url + "/realms/acme",
+			"jwks_uri":                              url + "/realms/acme/certs",
+			"id_token_signing_alg_values_supported": []string{"ES256", "HS256", "none"},
+		}
+	},
+		JSONWebKey{KeyID: "ec-1", Algorithm: "ES256", Key: &ecKey.PublicKey},
+		JSONWebKey{KeyID: "rsa-1", Algorithm: "RS256", Key: &rsaKey.PublicKey},
+	)
+	cfg := mustCreateConfig(WithOIDCDiscovery(issuer))
+
+	if got := cfg.AvailableAlgorithms(); len(got) != 1 || got[0] != "ES256" {
+		t.Errorf("expected only the advertised asymmetric algorithm ES256, got %v", got)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, iss string) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "user-1", "iss": iss, "exp": time.Now().Add(time.Hour).Unix()})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"advertised issuer and algorithm", sign(jwt.SigningMethodES256, "ec-1", ecKey, issuer), false},
+		{"other issuer", sign(jwt.SigningMethodES256, "ec-1", ecKey, "https://evil.example.com"), true},
+		{"algorithm not advertised", sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, issuer), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseToken(context.Background(), tt.token, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestWithOIDCDiscoveryDefaults tests RS256 when no algorithms are advertised
+// and WithJWKSAlgorithms overriding the advertised ones
+func TestWithOIDCDiscoveryDefaults(t *testing.T) {
+	issuer := serveOIDC(t, func(url string) map[string]interface{} {
+		return map[string]interface{}{"issuer": url + "/realms/acme/", "jwks_uri": url + "/realms/acme/certs"}
+	})
+
+	if got := mustCreateConfig(WithOIDCDiscovery(issuer)).AvailableAlgorithms(); len(got) != 1 || got[0] != "RS256" {
+		t.Errorf("expected RS256 by default, got %v", got)
+	}
+	if got := mustCreateConfig(WithOIDCDiscovery(issuer, WithJWKSAlgorithms("PS256"))).AvailableAlgorithms(); len(got) != 1 || got[0] != "PS256" {
+		t.Errorf("expected the overriding algorithm PS256, got %v", got)
+	}
+}
+
+// TestWithOIDCDiscoveryInvalid tests rejected provider metadata
+func TestWithOIDCDiscoveryInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata func(url string) map[string]interface{}
+	}{
+		{"issuer mismatch", func(url string) map[string]interface{} {
+			return map[string]interface{}{"issuer": "https://other.example.com", "jwks_uri": url + "/realms/acme/certs"}
+		}},
+		{"missing jwks_uri", func(url string) map[string]interface{} {
+			return map[string]interface{}{"issuer": url + "/realms/acme"}
+		}},
+		{"plain HTTP jwks_uri", func(url string) map[string]interface{} {
+			return map[string]interface{}{"issuer": url + "/realms/acme", "jwks_uri": "http://keys.example.com/certs"}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := serveOIDC(t, tt.metadata)
+			if _, err := NewConfig(WithOIDCDiscovery(issuer)); err == nil {
+				t.Error("expected configuration error")
+			}
+		})
+	}
+
+	if _, err := NewConfig(WithOIDCDiscovery("http://idp.example.com")); err == nil {
+		t.Error("expected error for a plain HTTP issuer")
+	}
+}
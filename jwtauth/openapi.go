@@ -0,0 +1,130 @@
+package jwtauth
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// OpenAPI component names used by OpenAPIComponents
+const (
+	OpenAPIErrorSchema     = "JWTAuthError"
+	OpenAPIUnauthorized    = "JWTAuthUnauthorized"
+	OpenAPITooManyRequests = "JWTAuthTooManyRequests"
+	OpenAPIForbidden       = "JWTAuthForbidden"
+)
+
+// openAPIUnknownReason is the reason reported for errors that are not a
+// *ValidationError (see getErrorCode)
+const openAPIUnknownReason = "UNKNOWN"
+
+// OpenAPIComponents returns OpenAPI 3 components describing the
+// middleware's error responses, built from the ErrorCodes registry so they
+// stay accurate as codes are added. The result holds "schemas" (the
+// JWTAuthError body with its reason enum) and "responses" (one per HTTP
+// status the middleware writes); merge it into a spec's "components" and
+// reference responses as "#/components/responses/JWTAuthUnauthorized".
+//
+// 403 covers only the authorization requirements configured on the
+// middleware; checks in handlers are the application's to document.
+// Deprecated codes are left out of the enum.
+func OpenAPIComponents() map[string]interface{} {
+	byStatus := map[int][]ErrorCodeInfo{}
+	var reasons []string
+	for _, info := range ErrorCodes() {
+		if info.Deprecated {
+			continue
+		}
+		info, _ = info.Code.Info() // Registry defaults filled in
+		byStatus[info.HTTPStatus] = append(byStatus[info.HTTPStatus], info)
+		reasons = append(reasons, string(info.Code))
+	}
+	reasons = append(reasons, openAPIUnknownReason)
+
+	var table strings.Builder
+	table.WriteString("Machine-readable failure reason:\n")
+	for _, info := range ErrorCodes() {
+		if !info.Deprecated {
+			fmt.Fprintf(&table, "\n- `%s`: %s", info.Code, info.Description)
+		}
+	}
+	fmt.Fprintf(&table, "\n- `%s`: Unexpected internal failure", openAPIUnknownReason)
+
+	schemas := map[string]interface{}{
+		OpenAPIErrorSchema: map[string]interface{}{
+			"type":     "object",
+			"required": []string{"error", "reason"},
+			"properties": map[string]interface{}{
+				"error": map[string]interface{}{
+					"type": "string",
+					"enum": []string{"unauthorized", "forbidden", "rate_limited"},
+				},
+				"reason": map[string]interface{}{
+					"type":        "string",
+					"enum":        reasons,
+					"description": table.String(),
+				},
+				"message": map[string]interface{}{
+					"type":        "string",
+					"description": "Details for " + strings.Join(messageCodes(), ", "),
+				},
+			},
+		},
+	}
+
+	responses := map[string]interface{}{}
+	statuses := make([]int, 0, len(byStatus))
+	for status := range byStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		name := OpenAPIUnauthorized
+		switch status {
+		case http.StatusTooManyRequests:
+			name = OpenAPITooManyRequests
+		case http.StatusForbidden:
+			name = OpenAPIForbidden
+		}
+
+		codes := make([]string, 0, len(byStatus[status]))
+		for _, info := range byStatus[status] {
+			codes = append(codes, string(info.Code))
+		}
+		if status == http.StatusUnauthorized {
+			codes = append(codes, openAPIUnknownReason)
+		}
+
+		response := map[string]interface{}{
+			"description": fmt.Sprintf("%s (reason: %s)", http.StatusText(status), strings.Join(codes, ", ")),
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{"$ref": "#/components/schemas/" + OpenAPIErrorSchema},
+				},
+			},
+		}
+		if status == http.StatusTooManyRequests {
+			response["headers"] = map[string]interface{}{
+				"Retry-After": map[string]interface{}{
+					"description": "Seconds until the tenant quota allows another request",
+					"schema":      map[string]interface{}{"type": "integer"},
+				},
+			}
+		}
+		responses[name] = response
+	}
+
+	return map[string]interface{}{"schemas": schemas, "responses": responses}
+}
+
+// messageCodes lists the codes whose responses include a message
+func messageCodes() []string {
+	var codes []string
+	for _, info := range ErrorCodes() {
+		if info.HasMessage && !info.Deprecated {
+			codes = append(codes, string(info.Code))
+		}
+	}
+	return codes
+}
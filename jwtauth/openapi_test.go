@@ -0,0 +1,54 @@
+package jwtauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// TestOpenAPIComponents tests the generated error response components
+func TestOpenAPIComponents(t *testing.T) {
+	components := OpenAPIComponents()
+	if _, err := json.Marshal(components); err != nil {
+		t.Fatalf("Expected components to marshal, got %v", err)
+	}
+
+	schema := components["schemas"].(map[string]interface{})[OpenAPIErrorSchema].(map[string]interface{})
+	reason := schema["properties"].(map[string]interface{})["reason"].(map[string]interface{})
+	enum := reason["enum"].([]string)
+	for _, info := range ErrorCodes() {
+		if got := slices.Contains(enum, string(info.Code)); got == info.Deprecated {
+			t.Errorf("%s: in enum = %v, deprecated = %v", info.Code, got, info.Deprecated)
+		}
+	}
+
+	responses := components["responses"].(map[string]interface{})
+	if _, ok := responses[OpenAPIUnauthorized]; !ok {
+		t.Error("Expected 401 response component")
+	}
+	limited, ok := responses[OpenAPITooManyRequests].(map[string]interface{})
+	if !ok || limited["headers"] == nil {
+		t.Error("Expected 429 response component with Retry-After header")
+	}
+
+	// A real middleware response matches the schema
+	cfg := mustCreateConfig(WithHS256([]byte("test-secret-key-min-32-bytes-long!!")))
+	router := createTestRouter(cfg)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/protected", nil))
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range schema["required"].([]string) {
+		if _, ok := body[field]; !ok {
+			t.Errorf("Response lacks required field %q", field)
+		}
+	}
+	if !slices.Contains(enum, body["reason"].(string)) {
+		t.Errorf("Response reason %v not in enum", body["reason"])
+	}
+}
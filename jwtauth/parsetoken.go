@@ -0,0 +1,89 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MaxTokenSize is the largest token ParseToken accepts, in bytes. Real
+// tokens are far smaller; HTTP servers commonly cap header lines near 8 KiB.
+const MaxTokenSize = 8192
+
+// ParseToken verifies a compact JWS token and validates its claims under
+// cfg, as the middleware does before applying request policies (IP and
+// device binding, revocation, rate limits).
+//
+// ParseToken is safe to call with arbitrary input, e.g. from a fuzzer or an
+// untrusted message: before any decoding, tokens must be at most
+// MaxTokenSize bytes and consist of three base64url segments, and a panic
+// anywhere in validation is recovered. Every failure is a *ValidationError.
+func ParseToken(ctx context.Context, tokenString string, cfg *Config) (claims *Claims, err error) {
+	if cfg == nil || cfg.parser == nil {
+		return nil, NewValidationError(ErrConfigError, "configuration is required (use NewConfig)", nil)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if cfg.anyOf != nil {
+		return parseAnyOf(ctx, tokenString, cfg)
+	}
+	defer cfg.lifecycle.track()()
+	if err := checkTokenBounds(tokenString); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			claims = nil
+			err = NewValidationError(ErrMalformed, "malformed token", fmt.Errorf("panic during validation: %v", r))
+		}
+	}()
+	return parseAndValidateJWTContext(ctx, tokenString, cfg)
+}
+
+// checkTokenBounds rejects input that cannot be a compact JWS before it
+// reaches the decoder: oversized tokens, unencoded or detached payloads, a
+// wrong segment count, an empty header, characters outside the unpadded
+// base64url alphabet and segment lengths no base64 encoding produces
+func checkTokenBounds(tokenString string) error {
+	if tokenString == "" {
+		return NewValidationError(ErrMissingToken, "token is empty", nil)
+	}
+	if len(tokenString) > MaxTokenSize {
+		return NewValidationError(ErrMalformed, fmt.Sprintf("token exceeds %d bytes", MaxTokenSize), nil)
+	}
+
+	// An unencoded payload may contain any character; name it first
+	if header, _, _ := strings.Cut(tokenString, "."); isUnencodedPayloadHeader(header) {
+		return errUnencodedPayload()
+	}
+
+	segments := strings.Split(tokenString, ".")
+	if len(segments) != 3 {
+		return NewValidationError(ErrMalformed, fmt.Sprintf("token must have 3 segments, got %d", len(segments)), nil)
+	}
+	if segments[0] == "" {
+		return NewValidationError(ErrMalformed, "token header cannot be empty", nil)
+	}
+	if segments[1] == "" {
+		return errDetachedPayload()
+	}
+
+	for i := 0; i < len(tokenString); i++ {
+		if c := tokenString[i]; c != '.' && !isBase64URLChar(c) {
+			return NewValidationError(ErrMalformed, fmt.Sprintf("token contains invalid character at offset %d", i), nil)
+		}
+	}
+	for _, segment := range segments {
+		if len(segment)%4 == 1 {
+			return NewValidationError(ErrMalformed, "token segment is truncated", nil)
+		}
+	}
+	return nil
+}
+
+// isBase64URLChar reports whether c is in the unpadded base64url alphabet
+func isBase64URLChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
@@ -0,0 +1,114 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const fuzzSecret = "test-secret-key-min-32-bytes-long!!"
+
+// TestParseToken tests input bounds and validation through ParseToken
+func TestParseToken(t *testing.T) {
+	cfg := mustCreateConfig(WithHS256([]byte(fuzzSecret)))
+	valid := mustSignHS256([]byte(fuzzSecret), jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+	header, payload, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode ErrorCode // Empty when the token is valid
+	}{
+		{"valid", valid, ""},
+		{"empty", "", ErrMissingToken},
+		{"oversized", valid + strings.Repeat("A", MaxTokenSize), ErrMalformed},
+		{"two segments", header + "." + payload[:strings.Index(payload, ".")], ErrMalformed},
+		{"four segments", valid + ".AAAA", ErrMalformed},
+		{"empty payload", header + "..sig", ErrDetachedPayload},
+		{"padding", strings.Replace(valid, ".", "=.", 1), ErrMalformed},
+		{"whitespace", " " + valid, ErrMalformed},
+		{"fullwidth dot", strings.Replace(valid, ".", "\uff0e", 1), ErrMalformed},
+		{"zero-width space", valid[:10] + "\u200b" + valid[10:], ErrMalformed},
+		{"truncated segment", header[:len(header)-len(header)%4-3] + "." + payload, ErrMalformed},
+		{"tampered signature", valid[:len(valid)-2] + "AA", ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseToken(context.Background(), tt.token, cfg)
+			if tt.wantCode == "" {
+				if err != nil || claims == nil || claims.Subject != "user123" {
+					t.Fatalf("Expected valid claims, got %v %v", claims, err)
+				}
+				return
+			}
+			if getErrorCode(err) != string(tt.wantCode) {
+				t.Errorf("Expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+
+	if _, err := ParseToken(context.Background(), valid, nil); getErrorCode(err) != string(ErrConfigError) {
+		t.Errorf("Expected CONFIG_ERROR for nil config, got %v", err)
+	}
+}
+
+// FuzzParseToken checks that ParseToken never panics and only returns
+// ValidationErrors. Run with: go test -fuzz=FuzzParseToken ./jwtauth/
+func FuzzParseToken(f *testing.F) {
+	secret := []byte(fuzzSecret)
+	cfg := mustCreateConfig(WithHS256(secret), WithRequiredClaims("sub"))
+	enc := base64.RawURLEncoding.EncodeToString
+
+	valid := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+	seeds := []string{
+		valid,
+		valid[:len(valid)/2], // Truncated
+		"..",
+		"a.b.c",
+		"eyJ.eyJ.",
+		// Malformed headers
+		enc([]byte(`{"alg":"none"}`)) + "." + enc([]byte(`{"sub":"x"}`)) + ".",
+		enc([]byte(`{"alg":["HS256"]}`)) + "." + enc([]byte(`{}`)) + ".sig",
+		enc([]byte(`{"alg":"HS256","kid":{"a":1}}`)) + "." + enc([]byte(`{}`)) + ".sig",
+		enc([]byte(`{"alg":"HS256"`)) + "." + enc([]byte(`{}`)) + ".sig",
+		enc([]byte(`null`)) + "." + enc([]byte(`null`)) + ".sig",
+		enc([]byte(`{"alg":"HS256","crit":["exp"]}`)) + "." + enc([]byte(`{"exp":1e400}`)) + ".sig",
+		// Truncated and padded base64
+		enc([]byte(`{"alg":"HS256"}`))[:5] + "." + enc([]byte(`{}`)) + ".sig",
+		strings.Replace(valid, ".", "==.", 1),
+		// Unicode tricks
+		strings.Replace(valid, ".", "\u2024", 1),
+		"\u202e" + valid,
+		valid + "\x00",
+		enc([]byte(`{"alg":"HS256"}`)) + "." + enc([]byte(`{"sub":"\ud800"}`)) + ".sig",
+		enc([]byte("{\"alg\":\"HS256\"}\xff")) + "." + enc([]byte(`{"exp":"soon"}`)) + ".sig",
+		// Deep nesting
+		enc([]byte(`{"alg":"HS256"}`)) + "." + enc([]byte(`{"sub":`+strings.Repeat("[", 1000)+`}`)) + ".sig",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, token string) {
+		claims, err := ParseToken(context.Background(), token, cfg)
+		if err == nil {
+			if claims == nil {
+				t.Fatal("Expected claims when no error is returned")
+			}
+			return
+		}
+		var valErr *ValidationError
+		if !errors.As(err, &valErr) {
+			t.Fatalf("Expected ValidationError, got %T: %v", err, err)
+		}
+		if claims != nil {
+			t.Fatal("Expected nil claims with an error")
+		}
+	})
+}
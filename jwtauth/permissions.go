@@ -0,0 +1,159 @@
+package jwtauth
+
+import (
+	"fmt"
+	"slices"
+)
+
+// PermissionMapper derives permissions from validated claims, translating
+// an IdP's representation of what a caller may do into plain strings
+type PermissionMapper interface {
+	Permissions(claims *Claims) []string
+}
+
+// PermissionMapperFunc adapts a function to the PermissionMapper interface
+type PermissionMapperFunc func(claims *Claims) []string
+
+// Permissions implements PermissionMapper
+func (f PermissionMapperFunc) Permissions(claims *Claims) []string {
+	return f(claims)
+}
+
+// WithPermissionMapping fills Claims.Permissions with the permissions
+// returned by mappers, in order and without duplicates, so handlers can
+// check Claims.HasPermission whichever IdP issued the token:
+//
+//	jwtauth.WithPermissionMapping(jwtauth.KeycloakPermissions("orders-api"))
+//	jwtauth.WithPermissionMapping(jwtauth.Auth0Permissions())
+//	jwtauth.WithPermissionMapping(jwtauth.AzureADPermissions(groupNames))
+//
+// Mapping runs during validation, before WithClaimAllowlist drops claims, so
+// the source claims need not be kept.
+func WithPermissionMapping(mappers ...PermissionMapper) ConfigOption {
+	return func(c *Config) error {
+		if len(mappers) == 0 {
+			return fmt.Errorf("permission mapping requires at least one mapper")
+		}
+		for _, mapper := range mappers {
+			if mapper == nil {
+				return fmt.Errorf("permission mappers cannot be nil")
+			}
+		}
+		c.permissionMappers = mappers
+		return nil
+	}
+}
+
+// HasPermission reports whether permission was mapped from the token's
+// claims (see WithPermissionMapping)
+func (c *Claims) HasPermission(permission string) bool {
+	return permission != "" && slices.Contains(c.Permissions, permission)
+}
+
+// mapPermissions applies the configured permission mappers to claims
+func (c *Config) mapPermissions(claims *Claims) []string {
+	if len(c.permissionMappers) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	permissions := []string{}
+	for _, mapper := range c.permissionMappers {
+		for _, permission := range mapper.Permissions(claims) {
+			if permission != "" && !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions
+}
+
+// ClaimPermissions maps the values of the claim at path (a string or an
+// array of strings) to permissions unchanged. path is a claim name or a
+// dot-separated path into nested objects, as for WithRoleClaim.
+func ClaimPermissions(path string) PermissionMapper {
+	return PermissionMapperFunc(func(claims *Claims) []string {
+		return claimStrings(lookupClaimPath(claims, path))
+	})
+}
+
+// ScopePermissions maps the granted scopes (scope or scp claim) to
+// permissions
+func ScopePermissions() PermissionMapper {
+	return PermissionMapperFunc(func(claims *Claims) []string {
+		granted := tokenScopes(claims)
+		scopes := make([]string, 0, len(granted))
+		for scope := range granted {
+			scopes = append(scopes, scope)
+		}
+		slices.Sort(scopes)
+		return scopes
+	})
+}
+
+// Auth0Permissions maps the permissions claim Auth0 adds to access tokens
+// when RBAC and "Add Permissions in the Access Token" are enabled for the
+// API
+func Auth0Permissions() PermissionMapper {
+	return ClaimPermissions("permissions")
+}
+
+// KeycloakPermissions maps Keycloak realm roles (realm_access.roles) and
+// the client roles of clients (resource_access.<client>.roles) to
+// permissions. Realm and client roles are merged unprefixed; name only the
+// clients whose roles apply to this service.
+func KeycloakPermissions(clients ...string) PermissionMapper {
+	return PermissionMapperFunc(func(claims *Claims) []string {
+		var permissions []string
+		if realm, ok := claims.Custom["realm_access"].(map[string]interface{}); ok {
+			permissions = append(permissions, claimStrings(realm["roles"])...)
+		}
+		resources, _ := claims.Custom["resource_access"].(map[string]interface{})
+		for _, client := range clients {
+			// Client IDs may contain dots, so they are not looked up as a path
+			if access, ok := resources[client].(map[string]interface{}); ok {
+				permissions = append(permissions, claimStrings(access["roles"])...)
+			}
+		}
+		return permissions
+	})
+}
+
+// AzureADPermissions maps Azure AD (Entra ID) app roles (roles claim) and
+// security groups (groups claim) to permissions. Groups are issued as object
+// IDs; groups maps the IDs of relevant groups to permission names, and other
+// groups are ignored. With a nil map, group IDs are used as issued.
+//
+// Users in more groups than fit in a token receive a _claim_names overage
+// reference instead of the groups claim; their groups are not mapped, so
+// emit groups as app roles or filter them in the app registration.
+func AzureADPermissions(groups map[string]string) PermissionMapper {
+	return PermissionMapperFunc(func(claims *Claims) []string {
+		permissions := claimStrings(claims.Custom["roles"])
+		for _, id := range claimStrings(claims.Custom["groups"]) {
+			if groups == nil {
+				permissions = append(permissions, id)
+			} else if name, ok := groups[id]; ok {
+				permissions = append(permissions, name)
+			}
+		}
+		return permissions
+	})
+}
+
+// claimStrings returns a string claim value, or the strings of an array
+func claimStrings(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
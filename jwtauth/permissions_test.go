@@ -0,0 +1,140 @@
+package jwtauth
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestPermissionMapping tests mapping IdP-specific claims to permissions
+func TestPermissionMapping(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	groupID := "6f1c1d9e-0b7a-4c1e-9a3f-2d8e5b4c7a10"
+
+	tests := []struct {
+		name   string
+		mapper PermissionMapper
+		claims jwt.MapClaims
+		want   []string
+	}{
+		{
+			"keycloak",
+			KeycloakPermissions("orders-api", "https://billing.example.com"),
+			jwt.MapClaims{
+				"realm_access": map[string]interface{}{"roles": []string{"offline_access", "orders:read"}},
+				"resource_access": map[string]interface{}{
+					"orders-api":                  map[string]interface{}{"roles": []string{"orders:write", "orders:read"}},
+					"https://billing.example.com": map[string]interface{}{"roles": []string{"invoices:read"}},
+					"account":                     map[string]interface{}{"roles": []string{"manage-account"}},
+				},
+			},
+			[]string{"offline_access", "orders:read", "orders:write", "invoices:read"},
+		},
+		{
+			"auth0",
+			Auth0Permissions(),
+			jwt.MapClaims{"permissions": []string{"read:orders", "create:orders"}, "scope": "openid"},
+			[]string{"read:orders", "create:orders"},
+		},
+		{
+			"azure ad",
+			AzureADPermissions(map[string]string{groupID: "orders:admin"}),
+			jwt.MapClaims{"roles": []string{"Orders.Read"}, "groups": []string{groupID, "0e4d1a77-5c2b-4f7e-8d61-93a0b2c4e5f6"}},
+			[]string{"Orders.Read", "orders:admin"},
+		},
+		{
+			"azure ad raw groups",
+			AzureADPermissions(nil),
+			jwt.MapClaims{"groups": []string{groupID}},
+			[]string{groupID},
+		},
+		{
+			"nested claim path",
+			ClaimPermissions("authz.permissions"),
+			jwt.MapClaims{"authz": map[string]interface{}{"permissions": "reports:read"}},
+			[]string{"reports:read"},
+		},
+		{
+			"scopes",
+			ScopePermissions(),
+			jwt.MapClaims{"scope": "orders:write orders:read"},
+			[]string{"orders:read", "orders:write"},
+		},
+		{
+			"no source claims",
+			KeycloakPermissions("orders-api"),
+			jwt.MapClaims{},
+			[]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mustCreateConfig(WithHS256(secret), WithPermissionMapping(tt.mapper))
+			tt.claims["sub"] = "user-1"
+			tt.claims["exp"] = time.Now().Add(time.Hour).Unix()
+			claims, err := ParseToken(context.Background(), mustSignHS256(secret, tt.claims), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(claims.Permissions, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, claims.Permissions)
+			}
+		})
+	}
+
+	// Mappers are merged in order without duplicates, before minimization
+	cfg := mustCreateConfig(WithHS256(secret),
+		WithPermissionMapping(Auth0Permissions(), ScopePermissions()),
+		WithClaimAllowlist("tenant"),
+	)
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "permissions": []string{"orders:read"}, "scope": "orders:read orders:write", "exp": time.Now().Add(time.Hour).Unix()})
+	claims, _, err := authenticateToken(context.Background(), token, "req-1", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(claims.Permissions, []string{"orders:read", "orders:write"}) {
+		t.Errorf("expected merged permissions, got %q", claims.Permissions)
+	}
+	if _, ok := claims.Custom["permissions"]; ok {
+		t.Error("expected the source claim to be minimized")
+	}
+	if !claims.HasPermission("orders:write") || claims.HasPermission("orders:delete") {
+		t.Errorf("unexpected HasPermission results for %q", claims.Permissions)
+	}
+
+	// Without mapping, Permissions stays nil
+	plain, err := ParseToken(context.Background(), token, mustCreateConfig(WithHS256(secret)))
+	if err != nil || plain.Permissions != nil {
+		t.Errorf("expected no permissions without mapping, got %q, %v", plain.Permissions, err)
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithPermissionMapping()); err == nil {
+		t.Error("expected error without mappers")
+	}
+}
+
+// TestPermissionMappingTokenCache tests that cached claims are mapped by the
+// reading Config
+func TestPermissionMappingTokenCache(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cache := NewMemoryCache(16)
+	auth0 := mustCreateConfig(WithHS256(secret), WithTokenCache(cache, time.Minute), WithPermissionMapping(Auth0Permissions()))
+	scopes := mustCreateConfig(WithHS256(secret), WithTokenCache(cache, time.Minute), WithPermissionMapping(ScopePermissions()))
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "permissions": []string{"a"}, "scope": "b", "exp": time.Now().Add(time.Hour).Unix()})
+
+	for _, tc := range []struct {
+		cfg  *Config
+		want string
+	}{{auth0, "a"}, {scopes, "b"}, {auth0, "a"}} {
+		claims, err := ParseToken(context.Background(), token, tc.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(claims.Permissions, []string{tc.want}) {
+			t.Errorf("expected [%s], got %q", tc.want, claims.Permissions)
+		}
+	}
+}
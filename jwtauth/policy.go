@@ -0,0 +1,289 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// PolicyInput is the input document sent to an authorization policy
+type PolicyInput struct {
+	Protocol string                 `json:"protocol"`         // "http" or "grpc"
+	Method   string                 `json:"method,omitempty"` // HTTP method; empty for gRPC
+	Path     string                 `json:"path"`             // URL path, or the full gRPC method ("/pkg.Service/Method")
+	Headers  map[string]string      `json:"headers,omitempty"`
+	ClientIP string                 `json:"client_ip,omitempty"`
+	Tenant   string                 `json:"tenant,omitempty"`
+	Claims   map[string]interface{} `json:"claims"` // Claims by JWT name; exp, nbf and iat in Unix seconds
+
+	Permissions []string `json:"permissions,omitempty"` // Claims.Permissions (WithPermissionMapping)
+}
+
+// PolicyDecision is the outcome of an authorization policy
+type PolicyDecision struct {
+	Allow bool
+
+	// Reason is a policy-specific code for a denial (e.g.
+	// "tenant_suspended"), returned to the client as the error message
+	Reason string
+}
+
+// Policy decides whether an authenticated request may proceed. Errors mean
+// no decision could be made; the request is then rejected with
+// POLICY_UNAVAILABLE.
+type Policy interface {
+	Evaluate(ctx context.Context, input *PolicyInput) (PolicyDecision, error)
+}
+
+// PolicyFunc adapts a function to the Policy interface, e.g. to evaluate an
+// embedded Rego policy prepared with the OPA Go SDK
+type PolicyFunc func(ctx context.Context, input *PolicyInput) (PolicyDecision, error)
+
+// Evaluate implements Policy
+func (f PolicyFunc) Evaluate(ctx context.Context, input *PolicyInput) (PolicyDecision, error) {
+	return f(ctx, input)
+}
+
+// PolicyOption configures WithPolicy
+type PolicyOption func(*policyOptions)
+
+type policyOptions struct {
+	headers []string
+}
+
+// WithPolicyHeaders adds the named request headers (gRPC metadata keys) to
+// the policy input. Headers are left out unless named, so credentials such
+// as Authorization and Cookie only reach the policy when listed.
+func WithPolicyHeaders(names ...string) PolicyOption {
+	return func(o *policyOptions) {
+		o.headers = append(o.headers, names...)
+	}
+}
+
+// WithPolicy delegates authorization to policy, e.g. an OPA server or an
+// embedded Rego policy. It runs for every request JWTAuth, SSE or the gRPC
+// interceptors authenticate, after route and method requirements, with the
+// claims and request metadata as input. Denied requests are rejected with
+// POLICY_DENIED (403, gRPC PERMISSION_DENIED) and the decision's reason as
+// message; if the policy fails, requests are rejected with
+// POLICY_UNAVAILABLE (503) rather than risk admitting a denied one.
+func WithPolicy(policy Policy, opts ...PolicyOption) ConfigOption {
+	return func(c *Config) error {
+		if policy == nil {
+			return fmt.Errorf("policy cannot be nil")
+		}
+		if opa, ok := policy.(OPA); ok {
+			if u, err := url.Parse(opa.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid OPA URL %q", opa.URL)
+			}
+		}
+		o := &policyOptions{}
+		for _, opt := range opts {
+			opt(o)
+		}
+		for i, name := range o.headers {
+			if name == "" {
+				return fmt.Errorf("policy header names cannot be empty")
+			}
+			o.headers[i] = textproto.CanonicalMIMEHeaderKey(name)
+		}
+		c.policy = policy
+		c.policyOptions = o
+		return nil
+	}
+}
+
+// checkHTTPPolicy evaluates the configured policy for an authenticated
+// HTTP request
+func (c *Config) checkHTTPPolicy(r *http.Request, claims *Claims, clientIP, tenant string) error {
+	if c.policy == nil {
+		return nil
+	}
+	return c.checkPolicy(r.Context(), c.httpPolicyInput(r, claims, clientIP, tenant))
+}
+
+// checkGRPCPolicy evaluates the configured policy for an authenticated RPC
+func (c *Config) checkGRPCPolicy(ctx context.Context, method string, md metadata.MD, claims *Claims, clientIP, tenant string) error {
+	if c.policy == nil {
+		return nil
+	}
+	return c.checkPolicy(ctx, c.grpcPolicyInput(method, md, claims, clientIP, tenant))
+}
+
+// checkPolicy evaluates the configured policy for input
+func (c *Config) checkPolicy(ctx context.Context, input *PolicyInput) error {
+	decision, err := c.policy.Evaluate(ctx, input)
+	if err != nil {
+		return NewValidationError(ErrPolicyUnavailable, "authorization policy unavailable", err)
+	}
+	if !decision.Allow {
+		reason := decision.Reason
+		if reason == "" {
+			reason = "denied by policy"
+		}
+		return NewValidationError(ErrPolicyDenied, reason, nil)
+	}
+	return nil
+}
+
+// httpPolicyInput builds the policy input for an HTTP request
+func (c *Config) httpPolicyInput(r *http.Request, claims *Claims, clientIP, tenant string) *PolicyInput {
+	input := &PolicyInput{
+		Protocol: "http",
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		ClientIP: clientIP,
+		Tenant:   tenant,
+		Claims:   policyClaims(claims),
+
+		Permissions: claims.Permissions,
+	}
+	for _, name := range c.policyOptions.headers {
+		if value := r.Header.Get(name); value != "" {
+			if input.Headers == nil {
+				input.Headers = map[string]string{}
+			}
+			input.Headers[name] = value
+		}
+	}
+	return input
+}
+
+// grpcPolicyInput builds the policy input for an RPC
+func (c *Config) grpcPolicyInput(method string, md metadata.MD, claims *Claims, clientIP, tenant string) *PolicyInput {
+	input := &PolicyInput{
+		Protocol: "grpc",
+		Path:     method,
+		ClientIP: clientIP,
+		Tenant:   tenant,
+		Claims:   policyClaims(claims),
+
+		Permissions: claims.Permissions,
+	}
+	for _, name := range c.policyOptions.headers {
+		if values := md.Get(name); len(values) > 0 && values[0] != "" {
+			if input.Headers == nil {
+				input.Headers = map[string]string{}
+			}
+			input.Headers[name] = values[0]
+		}
+	}
+	return input
+}
+
+// policyClaims returns claims by their JWT names, with times in Unix
+// seconds as in the token
+func policyClaims(claims *Claims) map[string]interface{} {
+	out := make(map[string]interface{}, len(claims.Custom)+7)
+	for name, value := range claims.Custom {
+		out[name] = value
+	}
+	for _, name := range []string{"sub", "iss", "aud", "jti", "exp", "nbf", "iat"} {
+		value, ok := claims.Get(name)
+		if !ok {
+			continue
+		}
+		if t, isTime := value.(time.Time); isTime {
+			value = t.Unix()
+		}
+		out[name] = value
+	}
+	return out
+}
+
+// maxPolicyResponseBytes bounds OPA responses
+const maxPolicyResponseBytes = 1 << 20
+
+// OPA is a Policy querying an Open Policy Agent server through its Data API
+//
+//	cfg, err := jwtauth.NewConfig(
+//		jwtauth.WithJWKSURL(jwksURL),
+//		jwtauth.WithPolicy(jwtauth.OPA{URL: "http://localhost:8181/v1/data/httpapi/authz"}),
+//	)
+//
+// The PolicyInput is posted as {"input": ...}. The decision at URL is either
+// a boolean or an object with a boolean "allow" and an optional string
+// "reason"; see PolicyDecisionFromResult.
+type OPA struct {
+	URL        string       // Data API URL of the decision, e.g. http://opa:8181/v1/data/httpapi/authz
+	HTTPClient *http.Client // Defaults to a client with a 5s timeout
+}
+
+// Evaluate implements Policy
+func (o OPA) Evaluate(ctx context.Context, input *PolicyInput) (PolicyDecision, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return PolicyDecision{}, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.URL, bytes.NewReader(body))
+	if err != nil {
+		return PolicyDecision{}, fmt.Errorf("failed to build OPA request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	client := o.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return PolicyDecision{}, fmt.Errorf("OPA request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return PolicyDecision{}, fmt.Errorf("OPA returned %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Result interface{} `json:"result"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxPolicyResponseBytes)).Decode(&result); err != nil {
+		return PolicyDecision{}, fmt.Errorf("failed to parse OPA response: %w", err)
+	}
+	if result.Result == nil {
+		return PolicyDecision{}, fmt.Errorf("OPA decision at %s is undefined", o.URL)
+	}
+	return PolicyDecisionFromResult(result.Result)
+}
+
+// PolicyDecisionFromResult converts a Rego decision to a PolicyDecision. The
+// decision is either a boolean or an object such as
+// {"allow": false, "reason": "tenant_suspended"}. Use it to evaluate an
+// embedded policy with the OPA Go SDK:
+//
+//	query, err := rego.New(rego.Query("data.authz"), rego.Module("authz.rego", src)).PrepareForEval(ctx)
+//	policy := jwtauth.PolicyFunc(func(ctx context.Context, input *jwtauth.PolicyInput) (jwtauth.PolicyDecision, error) {
+//		rs, err := query.Eval(ctx, rego.EvalInput(input))
+//		if err != nil || len(rs) == 0 {
+//			return jwtauth.PolicyDecision{}, fmt.Errorf("policy evaluation failed: %v", err)
+//		}
+//		return jwtauth.PolicyDecisionFromResult(rs[0].Expressions[0].Value)
+//	})
+func PolicyDecisionFromResult(result interface{}) (PolicyDecision, error) {
+	switch r := result.(type) {
+	case bool:
+		return PolicyDecision{Allow: r}, nil
+	case map[string]interface{}:
+		allow, ok := r["allow"].(bool)
+		if !ok {
+			return PolicyDecision{}, fmt.Errorf("policy decision has no boolean allow field")
+		}
+		decision := PolicyDecision{Allow: allow}
+		if reason, present := r["reason"]; present {
+			if decision.Reason, ok = reason.(string); !ok {
+				return PolicyDecision{}, fmt.Errorf("policy decision reason must be a string, got %T", reason)
+			}
+		}
+		return decision, nil
+	}
+	return PolicyDecision{}, fmt.Errorf("policy decision must be a boolean or an object, got %T", result)
+}
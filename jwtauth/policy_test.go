@@ -0,0 +1,166 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestOPAPolicy tests authorization decisions from an OPA server
+func TestOPAPolicy(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	var inputs []PolicyInput
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/data/httpapi/authz" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			Input PolicyInput `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid OPA request: %v", err)
+		}
+		inputs = append(inputs, body.Input)
+		switch body.Input.Claims["sub"] {
+		case "alice":
+			w.Write([]byte(`{"result": {"allow": true}}`))
+		case "bob":
+			w.Write([]byte(`{"result": {"allow": false, "reason": "tenant_suspended"}}`))
+		case "carol":
+			w.Write([]byte(`{"result": false}`))
+		case "dave":
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer opa.Close()
+
+	cfg := mustCreateConfig(WithHS256(secret), WithPolicy(OPA{URL: opa.URL + "/v1/data/httpapi/authz"}, WithPolicyHeaders("x-tenant")))
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	router.GET("/orders/:id", func(c *gin.Context) { c.Status(200) })
+
+	tests := []struct {
+		sub     string
+		want    int
+		reason  string
+		message string
+	}{
+		{"alice", 200, "", ""},
+		{"bob", 403, "POLICY_DENIED", "tenant_suspended"},
+		{"carol", 403, "POLICY_DENIED", "denied by policy"},
+		{"dave", 503, "POLICY_UNAVAILABLE", ""},
+		{"erin", 503, "POLICY_UNAVAILABLE", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sub, func(t *testing.T) {
+			token := mustSignHS256(secret, jwt.MapClaims{"sub": tt.sub, "roles": []string{"buyer"}, "exp": time.Now().Add(time.Hour).Unix()})
+			req := httptest.NewRequest("GET", "/orders/42", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("X-Tenant", "acme")
+			req.Header.Set("X-Other", "left out")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if tt.want == 200 {
+				return
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["reason"] != tt.reason || body["message"] != tt.message {
+				t.Errorf("expected %s %q, got %v", tt.reason, tt.message, body)
+			}
+		})
+	}
+
+	input := inputs[0]
+	if input.Protocol != "http" || input.Method != "GET" || input.Path != "/orders/42" {
+		t.Errorf("unexpected request metadata %+v", input)
+	}
+	if len(input.Headers) != 1 || input.Headers["X-Tenant"] != "acme" {
+		t.Errorf("expected only the X-Tenant header, got %v", input.Headers)
+	}
+	if roles, ok := input.Claims["roles"].([]interface{}); !ok || len(roles) != 1 || roles[0] != "buyer" {
+		t.Errorf("expected custom claims in the input, got %v", input.Claims)
+	}
+	if _, ok := input.Claims["exp"].(float64); !ok {
+		t.Errorf("expected exp in Unix seconds, got %T", input.Claims["exp"])
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithPolicy(OPA{URL: "opa:8181"})); err == nil {
+		t.Error("expected error for an invalid OPA URL")
+	}
+}
+
+// TestPolicyGRPC tests policy decisions in the gRPC interceptors
+func TestPolicyGRPC(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	var got *PolicyInput
+	policy := PolicyFunc(func(ctx context.Context, input *PolicyInput) (PolicyDecision, error) {
+		got = input
+		return PolicyDecision{Allow: input.Headers["X-Tenant"] == "acme", Reason: "wrong_tenant"}, nil
+	})
+	cfg := mustCreateConfig(WithHS256(secret), WithPolicy(policy, WithPolicyHeaders("X-Tenant")))
+	conn := startTestGRPCServer(t, grpc.UnaryInterceptor(UnaryServerInterceptor(cfg)))
+	client := healthpb.NewHealthClient(conn)
+
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "svc-a", "exp": time.Now().Add(time.Hour).Unix()})
+	call := func(tenant string) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token, "x-tenant", tenant)
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		return err
+	}
+
+	if err := call("acme"); err != nil {
+		t.Errorf("expected Check to succeed, got %v", err)
+	}
+	if got.Protocol != "grpc" || got.Path != "/grpc.health.v1.Health/Check" || got.Claims["sub"] != "svc-a" {
+		t.Errorf("unexpected policy input %+v", got)
+	}
+	err := call("globex")
+	if status.Code(err) != codes.PermissionDenied || status.Convert(err).Message() != string(ErrPolicyDenied) {
+		t.Errorf("expected PERMISSION_DENIED, got %v", err)
+	}
+}
+
+// TestPolicyDecisionFromResult tests decoding Rego decisions
+func TestPolicyDecisionFromResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  interface{}
+		want    PolicyDecision
+		wantErr bool
+	}{
+		{"boolean", true, PolicyDecision{Allow: true}, false},
+		{"object", map[string]interface{}{"allow": false, "reason": "mfa_required"}, PolicyDecision{Reason: "mfa_required"}, false},
+		{"missing allow", map[string]interface{}{"reason": "x"}, PolicyDecision{}, true},
+		{"non-string reason", map[string]interface{}{"allow": false, "reason": 7.0}, PolicyDecision{}, true},
+		{"set", []interface{}{"x"}, PolicyDecision{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PolicyDecisionFromResult(tt.result)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("expected %+v (error %t), got %+v, %v", tt.want, tt.wantErr, got, err)
+			}
+		})
+	}
+}
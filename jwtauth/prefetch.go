@@ -0,0 +1,106 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KeyPrefetcher is implemented by key providers that load keys from a
+// remote source (JWKS endpoints, secret managers) and can do so ahead of the
+// first token.
+type KeyPrefetcher interface {
+	// Prefetch loads the provider's keys, returning an error when the
+	// source cannot be reached or serves no usable keys
+	Prefetch(ctx context.Context) error
+}
+
+// Prefetch retry backoff bounds
+const (
+	prefetchInitialBackoff = 100 * time.Millisecond
+	prefetchMaxBackoff     = 2 * time.Second
+)
+
+// WithKeyPrefetch makes NewConfig load the keys of every configured
+// KeyPrefetcher, retrying until all succeed or timeout elapses, so the first
+// request does not pay the cold-start fetch or fail while a key source is
+// briefly unreachable. NewConfig fails if the keys cannot be loaded in time.
+//
+// To start without blocking, skip this option and gate readiness on
+// Config.Prefetch instead.
+func WithKeyPrefetch(timeout time.Duration) ConfigOption {
+	return func(c *Config) error {
+		if timeout <= 0 {
+			return fmt.Errorf("key prefetch timeout must be positive, got %v", timeout)
+		}
+		c.prefetchTimeout = timeout
+		return nil
+	}
+}
+
+// Prefetch loads the keys of every configured KeyPrefetcher concurrently,
+// retrying with backoff until all succeed or ctx is done. It returns nil
+// once every provider is ready, making it suitable for readiness probes:
+//
+//	go func() { ready.Store(cfg.Prefetch(ctx) == nil) }()
+func (c *Config) Prefetch(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, alg := range c.AvailableAlgorithms() {
+		validator, _ := c.getValidator(alg)
+		prefetcher, ok := validator.keyProvider.(KeyPrefetcher)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := prefetchWithRetry(ctx, prefetcher); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s keys: %w", alg, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// prefetchWithRetry calls p.Prefetch until it succeeds or ctx is done,
+// returning the last error
+func prefetchWithRetry(ctx context.Context, p KeyPrefetcher) error {
+	backoff := prefetchInitialBackoff
+	for {
+		err := p.Prefetch(ctx)
+		if err == nil {
+			return nil
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, prefetchMaxBackoff)
+	}
+}
+
+// prefetchKeys runs the WithKeyPrefetch startup prefetch
+func (c *Config) prefetchKeys() error {
+	if c.prefetchTimeout == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.prefetchTimeout)
+	defer cancel()
+	if err := c.Prefetch(ctx); err != nil {
+		return NewValidationError(ErrConfigError, fmt.Sprintf("key prefetch did not complete within %v", c.prefetchTimeout), err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestWithKeyPrefetch tests loading remote keys before the first request
+func TestWithKeyPrefetch(t *testing.T) {
+	key := mustGenerateECKey()
+	url, fetches := serveJWKS(t, JSONWebKey{KeyID: "k1", Algorithm: "ES256", Key: &key.PublicKey})
+
+	cfg := mustCreateConfig(WithKeyProvider("ES256", newRemoteJWKS(url)), WithKeyPrefetch(time.Second))
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("Expected NewConfig to fetch keys once, got %d", n)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["kid"] = "k1"
+	signed, _ := token.SignedString(key)
+	if _, err := ParseToken(context.Background(), signed, cfg); err != nil {
+		t.Fatalf("Expected token to validate, got %v", err)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("Expected the first request to use prefetched keys, got %d fetches", n)
+	}
+
+	if report := cfg.SelfTest(context.Background()); !report.OK() {
+		t.Errorf("Expected self-test to pass with a reachable JWKS, got %+v", report.Checks)
+	}
+}
+
+// TestWithKeyPrefetchRetries tests retries and the startup timeout
+func TestWithKeyPrefetchRetries(t *testing.T) {
+	key := mustGenerateECKey()
+	healthy, _ := serveJWKS(t, JSONWebKey{KeyID: "k1", Algorithm: "ES256", Key: &key.PublicKey})
+
+	var attempts atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		http.Redirect(w, r, healthy, http.StatusFound)
+	}))
+	defer flaky.Close()
+
+	if _, err := NewConfig(WithKeyProvider("ES256", newRemoteJWKS(flaky.URL)), WithKeyPrefetch(5*time.Second)); err != nil {
+		t.Fatalf("Expected prefetch to succeed after retries, got %v", err)
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	start := time.Now()
+	_, err := NewConfig(WithKeyProvider("ES256", newRemoteJWKS(down.URL)), WithKeyPrefetch(300*time.Millisecond))
+	if getErrorCode(err) != "CONFIG_ERROR" {
+		t.Errorf("Expected CONFIG_ERROR when keys cannot be loaded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected prefetch to stop at its timeout, took %v", elapsed)
+	}
+
+	// Configurations without remote key sources are ready immediately
+	cfg := mustCreateConfig(WithHS256([]byte("test-secret-key-min-32-bytes-long!!")))
+	if err := cfg.Prefetch(context.Background()); err != nil {
+		t.Errorf("Expected no error without prefetchers, got %v", err)
+	}
+}
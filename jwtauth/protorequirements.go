@@ -0,0 +1,150 @@
+package jwtauth
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ProtoRequirements configures WithProtoRequirements
+type ProtoRequirements struct {
+	// Extension is the custom method option holding the requirement, e.g.
+	// the generated E_Auth for
+	//
+	//	message AuthRule { repeated string scopes = 1; repeated string roles = 2; }
+	//	extend google.protobuf.MethodOptions { AuthRule auth = 50001; }
+	//
+	// Its message type is read by field name: repeated string fields
+	// "scopes" and "roles"; other fields are ignored.
+	Extension protoreflect.ExtensionType
+
+	// Files holds the service descriptors to scan (default
+	// protoregistry.GlobalFiles, where generated code registers itself)
+	Files *protoregistry.Files
+}
+
+// WithProtoRequirements reads per-method authorization from a custom proto
+// method option, so scopes and roles live next to the RPC definition:
+//
+//	rpc Delete(DeleteRequest) returns (DeleteResponse) {
+//	  option (acme.auth) = { scopes: ["orders:write"], roles: ["admin"] };
+//	}
+//
+// Every service method in Files carrying the option becomes a
+// WithMethodRequirements entry; entries set with WithMethodRequirements take
+// precedence. Generated packages must be linked into the binary (imported)
+// so their descriptors are registered before NewConfig runs.
+func WithProtoRequirements(p ProtoRequirements) ConfigOption {
+	return func(c *Config) error {
+		if p.Extension == nil {
+			return fmt.Errorf("proto requirements need an extension type")
+		}
+		ext := p.Extension.TypeDescriptor()
+		if ext.ContainingMessage().FullName() != "google.protobuf.MethodOptions" {
+			return fmt.Errorf("extension %s does not extend google.protobuf.MethodOptions", ext.FullName())
+		}
+		if ext.Message() == nil || ext.IsList() {
+			return fmt.Errorf("extension %s must be a singular message", ext.FullName())
+		}
+		scopes, roles := protoStringListField(ext.Message(), "scopes"), protoStringListField(ext.Message(), "roles")
+		if scopes == nil && roles == nil {
+			return fmt.Errorf("extension message %s has no repeated string scopes or roles field", ext.Message().FullName())
+		}
+
+		files := p.Files
+		if files == nil {
+			files = protoregistry.GlobalFiles
+		}
+		types := new(protoregistry.Types)
+		if err := types.RegisterExtension(p.Extension); err != nil {
+			return fmt.Errorf("registering extension %s: %w", ext.FullName(), err)
+		}
+
+		found := map[string]Requirement{}
+		var rangeErr error
+		files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+			services := file.Services()
+			for i := 0; i < services.Len(); i++ {
+				methods := services.Get(i).Methods()
+				for j := 0; j < methods.Len(); j++ {
+					method := methods.Get(j)
+					rule, err := methodOptionRule(method, p.Extension, types)
+					if err != nil {
+						rangeErr = fmt.Errorf("method %s: %w", method.FullName(), err)
+						return false
+					}
+					if rule == nil {
+						continue
+					}
+					req := Requirement{Scopes: protoStrings(rule, scopes), Roles: protoStrings(rule, roles)}
+					if err := req.validate(); err != nil {
+						rangeErr = fmt.Errorf("method %s: %w", method.FullName(), err)
+						return false
+					}
+					found[fmt.Sprintf("/%s/%s", services.Get(i).FullName(), method.Name())] = req
+				}
+			}
+			return true
+		})
+		if rangeErr != nil {
+			return rangeErr
+		}
+
+		if c.methodRequirements == nil {
+			c.methodRequirements = make(map[string]Requirement, len(found))
+		}
+		for method, req := range found {
+			if _, explicit := c.methodRequirements[method]; !explicit {
+				c.methodRequirements[method] = req
+			}
+		}
+		return nil
+	}
+}
+
+// methodOptionRule returns the extension message set on method, or nil.
+// Options are re-parsed with the extension registered, since descriptors
+// loaded before the extension's package keep it as an unknown field.
+func methodOptionRule(method protoreflect.MethodDescriptor, ext protoreflect.ExtensionType, types *protoregistry.Types) (protoreflect.Message, error) {
+	opts, ok := method.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return nil, nil
+	}
+	raw, err := proto.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	parsed := &descriptorpb.MethodOptions{}
+	if err := (proto.UnmarshalOptions{Resolver: types}).Unmarshal(raw, parsed); err != nil {
+		return nil, err
+	}
+	if !proto.HasExtension(parsed, ext) {
+		return nil, nil
+	}
+	return parsed.ProtoReflect().Get(ext.TypeDescriptor()).Message(), nil
+}
+
+// protoStringListField returns the repeated string field name of md, or nil
+func protoStringListField(md protoreflect.MessageDescriptor, name protoreflect.Name) protoreflect.FieldDescriptor {
+	field := md.Fields().ByName(name)
+	if field == nil || !field.IsList() || field.Kind() != protoreflect.StringKind {
+		return nil
+	}
+	return field
+}
+
+// protoStrings returns the values of a repeated string field
+func protoStrings(msg protoreflect.Message, field protoreflect.FieldDescriptor) []string {
+	if field == nil {
+		return nil
+	}
+	list := msg.Get(field).List()
+	values := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		values = append(values, list.Get(i).String())
+	}
+	return values
+}
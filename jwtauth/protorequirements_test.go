@@ -0,0 +1,137 @@
+package jwtauth
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// buildAuthProto builds, without protoc, the equivalent of:
+//
+//	package acme;
+//	message AuthRule { repeated string scopes = 1; repeated string roles = 2; }
+//	extend google.protobuf.MethodOptions { AuthRule auth = 50001; }
+//
+//	package test.v1;
+//	service Orders {
+//	  rpc Get(Req) returns (Req) { option (acme.auth) = { scopes: ["orders:read"] }; }
+//	  rpc Delete(Req) returns (Req) { option (acme.auth) = { scopes: ["orders:write"], roles: ["admin", "ops"] }; }
+//	  rpc Ping(Req) returns (Req);
+//	}
+func buildAuthProto(t *testing.T) (protoreflect.ExtensionType, *protoregistry.Files) {
+	t.Helper()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	authFile, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("acme/auth.proto"),
+		Package:    proto.String("acme"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("AuthRule"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("scopes"), Number: proto.Int32(1), Label: repeated, Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), JsonName: proto.String("scopes")},
+				{Name: proto.String("roles"), Number: proto.Int32(2), Label: repeated, Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), JsonName: proto.String("roles")},
+			},
+		}},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("auth"),
+			Number:   proto.Int32(50001),
+			Label:    optional,
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(".acme.AuthRule"),
+			Extendee: proto.String(".google.protobuf.MethodOptions"),
+			JsonName: proto.String("auth"),
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext := dynamicpb.NewExtensionType(authFile.Extensions().Get(0))
+	ruleDesc := authFile.Messages().Get(0)
+
+	options := func(scopes, roles []string) *descriptorpb.MethodOptions {
+		rule := dynamicpb.NewMessage(ruleDesc)
+		for name, values := range map[protoreflect.Name][]string{"scopes": scopes, "roles": roles} {
+			list := rule.Mutable(ruleDesc.Fields().ByName(name)).List()
+			for _, v := range values {
+				list.Append(protoreflect.ValueOfString(v))
+			}
+		}
+		opts := &descriptorpb.MethodOptions{}
+		proto.SetExtension(opts, ext, rule)
+		return opts
+	}
+	method := func(name string, opts *descriptorpb.MethodOptions) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{Name: proto.String(name), InputType: proto.String(".test.v1.Req"), OutputType: proto.String(".test.v1.Req"), Options: opts}
+	}
+	ordersFile, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("test/v1/orders.proto"),
+		Package:     proto.String("test.v1"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Req")}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Orders"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Get", options([]string{"orders:read"}, nil)),
+				method("Delete", options([]string{"orders:write"}, []string{"admin", "ops"})),
+				method("Ping", nil),
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := new(protoregistry.Files)
+	if err := files.RegisterFile(ordersFile); err != nil {
+		t.Fatal(err)
+	}
+	return ext, files
+}
+
+// TestWithProtoRequirements tests requirements read from proto method options
+func TestWithProtoRequirements(t *testing.T) {
+	ext, files := buildAuthProto(t)
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithProtoRequirements(ProtoRequirements{Extension: ext, Files: files}),
+		WithMethodRequirements(map[string]Requirement{"/test.v1.Orders/Get": {Scopes: []string{"orders:list"}}}),
+	)
+
+	claims := func(custom map[string]interface{}) *Claims {
+		return &Claims{Subject: "user123", Custom: custom}
+	}
+	tests := []struct {
+		name    string
+		method  string
+		claims  *Claims
+		allowed bool
+	}{
+		{"delete granted", "/test.v1.Orders/Delete", claims(map[string]interface{}{"scope": "orders:write", "roles": []interface{}{"ops"}}), true},
+		{"delete without role", "/test.v1.Orders/Delete", claims(map[string]interface{}{"scope": "orders:write", "roles": []interface{}{"viewer"}}), false},
+		{"delete without scope", "/test.v1.Orders/Delete", claims(map[string]interface{}{"role": "admin"}), false},
+		{"explicit entry wins", "/test.v1.Orders/Get", claims(map[string]interface{}{"scope": "orders:list"}), true},
+		{"annotation overridden", "/test.v1.Orders/Get", claims(map[string]interface{}{"scope": "orders:read"}), false},
+		{"no annotation", "/test.v1.Orders/Ping", claims(nil), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cfg.checkMethodRequirements(tt.method, tt.claims)
+			if tt.allowed && err != nil {
+				t.Errorf("Expected call to be allowed, got %v", err)
+			}
+			if !tt.allowed && getErrorCode(err) != string(ErrForbidden) {
+				t.Errorf("Expected FORBIDDEN, got %v", err)
+			}
+		})
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithProtoRequirements(ProtoRequirements{})); err == nil {
+		t.Error("Expected missing extension to be rejected")
+	}
+}
@@ -0,0 +1,53 @@
+package jwtauth
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key sources reported in Provenance.KeySource for keys that are not served
+// by a KeySourcer
+const (
+	KeySourceStatic   = "static"   // Single key configured with WithHS256, WithRS256, ...
+	KeySourceKeySet   = "key_set"  // WithKeySet
+	KeySourceProvider = "provider" // KeyProvider that does not implement KeySourcer
+)
+
+// Provenance records which validator authenticated a token
+type Provenance struct {
+	Algorithm string // Algorithm the signature was verified with
+	KeyID     string // Token's kid header (empty if absent)
+	KeySource string // KeySource of the key provider, or KeySourceStatic, KeySourceKeySet or KeySourceProvider; empty when accepted in degraded mode
+}
+
+// KeySourcer is implemented by key providers that name the source of their
+// keys in Claims.Provenance, e.g. "pkcs11" for keys held in an HSM. Built-in
+// sources are "jwks", "file", "rotation" and "secret_provider".
+type KeySourcer interface {
+	KeySource() string
+}
+
+// provenance describes the validator that verified token
+func (c *Config) provenance(token *jwt.Token, degraded bool) Provenance {
+	alg := token.Method.Alg()
+	kid, _ := token.Header["kid"].(string)
+	p := Provenance{Algorithm: alg, KeyID: kid}
+	if degraded {
+		return p
+	}
+	p.KeySource = c.validators[alg].keySource()
+	return p
+}
+
+// keySource names where the validator's keys come from
+func (v algorithmValidator) keySource() string {
+	switch {
+	case v.keySet != nil:
+		return KeySourceKeySet
+	case v.keyProvider == nil:
+		return KeySourceStatic
+	}
+	if sourcer, ok := v.keyProvider.(KeySourcer); ok {
+		return sourcer.KeySource()
+	}
+	return KeySourceProvider
+}
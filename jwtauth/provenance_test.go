@@ -0,0 +1,100 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// hsmKeyProvider is a KeyProvider naming its key source
+type hsmKeyProvider struct {
+	key interface{}
+}
+
+func (p hsmKeyProvider) KeySource() string { return "hsm" }
+
+func (p hsmKeyProvider) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	return p.key, nil
+}
+
+// TestClaimsProvenance tests the algorithm, kid and key source recorded for
+// each kind of validator
+func TestClaimsProvenance(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	rsaKey, ecKey := mustGenerateRSAKey(), mustGenerateECKey()
+	jwksURL, _ := serveJWKS(t, JSONWebKey{KeyID: "remote-1", Algorithm: "RS256", Key: &rsaKey.PublicKey})
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		opt   ConfigOption
+		token string
+		want  Provenance
+	}{
+		{"static key", WithHS256(secret), sign(jwt.SigningMethodHS256, "", secret), Provenance{"HS256", "", KeySourceStatic}},
+		{"key set", WithKeySet("ES256", KeySet{Keys: map[string]interface{}{"set-1": &ecKey.PublicKey}}), sign(jwt.SigningMethodES256, "set-1", ecKey), Provenance{"ES256", "set-1", KeySourceKeySet}},
+		{"JWKS", WithJWKSURL(jwksURL), sign(jwt.SigningMethodRS256, "remote-1", rsaKey), Provenance{"RS256", "remote-1", "jwks"}},
+		{"unnamed provider", WithKeyProvider("ES256", StaticKeyProvider(&ecKey.PublicKey)), sign(jwt.SigningMethodES256, "", ecKey), Provenance{"ES256", "", KeySourceProvider}},
+		{"named provider", WithKeyProvider("ES256", hsmKeyProvider{&ecKey.PublicKey}), sign(jwt.SigningMethodES256, "k1", ecKey), Provenance{"ES256", "k1", "hsm"}},
+		{"cached named provider", WithKeyProvider("ES256", CachedKeyProvider(hsmKeyProvider{&ecKey.PublicKey}, time.Minute)), sign(jwt.SigningMethodES256, "", ecKey), Provenance{"ES256", "", "hsm"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseToken(context.Background(), tt.token, mustCreateConfig(tt.opt))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Provenance != tt.want {
+				t.Errorf("expected provenance %+v, got %+v", tt.want, claims.Provenance)
+			}
+		})
+	}
+}
+
+// TestRouteRequirementsKeySources tests restricting a route to tokens
+// verified by a key source
+func TestRouteRequirementsKeySources(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	ecKey := mustGenerateECKey()
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithKeyProvider("ES256", hsmKeyProvider{&ecKey.PublicKey}),
+		WithRouteRequirements(RouteRule{Path: "/payments/*", KeySources: []string{"hsm"}}),
+	)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	router.POST("/payments/charge", func(c *gin.Context) { c.Status(200) })
+
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	hsmToken, _ := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(ecKey)
+	for token, want := range map[string]int{hsmToken: 200, mustSignHS256(secret, claims): 403} {
+		req := httptest.NewRequest("POST", "/payments/charge", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("expected %d, got %d: %s", want, w.Code, w.Body.String())
+		}
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithRouteRequirements(RouteRule{Path: "/x", KeySources: []string{""}})); err == nil {
+		t.Error("expected error for an empty key source")
+	}
+}
@@ -0,0 +1,172 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit describes an allowed request rate
+type RateLimit struct {
+	Requests int           // Requests allowed per Period
+	Period   time.Duration // Refill period
+	Burst    int           // Maximum burst size (defaults to Requests)
+}
+
+// RateLimitStore tracks request budgets per key. Implementations must be
+// safe for concurrent use; a shared store (e.g. Redis) enforces limits
+// across service replicas.
+type RateLimitStore interface {
+	// Allow consumes one request for key. When the request is not allowed,
+	// retryAfter reports how long until the next request would be.
+	Allow(ctx context.Context, key string, limit RateLimit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// TenantRateLimiter configures per-tenant request limits
+type TenantRateLimiter struct {
+	Default RateLimit            // Limit for tenants without an override
+	Tenants map[string]RateLimit // Per-tenant quota overrides
+	Store   RateLimitStore       // Budget store (defaults to an in-memory store)
+}
+
+// WithTenantRateLimit enforces per-tenant request limits keyed by the tenant
+// claim (requires WithTenantClaim). Requests over the limit are rejected with
+// RATE_LIMITED (HTTP 429 with Retry-After, gRPC RESOURCE_EXHAUSTED).
+// Tokens without a tenant are not limited. If the store fails, requests are
+// allowed and the failure is logged.
+func WithTenantRateLimit(limiter TenantRateLimiter) ConfigOption {
+	return func(c *Config) error {
+		if err := limiter.Default.validate(); err != nil {
+			return fmt.Errorf("default tenant rate limit: %w", err)
+		}
+		for tenant, limit := range limiter.Tenants {
+			if err := limit.validate(); err != nil {
+				return fmt.Errorf("rate limit for tenant %s: %w", tenant, err)
+			}
+		}
+		if limiter.Store == nil {
+			limiter.Store = NewMemoryRateLimitStore()
+		}
+		c.tenantRateLimiter = &limiter
+		return nil
+	}
+}
+
+// validate checks that the limit is usable
+func (l RateLimit) validate() error {
+	if l.Requests <= 0 {
+		return fmt.Errorf("requests must be positive, got %d", l.Requests)
+	}
+	if l.Period <= 0 {
+		return fmt.Errorf("period must be positive, got %v", l.Period)
+	}
+	if l.Burst < 0 {
+		return fmt.Errorf("burst must be non-negative, got %d", l.Burst)
+	}
+	return nil
+}
+
+// limitFor returns the effective limit for tenant
+func (l *TenantRateLimiter) limitFor(tenant string) RateLimit {
+	if limit, ok := l.Tenants[tenant]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// enforceTenantRateLimit consumes one request from the tenant's budget
+func enforceTenantRateLimit(ctx context.Context, cfg *Config, tenant string) error {
+	limiter := cfg.tenantRateLimiter
+	if limiter == nil || tenant == "" {
+		return nil
+	}
+
+	allowed, retryAfter, err := limiter.Store.Allow(ctx, "tenant:"+tenant, limiter.limitFor(tenant))
+	if err != nil {
+		// Fail open: the limiter protects capacity, not authentication
+		if cfg.Logger() != nil {
+			cfg.Logger().Warn("tenant rate limit store unavailable", "tenant_id", tenant, "error", err)
+		}
+		return nil
+	}
+	if allowed {
+		return nil
+	}
+
+	valErr := NewValidationError(ErrRateLimited, fmt.Sprintf("rate limit exceeded for tenant %s", tenant), nil)
+	valErr.RetryAfter = retryAfter
+	return valErr
+}
+
+// tokenBucket is the in-memory state for a single key
+type tokenBucket struct {
+	tokens   float64
+	lastFill time.Time
+	full     time.Time // When the bucket will have refilled to its burst
+}
+
+// memoryRateLimitStore is an in-process token bucket store
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore returns an in-process token bucket RateLimitStore.
+// Limits are enforced per process; use a shared store for fleet-wide quotas.
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow implements RateLimitStore
+func (s *memoryRateLimitStore) Allow(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	now := time.Now()
+	burst := float64(limit.Burst)
+	if burst == 0 {
+		burst = float64(limit.Requests)
+	}
+	rate := float64(limit.Requests) / limit.Period.Seconds() // tokens per second
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: burst, lastFill: now}
+		s.buckets[key] = bucket
+	}
+
+	// Refill based on elapsed time
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.lastFill).Seconds()*rate)
+	bucket.lastFill = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		bucket.full = now.Add(time.Duration((burst - bucket.tokens) / rate * float64(time.Second)))
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// sweep drops buckets that have refilled completely, which a new bucket
+// would recreate as they are, so the map does not grow unbounded
+func (s *memoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
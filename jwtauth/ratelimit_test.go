@@ -0,0 +1,198 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestMemoryRateLimitStore tests token bucket consumption and refill
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	limit := RateLimit{Requests: 2, Period: 100 * time.Millisecond}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if allowed, _, _ := store.Allow(ctx, "a", limit); !allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+
+	allowed, retryAfter, _ := store.Allow(ctx, "a", limit)
+	if allowed {
+		t.Fatal("Expected third request to be limited")
+	}
+	if retryAfter <= 0 || retryAfter > 50*time.Millisecond {
+		t.Errorf("Expected retryAfter in (0, 50ms], got %v", retryAfter)
+	}
+
+	// Other keys have independent budgets
+	if allowed, _, _ := store.Allow(ctx, "b", limit); !allowed {
+		t.Error("Expected separate key to be allowed")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if allowed, _, _ := store.Allow(ctx, "a", limit); !allowed {
+		t.Error("Expected request to be allowed after refill")
+	}
+}
+
+// TestMemoryRateLimitStoreSweep tests that idle buckets are only dropped
+// once they have refilled, so long periods keep their quota
+func TestMemoryRateLimitStoreSweep(t *testing.T) {
+	store := NewMemoryRateLimitStore().(*memoryRateLimitStore)
+	daily := RateLimit{Requests: 1, Period: 24 * time.Hour}
+	ctx := context.Background()
+
+	if allowed, _, _ := store.Allow(ctx, "daily", daily); !allowed {
+		t.Fatal("Expected the first request to be allowed")
+	}
+
+	// Two idle hours do not reset a daily quota
+	store.sweep(time.Now().Add(2 * time.Hour))
+	if allowed, _, _ := store.Allow(ctx, "daily", daily); allowed {
+		t.Error("Expected the daily quota to survive the sweep")
+	}
+
+	// A refilled bucket is dropped
+	store.sweep(time.Now().Add(25 * time.Hour))
+	if _, kept := store.buckets["daily"]; kept {
+		t.Error("Expected the refilled bucket to be swept")
+	}
+}
+
+// TestGinMiddlewareTenantRateLimit tests 429 responses with tenant-aware limits
+func TestGinMiddlewareTenantRateLimit(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithTenantClaim("tid"),
+		WithTenantRateLimit(TenantRateLimiter{
+			Default: RateLimit{Requests: 1, Period: time.Minute},
+			Tenants: map[string]RateLimit{"premium": {Requests: 3, Period: time.Minute}},
+		}),
+	)
+
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	router.GET("/protected", func(c *gin.Context) { c.Status(200) })
+
+	send := func(tenant string) *httptest.ResponseRecorder {
+		tokenString := mustSignHS256(secret, jwt.MapClaims{
+			"sub": "user123",
+			"tid": tenant,
+			"exp": time.Now().Add(time.Hour).Unix(),
+		})
+		req := httptest.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("basic"); w.Code != 200 {
+		t.Fatalf("Expected first basic request to succeed, got %d", w.Code)
+	}
+	w := send("basic")
+	if w.Code != 429 {
+		t.Fatalf("Expected 429 for second basic request, got %d", w.Code)
+	}
+	if secs, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || secs < 1 {
+		t.Errorf("Expected positive Retry-After header, got %q", w.Header().Get("Retry-After"))
+	}
+	if !contains(w.Body.String(), `"reason":"RATE_LIMITED"`) {
+		t.Errorf("Expected RATE_LIMITED reason, got %s", w.Body.String())
+	}
+
+	for i := 0; i < 3; i++ {
+		if w := send("premium"); w.Code != 200 {
+			t.Fatalf("Expected premium request %d to succeed, got %d", i+1, w.Code)
+		}
+	}
+	if w := send("premium"); w.Code != 429 {
+		t.Errorf("Expected premium quota to be enforced, got %d", w.Code)
+	}
+}
+
+// TestTenantRateLimitConfigErrors tests config-time validation of rate limits
+func TestTenantRateLimitConfigErrors(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	valid := TenantRateLimiter{Default: RateLimit{Requests: 1, Period: time.Second}}
+
+	if _, err := NewConfig(WithHS256(secret), WithTenantRateLimit(valid)); err == nil {
+		t.Error("Expected error when tenant claim is not configured")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithTenantClaim("tid"),
+		WithTenantRateLimit(TenantRateLimiter{Default: RateLimit{Requests: 0, Period: time.Second}})); err == nil {
+		t.Error("Expected error for zero requests")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithTenantClaim("tid"), WithTenantRateLimit(TenantRateLimiter{
+		Default: valid.Default,
+		Tenants: map[string]RateLimit{"x": {Requests: 1}},
+	})); err == nil {
+		t.Error("Expected error for tenant override without period")
+	}
+}
+
+// TestGRPCTenantRateLimitRetryInfo tests RESOURCE_EXHAUSTED with a RetryInfo
+// detail on the gRPC and gRPC-Web paths
+func TestGRPCTenantRateLimitRetryInfo(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithTenantClaim("tid"),
+		WithTenantRateLimit(TenantRateLimiter{Default: RateLimit{Requests: 1, Period: time.Minute}}),
+	)
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "svc", "tid": "acme", "exp": time.Now().Add(time.Hour).Unix()})
+
+	conn := startTestGRPCServer(t, grpc.UnaryInterceptor(UnaryServerInterceptor(cfg)))
+	client := healthpb.NewHealthClient(conn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("expected first call to succeed, got %v", err)
+	}
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted || st.Message() != string(ErrRateLimited) {
+		t.Fatalf("expected RESOURCE_EXHAUSTED %s, got %v", ErrRateLimited, err)
+	}
+	var retry *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil || retry.GetRetryDelay().AsDuration() <= 0 || retry.GetRetryDelay().AsDuration() > time.Minute {
+		t.Fatalf("expected RetryInfo with a delay up to 1m, got %v", st.Details())
+	}
+
+	// gRPC-Web carries the same status details and a Retry-After header
+	handler := GRPCWebHandler(cfg, http.NotFoundHandler())
+	req := httptest.NewRequest("POST", "/grpc.health.v1.Health/Check", nil)
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Header().Get("grpc-status") != strconv.Itoa(int(codes.ResourceExhausted)) {
+		t.Fatalf("expected grpc-status %d, got %q", codes.ResourceExhausted, w.Header().Get("grpc-status"))
+	}
+	if w.Header().Get("grpc-status-details-bin") == "" {
+		t.Error("expected grpc-status-details-bin")
+	}
+	if secs, _ := strconv.Atoi(w.Header().Get("Retry-After")); secs <= 0 {
+		t.Errorf("expected a positive Retry-After, got %q", w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,169 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+// RequireScope returns middleware requiring the validated token to grant
+// every one of scopes in its scope claim (space-delimited) or scp claim
+// (string or array). It must run after JWTAuth:
+//
+//	router.POST("/orders", jwtauth.RequireScope("orders:write"), createOrder)
+//
+// Tokens lacking a scope are rejected with 403 (FORBIDDEN). Use
+// UnaryRequireScope and StreamRequireScope for gRPC, or WithRouteRequirements
+// and WithMethodRequirements to declare scopes per route in one place.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return requireGin("RequireScope", mustRequirement("RequireScope", Requirement{Scopes: scopes}))
+}
+
+// UnaryRequireScope is RequireScope for gRPC unary RPCs; chain it after
+// UnaryServerInterceptor. Tokens lacking a scope are rejected with
+// PERMISSION_DENIED.
+func UnaryRequireScope(scopes ...string) grpc.UnaryServerInterceptor {
+	return requireUnary("UnaryRequireScope", mustRequirement("UnaryRequireScope", Requirement{Scopes: scopes}))
+}
+
+// StreamRequireScope is RequireScope for gRPC streams; chain it after
+// StreamServerInterceptor
+func StreamRequireScope(scopes ...string) grpc.StreamServerInterceptor {
+	return requireStream("StreamRequireScope", mustRequirement("StreamRequireScope", Requirement{Scopes: scopes}))
+}
+
+// RequireRole returns middleware requiring the validated token to grant
+// role, read from the claim set with WithRoleClaim (by default the roles or
+// role claim). It must run after JWTAuth:
+//
+//	admin := router.Group("/admin", jwtauth.JWTAuth(cfg), jwtauth.RequireRole("admin"))
+//
+// Tokens without the role are rejected with 403 (FORBIDDEN).
+func RequireRole(role string) gin.HandlerFunc {
+	return requireGin("RequireRole", mustRequirement("RequireRole", Requirement{Roles: []string{role}}))
+}
+
+// RequireAnyRole is RequireRole accepting tokens granting at least one of
+// roles
+func RequireAnyRole(roles ...string) gin.HandlerFunc {
+	return requireGin("RequireAnyRole", mustRequirement("RequireAnyRole", Requirement{Roles: roles}))
+}
+
+// WithRoleClaim sets the claim holding roles for RequireRole,
+// RequireAnyRole and the Roles of route and method requirements. path is a
+// claim name or a dot-separated path into nested objects, e.g.
+// "realm_access.roles" for Keycloak or "https://example.com/roles" for
+// namespaced Auth0 claims (names containing a dot are looked up whole
+// first). The claim may be a string or an array of strings.
+func WithRoleClaim(path string) ConfigOption {
+	return func(c *Config) error {
+		if strings.TrimSpace(path) == "" || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") {
+			return fmt.Errorf("invalid role claim path %q", path)
+		}
+		c.roleClaim = path
+		return nil
+	}
+}
+
+// lookupClaimPath returns the claim at a dot-separated path, trying the
+// whole path as a claim name first
+func lookupClaimPath(claims *Claims, path string) interface{} {
+	if value, ok := claims.Get(path); ok {
+		return value
+	}
+	name, rest, _ := strings.Cut(path, ".")
+	value, _ := claims.Get(name)
+	for rest != "" {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		name, rest, _ = strings.Cut(rest, ".")
+		value = object[name]
+	}
+	return value
+}
+
+// contextRoleClaim returns the role claim path of the configuration that
+// authenticated the request
+func contextRoleClaim(ctx context.Context) string {
+	if cfg, ok := ctx.Value(configContextKey).(*Config); ok {
+		return cfg.roleClaim
+	}
+	return ""
+}
+
+// mustRequirement panics on a malformed requirement, since it is a
+// programming error caught at router setup
+func mustRequirement(name string, req Requirement) Requirement {
+	if len(req.Scopes) == 0 && len(req.Roles) == 0 {
+		panic(fmt.Sprintf("jwtauth: %s requires at least one value", name))
+	}
+	if err := req.validate(); err != nil {
+		panic(fmt.Sprintf("jwtauth: %s: %v", name, err))
+	}
+	return req
+}
+
+// requireGin returns middleware enforcing req on the claims set by JWTAuth
+func requireGin(name string, req Requirement) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c.Request.Context())
+		if !ok {
+			abortAfterAuth(c, NewValidationError(ErrMissingToken, name+" must run after JWTAuth", nil))
+			return
+		}
+		if err := req.check(claims, contextRoleClaim(c.Request.Context())); err != nil {
+			abortAfterAuth(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireUnary returns a unary interceptor enforcing req on the claims set
+// by UnaryServerInterceptor
+func requireUnary(name string, req Requirement) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, r interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkGRPCRequirement(ctx, name, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, r)
+	}
+}
+
+// requireStream returns a stream interceptor enforcing req on the claims
+// set by StreamServerInterceptor
+func requireStream(name string, req Requirement) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkGRPCRequirement(ss.Context(), name, req); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// checkGRPCRequirement enforces req and returns a gRPC status error, logged
+// with the configuration the RPC was authenticated with
+func checkGRPCRequirement(ctx context.Context, name string, req Requirement) error {
+	claims, ok := GetClaims(ctx)
+	var err error
+	if !ok {
+		err = NewValidationError(ErrMissingToken, name+" must run after the authentication interceptor", nil)
+	} else {
+		err = req.check(claims, contextRoleClaim(ctx))
+	}
+	if err == nil {
+		return nil
+	}
+	if cfg, ok := ctx.Value(configContextKey).(*Config); ok {
+		requestID, _ := GetRequestID(ctx)
+		clientIP, _ := GetClientIP(ctx)
+		token, _ := GetToken(ctx)
+		logAuthFailureGRPC(cfg, requestID, clientIP, token, err, 0)
+	}
+	return grpcStatusError(err)
+}
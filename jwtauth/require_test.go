@@ -0,0 +1,165 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestRequireScope tests scope checks chained after JWTAuth
+func TestRequireScope(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret))
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/orders", JWTAuth(cfg), RequireScope("orders:write"), func(c *gin.Context) { c.Status(200) })
+	router.POST("/unauthenticated", RequireScope("orders:write"), func(c *gin.Context) { c.Status(200) })
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   int
+	}{
+		{"scope string", jwt.MapClaims{"scope": "orders:read orders:write"}, 200},
+		{"scp array", jwt.MapClaims{"scp": []string{"orders:write"}}, 200},
+		{"scp string", jwt.MapClaims{"scp": "orders:write"}, 200},
+		{"missing scope", jwt.MapClaims{"scope": "orders:read"}, 403},
+		{"no scope claim", jwt.MapClaims{}, 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims["sub"] = "user-1"
+			tt.claims["exp"] = time.Now().Add(time.Hour).Unix()
+			req := httptest.NewRequest("POST", "/orders", nil)
+			req.Header.Set("Authorization", "Bearer "+mustSignHS256(secret, tt.claims))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/unauthenticated", nil))
+	if w.Code != 401 {
+		t.Errorf("expected 401 without JWTAuth, got %d", w.Code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a scope containing spaces")
+		}
+	}()
+	RequireScope("orders:read orders:write")
+}
+
+// TestUnaryRequireScope tests scope checks chained after the gRPC interceptor
+func TestUnaryRequireScope(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret))
+	conn := startTestGRPCServer(t,
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor(cfg), UnaryRequireScope("health:check")),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor(cfg), StreamRequireScope("health:watch")),
+	)
+	client := healthpb.NewHealthClient(conn)
+
+	withScope := func(scope string) context.Context {
+		token := mustSignHS256(secret, jwt.MapClaims{"sub": "svc-a", "scope": scope, "exp": time.Now().Add(time.Hour).Unix()})
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	if _, err := client.Check(withScope("health:check"), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("expected Check to succeed, got %v", err)
+	}
+	_, err := client.Check(withScope("health:watch"), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.PermissionDenied || status.Convert(err).Message() != string(ErrForbidden) {
+		t.Errorf("expected PERMISSION_DENIED, got %v", err)
+	}
+
+	stream, err := client.Watch(withScope("health:check"), &healthpb.HealthCheckRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected Watch to be denied, got %v", err)
+	}
+}
+
+// TestRequireRole tests role checks with the default and nested role claims
+func TestRequireRole(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	gin.SetMode(gin.TestMode)
+	newRouter := func(cfg *Config) *gin.Engine {
+		router := gin.New()
+		router.Use(JWTAuth(cfg))
+		router.GET("/admin", RequireRole("admin"), func(c *gin.Context) { c.Status(200) })
+		router.GET("/reports", RequireAnyRole("admin", "analyst"), func(c *gin.Context) { c.Status(200) })
+		return router
+	}
+	serve := func(router *gin.Engine, path string, claims jwt.MapClaims) *httptest.ResponseRecorder {
+		claims["sub"] = "user-1"
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+mustSignHS256(secret, claims))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	defaults := newRouter(mustCreateConfig(WithHS256(secret)))
+	keycloak := newRouter(mustCreateConfig(WithHS256(secret), WithRoleClaim("realm_access.roles")))
+	tests := []struct {
+		name   string
+		router *gin.Engine
+		path   string
+		claims jwt.MapClaims
+		want   int
+	}{
+		{"roles array", defaults, "/admin", jwt.MapClaims{"roles": []string{"user", "admin"}}, 200},
+		{"role string", defaults, "/admin", jwt.MapClaims{"role": "admin"}, 200},
+		{"missing role", defaults, "/admin", jwt.MapClaims{"roles": []string{"analyst"}}, 403},
+		{"any role", defaults, "/reports", jwt.MapClaims{"roles": []string{"analyst"}}, 200},
+		{"no role claim", defaults, "/reports", jwt.MapClaims{}, 403},
+		{"nested path", keycloak, "/admin", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []string{"admin"}}}, 200},
+		{"top-level roles ignored with path", keycloak, "/admin", jwt.MapClaims{"roles": []string{"admin"}}, 403},
+		{"nested path missing role", keycloak, "/reports", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []string{"user"}}}, 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.router, tt.path, tt.claims)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := serve(defaults, "/admin", jwt.MapClaims{})
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["reason"] != string(ErrForbidden) || body["message"] != "role admin required" {
+		t.Errorf("unexpected error body %v", body)
+	}
+
+	// A namespaced claim containing dots is looked up whole
+	auth0 := newRouter(mustCreateConfig(WithHS256(secret), WithRoleClaim("https://example.com/roles")))
+	if w := serve(auth0, "/admin", jwt.MapClaims{"https://example.com/roles": []string{"admin"}}); w.Code != 200 {
+		t.Errorf("expected namespaced role claim to grant access, got %d", w.Code)
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithRoleClaim("realm_access.")); err == nil {
+		t.Error("expected error for a malformed role claim path")
+	}
+}
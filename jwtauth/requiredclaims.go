@@ -0,0 +1,223 @@
+package jwtauth
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimType is the JSON type a required claim must have
+type ClaimType int
+
+const (
+	// ClaimAny accepts any JSON type (presence check only)
+	ClaimAny ClaimType = iota
+	// ClaimString requires a JSON string
+	ClaimString
+	// ClaimBool requires a JSON boolean
+	ClaimBool
+	// ClaimNumber requires a JSON number
+	ClaimNumber
+	// ClaimStringArray requires a JSON array of strings
+	ClaimStringArray
+	// ClaimObject requires a JSON object
+	ClaimObject
+)
+
+// String returns the type name used in validation errors
+func (t ClaimType) String() string {
+	switch t {
+	case ClaimString:
+		return "string"
+	case ClaimBool:
+		return "bool"
+	case ClaimNumber:
+		return "number"
+	case ClaimStringArray:
+		return "string array"
+	case ClaimObject:
+		return "object"
+	default:
+		return "any"
+	}
+}
+
+// RequiredClaim is a typed claim expectation
+type RequiredClaim struct {
+	Name   string      // Claim name, e.g. "email_verified"
+	Type   ClaimType   // Expected JSON type (ClaimAny checks presence only)
+	Equals interface{} // Optional expected value (string, bool or number)
+}
+
+// WithClaimRequirements requires claims to be present with the given type
+// and, when Equals is set, value. Failures are INVALID_CLAIM errors naming
+// the claim and expectation; missing claims are MALFORMED as with
+// WithRequiredClaims.
+//
+//	jwtauth.WithClaimRequirements(
+//		jwtauth.RequiredClaim{Name: "email_verified", Type: jwtauth.ClaimBool, Equals: true},
+//		jwtauth.RequiredClaim{Name: "groups", Type: jwtauth.ClaimStringArray},
+//	)
+func WithClaimRequirements(requirements ...RequiredClaim) ConfigOption {
+	return func(c *Config) error {
+		for _, req := range requirements {
+			if err := req.validate(); err != nil {
+				return err
+			}
+		}
+		c.claimRequirements = append(c.claimRequirements, requirements...)
+		return nil
+	}
+}
+
+// ClaimRequirements returns the typed claim requirements
+func (c *Config) ClaimRequirements() []RequiredClaim {
+	return c.claimRequirements
+}
+
+// validate checks that the requirement is well-formed
+func (r RequiredClaim) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("required claim name cannot be empty")
+	}
+	if r.Type < ClaimAny || r.Type > ClaimObject {
+		return fmt.Errorf("required claim %s has unknown type %d", r.Name, r.Type)
+	}
+	if r.Equals != nil {
+		return r.checkEqualsType()
+	}
+	return nil
+}
+
+// checkEqualsType ensures Equals is comparable with the declared type
+func (r RequiredClaim) checkEqualsType() error {
+	var ok bool
+	switch r.Type {
+	case ClaimAny:
+		_, isString := r.Equals.(string)
+		_, isBool := r.Equals.(bool)
+		_, isNumber := toFloat(r.Equals)
+		ok = isString || isBool || isNumber
+	case ClaimString:
+		_, ok = r.Equals.(string)
+	case ClaimBool:
+		_, ok = r.Equals.(bool)
+	case ClaimNumber:
+		_, ok = toFloat(r.Equals)
+	}
+	if !ok {
+		return fmt.Errorf("required claim %s: Equals value %v (%T) is not a %s", r.Name, r.Equals, r.Equals, r.Type)
+	}
+	return nil
+}
+
+// validateClaimRequirements checks typed claim requirements
+func validateClaimRequirements(mapClaims jwt.MapClaims, cfg *Config) error {
+	for _, req := range cfg.claimRequirements {
+		value, ok := mapClaims[req.Name]
+		if !ok {
+			return NewValidationError(ErrMalformed, fmt.Sprintf("required claim missing: %s", req.Name), nil)
+		}
+		if msg := req.mismatch(value); msg != "" {
+			return NewValidationError(ErrInvalidClaim, msg, nil)
+		}
+	}
+	return nil
+}
+
+// mismatch describes how a present claim value fails the requirement, or
+// returns "" when it satisfies it
+func (r RequiredClaim) mismatch(value interface{}) string {
+	if !r.Type.matches(value) {
+		return fmt.Sprintf("claim %s must be of type %s, got %s", r.Name, r.Type, jsonTypeName(value))
+	}
+	if r.Equals != nil && !claimValueEquals(value, r.Equals) {
+		return fmt.Sprintf("claim %s must equal %v", r.Name, r.Equals)
+	}
+	return ""
+}
+
+// matches reports whether a decoded JSON value has this type
+func (t ClaimType) matches(value interface{}) bool {
+	switch t {
+	case ClaimString:
+		_, ok := value.(string)
+		return ok
+	case ClaimBool:
+		_, ok := value.(bool)
+		return ok
+	case ClaimNumber:
+		_, ok := toFloat(value)
+		return ok
+	case ClaimStringArray:
+		items, ok := value.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, item := range items {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+		return true
+	case ClaimObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	default:
+		return true
+	}
+}
+
+// claimValueEquals compares a decoded JSON value with an expected scalar.
+// Numbers compare by value regardless of Go numeric type.
+func claimValueEquals(value, expected interface{}) bool {
+	if want, ok := toFloat(expected); ok {
+		got, ok := toFloat(value)
+		return ok && got == want
+	}
+	return value == expected
+}
+
+// toFloat converts Go numeric types to float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, !math.IsNaN(n)
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// jsonTypeName describes a decoded JSON value's type for error messages
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,89 @@
+package jwtauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestClaimRequirements tests typed claim expectations
+func TestClaimRequirements(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithClaimRequirements(
+			RequiredClaim{Name: "email_verified", Type: ClaimBool, Equals: true},
+			RequiredClaim{Name: "groups", Type: ClaimStringArray},
+			RequiredClaim{Name: "level", Type: ClaimNumber, Equals: 2},
+			RequiredClaim{Name: "org", Type: ClaimObject},
+		),
+	)
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub":            "user123",
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"email_verified": true,
+			"groups":         []string{"eng", "ops"},
+			"level":          2,
+			"org":            map[string]interface{}{"id": "acme"},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		mutate      func(jwt.MapClaims)
+		wantCode    ErrorCode
+		wantMessage string
+	}{
+		{"all requirements met", func(jwt.MapClaims) {}, "", ""},
+		{"missing claim", func(c jwt.MapClaims) { delete(c, "groups") }, ErrMalformed, "required claim missing: groups"},
+		{"wrong type", func(c jwt.MapClaims) { c["email_verified"] = "true" }, ErrInvalidClaim, "claim email_verified must be of type bool, got string"},
+		{"wrong value", func(c jwt.MapClaims) { c["email_verified"] = false }, ErrInvalidClaim, "claim email_verified must equal true"},
+		{"mixed array", func(c jwt.MapClaims) { c["groups"] = []interface{}{"eng", 1} }, ErrInvalidClaim, "claim groups must be of type string array, got array"},
+		{"number mismatch", func(c jwt.MapClaims) { c["level"] = 3 }, ErrInvalidClaim, "claim level must equal 2"},
+		{"not an object", func(c jwt.MapClaims) { c["org"] = "acme" }, ErrInvalidClaim, "claim org must be of type object, got string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.mutate(claims)
+
+			_, err := parseAndValidateJWT(mustSignHS256(secret, claims), cfg)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("Expected token to validate, got %v", err)
+				}
+				return
+			}
+			valErr, ok := err.(*ValidationError)
+			if !ok || valErr.Code != tt.wantCode || valErr.Message != tt.wantMessage {
+				t.Errorf("Expected [%s] %s, got %v", tt.wantCode, tt.wantMessage, err)
+			}
+		})
+	}
+}
+
+// TestClaimRequirementsConfigErrors tests config-time validation of requirements
+func TestClaimRequirementsConfigErrors(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	tests := []struct {
+		name string
+		req  RequiredClaim
+	}{
+		{"empty name", RequiredClaim{Type: ClaimString}},
+		{"unknown type", RequiredClaim{Name: "x", Type: ClaimType(99)}},
+		{"equals type mismatch", RequiredClaim{Name: "x", Type: ClaimBool, Equals: "yes"}},
+		{"equals on array", RequiredClaim{Name: "x", Type: ClaimStringArray, Equals: "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConfig(WithHS256(secret), WithClaimRequirements(tt.req)); err == nil {
+				t.Error("Expected config error")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Blocklist reports whether a token has been revoked, keyed by its jti claim.
+// Implementations must be safe for concurrent use; a shared store (e.g. Redis)
+// makes revocations visible across service replicas.
+type Blocklist interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// WithBlocklist rejects tokens whose jti has been revoked (e.g. on logout)
+// with TOKEN_REVOKED. Tokens without a jti claim are rejected as MALFORMED
+// because they cannot be revoked. If the blocklist fails, requests are
+// rejected with REVOCATION_UNAVAILABLE rather than risk admitting a
+// revoked token.
+func WithBlocklist(blocklist Blocklist) ConfigOption {
+	return func(c *Config) error {
+		if blocklist == nil {
+			return fmt.Errorf("blocklist cannot be nil")
+		}
+		c.blocklist = blocklist
+		return nil
+	}
+}
+
+// checkRevocation consults the configured blocklist for validated claims.
+// It runs after the connection cache so revocations take effect immediately.
+func checkRevocation(ctx context.Context, cfg *Config, claims *Claims) error {
+	if cfg.blocklist == nil {
+		return nil
+	}
+	if claims.JWTID == "" {
+		return NewValidationError(ErrMalformed, "token has no jti claim and cannot be checked for revocation", nil)
+	}
+
+	revoked, err := cfg.blocklist.IsRevoked(ctx, claims.JWTID)
+	if err != nil {
+		return NewValidationError(ErrRevocationUnavailable, "revocation status unavailable", err)
+	}
+	if revoked {
+		return NewValidationError(ErrTokenRevoked, "token has been revoked", nil)
+	}
+	return nil
+}
+
+// MemoryBlocklist is an in-process Blocklist. Revocations are lost on restart
+// and not shared between replicas; use a shared store in production.
+// Lookups do not lock.
+type MemoryBlocklist struct {
+	revoked sync.Map // jti -> *time.Time after which the entry can be dropped
+}
+
+// NewMemoryBlocklist returns an empty in-process blocklist
+func NewMemoryBlocklist() *MemoryBlocklist {
+	return &MemoryBlocklist{}
+}
+
+// Revoke blocks jti until the given time, normally the token's expiry.
+// After that the token is rejected as expired anyway and the entry is dropped.
+// A zero until blocks jti indefinitely.
+func (b *MemoryBlocklist) Revoke(jti string, until time.Time) {
+	b.revoked.Store(jti, &until)
+}
+
+// Len returns the number of revoked jtis, including lapsed entries not yet
+// dropped
+func (b *MemoryBlocklist) Len() int {
+	n := 0
+	b.revoked.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// IsRevoked implements Blocklist
+func (b *MemoryBlocklist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	v, ok := b.revoked.Load(jti)
+	if !ok {
+		return false, nil
+	}
+	if until := v.(*time.Time); !until.IsZero() && time.Now().After(*until) {
+		b.revoked.CompareAndDelete(jti, v) // Keep a concurrent re-revocation
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,97 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestBlocklist tests that revoked tokens are rejected by the middleware
+func TestBlocklist(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	blocklist := NewMemoryBlocklist()
+	router := createTestRouter(mustCreateConfig(WithHS256(secret), WithBlocklist(blocklist)))
+
+	exp := time.Now().Add(time.Hour)
+	active := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "jti": "active", "exp": exp.Unix()})
+	revoked := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "jti": "revoked", "exp": exp.Unix()})
+	noJTI := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": exp.Unix()})
+	blocklist.Revoke("revoked", exp)
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantReason string
+	}{
+		{"active token", active, http.StatusOK, ""},
+		{"revoked token", revoked, http.StatusUnauthorized, string(ErrTokenRevoked)},
+		{"token without jti", noJTI, http.StatusUnauthorized, string(ErrMalformed)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantReason != "" {
+				var body map[string]string
+				json.Unmarshal(w.Body.Bytes(), &body)
+				if body["reason"] != tt.wantReason {
+					t.Errorf("Expected reason %s, got %s", tt.wantReason, body["reason"])
+				}
+			}
+		})
+	}
+}
+
+// blocklistFunc adapts a function to the Blocklist interface
+type blocklistFunc func(ctx context.Context, jti string) (bool, error)
+
+func (f blocklistFunc) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return f(ctx, jti)
+}
+
+// TestBlocklistUnavailable tests that blocklist failures fail closed
+func TestBlocklistUnavailable(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithBlocklist(blocklistFunc(func(ctx context.Context, jti string) (bool, error) {
+		return false, errors.New("connection refused")
+	})))
+
+	claims := &Claims{JWTID: "abc"}
+	err := checkRevocation(context.Background(), cfg, claims)
+	if getErrorCode(err) != string(ErrRevocationUnavailable) {
+		t.Errorf("Expected REVOCATION_UNAVAILABLE, got %v", err)
+	}
+}
+
+// TestMemoryBlocklistExpiry tests that entries are dropped once the token has expired
+func TestMemoryBlocklistExpiry(t *testing.T) {
+	blocklist := NewMemoryBlocklist()
+	ctx := context.Background()
+
+	blocklist.Revoke("expired", time.Now().Add(-time.Second))
+	blocklist.Revoke("forever", time.Time{})
+
+	if revoked, _ := blocklist.IsRevoked(ctx, "expired"); revoked {
+		t.Error("Expected entry past its expiry to be dropped")
+	}
+	if revoked, _ := blocklist.IsRevoked(ctx, "forever"); !revoked {
+		t.Error("Expected zero expiry to revoke indefinitely")
+	}
+	if _, err := NewConfig(WithBlocklist(nil)); err == nil {
+		t.Error("Expected nil blocklist to be rejected")
+	}
+}
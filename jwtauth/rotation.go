@@ -0,0 +1,352 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// KeyGenerator creates a new signing key for a rotation. Each key must have a
+// unique, non-empty KeyID.
+type KeyGenerator func(ctx context.Context) (Signer, error)
+
+// KeyPublisher publishes the set of verification keys, e.g. by writing a
+// JWKS document ({"keys": keys}) to object storage or a JWKS endpoint.
+// It is called with the complete set every time it changes.
+type KeyPublisher func(ctx context.Context, keys []JSONWebKey) error
+
+// RotationConfig configures NewRotationManager
+type RotationConfig struct {
+	Generate KeyGenerator // Creates the next signing key
+	Publish  KeyPublisher // Publishes the verification key set
+
+	// PropagationDelay is how long a newly published key waits before it
+	// signs tokens; it must cover verifiers' JWKS cache lifetimes
+	PropagationDelay time.Duration
+
+	// MaxTokenLifetime is the longest lifetime of an issued token; the
+	// previous key stays published this long after signing switches away
+	MaxTokenLifetime time.Duration
+
+	// Interval starts a rotation this often when using Run (0 rotates only
+	// when Rotate is called)
+	Interval time.Duration
+
+	Logger *slog.Logger // Logs rotation steps (optional)
+}
+
+// RotationManager codifies the signing key rotation runbook:
+//
+//  1. generate a new key and publish it alongside the current one
+//  2. after PropagationDelay, switch signing to the new key
+//  3. after MaxTokenLifetime more, retire the old key from the published set
+//
+// At every point each token in circulation can be verified by every
+// verifier. The manager is also a KeyProvider over the published keys, so
+// services that verify their own tokens can use it with WithKeyProvider.
+type RotationManager struct {
+	cfg RotationConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	active   Signer
+	pending  *rotatingKey
+	retiring []rotatingKey
+	lastRun  time.Time
+
+	// snapshot is replaced under mu after every change so the signing and
+	// verification hot paths read it without locking
+	snapshot atomic.Pointer[rotationSnapshot]
+
+	keyWatchers
+}
+
+// rotationSnapshot is an immutable view of the published keys
+type rotationSnapshot struct {
+	active  Signer
+	keys    []JSONWebKey
+	signers map[string]Signer // kid -> published signer
+}
+
+// rotatingKey is a key waiting to be activated or retired at a given time
+type rotatingKey struct {
+	signer Signer
+	at     time.Time
+}
+
+// ErrRotationInProgress is returned by Rotate while a new key is propagating
+var ErrRotationInProgress = errors.New("key rotation already in progress")
+
+// NewRotationManager returns a manager whose current signing key is initial.
+// Call Publish (or Rotate) to publish the initial key set.
+func NewRotationManager(initial Signer, cfg RotationConfig) (*RotationManager, error) {
+	if initial == nil {
+		return nil, fmt.Errorf("initial signer cannot be nil")
+	}
+	if initial.KeyID() == "" {
+		return nil, fmt.Errorf("initial signer must have a key ID")
+	}
+	if cfg.Generate == nil || cfg.Publish == nil {
+		return nil, fmt.Errorf("rotation requires Generate and Publish")
+	}
+	if cfg.PropagationDelay <= 0 || cfg.MaxTokenLifetime <= 0 {
+		return nil, fmt.Errorf("PropagationDelay and MaxTokenLifetime must be positive")
+	}
+	if cfg.Interval < 0 {
+		return nil, fmt.Errorf("rotation interval cannot be negative")
+	}
+
+	m := &RotationManager{cfg: cfg, now: time.Now, active: initial}
+	m.storeSnapshotLocked()
+	return m, nil
+}
+
+// Current returns the signer to use for new tokens. Pass it to SignToken
+// rather than caching it: it changes when a rotation switches keys.
+func (m *RotationManager) Current() Signer {
+	return m.snapshot.Load().active
+}
+
+// Keys returns the published verification keys: the current key, a
+// propagating key and keys awaiting retirement
+func (m *RotationManager) Keys() []JSONWebKey {
+	return slices.Clone(m.snapshot.Load().keys)
+}
+
+func (m *RotationManager) keysLocked() []JSONWebKey {
+	signers := []Signer{m.active}
+	if m.pending != nil {
+		signers = append(signers, m.pending.signer)
+	}
+	for _, k := range m.retiring {
+		signers = append(signers, k.signer)
+	}
+
+	keys := make([]JSONWebKey, len(signers))
+	for i, s := range signers {
+		keys[i] = JSONWebKey{KeyID: s.KeyID(), Algorithm: s.Algorithm(), Use: "sig", Key: s.Public()}
+	}
+	return keys
+}
+
+// storeSnapshotLocked publishes the current state to lock-free readers and
+// reports changes of the published keys to watchers
+func (m *RotationManager) storeSnapshotLocked() {
+	snap := &rotationSnapshot{active: m.active, keys: m.keysLocked(), signers: make(map[string]Signer)}
+	snap.signers[m.active.KeyID()] = m.active
+	if m.pending != nil {
+		snap.signers[m.pending.signer.KeyID()] = m.pending.signer
+	}
+	for _, k := range m.retiring {
+		snap.signers[k.signer.KeyID()] = k.signer
+	}
+	if previous := m.snapshot.Swap(snap); previous != nil {
+		m.notifyKeys(changeSourceRotation, jwkListLabels(previous.keys), jwkListLabels(snap.keys))
+	}
+}
+
+// jwkListLabels returns the sorted keyLabel values of keys
+func jwkListLabels(keys []JSONWebKey) []string {
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		labels[i] = keyLabel(k.KeyID, k.Key)
+	}
+	slices.Sort(labels)
+	return labels
+}
+
+// Publish publishes the current key set
+func (m *RotationManager) Publish(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cfg.Publish(ctx, m.keysLocked())
+}
+
+// Rotate generates a new key and publishes it. Signing switches to it once
+// PropagationDelay has passed and Advance (or Run) is called.
+func (m *RotationManager) Rotate(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.storeSnapshotLocked()
+
+	if m.pending != nil {
+		return ErrRotationInProgress
+	}
+
+	next, err := m.cfg.Generate(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	if next == nil || next.KeyID() == "" {
+		return fmt.Errorf("generated signing key must have a key ID")
+	}
+	if m.findLocked(next.KeyID()) != nil {
+		return fmt.Errorf("generated key ID %s is already published", next.KeyID())
+	}
+
+	now := m.now()
+	m.pending = &rotatingKey{signer: next, at: now.Add(m.cfg.PropagationDelay)}
+	if err := m.cfg.Publish(ctx, m.keysLocked()); err != nil {
+		m.pending = nil
+		return fmt.Errorf("failed to publish key set: %w", err)
+	}
+	m.lastRun = now
+
+	m.log(slog.LevelInfo, "signing key published", "kid", next.KeyID(), "activates_at", m.pending.at)
+	return nil
+}
+
+// Advance performs the transitions that are due: activating a propagated
+// key and retiring keys past MaxTokenLifetime (republishing the set)
+func (m *RotationManager) Advance(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.storeSnapshotLocked()
+
+	now := m.now()
+	if m.pending != nil && !now.Before(m.pending.at) {
+		previous := m.active
+		m.retiring = append(m.retiring, rotatingKey{signer: previous, at: now.Add(m.cfg.MaxTokenLifetime)})
+		m.active = m.pending.signer
+		m.pending = nil
+		m.log(slog.LevelInfo, "signing key activated", "kid", m.active.KeyID(), "previous_kid", previous.KeyID())
+	}
+
+	var kept []rotatingKey
+	var retired []string
+	for _, k := range m.retiring {
+		if now.Before(k.at) {
+			kept = append(kept, k)
+		} else {
+			retired = append(retired, k.signer.KeyID())
+		}
+	}
+	if len(retired) == 0 {
+		return nil
+	}
+
+	previous := m.retiring
+	m.retiring = kept
+	if err := m.cfg.Publish(ctx, m.keysLocked()); err != nil {
+		m.retiring = previous // Retry on the next Advance
+		return fmt.Errorf("failed to publish key set: %w", err)
+	}
+	m.log(slog.LevelInfo, "signing keys retired", "kids", retired)
+	return nil
+}
+
+// Run publishes the key set, then rotates every Interval and advances
+// transitions as they fall due, until ctx is cancelled. Failed steps are
+// logged and retried.
+func (m *RotationManager) Run(ctx context.Context) error {
+	if err := m.Publish(ctx); err != nil {
+		m.log(slog.LevelWarn, "key set publish failed", "error", err)
+	}
+	m.mu.Lock()
+	m.lastRun = m.now()
+	m.mu.Unlock()
+
+	for {
+		if err := m.Advance(ctx); err != nil {
+			m.log(slog.LevelWarn, "key rotation step failed", "error", err)
+		}
+		if m.rotationDue() {
+			if err := m.Rotate(ctx); err != nil && !errors.Is(err, ErrRotationInProgress) {
+				m.log(slog.LevelWarn, "key rotation failed", "error", err)
+				m.mu.Lock()
+				m.lastRun = m.now().Add(time.Minute - m.cfg.Interval) // Retry in a minute
+				m.mu.Unlock()
+			}
+		}
+
+		timer := time.NewTimer(m.untilNextStep())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// rotationDue reports whether Run should start a rotation
+func (m *RotationManager) rotationDue() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.cfg.Interval > 0 && m.pending == nil && !m.now().Before(m.lastRun.Add(m.cfg.Interval))
+}
+
+// untilNextStep returns the time until the next scheduled transition,
+// capped so failed steps are retried
+func (m *RotationManager) untilNextStep() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := m.now().Add(time.Minute)
+	if m.pending != nil && m.pending.at.Before(next) {
+		next = m.pending.at
+	}
+	for _, k := range m.retiring {
+		if k.at.Before(next) {
+			next = k.at
+		}
+	}
+	if m.cfg.Interval > 0 && m.pending == nil {
+		if due := m.lastRun.Add(m.cfg.Interval); due.Before(next) {
+			next = due
+		}
+	}
+
+	// Overdue steps are failed publishes; back off instead of spinning
+	if wait := next.Sub(m.now()); wait > 0 {
+		return wait
+	}
+	return time.Second
+}
+
+// KeySource implements KeySourcer
+func (m *RotationManager) KeySource() string { return "rotation" }
+
+// VerificationKey implements KeyProvider over the published keys. It does
+// not lock, so it is safe on the validation hot path.
+func (m *RotationManager) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	snap := m.snapshot.Load()
+	if kid == "" {
+		if snap.active.Algorithm() == alg {
+			return snap.active.Public(), nil
+		}
+		return nil, ErrKeyNotFound
+	}
+	if s := snap.signers[kid]; s != nil && s.Algorithm() == alg {
+		return s.Public(), nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// findLocked returns the published signer with the given key ID
+func (m *RotationManager) findLocked(kid string) Signer {
+	if m.active.KeyID() == kid {
+		return m.active
+	}
+	if m.pending != nil && m.pending.signer.KeyID() == kid {
+		return m.pending.signer
+	}
+	for _, k := range m.retiring {
+		if k.signer.KeyID() == kid {
+			return k.signer
+		}
+	}
+	return nil
+}
+
+// log emits a rotation log entry when a logger is configured
+func (m *RotationManager) log(level slog.Level, msg string, args ...interface{}) {
+	if m.cfg.Logger != nil {
+		m.cfg.Logger.Log(context.Background(), level, msg, args...)
+	}
+}
@@ -0,0 +1,185 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// rotationHarness drives a RotationManager with a fake clock
+type rotationHarness struct {
+	now       time.Time
+	generated int
+	published [][]string
+	failNext  bool
+}
+
+func (h *rotationHarness) generate(ctx context.Context) (Signer, error) {
+	h.generated++
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewCryptoSigner("ES256", fmt.Sprintf("key-%d", h.generated), key)
+}
+
+func (h *rotationHarness) publish(ctx context.Context, keys []JSONWebKey) error {
+	if h.failNext {
+		h.failNext = false
+		return errors.New("storage unavailable")
+	}
+	kids := make([]string, len(keys))
+	for i, k := range keys {
+		kids[i] = k.KeyID
+	}
+	h.published = append(h.published, kids)
+	return nil
+}
+
+func (h *rotationHarness) lastPublished() string {
+	if len(h.published) == 0 {
+		return ""
+	}
+	return fmt.Sprint(h.published[len(h.published)-1])
+}
+
+func newTestRotationManager(t *testing.T) (*RotationManager, *rotationHarness) {
+	t.Helper()
+	h := &rotationHarness{now: time.Unix(1700000000, 0)}
+	initial, err := h.generate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewRotationManager(initial, RotationConfig{
+		Generate:         h.generate,
+		Publish:          h.publish,
+		PropagationDelay: 10 * time.Minute,
+		MaxTokenLifetime: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewRotationManager failed: %v", err)
+	}
+	m.now = func() time.Time { return h.now }
+	return m, h
+}
+
+// TestRotationManagerLifecycle walks a key through publish, switch and retire
+func TestRotationManagerLifecycle(t *testing.T) {
+	ctx := context.Background()
+	m, h := newTestRotationManager(t)
+
+	cfg := mustCreateConfig(WithKeyProvider("ES256", m))
+	oldToken, err := SignToken(ctx, m.Current(), map[string]interface{}{"sub": "user", "exp": time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1. New key is published alongside the current one but does not sign yet
+	if err := m.Rotate(ctx); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if got := h.lastPublished(); got != "[key-1 key-2]" {
+		t.Errorf("Expected both keys published, got %s", got)
+	}
+	if m.Current().KeyID() != "key-1" {
+		t.Errorf("Expected key-1 to keep signing during propagation, got %s", m.Current().KeyID())
+	}
+	if err := m.Rotate(ctx); !errors.Is(err, ErrRotationInProgress) {
+		t.Errorf("Expected ErrRotationInProgress, got %v", err)
+	}
+
+	// 2. After the propagation delay signing switches
+	h.now = h.now.Add(10 * time.Minute)
+	if err := m.Advance(ctx); err != nil {
+		t.Fatalf("Advance failed: %v", err)
+	}
+	if m.Current().KeyID() != "key-2" {
+		t.Fatalf("Expected key-2 to sign after propagation, got %s", m.Current().KeyID())
+	}
+	newToken, _ := SignToken(ctx, m.Current(), map[string]interface{}{"sub": "user", "exp": time.Now().Add(time.Hour).Unix()})
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := parseAndValidateJWT(token, cfg); err != nil {
+			t.Errorf("Expected %s token to validate during overlap, got %v", name, err)
+		}
+	}
+
+	// 3. The old key is retired after the maximum token lifetime
+	h.now = h.now.Add(59 * time.Minute)
+	m.Advance(ctx)
+	if len(m.Keys()) != 2 {
+		t.Errorf("Expected old key kept until tokens expire, got %d keys", len(m.Keys()))
+	}
+	h.now = h.now.Add(time.Minute)
+	h.failNext = true
+	if err := m.Advance(ctx); err == nil {
+		t.Error("Expected publish failure to be reported")
+	}
+	if err := m.Advance(ctx); err != nil {
+		t.Fatalf("Expected retirement to be retried, got %v", err)
+	}
+	if got := h.lastPublished(); got != "[key-2]" {
+		t.Errorf("Expected only key-2 published, got %s", got)
+	}
+	if _, err := parseAndValidateJWT(oldToken, cfg); getErrorCode(err) != string(ErrKeyUnavailable) {
+		t.Errorf("Expected retired key to be unavailable, got %v", err)
+	}
+}
+
+// TestRotationManagerPublishFailure tests that a failed publish aborts the rotation
+func TestRotationManagerPublishFailure(t *testing.T) {
+	m, h := newTestRotationManager(t)
+
+	h.failNext = true
+	if err := m.Rotate(context.Background()); err == nil {
+		t.Fatal("Expected Rotate to fail")
+	}
+	if len(m.Keys()) != 1 {
+		t.Errorf("Expected unpublished key to be discarded, got %d keys", len(m.Keys()))
+	}
+	if err := m.Rotate(context.Background()); err != nil {
+		t.Errorf("Expected retry to succeed, got %v", err)
+	}
+}
+
+// TestNewRotationManagerValidation tests constructor validation
+func TestNewRotationManagerValidation(t *testing.T) {
+	h := &rotationHarness{}
+	initial, _ := h.generate(context.Background())
+	noKid, _ := NewCryptoSigner("ES256", "", mustGenerateECKey())
+	valid := RotationConfig{Generate: h.generate, Publish: h.publish, PropagationDelay: time.Minute, MaxTokenLifetime: time.Hour}
+
+	tests := []struct {
+		name    string
+		initial Signer
+		mutate  func(*RotationConfig)
+	}{
+		{"nil signer", nil, func(*RotationConfig) {}},
+		{"signer without kid", noKid, func(*RotationConfig) {}},
+		{"missing generator", initial, func(c *RotationConfig) { c.Generate = nil }},
+		{"missing publisher", initial, func(c *RotationConfig) { c.Publish = nil }},
+		{"zero propagation delay", initial, func(c *RotationConfig) { c.PropagationDelay = 0 }},
+		{"zero token lifetime", initial, func(c *RotationConfig) { c.MaxTokenLifetime = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.mutate(&cfg)
+			if _, err := NewRotationManager(tt.initial, cfg); err == nil {
+				t.Error("Expected configuration to be rejected")
+			}
+		})
+	}
+}
+
+func mustGenerateECKey() *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
@@ -0,0 +1,161 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestWithRouteRequirements tests route pattern authorization in JWTAuth
+func TestWithRouteRequirements(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithRouteRequirementsJSON([]byte(`[
+			{"methods": ["DELETE"], "path": "/api/users/:id", "roles": ["admin"]},
+			{"path": "/api/users/:id", "scopes": ["users:read"]},
+			{"path": "/admin/*", "claims": {"staff": true}}
+		]`)),
+	)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/users/:id", ok)
+	router.DELETE("/api/users/:id", ok)
+	router.GET("/admin/*path", ok)
+	router.GET("/public", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		claims jwt.MapClaims
+		want   int
+	}{
+		{"read with scope", "GET", "/api/users/42", jwt.MapClaims{"scope": "users:read"}, http.StatusOK},
+		{"read without scope", "GET", "/api/users/42", jwt.MapClaims{}, http.StatusForbidden},
+		{"delete as admin", "DELETE", "/api/users/42", jwt.MapClaims{"roles": []string{"admin"}}, http.StatusOK},
+		{"delete with read scope only", "DELETE", "/api/users/42", jwt.MapClaims{"scope": "users:read"}, http.StatusForbidden},
+		{"admin wildcard", "GET", "/admin/reports/daily", jwt.MapClaims{"staff": true}, http.StatusOK},
+		{"admin wildcard denied", "GET", "/admin/reports/daily", jwt.MapClaims{"staff": false}, http.StatusForbidden},
+		{"dot segments", "GET", "/public/../admin/reports", jwt.MapClaims{}, http.StatusForbidden},
+		{"no matching rule", "GET", "/public", jwt.MapClaims{}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.claims["sub"] = "user-1"
+			tt.claims["exp"] = time.Now().Add(time.Hour).Unix()
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+mustSignHS256(secret, tt.claims))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestRouteRequirementsAlgorithms tests a stronger algorithm requirement on
+// admin routes of a config that also accepts HS256
+func TestRouteRequirementsAlgorithms(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	ecKey := mustGenerateECKey()
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithES256(&ecKey.PublicKey),
+		WithRouteRequirementsJSON([]byte(`[{"path": "/admin/*", "algorithms": ["RS256", "ES256"]}]`)),
+	)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/admin/users", ok)
+	router.GET("/public", ok)
+
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	esToken, _ := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(ecKey)
+	hsToken := mustSignHS256(secret, claims)
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		want  int
+	}{
+		{"ES256 on admin route", "/admin/users", esToken, http.StatusOK},
+		{"HS256 on admin route", "/admin/users", hsToken, http.StatusForbidden},
+		{"HS256 elsewhere", "/public", hsToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestRouteRuleMatches tests keyMatch2-style pattern matching
+func TestRouteRuleMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/api/users/:id", "/api/users/42", true},
+		{"/api/users/:id", "/api/users/", false},
+		{"/api/users/:id", "/api/users/42/posts", false},
+		{"/api/users/:id/posts", "/api/users/42/posts", true},
+		{"/admin/*", "/admin/", true},
+		{"/admin/*", "/admin/a/b", true},
+		{"/admin/*", "/admin", false},
+		{"/health", "/health", true},
+		{"/health", "/healthz", false},
+	}
+	for _, tt := range tests {
+		rule, err := compileRouteRule(RouteRule{Path: tt.pattern})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.pattern, err)
+		}
+		if got := rule.matches("GET", cleanRoutePath(tt.path)); got != tt.want {
+			t.Errorf("%s against %s: expected %v, got %v", tt.pattern, tt.path, tt.want, got)
+		}
+	}
+}
+
+// TestWithRouteRequirementsInvalid tests rule validation
+func TestWithRouteRequirementsInvalid(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	tests := []struct {
+		name string
+		opt  ConfigOption
+	}{
+		{"relative path", WithRouteRequirements(RouteRule{Path: "api/users"})},
+		{"wildcard not last", WithRouteRequirements(RouteRule{Path: "/api/*/users"})},
+		{"unnamed parameter", WithRouteRequirements(RouteRule{Path: "/api/:"})},
+		{"invalid scope", WithRouteRequirements(RouteRule{Path: "/api", Scopes: []string{"a b"}})},
+		{"object claim value", WithRouteRequirements(RouteRule{Path: "/api", Claims: map[string]interface{}{"x": map[string]interface{}{}}})},
+		{"malformed JSON", WithRouteRequirementsJSON([]byte(`{"path": "/api"}`))},
+		{"unknown algorithm", WithRouteRequirements(RouteRule{Path: "/api", Algorithms: []string{"XS256"}})},
+		{"none algorithm", WithRouteRequirements(RouteRule{Path: "/api", Algorithms: []string{"none"}})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewConfig(WithHS256(secret), tt.opt); err == nil {
+				t.Error("expected configuration error")
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SecretFunc fetches an HMAC secret, e.g. from AWS Secrets Manager or GCP
+// Secret Manager. Wrapping the SDK call in a SecretFunc keeps the SDK out of
+// this module's dependencies.
+type SecretFunc func(ctx context.Context) ([]byte, error)
+
+// SecretFromFile returns a SecretFunc reading the secret from path on every
+// fetch, e.g. a mounted Kubernetes secret that is updated in place. Trailing
+// CR and LF bytes are removed.
+func SecretFromFile(path string) SecretFunc {
+	return func(ctx context.Context) ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.TrimRight(data, "\r\n"), nil
+	}
+}
+
+// SecretFromReader returns a SecretFunc reading the secret from r on the
+// first fetch, with trailing CR and LF bytes removed. Later fetches return
+// the same secret, so it does not support reloads.
+func SecretFromReader(r io.Reader) SecretFunc {
+	var once sync.Once
+	var secret []byte
+	var err error
+	return func(ctx context.Context) ([]byte, error) {
+		once.Do(func() {
+			var data []byte
+			if data, err = io.ReadAll(r); err == nil {
+				secret = bytes.TrimRight(data, "\r\n")
+			}
+		})
+		return secret, err
+	}
+}
+
+// secretFetchTimeout bounds each SecretFunc call
+const secretFetchTimeout = 30 * time.Second
+
+// secretRetryInterval is the longest wait before retrying a failed reload
+const secretRetryInterval = 30 * time.Second
+
+// WithHS256Provider configures HS256 validation with a secret fetched once by
+// fetch while NewConfig runs. NewConfig fails if the fetch fails or returns
+// a secret shorter than 32 bytes.
+func WithHS256Provider(fetch SecretFunc) ConfigOption {
+	return WithHS256ProviderRefresh(fetch, 0)
+}
+
+// WithHS256ProviderRefresh is WithHS256Provider with the secret fetched again
+// once it is older than every. Reloads run in the background after the first
+// request past the interval, so validation never waits on the secret
+// manager; while a reload is pending or after it fails, the previous secret
+// stays in use and failed reloads are retried within 30 seconds.
+//
+// Tokens signed with the previous secret fail once the new one is loaded,
+// so rotate by issuing with the new secret only after every has elapsed, or
+// use WithKeyProvider with kids for overlapping secrets.
+func WithHS256ProviderRefresh(fetch SecretFunc, every time.Duration) ConfigOption {
+	return func(c *Config) error {
+		if fetch == nil {
+			return fmt.Errorf("HS256 secret provider cannot be nil")
+		}
+		if every < 0 {
+			return fmt.Errorf("HS256 secret refresh interval cannot be negative")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), secretFetchTimeout)
+		defer cancel()
+		secret, err := fetchSecret(ctx, fetch)
+		if err != nil {
+			return err
+		}
+		if every == 0 {
+			return WithHS256(secret)(c)
+		}
+
+		p := &reloadingSecret{fetch: fetch, every: every}
+		p.current.Store(&secretState{secret: secret, next: time.Now().Add(every)})
+		c.validators["HS256"] = algorithmValidator{
+			signingMethod: jwt.SigningMethodHS256,
+			keyProvider:   p,
+		}
+		return nil
+	}
+}
+
+// fetchSecret calls fetch and enforces the HS256 minimum secret length
+func fetchSecret(ctx context.Context, fetch SecretFunc) ([]byte, error) {
+	secret, err := fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching HS256 secret: %w", err)
+	}
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("HS256 secret must be at least 32 bytes (256 bits), got %d bytes", len(secret))
+	}
+	return secret, nil
+}
+
+// reloadingSecret is the KeyProvider behind WithHS256ProviderRefresh
+type reloadingSecret struct {
+	keyWatchers
+
+	fetch     SecretFunc
+	every     time.Duration
+	current   atomic.Pointer[secretState]
+	reloading atomic.Bool
+}
+
+// secretState is the secret in use and when to reload it
+type secretState struct {
+	secret []byte
+	next   time.Time
+}
+
+// KeySource implements KeySourcer
+func (p *reloadingSecret) KeySource() string { return "secret_provider" }
+
+// VerificationKey implements KeyProvider. It returns the current secret and
+// starts a background reload when the secret is due.
+func (p *reloadingSecret) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	state := p.current.Load()
+	if time.Now().After(state.next) && p.reloading.CompareAndSwap(false, true) {
+		go p.reload(context.WithoutCancel(ctx))
+	}
+	return state.secret, nil
+}
+
+// reload fetches a new secret, keeping the previous one on failure
+func (p *reloadingSecret) reload(ctx context.Context) {
+	defer p.reloading.Store(false)
+
+	ctx, cancel := context.WithTimeout(ctx, secretFetchTimeout)
+	defer cancel()
+	secret, err := fetchSecret(ctx, p.fetch)
+	if err != nil {
+		retry := min(p.every, secretRetryInterval)
+		p.current.Store(&secretState{secret: p.current.Load().secret, next: time.Now().Add(retry)})
+		return
+	}
+	previous := p.current.Swap(&secretState{secret: secret, next: time.Now().Add(p.every)})
+	p.notifyKeys(changeSourceSecretRefresh, []string{keyLabel("", previous.secret)}, []string{keyLabel("", secret)})
+}
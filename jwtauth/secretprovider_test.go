@@ -0,0 +1,121 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestWithHS256Provider tests secrets fetched while building the config
+func TestWithHS256Provider(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	cfg := mustCreateConfig(WithHS256Provider(func(ctx context.Context) ([]byte, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("Expected secret fetch to have a deadline")
+		}
+		return secret, nil
+	}))
+	if _, err := parseAndValidateJWT(token, cfg); err != nil {
+		t.Errorf("Expected token to validate, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		fetch   SecretFunc
+		wantErr string
+	}{
+		{"fetch error", func(ctx context.Context) ([]byte, error) { return nil, errors.New("access denied") }, "access denied"},
+		{"short secret", func(ctx context.Context) ([]byte, error) { return []byte("short"), nil }, "at least 32 bytes"},
+		{"nil provider", nil, "cannot be nil"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfig(WithHS256Provider(tt.fetch))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("file and reader sources", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secret")
+		if err := os.WriteFile(path, append(secret, '\n'), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		for _, fetch := range []SecretFunc{SecretFromFile(path), SecretFromReader(strings.NewReader(string(secret) + "\r\n"))} {
+			cfg := mustCreateConfig(WithHS256Provider(fetch))
+			if _, err := parseAndValidateJWT(token, cfg); err != nil {
+				t.Errorf("Expected token to validate, got %v", err)
+			}
+		}
+	})
+}
+
+// TestWithHS256ProviderRefresh tests background secret reloads
+func TestWithHS256ProviderRefresh(t *testing.T) {
+	oldSecret := []byte("old-secret-key-min-32-bytes-long!!!")
+	newSecret := []byte("new-secret-key-min-32-bytes-long!!!")
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	oldToken, newToken := mustSignHS256(oldSecret, claims), mustSignHS256(newSecret, claims)
+
+	var mu sync.Mutex
+	current, fetchErr, fetches := oldSecret, error(nil), 0
+	cfg := mustCreateConfig(WithHS256ProviderRefresh(func(ctx context.Context) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches++
+		return current, fetchErr
+	}, 20*time.Millisecond))
+
+	// waitForFetches validates oldToken until the provider was called n times
+	waitForFetches := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			parseAndValidateJWT(oldToken, cfg)
+			mu.Lock()
+			done := fetches >= n
+			mu.Unlock()
+			if done {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d fetches", n)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// A failed reload keeps the previous secret
+	mu.Lock()
+	fetchErr = errors.New("secret manager unavailable")
+	mu.Unlock()
+	waitForFetches(2)
+	if _, err := parseAndValidateJWT(oldToken, cfg); err != nil {
+		t.Errorf("Expected previous secret after failed reload, got %v", err)
+	}
+
+	mu.Lock()
+	current, fetchErr = newSecret, nil
+	mu.Unlock()
+	waitForFetches(3)
+	deadline := time.Now().Add(5 * time.Second)
+	for _, err := parseAndValidateJWT(newToken, cfg); err != nil; _, err = parseAndValidateJWT(newToken, cfg) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected reloaded secret to validate, got %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := parseAndValidateJWT(oldToken, cfg); getErrorCode(err) != string(ErrInvalidSignature) {
+		t.Errorf("Expected previous secret to be replaced, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package jwtauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// Comparing secrets with == or bytes.Equal returns at the first differing
+// byte, so response times reveal how much of a guess was right. The helpers
+// below take the same time whatever the contents, and hash both inputs
+// first so the time does not reveal the secret's length either. Use them
+// whenever a request value is checked against a secret: API keys, CSRF
+// tokens, webhook secrets, or claims such as a session binding.
+
+// SecureCompare reports whether a and b are equal in constant time
+func SecureCompare(a, b string) bool {
+	return SecureCompareBytes([]byte(a), []byte(b))
+}
+
+// SecureCompareBytes reports whether a and b are equal in constant time
+func SecureCompareBytes(a, b []byte) bool {
+	sumA, sumB := sha256.Sum256(a), sha256.Sum256(b)
+	return subtle.ConstantTimeCompare(sumA[:], sumB[:]) == 1
+}
+
+// SecureCompareAny reports whether value equals one of candidates, e.g. the
+// currently valid API keys. Every candidate is compared, so the time does
+// not reveal which one matched.
+func SecureCompareAny(value string, candidates ...string) bool {
+	sum := sha256.Sum256([]byte(value))
+	match := 0
+	for _, candidate := range candidates {
+		candidateSum := sha256.Sum256([]byte(candidate))
+		match |= subtle.ConstantTimeCompare(sum[:], candidateSum[:])
+	}
+	return match == 1
+}
+
+// SecureClaimEquals reports whether the string claim name equals expected
+// in constant time, e.g. a csrf claim against the X-CSRF-Token header.
+// Missing and non-string claims never match, and an empty expected value
+// never matches either.
+func SecureClaimEquals(claims *Claims, name, expected string) bool {
+	if claims == nil {
+		return false
+	}
+	value, ok := claims.GetString(name)
+	return ok && expected != "" && SecureCompare(value, expected)
+}
@@ -0,0 +1,54 @@
+package jwtauth
+
+import "testing"
+
+// TestSecureCompare tests the constant-time comparison helpers
+func TestSecureCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"api-key-123", "api-key-123", true},
+		{"api-key-123", "api-key-124", false},
+		{"api-key-123", "api-key-1234", false},
+		{"", "", true},
+		{"", "x", false},
+	}
+	for _, tt := range tests {
+		if got := SecureCompare(tt.a, tt.b); got != tt.want {
+			t.Errorf("SecureCompare(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := SecureCompareBytes([]byte(tt.a), []byte(tt.b)); got != tt.want {
+			t.Errorf("SecureCompareBytes(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if !SecureCompareAny("key-b", "key-a", "key-b", "key-c") {
+		t.Error("expected a match among candidates")
+	}
+	if SecureCompareAny("key-d", "key-a", "key-b") || SecureCompareAny("key-a") {
+		t.Error("expected no match")
+	}
+}
+
+// TestSecureClaimEquals tests constant-time claim comparison
+func TestSecureClaimEquals(t *testing.T) {
+	claims := &Claims{Subject: "user-1", Custom: map[string]interface{}{"csrf": "token-abc", "count": 3.0}}
+
+	if !SecureClaimEquals(claims, "csrf", "token-abc") || !SecureClaimEquals(claims, "sub", "user-1") {
+		t.Error("expected matching claims")
+	}
+	for _, tt := range []struct{ name, expected string }{
+		{"csrf", "token-abd"},
+		{"csrf", ""},
+		{"missing", ""},
+		{"count", "3"},
+	} {
+		if SecureClaimEquals(claims, tt.name, tt.expected) {
+			t.Errorf("expected %s=%q not to match", tt.name, tt.expected)
+		}
+	}
+	if SecureClaimEquals(nil, "csrf", "token-abc") {
+		t.Error("expected nil claims not to match")
+	}
+}
@@ -0,0 +1,200 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SelfTestStatus is the outcome of a single self-test check
+type SelfTestStatus string
+
+const (
+	SelfTestPass    SelfTestStatus = "pass"
+	SelfTestFail    SelfTestStatus = "fail"
+	SelfTestSkipped SelfTestStatus = "skipped"
+)
+
+// SelfTestCheck is the result of checking one configured algorithm
+type SelfTestCheck struct {
+	Algorithm string         // Algorithm under test
+	Check     string         // "round_trip", "key_provider" or "static_key"
+	Status    SelfTestStatus // Outcome
+	Detail    string         // Failure reason or skip explanation
+	Latency   time.Duration  // Time spent on the check
+}
+
+// SelfTestReport summarizes a Config self-test
+type SelfTestReport struct {
+	Checks   []SelfTestCheck
+	Duration time.Duration
+}
+
+// OK reports whether no check failed
+func (r *SelfTestReport) OK() bool {
+	for _, check := range r.Checks {
+		if check.Status == SelfTestFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Failures returns the failed checks
+func (r *SelfTestReport) Failures() []SelfTestCheck {
+	var failed []SelfTestCheck
+	for _, check := range r.Checks {
+		if check.Status == SelfTestFail {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// SelfTest exercises every configured algorithm so misconfigured keys are
+// caught at deploy time rather than on the first user request:
+//
+//   - HMAC secrets: a throwaway token is minted and verified
+//   - Key providers: the provider is probed for a key; providers that also
+//     implement Signer (HSM, KMS) get a full mint-and-verify round trip
+//   - Static public keys: the key is sanity-checked (tokens cannot be minted
+//     without the private key)
+//
+// Only the signature path is exercised; policy checks such as audience or
+// required claims are not evaluated.
+func (c *Config) SelfTest(ctx context.Context) *SelfTestReport {
+	start := time.Now()
+	report := &SelfTestReport{}
+
+	for _, alg := range c.AvailableAlgorithms() {
+		validator, _ := c.getValidator(alg)
+		checkStart := time.Now()
+
+		var check SelfTestCheck
+		switch {
+		case validator.keyProvider != nil:
+			check = c.selfTestProvider(ctx, alg, validator)
+		case validator.keySet != nil:
+			check = c.selfTestKeySet(ctx, validator)
+		default:
+			check = c.selfTestStaticKey(ctx, alg, validator)
+		}
+
+		check.Algorithm = alg
+		check.Latency = time.Since(checkStart)
+		report.Checks = append(report.Checks, check)
+	}
+
+	report.Duration = time.Since(start)
+	return report
+}
+
+// selfTestProvider probes a key provider, round-tripping when it can sign
+func (c *Config) selfTestProvider(ctx context.Context, alg string, validator algorithmValidator) SelfTestCheck {
+	if signer, ok := validator.keyProvider.(Signer); ok && signer.Algorithm() == alg {
+		token, err := signTokenType(ctx, signer, c.selfTestTokenType(), selfTestClaims())
+		if err != nil {
+			return SelfTestCheck{Check: "round_trip", Status: SelfTestFail, Detail: fmt.Sprintf("signing failed: %v", err)}
+		}
+		return c.selfTestVerify(ctx, token)
+	}
+
+	if prefetcher, ok := validator.keyProvider.(KeyPrefetcher); ok {
+		if err := prefetcher.Prefetch(ctx); err != nil {
+			return SelfTestCheck{Check: "key_provider", Status: SelfTestFail, Detail: fmt.Sprintf("key provider unavailable: %v", err)}
+		}
+		return SelfTestCheck{Check: "key_provider", Status: SelfTestPass}
+	}
+
+	key, err := validator.keyProvider.VerificationKey(ctx, alg, "")
+	if err != nil {
+		return SelfTestCheck{Check: "key_provider", Status: SelfTestFail, Detail: fmt.Sprintf("key provider unavailable: %v", err)}
+	}
+	if key == nil {
+		return SelfTestCheck{Check: "key_provider", Status: SelfTestFail, Detail: "key provider returned no key"}
+	}
+	return SelfTestCheck{Check: "key_provider", Status: SelfTestPass}
+}
+
+// selfTestStaticKey round-trips HMAC secrets and sanity-checks public keys
+func (c *Config) selfTestStaticKey(ctx context.Context, alg string, validator algorithmValidator) SelfTestCheck {
+	if secret, ok := validator.signingKey.([]byte); ok {
+		token := jwt.NewWithClaims(validator.signingMethod, jwt.MapClaims(selfTestClaims()))
+		token.Header["typ"] = c.selfTestTokenType()
+		signed, err := token.SignedString(secret)
+		if err != nil {
+			return SelfTestCheck{Check: "round_trip", Status: SelfTestFail, Detail: fmt.Sprintf("signing failed: %v", err)}
+		}
+		return c.selfTestVerify(ctx, signed)
+	}
+
+	if rsaKey, ok := validator.signingKey.(*rsa.PublicKey); ok {
+		if rsaKey.N == nil || rsaKey.N.BitLen() < 2048 {
+			return SelfTestCheck{Check: "static_key", Status: SelfTestFail, Detail: "RSA key is smaller than 2048 bits"}
+		}
+	}
+	return SelfTestCheck{Check: "static_key", Status: SelfTestSkipped, Detail: "public key only; token round trip not possible"}
+}
+
+// selfTestKeySet checks every key of a key set like a single static key,
+// minting HMAC tokens with the key's kid so routing is exercised too
+func (c *Config) selfTestKeySet(ctx context.Context, validator algorithmValidator) SelfTestCheck {
+	result := SelfTestCheck{Check: "static_key", Status: SelfTestSkipped, Detail: "public keys only; token round trip not possible"}
+	for _, kid := range validator.keySet.kids() {
+		key := validator.keySet.keys[kid]
+		var check SelfTestCheck
+		if secret, ok := key.([]byte); ok {
+			token := jwt.NewWithClaims(validator.signingMethod, jwt.MapClaims(selfTestClaims()))
+			token.Header["kid"] = kid
+			token.Header["typ"] = c.selfTestTokenType()
+			signed, err := token.SignedString(secret)
+			if err != nil {
+				return SelfTestCheck{Check: "round_trip", Status: SelfTestFail, Detail: fmt.Sprintf("kid %s: signing failed: %v", kid, err)}
+			}
+			check = c.selfTestVerify(ctx, signed)
+		} else {
+			check = c.selfTestStaticKey(ctx, "", algorithmValidator{signingKey: key, signingMethod: validator.signingMethod})
+		}
+		if check.Status == SelfTestFail {
+			check.Detail = fmt.Sprintf("kid %s: %s", kid, check.Detail)
+			return check
+		}
+		if check.Status == SelfTestPass {
+			result = check
+		}
+	}
+	return result
+}
+
+// selfTestVerify verifies a minted token through the algorithm routing path
+func (c *Config) selfTestVerify(ctx context.Context, token string) SelfTestCheck {
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		return validateAlgorithm(ctx, t, c)
+	}, jwt.WithoutClaimsValidation())
+	if err != nil || !parsed.Valid {
+		return SelfTestCheck{Check: "round_trip", Status: SelfTestFail, Detail: fmt.Sprintf("verification failed: %v", err)}
+	}
+	return SelfTestCheck{Check: "round_trip", Status: SelfTestPass}
+}
+
+// selfTestTokenType returns the typ header of minted tokens: the first type
+// accepted under WithTokenTypes, or JWT
+func (c *Config) selfTestTokenType() string {
+	if len(c.tokenTypes) > 0 {
+		return c.tokenTypes[0]
+	}
+	return TokenTypeJWT
+}
+
+// selfTestClaims returns claims for a short-lived throwaway token
+func selfTestClaims() map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"sub": "jwtauth-selftest",
+		"iat": now.Unix(),
+		"exp": now.Add(time.Minute).Unix(),
+	}
+}
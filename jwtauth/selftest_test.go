@@ -0,0 +1,98 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// signingProvider is a KeyProvider that can also mint tokens
+type signingProvider struct {
+	Signer
+}
+
+func (p signingProvider) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	return p.Public(), nil
+}
+
+// TestSelfTest tests self-test checks for each kind of configured key
+func TestSelfTest(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, _ := NewCryptoSigner("ES256", "", ecKey)
+
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithRS256(&mustGenerateRSAKey().PublicKey),
+		WithKeyProvider("ES256", signingProvider{signer}),
+		WithKeyProvider("PS256", KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+			return nil, errors.New("jwks endpoint unreachable")
+		})),
+		WithAudience("api"), // Policy checks must not affect the self-test
+	)
+
+	report := cfg.SelfTest(context.Background())
+
+	want := map[string]SelfTestStatus{
+		"ES256": SelfTestPass,
+		"HS256": SelfTestPass,
+		"PS256": SelfTestFail,
+		"RS256": SelfTestSkipped,
+	}
+	if len(report.Checks) != len(want) {
+		t.Fatalf("Expected %d checks, got %d", len(want), len(report.Checks))
+	}
+	for _, check := range report.Checks {
+		if check.Status != want[check.Algorithm] {
+			t.Errorf("%s: expected %s, got %s (%s)", check.Algorithm, want[check.Algorithm], check.Status, check.Detail)
+		}
+	}
+
+	if report.OK() {
+		t.Error("Expected report to fail with an unreachable provider")
+	}
+	if failures := report.Failures(); len(failures) != 1 || failures[0].Algorithm != "PS256" {
+		t.Errorf("Expected single PS256 failure, got %+v", failures)
+	}
+}
+
+// TestSelfTestTokenTypes tests that minted tokens carry an accepted typ
+func TestSelfTestTokenTypes(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, _ := NewCryptoSigner("ES256", "", ecKey)
+
+	for _, opt := range []ConfigOption{WithTokenTypes(TokenTypeAccessToken), WithAccessTokenProfile()} {
+		cfg := mustCreateConfig(
+			WithHS256(secret),
+			WithKeyProvider("ES256", signingProvider{signer}),
+			WithKeySet("HS384", KeySet{Keys: map[string]interface{}{"k1": append(secret, secret...)}}),
+			WithAudience("api"),
+			opt,
+		)
+		report := cfg.SelfTest(context.Background())
+		if !report.OK() {
+			t.Errorf("Expected self-test to pass with token types %q, got %+v", cfg.tokenTypes, report.Failures())
+		}
+	}
+}
+
+// TestSelfTestDetectsWrongKey tests that a provider signing with a key it does not serve fails
+func TestSelfTestDetectsWrongKey(t *testing.T) {
+	signingKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, _ := NewCryptoSigner("ES256", "", signingKey)
+
+	provider := struct {
+		Signer
+		KeyProvider
+	}{signer, StaticKeyProvider(&otherKey.PublicKey)}
+
+	report := mustCreateConfig(WithKeyProvider("ES256", provider)).SelfTest(context.Background())
+	if report.OK() {
+		t.Error("Expected mismatched signing and verification keys to fail the self-test")
+	}
+}
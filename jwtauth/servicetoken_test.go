@@ -0,0 +1,112 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestJWTAuthService tests user and service tokens validated with separate configs
+func TestJWTAuthService(t *testing.T) {
+	userSecret := []byte("user-secret-key-min-32-bytes-long!!!")
+	serviceSecret := []byte("service-secret-key-min-32-bytes-long!")
+	userCfg := mustCreateConfig(WithHS256(userSecret))
+	serviceCfg := mustCreateConfig(WithHS256(serviceSecret), WithAudience("gateway"))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JWTAuth(userCfg), JWTAuthService(serviceCfg))
+	router.GET("/protected", func(c *gin.Context) {
+		user := MustGetClaims(c.Request.Context())
+		service, ok := GetServiceClaims(c.Request.Context())
+		if !ok {
+			t.Error("Expected service claims in context")
+			return
+		}
+		c.String(http.StatusOK, user.Subject+" via "+service.Subject)
+	})
+
+	exp := time.Now().Add(time.Hour).Unix()
+	user := mustSignHS256(userSecret, jwt.MapClaims{"sub": "alice", "exp": exp})
+	service := mustSignHS256(serviceSecret, jwt.MapClaims{"sub": "billing", "aud": "gateway", "exp": exp})
+	wrongAud := mustSignHS256(serviceSecret, jwt.MapClaims{"sub": "billing", "aud": "other", "exp": exp})
+
+	tests := []struct {
+		name       string
+		user       string
+		service    string
+		wantStatus int
+		wantBody   string
+	}{
+		{"both tokens", "Bearer " + user, service, http.StatusOK, "alice via billing"},
+		{"service token as bearer", "Bearer " + user, "Bearer " + service, http.StatusOK, "alice via billing"},
+		{"missing service token", "Bearer " + user, "", http.StatusUnauthorized, "MISSING_TOKEN"},
+		{"user token as service token", "Bearer " + user, user, http.StatusUnauthorized, "INVALID_SIGNATURE"},
+		{"service token wrong audience", "Bearer " + user, wrongAud, http.StatusUnauthorized, "INVALID_AUDIENCE"},
+		{"service token as user token", "Bearer " + service, service, http.StatusUnauthorized, "INVALID_SIGNATURE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("Authorization", tt.user)
+			if tt.service != "" {
+				req.Header.Set(ServiceTokenHeader, tt.service)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("Expected %d %s, got %d %s", tt.wantStatus, tt.wantBody, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+// TestServiceUnaryServerInterceptor tests service tokens in gRPC metadata
+func TestServiceUnaryServerInterceptor(t *testing.T) {
+	userSecret := []byte("user-secret-key-min-32-bytes-long!!!")
+	serviceSecret := []byte("service-secret-key-min-32-bytes-long!")
+	userCfg := mustCreateConfig(WithHS256(userSecret))
+	serviceCfg := mustCreateConfig(WithHS256(serviceSecret), WithTokenHeader("x-caller-token"))
+
+	var gotService string
+	capture := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if claims, ok := GetServiceClaims(ctx); ok {
+			gotService = claims.Subject
+		}
+		return handler(ctx, req)
+	}
+	conn := startTestGRPCServer(t, grpc.ChainUnaryInterceptor(
+		UnaryServerInterceptor(userCfg),
+		ServiceUnaryServerInterceptor(serviceCfg),
+		capture,
+	))
+
+	exp := time.Now().Add(time.Hour).Unix()
+	user := mustSignHS256(userSecret, jwt.MapClaims{"sub": "alice", "exp": exp})
+	service := mustSignHS256(serviceSecret, jwt.MapClaims{"sub": "billing", "exp": exp})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+user, "x-caller-token", service)
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Expected both tokens to validate, got %v", err)
+	}
+	if gotService != "billing" {
+		t.Errorf("Expected service principal billing, got %q", gotService)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+user)
+	_, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unauthenticated || status.Convert(err).Message() != "MISSING_TOKEN" {
+		t.Errorf("Expected MISSING_TOKEN without service token, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// drainPollInterval is how often Close checks for in-flight validations
+const drainPollInterval = 10 * time.Millisecond
+
+// lifecycle tracks in-flight validations and shutdown of a Config. It is
+// shared with the canary configuration.
+type lifecycle struct {
+	inFlight atomic.Int64
+	closing  atomic.Bool
+}
+
+// track counts a validation as in flight until the returned func is called
+func (l *lifecycle) track() func() {
+	if l == nil {
+		return func() {}
+	}
+	l.inFlight.Add(1)
+	return func() { l.inFlight.Add(-1) }
+}
+
+// InFlight returns the number of validations currently running under the
+// Config, across HTTP, gRPC, message and ParseToken callers
+func (c *Config) InFlight() int64 {
+	if c.anyOf != nil {
+		var n int64
+		for _, member := range c.anyOf {
+			n += member.InFlight()
+		}
+		return n
+	}
+	if c.lifecycle == nil {
+		return 0
+	}
+	return c.lifecycle.inFlight.Load()
+}
+
+// ShuttingDown reports whether Close has been called
+func (c *Config) ShuttingDown() bool {
+	return c.lifecycle != nil && c.lifecycle.closing.Load()
+}
+
+// Close drains the Config for a graceful shutdown. Call it after the server
+// has stopped accepting connections (http.Server.Shutdown,
+// grpc.Server.GracefulStop):
+//
+//  1. HealthHandler starts reporting 503 so load balancers stop routing here.
+//  2. Close waits until in-flight validations finish, or ctx is done.
+//  3. Background key refreshes (WithJWKSBackgroundRefresh) stop, and key
+//     providers implementing io.Closer are closed.
+//  4. An AsyncHandler behind the logger is flushed, so the security events
+//     of drained requests are written.
+//
+// Tokens are still validated after Close, with the keys loaded last. Close
+// is safe to call more than once, e.g. again after a drain timed out; key
+// providers are closed only the first time. For an AnyOf composite it
+// closes every member. The error joins a drain timeout (wrapping
+// ctx.Err()) with the errors of closing providers and flushing logs.
+func (c *Config) Close(ctx context.Context) error {
+	if c.anyOf != nil {
+		var errs []error
+		c.lifecycle.closing.Store(true)
+		for _, member := range c.anyOf {
+			errs = append(errs, member.Close(ctx))
+		}
+		return errors.Join(errs...)
+	}
+	if c.lifecycle == nil {
+		return NewValidationError(ErrConfigError, "configuration is required (use NewConfig)", nil)
+	}
+	first := c.lifecycle.closing.CompareAndSwap(false, true)
+
+	errs := []error{c.drain(ctx)}
+	if first {
+		errs = append(errs, c.closeKeyProviders()...)
+	}
+	if logger := c.Logger(); logger != nil {
+		if async, ok := logger.Handler().(*AsyncHandler); ok {
+			if err := async.Flush(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("flushing logs: %w", err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeKeyProviders closes the key providers implementing io.Closer
+func (c *Config) closeKeyProviders() []error {
+	var errs []error
+	closed := map[KeyProvider]bool{}
+	for _, alg := range c.AvailableAlgorithms() {
+		provider := c.validators[alg].keyProvider
+		closer, ok := provider.(io.Closer)
+		if !ok || closed[provider] {
+			continue
+		}
+		closed[provider] = true
+		if err := closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing %s key provider: %w", alg, err))
+		}
+	}
+	return errs
+}
+
+// drain waits until no validation is in flight or ctx is done
+func (c *Config) drain(ctx context.Context) error {
+	if c.InFlight() == 0 {
+		return nil
+	}
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for c.InFlight() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("draining %d in-flight validations: %w", c.InFlight(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// healthStatus is the HealthHandler response
+type healthStatus struct {
+	Status   string `json:"status"` // "ok" or "shutting_down"
+	InFlight int64  `json:"in_flight"`
+}
+
+// HealthHandler returns an http.Handler for readiness probes. It responds
+// 200 {"status": "ok"} until Close is called and 503
+// {"status": "shutting_down"} afterwards, with the number of in-flight
+// validations. Do not use it for liveness probes, which would restart the
+// process while it drains.
+//
+//	mux.Handle("/readyz", jwtauth.HealthHandler(cfg))
+func HealthHandler(cfg *Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := healthStatus{Status: "ok", InFlight: cfg.InFlight()}
+		code := http.StatusOK
+		if cfg.ShuttingDown() {
+			status.Status = "shutting_down"
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(status)
+	})
+}
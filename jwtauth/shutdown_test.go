@@ -0,0 +1,106 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestConfigClose tests draining in-flight validations and the health
+// handler during shutdown
+func TestConfigClose(t *testing.T) {
+	key := mustGenerateECKey()
+	unblock := make(chan struct{})
+	provider := KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		<-unblock
+		return &key.PublicKey, nil
+	})
+	var logs bytes.Buffer
+	async := NewAsyncHandler(slog.NewJSONHandler(&logs, nil), 16)
+	cfg := mustCreateConfig(WithKeyProvider("ES256", provider), WithLogger(slog.New(async)))
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}).SignedString(key)
+
+	health := func() healthStatus {
+		t.Helper()
+		w := httptest.NewRecorder()
+		HealthHandler(cfg).ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+		var status healthStatus
+		if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+			t.Fatal(err)
+		}
+		if (w.Code == 503) != (status.Status == "shutting_down") {
+			t.Errorf("status %q returned with %d", status.Status, w.Code)
+		}
+		return status
+	}
+
+	validated := make(chan error, 1)
+	go func() {
+		_, _, err := authenticateToken(context.Background(), token, "req-1", cfg)
+		cfg.Logger().Info("validated")
+		validated <- err
+	}()
+	for cfg.InFlight() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if status := health(); status.Status != "ok" || status.InFlight != 1 {
+		t.Errorf("expected ok with one validation in flight, got %+v", status)
+	}
+
+	// Draining times out while the validation is blocked
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := cfg.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected drain timeout, got %v", err)
+	}
+	if status := health(); status.Status != "shutting_down" {
+		t.Errorf("expected shutting_down after Close, got %+v", status)
+	}
+
+	close(unblock)
+	if err := <-validated; err != nil {
+		t.Errorf("expected the in-flight validation to succeed, got %v", err)
+	}
+	if err := cfg.Close(context.Background()); err != nil {
+		t.Errorf("expected Close to drain, got %v", err)
+	}
+	if cfg.InFlight() != 0 {
+		t.Errorf("expected no validations in flight, got %d", cfg.InFlight())
+	}
+	if !strings.Contains(logs.String(), "validated") {
+		t.Error("expected Close to flush the async log handler")
+	}
+
+	// Tokens are still validated after Close
+	if _, err := ParseToken(context.Background(), token, cfg); err != nil {
+		t.Errorf("expected validation after Close, got %v", err)
+	}
+}
+
+// TestConfigCloseStopsJWKSRefresh tests that Close ends background refresh
+func TestConfigCloseStopsJWKSRefresh(t *testing.T) {
+	key := mustGenerateECKey()
+	url, fetches := serveJWKS(t, JSONWebKey{KeyID: "k1", Algorithm: "ES256", Key: &key.PublicKey})
+	cfg := mustCreateConfig(WithJWKSURL(url, WithJWKSAlgorithms("ES256"), WithJWKSBackgroundRefresh(context.Background(), 5*time.Millisecond)))
+	for fetches.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := cfg.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond) // Let a refresh already under way finish
+	stopped := fetches.Load()
+	time.Sleep(30 * time.Millisecond)
+	if fetches.Load() != stopped {
+		t.Errorf("expected no refreshes after Close, got %d more", fetches.Load()-stopped)
+	}
+}
@@ -0,0 +1,169 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Signer produces JWS signatures with a key that may never leave its key
+// store (HSM, cloud KMS). Implementations must be safe for concurrent use.
+type Signer interface {
+	// Algorithm returns the JWS algorithm name (e.g. "RS256", "ES256")
+	Algorithm() string
+	// KeyID returns the "kid" header value for produced tokens (may be empty)
+	KeyID() string
+	// Public returns the public half of the signing key
+	Public() crypto.PublicKey
+	// Sign returns the JWS signature over signingInput ("<header>.<payload>")
+	Sign(ctx context.Context, signingInput []byte) ([]byte, error)
+}
+
+// cryptoSigner adapts a crypto.Signer to the Signer interface
+type cryptoSigner struct {
+	alg    string
+	kid    string
+	signer crypto.Signer
+	hash   crypto.Hash
+	pss    bool
+}
+
+// NewCryptoSigner wraps a crypto.Signer (e.g. an HSM or KMS-backed key) as a
+// Signer for the given JWS algorithm. Supported algorithms are RS256/384/512,
+// PS256/384/512, ES256/384/512 and EdDSA.
+func NewCryptoSigner(alg, kid string, signer crypto.Signer) (Signer, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("signer cannot be nil")
+	}
+
+	s := &cryptoSigner{alg: alg, kid: kid, signer: signer}
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		s.hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		s.hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		s.hash = crypto.SHA512
+	case "EdDSA":
+		s.hash = 0
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %s", alg)
+	}
+	s.pss = alg[0] == 'P'
+
+	// Ensure the key type matches the algorithm family
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		if alg[0] != 'R' && alg[0] != 'P' {
+			return nil, fmt.Errorf("RSA key cannot be used with %s", alg)
+		}
+	case *ecdsa.PublicKey:
+		if alg[0] != 'E' || alg == "EdDSA" {
+			return nil, fmt.Errorf("ECDSA key cannot be used with %s", alg)
+		}
+	case ed25519.PublicKey:
+		if alg != "EdDSA" {
+			return nil, fmt.Errorf("Ed25519 key cannot be used with %s", alg)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", signer.Public())
+	}
+
+	return s, nil
+}
+
+func (s *cryptoSigner) Algorithm() string        { return s.alg }
+func (s *cryptoSigner) KeyID() string            { return s.kid }
+func (s *cryptoSigner) Public() crypto.PublicKey { return s.signer.Public() }
+
+// Sign hashes signingInput and signs it with the wrapped crypto.Signer
+func (s *cryptoSigner) Sign(ctx context.Context, signingInput []byte) ([]byte, error) {
+	if s.hash == 0 {
+		// Ed25519 signs the message directly
+		return s.signer.Sign(rand.Reader, signingInput, crypto.Hash(0))
+	}
+
+	h := s.hash.New()
+	h.Write(signingInput)
+	digest := h.Sum(nil)
+
+	var opts crypto.SignerOpts = s.hash
+	if s.pss {
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: s.hash}
+	}
+
+	sig, err := s.signer.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if pub, ok := s.signer.Public().(*ecdsa.PublicKey); ok {
+		return ECDSASignatureToJWS(sig, pub.Curve.Params().BitSize)
+	}
+	return sig, nil
+}
+
+// ECDSASignatureToJWS converts an ASN.1 DER encoded ECDSA signature (as
+// returned by crypto.Signer, PKCS#11 and cloud KMS APIs) into the fixed-size
+// r||s form required by JWS
+func ECDSASignatureToJWS(der []byte, curveBits int) ([]byte, error) {
+	var parsed struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse ECDSA signature: %w", err)
+	}
+
+	size := (curveBits + 7) / 8
+	if parsed.R.Sign() <= 0 || parsed.S.Sign() <= 0 || len(parsed.R.Bytes()) > size || len(parsed.S.Bytes()) > size {
+		return nil, fmt.Errorf("invalid ECDSA signature")
+	}
+
+	out := make([]byte, 2*size)
+	parsed.R.FillBytes(out[:size])
+	parsed.S.FillBytes(out[size:])
+	return out, nil
+}
+
+// SignToken mints a compact JWS for claims using signer
+func SignToken(ctx context.Context, signer Signer, claims map[string]interface{}) (string, error) {
+	return signTokenType(ctx, signer, TokenTypeJWT, claims)
+}
+
+// signTokenType is SignToken with the given typ header
+func signTokenType(ctx context.Context, signer Signer, typ string, claims map[string]interface{}) (string, error) {
+	header := map[string]interface{}{
+		"alg": signer.Algorithm(),
+		"typ": typ,
+	}
+	if kid := signer.KeyID(); kid != "" {
+		header["kid"] = kid
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode header: %w", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	sig, err := signer.Sign(ctx, []byte(signingInput))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
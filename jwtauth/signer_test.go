@@ -0,0 +1,76 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestCryptoSignerRoundTrip tests that tokens minted via NewCryptoSigner verify with the jwt library
+func TestCryptoSignerRoundTrip(t *testing.T) {
+	rsaKey := mustGenerateRSAKey()
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ec384Key, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		alg string
+		key crypto.Signer
+	}{
+		{"RS256", rsaKey},
+		{"RS512", rsaKey},
+		{"PS256", rsaKey},
+		{"ES256", ecKey},
+		{"ES384", ec384Key},
+		{"EdDSA", edKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			signer, err := NewCryptoSigner(tt.alg, "key-1", tt.key)
+			if err != nil {
+				t.Fatalf("NewCryptoSigner failed: %v", err)
+			}
+
+			tokenString, err := SignToken(context.Background(), signer, map[string]interface{}{
+				"sub": "user123",
+				"exp": time.Now().Add(time.Hour).Unix(),
+			})
+			if err != nil {
+				t.Fatalf("SignToken failed: %v", err)
+			}
+
+			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+				return tt.key.Public(), nil
+			})
+			if err != nil || !token.Valid {
+				t.Fatalf("Expected token to verify, got %v", err)
+			}
+			if token.Header["kid"] != "key-1" {
+				t.Errorf("Expected kid header key-1, got %v", token.Header["kid"])
+			}
+		})
+	}
+}
+
+// TestCryptoSignerKeyMismatch tests that key type and algorithm family must agree
+func TestCryptoSignerKeyMismatch(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if _, err := NewCryptoSigner("RS256", "", ecKey); err == nil {
+		t.Error("Expected error for ECDSA key with RS256")
+	}
+	if _, err := NewCryptoSigner("HS256", "", ecKey); err == nil {
+		t.Error("Expected error for HS256 signer")
+	}
+	if _, err := NewCryptoSigner("ES256", "", nil); err == nil {
+		t.Error("Expected error for nil signer")
+	}
+}
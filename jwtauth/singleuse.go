@@ -0,0 +1,147 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JTIStore records the jti of tokens consumed by RequireSingleUse.
+// Implementations must be safe for concurrent use, and Consume must be
+// atomic across every replica sharing the store.
+type JTIStore interface {
+	// Consume marks jti as used until the given time (zero means
+	// indefinitely) and reports whether this call was the first use
+	Consume(ctx context.Context, jti string, until time.Time) (bool, error)
+}
+
+// RequireSingleUse returns middleware accepting each token once, for
+// sensitive routes such as password resets or payment confirmations. It
+// must run after JWTAuth:
+//
+//	store := jwtauth.NewMemoryJTIStore()
+//	router.POST("/password/reset", jwtauth.RequireSingleUse(store), resetPassword)
+//
+// The token's jti is consumed atomically before the handler runs, so
+// concurrent replays are rejected even if the first request later fails.
+// Reused tokens are rejected with TOKEN_REPLAYED and tokens without a jti
+// claim as MALFORMED. If the store fails, requests are rejected with
+// REVOCATION_UNAVAILABLE. Routes sharing a store share consumption: a
+// token used on one of them is rejected on the others.
+func RequireSingleUse(store JTIStore) gin.HandlerFunc {
+	if store == nil {
+		panic("jwtauth: RequireSingleUse requires a JTIStore")
+	}
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		claims, ok := GetClaims(ctx)
+		if !ok {
+			abortAfterAuth(c, NewValidationError(ErrMissingToken, "RequireSingleUse must run after JWTAuth", nil))
+			return
+		}
+		if claims.JWTID == "" {
+			abortAfterAuth(c, NewValidationError(ErrMalformed, "token has no jti claim and cannot be used once", nil))
+			return
+		}
+
+		// Keep the jti for as long as the middleware accepts the token
+		until := claims.ExpiresAt
+		if cfg, ok := ctx.Value(configContextKey).(*Config); ok && !until.IsZero() {
+			until = until.Add(cfg.ClockSkewLeeway())
+		}
+		first, err := store.Consume(ctx, claims.JWTID, until)
+		if err != nil {
+			abortAfterAuth(c, NewValidationError(ErrRevocationUnavailable, "single-use status unavailable", err))
+			return
+		}
+		if !first {
+			abortAfterAuth(c, NewValidationError(ErrTokenReplayed, "token has already been used", nil))
+			return
+		}
+		c.Next()
+	}
+}
+
+// MemoryJTIStore is an in-process JTIStore. Consumed tokens are forgotten
+// on restart and not shared between replicas; use NewCacheJTIStore with a
+// shared cache in production.
+type MemoryJTIStore struct {
+	used     sync.Map // jti -> time.Time after which the entry can be dropped
+	consumed atomic.Uint64
+}
+
+// NewMemoryJTIStore returns an empty in-process JTIStore
+func NewMemoryJTIStore() *MemoryJTIStore {
+	return &MemoryJTIStore{}
+}
+
+// Consume implements JTIStore
+func (s *MemoryJTIStore) Consume(ctx context.Context, jti string, until time.Time) (bool, error) {
+	// Drop lapsed entries now and then to bound memory
+	if s.consumed.Add(1)%1024 == 0 {
+		s.sweep()
+	}
+	for {
+		v, loaded := s.used.LoadOrStore(jti, until)
+		if !loaded {
+			return true, nil
+		}
+		if prev := v.(time.Time); prev.IsZero() || time.Now().Before(prev) {
+			return false, nil
+		}
+		// The previous use has lapsed; take it over unless another
+		// request did first
+		if s.used.CompareAndSwap(jti, v, until) {
+			return true, nil
+		}
+	}
+}
+
+// sweep removes entries whose time has passed
+func (s *MemoryJTIStore) sweep() {
+	now := time.Now()
+	s.used.Range(func(key, v interface{}) bool {
+		if until := v.(time.Time); !until.IsZero() && now.After(until) {
+			s.used.CompareAndDelete(key, v)
+		}
+		return true
+	})
+}
+
+// atomicAdder is implemented by caches that can insert a key only if it is
+// absent, such as NewRedisCache
+type atomicAdder interface {
+	add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+}
+
+// CacheJTIStore is a JTIStore backed by a Cache. With a shared cache such
+// as NewRedisCache, each token can be used once across all replicas.
+type CacheJTIStore struct {
+	cache atomicAdder
+}
+
+// NewCacheJTIStore returns a JTIStore keeping consumed jtis in cache. The
+// cache must support atomic insertion; NewRedisCache does.
+func NewCacheJTIStore(cache Cache) (*CacheJTIStore, error) {
+	adder, ok := cache.(atomicAdder)
+	if !ok {
+		return nil, errors.New("cache does not support atomic insertion (use NewRedisCache or NewMemoryJTIStore)")
+	}
+	return &CacheJTIStore{cache: adder}, nil
+}
+
+// Consume implements JTIStore
+func (s *CacheJTIStore) Consume(ctx context.Context, jti string, until time.Time) (bool, error) {
+	var ttl time.Duration
+	if !until.IsZero() {
+		ttl = time.Until(until)
+		if ttl <= 0 {
+			ttl = time.Millisecond
+		}
+	}
+	return s.cache.add(ctx, "jwtauth:jti:"+jti, []byte{1}, ttl)
+}
@@ -0,0 +1,113 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jtiStoreFunc adapts a function to the JTIStore interface
+type jtiStoreFunc func(ctx context.Context, jti string, until time.Time) (bool, error)
+
+func (f jtiStoreFunc) Consume(ctx context.Context, jti string, until time.Time) (bool, error) {
+	return f(ctx, jti, until)
+}
+
+// singleUseRouter protects POST /reset with RequireSingleUse and leaves
+// GET /profile reusable
+func singleUseRouter(cfg *Config, store JTIStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(JWTAuth(cfg))
+	router.POST("/reset", RequireSingleUse(store), func(c *gin.Context) { c.Status(200) })
+	router.GET("/profile", func(c *gin.Context) { c.Status(200) })
+	return router
+}
+
+func serveWithToken(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestRequireSingleUse tests that a token is accepted once on protected routes
+func TestRequireSingleUse(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	router := singleUseRouter(mustCreateConfig(WithHS256(secret)), NewMemoryJTIStore())
+	exp := time.Now().Add(time.Hour).Unix()
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "jti": "reset-1", "exp": exp})
+
+	if w := serveWithToken(router, "POST", "/reset", token); w.Code != 200 {
+		t.Fatalf("expected first use to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	w := serveWithToken(router, "POST", "/reset", token)
+	if w.Code != 401 || !contains(w.Body.String(), string(ErrTokenReplayed)) {
+		t.Errorf("expected TOKEN_REPLAYED on reuse, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serveWithToken(router, "GET", "/profile", token); w.Code != 200 {
+		t.Errorf("expected unprotected routes to accept the token again, got %d", w.Code)
+	}
+
+	noJTI := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "exp": exp})
+	if w := serveWithToken(router, "POST", "/reset", noJTI); w.Code != 401 || !contains(w.Body.String(), string(ErrMalformed)) {
+		t.Errorf("expected MALFORMED without jti, got %d: %s", w.Code, w.Body.String())
+	}
+
+	failing := singleUseRouter(mustCreateConfig(WithHS256(secret)), jtiStoreFunc(func(ctx context.Context, jti string, until time.Time) (bool, error) {
+		return false, errors.New("connection refused")
+	}))
+	if w := serveWithToken(failing, "POST", "/reset", token); !contains(w.Body.String(), string(ErrRevocationUnavailable)) {
+		t.Errorf("expected REVOCATION_UNAVAILABLE when the store fails, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestRequireSingleUseConcurrent tests that concurrent replays are rejected
+func TestRequireSingleUseConcurrent(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	router := singleUseRouter(mustCreateConfig(WithHS256(secret)), NewMemoryJTIStore())
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user-1", "jti": "pay-1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if serveWithToken(router, "POST", "/reset", token).Code == 200 {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted.Load() != 1 {
+		t.Errorf("expected exactly one accepted request, got %d", accepted.Load())
+	}
+}
+
+// TestMemoryJTIStoreExpiry tests that lapsed entries can be consumed again
+func TestMemoryJTIStoreExpiry(t *testing.T) {
+	store := NewMemoryJTIStore()
+	ctx := context.Background()
+	if first, _ := store.Consume(ctx, "a", time.Now().Add(-time.Second)); !first {
+		t.Fatal("expected first use")
+	}
+	if first, _ := store.Consume(ctx, "a", time.Now().Add(time.Hour)); !first {
+		t.Error("expected a lapsed entry to be consumable again")
+	}
+	if first, _ := store.Consume(ctx, "a", time.Now().Add(time.Hour)); first {
+		t.Error("expected the renewed entry to reject reuse")
+	}
+
+	if _, err := NewCacheJTIStore(NewMemoryCache(10)); err == nil {
+		t.Error("expected error for a cache without atomic insertion")
+	}
+}
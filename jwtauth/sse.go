@@ -0,0 +1,122 @@
+package jwtauth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// sseOptions holds settings for JWTAuthSSE
+type sseOptions struct {
+	queryParam         string
+	revalidateInterval time.Duration
+}
+
+// SSEOption configures JWTAuthSSE
+type SSEOption func(*sseOptions)
+
+// WithSSEQueryParam sets the query parameter carrying the token (default "access_token")
+func WithSSEQueryParam(name string) SSEOption {
+	return func(o *sseOptions) {
+		o.queryParam = name
+	}
+}
+
+// WithSSERevalidation re-checks the token against the blocklist every
+// interval while the stream is open, terminating it once revoked
+func WithSSERevalidation(interval time.Duration) SSEOption {
+	return func(o *sseOptions) {
+		o.revalidateInterval = interval
+	}
+}
+
+// JWTAuthSSE returns a Gin middleware for Server-Sent Events routes.
+//
+// Browsers' EventSource cannot set headers, so in addition to the
+// Authorization header and cookie, the token is accepted from a query
+// parameter. Mount it only on SSE routes: query strings end up in access
+// logs and browser history, so prefer short-lived, narrowly scoped tokens.
+//
+// The request context is cancelled when the token expires (and, with
+// WithSSERevalidation, when it is revoked), so handlers streaming until
+// ctx.Done() terminate on time. StreamTerminationReason reports why.
+func JWTAuthSSE(cfg *Config, opts ...SSEOption) gin.HandlerFunc {
+	options := sseOptions{queryParam: "access_token"}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(c *gin.Context) {
+		if isPreflight(c, cfg) {
+			abortPreflight(c, cfg)
+			return
+		}
+
+		startTime := time.Now()
+
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		clientIP := cfg.clientIP(c.Request.RemoteAddr, c.Request.Header.Values("X-Forwarded-For"))
+		reqCtx := WithClientIP(c.Request.Context(), clientIP)
+		reqCtx = withDeviceFingerprint(reqCtx, deviceFingerprintFromRequest(c.Request, cfg))
+
+		// Extract token from header/cookie, falling back to the query parameter
+		tokens, err := extractTokens(c.Request, cfg, requestID)
+		if err != nil && !isAmbiguous(err) {
+			if queryToken := strings.TrimSpace(c.Query(options.queryParam)); queryToken != "" {
+				tokens, err = []string{queryToken}, nil
+			}
+		}
+		if err != nil {
+			logAuthFailure(cfg, requestID, clientIP, "", err, time.Since(startTime))
+			abortWithError(c, cfg, err)
+			return
+		}
+
+		// Validate token and apply revocation and rate limit policies
+		token, claims, tenant, err := authenticateCandidates(reqCtx, tokens, requestID, cfg)
+		if err != nil {
+			logAuthFailure(cfg, requestID, clientIP, token, err, time.Since(startTime))
+			abortWithError(c, cfg, err)
+			return
+		}
+
+		// Enforce per-route authorization
+		if err := cfg.checkRouteRequirements(c.Request, claims); err != nil {
+			logAuthFailure(cfg, requestID, clientIP, token, err, time.Since(startTime))
+			abortWithError(c, cfg, err)
+			return
+		}
+		if err := cfg.checkHTTPPolicy(c.Request.WithContext(reqCtx), claims, clientIP, tenant); err != nil {
+			logAuthFailure(cfg, requestID, clientIP, token, err, time.Since(startTime))
+			abortWithError(c, cfg, err)
+			return
+		}
+
+		// Inject claims and request ID into context
+		ctx := WithClaims(reqCtx, claims)
+		ctx = WithToken(ctx, token)
+		ctx = WithRequestID(ctx, requestID)
+		ctx = withConfig(ctx, cfg)
+		if tenant != "" {
+			ctx = WithTenant(ctx, tenant)
+		}
+
+		// Terminate the stream when the token stops being valid
+		ctx, cancel := watchStream(ctx, cfg, claims, options.revalidateInterval, func(reason error) {
+			logAuthFailure(cfg, requestID, clientIP, token, reason, time.Since(startTime))
+		})
+		defer cancel(nil)
+		c.Request = c.Request.WithContext(ctx)
+		setExpiryHeaders(c.Writer.Header(), cfg, claims)
+
+		// Log successful authentication
+		logAuthSuccess(cfg, requestID, clientIP, claims, token, time.Since(startTime))
+
+		c.Next()
+	}
+}
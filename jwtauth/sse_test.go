@@ -0,0 +1,100 @@
+package jwtauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// createSSERouter returns a router whose /events handler blocks until the
+// stream is terminated and records the reason
+func createSSERouter(cfg *Config, reasons chan<- error, opts ...SSEOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events", JWTAuthSSE(cfg, opts...), func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+			reasons <- StreamTerminationReason(c.Request.Context())
+		case <-time.After(5 * time.Second):
+			reasons <- nil
+		}
+	})
+	return router
+}
+
+// TestJWTAuthSSEQueryToken tests that SSE routes accept the token from the query string
+func TestJWTAuthSSEQueryToken(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret))
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/events", JWTAuthSSE(cfg, WithSSEQueryParam("token")), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?token="+token, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected query token to be accepted, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?access_token="+token, nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected default parameter to be ignored when renamed, got %d", w.Code)
+	}
+
+	// Regular routes never read tokens from the query string
+	w = httptest.NewRecorder()
+	createTestRouter(cfg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/protected?access_token="+token, nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected JWTAuth to ignore query tokens, got %d", w.Code)
+	}
+}
+
+// TestJWTAuthSSEExpiry tests that streams are terminated when the token expires
+func TestJWTAuthSSEExpiry(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithClockSkew(0))
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Second).Unix()})
+
+	reasons := make(chan error, 1)
+	router := createSSERouter(cfg, reasons)
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if reason := <-reasons; getErrorCode(reason) != string(ErrExpired) {
+		t.Errorf("Expected stream to terminate with EXPIRED, got %v", reason)
+	}
+}
+
+// TestJWTAuthSSERevalidation tests that revoked tokens terminate open streams
+func TestJWTAuthSSERevalidation(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	blocklist := NewMemoryBlocklist()
+	cfg := mustCreateConfig(WithHS256(secret), WithBlocklist(blocklist))
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "jti": "stream-1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	reasons := make(chan error, 1)
+	router := createSSERouter(cfg, reasons, WithSSERevalidation(10*time.Millisecond))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		blocklist.Revoke("stream-1", time.Time{})
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, "/events?access_token="+token, nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if reason := <-reasons; getErrorCode(reason) != string(ErrTokenRevoked) {
+		t.Errorf("Expected stream to terminate with TOKEN_REVOKED, got %v", reason)
+	}
+}
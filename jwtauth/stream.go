@@ -0,0 +1,107 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// StreamExpiryFunc is called when a long-lived request or stream outlives
+// its token. reason is the validation error (EXPIRED, TOKEN_REVOKED, ...).
+// Use it to close connections the request context does not control, such as
+// hijacked WebSocket connections.
+type StreamExpiryFunc func(ctx context.Context, claims *Claims, reason error)
+
+// WithStreamExpiryEnforcement terminates long-lived requests and streams when
+// the token that authenticated them expires. The request context is cancelled
+// at claims.ExpiresAt (plus clock skew) for Gin and gRPC handlers, and gRPC
+// streams are closed with UNAUTHENTICATED when their handler returns. Tokens
+// without exp are not affected.
+func WithStreamExpiryEnforcement() ConfigOption {
+	return func(c *Config) error {
+		c.streamExpiry = true
+		return nil
+	}
+}
+
+// WithStreamExpiryHandler enables stream expiry enforcement and calls fn when
+// a stream is terminated, e.g. to close a WebSocket connection
+func WithStreamExpiryHandler(fn StreamExpiryFunc) ConfigOption {
+	return func(c *Config) error {
+		if fn == nil {
+			return errors.New("stream expiry handler cannot be nil")
+		}
+		c.streamExpiry = true
+		c.streamExpiryFunc = fn
+		return nil
+	}
+}
+
+// watchStream returns a context that is cancelled when the token expires or,
+// if interval is positive, when a periodic revocation check fails. The
+// returned cancel func must be called once the request completes.
+func watchStream(ctx context.Context, cfg *Config, claims *Claims, interval time.Duration, onTerminate func(error)) (context.Context, context.CancelCauseFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	var expired <-chan time.Time
+	var timer *time.Timer
+	if !claims.ExpiresAt.IsZero() {
+		timer = time.NewTimer(time.Until(claims.ExpiresAt.Add(cfg.ClockSkewLeeway())))
+		expired = timer.C
+	}
+
+	var recheck <-chan time.Time
+	var ticker *time.Ticker
+	if interval > 0 && cfg.blocklist != nil {
+		ticker = time.NewTicker(interval)
+		recheck = ticker.C
+	}
+
+	if expired == nil && recheck == nil {
+		return ctx, cancel
+	}
+
+	terminate := func(reason error) {
+		onTerminate(reason)
+		cancel(reason)
+		if cfg.streamExpiryFunc != nil {
+			cfg.streamExpiryFunc(ctx, claims, reason)
+		}
+	}
+
+	go func() {
+		if timer != nil {
+			defer timer.Stop()
+		}
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-expired:
+				terminate(NewValidationError(ErrExpired, "token expired during stream", nil))
+				return
+			case <-recheck:
+				if err := checkRevocation(ctx, cfg, claims); err != nil {
+					terminate(err)
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// StreamTerminationReason returns the validation error that terminated a
+// stream (EXPIRED, TOKEN_REVOKED, ...), or nil if the context was not
+// cancelled by stream expiry enforcement
+func StreamTerminationReason(ctx context.Context) error {
+	var valErr *ValidationError
+	if errors.As(context.Cause(ctx), &valErr) {
+		return valErr
+	}
+	return nil
+}
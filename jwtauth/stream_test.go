@@ -0,0 +1,147 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestStreamServerInterceptor tests authentication of gRPC streams
+func TestStreamServerInterceptor(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret))
+	conn := startTestGRPCServer(t, grpc.StreamInterceptor(StreamServerInterceptor(cfg)))
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, _ := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if _, err := stream.Recv(); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected UNAUTHENTICATED without token, got %v", err)
+	}
+
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()})
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	stream, _ = client.Watch(authCtx, &healthpb.HealthCheckRequest{})
+	if _, err := stream.Recv(); err != nil {
+		t.Errorf("Expected authenticated stream, got %v", err)
+	}
+}
+
+// TestStreamExpiryEnforcementGRPC tests that open gRPC streams are closed at token expiry
+func TestStreamExpiryEnforcementGRPC(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithClockSkew(0), WithStreamExpiryEnforcement())
+	conn := startTestGRPCServer(t, grpc.StreamInterceptor(StreamServerInterceptor(cfg)))
+
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Second).Unix()})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+
+	// Watch sends the current status and then blocks until it changes
+	stream, _ := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{})
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Expected initial health status, got %v", err)
+	}
+
+	_, err := stream.Recv()
+	if status.Code(err) != codes.Unauthenticated || status.Convert(err).Message() != string(ErrExpired) {
+		t.Errorf("Expected stream to close with UNAUTHENTICATED/EXPIRED, got %v", err)
+	}
+}
+
+// expiryTestStream is a server stream whose sends always succeed
+type expiryTestStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *expiryTestStream) Context() context.Context { return s.ctx }
+
+func (s *expiryTestStream) SendMsg(m interface{}) error { return nil }
+
+// TestStreamExpiryWaitsForHandler tests that an expired stream's sends fail
+// and the interceptor returns only after the handler does
+func TestStreamExpiryWaitsForHandler(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithClockSkew(0), WithStreamExpiryEnforcement())
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Second).Unix()})
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+
+	// The handler ignores the context and sends until a send fails
+	var handlerErr error
+	returned := false
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		defer func() { returned = true }()
+		for {
+			if handlerErr = stream.SendMsg(nil); handlerErr != nil {
+				return handlerErr
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	err := StreamServerInterceptor(cfg)(nil, &expiryTestStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Watch"}, handler)
+	if !returned {
+		t.Fatal("expected the interceptor to wait for the handler")
+	}
+	if status.Code(handlerErr) != codes.Unauthenticated {
+		t.Errorf("expected SendMsg to fail with UNAUTHENTICATED, got %v", handlerErr)
+	}
+	if status.Code(err) != codes.Unauthenticated || status.Convert(err).Message() != string(ErrExpired) {
+		t.Errorf("expected UNAUTHENTICATED/EXPIRED, got %v", err)
+	}
+}
+
+// TestStreamExpiryHandler tests that long-lived HTTP requests are cancelled
+// at expiry and the handler is notified
+func TestStreamExpiryHandler(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	notified := make(chan error, 1)
+	cfg := mustCreateConfig(
+		WithHS256(secret),
+		WithClockSkew(0),
+		WithStreamExpiryHandler(func(ctx context.Context, claims *Claims, reason error) {
+			notified <- reason
+		}),
+	)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws", JWTAuth(cfg), func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	token := mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Second).Unix()})
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case reason := <-notified:
+		if getErrorCode(reason) != string(ErrExpired) {
+			t.Errorf("Expected EXPIRED, got %v", reason)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("Expected stream expiry handler to be called")
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithStreamExpiryHandler(nil)); err == nil {
+		t.Error("Expected nil handler to be rejected")
+	}
+}
@@ -0,0 +1,23 @@
+package jwtauth
+
+// WithStrictProfile enables the hardening options that reject tokens and
+// requests a lenient verifier would accept:
+//
+//   - WithEmbeddedKeyRejection: jwk, jku and x5u headers fail with EMBEDDED_KEY_HEADER
+//   - WithDuplicateHeaderPolicy(DuplicateHeaderStrict): several distinct
+//     Authorization values fail with AMBIGUOUS_TOKEN
+//
+// Options after it can relax individual settings.
+func WithStrictProfile() ConfigOption {
+	return func(c *Config) error {
+		for _, opt := range []ConfigOption{
+			WithEmbeddedKeyRejection(),
+			WithDuplicateHeaderPolicy(DuplicateHeaderStrict),
+		} {
+			if err := opt(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
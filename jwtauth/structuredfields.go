@@ -0,0 +1,285 @@
+package jwtauth
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sfToken is an RFC 8941 token (an unquoted identifier)
+type sfToken string
+
+// sfParam is a structured field parameter. Values are string, sfToken,
+// []byte, int64 or bool.
+type sfParam struct {
+	name  string
+	value interface{}
+}
+
+// sfItem is a bare item with its parameters
+type sfItem struct {
+	value  interface{}
+	params []sfParam
+}
+
+// sfMember is a dictionary member: an item, or an inner list when isList
+type sfMember struct {
+	name   string
+	isList bool
+	item   sfItem   // Set unless isList
+	list   []sfItem // Set when isList
+	params []sfParam
+	raw    string // The member value exactly as received
+}
+
+// param returns the named parameter value
+func (m sfMember) param(name string) (interface{}, bool) {
+	for _, p := range m.params {
+		if p.name == name {
+			return p.value, true
+		}
+	}
+	return nil, false
+}
+
+// sfParser parses the subset of RFC 8941 used by HTTP message signatures and
+// digests: dictionaries of items and inner lists. Decimals are not supported.
+type sfParser struct {
+	s string
+	i int
+}
+
+// parseSFDictionary parses a dictionary header value
+func parseSFDictionary(s string) ([]sfMember, error) {
+	p := &sfParser{s: s}
+	p.skipSpaces()
+
+	var members []sfMember
+	for p.i < len(p.s) {
+		name, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+
+		member := sfMember{name: name}
+		start := p.i
+		if p.consume('=') {
+			start = p.i
+			if p.peek() == '(' {
+				member.isList = true
+				if member.list, err = p.innerList(); err != nil {
+					return nil, err
+				}
+			} else if member.item.value, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		} else {
+			member.item.value = true
+		}
+		if member.params, err = p.parameters(); err != nil {
+			return nil, err
+		}
+		member.raw = p.s[start:p.i]
+		if !member.isList {
+			member.item.params = member.params
+		}
+
+		// Later duplicates override earlier ones
+		replaced := false
+		for i := range members {
+			if members[i].name == name {
+				members[i] = member
+				replaced = true
+			}
+		}
+		if !replaced {
+			members = append(members, member)
+		}
+
+		p.skipOWS()
+		if p.i >= len(p.s) {
+			break
+		}
+		if !p.consume(',') {
+			return nil, fmt.Errorf("expected ',' at offset %d", p.i)
+		}
+		p.skipOWS()
+		if p.i >= len(p.s) {
+			return nil, fmt.Errorf("trailing ',' in dictionary")
+		}
+	}
+	return members, nil
+}
+
+// innerList parses "(" *item ")"
+func (p *sfParser) innerList() ([]sfItem, error) {
+	p.consume('(')
+	var items []sfItem
+	for {
+		p.skipSpaces()
+		if p.consume(')') {
+			return items, nil
+		}
+		if p.i >= len(p.s) {
+			return nil, fmt.Errorf("unterminated inner list")
+		}
+		value, err := p.bareItem()
+		if err != nil {
+			return nil, err
+		}
+		params, err := p.parameters()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, sfItem{value: value, params: params})
+		if c := p.peek(); c != ' ' && c != ')' {
+			return nil, fmt.Errorf("expected ' ' or ')' at offset %d", p.i)
+		}
+	}
+}
+
+// parameters parses *( ";" key [ "=" bare-item ] )
+func (p *sfParser) parameters() ([]sfParam, error) {
+	var params []sfParam
+	for p.consume(';') {
+		p.skipSpaces()
+		name, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		var value interface{} = true
+		if p.consume('=') {
+			if value, err = p.bareItem(); err != nil {
+				return nil, err
+			}
+		}
+		params = append(params, sfParam{name: name, value: value})
+	}
+	return params, nil
+}
+
+// key parses a dictionary or parameter key
+func (p *sfParser) key() (string, error) {
+	start := p.i
+	if c := p.peek(); !(c >= 'a' && c <= 'z' || c == '*') {
+		return "", fmt.Errorf("invalid key at offset %d", p.i)
+	}
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.' || c == '*') {
+			break
+		}
+		p.i++
+	}
+	return p.s[start:p.i], nil
+}
+
+// bareItem parses a string, byte sequence, integer, boolean or token
+func (p *sfParser) bareItem() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		return p.quotedString()
+	case c == ':':
+		p.i++
+		end := strings.IndexByte(p.s[p.i:], ':')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated byte sequence")
+		}
+		data, err := base64.StdEncoding.DecodeString(p.s[p.i : p.i+end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid byte sequence: %w", err)
+		}
+		p.i += end + 1
+		return data, nil
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.i
+		p.i++
+		for p.i < len(p.s) && p.s[p.i] >= '0' && p.s[p.i] <= '9' {
+			p.i++
+		}
+		if p.peek() == '.' {
+			return nil, fmt.Errorf("decimals are not supported")
+		}
+		n, err := strconv.ParseInt(p.s[start:p.i], 10, 64)
+		if err != nil || p.i-start > 16 {
+			return nil, fmt.Errorf("invalid integer at offset %d", start)
+		}
+		return n, nil
+	case c == '?':
+		if p.i+1 < len(p.s) && (p.s[p.i+1] == '0' || p.s[p.i+1] == '1') {
+			p.i += 2
+			return p.s[p.i-1] == '1', nil
+		}
+		return nil, fmt.Errorf("invalid boolean at offset %d", p.i)
+	case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c == '*':
+		start := p.i
+		for p.i < len(p.s) && isSFTokenChar(p.s[p.i]) {
+			p.i++
+		}
+		return sfToken(p.s[start:p.i]), nil
+	}
+	return nil, fmt.Errorf("invalid item at offset %d", p.i)
+}
+
+// quotedString parses a string with \" and \\ escapes
+func (p *sfParser) quotedString() (string, error) {
+	p.i++
+	var b strings.Builder
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		p.i++
+		switch {
+		case c == '\\':
+			if p.i >= len(p.s) || (p.s[p.i] != '"' && p.s[p.i] != '\\') {
+				return "", fmt.Errorf("invalid escape in string")
+			}
+			b.WriteByte(p.s[p.i])
+			p.i++
+		case c == '"':
+			return b.String(), nil
+		case c < 0x20 || c > 0x7e:
+			return "", fmt.Errorf("invalid character in string")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// isSFTokenChar reports whether c may appear in a token after its first character
+func isSFTokenChar(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+		strings.IndexByte("!#$%&'*+-.^_`|~:/", c) >= 0
+}
+
+// peek returns the current character, or 0 at the end
+func (p *sfParser) peek() byte {
+	if p.i < len(p.s) {
+		return p.s[p.i]
+	}
+	return 0
+}
+
+// consume advances past c if it is the current character
+func (p *sfParser) consume(c byte) bool {
+	if p.peek() == c {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// skipSpaces skips SP characters
+func (p *sfParser) skipSpaces() {
+	for p.peek() == ' ' {
+		p.i++
+	}
+}
+
+// skipOWS skips optional whitespace (SP and HTAB)
+func (p *sfParser) skipOWS() {
+	for c := p.peek(); c == ' ' || c == '\t'; c = p.peek() {
+		p.i++
+	}
+}
@@ -0,0 +1,62 @@
+package jwtauth
+
+import (
+	"fmt"
+	"net/textproto"
+	"strings"
+)
+
+// WithTokenHeader reads the token from the named header (gRPC metadata key,
+// message header) instead of Authorization, as set by identity-aware proxies
+// such as Google IAP (X-Goog-IAP-JWT-Assertion) or Cloudflare Access
+// (Cf-Access-Jwt-Assertion). The value may be the bare token or
+// "Bearer <token>". Several distinct values are rejected as AMBIGUOUS_TOKEN,
+// since a proxy sets exactly one.
+func WithTokenHeader(name string) ConfigOption {
+	return func(c *Config) error {
+		if name == "" || strings.ContainsAny(name, " :\t") {
+			return fmt.Errorf("invalid token header name %q", name)
+		}
+		c.tokenHeader = textproto.CanonicalMIMEHeaderKey(name)
+		return nil
+	}
+}
+
+// tokenHeaderCandidates extracts the token from the values of the header
+// configured with WithTokenHeader
+func tokenHeaderCandidates(values []string, cfg *Config, requestID string) ([]string, error) {
+	return singleTokenCandidates(values, cfg.tokenHeader, cfg, requestID)
+}
+
+// singleTokenCandidates extracts the token from header or metadata values that
+// must carry exactly one token, bare or as "Bearer <token>"
+func singleTokenCandidates(values []string, name string, cfg *Config, requestID string) ([]string, error) {
+	if len(values) == 0 || values[0] == "" {
+		return nil, NewValidationError(ErrMissingToken, fmt.Sprintf("%s header not found", name), nil)
+	}
+
+	distinct := splitAuthorizationValues(values)
+	if len(distinct) > 1 {
+		logDuplicateHeaders(cfg, requestID, len(distinct))
+		return nil, NewValidationError(
+			ErrAmbiguousToken,
+			fmt.Sprintf("request carries %d distinct %s values", len(distinct), name),
+			nil,
+		)
+	}
+	if len(distinct) == 0 {
+		return nil, NewValidationError(ErrMissingToken, "token is empty", nil)
+	}
+
+	if scheme, _, ok := strings.Cut(distinct[0], " "); ok && strings.EqualFold(scheme, "bearer") {
+		token, err := parseBearer(distinct[0], fmt.Sprintf("invalid %s header format", name))
+		if err != nil {
+			return nil, err
+		}
+		return []string{token}, nil
+	}
+	if strings.ContainsAny(distinct[0], " \t") {
+		return nil, NewValidationError(ErrMalformed, fmt.Sprintf("invalid %s header format", name), nil)
+	}
+	return distinct, nil
+}
@@ -0,0 +1,184 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// AccessToken is a bearer token for outbound requests
+type AccessToken struct {
+	Token     string
+	Type      string    // Token type for the Authorization header (default "Bearer")
+	ExpiresAt time.Time // Zero when the issuer did not say
+}
+
+// Valid reports whether the token is set and not expired at now
+func (t *AccessToken) Valid(now time.Time) bool {
+	return t != nil && t.Token != "" && (t.ExpiresAt.IsZero() || now.Before(t.ExpiresAt))
+}
+
+// authorization returns the Authorization header value
+func (t *AccessToken) authorization() string {
+	if t.Type == "" || strings.EqualFold(t.Type, "bearer") {
+		return "Bearer " + t.Token
+	}
+	return t.Type + " " + t.Token
+}
+
+// TokenSource supplies access tokens for outbound requests. Implementations
+// must be safe for concurrent use.
+type TokenSource interface {
+	Token(ctx context.Context) (*AccessToken, error)
+}
+
+// TokenSourceFunc adapts a function to the TokenSource interface
+type TokenSourceFunc func(ctx context.Context) (*AccessToken, error)
+
+// Token implements TokenSource
+func (f TokenSourceFunc) Token(ctx context.Context) (*AccessToken, error) {
+	return f(ctx)
+}
+
+// NewCachingTokenSource returns a TokenSource that reuses tokens from src
+// until they expire. Within refreshBefore of expiry, callers keep getting the
+// current token while one background fetch replaces it; once no valid token
+// is cached, concurrent callers share a single fetch. Tokens without an
+// expiry are reused indefinitely, so sources should report ExpiresAt.
+func NewCachingTokenSource(src TokenSource, refreshBefore time.Duration) TokenSource {
+	if refreshBefore < 0 {
+		refreshBefore = 0
+	}
+	return &cachingTokenSource{src: src, refreshBefore: refreshBefore, now: time.Now}
+}
+
+// cachingTokenSource caches tokens with refresh-ahead and deduplicated fetches
+type cachingTokenSource struct {
+	src           TokenSource
+	refreshBefore time.Duration
+	now           func() time.Time
+
+	current atomic.Pointer[AccessToken] // Read without locking on every request
+
+	mu       sync.Mutex
+	inflight *tokenFetch
+}
+
+// tokenFetch is a fetch shared by concurrent callers
+type tokenFetch struct {
+	done  chan struct{}
+	token *AccessToken
+	err   error
+}
+
+// Token implements TokenSource
+func (s *cachingTokenSource) Token(ctx context.Context) (*AccessToken, error) {
+	now := s.now()
+	if tok := s.current.Load(); tok.Valid(now) {
+		if !tok.ExpiresAt.IsZero() && !now.Before(tok.ExpiresAt.Add(-s.refreshBefore)) {
+			s.fetch(ctx) // Refresh ahead; keep serving the current token
+		}
+		return tok, nil
+	}
+
+	f := s.fetch(ctx)
+	select {
+	case <-f.done:
+		return f.token, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch starts a fetch unless one is in flight and returns it. The fetch is
+// detached from the caller's cancellation so that other waiters, and the
+// cache, still get its result.
+func (s *cachingTokenSource) fetch(ctx context.Context) *tokenFetch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inflight != nil {
+		return s.inflight
+	}
+
+	f := &tokenFetch{done: make(chan struct{})}
+	s.inflight = f
+	go func() {
+		tok, err := s.src.Token(context.WithoutCancel(ctx))
+		if err == nil && !tok.Valid(s.now()) {
+			err = fmt.Errorf("token source returned an expired or empty token")
+		}
+		if err == nil {
+			s.current.Store(tok)
+			f.token = tok
+		}
+		f.err = err
+
+		s.mu.Lock()
+		s.inflight = nil
+		s.mu.Unlock()
+		close(f.done)
+	}()
+	return f
+}
+
+// Transport is an http.RoundTripper that adds an Authorization header with
+// a token from Source to each request that does not already carry one
+type Transport struct {
+	Source TokenSource
+	Base   http.RoundTripper // Defaults to http.DefaultTransport
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Header.Get("Authorization") != "" {
+		return base.RoundTrip(req)
+	}
+
+	tok, err := t.Source.Token(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("outbound token: %w", err)
+	}
+
+	// RoundTrippers must not modify the caller's request
+	out := req.Clone(req.Context())
+	out.Header.Set("Authorization", tok.authorization())
+	return base.RoundTrip(out)
+}
+
+// TokenCredentials are gRPC per-RPC credentials sending a token from Source
+// in the "authorization" metadata:
+//
+//	grpc.NewClient(addr, grpc.WithPerRPCCredentials(jwtauth.TokenCredentials{Source: src}))
+type TokenCredentials struct {
+	Source        TokenSource
+	AllowInsecure bool // Send tokens over connections without transport security (tests only)
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (c TokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	tok, err := c.Source.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("outbound token: %w", err)
+	}
+	return map[string]string{"authorization": tok.authorization()}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials
+func (c TokenCredentials) RequireTransportSecurity() bool {
+	return !c.AllowInsecure
+}
+
+var _ credentials.PerRPCCredentials = TokenCredentials{}
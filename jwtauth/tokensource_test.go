@@ -0,0 +1,240 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startTestTokenEndpoint starts a client credentials token endpoint issuing
+// numbered tokens valid for expiresIn seconds
+func startTestTokenEndpoint(t *testing.T, expiresIn int, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "svc-a" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "read write" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_request"})
+			return
+		}
+		time.Sleep(delay)
+		n := calls.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// TestClientCredentialsTokenSource tests fetching, caching and dedupe
+func TestClientCredentialsTokenSource(t *testing.T) {
+	ctx := context.Background()
+	srv, calls := startTestTokenEndpoint(t, 3600, 50*time.Millisecond)
+	src, err := NewClientCredentialsTokenSource(ClientCredentials{
+		TokenURL:     srv.URL,
+		ClientID:     "svc-a",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"read", "write"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tok, err := src.Token(ctx)
+			if err != nil || tok.Token != "token-1" {
+				t.Errorf("Expected token-1, got %v, %v", tok, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := src.Token(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected concurrent callers to share one fetch, got %d", n)
+	}
+
+	t.Run("endpoint error", func(t *testing.T) {
+		bad, err := NewClientCredentialsTokenSource(ClientCredentials{TokenURL: srv.URL, ClientID: "svc-a", ClientSecret: "wrong"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := bad.Token(ctx); err == nil {
+			t.Error("Expected invalid_client error")
+		}
+	})
+
+	t.Run("config validation", func(t *testing.T) {
+		if _, err := NewClientCredentialsTokenSource(ClientCredentials{ClientID: "svc-a"}); err == nil {
+			t.Error("Expected missing token URL to be rejected")
+		}
+		if _, err := NewClientCredentialsTokenSource(ClientCredentials{TokenURL: "not a url", ClientID: "svc-a"}); err == nil {
+			t.Error("Expected invalid token URL to be rejected")
+		}
+	})
+}
+
+// TestCachingTokenSourceRefresh tests refresh ahead of expiry
+func TestCachingTokenSourceRefresh(t *testing.T) {
+	ctx := context.Background()
+	var now atomic.Int64
+	now.Store(time.Now().UnixNano())
+	clock := func() time.Time { return time.Unix(0, now.Load()) }
+
+	var calls atomic.Int32
+	src := NewCachingTokenSource(TokenSourceFunc(func(ctx context.Context) (*AccessToken, error) {
+		n := calls.Add(1)
+		return &AccessToken{Token: fmt.Sprintf("token-%d", n), ExpiresAt: clock().Add(10 * time.Minute)}, nil
+	}), time.Minute)
+	src.(*cachingTokenSource).now = clock
+
+	tok, err := src.Token(ctx)
+	if err != nil || tok.Token != "token-1" {
+		t.Fatalf("Expected token-1, got %v, %v", tok, err)
+	}
+
+	// Inside the refresh window the current token is served while refreshing
+	now.Add(int64(9*time.Minute + 30*time.Second))
+	tok, err = src.Token(ctx)
+	if err != nil || tok.Token != "token-1" {
+		t.Fatalf("Expected token-1 during refresh, got %v, %v", tok, err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for src.(*cachingTokenSource).current.Load().Token != "token-2" {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected background refresh to store token-2")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// After expiry callers wait for a fresh token
+	now.Add(int64(20 * time.Minute))
+	tok, err = src.Token(ctx)
+	if err != nil || tok.Token != "token-3" {
+		t.Fatalf("Expected token-3 after expiry, got %v, %v", tok, err)
+	}
+
+	t.Run("failed fetch", func(t *testing.T) {
+		failing := NewCachingTokenSource(TokenSourceFunc(func(ctx context.Context) (*AccessToken, error) {
+			return nil, fmt.Errorf("idp unavailable")
+		}), time.Minute)
+		if _, err := failing.Token(ctx); err == nil {
+			t.Error("Expected fetch error")
+		}
+	})
+}
+
+// TestTransport tests Authorization injection for outbound HTTP requests
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	src := TokenSourceFunc(func(ctx context.Context) (*AccessToken, error) {
+		return &AccessToken{Token: "abc"}, nil
+	})
+	client := &http.Client{Transport: &Transport{Source: src}}
+
+	get := func(auth string) string {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var buf [64]byte
+		n, _ := resp.Body.Read(buf[:])
+		if auth == "" && req.Header.Get("Authorization") != "" {
+			t.Error("Expected caller's request to be left unmodified")
+		}
+		return string(buf[:n])
+	}
+
+	if got := get(""); got != "Bearer abc" {
+		t.Errorf("Expected injected bearer token, got %q", got)
+	}
+	if got := get("Basic xyz"); got != "Basic xyz" {
+		t.Errorf("Expected existing Authorization to be kept, got %q", got)
+	}
+
+	md, err := TokenCredentials{Source: src}.GetRequestMetadata(context.Background())
+	if err != nil || md["authorization"] != "Bearer abc" {
+		t.Errorf("Expected gRPC authorization metadata, got %v, %v", md, err)
+	}
+}
+
+// TestClientCredentialsPrivateKeyJWT tests private_key_jwt client authentication
+func TestClientCredentialsPrivateKeyJWT(t *testing.T) {
+	ctx := context.Background()
+	key := mustGenerateECKey()
+	signer, err := NewCryptoSigner("ES256", "client-key-1", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := mustCreateConfig(
+		WithKeyProvider("ES256", KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+			if kid != "client-key-1" {
+				return nil, ErrKeyNotFound
+			}
+			return &key.PublicKey, nil
+		})),
+		WithRequiredClaims("jti"),
+	)
+
+	var tokenURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); ok {
+			t.Error("Expected no Basic credentials with private_key_jwt")
+		}
+		if r.FormValue("client_assertion_type") != ClientAssertionType {
+			t.Errorf("Unexpected client_assertion_type %q", r.FormValue("client_assertion_type"))
+		}
+		claims, err := ParseToken(r.Context(), r.FormValue("client_assertion"), idp)
+		if err != nil || claims.Issuer != "svc-a" || claims.Subject != "svc-a" || r.FormValue("client_id") != "svc-a" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		if aud, _ := claims.Get("aud"); aud != tokenURL {
+			t.Errorf("Expected assertion audience %q, got %v", tokenURL, aud)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "from-assertion", "expires_in": 300})
+	}))
+	defer srv.Close()
+	tokenURL = srv.URL + "/token"
+
+	cc := ClientCredentials{TokenURL: tokenURL, ClientID: "svc-a", Signer: signer}
+	tok, err := cc.Token(ctx)
+	if err != nil || tok.Token != "from-assertion" || tok.ExpiresAt.IsZero() {
+		t.Fatalf("Expected token from assertion, got %v, %v", tok, err)
+	}
+
+	cc.ClientSecret = "s3cret"
+	if _, err := NewClientCredentialsTokenSource(cc); err == nil {
+		t.Error("Expected secret and signer together to be rejected")
+	}
+}
@@ -0,0 +1,80 @@
+package jwtauth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Token types accepted by WithTokenTypes
+const (
+	TokenTypeJWT         = "JWT"    // Generic JWT (RFC 7519)
+	TokenTypeAccessToken = "at+jwt" // OAuth 2.0 access token (RFC 9068)
+)
+
+// accessTokenClaims are required by the RFC 9068 access token profile
+var accessTokenClaims = []string{"iss", "exp", "aud", "sub", "client_id", "iat", "jti"}
+
+// WithTokenTypes requires the typ header to be one of types, e.g.
+// TokenTypeAccessToken so ID tokens (typ "JWT") cannot be presented as
+// access tokens. Types compare case-insensitively and with or without the
+// "application/" prefix. Tokens without a typ header, or with another
+// type, are rejected with INVALID_TOKEN_TYPE before any key is resolved.
+func WithTokenTypes(types ...string) ConfigOption {
+	return func(c *Config) error {
+		if len(types) == 0 {
+			return fmt.Errorf("at least one token type must be specified")
+		}
+		for _, typ := range types {
+			normalized := normalizeTokenType(typ)
+			if normalized == "" {
+				return fmt.Errorf("token type cannot be empty")
+			}
+			if !slices.Contains(c.tokenTypes, normalized) {
+				c.tokenTypes = append(c.tokenTypes, normalized)
+			}
+		}
+		return nil
+	}
+}
+
+// WithAccessTokenProfile enforces the JWT access token profile of RFC 9068:
+// the typ header must be at+jwt, and the iss, exp, aud, sub, client_id, iat
+// and jti claims are required. Requires WithAudience, since resource
+// servers must check that tokens were issued for them. Combine with
+// WithIssuer to pin the authorization server.
+func WithAccessTokenProfile() ConfigOption {
+	return func(c *Config) error {
+		if err := WithTokenTypes(TokenTypeAccessToken)(c); err != nil {
+			return err
+		}
+		for _, name := range accessTokenClaims {
+			if !slices.Contains(c.requiredClaims, name) {
+				c.requiredClaims = append(c.requiredClaims, name)
+			}
+		}
+		c.accessTokenProfile = true
+		return nil
+	}
+}
+
+// normalizeTokenType lowercases typ and drops the optional "application/"
+// prefix (RFC 7515 section 4.1.9)
+func normalizeTokenType(typ string) string {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	return strings.TrimPrefix(typ, "application/")
+}
+
+// checkTokenType rejects tokens whose typ header is not accepted
+func checkTokenType(token *jwt.Token, cfg *Config) error {
+	typ, _ := token.Header["typ"].(string)
+	if typ == "" {
+		return NewValidationError(ErrInvalidTokenType, fmt.Sprintf("token type must be %s, typ header is missing", strings.Join(cfg.tokenTypes, " or ")), nil)
+	}
+	if !slices.Contains(cfg.tokenTypes, normalizeTokenType(typ)) {
+		return NewValidationError(ErrInvalidTokenType, fmt.Sprintf("token type must be %s, got %s", strings.Join(cfg.tokenTypes, " or "), typ), nil)
+	}
+	return nil
+}
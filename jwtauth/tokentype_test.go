@@ -0,0 +1,95 @@
+package jwtauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signWithType signs claims with HS256 and the given typ header (none if empty)
+func signWithType(t *testing.T, secret []byte, typ string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if typ == "" {
+		delete(token.Header, "typ")
+	} else {
+		token.Header["typ"] = typ
+	}
+	signed, err := token.SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// TestTokenTypes tests typ header requirements
+func TestTokenTypes(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+
+	tests := []struct {
+		name    string
+		types   []string
+		typ     string
+		wantErr bool
+	}{
+		{"JWT accepted", []string{TokenTypeJWT}, "JWT", false},
+		{"case-insensitive", []string{TokenTypeJWT}, "jwt", false},
+		{"media type prefix", []string{TokenTypeAccessToken}, "application/at+jwt", false},
+		{"either type", []string{TokenTypeJWT, TokenTypeAccessToken}, "at+JWT", false},
+		{"ID token as access token", []string{TokenTypeAccessToken}, "JWT", true},
+		{"missing typ", []string{TokenTypeJWT}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mustCreateConfig(WithHS256(secret), WithTokenTypes(tt.types...))
+			_, err := parseAndValidateJWT(signWithType(t, secret, tt.typ, claims), cfg)
+			if tt.wantErr {
+				if getErrorCode(err) != string(ErrInvalidTokenType) {
+					t.Errorf("expected INVALID_TOKEN_TYPE, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("expected token to validate, got %v", err)
+			}
+		})
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithTokenTypes()); err == nil {
+		t.Error("expected error for an empty type list")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithTokenTypes(" ")); err == nil {
+		t.Error("expected error for an empty type")
+	}
+}
+
+// TestAccessTokenProfile tests RFC 9068 typ and claim requirements
+func TestAccessTokenProfile(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithAccessTokenProfile(), WithAudience("https://api.example.com"))
+	accessToken := func() jwt.MapClaims {
+		now := time.Now()
+		return jwt.MapClaims{
+			"iss": "https://idp.example.com", "sub": "user-1", "aud": "https://api.example.com",
+			"client_id": "web-app", "jti": "at-1", "iat": now.Unix(), "exp": now.Add(time.Hour).Unix(),
+		}
+	}
+
+	if _, err := parseAndValidateJWT(signWithType(t, secret, "at+jwt", accessToken()), cfg); err != nil {
+		t.Fatalf("expected access token to validate, got %v", err)
+	}
+	if _, err := parseAndValidateJWT(signWithType(t, secret, "JWT", accessToken()), cfg); getErrorCode(err) != string(ErrInvalidTokenType) {
+		t.Errorf("expected an ID token to be rejected with INVALID_TOKEN_TYPE, got %v", err)
+	}
+	for _, name := range accessTokenClaims {
+		claims := accessToken()
+		delete(claims, name)
+		if _, err := parseAndValidateJWT(signWithType(t, secret, "at+jwt", claims), cfg); err == nil {
+			t.Errorf("expected error without %s", name)
+		}
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithAccessTokenProfile()); err == nil {
+		t.Error("expected error without WithAudience")
+	}
+}
@@ -0,0 +1,69 @@
+package jwtauth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Unsecured JWS shapes beyond alg=none are rejected with specific codes
+// rather than as generic signature or format errors, so that attempts to
+// smuggle unsigned or unbound content stand out in security events:
+//
+//   - EMPTY_SIGNATURE: a real algorithm with an empty signature segment
+//   - DETACHED_PAYLOAD: an empty payload segment (RFC 7515 Appendix F)
+//   - UNENCODED_PAYLOAD: the RFC 7797 "b64": false header
+
+// checkUnsecuredHeader rejects unsecured shapes visible after the token is
+// decoded. It runs before key resolution.
+func checkUnsecuredHeader(token *jwt.Token) error {
+	if b64, ok := token.Header["b64"]; ok && b64 != true {
+		return errUnencodedPayload()
+	}
+	if len(token.Signature) == 0 {
+		return NewValidationError(ErrEmptySignature, "token signature is empty", nil)
+	}
+	return nil
+}
+
+// classifyUnsecuredJWS returns the specific error for a token the JWT parser
+// rejected as malformed because its payload is detached or unencoded, or nil
+func classifyUnsecuredJWS(tokenString string) error {
+	header, rest, ok := strings.Cut(tokenString, ".")
+	if !ok {
+		return nil
+	}
+	if isUnencodedPayloadHeader(header) {
+		return errUnencodedPayload()
+	}
+	if payload, _, ok := strings.Cut(rest, "."); ok && payload == "" {
+		return errDetachedPayload()
+	}
+	return nil
+}
+
+// isUnencodedPayloadHeader reports whether a raw header segment sets b64 to
+// anything but true
+func isUnencodedPayloadHeader(segment string) bool {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return false
+	}
+	var header struct {
+		B64 *json.RawMessage `json:"b64"`
+	}
+	if json.Unmarshal(data, &header) != nil || header.B64 == nil {
+		return false
+	}
+	return string(*header.B64) != "true"
+}
+
+func errDetachedPayload() error {
+	return NewValidationError(ErrDetachedPayload, "token payload is detached (empty payload segment)", nil)
+}
+
+func errUnencodedPayload() error {
+	return NewValidationError(ErrUnencodedPayload, "unencoded payloads (b64=false) are not accepted", nil)
+}
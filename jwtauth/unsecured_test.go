@@ -0,0 +1,57 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestUnsecuredJWSVariants tests that unsecured shapes beyond alg=none are
+// rejected with specific codes
+func TestUnsecuredJWSVariants(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret))
+	enc := base64.RawURLEncoding.EncodeToString
+
+	claims := jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()}
+	valid := mustSignHS256(secret, claims)
+	header := enc([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := enc([]byte(`{"sub":"user123"}`))
+
+	// b64=false with a payload that happens to be valid base64url JSON
+	unencoded := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	unencoded.Header["b64"] = false
+	unencoded.Header["crit"] = []string{"b64"}
+	unencodedToken, _ := unencoded.SignedString(secret)
+	unencodedHeader := enc([]byte(`{"alg":"HS256","b64":false,"crit":["b64"]}`))
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode ErrorCode
+	}{
+		{"empty signature", header + "." + payload + ".", ErrEmptySignature},
+		{"signature stripped from valid token", valid[:len(valid)-43], ErrEmptySignature},
+		{"detached payload", header + ".." + valid[len(valid)-43:], ErrDetachedPayload},
+		{"unencoded payload (decodable)", unencodedToken, ErrUnencodedPayload},
+		{"unencoded payload (raw)", unencodedHeader + `.{"sub":"user123"}.` + valid[len(valid)-43:], ErrUnencodedPayload},
+		{"none algorithm still named", enc([]byte(`{"alg":"none"}`)) + "." + payload + ".", ErrNoneAlgorithm},
+		{"tampered signature", valid[:len(valid)-2] + "AA", ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseAndValidateJWT(tt.token, cfg)
+			if getErrorCode(err) != string(tt.wantCode) {
+				t.Errorf("parseAndValidateJWT: expected %s, got %v", tt.wantCode, err)
+			}
+			_, err = ParseToken(context.Background(), tt.token, cfg)
+			if getErrorCode(err) != string(tt.wantCode) {
+				t.Errorf("ParseToken: expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+}
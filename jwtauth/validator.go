@@ -1,24 +1,120 @@
 package jwtauth
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// authenticateToken validates a token and applies the post-validation
+// policies shared by every transport (IP and device binding, revocation,
+// tenant rate limits). It returns the claims and the tenant extracted from
+// them. requestID correlates security events emitted along the way.
+//
+// With WithCanary, tokens in the canary fraction are authenticated with the
+// canary configuration; the others are also checked against it report-only.
+func authenticateToken(ctx context.Context, tokenString, requestID string, cfg *Config) (*Claims, string, error) {
+	if cfg.anyOf != nil {
+		return authenticateAnyOf(ctx, tokenString, requestID, cfg)
+	}
+	defer cfg.lifecycle.track()()
+	if len(cfg.faults) > 0 {
+		var err error
+		if ctx, err = cfg.injectFaults(ctx); err != nil {
+			return nil, "", err
+		}
+	}
+	if cfg.canary == nil {
+		claims, tenant, err := authenticateTokenWith(ctx, tokenString, requestID, cfg)
+		return cfg.minimizeClaims(claims), tenant, err
+	}
+	if cfg.canary.enforces(tokenString) {
+		claims, tenant, err := authenticateTokenWith(ctx, tokenString, requestID, cfg.canary.cfg)
+		return cfg.canary.cfg.minimizeClaims(claims), tenant, err
+	}
+
+	claims, tenant, err := authenticateTokenWith(ctx, tokenString, requestID, cfg)
+	if err == nil {
+		cfg.canary.report(ctx, cfg, tokenString, requestID, claims, tenant)
+	}
+	return cfg.minimizeClaims(claims), tenant, err
+}
+
+// authenticateTokenWith runs validation and post-validation policies under
+// a single configuration
+func authenticateTokenWith(ctx context.Context, tokenString, requestID string, cfg *Config) (*Claims, string, error) {
+	claims, err := validateWithConnCache(ctx, tokenString, cfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Reject tokens presented from another client IP
+	if err := checkIPBinding(ctx, cfg, claims); err != nil {
+		return nil, "", err
+	}
+
+	// Reject tokens presented without their bound device fingerprint
+	if err := checkDeviceBinding(ctx, cfg, claims); err != nil {
+		return nil, "", err
+	}
+
+	// Reject revoked tokens
+	if err := checkRevocation(ctx, cfg, claims); err != nil {
+		return nil, "", err
+	}
+
+	// Enforce per-tenant rate limits
+	tenant := cfg.tenantFromClaims(claims)
+	if err := enforceTenantRateLimit(ctx, cfg, tenant); err != nil {
+		return nil, "", err
+	}
+
+	// Flag material claim changes for the same subject
+	detectClaimsAnomaly(ctx, cfg, requestID, tokenString, claims, tenant)
+
+	// Report tokens used close to their expiry
+	warnNearExpiry(ctx, cfg, requestID, tokenString, claims, tenant)
+
+	return claims, tenant, nil
+}
+
 // parseAndValidateJWT parses and validates a JWT token string
 func parseAndValidateJWT(tokenString string, cfg *Config) (*Claims, error) {
-	// Parse the token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate the algorithm and get the appropriate signing key
-		signingKey, err := validateAlgorithm(token, cfg)
-		if err != nil {
-			return nil, err
+	return parseAndValidateJWTContext(context.Background(), tokenString, cfg)
+}
+
+// parseAndValidateJWTContext parses and validates a JWT token string.
+// ctx is passed to key providers when resolving verification keys.
+func parseAndValidateJWTContext(ctx context.Context, tokenString string, cfg *Config) (*Claims, error) {
+	// Parse the token with the parser built for this configuration. Static
+	// keys share one key function; key providers need the request context.
+	keyFunc := cfg.staticKeyFunc
+	var outage *outageMark
+	if keyFunc == nil && cfg.keyOutage != nil {
+		outage = &outageMark{}
+		ctx = context.WithValue(ctx, outageMarkContextKey{}, outage)
+	}
+	if keyFunc == nil {
+		keyFunc = func(token *jwt.Token) (interface{}, error) {
+			// Validate the algorithm and get the appropriate signing key
+			return validateAlgorithm(ctx, token, cfg)
 		}
-		return signingKey, nil
-	})
+	}
+	var release func()
+	if cfg.verifyPool != nil {
+		keyFunc = cfg.verifyPool.wrap(ctx, keyFunc, &release)
+	}
+	token, err := cfg.parser.Parse(tokenString, keyFunc)
+	if release != nil {
+		release()
+	}
+	if errors.Is(err, errKeyOutageFailOpen) {
+		token, err = parseUnverified(tokenString)
+	}
 
 	if err != nil {
 		// Check if error is already a ValidationError (from validateAlgorithm)
@@ -33,8 +129,29 @@ func parseAndValidateJWT(tokenString string, cfg *Config) (*Claims, error) {
 			return nil, valErr
 		}
 
+		// The parser rejects algorithms outside ValidMethods before calling
+		// the key function; classify them as the key function would
+		if token != nil && token.Method != nil {
+			if _, exists := cfg.getValidator(token.Method.Alg()); !exists {
+				if _, algErr := validateAlgorithm(ctx, token, cfg); algErr != nil {
+					return nil, algErr
+				}
+			}
+		}
+
+		// Detached and unencoded payloads fail to decode; name them
+		if errors.Is(err, jwt.ErrTokenMalformed) {
+			if unsecuredErr := classifyUnsecuredJWS(tokenString); unsecuredErr != nil {
+				return nil, unsecuredErr
+			}
+		}
+
 		// Check for specific JWT library error types
 		if errors.Is(err, jwt.ErrTokenExpired) {
+			// The signature was verified before the claims were checked
+			if mapClaims, ok := token.Claims.(jwt.MapClaims); ok && cfg.driftDetector != nil {
+				observeClaimsIssuedAt(cfg, mapClaims)
+			}
 			return nil, NewValidationError(ErrExpired, "token has expired", err)
 		}
 		if errors.Is(err, jwt.ErrSignatureInvalid) {
@@ -65,6 +182,12 @@ func parseAndValidateJWT(tokenString string, cfg *Config) (*Claims, error) {
 	if err != nil {
 		return nil, err
 	}
+	claims.degraded = outage != nil && outage.degraded
+	claims.Provenance = cfg.provenance(token, claims.degraded)
+	claims.Permissions = cfg.mapPermissions(claims)
+
+	// Feed clock drift detection before time checks can reject the token
+	cfg.observeIssuedAt(claims.Issuer, claims.IssuedAt)
 
 	// Validate time-based claims with clock skew
 	if err := validateClaims(claims, cfg); err != nil {
@@ -75,32 +198,111 @@ func parseAndValidateJWT(tokenString string, cfg *Config) (*Claims, error) {
 	if err := validateRequiredClaims(mapClaims, cfg); err != nil {
 		return nil, err
 	}
+	if err := validateClaimRequirements(mapClaims, cfg); err != nil {
+		return nil, err
+	}
+	if err := validateClaimSchema(mapClaims, cfg); err != nil {
+		return nil, err
+	}
 
+	// Validate issuer and audience
+	if err := validateIssuer(claims, cfg); err != nil {
+		return nil, err
+	}
+	if err := validateAudience(mapClaims, cfg); err != nil {
+		return nil, err
+	}
+
+	// Validate may_act delegation constraints
+	if err := validateDelegation(mapClaims, cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.issuedAtTelemetry.observe(claims.Issuer, claims.IssuedAt)
 	return claims, nil
 }
 
+// buildParser constructs the parser shared by every request. ValidMethods is
+// fixed to the configured algorithms, and configurations without key
+// providers get a single key function instead of a closure per request.
+func (c *Config) buildParser() {
+	c.parser = jwt.NewParser(jwt.WithValidMethods(c.AvailableAlgorithms()))
+	c.staticKeyFunc = nil
+	for _, validator := range c.validators {
+		if validator.keyProvider != nil {
+			return
+		}
+	}
+	c.staticKeyFunc = func(token *jwt.Token) (interface{}, error) {
+		return validateAlgorithm(context.Background(), token, c)
+	}
+}
+
 // validateAlgorithm ensures the token uses a configured algorithm and returns the appropriate signing key
-func validateAlgorithm(token *jwt.Token, cfg *Config) (interface{}, error) {
+func validateAlgorithm(ctx context.Context, token *jwt.Token, cfg *Config) (interface{}, error) {
+	validator, alg, err := selectValidator(token, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject empty signatures and unencoded payloads before key lookup
+	if err := checkUnsecuredHeader(token); err != nil {
+		return nil, err
+	}
+	if cfg.rejectEmbeddedKeys {
+		if err := checkEmbeddedKeyHeaders(token); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkNotWebhookType(token, cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.tokenTypes) > 0 {
+		if err := checkTokenType(token, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	// Return the signing key for this algorithm
+	return resolveVerificationKey(ctx, token, cfg, validator, alg)
+}
+
+// resolveVerificationKey returns the key for the token's kid under the key
+// outage policy, rejecting provider keys below the minimum RSA size
+func resolveVerificationKey(ctx context.Context, token *jwt.Token, cfg *Config, validator algorithmValidator, alg string) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, err := resolveKeyWithOutagePolicy(ctx, cfg, validator, alg, kid)
+	if err == nil && validator.keyProvider != nil {
+		if sizeErr := cfg.checkRSAKeySize(key); sizeErr != nil {
+			return nil, NewValidationError(ErrKeyUnavailable, fmt.Sprintf("verification key for %s rejected: %v", alg, sizeErr), nil)
+		}
+	}
+	return key, err
+}
+
+// selectValidator returns the validator for the token's algorithm, rejecting
+// missing, "none", unconfigured and confused algorithms
+func selectValidator(token *jwt.Token, cfg *Config) (algorithmValidator, string, error) {
 	// Extract algorithm from token header
 	alg, ok := token.Header["alg"].(string)
 	if !ok {
 		// Check if alg field exists but is not a string
 		if _, exists := token.Header["alg"]; exists {
-			return nil, NewValidationError(ErrMalformedAlgorithmHeader, "algorithm header must be a string", nil)
+			return algorithmValidator{}, "", NewValidationError(ErrMalformedAlgorithmHeader, "algorithm header must be a string", nil)
 		}
-		return nil, NewValidationError(ErrMalformed, "missing algorithm in token header", nil)
+		return algorithmValidator{}, "", NewValidationError(ErrMalformed, "missing algorithm in token header", nil)
 	}
 
 	// Reject "none" algorithm explicitly (case-insensitive check)
 	if alg == "none" || alg == "None" || alg == "NONE" {
-		return nil, NewValidationError(ErrNoneAlgorithm, "none algorithm not allowed", nil)
+		return algorithmValidator{}, "", NewValidationError(ErrNoneAlgorithm, "none algorithm not allowed", nil)
 	}
 
 	// Look up validator for this algorithm (case-sensitive)
 	validator, exists := cfg.getValidator(alg)
 	if !exists {
 		availableAlgs := cfg.AvailableAlgorithms()
-		return nil, NewValidationError(
+		return algorithmValidator{}, "", NewValidationError(
 			ErrUnsupportedAlgorithm,
 			fmt.Sprintf("algorithm %s not supported (available: %s)", alg, joinStrings(availableAlgs)),
 			nil,
@@ -110,7 +312,7 @@ func validateAlgorithm(token *jwt.Token, cfg *Config) (interface{}, error) {
 	// Verify token's signing method matches the validator's expected signing method
 	// This prevents algorithm confusion attacks
 	if token.Method.Alg() != validator.signingMethod.Alg() {
-		return nil, NewValidationError(
+		return algorithmValidator{}, "", NewValidationError(
 			ErrInvalidSignature,
 			fmt.Sprintf("algorithm confusion detected: token method %s does not match expected method %s",
 				token.Method.Alg(), validator.signingMethod.Alg()),
@@ -118,8 +320,7 @@ func validateAlgorithm(token *jwt.Token, cfg *Config) (interface{}, error) {
 		)
 	}
 
-	// Return the signing key for this algorithm
-	return validator.signingKey, nil
+	return validator, alg, nil
 }
 
 // joinStrings joins a string slice with commas
@@ -161,8 +362,12 @@ func mapJWTClaimsToClaims(mapClaims jwt.MapClaims, cfg *Config) (*Claims, error)
 	if iss, ok := mapClaims["iss"].(string); ok {
 		claims.Issuer = iss
 	}
-	if aud, ok := mapClaims["aud"].(string); ok {
-		claims.Audience = aud
+	// aud may be a string or an array (RFC 7519 section 4.1.3)
+	if aud, err := mapClaims.GetAudience(); err == nil && len(aud) > 0 {
+		claims.Audiences = aud
+		if len(aud) == 1 {
+			claims.Audience = aud[0]
+		}
 	}
 	if jti, ok := mapClaims["jti"].(string); ok {
 		claims.JWTID = jti
@@ -236,3 +441,61 @@ func validateRequiredClaims(mapClaims jwt.MapClaims, cfg *Config) error {
 	}
 	return nil
 }
+
+// validateIssuer checks the iss claim against the accepted issuers
+func validateIssuer(claims *Claims, cfg *Config) error {
+	if len(cfg.issuers) == 0 || slices.Contains(cfg.issuers, claims.Issuer) {
+		return nil
+	}
+	if claims.Issuer == "" {
+		return NewValidationError(ErrInvalidIssuer, "claim iss is missing", nil)
+	}
+	return NewValidationError(ErrInvalidIssuer, fmt.Sprintf("claim iss must be one of: %s", joinStrings(cfg.issuers)), nil)
+}
+
+// validateAudience checks the aud claim against the configured audiences
+func validateAudience(mapClaims jwt.MapClaims, cfg *Config) error {
+	expected := cfg.Audiences()
+	if len(expected) == 0 {
+		return nil
+	}
+
+	// GetAudience accepts both the string and array forms of aud
+	tokenAuds, err := mapClaims.GetAudience()
+	if err != nil {
+		return NewValidationError(ErrInvalidAudience, "audience claim is malformed", err)
+	}
+	if len(tokenAuds) == 0 {
+		return NewValidationError(ErrInvalidAudience, "audience claim missing", nil)
+	}
+
+	matched := 0
+	for _, want := range expected {
+		for _, got := range tokenAuds {
+			if got == want {
+				matched++
+				break
+			}
+		}
+	}
+
+	if cfg.AudienceMatch() == MatchAll {
+		if matched != len(expected) {
+			return NewValidationError(
+				ErrInvalidAudience,
+				fmt.Sprintf("token audience must include all of: %s", joinStrings(expected)),
+				nil,
+			)
+		}
+		return nil
+	}
+
+	if matched == 0 {
+		return NewValidationError(
+			ErrInvalidAudience,
+			fmt.Sprintf("token audience must include one of: %s", joinStrings(expected)),
+			nil,
+		)
+	}
+	return nil
+}
@@ -1,8 +1,14 @@
 package jwtauth
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"log/slog"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -271,7 +277,7 @@ func TestMalformedAlgorithmHeader(t *testing.T) {
 			}
 
 			// Try to validate (this should fail in validateAlgorithm)
-			_, err := validateAlgorithm(token, cfg)
+			_, err := validateAlgorithm(context.Background(), token, cfg)
 
 			if err == nil {
 				t.Errorf("%s: expected error, got nil", tt.description)
@@ -422,3 +428,187 @@ func TestUnsupportedAlgorithmErrorMessage(t *testing.T) {
 		}
 	})
 }
+
+// TestAudienceMatching tests WithAudience with any/all matching and string/array aud forms
+func TestAudienceMatching(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+
+	tests := []struct {
+		name    string
+		match   AudienceMatch
+		aud     interface{}
+		wantErr bool
+	}{
+		{"any: string aud matches", MatchAny, "api", false},
+		{"any: array aud matches one", MatchAny, []string{"other", "admin"}, false},
+		{"any: string aud no match", MatchAny, "other", true},
+		{"any: array aud no match", MatchAny, []string{"x", "y"}, true},
+		{"any: missing aud", MatchAny, nil, true},
+		{"all: array aud contains all", MatchAll, []string{"admin", "api", "extra"}, false},
+		{"all: array aud missing one", MatchAll, []string{"api"}, true},
+		{"all: string aud insufficient", MatchAll, "api", true},
+		{"any: malformed aud", MatchAny, 42, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mustCreateConfig(WithHS256(secret), WithAudience("api", "admin"), WithAudienceMatch(tt.match))
+
+			claims := jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()}
+			if tt.aud != nil {
+				claims["aud"] = tt.aud
+			}
+			tokenString := mustSignHS256(secret, claims)
+
+			_, err := parseAndValidateJWT(tokenString, cfg)
+			if tt.wantErr {
+				if getErrorCode(err) != string(ErrInvalidAudience) {
+					t.Errorf("Expected INVALID_AUDIENCE, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("Expected token to validate, got %v", err)
+			}
+		})
+	}
+}
+
+// TestAudienceClaimMapping tests that every form of aud reaches
+// Claims.Audiences, and the deprecated Audience for a single audience
+func TestAudienceClaimMapping(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret), WithAudience("api"))
+
+	tests := []struct {
+		aud          interface{}
+		wantAuds     []string
+		wantAudience string
+		wantGet      interface{}
+	}{
+		{"api", []string{"api"}, "api", "api"},
+		{[]string{"api"}, []string{"api"}, "api", "api"},
+		{[]string{"api", "billing"}, []string{"api", "billing"}, "", []interface{}{"api", "billing"}},
+	}
+	for _, tt := range tests {
+		claims, err := parseAndValidateJWT(mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "aud": tt.aud, "exp": time.Now().Add(time.Hour).Unix()}), cfg)
+		if err != nil {
+			t.Fatalf("Expected aud %v to validate, got %v", tt.aud, err)
+		}
+		if !reflect.DeepEqual(claims.Audiences, tt.wantAuds) || claims.Audience != tt.wantAudience {
+			t.Errorf("aud %v: expected Audiences %v and Audience %q, got %v and %q", tt.aud, tt.wantAuds, tt.wantAudience, claims.Audiences, claims.Audience)
+		}
+		if got, _ := claims.Get("aud"); !reflect.DeepEqual(got, tt.wantGet) {
+			t.Errorf("aud %v: expected Get(\"aud\") %v, got %v", tt.aud, tt.wantGet, got)
+		}
+		if !claims.HasAudience("api") || claims.HasAudience("other") || claims.HasAudience("") {
+			t.Errorf("aud %v: unexpected HasAudience results", tt.aud)
+		}
+	}
+}
+
+// TestAudienceConfigErrors tests config-time validation of audience options
+func TestAudienceConfigErrors(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+
+	if _, err := NewConfig(WithHS256(secret), WithAudience()); err == nil {
+		t.Error("Expected error for empty audience list")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithAudience("")); err == nil {
+		t.Error("Expected error for empty audience")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithAudienceMatch(AudienceMatch(7))); err == nil {
+		t.Error("Expected error for invalid match mode")
+	}
+}
+
+// TestIssuerValidation tests WithIssuer and WithIssuers allowlists
+func TestIssuerValidation(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+
+	tests := []struct {
+		name    string
+		opt     ConfigOption
+		iss     interface{}
+		wantErr bool
+	}{
+		{"single issuer matches", WithIssuer("https://idp.example.com"), "https://idp.example.com", false},
+		{"allowlist matches second", WithIssuers("https://a.example.com", "https://b.example.com"), "https://b.example.com", false},
+		{"issuer not listed", WithIssuers("https://a.example.com", "https://b.example.com"), "https://evil.example.com", true},
+		{"missing iss", WithIssuer("https://idp.example.com"), nil, true},
+		{"trailing slash differs", WithIssuer("https://idp.example.com"), "https://idp.example.com/", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()}
+			if tt.iss != nil {
+				claims["iss"] = tt.iss
+			}
+			_, err := parseAndValidateJWT(mustSignHS256(secret, claims), mustCreateConfig(WithHS256(secret), tt.opt))
+			if tt.wantErr {
+				if getErrorCode(err) != string(ErrInvalidIssuer) {
+					t.Errorf("Expected INVALID_ISSUER, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("Expected token to validate, got %v", err)
+			}
+		})
+	}
+
+	// The code reaches the failure security event
+	var logs bytes.Buffer
+	cfg := mustCreateConfig(WithHS256(secret), WithIssuer("https://idp.example.com"), WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+mustSignHS256(secret, jwt.MapClaims{"sub": "user123", "iss": "https://evil.example.com", "exp": time.Now().Add(time.Hour).Unix()}))
+	createTestRouter(cfg).ServeHTTP(httptest.NewRecorder(), req)
+	if !strings.Contains(logs.String(), `"failure_reason":"INVALID_ISSUER"`) {
+		t.Errorf("Expected INVALID_ISSUER in the security event, got %s", logs.String())
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithIssuers()); err == nil {
+		t.Error("Expected error for empty issuer list")
+	}
+	if _, err := NewConfig(WithHS256(secret), WithIssuer("")); err == nil {
+		t.Error("Expected error for empty issuer")
+	}
+}
+
+// TestPrecompiledParser tests that the per-config parser classifies
+// algorithms outside its ValidMethods like the key function does
+func TestPrecompiledParser(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	cfg := mustCreateConfig(WithHS256(secret))
+
+	if cfg.staticKeyFunc == nil {
+		t.Error("Expected a shared key function for static keys")
+	}
+	providerCfg := mustCreateConfig(WithKeyProvider("HS256", KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+		return secret, nil
+	})))
+	if providerCfg.staticKeyFunc != nil {
+		t.Error("Expected key provider configs to resolve keys per request")
+	}
+
+	claims := jwt.MapClaims{"sub": "user123", "exp": time.Now().Add(time.Hour).Unix()}
+	hs384, _ := jwt.NewWithClaims(jwt.SigningMethodHS384, claims).SignedString(secret)
+	none, _ := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode ErrorCode
+	}{
+		{"unconfigured algorithm", hs384, ErrUnsupportedAlgorithm},
+		{"none algorithm", none, ErrNoneAlgorithm},
+		{"tampered signature", mustSignHS256(secret, claims) + "x", ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, c := range []*Config{cfg, providerCfg} {
+				_, err := parseAndValidateJWT(tt.token, c)
+				if getErrorCode(err) != string(tt.wantCode) {
+					t.Errorf("Expected %s, got %v", tt.wantCode, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package jwtauth
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// verifyOverloadRetryAfter is the Retry-After of VERIFICATION_OVERLOADED
+// rejections
+const verifyOverloadRetryAfter = time.Second
+
+// WithVerificationWorkers bounds concurrent signature verifications to
+// workers, so bursts of expensive verifications (4096-bit RSA, PS512) cannot
+// occupy every core and starve request handlers. A request waits for a free
+// worker after its key is resolved; if its context ends first, it is rejected
+// with VERIFICATION_OVERLOADED (503). HMAC tokens and tokens served from the
+// token cache are not counted. A good starting point is runtime.GOMAXPROCS(0)
+// minus the cores handlers need. Bound the wait with WithVerificationQueue.
+func WithVerificationWorkers(workers int) ConfigOption {
+	return func(c *Config) error {
+		if workers <= 0 {
+			return fmt.Errorf("verification workers must be positive, got %d", workers)
+		}
+		c.verifyPool = &verifyPool{slots: make(chan struct{}, workers)}
+		return nil
+	}
+}
+
+// WithVerificationQueue bounds waiting for a verification worker: at most
+// maxWaiting requests queue (0 for no limit), each for at most timeout (0 to
+// wait until the request context ends). Requests arriving at a full queue or
+// waiting longer are rejected with VERIFICATION_OVERLOADED (503, gRPC
+// UNAVAILABLE) and Retry-After, so a load spike sheds excess requests quickly
+// instead of raising the latency of every request. Requires
+// WithVerificationWorkers.
+func WithVerificationQueue(maxWaiting int, timeout time.Duration) ConfigOption {
+	return func(c *Config) error {
+		if maxWaiting < 0 {
+			return fmt.Errorf("verification queue length must be non-negative, got %d", maxWaiting)
+		}
+		if timeout < 0 {
+			return fmt.Errorf("verification queue timeout must be non-negative, got %v", timeout)
+		}
+		c.verifyQueueMax = maxWaiting
+		c.verifyQueueTimeout = timeout
+		c.verifyQueueSet = true
+		return nil
+	}
+}
+
+// checkVerifyPool applies WithVerificationQueue to the worker pool
+func (c *Config) checkVerifyPool() error {
+	if !c.verifyQueueSet {
+		return nil
+	}
+	if c.verifyPool == nil {
+		return NewValidationError(ErrConfigError, "verification queue requires WithVerificationWorkers", nil)
+	}
+	c.verifyPool.maxWaiting = int64(c.verifyQueueMax)
+	c.verifyPool.timeout = c.verifyQueueTimeout
+	return nil
+}
+
+// VerificationStats reports the signature verification worker pool
+type VerificationStats struct {
+	Workers  int    // Configured workers (0 without WithVerificationWorkers)
+	Busy     int    // Workers currently verifying
+	Waiting  int    // Requests waiting for a worker
+	Rejected uint64 // Requests rejected with VERIFICATION_OVERLOADED
+}
+
+// VerificationStats returns the current worker pool counters
+func (c *Config) VerificationStats() VerificationStats {
+	p := c.verifyPool
+	if p == nil {
+		return VerificationStats{}
+	}
+	return VerificationStats{
+		Workers:  cap(p.slots),
+		Busy:     len(p.slots),
+		Waiting:  int(p.waiting.Load()),
+		Rejected: p.rejected.Load(),
+	}
+}
+
+// verifyPool is a semaphore bounding concurrent signature verifications
+type verifyPool struct {
+	slots      chan struct{}
+	maxWaiting int64         // 0 for no limit
+	timeout    time.Duration // 0 to wait until the context ends
+
+	waiting  atomic.Int64
+	rejected atomic.Uint64
+}
+
+// acquire takes a worker slot, waiting within the queue limits. The returned
+// func releases the slot.
+func (p *verifyPool) acquire(ctx context.Context) (func(), error) {
+	select {
+	case p.slots <- struct{}{}:
+		return p.release, nil
+	default:
+	}
+
+	waiting := p.waiting.Add(1)
+	defer p.waiting.Add(-1)
+	if p.maxWaiting > 0 && waiting > p.maxWaiting {
+		return nil, p.reject("signature verification queue is full", nil)
+	}
+
+	var expired <-chan time.Time
+	if p.timeout > 0 {
+		timer := time.NewTimer(p.timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case p.slots <- struct{}{}:
+		return p.release, nil
+	case <-expired:
+		return nil, p.reject(fmt.Sprintf("no signature verification worker available within %v", p.timeout), nil)
+	case <-ctx.Done():
+		return nil, p.reject("no signature verification worker available", ctx.Err())
+	}
+}
+
+// reject counts and returns a VERIFICATION_OVERLOADED error
+func (p *verifyPool) reject(message string, internal error) error {
+	p.rejected.Add(1)
+	err := NewValidationError(ErrVerificationOverloaded, message, internal)
+	err.RetryAfter = verifyOverloadRetryAfter
+	return err
+}
+
+// release frees a worker slot
+func (p *verifyPool) release() {
+	<-p.slots
+}
+
+// wrap wraps keyFunc to take a worker slot once the key is
+// resolved, so key fetches do not hold workers. *release is set when a slot
+// was taken and must be called after verification.
+func (p *verifyPool) wrap(ctx context.Context, keyFunc jwt.Keyfunc, release *func()) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		key, err := keyFunc(token)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := key.([]byte); ok {
+			return key, nil
+		}
+		if *release, err = p.acquire(ctx); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+}
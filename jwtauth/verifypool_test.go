@@ -0,0 +1,113 @@
+package jwtauth
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestVerificationWorkers tests that verifications wait for a worker and
+// fail with VERIFICATION_OVERLOADED when none frees up
+func TestVerificationWorkers(t *testing.T) {
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	key := mustGenerateECKey()
+	cfg := mustCreateConfig(WithES256(&key.PublicKey), WithHS256(secret), WithVerificationWorkers(1))
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Occupy the only worker
+	release, err := cfg.verifyPool.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := parseAndValidateJWTContext(ctx, token, cfg); getErrorCode(err) != string(ErrVerificationOverloaded) {
+		t.Errorf("expected VERIFICATION_OVERLOADED, got %v", err)
+	}
+	if _, err := parseAndValidateJWT(mustSignHS256(secret, claims), cfg); err != nil {
+		t.Errorf("expected HMAC tokens not to wait for a worker, got %v", err)
+	}
+
+	// A waiting verification proceeds once the worker is released
+	done := make(chan error, 1)
+	go func() {
+		_, err := parseAndValidateJWT(token, cfg)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	release()
+	if err := <-done; err != nil {
+		t.Errorf("expected token to validate after release, got %v", err)
+	}
+	if len(cfg.verifyPool.slots) != 0 {
+		t.Error("expected every worker to be released")
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithVerificationWorkers(0)); err == nil {
+		t.Error("expected error for zero workers")
+	}
+}
+
+// TestVerificationQueue tests shedding load when the worker queue is full
+// or a request waits too long
+func TestVerificationQueue(t *testing.T) {
+	key := mustGenerateECKey()
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Queue timeout: 503 with Retry-After
+	cfg := mustCreateConfig(WithES256(&key.PublicKey), WithVerificationWorkers(1), WithVerificationQueue(0, 10*time.Millisecond))
+	release, _ := cfg.verifyPool.acquire(context.Background())
+	req := httptest.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	createTestRouter(cfg).ServeHTTP(w, req)
+	if w.Code != 503 || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected 503 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	release()
+
+	// Full queue: rejected without waiting
+	cfg = mustCreateConfig(WithES256(&key.PublicKey), WithVerificationWorkers(1), WithVerificationQueue(1, 0))
+	release, _ = cfg.verifyPool.acquire(context.Background())
+	waitCtx, cancelWait := context.WithCancel(context.Background())
+	waited := make(chan error, 1)
+	go func() {
+		_, err := parseAndValidateJWTContext(waitCtx, token, cfg)
+		waited <- err
+	}()
+	for cfg.VerificationStats().Waiting == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	if _, err := parseAndValidateJWT(token, cfg); getErrorCode(err) != string(ErrVerificationOverloaded) {
+		t.Errorf("expected VERIFICATION_OVERLOADED for a full queue, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("expected a full queue to reject immediately")
+	}
+	cancelWait()
+	if err := <-waited; getErrorCode(err) != string(ErrVerificationOverloaded) {
+		t.Errorf("expected the canceled request to fail, got %v", err)
+	}
+	release()
+
+	stats := cfg.VerificationStats()
+	if stats.Workers != 1 || stats.Busy != 0 || stats.Waiting != 0 || stats.Rejected != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if _, err := NewConfig(WithES256(&key.PublicKey), WithVerificationQueue(10, time.Second)); err == nil {
+		t.Error("expected error without WithVerificationWorkers")
+	}
+}
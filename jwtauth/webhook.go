@@ -0,0 +1,185 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultWebhookType is the typ header webhook signatures carry unless
+// WithWebhookVerification sets another
+const DefaultWebhookType = "webhook+jws"
+
+// WebhookVerification configures VerifyWebhook
+type WebhookVerification struct {
+	Header       string        // Header carrying the JWS (default "X-JWS-Signature")
+	MaxBodyBytes int64         // Largest body read (default 1 MiB)
+	Type         string        // Required typ header (default DefaultWebhookType)
+	MaxAge       time.Duration // When set, require an iat header parameter at most this old
+}
+
+// defaultWebhookVerification is used when WithWebhookVerification is not set
+var defaultWebhookVerification = WebhookVerification{Header: "X-JWS-Signature", MaxBodyBytes: 1 << 20, Type: DefaultWebhookType}
+
+// WithWebhookVerification overrides the signature header, body limit,
+// signature type and maximum signature age used by VerifyWebhook
+func WithWebhookVerification(v WebhookVerification) ConfigOption {
+	return func(c *Config) error {
+		if v.Header == "" {
+			v.Header = defaultWebhookVerification.Header
+		}
+		if v.MaxBodyBytes == 0 {
+			v.MaxBodyBytes = defaultWebhookVerification.MaxBodyBytes
+		}
+		if v.MaxBodyBytes < 0 {
+			return fmt.Errorf("webhook body limit must be positive, got %d", v.MaxBodyBytes)
+		}
+		if v.Type == "" {
+			v.Type = defaultWebhookVerification.Type
+		}
+		v.Type = normalizeTokenType(v.Type)
+		if v.MaxAge < 0 {
+			return fmt.Errorf("webhook max age must be positive, got %v", v.MaxAge)
+		}
+		c.webhook = &v
+		return nil
+	}
+}
+
+// webhookVerification returns the webhook settings in effect
+func (c *Config) webhookVerification() WebhookVerification {
+	if c.webhook != nil {
+		return *c.webhook
+	}
+	return defaultWebhookVerification
+}
+
+// checkNotWebhookType rejects bearer tokens typed as webhook signatures, so
+// a signed webhook body cannot be replayed as a token
+func checkNotWebhookType(token *jwt.Token, cfg *Config) error {
+	typ, _ := token.Header["typ"].(string)
+	if typ != "" && normalizeTokenType(typ) == cfg.webhookVerification().Type {
+		return NewValidationError(ErrInvalidTokenType, fmt.Sprintf("token type %s is reserved for webhook signatures", typ), nil)
+	}
+	return nil
+}
+
+// SignWebhook signs a webhook body for VerifyWebhook and returns the
+// detached JWS for the signature header. The header carries typ (the
+// DefaultWebhookType if empty) and the signing time as iat, for receivers
+// that set WebhookVerification.MaxAge.
+func SignWebhook(ctx context.Context, signer Signer, body []byte, typ string) (string, error) {
+	if typ == "" {
+		typ = DefaultWebhookType
+	}
+	return signDetachedJWS(ctx, signer, body, false, map[string]interface{}{"typ": typ, "iat": time.Now().Unix()})
+}
+
+// VerifyWebhook verifies a JWS-signed webhook: the signature header holds a
+// JWS over the request body, signed with a key the Config trusts (static keys
+// or key providers, as for tokens). The JWS may be detached
+// ("<header>..<signature>", including RFC 7797 "b64": false) or carry the
+// base64url-encoded body as its payload. Requires WithDetachedPayloads.
+//
+// The typ header must be the webhook type (DefaultWebhookType unless
+// WithWebhookVerification sets another), which the middleware in turn
+// refuses as a bearer token, so signatures cannot cross between the two.
+// With WebhookVerification.MaxAge, the iat header parameter must also be
+// at most that old (give or take the clock skew leeway), limiting replays.
+//
+// On success it returns the body, which is also restored on r.Body for the
+// handler. Failures are *ValidationError values; do not act on the body
+// unless VerifyWebhook succeeds.
+//
+//	body, err := jwtauth.VerifyWebhook(r, cfg)
+//	if err != nil {
+//		http.Error(w, "invalid signature", http.StatusUnauthorized)
+//		return
+//	}
+func VerifyWebhook(r *http.Request, cfg *Config) ([]byte, error) {
+	if cfg == nil || cfg.parser == nil {
+		return nil, NewValidationError(ErrConfigError, "configuration is required (use NewConfig)", nil)
+	}
+	if !cfg.detachedPayloads {
+		return nil, NewValidationError(ErrDetachedPayload, "detached payloads are not enabled (use WithDetachedPayloads)", nil)
+	}
+	opts := cfg.webhookVerification()
+
+	jws := strings.TrimSpace(r.Header.Get(opts.Header))
+	if jws == "" {
+		return nil, NewValidationError(ErrMissingToken, fmt.Sprintf("webhook signature header %s missing", opts.Header), nil)
+	}
+
+	body, err := readRequestBody(r, opts.MaxBodyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// An attached payload must be the encoded body; verify it detached
+	if header, rest, ok := strings.Cut(jws, "."); ok {
+		if payload, signature, ok := strings.Cut(rest, "."); ok && payload != "" {
+			if payload != base64.RawURLEncoding.EncodeToString(body) {
+				return nil, NewValidationError(ErrInvalidSignature, "webhook JWS payload does not match the request body", nil)
+			}
+			jws = header + ".." + signature
+		}
+	}
+
+	header, err := verifyDetachedJWS(r.Context(), jws, body, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWebhookHeader(header, opts, cfg.clockSkewLeeway); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// checkWebhookHeader checks the typ and, with MaxAge, the iat of a verified
+// webhook signature header
+func checkWebhookHeader(header map[string]interface{}, opts WebhookVerification, leeway time.Duration) error {
+	typ, _ := header["typ"].(string)
+	if normalizeTokenType(typ) != opts.Type {
+		return NewValidationError(ErrInvalidTokenType, fmt.Sprintf("webhook signature type must be %s, got %q", opts.Type, typ), nil)
+	}
+	if opts.MaxAge == 0 {
+		return nil
+	}
+	iat, ok := header["iat"].(float64)
+	if !ok {
+		return NewValidationError(ErrMalformed, "webhook signature has no numeric iat header parameter", nil)
+	}
+	age := time.Since(time.Unix(int64(iat), 0))
+	if age > opts.MaxAge+leeway {
+		return NewValidationError(ErrExpired, fmt.Sprintf("webhook signature is %v old", age.Round(time.Second)), nil)
+	}
+	if age < -leeway {
+		return NewValidationError(ErrMalformed, "webhook signature iat is in the future", nil)
+	}
+	return nil
+}
+
+// readRequestBody reads at most limit bytes of the body and restores it on r
+func readRequestBody(r *http.Request, limit int64) ([]byte, error) {
+	if r.Body == nil {
+		r.Body = http.NoBody
+		return []byte{}, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, NewValidationError(ErrMalformed, "failed to read request body", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, NewValidationError(ErrMalformed, fmt.Sprintf("request body exceeds %d bytes", limit), nil)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
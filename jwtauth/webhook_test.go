@@ -0,0 +1,184 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestVerifyWebhook tests webhook signature verification over request bodies
+func TestVerifyWebhook(t *testing.T) {
+	ctx := context.Background()
+	key := mustGenerateECKey()
+	signer, err := NewCryptoSigner("ES256", "partner-1", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := mustCreateConfig(
+		WithHS256([]byte("test-secret-key-min-32-bytes-long!!")),
+		WithKeyProvider("ES256", KeyProviderFunc(func(ctx context.Context, alg, kid string) (interface{}, error) {
+			return &key.PublicKey, nil
+		})),
+		WithDetachedPayloads(),
+		WithWebhookVerification(WebhookVerification{Header: "X-Partner-Signature", MaxBodyBytes: 64}),
+	)
+
+	body := `{"event":"invoice.paid"}`
+	webhook := map[string]interface{}{"typ": DefaultWebhookType}
+	detached, _ := signDetachedJWS(ctx, signer, []byte(body), false, webhook)
+	unencoded, _ := signDetachedJWS(ctx, signer, []byte(body), true, webhook)
+	header, _, _ := strings.Cut(detached, ".")
+	attached := header + "." + base64.RawURLEncoding.EncodeToString([]byte(body)) + detached[len(header)+1:]
+	untyped, _ := SignDetachedJWS(ctx, signer, []byte(body), false)
+	signed, err := SignWebhook(ctx, signer, []byte(body), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		wantCode  ErrorCode // Empty when the webhook verifies
+	}{
+		{"detached", body, detached, ""},
+		{"unencoded", body, unencoded, ""},
+		{"attached", body, attached, ""},
+		{"signed with SignWebhook", body, signed, ""},
+		{"missing type", body, untyped, ErrInvalidTokenType},
+		{"tampered body", strings.Replace(body, "paid", "void", 1), detached, ErrInvalidSignature},
+		{"attached payload mismatch", strings.Replace(body, "paid", "void", 1), attached, ErrInvalidSignature},
+		{"missing signature", body, "", ErrMissingToken},
+		{"oversized body", body + strings.Repeat(" ", 64), detached, ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/partner", strings.NewReader(tt.body))
+			if tt.signature != "" {
+				req.Header.Set("X-Partner-Signature", tt.signature)
+			}
+
+			got, err := VerifyWebhook(req, cfg)
+			if tt.wantCode != "" {
+				if getErrorCode(err) != string(tt.wantCode) {
+					t.Errorf("Expected %s, got %v", tt.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected webhook to verify, got %v", err)
+			}
+			if string(got) != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, got)
+			}
+			// The handler can still read the body
+			if restored, _ := io.ReadAll(req.Body); string(restored) != tt.body {
+				t.Errorf("Expected restored body %q, got %q", tt.body, restored)
+			}
+		})
+	}
+}
+
+// TestWebhookTokenSeparation tests that webhook signatures and bearer tokens
+// cannot stand in for each other
+func TestWebhookTokenSeparation(t *testing.T) {
+	ctx := context.Background()
+	secret := []byte("test-secret-key-min-32-bytes-long!!")
+	key := mustGenerateECKey()
+	signer, err := NewCryptoSigner("ES256", "", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := mustCreateConfig(WithHS256(secret), WithES256(&key.PublicKey), WithDetachedPayloads())
+
+	// A webhook body shaped like claims, signed as a webhook, is no token
+	body := []byte(`{"sub":"admin","exp":4102444800}`)
+	jws, err := SignWebhook(ctx, signer, body, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, _ := strings.Cut(jws, ".")
+	token := header + "." + base64.RawURLEncoding.EncodeToString(body) + jws[len(header)+1:]
+	if _, err := ParseToken(ctx, token, cfg); getErrorCode(err) != string(ErrInvalidTokenType) {
+		t.Errorf("Expected INVALID_TOKEN_TYPE for a webhook signature as token, got %v", err)
+	}
+
+	// A bearer token, posted with its claims as the body, is no webhook
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	bearer := mustSignHS256(secret, claims)
+	payload, _ := base64.RawURLEncoding.DecodeString(strings.Split(bearer, ".")[1])
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(payload))
+	req.Header.Set("X-JWS-Signature", bearer)
+	if _, err := VerifyWebhook(req, cfg); getErrorCode(err) != string(ErrInvalidTokenType) {
+		t.Errorf("Expected INVALID_TOKEN_TYPE for a token as webhook signature, got %v", err)
+	}
+
+	// VerifyWebhook honors the WithDetachedPayloads opt-in
+	req = httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+	req.Header.Set("X-JWS-Signature", jws)
+	if _, err := VerifyWebhook(req, mustCreateConfig(WithES256(&key.PublicKey))); getErrorCode(err) != string(ErrDetachedPayload) {
+		t.Errorf("Expected DETACHED_PAYLOAD without the opt-in, got %v", err)
+	}
+
+	if _, err := NewConfig(WithHS256(secret), WithTokenTypes(DefaultWebhookType)); err == nil {
+		t.Error("Expected the webhook type to be refused as a token type")
+	}
+}
+
+// TestWebhookMaxAge tests the iat check of WebhookVerification.MaxAge
+func TestWebhookMaxAge(t *testing.T) {
+	ctx := context.Background()
+	key := mustGenerateECKey()
+	signer, err := NewCryptoSigner("ES256", "", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := mustCreateConfig(WithES256(&key.PublicKey), WithDetachedPayloads(), WithClockSkew(0),
+		WithWebhookVerification(WebhookVerification{Type: "application/partner-event+jws", MaxAge: 5 * time.Minute}))
+
+	body := []byte(`{"event":"invoice.paid"}`)
+	typ := "partner-event+jws"
+	fresh, _ := SignWebhook(ctx, signer, body, typ)
+	stale, _ := signDetachedJWS(ctx, signer, body, false, map[string]interface{}{"typ": typ, "iat": time.Now().Add(-time.Hour).Unix()})
+	future, _ := signDetachedJWS(ctx, signer, body, false, map[string]interface{}{"typ": typ, "iat": time.Now().Add(time.Hour).Unix()})
+	undated, _ := signDetachedJWS(ctx, signer, body, false, map[string]interface{}{"typ": typ})
+	defaultType, _ := SignWebhook(ctx, signer, body, "")
+
+	tests := []struct {
+		name      string
+		signature string
+		wantCode  ErrorCode // Empty when the webhook verifies
+	}{
+		{"fresh", fresh, ""},
+		{"stale", stale, ErrExpired},
+		{"future", future, ErrMalformed},
+		{"no iat", undated, ErrMalformed},
+		{"other type", defaultType, ErrInvalidTokenType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+			req.Header.Set("X-JWS-Signature", tt.signature)
+			_, err := VerifyWebhook(req, cfg)
+			if tt.wantCode == "" && err != nil {
+				t.Errorf("Expected webhook to verify, got %v", err)
+			}
+			if tt.wantCode != "" && getErrorCode(err) != string(tt.wantCode) {
+				t.Errorf("Expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+
+	if _, err := NewConfig(WithES256(&key.PublicKey), WithWebhookVerification(WebhookVerification{MaxAge: -time.Second})); err == nil {
+		t.Error("Expected error for a negative max age")
+	}
+}
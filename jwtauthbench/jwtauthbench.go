@@ -0,0 +1,242 @@
+// Package jwtauthbench measures token validation latency and allocations of a
+// live jwtauth.Config and reports them as JSON, so adopters can check the
+// middleware's latency targets (e.g. <1ms p99) on their own hardware, keys and
+// key providers:
+//
+//	report, err := jwtauthbench.Run(ctx, cfg, jwtauthbench.Options{
+//		Tokens:      []string{token},
+//		Iterations:  50000,
+//		Concurrency: runtime.GOMAXPROCS(0),
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	report.WriteJSON(os.Stdout)
+//	if failures := report.Check(jwtauthbench.Targets{P99: time.Millisecond}); len(failures) > 0 {
+//		log.Fatalf("latency targets missed: %v", failures)
+//	}
+//
+// Tokens are validated with jwtauth.ParseToken, which runs the same checks as
+// the HTTP middleware and gRPC interceptors. Allocations are measured with
+// runtime.MemStats across the whole run, so other goroutines of the process
+// are included; run the harness in a dedicated process or test for stable
+// numbers.
+package jwtauthbench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+)
+
+// Defaults for Options
+const (
+	DefaultIterations = 10000
+	DefaultWarmup     = 1000
+)
+
+// Options configures a benchmark run
+type Options struct {
+	Name        string   // Reported name (default "ParseToken")
+	Tokens      []string // Tokens validated round-robin; every token must be valid
+	Iterations  int      // Measured validations (default DefaultIterations)
+	Warmup      int      // Unmeasured validations before the run (default DefaultWarmup; negative disables)
+	Concurrency int      // Parallel validating goroutines (default 1)
+}
+
+// Latency holds latency statistics in nanoseconds
+type Latency struct {
+	Min  time.Duration `json:"min_ns"`
+	Mean time.Duration `json:"mean_ns"`
+	P50  time.Duration `json:"p50_ns"`
+	P90  time.Duration `json:"p90_ns"`
+	P99  time.Duration `json:"p99_ns"`
+	P999 time.Duration `json:"p999_ns"`
+	Max  time.Duration `json:"max_ns"`
+}
+
+// Report is the result of a benchmark run
+type Report struct {
+	Name        string        `json:"name"`
+	Algorithms  []string      `json:"algorithms"`
+	Iterations  int           `json:"iterations"`
+	Concurrency int           `json:"concurrency"`
+	Errors      int           `json:"errors"`
+	Duration    time.Duration `json:"duration_ns"`
+	OpsPerSec   float64       `json:"ops_per_sec"`
+	Latency     Latency       `json:"latency"`
+	AllocsPerOp float64       `json:"allocs_per_op"`
+	BytesPerOp  float64       `json:"bytes_per_op"`
+
+	GoVersion string    `json:"go_version"`
+	GOOS      string    `json:"goos"`
+	GOARCH    string    `json:"goarch"`
+	NumCPU    int       `json:"num_cpu"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Targets are limits checked by Report.Check; zero fields are not checked
+type Targets struct {
+	P50         time.Duration
+	P99         time.Duration
+	AllocsPerOp float64
+	MaxErrors   int // Tolerated validation errors
+}
+
+// Run validates opts.Tokens against cfg and reports latency percentiles,
+// throughput and allocations. It fails without measuring when a token does
+// not validate, and stops early when ctx is canceled.
+func Run(ctx context.Context, cfg *jwtauth.Config, opts Options) (*Report, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("jwtauthbench: config is required")
+	}
+	if len(opts.Tokens) == 0 {
+		return nil, fmt.Errorf("jwtauthbench: at least one token is required")
+	}
+	if opts.Name == "" {
+		opts.Name = "ParseToken"
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultIterations
+	}
+	if opts.Warmup == 0 {
+		opts.Warmup = DefaultWarmup
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Concurrency > opts.Iterations {
+		opts.Concurrency = opts.Iterations
+	}
+
+	for i, token := range opts.Tokens {
+		if _, err := jwtauth.ParseToken(ctx, token, cfg); err != nil {
+			return nil, fmt.Errorf("jwtauthbench: token %d does not validate: %w", i, err)
+		}
+	}
+	for i := 0; i < opts.Warmup; i++ {
+		jwtauth.ParseToken(ctx, opts.Tokens[i%len(opts.Tokens)], cfg)
+	}
+
+	report := &Report{
+		Name:        opts.Name,
+		Algorithms:  cfg.AvailableAlgorithms(),
+		Concurrency: opts.Concurrency,
+		GoVersion:   runtime.Version(),
+		GOOS:        runtime.GOOS,
+		GOARCH:      runtime.GOARCH,
+		NumCPU:      runtime.NumCPU(),
+		StartedAt:   time.Now().UTC(),
+	}
+
+	// Each worker records into its own slice so measuring does not contend
+	latencies := make([][]time.Duration, opts.Concurrency)
+	errs := make([]int, opts.Concurrency)
+	var wg sync.WaitGroup
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for w := 0; w < opts.Concurrency; w++ {
+		n := opts.Iterations / opts.Concurrency
+		if w < opts.Iterations%opts.Concurrency {
+			n++
+		}
+		latencies[w] = make([]time.Duration, 0, n)
+		wg.Add(1)
+		go func(w, n int) {
+			defer wg.Done()
+			for i := 0; i < n && ctx.Err() == nil; i++ {
+				token := opts.Tokens[(w+i*opts.Concurrency)%len(opts.Tokens)]
+				t0 := time.Now()
+				_, err := jwtauth.ParseToken(ctx, token, cfg)
+				latencies[w] = append(latencies[w], time.Since(t0))
+				if err != nil {
+					errs[w]++
+				}
+			}
+		}(w, n)
+	}
+	wg.Wait()
+	report.Duration = time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	var all []time.Duration
+	for w := range latencies {
+		all = append(all, latencies[w]...)
+		report.Errors += errs[w]
+	}
+	if len(all) == 0 {
+		return nil, fmt.Errorf("jwtauthbench: %w", ctx.Err())
+	}
+	report.Iterations = len(all)
+	report.Latency = summarize(all)
+	report.OpsPerSec = float64(len(all)) / report.Duration.Seconds()
+	report.AllocsPerOp = float64(after.Mallocs-before.Mallocs) / float64(len(all))
+	report.BytesPerOp = float64(after.TotalAlloc-before.TotalAlloc) / float64(len(all))
+	return report, nil
+}
+
+// summarize computes latency statistics, sorting samples in place
+func summarize(samples []time.Duration) Latency {
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	var total time.Duration
+	for _, d := range samples {
+		total += d
+	}
+	return Latency{
+		Min:  samples[0],
+		Mean: total / time.Duration(len(samples)),
+		P50:  percentile(samples, 0.50),
+		P90:  percentile(samples, 0.90),
+		P99:  percentile(samples, 0.99),
+		P999: percentile(samples, 0.999),
+		Max:  samples[len(samples)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted samples
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.999999) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// Check returns a description of every target the report misses, or nil
+// when all are met
+func (r *Report) Check(t Targets) []string {
+	var failures []string
+	if t.P50 > 0 && r.Latency.P50 > t.P50 {
+		failures = append(failures, fmt.Sprintf("p50 %v exceeds %v", r.Latency.P50, t.P50))
+	}
+	if t.P99 > 0 && r.Latency.P99 > t.P99 {
+		failures = append(failures, fmt.Sprintf("p99 %v exceeds %v", r.Latency.P99, t.P99))
+	}
+	if t.AllocsPerOp > 0 && r.AllocsPerOp > t.AllocsPerOp {
+		failures = append(failures, fmt.Sprintf("%.1f allocs/op exceeds %.1f", r.AllocsPerOp, t.AllocsPerOp))
+	}
+	if r.Errors > t.MaxErrors {
+		failures = append(failures, fmt.Sprintf("%d validation errors exceed %d", r.Errors, t.MaxErrors))
+	}
+	return failures
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
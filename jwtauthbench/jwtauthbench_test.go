@@ -0,0 +1,86 @@
+package jwtauthbench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testSecret = []byte("test-secret-key-min-32-bytes-long!!")
+
+// newTestConfig returns an HS256 config and a valid token for it
+func newTestConfig(t *testing.T) (*jwtauth.Config, string) {
+	t.Helper()
+	cfg, err := jwtauth.NewConfig(jwtauth.WithHS256(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg, token
+}
+
+// TestRun tests the report of a concurrent run and its JSON encoding
+func TestRun(t *testing.T) {
+	cfg, token := newTestConfig(t)
+	report, err := Run(context.Background(), cfg, Options{Tokens: []string{token}, Iterations: 1001, Warmup: 10, Concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Iterations != 1001 || report.Concurrency != 4 || report.Errors != 0 {
+		t.Errorf("unexpected run summary %+v", report)
+	}
+	l := report.Latency
+	if l.Min <= 0 || l.Min > l.P50 || l.P50 > l.P90 || l.P90 > l.P99 || l.P99 > l.P999 || l.P999 > l.Max {
+		t.Errorf("expected ordered latency percentiles, got %+v", l)
+	}
+	if report.OpsPerSec <= 0 || report.AllocsPerOp <= 0 {
+		t.Errorf("expected throughput and allocations, got %v ops/s %v allocs/op", report.OpsPerSec, report.AllocsPerOp)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	latency, _ := decoded["latency"].(map[string]interface{})
+	if decoded["name"] != "ParseToken" || latency["p99_ns"] == nil || decoded["allocs_per_op"] == nil {
+		t.Errorf("unexpected JSON report %s", buf.String())
+	}
+}
+
+// TestRunInvalid tests rejected runs
+func TestRunInvalid(t *testing.T) {
+	cfg, token := newTestConfig(t)
+	if _, err := Run(context.Background(), cfg, Options{}); err == nil {
+		t.Error("expected error without tokens")
+	}
+	if _, err := Run(context.Background(), cfg, Options{Tokens: []string{token, "not-a-token"}}); err == nil {
+		t.Error("expected error for an invalid token")
+	}
+	if _, err := Run(context.Background(), nil, Options{Tokens: []string{token}}); err == nil {
+		t.Error("expected error without a config")
+	}
+}
+
+// TestReportCheck tests latency, allocation and error targets
+func TestReportCheck(t *testing.T) {
+	report := &Report{Latency: Latency{P50: 200 * time.Microsecond, P99: 2 * time.Millisecond}, AllocsPerOp: 40, Errors: 1}
+
+	if failures := report.Check(Targets{P50: time.Millisecond, P99: 5 * time.Millisecond, AllocsPerOp: 50, MaxErrors: 1}); failures != nil {
+		t.Errorf("expected targets to be met, got %v", failures)
+	}
+	if failures := report.Check(Targets{P99: time.Millisecond, AllocsPerOp: 30}); len(failures) != 3 {
+		t.Errorf("expected p99, allocation and error failures, got %v", failures)
+	}
+}
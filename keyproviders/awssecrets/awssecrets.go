@@ -0,0 +1,373 @@
+// Package awssecrets provides a jwtauth.KeyProvider and jwtauth.SecretFunc
+// backed by AWS Secrets Manager or SSM Parameter Store, for HMAC secrets and
+// PEM public keys stored as secrets or SecureString parameters.
+//
+// The provider talks to AWS through the small SecretsManagerClient and
+// ParameterClient interfaces so the AWS SDK stays out of the middleware's
+// dependency graph. An adapter for github.com/aws/aws-sdk-go-v2:
+//
+//	type smClient struct{ c *secretsmanager.Client }
+//
+//	func (s smClient) GetSecretValue(ctx context.Context, id, stage string) (awssecrets.Version, error) {
+//		out, err := s.c.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: &id, VersionStage: &stage})
+//		var notFound *smtypes.ResourceNotFoundException
+//		if errors.As(err, &notFound) {
+//			return awssecrets.Version{}, jwtauth.ErrKeyNotFound
+//		}
+//		if err != nil {
+//			return awssecrets.Version{}, err
+//		}
+//		value := out.SecretBinary
+//		if out.SecretString != nil {
+//			value = []byte(*out.SecretString)
+//		}
+//		return awssecrets.Version{ID: *out.VersionId, Value: value}, nil
+//	}
+//
+//	type ssmClient struct{ c *ssm.Client }
+//
+//	func (s ssmClient) GetParameter(ctx context.Context, name string) (awssecrets.Version, error) {
+//		out, err := s.c.GetParameter(ctx, &ssm.GetParameterInput{Name: &name, WithDecryption: aws.Bool(true)})
+//		var notFound *ssmtypes.ParameterVersionNotFound
+//		if errors.As(err, &notFound) {
+//			return awssecrets.Version{}, jwtauth.ErrKeyNotFound
+//		}
+//		if err != nil {
+//			return awssecrets.Version{}, err
+//		}
+//		return awssecrets.Version{ID: strconv.FormatInt(out.Parameter.Version, 10), Value: []byte(*out.Parameter.Value)}, nil
+//	}
+//
+// Both the current and the previous version are trusted, so tokens signed
+// just before a rotation keep validating. Tokens select a version with their
+// kid header (the Secrets Manager version ID or the parameter version);
+// tokens without a kid use the current version.
+package awssecrets
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+)
+
+// Version is one version of a secret or parameter
+type Version struct {
+	ID    string // Secrets Manager version ID or parameter version number
+	Value []byte // SecretString/SecretBinary or the decrypted parameter value
+}
+
+// SecretsManagerClient is the subset of the Secrets Manager API used by the
+// provider
+type SecretsManagerClient interface {
+	// GetSecretValue returns the version of secretID carrying versionStage
+	// ("AWSCURRENT" or "AWSPREVIOUS"). Missing versions must be reported
+	// with an error wrapping jwtauth.ErrKeyNotFound.
+	GetSecretValue(ctx context.Context, secretID, versionStage string) (Version, error)
+}
+
+// ParameterClient is the subset of the SSM API used by the provider
+type ParameterClient interface {
+	// GetParameter returns the decrypted value of name, which may carry a
+	// ":<version>" selector. Missing versions must be reported with an
+	// error wrapping jwtauth.ErrKeyNotFound.
+	GetParameter(ctx context.Context, name string) (Version, error)
+}
+
+// Option configures a Provider
+type Option func(*Provider)
+
+// WithTTL sets how long fetched versions are used before they are reloaded
+// in the background (default 5 minutes)
+func WithTTL(ttl time.Duration) Option {
+	return func(p *Provider) {
+		p.ttl = ttl
+	}
+}
+
+// WithMinRefreshInterval limits how often a token with an unknown kid can
+// force a synchronous reload (default 30 seconds)
+func WithMinRefreshInterval(interval time.Duration) Option {
+	return func(p *Provider) {
+		p.minRefresh = interval
+	}
+}
+
+// Provider resolves verification keys from one secret or parameter
+type Provider struct {
+	name       string
+	alg        string
+	fetch      func(ctx context.Context) ([]Version, error) // Current version first
+	ttl        time.Duration
+	minRefresh time.Duration
+
+	keys       atomic.Pointer[keySet]
+	refreshing atomic.Bool
+	mu         sync.Mutex // Serializes synchronous reloads
+}
+
+// keySet is the parsed current and previous versions
+type keySet struct {
+	versions []Version
+	keys     []interface{} // Parsed key for each version
+	loaded   time.Time
+}
+
+// NewSecretsManager creates a provider for the secret secretID (name or ARN)
+// and loads its AWSCURRENT and AWSPREVIOUS versions. For HS* algorithms the
+// secret value is the HMAC secret; otherwise it must be a PEM public key.
+func NewSecretsManager(ctx context.Context, client SecretsManagerClient, secretID, alg string, opts ...Option) (*Provider, error) {
+	if client == nil {
+		return nil, fmt.Errorf("awssecrets: client cannot be nil")
+	}
+	fetch := func(ctx context.Context) ([]Version, error) {
+		var versions []Version
+		for _, stage := range []string{"AWSCURRENT", "AWSPREVIOUS"} {
+			v, err := client.GetSecretValue(ctx, secretID, stage)
+			if errors.Is(err, jwtauth.ErrKeyNotFound) && stage == "AWSPREVIOUS" {
+				break // Secrets that were never rotated have no previous version
+			}
+			if err != nil {
+				return nil, fmt.Errorf("awssecrets: failed to fetch %s of %s: %w", stage, secretID, err)
+			}
+			versions = append(versions, v)
+		}
+		return versions, nil
+	}
+	return newProvider(ctx, secretID, alg, fetch, opts)
+}
+
+// NewParameterStore creates a provider for the SSM parameter name and loads
+// its latest and preceding versions
+func NewParameterStore(ctx context.Context, client ParameterClient, name, alg string, opts ...Option) (*Provider, error) {
+	if client == nil {
+		return nil, fmt.Errorf("awssecrets: client cannot be nil")
+	}
+	fetch := func(ctx context.Context) ([]Version, error) {
+		current, err := client.GetParameter(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("awssecrets: failed to fetch parameter %s: %w", name, err)
+		}
+		versions := []Version{current}
+
+		n, err := strconv.ParseInt(current.ID, 10, 64)
+		if err != nil || n <= 1 {
+			return versions, nil
+		}
+		previous, err := client.GetParameter(ctx, name+":"+strconv.FormatInt(n-1, 10))
+		if errors.Is(err, jwtauth.ErrKeyNotFound) {
+			return versions, nil // Older versions age out of the parameter history
+		}
+		if err != nil {
+			return nil, fmt.Errorf("awssecrets: failed to fetch parameter %s version %d: %w", name, n-1, err)
+		}
+		return append(versions, previous), nil
+	}
+	return newProvider(ctx, name, alg, fetch, opts)
+}
+
+// newProvider applies options and performs the initial load
+func newProvider(ctx context.Context, name, alg string, fetch func(context.Context) ([]Version, error), opts []Option) (*Provider, error) {
+	if name == "" {
+		return nil, fmt.Errorf("awssecrets: secret name is required")
+	}
+	if alg == "" || alg == "none" {
+		return nil, fmt.Errorf("awssecrets: unsupported algorithm %q", alg)
+	}
+
+	p := &Provider{name: name, alg: alg, fetch: fetch, ttl: 5 * time.Minute, minRefresh: 30 * time.Second}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if err := p.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Refresh reloads the versions now. Call it when a rotation completes, or
+// let HandleEvent do so for rotation events.
+func (p *Provider) Refresh(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.refreshLocked(ctx)
+}
+
+// refreshLocked fetches and parses the versions; the previous key set is
+// kept when anything fails
+func (p *Provider) refreshLocked(ctx context.Context) error {
+	versions, err := p.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	set := &keySet{versions: versions, loaded: time.Now()}
+	for _, v := range versions {
+		key, err := p.parseKey(v.Value)
+		if err != nil {
+			return fmt.Errorf("awssecrets: version %s of %s: %w", v.ID, p.name, err)
+		}
+		set.keys = append(set.keys, key)
+	}
+	p.keys.Store(set)
+	return nil
+}
+
+// parseKey converts a version value into a verification key for p.alg
+func (p *Provider) parseKey(value []byte) (interface{}, error) {
+	if strings.HasPrefix(p.alg, "HS") {
+		if len(value) < 32 {
+			return nil, fmt.Errorf("HMAC secret must be at least 32 bytes, got %d", len(value))
+		}
+		return value, nil
+	}
+
+	block, _ := pem.Decode(value)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("failed to parse public key")
+}
+
+// KeySource implements jwtauth.KeySourcer
+func (p *Provider) KeySource() string { return "awssecrets" }
+
+// VerificationKey implements jwtauth.KeyProvider. An unknown kid triggers a
+// synchronous reload, at most once per WithMinRefreshInterval, in case the
+// secret was rotated since the last load.
+func (p *Provider) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	if alg != p.alg {
+		return nil, fmt.Errorf("awssecrets: %s is configured for %s, not %s", p.name, p.alg, alg)
+	}
+
+	set := p.keys.Load()
+	if time.Since(set.loaded) > p.ttl && p.refreshing.CompareAndSwap(false, true) {
+		go func() {
+			defer p.refreshing.Store(false)
+			// Errors keep the previous versions; the next request retries
+			_ = p.Refresh(context.WithoutCancel(ctx))
+		}()
+	}
+	if key, ok := set.find(kid); ok {
+		return key, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if set = p.keys.Load(); time.Since(set.loaded) >= p.minRefresh {
+		if err := p.refreshLocked(ctx); err != nil {
+			return nil, err
+		}
+		set = p.keys.Load()
+	}
+	if key, ok := set.find(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("awssecrets: no version %q of %s: %w", kid, p.name, jwtauth.ErrKeyNotFound)
+}
+
+// find returns the key for kid, or the current key when kid is empty
+func (s *keySet) find(kid string) (interface{}, bool) {
+	if kid == "" {
+		return s.keys[0], true
+	}
+	for i, v := range s.versions {
+		if v.ID == kid {
+			return s.keys[i], true
+		}
+	}
+	return nil, false
+}
+
+// Current returns the current version, e.g. to sign HMAC tokens with its ID
+// as the kid header
+func (p *Provider) Current() Version {
+	return p.keys.Load().versions[0]
+}
+
+// SecretFunc returns the current HMAC secret for
+// jwtauth.WithHS256ProviderRefresh, for deployments that do not set kid
+func (p *Provider) SecretFunc() jwtauth.SecretFunc {
+	return func(ctx context.Context) ([]byte, error) {
+		if err := p.Refresh(ctx); err != nil {
+			return nil, err
+		}
+		return p.Current().Value, nil
+	}
+}
+
+// rotationEvent is the subset of EventBridge events for Secrets Manager API
+// calls (via CloudTrail) and Parameter Store changes
+type rotationEvent struct {
+	Source string `json:"source"`
+	Detail struct {
+		EventName         string `json:"eventName"`
+		RequestParameters struct {
+			SecretID string `json:"secretId"`
+		} `json:"requestParameters"`
+		AdditionalEventData struct {
+			SecretID string `json:"SecretId"`
+		} `json:"additionalEventData"`
+		Name      string `json:"name"`
+		Operation string `json:"operation"`
+	} `json:"detail"`
+}
+
+// HandleEvent reloads the versions when event, an EventBridge event JSON
+// delivered e.g. through SQS or Lambda, reports a rotation or update of
+// this provider's secret or parameter. Unrelated events are ignored.
+func (p *Provider) HandleEvent(ctx context.Context, event []byte) error {
+	var e rotationEvent
+	if err := json.Unmarshal(event, &e); err != nil {
+		return fmt.Errorf("awssecrets: invalid event: %w", err)
+	}
+
+	switch e.Source {
+	case "aws.secretsmanager":
+		switch e.Detail.EventName {
+		case "RotationSucceeded", "PutSecretValue", "UpdateSecretVersionStage":
+		default:
+			return nil
+		}
+		id := e.Detail.AdditionalEventData.SecretID
+		if id == "" {
+			id = e.Detail.RequestParameters.SecretID
+		}
+		if !p.matchesSecret(id) {
+			return nil
+		}
+	case "aws.ssm":
+		if e.Detail.Name != p.name || e.Detail.Operation == "Delete" {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return p.Refresh(ctx)
+}
+
+// matchesSecret reports whether a secret ID from an event names this
+// provider's secret. Events carry ARNs, which end in the secret name plus a
+// "-" and six random characters.
+func (p *Provider) matchesSecret(id string) bool {
+	if id == p.name {
+		return true
+	}
+	_, name, ok := strings.Cut(id, ":secret:")
+	return ok && len(name) == len(p.name)+7 && strings.HasPrefix(name, p.name+"-")
+}
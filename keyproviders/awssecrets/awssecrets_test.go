@@ -0,0 +1,242 @@
+package awssecrets
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeSecretsManager serves versions by staging label
+type fakeSecretsManager struct {
+	mu     sync.Mutex
+	stages map[string]Version
+	calls  int
+}
+
+func (f *fakeSecretsManager) GetSecretValue(ctx context.Context, secretID, stage string) (Version, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if secretID != "prod/jwt" {
+		return Version{}, errors.New("access denied")
+	}
+	v, ok := f.stages[stage]
+	if !ok {
+		return Version{}, jwtauth.ErrKeyNotFound
+	}
+	return v, nil
+}
+
+// rotate makes next the current version
+func (f *fakeSecretsManager) rotate(next Version) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stages["AWSPREVIOUS"] = f.stages["AWSCURRENT"]
+	f.stages["AWSCURRENT"] = next
+}
+
+func (f *fakeSecretsManager) callCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// signHS256 signs a token with an optional kid header
+func signHS256(t *testing.T, secret []byte, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// validate validates a token through a jwtauth config using p
+func validate(t *testing.T, p *Provider, alg, token string) error {
+	t.Helper()
+	cfg, err := jwtauth.NewConfig(jwtauth.WithKeyProvider(alg, p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = jwtauth.ParseToken(context.Background(), token, cfg)
+	return err
+}
+
+// TestSecretsManagerProvider tests current/previous versions, kid selection
+// and rotation handling
+func TestSecretsManagerProvider(t *testing.T) {
+	v1 := Version{ID: "v1", Value: []byte("first-secret-key-min-32-bytes-long!!")}
+	v2 := Version{ID: "v2", Value: []byte("second-secret-key-min-32-bytes-long!")}
+	v3 := Version{ID: "v3", Value: []byte("third-secret-key-min-32-bytes-long!!")}
+	client := &fakeSecretsManager{stages: map[string]Version{"AWSCURRENT": v2, "AWSPREVIOUS": v1}}
+
+	ctx := context.Background()
+	p, err := NewSecretsManager(ctx, client, "prod/jwt", "HS256", WithMinRefreshInterval(time.Hour))
+	if err != nil {
+		t.Fatalf("NewSecretsManager failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"current by kid", signHS256(t, v2.Value, "v2"), false},
+		{"previous by kid", signHS256(t, v1.Value, "v1"), false},
+		{"no kid uses current", signHS256(t, v2.Value, ""), false},
+		{"no kid signed with previous", signHS256(t, v1.Value, ""), true},
+		{"unknown kid", signHS256(t, v3.Value, "v3"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validate(t, p, "HS256", tt.token); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// Unrelated events are ignored; rotation events for the secret's ARN reload it
+	client.rotate(v3)
+	calls := client.callCount()
+	other := `{"source":"aws.secretsmanager","detail":{"eventName":"RotationSucceeded","additionalEventData":{"SecretId":"arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/other-AbCdEf"}}}`
+	if err := p.HandleEvent(ctx, []byte(other)); err != nil || client.callCount() != calls {
+		t.Errorf("Expected unrelated event to be ignored, got %v after %d calls", err, client.callCount()-calls)
+	}
+	rotated := `{"source":"aws.secretsmanager","detail":{"eventName":"RotationSucceeded","additionalEventData":{"SecretId":"arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/jwt-AbCdEf"}}}`
+	if err := p.HandleEvent(ctx, []byte(rotated)); err != nil {
+		t.Fatalf("HandleEvent failed: %v", err)
+	}
+	if err := validate(t, p, "HS256", signHS256(t, v3.Value, "v3")); err != nil {
+		t.Errorf("Expected rotated version to validate, got %v", err)
+	}
+	if err := validate(t, p, "HS256", signHS256(t, v2.Value, "v2")); err != nil {
+		t.Errorf("Expected previous version to keep validating, got %v", err)
+	}
+	if p.Current().ID != "v3" {
+		t.Errorf("Expected current version v3, got %s", p.Current().ID)
+	}
+
+	t.Run("unknown kid reloads", func(t *testing.T) {
+		p, err := NewSecretsManager(ctx, client, "prod/jwt", "HS256", WithMinRefreshInterval(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v4 := Version{ID: "v4", Value: []byte("fourth-secret-key-min-32-bytes-long!")}
+		client.rotate(v4)
+		if err := validate(t, p, "HS256", signHS256(t, v4.Value, "v4")); err != nil {
+			t.Errorf("Expected unknown kid to trigger a reload, got %v", err)
+		}
+	})
+
+	t.Run("never rotated", func(t *testing.T) {
+		client := &fakeSecretsManager{stages: map[string]Version{"AWSCURRENT": v1}}
+		p, err := NewSecretsManager(ctx, client, "prod/jwt", "HS256")
+		if err != nil {
+			t.Fatalf("Expected a secret without AWSPREVIOUS to load, got %v", err)
+		}
+		cfg, err := jwtauth.NewConfig(jwtauth.WithHS256Provider(p.SecretFunc()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := jwtauth.ParseToken(ctx, signHS256(t, v1.Value, ""), cfg); err != nil {
+			t.Errorf("Expected SecretFunc secret to validate, got %v", err)
+		}
+	})
+
+	for _, tt := range []struct {
+		name   string
+		client SecretsManagerClient
+		id     string
+		want   string
+	}{
+		{"fetch error", client, "prod/other", "access denied"},
+		{"short secret", &fakeSecretsManager{stages: map[string]Version{"AWSCURRENT": {ID: "v1", Value: []byte("short")}}}, "prod/jwt", "at least 32 bytes"},
+		{"nil client", nil, "prod/jwt", "client cannot be nil"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSecretsManager(ctx, tt.client, tt.id, "HS256"); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// fakeParameterStore serves numbered parameter versions
+type fakeParameterStore struct {
+	values []string // values[i] is version i+1
+}
+
+func (f *fakeParameterStore) GetParameter(ctx context.Context, name string) (Version, error) {
+	name, selector, _ := strings.Cut(name, ":")
+	if name != "/app/jwt-public-key" {
+		return Version{}, errors.New("parameter not found")
+	}
+	n := len(f.values)
+	if selector != "" {
+		n = int(selector[0] - '0')
+	}
+	return Version{ID: string(rune('0' + n)), Value: []byte(f.values[n-1])}, nil
+}
+
+// TestParameterStoreProvider tests PEM public keys from SSM parameters
+func TestParameterStoreProvider(t *testing.T) {
+	ctx := context.Background()
+	keys := make([]*ecdsa.PrivateKey, 3)
+	store := &fakeParameterStore{}
+	for i := range keys {
+		keys[i], _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		der, _ := x509.MarshalPKIXPublicKey(&keys[i].PublicKey)
+		store.values = append(store.values, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	}
+
+	p, err := NewParameterStore(ctx, store, "/app/jwt-public-key", "ES256")
+	if err != nil {
+		t.Fatalf("NewParameterStore failed: %v", err)
+	}
+
+	sign := func(key *ecdsa.PrivateKey, kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "svc", "exp": time.Now().Add(time.Hour).Unix()})
+		token.Header["kid"] = kid
+		signed, _ := token.SignedString(key)
+		return signed
+	}
+	if err := validate(t, p, "ES256", sign(keys[2], "3")); err != nil {
+		t.Errorf("Expected latest version to validate, got %v", err)
+	}
+	if err := validate(t, p, "ES256", sign(keys[1], "2")); err != nil {
+		t.Errorf("Expected preceding version to validate, got %v", err)
+	}
+	if err := validate(t, p, "ES256", sign(keys[0], "1")); err == nil {
+		t.Error("Expected older versions to be rejected")
+	}
+
+	// Parameter Store change events reload the parameter
+	der, _ := x509.MarshalPKIXPublicKey(&keys[0].PublicKey)
+	store.values = append(store.values, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	event := `{"source":"aws.ssm","detail-type":"Parameter Store Change","detail":{"name":"/app/jwt-public-key","operation":"Update"}}`
+	if err := p.HandleEvent(ctx, []byte(event)); err != nil {
+		t.Fatal(err)
+	}
+	if p.Current().ID != "4" {
+		t.Errorf("Expected version 4 after change event, got %s", p.Current().ID)
+	}
+
+	if _, err := p.VerificationKey(ctx, "RS256", "4"); err == nil {
+		t.Error("Expected other algorithms to be rejected")
+	}
+}
@@ -0,0 +1,198 @@
+// Package azurekv provides an Azure Key Vault backed jwtauth.KeyProvider and
+// jwtauth.Signer for keys that are never exported from the vault.
+//
+// The provider talks to Key Vault through the small Client interface so the
+// Azure SDK stays out of the middleware's dependency graph. An adapter for
+// github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys:
+//
+//	type vaultClient struct{ c *azkeys.Client } // created for the vault URL
+//
+//	func (v vaultClient) GetKey(ctx context.Context, name, version string) ([]byte, error) {
+//		resp, err := v.c.GetKey(ctx, name, version, nil)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return json.Marshal(resp.Key)
+//	}
+//
+//	func (v vaultClient) Sign(ctx context.Context, name, version, alg string, digest []byte) ([]byte, error) {
+//		algorithm := azkeys.SignatureAlgorithm(alg)
+//		resp, err := v.c.Sign(ctx, name, version, azkeys.SignParameters{Algorithm: &algorithm, Value: digest}, nil)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return resp.Result, nil
+//	}
+//
+// Certificates stored in Key Vault are backed by a key with the same name,
+// so certificate-based deployments use the certificate name as the key name.
+package azurekv
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+)
+
+// Client is the subset of the Key Vault keys API used by the provider
+type Client interface {
+	// GetKey returns the public JSON Web Key for a key version
+	// (empty version selects the current version). Errors for versions that
+	// do not exist should wrap jwtauth.ErrKeyNotFound, and errors reaching
+	// the vault jwtauth.ErrKeyProviderUnavailable.
+	GetKey(ctx context.Context, name, version string) ([]byte, error)
+	// Sign signs a precomputed digest with Key Vault's sign operation. For
+	// ECDSA keys Key Vault already returns the JWS r||s signature form.
+	Sign(ctx context.Context, name, version, alg string, digest []byte) ([]byte, error)
+}
+
+// Option configures a Provider
+type Option func(*Provider)
+
+// WithVersion pins the provider to a specific key version for signing.
+// By default the current version is used.
+func WithVersion(version string) Option {
+	return func(p *Provider) {
+		p.version = version
+	}
+}
+
+// WithCacheTTL sets how long fetched public keys are cached (default 10 minutes)
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(p *Provider) {
+		p.cacheTTL = ttl
+	}
+}
+
+// Provider is an Azure Key Vault backed key provider and signer
+type Provider struct {
+	client   Client
+	vaultURL string
+	name     string
+	version  string
+	alg      string
+	hash     crypto.Hash
+	cacheTTL time.Duration
+	kid      string
+	public   crypto.PublicKey
+	keys     jwtauth.KeyProvider
+}
+
+// New creates a provider for the key name in the vault at vaultURL and
+// fetches its current public key
+func New(ctx context.Context, client Client, vaultURL, name, alg string, opts ...Option) (*Provider, error) {
+	if client == nil {
+		return nil, fmt.Errorf("azurekv: client cannot be nil")
+	}
+	if vaultURL == "" || name == "" {
+		return nil, fmt.Errorf("azurekv: vault URL and key name are required")
+	}
+
+	p := &Provider{
+		client:   client,
+		vaultURL: strings.TrimRight(vaultURL, "/"),
+		name:     name,
+		alg:      alg,
+		cacheTTL: 10 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		p.hash = crypto.SHA256
+	case "RS384", "PS384", "ES384":
+		p.hash = crypto.SHA384
+	case "RS512", "PS512", "ES512":
+		p.hash = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("azurekv: unsupported algorithm %s", alg)
+	}
+
+	jwk, err := p.fetch(ctx, p.version)
+	if err != nil {
+		return nil, err
+	}
+	p.kid = jwk.KeyID
+	p.public = jwk.Key
+
+	// Version-specific lookups are cached so rotated keys are picked up
+	// after the TTL while tokens signed by older versions still verify
+	p.keys = jwtauth.CachedKeyProvider(jwtauth.KeyProviderFunc(p.resolve), p.cacheTTL)
+
+	return p, nil
+}
+
+// fetch retrieves and parses a key version from the vault
+func (p *Provider) fetch(ctx context.Context, version string) (*jwtauth.JSONWebKey, error) {
+	data, err := p.client.GetKey(ctx, p.name, version)
+	if err != nil {
+		return nil, fmt.Errorf("azurekv: failed to fetch key %s from %s: %w", p.name, p.vaultURL, err)
+	}
+	jwk, err := jwtauth.ParseJWK(data)
+	if err != nil {
+		return nil, fmt.Errorf("azurekv: %w", err)
+	}
+	return jwk, nil
+}
+
+// resolve maps a token kid (the Key Vault key identifier) to a key version
+func (p *Provider) resolve(ctx context.Context, alg, kid string) (interface{}, error) {
+	version := p.version
+	if kid != "" {
+		// Key Vault kids look like https://{vault}/keys/{name}/{version}
+		prefix := p.vaultURL + "/keys/" + p.name + "/"
+		if !strings.HasPrefix(kid, prefix) {
+			return nil, fmt.Errorf("azurekv: key id %q does not belong to key %s: %w", kid, p.name, jwtauth.ErrKeyNotFound)
+		}
+		version = strings.TrimPrefix(kid, prefix)
+	}
+
+	jwk, err := p.fetch(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	return jwk.Key, nil
+}
+
+// KeySource implements jwtauth.KeySourcer
+func (p *Provider) KeySource() string { return "azurekv" }
+
+// VerificationKey implements jwtauth.KeyProvider
+func (p *Provider) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	if alg != p.alg {
+		return nil, fmt.Errorf("azurekv: key %s is configured for %s, not %s: %w", p.name, p.alg, alg, jwtauth.ErrKeyNotFound)
+	}
+	return p.keys.VerificationKey(ctx, alg, kid)
+}
+
+// Algorithm implements jwtauth.Signer
+func (p *Provider) Algorithm() string { return p.alg }
+
+// KeyID implements jwtauth.Signer; it is the full Key Vault key identifier
+func (p *Provider) KeyID() string { return p.kid }
+
+// Public implements jwtauth.Signer
+func (p *Provider) Public() crypto.PublicKey { return p.public }
+
+// Sign implements jwtauth.Signer using Key Vault's sign operation
+func (p *Provider) Sign(ctx context.Context, signingInput []byte) ([]byte, error) {
+	h := p.hash.New()
+	h.Write(signingInput)
+
+	version := p.version
+	if version == "" {
+		version = p.kid[strings.LastIndex(p.kid, "/")+1:]
+	}
+
+	sig, err := p.client.Sign(ctx, p.name, version, p.alg, h.Sum(nil))
+	if err != nil {
+		return nil, fmt.Errorf("azurekv: sign failed: %w", err)
+	}
+	return sig, nil
+}
@@ -0,0 +1,139 @@
+package azurekv
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testVault = "https://myvault.vault.azure.net"
+
+// fakeVault emulates Key Vault with versioned EC keys
+type fakeVault struct {
+	versions map[string]*ecdsa.PrivateKey
+	current  string
+}
+
+func (f *fakeVault) GetKey(ctx context.Context, name, version string) ([]byte, error) {
+	if version == "" {
+		version = f.current
+	}
+	key, ok := f.versions[version]
+	if !ok {
+		return nil, errors.New("KeyNotFound")
+	}
+	return json.Marshal(map[string]string{
+		"kid": testVault + "/keys/" + name + "/" + version,
+		"kty": "EC-HSM",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	})
+}
+
+func (f *fakeVault) Sign(ctx context.Context, name, version, alg string, digest []byte) ([]byte, error) {
+	key, ok := f.versions[version]
+	if !ok {
+		return nil, errors.New("KeyNotFound")
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+	if err != nil {
+		return nil, err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return sig, nil
+}
+
+func newTestKey() *ecdsa.PrivateKey {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	return key
+}
+
+func verify(t *testing.T, provider *Provider, token string) error {
+	t.Helper()
+	_, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return provider.VerificationKey(context.Background(), token.Method.Alg(), kid)
+	})
+	return err
+}
+
+// TestProviderSignAndVerifyAcrossRotation tests signing with the current
+// version and verifying tokens signed by older versions after rotation
+func TestProviderSignAndVerifyAcrossRotation(t *testing.T) {
+	vault := &fakeVault{versions: map[string]*ecdsa.PrivateKey{"v1": newTestKey()}, current: "v1"}
+	ctx := context.Background()
+
+	oldProvider, err := New(ctx, vault, testVault+"/", "jwt-key", "ES256")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if oldProvider.KeyID() != testVault+"/keys/jwt-key/v1" {
+		t.Errorf("Unexpected kid %s", oldProvider.KeyID())
+	}
+
+	claims := map[string]interface{}{"sub": "svc", "exp": time.Now().Add(time.Hour).Unix()}
+	oldToken, err := jwtauth.SignToken(ctx, oldProvider, claims)
+	if err != nil {
+		t.Fatalf("SignToken failed: %v", err)
+	}
+
+	// Rotate to v2
+	vault.versions["v2"] = newTestKey()
+	vault.current = "v2"
+	newProvider, err := New(ctx, vault, testVault, "jwt-key", "ES256", WithCacheTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	newToken, _ := jwtauth.SignToken(ctx, newProvider, claims)
+
+	if err := verify(t, newProvider, oldToken); err != nil {
+		t.Errorf("Expected v1 token to verify after rotation, got %v", err)
+	}
+	if err := verify(t, newProvider, newToken); err != nil {
+		t.Errorf("Expected v2 token to verify, got %v", err)
+	}
+}
+
+// TestProviderRejectsForeignKid tests that kids from other keys are rejected
+func TestProviderRejectsForeignKid(t *testing.T) {
+	vault := &fakeVault{versions: map[string]*ecdsa.PrivateKey{"v1": newTestKey()}, current: "v1"}
+	provider, err := New(context.Background(), vault, testVault, "jwt-key", "ES256")
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if _, err := provider.VerificationKey(context.Background(), "ES256", "https://evil.vault.azure.net/keys/jwt-key/v1"); !errors.Is(err, jwtauth.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound for kid from another vault, got %v", err)
+	}
+	if _, err := provider.VerificationKey(context.Background(), "RS256", ""); !errors.Is(err, jwtauth.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound for mismatched algorithm, got %v", err)
+	}
+}
+
+// TestNewErrors tests provider construction failures
+func TestNewErrors(t *testing.T) {
+	vault := &fakeVault{versions: map[string]*ecdsa.PrivateKey{}, current: "v1"}
+	ctx := context.Background()
+
+	if _, err := New(ctx, vault, testVault, "jwt-key", "ES256"); err == nil {
+		t.Error("Expected error when key is missing")
+	}
+	if _, err := New(ctx, vault, testVault, "jwt-key", "HS256"); err == nil {
+		t.Error("Expected error for unsupported algorithm")
+	}
+	if _, err := New(ctx, nil, testVault, "jwt-key", "ES256"); err == nil {
+		t.Error("Expected error for nil client")
+	}
+}
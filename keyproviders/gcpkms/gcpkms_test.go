@@ -0,0 +1,114 @@
+package gcpkms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testKeyName = "projects/p/locations/global/keyRings/r/cryptoKeys/jwt/cryptoKeyVersions/1"
+
+// fakeKMS emulates Cloud KMS with an in-memory key
+type fakeKMS struct {
+	key        crypto.Signer
+	fetchCalls int
+}
+
+func (f *fakeKMS) GetPublicKey(ctx context.Context, keyName string) (string, error) {
+	f.fetchCalls++
+	if keyName != testKeyName {
+		return "", errors.New("not found")
+	}
+	der, err := x509.MarshalPKIXPublicKey(f.key.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+func (f *fakeKMS) AsymmetricSign(ctx context.Context, keyName string, hash crypto.Hash, digest []byte) ([]byte, error) {
+	return f.key.Sign(rand.Reader, digest, hash)
+}
+
+// TestProviderSignAndVerify tests KMS signing for RSA and ECDSA key versions
+func TestProviderSignAndVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	tests := []struct {
+		alg string
+		key crypto.Signer
+	}{
+		{"RS256", rsaKey},
+		{"ES256", ecKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			kms := &fakeKMS{key: tt.key}
+			provider, err := New(context.Background(), kms, testKeyName, tt.alg, WithKeyID("kms-1"))
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+
+			token, err := jwtauth.SignToken(context.Background(), provider, map[string]interface{}{
+				"sub": "svc",
+				"exp": time.Now().Add(time.Hour).Unix(),
+			})
+			if err != nil {
+				t.Fatalf("SignToken failed: %v", err)
+			}
+
+			parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+				return provider.VerificationKey(context.Background(), token.Method.Alg(), token.Header["kid"].(string))
+			})
+			if err != nil || !parsed.Valid {
+				t.Fatalf("Expected token to verify, got %v", err)
+			}
+
+			// Public key is fetched once and cached
+			provider.VerificationKey(context.Background(), tt.alg, "")
+			if kms.fetchCalls != 1 {
+				t.Errorf("Expected 1 public key fetch, got %d", kms.fetchCalls)
+			}
+
+			if _, err := provider.VerificationKey(context.Background(), tt.alg, "other"); !errors.Is(err, jwtauth.ErrKeyNotFound) {
+				t.Errorf("Expected ErrKeyNotFound for unknown kid, got %v", err)
+			}
+			if _, err := provider.VerificationKey(context.Background(), "HS256", ""); !errors.Is(err, jwtauth.ErrKeyNotFound) {
+				t.Errorf("Expected ErrKeyNotFound for mismatched algorithm, got %v", err)
+			}
+		})
+	}
+}
+
+// TestNewErrors tests provider construction failures
+func TestNewErrors(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	kms := &fakeKMS{key: ecKey}
+	ctx := context.Background()
+
+	if _, err := New(ctx, kms, testKeyName, "RS256"); err == nil {
+		t.Error("Expected error for ECDSA key with RS256")
+	}
+	if _, err := New(ctx, kms, testKeyName, "HS256"); err == nil {
+		t.Error("Expected error for unsupported algorithm")
+	}
+	if _, err := New(ctx, kms, "missing", "ES256"); err == nil {
+		t.Error("Expected error when key cannot be fetched")
+	}
+	if _, err := New(ctx, nil, testKeyName, "ES256"); err == nil {
+		t.Error("Expected error for nil client")
+	}
+}
@@ -0,0 +1,112 @@
+// Package pkcs11 provides an HSM-backed jwtauth.KeyProvider and jwtauth.Signer.
+//
+// The package does not link against a PKCS#11 library itself. Callers supply
+// an Opener that logs into the token and locates the key pair, typically with
+// github.com/ThalesIgnite/crypto11:
+//
+//	open := func(cfg pkcs11.Config) (crypto.Signer, io.Closer, error) {
+//		ctx, err := crypto11.Configure(&crypto11.Config{
+//			Path:       cfg.ModulePath,
+//			SlotNumber: &cfg.Slot,
+//			Pin:        cfg.PIN,
+//		})
+//		if err != nil {
+//			return nil, nil, err
+//		}
+//		signer, err := ctx.FindKeyPair(nil, []byte(cfg.KeyLabel))
+//		if err != nil || signer == nil {
+//			ctx.Close()
+//			return nil, nil, fmt.Errorf("key %q not found", cfg.KeyLabel)
+//		}
+//		return signer, ctx, nil
+//	}
+//
+// Private key material never leaves the HSM; only the public key is read to
+// verify tokens.
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+)
+
+// Config identifies the HSM key pair used for signing and verification
+type Config struct {
+	ModulePath string // Path to the vendor PKCS#11 module (.so/.dll)
+	Slot       int    // Token slot number
+	PIN        string // User PIN for the slot
+	KeyLabel   string // CKA_LABEL of the key pair
+	KeyID      string // "kid" header for produced tokens (optional)
+	Algorithm  string // JWS algorithm, e.g. "RS256", "PS256", "ES256"
+}
+
+// Opener opens a PKCS#11 session for cfg and returns the located key pair.
+// The returned io.Closer (may be nil) is closed by Provider.Close.
+type Opener func(cfg Config) (crypto.Signer, io.Closer, error)
+
+// Provider is a PKCS#11-backed key provider and signer
+type Provider struct {
+	jwtauth.Signer
+	cfg    Config
+	closer io.Closer
+}
+
+// New opens the HSM key pair described by cfg
+func New(cfg Config, open Opener) (*Provider, error) {
+	if open == nil {
+		return nil, fmt.Errorf("pkcs11: opener cannot be nil")
+	}
+	if cfg.KeyLabel == "" {
+		return nil, fmt.Errorf("pkcs11: key label is required")
+	}
+	if cfg.Algorithm == "" {
+		return nil, fmt.Errorf("pkcs11: algorithm is required")
+	}
+
+	key, closer, err := open(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11: failed to open key %q in slot %d: %w", cfg.KeyLabel, cfg.Slot, err)
+	}
+	if key == nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("pkcs11: key %q not found in slot %d", cfg.KeyLabel, cfg.Slot)
+	}
+
+	signer, err := jwtauth.NewCryptoSigner(cfg.Algorithm, cfg.KeyID, key)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("pkcs11: %w", err)
+	}
+
+	return &Provider{Signer: signer, cfg: cfg, closer: closer}, nil
+}
+
+// KeySource implements jwtauth.KeySourcer
+func (p *Provider) KeySource() string { return "pkcs11" }
+
+// VerificationKey implements jwtauth.KeyProvider
+func (p *Provider) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	if alg != p.cfg.Algorithm {
+		return nil, fmt.Errorf("pkcs11: key %q is configured for %s, not %s: %w", p.cfg.KeyLabel, p.cfg.Algorithm, alg, jwtauth.ErrKeyNotFound)
+	}
+	if kid != "" && p.cfg.KeyID != "" && kid != p.cfg.KeyID {
+		return nil, fmt.Errorf("pkcs11: unknown key id %q: %w", kid, jwtauth.ErrKeyNotFound)
+	}
+	return p.Public(), nil
+}
+
+// Close releases the PKCS#11 session
+func (p *Provider) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
@@ -0,0 +1,125 @@
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeSession struct {
+	closed bool
+}
+
+func (s *fakeSession) Close() error {
+	s.closed = true
+	return nil
+}
+
+// TestProviderSignAndVerify tests that HSM-signed tokens validate through the provider
+func TestProviderSignAndVerify(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	session := &fakeSession{}
+
+	var opened Config
+	provider, err := New(Config{
+		ModulePath: "/usr/lib/softhsm/libsofthsm2.so",
+		Slot:       1,
+		PIN:        "1234",
+		KeyLabel:   "jwt-signing",
+		KeyID:      "hsm-1",
+		Algorithm:  "ES256",
+	}, func(cfg Config) (crypto.Signer, io.Closer, error) {
+		opened = cfg
+		return key, session, nil
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if opened.KeyLabel != "jwt-signing" || opened.Slot != 1 {
+		t.Errorf("Opener received unexpected config: %+v", opened)
+	}
+
+	cfg, err := jwtauth.NewConfig(jwtauth.WithKeyProvider("ES256", provider))
+	if err != nil {
+		t.Fatalf("NewConfig failed: %v", err)
+	}
+
+	token, err := jwtauth.SignToken(context.Background(), provider, map[string]interface{}{
+		"sub": "service-a",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("SignToken failed: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(jwtauth.JWTAuth(cfg))
+	router.GET("/", func(c *gin.Context) {
+		c.String(200, jwtauth.MustGetClaims(c.Request.Context()).Subject)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 200 || w.Body.String() != "service-a" {
+		t.Fatalf("Expected token to validate, got %d %s", w.Code, w.Body.String())
+	}
+
+	if err := provider.Close(); err != nil || !session.closed {
+		t.Error("Expected Close to close the PKCS#11 session")
+	}
+}
+
+// TestProviderRejectsMismatchedKeys tests algorithm and kid checks
+func TestProviderRejectsMismatchedKeys(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	provider, err := New(Config{KeyLabel: "k", KeyID: "hsm-1", Algorithm: "ES256"},
+		func(cfg Config) (crypto.Signer, io.Closer, error) { return key, nil, nil })
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if _, err := provider.VerificationKey(context.Background(), "RS256", ""); !errors.Is(err, jwtauth.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound for mismatched algorithm, got %v", err)
+	}
+	if _, err := provider.VerificationKey(context.Background(), "ES256", "other"); !errors.Is(err, jwtauth.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound for unknown kid, got %v", err)
+	}
+	if _, err := provider.VerificationKey(context.Background(), "ES256", ""); err != nil {
+		t.Errorf("Expected key for empty kid, got %v", err)
+	}
+}
+
+// TestNewErrors tests configuration and opener failures
+func TestNewErrors(t *testing.T) {
+	session := &fakeSession{}
+	open := func(cfg Config) (crypto.Signer, io.Closer, error) { return nil, session, nil }
+
+	if _, err := New(Config{KeyLabel: "k", Algorithm: "ES256"}, open); err == nil {
+		t.Error("Expected error when key is not found")
+	}
+	if !session.closed {
+		t.Error("Expected session to be closed when key is not found")
+	}
+
+	failing := func(cfg Config) (crypto.Signer, io.Closer, error) { return nil, nil, errors.New("bad pin") }
+	if _, err := New(Config{KeyLabel: "k", Algorithm: "ES256"}, failing); err == nil {
+		t.Error("Expected error when opener fails")
+	}
+	if _, err := New(Config{Algorithm: "ES256"}, failing); err == nil {
+		t.Error("Expected error for missing key label")
+	}
+}
@@ -0,0 +1,152 @@
+package sops
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writePlaintext writes a decrypted-form secrets document for use with a
+// pass-through decryptor
+func writePlaintext(t *testing.T, doc interface{}) string {
+	t.Helper()
+	data, _ := json.Marshal(doc)
+	path := filepath.Join(t.TempDir(), "secrets.enc.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	return path
+}
+
+// TestLoadSecrets tests decrypting and converting secrets to options and signers
+func TestLoadSecrets(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pubDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecPubDER, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	privDER, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+
+	path := writePlaintext(t, map[string]interface{}{
+		"hmac_secrets": map[string]string{"HS256": "0123456789abcdef0123456789abcdef"},
+		"public_keys": map[string]string{
+			"RS256": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})),
+			"ES256": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ecPubDER})),
+		},
+		"private_keys": []map[string]string{
+			{"alg": "ES256", "kid": "signing-1", "pem": string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))},
+		},
+	})
+
+	// "cat" stands in for sops/age: the file already holds the plaintext
+	secrets, err := Load(context.Background(), path, &CommandDecryptor{Command: "cat"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	opts, err := secrets.Options()
+	if err != nil {
+		t.Fatalf("Options failed: %v", err)
+	}
+	cfg, err := jwtauth.NewConfig(opts...)
+	if err != nil {
+		t.Fatalf("NewConfig failed: %v", err)
+	}
+	if algs := cfg.AvailableAlgorithms(); len(algs) != 3 {
+		t.Errorf("Expected 3 algorithms, got %v", algs)
+	}
+
+	signers, err := secrets.Signers()
+	if err != nil || len(signers) != 1 {
+		t.Fatalf("Signers failed: %v", err)
+	}
+	token, err := jwtauth.SignToken(context.Background(), signers[0], map[string]interface{}{"sub": "svc"})
+	if err != nil {
+		t.Fatalf("SignToken failed: %v", err)
+	}
+	if _, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return &ecKey.PublicKey, nil }); err != nil {
+		t.Errorf("Expected signed token to verify, got %v", err)
+	}
+}
+
+// TestHMACAlgorithms tests that each HMAC algorithm maps to its own option
+func TestHMACAlgorithms(t *testing.T) {
+	secrets := &Secrets{HMACSecrets: map[string]string{
+		"HS256": strings.Repeat("a", 32),
+		"HS384": strings.Repeat("b", 48),
+		"HS512": strings.Repeat("c", 64),
+	}}
+	opts, err := secrets.Options()
+	if err != nil {
+		t.Fatalf("Options failed: %v", err)
+	}
+	cfg, err := jwtauth.NewConfig(opts...)
+	if err != nil {
+		t.Fatalf("NewConfig failed: %v", err)
+	}
+	if algs := cfg.AvailableAlgorithms(); !slices.Equal(algs, []string{"HS256", "HS384", "HS512"}) {
+		t.Errorf("Expected HS256, HS384 and HS512, got %v", algs)
+	}
+
+	for alg, secret := range secrets.HMACSecrets {
+		method := jwt.GetSigningMethod(alg)
+		token, _ := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "svc"}).SignedString([]byte(secret))
+		if _, err := jwtauth.ParseToken(context.Background(), token, cfg); err != nil {
+			t.Errorf("Expected %s token to verify, got %v", alg, err)
+		}
+	}
+
+	// Secrets shorter than the hash output are rejected by the option
+	short := &Secrets{HMACSecrets: map[string]string{"HS512": strings.Repeat("c", 32)}}
+	opts, _ = short.Options()
+	if _, err := jwtauth.NewConfig(opts...); err == nil {
+		t.Error("Expected a short HS512 secret to be rejected")
+	}
+}
+
+type failingDecryptor struct{}
+
+func (failingDecryptor) Decrypt(ctx context.Context, path string) ([]byte, error) {
+	return nil, errors.New("no matching age identity")
+}
+
+// TestLoadErrors tests decryption and parsing failures
+func TestLoadErrors(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := Load(ctx, "secrets.enc.json", failingDecryptor{}); err == nil {
+		t.Error("Expected error when decryption fails")
+	}
+
+	path := writePlaintext(t, "not an object")
+	if _, err := Load(ctx, path, &CommandDecryptor{Command: "cat"}); err == nil {
+		t.Error("Expected error for invalid document")
+	}
+
+	if _, err := Load(ctx, filepath.Join(t.TempDir(), "missing"), &CommandDecryptor{Command: "cat"}); err == nil {
+		t.Error("Expected error when command fails")
+	}
+
+	secrets := &Secrets{HMACSecrets: map[string]string{"HS999": "x"}}
+	if _, err := secrets.Options(); err == nil {
+		t.Error("Expected error for unsupported HMAC algorithm")
+	}
+
+	secrets = &Secrets{PrivateKeys: []PrivateKey{{Algorithm: "ES256", PEM: "garbage"}}}
+	if _, err := secrets.Signers(); err == nil {
+		t.Error("Expected error for invalid private key")
+	}
+}
@@ -0,0 +1,494 @@
+// Package vault provides a jwtauth.KeyProvider that reads RSA, EC and
+// Ed25519 public keys from HashiCorp Vault, so verification keys are managed
+// in Vault instead of environment variables or files.
+//
+// Keys are read from a Transit key (every key version's public key, selected
+// by the token's kid as the version number) or from a field of a KV version 2
+// secret holding a PEM public key (current and previous secret versions,
+// selected by kid as the version number). The provider speaks the Vault HTTP
+// API directly, so the Vault SDK stays out of the middleware's dependency
+// graph:
+//
+//	client, err := vault.NewClient("https://vault.internal:8200",
+//		vault.WithTokenFile("/var/run/secrets/vault-token"), // Vault Agent sink
+//	)
+//	go client.RenewToken(ctx, nil) // Keep the token lease alive
+//
+//	provider, err := vault.NewTransit(ctx, client, "transit", "jwt-signing", "RS256")
+//	cfg, err := jwtauth.NewConfig(jwtauth.WithKeyProvider("RS256", provider))
+//
+// Keys are cached for WithTTL and reloaded in the background; KV secrets with
+// a shorter lease are reloaded when their lease ends.
+package vault
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+)
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithToken authenticates with a fixed Vault token
+func WithToken(token string) ClientOption {
+	return func(c *Client) {
+		c.token = func() (string, error) { return token, nil }
+	}
+}
+
+// WithTokenFile reads the Vault token from path on every request, e.g. the
+// sink file of a Vault Agent that re-authenticates on its own
+func WithTokenFile(path string) ClientOption {
+	return func(c *Client) {
+		c.token = func() (string, error) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("vault: reading token file: %w", err)
+			}
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+}
+
+// WithNamespace sets the Vault Enterprise namespace of every request
+func WithNamespace(namespace string) ClientOption {
+	return func(c *Client) {
+		c.namespace = namespace
+	}
+}
+
+// WithHTTPClient sets the HTTP client, e.g. one trusting Vault's CA
+// (default: 10 second timeout)
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// Client is a minimal Vault HTTP API client
+type Client struct {
+	addr      string
+	token     func() (string, error)
+	namespace string
+	http      *http.Client
+}
+
+// NewClient creates a client for the Vault server at addr
+func NewClient(addr string, opts ...ClientOption) (*Client, error) {
+	u, err := url.Parse(addr)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("vault: invalid address %q", addr)
+	}
+	c := &Client{addr: strings.TrimRight(addr, "/"), http: &http.Client{Timeout: 10 * time.Second}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.token == nil {
+		return nil, fmt.Errorf("vault: a token is required (WithToken or WithTokenFile)")
+	}
+	return c, nil
+}
+
+// response is the common envelope of Vault API responses
+type response struct {
+	LeaseDuration int             `json:"lease_duration"`
+	Data          json.RawMessage `json:"data"`
+	Auth          *struct {
+		LeaseDuration int  `json:"lease_duration"`
+		Renewable     bool `json:"renewable"`
+	} `json:"auth"`
+	Errors []string `json:"errors"`
+}
+
+// do sends a request to the API path (without /v1/); 404 responses are
+// reported as jwtauth.ErrKeyNotFound
+func (c *Client) do(ctx context.Context, method, path string) (*response, error) {
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.addr+"/v1/"+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	req.Header.Set("X-Vault-Request", "true")
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("vault: %s %s: %w: %w", method, path, jwtauth.ErrKeyProviderUnavailable, err)
+	}
+
+	var out response
+	json.Unmarshal(body, &out) // Error responses may not be JSON
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("vault: %s not found: %w", path, jwtauth.ErrKeyNotFound)
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		// Sealed, in standby without a leader, or rate limited
+		return nil, fmt.Errorf("vault: %s %s: status %d %s: %w", method, path, resp.StatusCode, strings.Join(out.Errors, "; "), jwtauth.ErrKeyProviderUnavailable)
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("vault: %s %s: status %d %s", method, path, resp.StatusCode, strings.Join(out.Errors, "; "))
+	}
+	return &out, nil
+}
+
+// RenewToken keeps the client's token lease alive until ctx is done,
+// renewing it when two thirds of its TTL have passed. It returns nil at
+// once for tokens that are not renewable (e.g. root tokens). Failed renewals
+// are reported to onError, when set, and retried after 10 seconds.
+func (c *Client) RenewToken(ctx context.Context, onError func(error)) error {
+	resp, err := c.do(ctx, http.MethodGet, "auth/token/lookup-self")
+	if err != nil {
+		return err
+	}
+	var self struct {
+		TTL       int  `json:"ttl"`
+		Renewable bool `json:"renewable"`
+	}
+	if err := json.Unmarshal(resp.Data, &self); err != nil {
+		return fmt.Errorf("vault: decoding token lookup: %w", err)
+	}
+	if !self.Renewable || self.TTL <= 0 {
+		return nil
+	}
+
+	wait := time.Duration(self.TTL) * time.Second * 2 / 3
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		resp, err := c.do(ctx, http.MethodPost, "auth/token/renew-self")
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil
+			}
+			if onError != nil {
+				onError(err)
+			}
+			wait = 10 * time.Second
+		case resp.Auth == nil || !resp.Auth.Renewable || resp.Auth.LeaseDuration <= 0:
+			return nil // Reached its max TTL; the token source must replace it
+		default:
+			wait = time.Duration(resp.Auth.LeaseDuration) * time.Second * 2 / 3
+		}
+	}
+}
+
+// Option configures a Provider
+type Option func(*Provider)
+
+// WithTTL sets how long fetched keys are used before they are reloaded in
+// the background (default 5 minutes). KV secrets with a shorter lease are
+// reloaded when their lease ends.
+func WithTTL(ttl time.Duration) Option {
+	return func(p *Provider) {
+		p.ttl = ttl
+	}
+}
+
+// WithMinRefreshInterval limits how often a token with an unknown kid can
+// force a synchronous reload (default 30 seconds)
+func WithMinRefreshInterval(interval time.Duration) Option {
+	return func(p *Provider) {
+		p.minRefresh = interval
+	}
+}
+
+// WithField sets the KV secret field holding the PEM public key (default
+// "public_key")
+func WithField(field string) Option {
+	return func(p *Provider) {
+		p.field = field
+	}
+}
+
+// Provider resolves verification keys from a Transit key or a KV secret
+type Provider struct {
+	name       string
+	alg        string
+	field      string
+	fetch      func(ctx context.Context, p *Provider) (*keySet, error)
+	ttl        time.Duration
+	minRefresh time.Duration
+
+	keys       atomic.Pointer[keySet]
+	refreshing atomic.Bool
+	mu         sync.Mutex // Serializes synchronous reloads
+}
+
+// keySet is the loaded key versions
+type keySet struct {
+	keys    map[string]interface{} // Version -> key
+	current string                 // Version used for tokens without kid
+	expires time.Time              // Reload due
+	loaded  time.Time
+}
+
+// NewTransit creates a provider for the Transit key name on the engine
+// mounted at mount and loads the public keys of all its versions. Tokens
+// select a version with their kid header; tokens without kid use the latest.
+func NewTransit(ctx context.Context, client *Client, mount, name, alg string, opts ...Option) (*Provider, error) {
+	path := strings.Trim(mount, "/") + "/keys/" + url.PathEscape(name)
+	fetch := func(ctx context.Context, p *Provider) (*keySet, error) {
+		resp, err := client.do(ctx, http.MethodGet, path)
+		if err != nil {
+			return nil, err
+		}
+		var key struct {
+			Type          string `json:"type"`
+			LatestVersion int    `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		}
+		if err := json.Unmarshal(resp.Data, &key); err != nil {
+			return nil, fmt.Errorf("vault: decoding transit key %s: %w", name, err)
+		}
+		set := &keySet{keys: map[string]interface{}{}, current: strconv.Itoa(key.LatestVersion)}
+		for version, v := range key.Keys {
+			if v.PublicKey == "" {
+				return nil, fmt.Errorf("vault: transit key %s (%s) has no public key", name, key.Type)
+			}
+			public, err := p.parseKey(v.PublicKey, key.Type == "ed25519")
+			if err != nil {
+				return nil, fmt.Errorf("vault: transit key %s version %s: %w", name, version, err)
+			}
+			set.keys[version] = public
+		}
+		return set, nil
+	}
+	return newProvider(ctx, name, alg, fetch, opts)
+}
+
+// NewKV creates a provider for the KV version 2 secret path on the engine
+// mounted at mount and loads the PEM public key in its WithField field from
+// the current and previous secret versions. Tokens select a version with
+// their kid header; tokens without kid use the current version.
+func NewKV(ctx context.Context, client *Client, mount, path, alg string, opts ...Option) (*Provider, error) {
+	dataPath := strings.Trim(mount, "/") + "/data/" + strings.Trim(path, "/")
+	read := func(ctx context.Context, query string) (map[string]interface{}, int, time.Duration, error) {
+		resp, err := client.do(ctx, http.MethodGet, dataPath+query)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		var secret struct {
+			Data     map[string]interface{} `json:"data"`
+			Metadata struct {
+				Version int `json:"version"`
+			} `json:"metadata"`
+		}
+		if err := json.Unmarshal(resp.Data, &secret); err != nil || secret.Data == nil {
+			return nil, 0, 0, fmt.Errorf("vault: secret %s has no data (deleted version?): %w", path, jwtauth.ErrKeyNotFound)
+		}
+		return secret.Data, secret.Metadata.Version, time.Duration(resp.LeaseDuration) * time.Second, nil
+	}
+	fetch := func(ctx context.Context, p *Provider) (*keySet, error) {
+		data, version, lease, err := read(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		set := &keySet{keys: map[string]interface{}{}, current: strconv.Itoa(version)}
+		if lease > 0 && lease < p.ttl {
+			set.expires = time.Now().Add(lease)
+		}
+		for {
+			value, _ := data[p.field].(string)
+			public, err := p.parseKey(value, false)
+			if err != nil {
+				return nil, fmt.Errorf("vault: field %s of %s version %d: %w", p.field, path, version, err)
+			}
+			set.keys[strconv.Itoa(version)] = public
+
+			if len(set.keys) == 2 || version <= 1 {
+				return set, nil
+			}
+			version--
+			data, _, _, err = read(ctx, "?version="+strconv.Itoa(version))
+			if errors.Is(err, jwtauth.ErrKeyNotFound) {
+				return set, nil // Destroyed or deleted previous versions are not trusted
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return newProvider(ctx, path, alg, fetch, opts)
+}
+
+// newProvider applies options and performs the initial load
+func newProvider(ctx context.Context, name, alg string, fetch func(context.Context, *Provider) (*keySet, error), opts []Option) (*Provider, error) {
+	if name == "" {
+		return nil, fmt.Errorf("vault: key name is required")
+	}
+	switch alg {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA":
+	default:
+		return nil, fmt.Errorf("vault: unsupported algorithm %q", alg)
+	}
+
+	p := &Provider{name: name, alg: alg, field: "public_key", fetch: fetch, ttl: 5 * time.Minute, minRefresh: 30 * time.Second}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if err := p.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Refresh reloads the keys now, e.g. after rotating the Transit key
+func (p *Provider) Refresh(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.refreshLocked(ctx)
+}
+
+// refreshLocked fetches the keys; the previous keys are kept when anything
+// fails
+func (p *Provider) refreshLocked(ctx context.Context) error {
+	set, err := p.fetch(ctx, p)
+	if err != nil {
+		return err
+	}
+	if _, ok := set.keys[set.current]; !ok {
+		return fmt.Errorf("vault: current version %s of %s has no key", set.current, p.name)
+	}
+	set.loaded = time.Now()
+	if set.expires.IsZero() {
+		set.expires = set.loaded.Add(p.ttl)
+	}
+	p.keys.Store(set)
+	return nil
+}
+
+// parseKey converts a PEM public key (or a base64 Ed25519 key from Transit)
+// into a verification key for p.alg
+func (p *Provider) parseKey(value string, rawEd25519 bool) (interface{}, error) {
+	var key interface{}
+	if block, _ := pem.Decode([]byte(value)); block != nil {
+		var err error
+		if key, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			if key, err = x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+				return nil, fmt.Errorf("failed to parse public key")
+			}
+		}
+	} else if raw, err := base64.StdEncoding.DecodeString(value); rawEd25519 && err == nil && len(raw) == ed25519.PublicKeySize {
+		key = ed25519.PublicKey(raw)
+	} else {
+		return nil, fmt.Errorf("failed to decode PEM block")
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if p.alg[0] != 'R' && p.alg[0] != 'P' {
+			return nil, fmt.Errorf("RSA key cannot be used with %s", p.alg)
+		}
+	case *ecdsa.PublicKey:
+		if p.alg[0] != 'E' || p.alg == "EdDSA" {
+			return nil, fmt.Errorf("ECDSA key cannot be used with %s", p.alg)
+		}
+	case ed25519.PublicKey:
+		if p.alg != "EdDSA" {
+			return nil, fmt.Errorf("Ed25519 key cannot be used with %s", p.alg)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return key, nil
+}
+
+// KeySource implements jwtauth.KeySourcer
+func (p *Provider) KeySource() string { return "vault" }
+
+// VerificationKey implements jwtauth.KeyProvider. An unknown kid triggers a
+// synchronous reload, at most once per WithMinRefreshInterval, in case the
+// key was rotated since the last load.
+func (p *Provider) VerificationKey(ctx context.Context, alg, kid string) (interface{}, error) {
+	if alg != p.alg {
+		return nil, fmt.Errorf("vault: %s is configured for %s, not %s", p.name, p.alg, alg)
+	}
+
+	set := p.keys.Load()
+	if time.Now().After(set.expires) && p.refreshing.CompareAndSwap(false, true) {
+		go func() {
+			defer p.refreshing.Store(false)
+			// Errors keep the previous keys; the next request retries
+			_ = p.Refresh(context.WithoutCancel(ctx))
+		}()
+	}
+	if key, ok := set.find(kid); ok {
+		return key, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if set = p.keys.Load(); time.Since(set.loaded) >= p.minRefresh {
+		if err := p.refreshLocked(ctx); err != nil {
+			return nil, err
+		}
+		set = p.keys.Load()
+	}
+	if key, ok := set.find(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("vault: no version %q of %s: %w", kid, p.name, jwtauth.ErrKeyNotFound)
+}
+
+// find returns the key for kid, or the current key when kid is empty
+func (s *keySet) find(kid string) (interface{}, bool) {
+	if kid == "" {
+		kid = s.current
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// Versions returns the loaded key versions, newest first
+func (p *Provider) Versions() []string {
+	set := p.keys.Load()
+	versions := make([]string, 0, len(set.keys))
+	for version := range set.keys {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		a, _ := strconv.Atoi(versions[i])
+		b, _ := strconv.Atoi(versions[j])
+		return a > b
+	})
+	return versions
+}
@@ -0,0 +1,257 @@
+package vault
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wang-tianhao/Vibrant-auth-middleware-go/jwtauth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testToken = "s.test-token"
+
+// fakeVault serves Transit keys, KV v2 secrets and token endpoints
+type fakeVault struct {
+	mu       sync.Mutex
+	transit  []*ecdsa.PrivateKey // Version i+1
+	kv       []string            // PEM of version i+1
+	lease    int
+	renewals int
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("X-Vault-Token") != testToken {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	var out map[string]interface{}
+	switch r.URL.Path {
+	case "/v1/transit/keys/jwt":
+		keys := map[string]interface{}{}
+		for i, key := range f.transit {
+			keys[strconv.Itoa(i+1)] = map[string]string{"public_key": publicPEM(&key.PublicKey)}
+		}
+		out = map[string]interface{}{"data": map[string]interface{}{"type": "ecdsa-p256", "latest_version": len(f.transit), "keys": keys}}
+	case "/v1/secret/data/jwt":
+		version := len(f.kv)
+		if v := r.URL.Query().Get("version"); v != "" {
+			version, _ = strconv.Atoi(v)
+		}
+		out = map[string]interface{}{
+			"lease_duration": f.lease,
+			"data": map[string]interface{}{
+				"data":     map[string]string{"public_key": f.kv[version-1]},
+				"metadata": map[string]int{"version": version},
+			},
+		}
+	case "/v1/auth/token/lookup-self":
+		out = map[string]interface{}{"data": map[string]interface{}{"ttl": 1, "renewable": true}}
+	case "/v1/auth/token/renew-self":
+		f.renewals++
+		out = map[string]interface{}{"auth": map[string]interface{}{"lease_duration": 1, "renewable": true}}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{}})
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+// rotateTransit adds a new Transit key version
+func (f *fakeVault) rotateTransit(t *testing.T) *ecdsa.PrivateKey {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := generateKey(t)
+	f.transit = append(f.transit, key)
+	return key
+}
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func publicPEM(key interface{}) string {
+	der, _ := x509.MarshalPKIXPublicKey(key)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// startVault starts f and returns a client authenticated with testToken
+func startVault(t *testing.T, f *fakeVault) *Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	client, err := NewClient(srv.URL, WithToken(testToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// signES256 signs a token with an optional kid header
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// validate validates a token through a jwtauth config using p
+func validate(t *testing.T, p *Provider, token string) (*jwtauth.Claims, error) {
+	t.Helper()
+	cfg, err := jwtauth.NewConfig(jwtauth.WithKeyProvider("ES256", p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwtauth.ParseToken(context.Background(), token, cfg)
+}
+
+// TestTransitProvider tests version selection by kid and picking up a
+// rotated key for an unknown kid
+func TestTransitProvider(t *testing.T) {
+	f := &fakeVault{}
+	v1 := f.rotateTransit(t)
+	client := startVault(t, f)
+
+	p, err := NewTransit(context.Background(), client, "transit", "jwt", "ES256", WithMinRefreshInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := validate(t, p, signES256(t, v1, "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Provenance.KeySource != "vault" {
+		t.Errorf("expected key source vault, got %q", claims.Provenance.KeySource)
+	}
+	if _, err := validate(t, p, signES256(t, v1, "")); err != nil {
+		t.Errorf("expected the latest version for tokens without kid, got %v", err)
+	}
+
+	v2 := f.rotateTransit(t)
+	if _, err := validate(t, p, signES256(t, v2, "2")); err != nil {
+		t.Errorf("expected the rotated version to be loaded, got %v", err)
+	}
+	if _, err := validate(t, p, signES256(t, v1, "1")); err != nil {
+		t.Errorf("expected the previous version to stay trusted, got %v", err)
+	}
+	if got := p.Versions(); len(got) != 2 || got[0] != "2" {
+		t.Errorf("expected versions [2 1], got %v", got)
+	}
+	if _, err := p.VerificationKey(context.Background(), "ES256", "9"); !errors.Is(err, jwtauth.ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound for an unknown version, got %v", err)
+	}
+}
+
+// TestKVProvider tests the current and previous secret versions and lease-based reloads
+func TestKVProvider(t *testing.T) {
+	v1, v2, v3 := generateKey(t), generateKey(t), generateKey(t)
+	f := &fakeVault{kv: []string{publicPEM(&v1.PublicKey), publicPEM(&v2.PublicKey)}, lease: 1}
+	client := startVault(t, f)
+
+	p, err := NewKV(context.Background(), client, "secret", "jwt", "ES256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Versions(); len(got) != 2 || got[0] != "2" || got[1] != "1" {
+		t.Fatalf("expected versions [2 1], got %v", got)
+	}
+	if _, err := validate(t, p, signES256(t, v2, "")); err != nil {
+		t.Errorf("expected the current version, got %v", err)
+	}
+
+	// The 1 second lease is shorter than the TTL, so the next use after it
+	// reloads in the background
+	f.mu.Lock()
+	f.kv = append(f.kv, publicPEM(&v3.PublicKey))
+	f.mu.Unlock()
+	time.Sleep(1100 * time.Millisecond)
+	p.VerificationKey(context.Background(), "ES256", "")
+	deadline := time.Now().Add(time.Second)
+	for p.Versions()[0] != "3" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := p.Versions(); len(got) != 2 || got[0] != "3" || got[1] != "2" {
+		t.Errorf("expected versions [3 2] after the lease ended, got %v", got)
+	}
+}
+
+// TestProviderErrors tests rejected configurations
+func TestProviderErrors(t *testing.T) {
+	f := &fakeVault{}
+	f.rotateTransit(t)
+	client := startVault(t, f)
+
+	if _, err := NewTransit(context.Background(), client, "transit", "jwt", "RS256"); err == nil {
+		t.Error("expected error for an ECDSA key with RS256")
+	}
+	if _, err := NewTransit(context.Background(), client, "transit", "jwt", "HS256"); err == nil {
+		t.Error("expected error for HMAC algorithms")
+	}
+	if _, err := NewTransit(context.Background(), client, "transit", "missing", "ES256"); !errors.Is(err, jwtauth.ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound for a missing key, got %v", err)
+	}
+
+	denied, _ := NewClient(client.addr, WithToken("wrong"))
+	if _, err := NewTransit(context.Background(), denied, "transit", "jwt", "ES256"); err == nil {
+		t.Error("expected error for a rejected token")
+	}
+	if _, err := NewClient(client.addr); err == nil {
+		t.Error("expected error without a token")
+	}
+	if _, err := NewClient("vault.internal:8200", WithToken(testToken)); err == nil {
+		t.Error("expected error for an address without scheme")
+	}
+}
+
+// TestRenewToken tests renewing the token lease and reading it from a file
+func TestRenewToken(t *testing.T) {
+	f := &fakeVault{}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	path := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(path, []byte(testToken+"\n"), 0o600)
+	client, err := NewClient(srv.URL, WithTokenFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	if err := client.RenewToken(ctx, func(err error) { t.Error(err) }); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.renewals < 1 {
+		t.Errorf("expected the token to be renewed, got %d renewals", f.renewals)
+	}
+}